@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+// checkLabel labels the objects the check creates with dry run, so that webhooks can tell them apart
+const checkLabel = "kuberhealthy-admission-webhook-check"
+
+// dryRunUnsupported is part of the error the API server returns for a dry run request that reaches a webhook that
+// may have side effects
+const dryRunUnsupported = "does not support dry run"
+
+// testResources are the resources the check can create objects of with dry run, by the name they are configured with
+var testResources = map[string]schema.GroupVersionResource{
+	"configmaps":  {Version: "v1", Resource: "configmaps"},
+	"secrets":     {Version: "v1", Resource: "secrets"},
+	"services":    {Version: "v1", Resource: "services"},
+	"pods":        {Version: "v1", Resource: "pods"},
+	"deployments": {Group: "apps", Version: "v1", Resource: "deployments"},
+}
+
+// checker creates objects with dry run for the resources covered by admission webhooks, and ensures the webhooks
+// admit them in time
+type checker struct {
+	namespace  string
+	resources  []string      // the resources objects are created for, which are keys of testResources
+	image      string        // the image of the pods and deployments that are created
+	maxLatency time.Duration // how long a dry run create can take
+	client     kubernetes.Interface
+}
+
+// webhook is a validating or mutating admission webhook
+type webhook struct {
+	name          string // the name of the webhook configuration and of the webhook
+	rules         []admissionv1.RuleWithOperations
+	failurePolicy admissionv1.FailurePolicyType
+}
+
+// run creates an object with dry run for each resource that is covered by a webhook.  The errors of the run are
+// returned, along with how long each create took.
+func (c *checker) run(ctx context.Context) ([]string, map[string]string) {
+	webhooks, err := c.webhooks(ctx)
+	if err != nil {
+		return []string{"Failed to list admission webhooks: " + err.Error()}, nil
+	}
+	log.Infoln("Found", len(webhooks), "admission webhooks")
+
+	var errs []string
+	details := make(map[string]string)
+	name := "kuberhealthy-admission-webhook-check-" + strconv.FormatInt(time.Now().Unix(), 10)
+	for _, resource := range c.resources {
+		gvr := testResources[resource]
+		covering := coveringWebhooks(webhooks, gvr)
+		if len(covering) == 0 {
+			log.Infoln("No admission webhooks cover creating", resource)
+			continue
+		}
+		log.Infoln("Creating", resource, "with dry run, which is covered by admission webhooks", covering)
+
+		start := time.Now()
+		err := c.dryRunCreate(ctx, resource, name)
+		latency := time.Since(start)
+		details[resource] = latency.Round(time.Millisecond).String()
+		switch {
+		case err != nil && strings.Contains(err.Error(), dryRunUnsupported):
+			log.Warnln("Creating", resource, "with dry run is not supported by an admission webhook:", err)
+			details[resource] = "dry run unsupported"
+		case err != nil:
+			errs = append(errs, fmt.Sprintf("Dry run create of %s covered by admission webhooks %s failed after %s: %s", resource, strings.Join(covering, ", "), latency.Round(time.Millisecond), err))
+		case latency > c.maxLatency:
+			errs = append(errs, fmt.Sprintf("Dry run create of %s covered by admission webhooks %s took %s, which is longer than %s", resource, strings.Join(covering, ", "), latency.Round(time.Millisecond), c.maxLatency))
+		default:
+			log.Infoln("Admission webhooks admitted", resource, "in", latency.Round(time.Millisecond))
+		}
+	}
+	return errs, details
+}
+
+// webhooks returns the validating and mutating admission webhooks of the cluster
+func (c *checker) webhooks(ctx context.Context) ([]webhook, error) {
+	var webhooks []webhook
+
+	validating, err := c.client.AdmissionregistrationV1().ValidatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, configuration := range validating.Items {
+		for _, w := range configuration.Webhooks {
+			webhooks = append(webhooks, webhook{name: configuration.Name + "/" + w.Name, rules: w.Rules, failurePolicy: failurePolicy(w.FailurePolicy)})
+		}
+	}
+
+	mutating, err := c.client.AdmissionregistrationV1().MutatingWebhookConfigurations().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, configuration := range mutating.Items {
+		for _, w := range configuration.Webhooks {
+			webhooks = append(webhooks, webhook{name: configuration.Name + "/" + w.Name, rules: w.Rules, failurePolicy: failurePolicy(w.FailurePolicy)})
+		}
+	}
+	return webhooks, nil
+}
+
+// failurePolicy returns the failure policy of a webhook, which is Fail when it is not set
+func failurePolicy(policy *admissionv1.FailurePolicyType) admissionv1.FailurePolicyType {
+	if policy == nil {
+		return admissionv1.Fail
+	}
+	return *policy
+}
+
+// coveringWebhooks returns the names and failure policies of the webhooks with a rule that matches creating objects
+// of a resource
+func coveringWebhooks(webhooks []webhook, gvr schema.GroupVersionResource) []string {
+	var covering []string
+	for _, w := range webhooks {
+		for _, rule := range w.rules {
+			if ruleMatches(rule, gvr) {
+				covering = append(covering, fmt.Sprintf("%s (failurePolicy=%s)", w.name, w.failurePolicy))
+				break
+			}
+		}
+	}
+	sort.Strings(covering)
+	return covering
+}
+
+// ruleMatches determines if a webhook rule matches creating objects of a resource
+func ruleMatches(rule admissionv1.RuleWithOperations, gvr schema.GroupVersionResource) bool {
+	operation := false
+	for _, o := range rule.Operations {
+		if o == admissionv1.Create || o == admissionv1.OperationAll {
+			operation = true
+		}
+	}
+	if rule.Scope != nil && *rule.Scope == admissionv1.ClusterScope {
+		return false
+	}
+	return operation && matchesAny(rule.APIGroups, gvr.Group) && matchesAny(rule.APIVersions, gvr.Version) && matchesAny(rule.Resources, gvr.Resource)
+}
+
+// matchesAny determines if a value is in a list of values of a webhook rule, where * matches every value
+func matchesAny(values []string, value string) bool {
+	for _, v := range values {
+		if v == "*" || v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// dryRunCreate creates an object of a resource with dry run, so that it is admitted by the webhooks but not stored
+func (c *checker) dryRunCreate(ctx context.Context, resource string, name string) error {
+	options := metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}}
+	meta := metav1.ObjectMeta{Name: name, Namespace: c.namespace, Labels: map[string]string{checkLabel: name}}
+
+	var err error
+	switch resource {
+	case "configmaps":
+		_, err = c.client.CoreV1().ConfigMaps(c.namespace).Create(ctx, &apiv1.ConfigMap{ObjectMeta: meta, Data: map[string]string{"check": name}}, options)
+	case "secrets":
+		_, err = c.client.CoreV1().Secrets(c.namespace).Create(ctx, &apiv1.Secret{ObjectMeta: meta, StringData: map[string]string{"check": name}}, options)
+	case "services":
+		service := &apiv1.Service{
+			ObjectMeta: meta,
+			Spec: apiv1.ServiceSpec{
+				Selector: meta.Labels,
+				Ports:    []apiv1.ServicePort{{Name: "http", Protocol: apiv1.ProtocolTCP, Port: 80}},
+			},
+		}
+		_, err = c.client.CoreV1().Services(c.namespace).Create(ctx, service, options)
+	case "pods":
+		_, err = c.client.CoreV1().Pods(c.namespace).Create(ctx, &apiv1.Pod{ObjectMeta: meta, Spec: c.podSpec()}, options)
+	case "deployments":
+		replicas := int32(1)
+		deployment := &appsv1.Deployment{
+			ObjectMeta: meta,
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: meta.Labels},
+				Template: apiv1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: meta.Labels},
+					Spec:       c.podSpec(),
+				},
+			},
+		}
+		_, err = c.client.AppsV1().Deployments(c.namespace).Create(ctx, deployment, options)
+	default:
+		err = fmt.Errorf("unknown resource %s", resource)
+	}
+	return err
+}
+
+// podSpec returns the spec of the pods that are created with dry run
+func (c *checker) podSpec() apiv1.PodSpec {
+	automount := false
+	return apiv1.PodSpec{
+		AutomountServiceAccountToken: &automount,
+		Containers: []apiv1.Container{{
+			Name:            "check",
+			Image:           c.image,
+			ImagePullPolicy: apiv1.PullIfNotPresent,
+		}},
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// testRule returns a webhook rule for creating the supplied resources
+func testRule(group string, resources ...string) admissionv1.RuleWithOperations {
+	return admissionv1.RuleWithOperations{
+		Operations: []admissionv1.OperationType{admissionv1.Create, admissionv1.Update},
+		Rule:       admissionv1.Rule{APIGroups: []string{group}, APIVersions: []string{"*"}, Resources: resources},
+	}
+}
+
+func TestRun(t *testing.T) {
+	ignore := admissionv1.Ignore
+	client := fake.NewSimpleClientset(
+		&admissionv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+			Webhooks: []admissionv1.ValidatingWebhook{
+				{Name: "validate.policy.example.com", Rules: []admissionv1.RuleWithOperations{testRule("", "configmaps", "secrets")}},
+			},
+		},
+		&admissionv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "sidecar-injector"},
+			Webhooks: []admissionv1.MutatingWebhook{
+				{Name: "inject.sidecar.example.com", FailurePolicy: &ignore, Rules: []admissionv1.RuleWithOperations{testRule("", "pods")}},
+			},
+		},
+	)
+
+	// the configmap webhook times out, the secret webhook has side effects and the pod webhook is slow
+	client.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New(`Internal error occurred: failed calling webhook "validate.policy.example.com": context deadline exceeded`)
+	})
+	client.PrependReactor("create", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New(`admission webhook "validate.policy.example.com" does not support dry run`)
+	})
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		time.Sleep(time.Millisecond * 100)
+		return false, nil, nil
+	})
+
+	c := &checker{
+		namespace:  "kuberhealthy",
+		resources:  []string{"configmaps", "secrets", "services", "pods", "deployments"},
+		image:      "registry.k8s.io/pause:3.9",
+		maxLatency: time.Millisecond * 50,
+		client:     client,
+	}
+	errs, details := c.run(context.Background())
+	if len(errs) != 2 {
+		t.Fatal("expected the configmap and pod webhooks to fail but got", errs)
+	}
+	if !strings.Contains(errs[0], "Dry run create of configmaps covered by admission webhooks policy/validate.policy.example.com (failurePolicy=Fail) failed") || !strings.Contains(errs[0], "context deadline exceeded") {
+		t.Fatal("expected the configmap webhook to time out but got", errs[0])
+	}
+	if !strings.Contains(errs[1], "Dry run create of pods covered by admission webhooks sidecar-injector/inject.sidecar.example.com (failurePolicy=Ignore) took") {
+		t.Fatal("expected the pod webhook to be slow but got", errs[1])
+	}
+
+	if details["secrets"] != "dry run unsupported" {
+		t.Fatal("expected the secret webhook to not support dry run but got", details["secrets"])
+	}
+	if _, ok := details["services"]; ok {
+		t.Fatal("expected services to not be created when no webhook covers them")
+	}
+	if len(details) != 3 {
+		t.Fatal("expected details for the covered resources but got", details)
+	}
+
+	deployments, _ := client.AppsV1().Deployments("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if len(deployments.Items) != 0 {
+		t.Fatal("expected no deployments to be created when no webhook covers them")
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	cluster := admissionv1.ClusterScope
+
+	tests := []struct {
+		name     string
+		rule     admissionv1.RuleWithOperations
+		resource schema.GroupVersionResource
+		matches  bool
+	}{
+		{name: "exact", rule: testRule("", "pods"), resource: pods, matches: true},
+		{name: "wildcard", rule: testRule("*", "*"), resource: deployments, matches: true},
+		{name: "other group", rule: testRule("", "deployments"), resource: deployments},
+		{name: "other resource", rule: testRule("", "services"), resource: pods},
+		{name: "subresource", rule: testRule("", "pods/exec"), resource: pods},
+		{
+			name: "all operations",
+			rule: admissionv1.RuleWithOperations{
+				Operations: []admissionv1.OperationType{admissionv1.OperationAll},
+				Rule:       admissionv1.Rule{APIGroups: []string{"apps"}, APIVersions: []string{"v1"}, Resources: []string{"deployments"}},
+			},
+			resource: deployments,
+			matches:  true,
+		},
+		{
+			name: "delete only",
+			rule: admissionv1.RuleWithOperations{
+				Operations: []admissionv1.OperationType{admissionv1.Delete},
+				Rule:       admissionv1.Rule{APIGroups: []string{""}, APIVersions: []string{"v1"}, Resources: []string{"pods"}},
+			},
+			resource: pods,
+		},
+		{
+			name: "cluster scope",
+			rule: admissionv1.RuleWithOperations{
+				Operations: []admissionv1.OperationType{admissionv1.Create},
+				Rule:       admissionv1.Rule{APIGroups: []string{"*"}, APIVersions: []string{"*"}, Resources: []string{"*"}, Scope: &cluster},
+			},
+			resource: pods,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if ruleMatches(test.rule, test.resource) != test.matches {
+				t.Fatal("expected the rule to match:", test.matches)
+			}
+		})
+	}
+}
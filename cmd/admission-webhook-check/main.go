@@ -0,0 +1,133 @@
+// Package main implements a check that creates objects with dry run for the resources covered by admission webhooks,
+// and fails when the webhooks reject them, time out or are slow.  A webhook whose backend is down can block every
+// create of the resources it covers when its failure policy is Fail.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	checkclient "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/nodeCheck"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/util"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
+)
+
+const (
+	// defaultNamespace is the namespace objects are created in when the check's namespace can not be found
+	defaultNamespace = "kuberhealthy"
+
+	// defaultResources are the resources objects are created for
+	defaultResources = "configmaps,secrets,services,pods,deployments"
+
+	// defaultImage is the image of the pods and deployments that are created
+	defaultImage = "registry.k8s.io/pause:3.9"
+
+	// defaultMaxLatency is how long a dry run create can take
+	defaultMaxLatency = time.Second * 3
+)
+
+// kubeConfigFile is a variable containing file path of Kubernetes config files
+var kubeConfigFile = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+
+func init() {
+	// set debug mode for nodeCheck pkg
+	nodeCheck.EnableDebugOutput()
+}
+
+func main() {
+	checker, err := newCheckerFromEnv()
+	if err != nil {
+		log.Errorln("Error parsing the check configuration:", err)
+		reportToKuberhealthy([]string{err.Error()}, nil)
+		return
+	}
+
+	ctx := context.Background()
+	deadline, err := checkclient.GetDeadline()
+	if err != nil {
+		log.Warnln("There was an issue getting the check deadline:", err.Error())
+	} else {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-time.Second*5))
+		defer cancel()
+	}
+
+	client, err := kubeClient.Create(kubeConfigFile)
+	if err != nil {
+		reportToKuberhealthy([]string{"Failed to create a kubernetes client: " + err.Error()}, nil)
+		return
+	}
+	checker.client = client
+
+	// hits kuberhealthy endpoint to see if node is ready
+	err = nodeCheck.WaitForKuberhealthy(ctx)
+	if err != nil {
+		log.Errorln("Error waiting for kuberhealthy endpoint to be contactable by checker pod with error:" + err.Error())
+	}
+
+	reportToKuberhealthy(checker.run(ctx))
+}
+
+// newCheckerFromEnv creates a checker from the environment variables of the checker pod
+func newCheckerFromEnv() (*checker, error) {
+	c := &checker{
+		namespace:  util.GetInstanceNamespace(defaultNamespace),
+		image:      defaultImage,
+		maxLatency: defaultMaxLatency,
+	}
+	if namespace := os.Getenv("CHECK_NAMESPACE"); len(namespace) > 0 {
+		c.namespace = namespace
+	}
+	if image := os.Getenv("TEST_IMAGE"); len(image) > 0 {
+		c.image = image
+	}
+
+	var err error
+	if v := os.Getenv("MAX_LATENCY"); len(v) > 0 {
+		c.maxLatency, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MAX_LATENCY: %w", err)
+		}
+	}
+
+	resources := os.Getenv("RESOURCES")
+	if len(resources) == 0 {
+		resources = defaultResources
+	}
+	for _, resource := range strings.Split(resources, ",") {
+		resource = strings.TrimSpace(resource)
+		if len(resource) == 0 {
+			continue
+		}
+		if _, ok := testResources[resource]; !ok {
+			return nil, fmt.Errorf("invalid RESOURCES: %s is not one of %s", resource, defaultResources)
+		}
+		c.resources = append(c.resources, resource)
+	}
+
+	log.Infoln("Creating", c.resources, "with dry run in namespace", c.namespace, "within", c.maxLatency)
+	return c, nil
+}
+
+// reportToKuberhealthy reports the errors of the run to Kuberhealthy, or success when there are none, along with how
+// long the dry run creates took
+func reportToKuberhealthy(errorMessages []string, details map[string]string) {
+	var err error
+	if len(errorMessages) == 0 {
+		err = checkclient.ReportSuccessWithDetails(details)
+	} else {
+		log.Errorln("Reporting errors to Kuberhealthy:", errorMessages)
+		err = checkclient.ReportFailureWithDetails(errorMessages, details)
+	}
+	if err != nil {
+		log.Fatalln("error reporting to kuberhealthy:", err.Error())
+	}
+	log.Infoln("Successfully reported to Kuberhealthy")
+}
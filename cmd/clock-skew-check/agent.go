@@ -0,0 +1,172 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// terminationLogPath is where agents write their measurement, so that the checker can read it from the status of
+	// their pod
+	terminationLogPath = "/dev/termination-log"
+
+	// ntpEpochOffset is the number of seconds between the NTP epoch of 1900 and the Unix epoch of 1970
+	ntpEpochOffset = 2208988800
+
+	// dateSampleInterval is how often an agent requests the API server while waiting for its Date header to change
+	dateSampleInterval = time.Millisecond * 20
+)
+
+// agentResult is the measurement an agent writes to its termination log
+type agentResult struct {
+	Skew  time.Duration `json:"skew"` // how far the clock of the node is ahead of the reference, or behind it when negative
+	Error string        `json:"error,omitempty"`
+}
+
+// agentMain measures the clock skew of the node the agent runs on against the NTP server, or the API server when no
+// NTP server is set, and writes the result to the termination log.  The exit code of the agent is returned.
+func agentMain() int {
+	var skew time.Duration
+	var err error
+	if ntpServer := os.Getenv("NTP_SERVER"); len(ntpServer) > 0 {
+		skew, err = ntpSkew(ntpServer, time.Second*5)
+	} else {
+		apiServer := net.JoinHostPort(os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"))
+		skew, err = dateSkew("https://"+apiServer+"/healthz", dateSampleInterval, time.Second*5)
+	}
+
+	result := agentResult{Skew: skew}
+	if err != nil {
+		log.Errorln("Failed to measure the clock skew:", err)
+		result.Error = err.Error()
+	} else {
+		log.Infoln("The clock of the node is off by", skew)
+	}
+
+	// the checker reads the result from the termination message of the pod
+	b, err := json.Marshal(result)
+	if err != nil {
+		log.Errorln("Failed to encode the result:", err)
+		return 1
+	}
+	err = os.WriteFile(terminationLogPath, b, 0644)
+	if err != nil {
+		log.Errorln("Failed to write the termination log:", err)
+		return 1
+	}
+	return 0
+}
+
+// dateSkew measures the clock skew against the server at a url from the Date header of its responses.  The header
+// only has a resolution of a second, so the url is requested until the header changes, which is when the clock of the
+// server is at the new whole second.  Any response will do, so the API server is requested without credentials.
+func dateSkew(u string, interval time.Duration, timeout time.Duration) (time.Duration, error) {
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// the certificate of the server is not verified, since only the Date header is read and a skewed clock
+			// may consider a valid certificate expired
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+
+	var last string
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		sent := time.Now()
+		resp, err := client.Get(u)
+		if err != nil {
+			return 0, err
+		}
+		received := time.Now()
+		resp.Body.Close()
+
+		date := resp.Header.Get("Date")
+		if len(date) == 0 {
+			return 0, fmt.Errorf("%s responded without a Date header", u)
+		}
+		if len(last) > 0 && date != last {
+			server, err := http.ParseTime(date)
+			if err != nil {
+				return 0, fmt.Errorf("failed to parse the Date header %q: %w", date, err)
+			}
+			// the server responded half way between sending the request and receiving the response
+			return sent.Add(received.Sub(sent) / 2).Sub(server), nil
+		}
+		last = date
+		time.Sleep(interval)
+	}
+	return 0, fmt.Errorf("the Date header of %s did not change within %s", u, timeout)
+}
+
+// ntpSkew measures the clock skew against an NTP server with a single SNTP request
+func ntpSkew(server string, timeout time.Duration) (time.Duration, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	err = conn.SetDeadline(time.Now().Add(timeout))
+	if err != nil {
+		return 0, err
+	}
+
+	// a client request of NTP version 4 with its transmit time set, which the server echoes as the origin time
+	request := make([]byte, 48)
+	request[0] = 0x23
+	sent := time.Now()
+	binary.BigEndian.PutUint64(request[40:], toNTPTime(sent))
+	_, err = conn.Write(request)
+	if err != nil {
+		return 0, err
+	}
+
+	response := make([]byte, 48)
+	n, err := conn.Read(response)
+	if err != nil {
+		return 0, err
+	}
+	received := time.Now()
+	if n < 48 {
+		return 0, fmt.Errorf("NTP server %s sent a response of %d bytes", server, n)
+	}
+	if response[1] == 0 {
+		return 0, errors.New("NTP server " + server + " is not synchronized")
+	}
+	if binary.BigEndian.Uint64(response[24:]) != binary.BigEndian.Uint64(request[40:]) {
+		return 0, fmt.Errorf("NTP server %s sent a response to another request", server)
+	}
+
+	// the offset of the server is the mean of the differences of the clocks on the way there and back
+	serverReceived := fromNTPTime(binary.BigEndian.Uint64(response[32:]))
+	serverSent := fromNTPTime(binary.BigEndian.Uint64(response[40:]))
+	offset := (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2
+	return -offset, nil
+}
+
+// toNTPTime converts a time to an NTP timestamp, which has the seconds since 1900 in its upper half and the fraction of
+// a second in its lower half
+func toNTPTime(t time.Time) uint64 {
+	seconds := uint64(t.Unix() + ntpEpochOffset)
+	fraction := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return seconds<<32 | fraction
+}
+
+// fromNTPTime converts an NTP timestamp to a time
+func fromNTPTime(ntp uint64) time.Time {
+	seconds := int64(ntp>>32) - ntpEpochOffset
+	nanoseconds := int64((ntp & 0xffffffff) * uint64(time.Second) >> 32)
+	return time.Unix(seconds, nanoseconds)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/binary"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// withinSkew ensures a measured skew is close to the expected skew
+func withinSkew(t *testing.T, measured time.Duration, expected time.Duration) {
+	t.Helper()
+	diff := measured - expected
+	if diff < -time.Millisecond*100 || diff > time.Millisecond*100 {
+		t.Fatal("expected a skew of about", expected, "but measured", measured)
+	}
+}
+
+func TestDateSkew(t *testing.T) {
+	// the clock of the server is 3 seconds ahead, so the clock of the agent is 3 seconds behind
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", time.Now().Add(time.Second*3).UTC().Format(http.TimeFormat))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	skew, err := dateSkew(server.URL, time.Millisecond*5, time.Second*3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withinSkew(t, skew, -time.Second*3)
+}
+
+func TestDateSkewUnchangedDate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Date", "Mon, 01 May 2023 10:00:00 GMT")
+	}))
+	defer server.Close()
+
+	_, err := dateSkew(server.URL, time.Millisecond*5, time.Millisecond*100)
+	if err == nil {
+		t.Fatal("expected an error when the Date header does not change")
+	}
+}
+
+// serveNTP answers NTP requests with a clock that is off by the supplied offset, or as an unsynchronized server
+func serveNTP(t *testing.T, offset time.Duration, synchronized bool) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		request := make([]byte, 48)
+		for {
+			_, addr, err := conn.ReadFrom(request)
+			if err != nil {
+				return
+			}
+			received := time.Now().Add(offset)
+			response := make([]byte, 48)
+			response[0] = 0x24
+			if synchronized {
+				response[1] = 2
+			}
+			copy(response[24:32], request[40:48])
+			binary.BigEndian.PutUint64(response[32:], toNTPTime(received))
+			binary.BigEndian.PutUint64(response[40:], toNTPTime(time.Now().Add(offset)))
+			_, _ = conn.WriteTo(response, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestNTPSkew(t *testing.T) {
+	// the clock of the server is 2 seconds behind, so the clock of the agent is 2 seconds ahead
+	skew, err := ntpSkew(serveNTP(t, -time.Second*2, true), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withinSkew(t, skew, time.Second*2)
+
+	_, err = ntpSkew(serveNTP(t, 0, false), time.Second)
+	if err == nil {
+		t.Fatal("expected an error from an unsynchronized NTP server")
+	}
+}
+
+func TestNTPTime(t *testing.T) {
+	now := time.Date(2023, 5, 1, 10, 0, 0, 123456789, time.UTC)
+	converted := fromNTPTime(toNTPTime(now))
+	if diff := converted.Sub(now); diff < -time.Nanosecond || diff > time.Nanosecond {
+		t.Fatal("expected", now, "but got", converted)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// checkLabel labels the agent pods created by the check, so that those left behind by earlier runs can be found
+	// and removed
+	checkLabel = "kuberhealthy-clock-skew-check"
+
+	// cleanupTimeout is how long removing the agent pods can take after the run
+	cleanupTimeout = time.Second * 30
+)
+
+// checker starts an agent on a sample of nodes and ensures the clock of each node is within the allowed skew of the
+// API server or an NTP server
+type checker struct {
+	namespace       string
+	image           string        // the image of the agent pods, which is the image of the check
+	nodeSelector    string        // a label selector that limits the nodes agents are started on
+	sampleSize      int           // the number of nodes agents are started on
+	ntpServer       string        // the NTP server clocks are compared with instead of the API server
+	maxSkew         time.Duration // how far the clock of a node can be off
+	agentTimeout    time.Duration // how long agents can take to start and measure
+	pollInterval    time.Duration
+	ownerReferences []metav1.OwnerReference
+	client          kubernetes.Interface
+}
+
+// run starts an agent on a sample of nodes, waits for their measurements and removes them again.  The errors of the
+// run are returned, along with the skew of each node.
+func (c *checker) run(ctx context.Context) ([]string, map[string]string) {
+	err := c.cleanUp(ctx)
+	if err != nil {
+		return []string{"Failed to remove the agent pods of earlier runs: " + err.Error()}, nil
+	}
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		err := c.cleanUp(cleanupCtx)
+		if err != nil {
+			log.Errorln("Failed to remove the agent pods of this run:", err)
+		}
+	}()
+
+	nodes, err := c.sampleNodes(ctx)
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+
+	name := "clock-skew-check-" + strconv.FormatInt(time.Now().Unix(), 10)
+	agents := make(map[string]string, len(nodes))
+	var errs []string
+	for i, node := range nodes {
+		agentName := fmt.Sprintf("%s-%d", name, i)
+		log.Infoln("Creating agent pod", c.namespace+"/"+agentName, "on node", node)
+		_, err := c.client.CoreV1().Pods(c.namespace).Create(ctx, c.agentPod(agentName, node), metav1.CreateOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Failed to create agent pod %s on node %s: %s", agentName, node, err))
+			continue
+		}
+		agents[agentName] = node
+	}
+
+	results := c.waitForAgents(ctx, agents)
+	details := make(map[string]string, len(results))
+	reference := "the API server"
+	if len(c.ntpServer) > 0 {
+		reference = "NTP server " + c.ntpServer
+	}
+	for _, agentName := range sortedKeys(agents) {
+		node := agents[agentName]
+		result, ok := results[agentName]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Sprintf("Agent pod %s on node %s did not measure the clock skew within %s", agentName, node, c.agentTimeout))
+		case len(result.Error) > 0:
+			errs = append(errs, fmt.Sprintf("Failed to measure the clock skew of node %s against %s: %s", node, reference, result.Error))
+		default:
+			skew := result.Skew.Round(time.Millisecond)
+			details[node] = skew.String()
+			if result.Skew > c.maxSkew || result.Skew < -c.maxSkew {
+				errs = append(errs, fmt.Sprintf("The clock of node %s is off by %s from %s, which is more than %s", node, skew, reference, c.maxSkew))
+				continue
+			}
+			log.Infoln("The clock of node", node, "is off by", skew, "from", reference)
+		}
+	}
+	return errs, details
+}
+
+// waitForAgents waits for the agents to terminate and returns the results they wrote to their termination message
+func (c *checker) waitForAgents(ctx context.Context, agents map[string]string) map[string]agentResult {
+	results := make(map[string]agentResult, len(agents))
+	_ = wait.PollUntilContextTimeout(ctx, c.pollInterval, c.agentTimeout, true, func(ctx context.Context) (bool, error) {
+		for agentName := range agents {
+			if _, ok := results[agentName]; ok {
+				continue
+			}
+			pod, err := c.client.CoreV1().Pods(c.namespace).Get(ctx, agentName, metav1.GetOptions{})
+			if err != nil {
+				log.Warnln("Failed to get agent pod", agentName+":", err)
+				continue
+			}
+			for _, status := range pod.Status.ContainerStatuses {
+				if status.State.Terminated == nil {
+					continue
+				}
+				results[agentName] = parseResult(status.State.Terminated)
+			}
+		}
+		return len(results) == len(agents), nil
+	})
+	return results
+}
+
+// parseResult reads the result of an agent from the state of its terminated container
+func parseResult(terminated *apiv1.ContainerStateTerminated) agentResult {
+	var result agentResult
+	err := json.Unmarshal([]byte(strings.TrimSpace(terminated.Message)), &result)
+	if err != nil {
+		return agentResult{Error: fmt.Sprintf("agent exited with code %d (%s) without a result: %s", terminated.ExitCode, terminated.Reason, strings.TrimSpace(terminated.Message))}
+	}
+	return result
+}
+
+// sampleNodes returns the names of up to sampleSize random nodes that are ready and match the node selector
+func (c *checker) sampleNodes(ctx context.Context) ([]string, error) {
+	nodeList, err := c.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: c.nodeSelector})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list nodes: %w", err)
+	}
+
+	var nodes []string
+	for _, node := range nodeList.Items {
+		if nodeReady(&node) {
+			nodes = append(nodes, node.Name)
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("Found no ready nodes to measure the clock skew of")
+	}
+
+	rand.Shuffle(len(nodes), func(i, j int) { nodes[i], nodes[j] = nodes[j], nodes[i] })
+	if len(nodes) > c.sampleSize {
+		nodes = nodes[:c.sampleSize]
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+// nodeReady determines if the Ready condition of a node is true
+func nodeReady(node *apiv1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == apiv1.NodeReady {
+			return condition.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the keys of a map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// agentPod returns the pod that measures the clock skew of a node.  Containers share the clock of their node, so the
+// agent measures the clock of the node.  The pod is bound to the node directly and tolerates every taint, so that
+// nodes reserved for some workloads are checked too.
+func (c *checker) agentPod(name string, node string) *apiv1.Pod {
+	automount := false
+	env := []apiv1.EnvVar{{Name: "MODE", Value: "agent"}}
+	if len(c.ntpServer) > 0 {
+		env = append(env, apiv1.EnvVar{Name: "NTP_SERVER", Value: c.ntpServer})
+	}
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       c.namespace,
+			Labels:          map[string]string{checkLabel: name},
+			OwnerReferences: c.ownerReferences,
+		},
+		Spec: apiv1.PodSpec{
+			NodeName:                     node,
+			Tolerations:                  []apiv1.Toleration{{Operator: apiv1.TolerationOpExists}},
+			RestartPolicy:                apiv1.RestartPolicyNever,
+			AutomountServiceAccountToken: &automount,
+			Containers: []apiv1.Container{{
+				Name:                     "agent",
+				Image:                    c.image,
+				ImagePullPolicy:          apiv1.PullIfNotPresent,
+				Env:                      env,
+				TerminationMessagePolicy: apiv1.TerminationMessageReadFile,
+				Resources: apiv1.ResourceRequirements{
+					Requests: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("10m"),
+						apiv1.ResourceMemory: resource.MustParse("20Mi"),
+					},
+				},
+			}},
+		},
+	}
+}
+
+// cleanUp removes the agent pods created by the check
+func (c *checker) cleanUp(ctx context.Context) error {
+	pods, err := c.client.CoreV1().Pods(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: checkLabel})
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		log.Infoln("Removing pod", pod.Name)
+		err := c.client.CoreV1().Pods(c.namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
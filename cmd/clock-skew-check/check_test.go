@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testNode returns a ready node
+func testNode(name string) *apiv1.Node {
+	return &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     apiv1.NodeStatus{Conditions: []apiv1.NodeCondition{{Type: apiv1.NodeReady, Status: apiv1.ConditionTrue}}},
+	}
+}
+
+// newTestChecker returns a checker with short timeouts and a fake client
+func newTestChecker(client *fake.Clientset) *checker {
+	return &checker{
+		namespace:    "kuberhealthy",
+		image:        "kuberhealthy/clock-skew-check:test",
+		sampleSize:   5,
+		maxSkew:      time.Second,
+		agentTimeout: time.Millisecond * 300,
+		pollInterval: time.Millisecond * 10,
+		client:       client,
+	}
+}
+
+// simulateKubelet terminates each agent pod with the termination message for its node, like a kubelet running them
+// would
+func simulateKubelet(t *testing.T, client *fake.Clientset, messages map[string]string) {
+	go func() {
+		ctx := context.Background()
+		for i := 0; i < 100; i++ {
+			time.Sleep(time.Millisecond * 5)
+			pods, err := client.CoreV1().Pods("kuberhealthy").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				continue
+			}
+			for _, pod := range pods.Items {
+				message, ok := messages[pod.Spec.NodeName]
+				if !ok || len(pod.Status.ContainerStatuses) > 0 {
+					continue
+				}
+				pod.Status.ContainerStatuses = []apiv1.ContainerStatus{{
+					Name:  "agent",
+					State: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{Message: message}},
+				}}
+				_, err := client.CoreV1().Pods("kuberhealthy").UpdateStatus(ctx, &pod, metav1.UpdateOptions{})
+				if err != nil {
+					t.Log("failed to update pod status:", err)
+				}
+			}
+		}
+	}()
+}
+
+func TestCheckerRun(t *testing.T) {
+	client := fake.NewSimpleClientset(testNode("node-a"), testNode("node-b"), testNode("node-c"), testNode("node-d"))
+	c := newTestChecker(client)
+	simulateKubelet(t, client, map[string]string{
+		"node-a": `{"skew":12000000}`,
+		"node-b": `{"skew":-2500000000}`,
+		"node-c": `{"skew":0,"error":"Get \"https://10.96.0.1:443/healthz\": dial tcp 10.96.0.1:443: i/o timeout"}`,
+		// node-d never reports
+	})
+
+	errs, details := c.run(context.Background())
+	if len(errs) != 3 {
+		t.Fatal("expected errors for nodes b, c and d but got", errs)
+	}
+	if !strings.Contains(errs[0], "The clock of node node-b is off by -2.5s from the API server, which is more than 1s") {
+		t.Fatal("expected node-b to be skewed but got", errs[0])
+	}
+	if !strings.Contains(errs[1], "Failed to measure the clock skew of node node-c against the API server: Get") {
+		t.Fatal("expected node-c to fail to measure but got", errs[1])
+	}
+	if !strings.Contains(errs[2], "on node node-d did not measure the clock skew") {
+		t.Fatal("expected node-d to time out but got", errs[2])
+	}
+	if len(details) != 2 || details["node-a"] != "12ms" || details["node-b"] != "-2.5s" {
+		t.Fatal("expected the skew of nodes a and b in the details but got", details)
+	}
+
+	pods, _ := client.CoreV1().Pods("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if len(pods.Items) != 0 {
+		t.Fatal("expected the agent pods to be removed but found", len(pods.Items))
+	}
+}
+
+func TestAgentPod(t *testing.T) {
+	c := newTestChecker(nil)
+	c.ntpServer = "pool.ntp.org"
+	pod := c.agentPod("clock-skew-check-1-0", "node-a")
+	if pod.Spec.NodeName != "node-a" || pod.Spec.Tolerations[0].Operator != apiv1.TolerationOpExists {
+		t.Fatal("expected the agent to be bound to its node and tolerate every taint")
+	}
+	env := make(map[string]string)
+	for _, e := range pod.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	if env["MODE"] != "agent" || env["NTP_SERVER"] != "pool.ntp.org" {
+		t.Fatal("expected the agent to measure against the NTP server but got", env)
+	}
+}
+
+func TestParseResult(t *testing.T) {
+	result := parseResult(&apiv1.ContainerStateTerminated{ExitCode: 2, Reason: "Error", Message: "panic: boom"})
+	if !strings.Contains(result.Error, "agent exited with code 2 (Error) without a result: panic: boom") {
+		t.Fatal("expected an error for an agent without a result but got", result.Error)
+	}
+}
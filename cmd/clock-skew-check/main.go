@@ -0,0 +1,155 @@
+// Package main implements a check that starts agents on a sample of nodes and ensures the clock of each node is within
+// the allowed skew of the API server, or of an NTP server.  Clock skew breaks TLS and token validation.  The same
+// binary runs as the agents when MODE is agent.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	checkclient "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/nodeCheck"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/util"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
+)
+
+const (
+	// defaultImage is the image of the agent pods
+	defaultImage = "kuberhealthy/clock-skew-check:v1.0.0"
+
+	// defaultNamespace is the namespace the agent pods are created in when the check's namespace can not be found
+	defaultNamespace = "kuberhealthy"
+
+	// defaultSampleSize is the number of nodes agents are started on
+	defaultSampleSize = 5
+
+	// defaultMaxSkew is how far the clock of a node can be off
+	defaultMaxSkew = time.Second
+
+	// defaultAgentTimeout is how long agents can take to start and measure
+	defaultAgentTimeout = time.Minute * 2
+)
+
+// kubeConfigFile is a variable containing file path of Kubernetes config files
+var kubeConfigFile = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+
+func init() {
+	// set debug mode for nodeCheck pkg
+	nodeCheck.EnableDebugOutput()
+}
+
+func main() {
+	// the agent pods run the same image as the check
+	if os.Getenv("MODE") == "agent" {
+		os.Exit(agentMain())
+	}
+
+	checker, err := newCheckerFromEnv()
+	if err != nil {
+		log.Errorln("Error parsing the check configuration:", err)
+		reportToKuberhealthy([]string{err.Error()}, nil)
+		return
+	}
+
+	// leave time to clean up and report before the deadline of the run
+	ctx := context.Background()
+	deadline, err := checkclient.GetDeadline()
+	if err != nil {
+		log.Warnln("There was an issue getting the check deadline:", err.Error())
+	} else {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-time.Second*30))
+		defer cancel()
+	}
+
+	client, err := kubeClient.Create(kubeConfigFile)
+	if err != nil {
+		reportToKuberhealthy([]string{"Failed to create a kubernetes client: " + err.Error()}, nil)
+		return
+	}
+	checker.client = client
+
+	// the agent pods are owned by the checker pod, so that they are garbage collected if cleanup is interrupted
+	checker.ownerReferences, err = util.GetOwnerRef(client, checker.namespace)
+	if err != nil {
+		log.Warnln("Failed to get the owner reference of the checker pod:", err)
+	}
+
+	// hits kuberhealthy endpoint to see if node is ready
+	err = nodeCheck.WaitForKuberhealthy(ctx)
+	if err != nil {
+		log.Errorln("Error waiting for kuberhealthy endpoint to be contactable by checker pod with error:" + err.Error())
+	}
+
+	reportToKuberhealthy(checker.run(ctx))
+}
+
+// newCheckerFromEnv creates a checker from the environment variables of the checker pod
+func newCheckerFromEnv() (*checker, error) {
+	c := &checker{
+		namespace:    util.GetInstanceNamespace(defaultNamespace),
+		image:        defaultImage,
+		nodeSelector: os.Getenv("NODE_SELECTOR"),
+		sampleSize:   defaultSampleSize,
+		ntpServer:    os.Getenv("NTP_SERVER"),
+		maxSkew:      defaultMaxSkew,
+		agentTimeout: defaultAgentTimeout,
+		pollInterval: time.Second * 2,
+	}
+	if image := os.Getenv("CHECK_IMAGE"); len(image) > 0 {
+		c.image = image
+	}
+	if namespace := os.Getenv("CHECK_NAMESPACE"); len(namespace) > 0 {
+		c.namespace = namespace
+	}
+
+	var err error
+	if v := os.Getenv("NODE_SAMPLE_SIZE"); len(v) > 0 {
+		c.sampleSize, err = strconv.Atoi(v)
+		if err != nil || c.sampleSize < 1 {
+			return nil, fmt.Errorf("NODE_SAMPLE_SIZE must be a positive number, got %q", v)
+		}
+	}
+	// the skew of each node is reported in the details of the check
+	if c.sampleSize > status.MaxDetails {
+		return nil, fmt.Errorf("NODE_SAMPLE_SIZE can be at most %d, got %d", status.MaxDetails, c.sampleSize)
+	}
+	if v := os.Getenv("MAX_CLOCK_SKEW"); len(v) > 0 {
+		c.maxSkew, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MAX_CLOCK_SKEW: %w", err)
+		}
+	}
+	if v := os.Getenv("AGENT_TIMEOUT"); len(v) > 0 {
+		c.agentTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AGENT_TIMEOUT: %w", err)
+		}
+	}
+
+	log.Infoln("Measuring the clock skew of", c.sampleSize, "nodes, allowing", c.maxSkew)
+	return c, nil
+}
+
+// reportToKuberhealthy reports the errors of the run to Kuberhealthy, or success when there are none, along with the
+// skew of each node
+func reportToKuberhealthy(errorMessages []string, details map[string]string) {
+	var err error
+	if len(errorMessages) == 0 {
+		err = checkclient.ReportSuccessWithDetails(details)
+	} else {
+		log.Errorln("Reporting errors to Kuberhealthy:", errorMessages)
+		err = checkclient.ReportFailureWithDetails(errorMessages, details)
+	}
+	if err != nil {
+		log.Fatalln("error reporting to kuberhealthy:", err.Error())
+	}
+	log.Infoln("Successfully reported to Kuberhealthy")
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// checkLabel labels the CronJobs and jobs created by the check, so that those left behind by earlier runs can be
+	// found and removed
+	checkLabel = "kuberhealthy-cronjob-scheduling-check"
+
+	// cleanupTimeout is how long removing the CronJob and its jobs can take after the run
+	cleanupTimeout = time.Second * 30
+
+	// schedule is the schedule of the test CronJob.  It is the most frequent schedule a CronJob supports.
+	schedule = "* * * * *"
+
+	// scheduledTimestampAnnotation is set on jobs by the CronJob controller of Kubernetes 1.28 and later to the time
+	// they were scheduled for
+	scheduledTimestampAnnotation = "batch.kubernetes.io/cronjob-scheduled-timestamp"
+)
+
+// checker creates a CronJob that runs every minute and ensures the CronJob controller creates a job for each schedule
+// in time, and that the jobs complete
+type checker struct {
+	namespace         string
+	image             string        // the image of the job pods, which is the image of the check
+	runs              int           // the number of schedules the CronJob is observed for
+	scheduleTolerance time.Duration // how late a job can be created after the time it was scheduled for
+	completionTimeout time.Duration // how long a job can take to complete after it was scheduled
+	pollInterval      time.Duration
+	ownerReferences   []metav1.OwnerReference
+	client            kubernetes.Interface
+}
+
+// run creates the test CronJob, waits for its jobs and removes them all again.  The errors of the run are returned.
+func (c *checker) run(ctx context.Context) []string {
+	err := c.cleanUp(ctx)
+	if err != nil {
+		return []string{"Failed to remove the CronJobs of earlier runs: " + err.Error()}
+	}
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		err := c.cleanUp(cleanupCtx)
+		if err != nil {
+			log.Errorln("Failed to remove the CronJob of this run:", err)
+		}
+	}()
+
+	name := "cronjob-scheduling-check-" + strconv.FormatInt(time.Now().Unix(), 10)
+	log.Infoln("Creating CronJob", c.namespace+"/"+name, "with schedule", schedule)
+	cronJob, err := c.client.BatchV1().CronJobs(c.namespace).Create(ctx, c.cronJob(name), metav1.CreateOptions{})
+	if err != nil {
+		return []string{fmt.Sprintf("Failed to create CronJob %s: %s", name, err)}
+	}
+
+	// the schedules are computed from the creation time the API server assigned, so that the clock of the checker
+	// node does not matter
+	created := cronJob.CreationTimestamp.Time
+	if created.IsZero() {
+		created = time.Now()
+	}
+	schedules := scheduleTimes(created, c.runs)
+	log.Infoln("Expecting jobs of CronJob", name, "for the schedules", schedules)
+
+	// wait until every schedule has a completed job or is known to have failed
+	var errs []string
+	last := schedules[len(schedules)-1]
+	timeout := time.Until(last.Add(c.completionTimeout))
+	err = wait.PollUntilContextTimeout(ctx, c.pollInterval, timeout, true, func(ctx context.Context) (bool, error) {
+		jobs, err := c.client.BatchV1().Jobs(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: checkLabel + "=" + name})
+		if err != nil {
+			log.Warnln("Failed to list the jobs of CronJob", name+":", err)
+			return false, nil
+		}
+		var pending int
+		errs, pending = c.evaluate(name, jobs.Items, schedules, time.Now())
+		log.Debugln("CronJob", name, "has", len(jobs.Items), "jobs and", pending, "pending schedules")
+		return pending == 0, nil
+	})
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("Timed out waiting for the jobs of CronJob %s to complete", name))
+	}
+	return errs
+}
+
+// evaluate matches the jobs of a CronJob with the times they should have been scheduled for.  It returns an error for
+// each schedule that was missed, created late, failed or did not complete in time, and the number of schedules that
+// are still pending at the supplied time.
+func (c *checker) evaluate(name string, jobs []batchv1.Job, schedules []time.Time, now time.Time) ([]string, int) {
+	bySchedule := make(map[int64]*batchv1.Job, len(jobs))
+	for i := range jobs {
+		scheduled, ok := scheduledTime(name, &jobs[i])
+		if !ok {
+			log.Warnln("Failed to find the time job", jobs[i].Name, "was scheduled for")
+			continue
+		}
+		bySchedule[scheduled.Unix()] = &jobs[i]
+	}
+
+	var errs []string
+	var pending int
+	for _, scheduled := range schedules {
+		at := scheduled.UTC().Format(time.RFC3339)
+		job := bySchedule[scheduled.Unix()]
+		if job == nil {
+			if now.After(scheduled.Add(c.scheduleTolerance)) {
+				errs = append(errs, fmt.Sprintf("CronJob %s missed its schedule at %s: no job was created within %s", name, at, c.scheduleTolerance))
+				continue
+			}
+			pending++
+			continue
+		}
+
+		if delay := job.CreationTimestamp.Sub(scheduled); delay > c.scheduleTolerance {
+			errs = append(errs, fmt.Sprintf("CronJob %s created job %s for its schedule at %s %s late", name, job.Name, at, delay.Round(time.Second)))
+		}
+		switch {
+		case jobCondition(job, batchv1.JobComplete):
+			log.Infoln("Job", job.Name, "of the schedule at", at, "completed")
+		case jobCondition(job, batchv1.JobFailed):
+			errs = append(errs, fmt.Sprintf("Job %s of CronJob %s for its schedule at %s failed", job.Name, name, at))
+		case now.After(scheduled.Add(c.completionTimeout)):
+			errs = append(errs, fmt.Sprintf("Job %s of CronJob %s for its schedule at %s did not complete within %s", job.Name, name, at, c.completionTimeout))
+		default:
+			pending++
+		}
+	}
+	return errs, pending
+}
+
+// scheduleTimes returns the first runs times a CronJob created at the supplied time is scheduled for
+func scheduleTimes(created time.Time, runs int) []time.Time {
+	times := make([]time.Time, runs)
+	next := created.Truncate(time.Minute).Add(time.Minute)
+	for i := range times {
+		times[i] = next
+		next = next.Add(time.Minute)
+	}
+	return times
+}
+
+// scheduledTime returns the time a job of a CronJob was scheduled for.  It is read from the annotation newer CronJob
+// controllers set, or else from the name of the job, which ends with the scheduled time in minutes.
+func scheduledTime(cronJobName string, job *batchv1.Job) (time.Time, bool) {
+	if timestamp, ok := job.Annotations[scheduledTimestampAnnotation]; ok {
+		t, err := time.Parse(time.RFC3339, timestamp)
+		if err == nil {
+			return t, true
+		}
+	}
+
+	minutes, err := strconv.ParseInt(strings.TrimPrefix(job.Name, cronJobName+"-"), 10, 64)
+	if err != nil || !strings.HasPrefix(job.Name, cronJobName+"-") {
+		return time.Time{}, false
+	}
+	return time.Unix(minutes*60, 0), true
+}
+
+// jobCondition determines if a condition of a job is true
+func jobCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// cronJob returns the test CronJob.  Its jobs run the check image in job mode, which exits right away, and are kept
+// until the end of the run so that they can be examined.  Jobs run concurrently if an earlier one is slow, so that
+// every schedule is expected to create a job.
+func (c *checker) cronJob(name string) *batchv1.CronJob {
+	labels := map[string]string{checkLabel: name}
+	history := int32(c.runs)
+	backoffLimit := int32(0)
+	deadline := int64(c.completionTimeout.Seconds())
+	automount := false
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       c.namespace,
+			Labels:          labels,
+			OwnerReferences: c.ownerReferences,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   schedule,
+			ConcurrencyPolicy:          batchv1.AllowConcurrent,
+			SuccessfulJobsHistoryLimit: &history,
+			FailedJobsHistoryLimit:     &history,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: batchv1.JobSpec{
+					BackoffLimit:          &backoffLimit,
+					ActiveDeadlineSeconds: &deadline,
+					Template: apiv1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec: apiv1.PodSpec{
+							RestartPolicy:                apiv1.RestartPolicyNever,
+							AutomountServiceAccountToken: &automount,
+							Containers: []apiv1.Container{{
+								Name:            "job",
+								Image:           c.image,
+								ImagePullPolicy: apiv1.PullIfNotPresent,
+								Env:             []apiv1.EnvVar{{Name: "MODE", Value: "job"}},
+								Resources: apiv1.ResourceRequirements{
+									Requests: apiv1.ResourceList{
+										apiv1.ResourceCPU:    resource.MustParse("10m"),
+										apiv1.ResourceMemory: resource.MustParse("20Mi"),
+									},
+								},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// cleanUp removes the CronJobs and jobs created by the check
+func (c *checker) cleanUp(ctx context.Context) error {
+	background := metav1.DeletePropagationBackground
+	cronJobs, err := c.client.BatchV1().CronJobs(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: checkLabel})
+	if err != nil {
+		return err
+	}
+	for _, cronJob := range cronJobs.Items {
+		log.Infoln("Removing CronJob", cronJob.Name)
+		err := c.client.BatchV1().CronJobs(c.namespace).Delete(ctx, cronJob.Name, metav1.DeleteOptions{PropagationPolicy: &background})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	jobs, err := c.client.BatchV1().Jobs(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: checkLabel})
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs.Items {
+		log.Infoln("Removing job", job.Name)
+		err := c.client.BatchV1().Jobs(c.namespace).Delete(ctx, job.Name, metav1.DeleteOptions{PropagationPolicy: &background})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
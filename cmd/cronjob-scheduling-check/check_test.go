@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newTestChecker returns a checker with a fake client
+func newTestChecker(client *fake.Clientset) *checker {
+	return &checker{
+		namespace:         "kuberhealthy",
+		image:             "kuberhealthy/cronjob-scheduling-check:test",
+		runs:              2,
+		scheduleTolerance: time.Second * 30,
+		completionTimeout: time.Minute * 2,
+		pollInterval:      time.Millisecond * 10,
+		client:            client,
+	}
+}
+
+// testJob returns a job of a CronJob for the supplied schedule, created after the supplied delay, named like the
+// CronJob controller names it
+func testJob(cronJobName string, scheduled time.Time, delay time.Duration, condition batchv1.JobConditionType) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              fmt.Sprintf("%s-%d", cronJobName, scheduled.Unix()/60),
+			Namespace:         "kuberhealthy",
+			Labels:            map[string]string{checkLabel: cronJobName},
+			CreationTimestamp: metav1.NewTime(scheduled.Add(delay)),
+		},
+	}
+	if len(condition) > 0 {
+		job.Status.Conditions = []batchv1.JobCondition{{Type: condition, Status: apiv1.ConditionTrue}}
+	}
+	return job
+}
+
+func TestScheduleTimes(t *testing.T) {
+	created := time.Date(2023, 5, 1, 10, 4, 30, 0, time.UTC)
+	times := scheduleTimes(created, 3)
+	expected := []time.Time{
+		time.Date(2023, 5, 1, 10, 5, 0, 0, time.UTC),
+		time.Date(2023, 5, 1, 10, 6, 0, 0, time.UTC),
+		time.Date(2023, 5, 1, 10, 7, 0, 0, time.UTC),
+	}
+	for i := range expected {
+		if !times[i].Equal(expected[i]) {
+			t.Fatal("expected the schedules", expected, "but got", times)
+		}
+	}
+}
+
+func TestScheduledTime(t *testing.T) {
+	scheduled := time.Date(2023, 5, 1, 10, 5, 0, 0, time.UTC)
+	job := testJob("cronjob-scheduling-check-1", scheduled, 0, "")
+	at, ok := scheduledTime("cronjob-scheduling-check-1", job)
+	if !ok || !at.Equal(scheduled) {
+		t.Fatal("expected the scheduled time from the job name to be", scheduled, "but got", at)
+	}
+
+	job.Annotations = map[string]string{scheduledTimestampAnnotation: "2023-05-01T10:06:00Z"}
+	at, ok = scheduledTime("cronjob-scheduling-check-1", job)
+	if !ok || !at.Equal(scheduled.Add(time.Minute)) {
+		t.Fatal("expected the scheduled time from the annotation but got", at)
+	}
+
+	_, ok = scheduledTime("cronjob-scheduling-check-1", &batchv1.Job{ObjectMeta: metav1.ObjectMeta{Name: "other-job"}})
+	if ok {
+		t.Fatal("expected no scheduled time for a job not named after the CronJob")
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	c := newTestChecker(nil)
+	name := "cronjob-scheduling-check-1"
+	schedules := scheduleTimes(time.Date(2023, 5, 1, 10, 4, 30, 0, time.UTC), 2)
+
+	tests := []struct {
+		name    string
+		jobs    []batchv1.Job
+		now     time.Time
+		errs    []string
+		pending int
+	}{
+		{
+			name:    "before the first schedule",
+			now:     schedules[0].Add(-time.Second),
+			pending: 2,
+		},
+		{
+			name: "all jobs completed",
+			jobs: []batchv1.Job{
+				*testJob(name, schedules[0], time.Second, batchv1.JobComplete),
+				*testJob(name, schedules[1], time.Second, batchv1.JobComplete),
+			},
+			now: schedules[1].Add(time.Second * 10),
+		},
+		{
+			name: "first job running",
+			jobs: []batchv1.Job{*testJob(name, schedules[0], time.Second, "")},
+			now:  schedules[0].Add(time.Second * 10),
+			// the second schedule has not come yet
+			pending: 2,
+		},
+		{
+			name: "missed schedule",
+			jobs: []batchv1.Job{*testJob(name, schedules[1], time.Second, batchv1.JobComplete)},
+			now:  schedules[1].Add(time.Second * 10),
+			errs: []string{"missed its schedule at 2023-05-01T10:05:00Z"},
+		},
+		{
+			name: "late and failed jobs",
+			jobs: []batchv1.Job{
+				*testJob(name, schedules[0], time.Second*45, batchv1.JobComplete),
+				*testJob(name, schedules[1], time.Second, batchv1.JobFailed),
+			},
+			now: schedules[1].Add(time.Minute),
+			errs: []string{
+				"created job cronjob-scheduling-check-1-28048925 for its schedule at 2023-05-01T10:05:00Z 45s late",
+				"for its schedule at 2023-05-01T10:06:00Z failed",
+			},
+		},
+		{
+			name: "job did not complete",
+			jobs: []batchv1.Job{
+				*testJob(name, schedules[0], time.Second, batchv1.JobComplete),
+				*testJob(name, schedules[1], time.Second, ""),
+			},
+			now:  schedules[1].Add(time.Minute * 3),
+			errs: []string{"for its schedule at 2023-05-01T10:06:00Z did not complete within 2m0s"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			errs, pending := c.evaluate(name, test.jobs, schedules, test.now)
+			if pending != test.pending {
+				t.Fatal("expected", test.pending, "pending schedules but got", pending)
+			}
+			if len(errs) != len(test.errs) {
+				t.Fatal("expected errors", test.errs, "but got", errs)
+			}
+			for i := range errs {
+				if !strings.Contains(errs[i], test.errs[i]) {
+					t.Fatalf("expected error %q to contain %q", errs[i], test.errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	// the CronJob is created in the past, so that all its schedules have passed, and the reactor creates jobs for
+	// all but its second schedule like a CronJob controller that missed it would
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "cronjobs", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		cronJob := action.(k8stesting.CreateAction).GetObject().(*batchv1.CronJob)
+		cronJob.CreationTimestamp = metav1.NewTime(time.Now().Add(-time.Minute * 10))
+		schedules := scheduleTimes(cronJob.CreationTimestamp.Time, 3)
+		for i, scheduled := range schedules {
+			if i == 1 {
+				continue
+			}
+			err := client.Tracker().Add(testJob(cronJob.Name, scheduled, time.Second, batchv1.JobComplete))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return false, nil, nil
+	})
+
+	c := newTestChecker(client)
+	c.runs = 3
+	errs := c.run(context.Background())
+	if len(errs) != 1 || !strings.Contains(errs[0], "missed its schedule") {
+		t.Fatal("expected the missed schedule to be reported but got", errs)
+	}
+
+	cronJobs, _ := client.BatchV1().CronJobs("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	jobs, _ := client.BatchV1().Jobs("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if len(cronJobs.Items) != 0 || len(jobs.Items) != 0 {
+		t.Fatal("expected the CronJob and its jobs to be removed but found", len(cronJobs.Items), "CronJobs and", len(jobs.Items), "jobs")
+	}
+}
+
+func TestCronJob(t *testing.T) {
+	c := newTestChecker(nil)
+	cronJob := c.cronJob("cronjob-scheduling-check-1")
+	if cronJob.Spec.Schedule != schedule || cronJob.Spec.ConcurrencyPolicy != batchv1.AllowConcurrent {
+		t.Fatal("expected the CronJob to run every minute concurrently but got", cronJob.Spec.Schedule, cronJob.Spec.ConcurrencyPolicy)
+	}
+	if *cronJob.Spec.SuccessfulJobsHistoryLimit < int32(c.runs) {
+		t.Fatal("expected the CronJob to keep the jobs of every observed schedule")
+	}
+	if cronJob.Spec.JobTemplate.Labels[checkLabel] != cronJob.Name {
+		t.Fatal("expected the jobs to be labeled with the name of the CronJob")
+	}
+	container := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+	if container.Image != c.image || container.Env[0].Value != "job" {
+		t.Fatal("expected the jobs to run the check image in job mode")
+	}
+}
@@ -0,0 +1,147 @@
+// Package main implements a check that creates a CronJob which runs every minute and ensures the CronJob controller
+// creates its jobs on schedule and that they complete.  The jobs run the same binary in job mode.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	checkclient "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/nodeCheck"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/util"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
+)
+
+const (
+	// defaultImage is the image of the job pods
+	defaultImage = "kuberhealthy/cronjob-scheduling-check:v1.0.0"
+
+	// defaultNamespace is the namespace the CronJob is created in when the check's namespace can not be found
+	defaultNamespace = "kuberhealthy"
+
+	// defaultRuns is the number of schedules the CronJob is observed for
+	defaultRuns = 2
+
+	// defaultScheduleTolerance is how late a job can be created after the time it was scheduled for
+	defaultScheduleTolerance = time.Second * 30
+
+	// defaultCompletionTimeout is how long a job can take to complete after it was scheduled
+	defaultCompletionTimeout = time.Minute * 2
+)
+
+// kubeConfigFile is a variable containing file path of Kubernetes config files
+var kubeConfigFile = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+
+func init() {
+	// set debug mode for nodeCheck pkg
+	nodeCheck.EnableDebugOutput()
+}
+
+func main() {
+	// the jobs of the CronJob run the same image as the check and only need to complete
+	if os.Getenv("MODE") == "job" {
+		log.Infoln("Job of the cronjob scheduling check completed")
+		return
+	}
+
+	checker, err := newCheckerFromEnv()
+	if err != nil {
+		log.Errorln("Error parsing the check configuration:", err)
+		reportToKuberhealthy([]string{err.Error()})
+		return
+	}
+
+	// leave time to clean up and report before the deadline of the run
+	ctx := context.Background()
+	deadline, err := checkclient.GetDeadline()
+	if err != nil {
+		log.Warnln("There was an issue getting the check deadline:", err.Error())
+	} else {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-time.Second*30))
+		defer cancel()
+	}
+
+	client, err := kubeClient.Create(kubeConfigFile)
+	if err != nil {
+		reportToKuberhealthy([]string{"Failed to create a kubernetes client: " + err.Error()})
+		return
+	}
+	checker.client = client
+
+	// the CronJob is owned by the checker pod, so that it stops scheduling jobs if cleanup is interrupted
+	checker.ownerReferences, err = util.GetOwnerRef(client, checker.namespace)
+	if err != nil {
+		log.Warnln("Failed to get the owner reference of the checker pod:", err)
+	}
+
+	// hits kuberhealthy endpoint to see if node is ready
+	err = nodeCheck.WaitForKuberhealthy(ctx)
+	if err != nil {
+		log.Errorln("Error waiting for kuberhealthy endpoint to be contactable by checker pod with error:" + err.Error())
+	}
+
+	reportToKuberhealthy(checker.run(ctx))
+}
+
+// newCheckerFromEnv creates a checker from the environment variables of the checker pod
+func newCheckerFromEnv() (*checker, error) {
+	c := &checker{
+		namespace:         util.GetInstanceNamespace(defaultNamespace),
+		image:             defaultImage,
+		runs:              defaultRuns,
+		scheduleTolerance: defaultScheduleTolerance,
+		completionTimeout: defaultCompletionTimeout,
+		pollInterval:      time.Second * 5,
+	}
+	if image := os.Getenv("CHECK_IMAGE"); len(image) > 0 {
+		c.image = image
+	}
+	if namespace := os.Getenv("CHECK_NAMESPACE"); len(namespace) > 0 {
+		c.namespace = namespace
+	}
+
+	var err error
+	if v := os.Getenv("RUNS"); len(v) > 0 {
+		c.runs, err = strconv.Atoi(v)
+		if err != nil || c.runs < 1 {
+			return nil, fmt.Errorf("RUNS must be a positive number, got %q", v)
+		}
+	}
+	if v := os.Getenv("SCHEDULE_TOLERANCE"); len(v) > 0 {
+		c.scheduleTolerance, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCHEDULE_TOLERANCE: %w", err)
+		}
+	}
+	if v := os.Getenv("COMPLETION_TIMEOUT"); len(v) > 0 {
+		c.completionTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid COMPLETION_TIMEOUT: %w", err)
+		}
+	}
+
+	log.Infoln("Observing", c.runs, "schedules of a test CronJob in namespace", c.namespace)
+	return c, nil
+}
+
+// reportToKuberhealthy reports the errors of the run to Kuberhealthy, or success when there are none
+func reportToKuberhealthy(errorMessages []string) {
+	var err error
+	if len(errorMessages) == 0 {
+		err = checkclient.ReportSuccess()
+	} else {
+		log.Errorln("Reporting errors to Kuberhealthy:", errorMessages)
+		err = checkclient.ReportFailure(errorMessages)
+	}
+	if err != nil {
+		log.Fatalln("error reporting to kuberhealthy:", err.Error())
+	}
+	log.Infoln("Successfully reported to Kuberhealthy")
+}
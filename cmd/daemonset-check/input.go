@@ -2,12 +2,16 @@ package main
 
 import (
 	"errors"
+	"strconv"
 	"strings"
 	"time"
 
-	kh "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
 	log "github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/daemonset"
+	kh "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
 )
 
 func findValEffect(find string) (string, string, error) {
@@ -134,6 +138,21 @@ func parseInputValues() {
 	checkDeadline = khDeadline.Add(-shutdownGracePeriod)
 	log.Infoln("Check deadline in", checkDeadline.Sub(now))
 
+	// Parse incoming daemonset deploy and removal timeouts
+	deployTimeout, err = parseTimeout("DAEMONSET_DEPLOY_TIMEOUT", deployTimeoutEnv, checkDeadline.Sub(now))
+	if err != nil {
+		log.Fatalln("error occurred attempting to parse DAEMONSET_DEPLOY_TIMEOUT:", err)
+	}
+	removeTimeout, err = parseTimeout("DAEMONSET_REMOVE_TIMEOUT", removeTimeoutEnv, checkDeadline.Sub(now))
+	if err != nil {
+		log.Fatalln("error occurred attempting to parse DAEMONSET_REMOVE_TIMEOUT:", err)
+	}
+	if len(deployTimeoutEnv) != 0 && len(removeTimeoutEnv) != 0 && deployTimeout+removeTimeout > checkDeadline.Sub(now) {
+		log.Warnln("DAEMONSET_DEPLOY_TIMEOUT", deployTimeout, "and DAEMONSET_REMOVE_TIMEOUT", removeTimeout,
+			"add up to more than the", checkDeadline.Sub(now), "left until the check deadline. Raise the timeout of the khcheck.")
+	}
+	log.Infoln("Setting daemonset deploy timeout to:", deployTimeout, "and removal timeout to:", removeTimeout)
+
 	// Parse incoming namespace environment variable
 	checkNamespace = defaultCheckNamespace
 	if len(checkNamespaceEnv) != 0 {
@@ -143,13 +162,19 @@ func parseInputValues() {
 	log.Infoln("Performing check in", checkNamespace, "namespace.")
 
 	// Allow user to override the image used by the daemonset check - see #114
-	dsPauseContainerImage = defaultDSPauseContainerImage
+	dsPauseContainerImage = daemonset.DefaultPauseContainerImage
 	if len(dsPauseContainerImageEnv) > 0 {
 		log.Infoln("Parsed PAUSE_CONTAINER_IMAGE:", dsPauseContainerImageEnv)
 		dsPauseContainerImage = dsPauseContainerImageEnv
 	}
 	log.Infoln("Setting DS pause container image to:", dsPauseContainerImage)
 
+	// Parse incoming per architecture pause container images for mixed architecture clusters
+	if len(dsPauseContainerImageByArchEnv) != 0 {
+		dsPauseContainerImageByArch = daemonset.ParseImagesByArch(dsPauseContainerImageByArchEnv)
+		log.Infoln("Parsed PAUSE_CONTAINER_IMAGE_BY_ARCH:", dsPauseContainerImageByArch)
+	}
+
 	// Parse incoming check daemonset name
 	checkDSName = defaultCheckDSName
 	if len(checkDSNameEnv) != 0 {
@@ -166,6 +191,13 @@ func parseInputValues() {
 	}
 	log.Infoln("Setting check priority class name to:", podPriorityClassName)
 
+	// Parse incoming daemonset pod resources
+	dsResources, err = parseResources(dsCPURequestEnv, dsMemoryRequestEnv, dsCPULimitEnv, dsMemoryLimitEnv)
+	if err != nil {
+		log.Fatalln("error occurred attempting to parse the daemonset pod resources:", err)
+	}
+	log.Infoln("Setting daemonset pod resources to requests:", dsResources.Requests, "limits:", dsResources.Limits)
+
 	// Parse incoming deployment node selectors
 	if len(dsNodeSelectorsEnv) != 0 {
 		splitEnvVars := strings.Split(dsNodeSelectorsEnv, ",")
@@ -181,6 +213,12 @@ func parseInputValues() {
 		}
 		log.Infoln("Parsed NODE_SELECTOR:", dsNodeSelectors)
 	}
+
+	// Parse incoming node labels of nodes to exclude from the check
+	if len(dsNodeExclusionsEnv) != 0 {
+		dsNodeExclusions = daemonset.ParseNodeExclusions(dsNodeExclusionsEnv)
+		log.Infoln("Parsed EXCLUDE_NODE_LABELS:", daemonset.FormatNodeExclusions(dsNodeExclusions))
+	}
 	// Parse incoming deployment tolerations
 	if len(tolerationsEnv) != 0 {
 		splitEnvVars := strings.Split(tolerationsEnv, ",")
@@ -211,6 +249,21 @@ func parseInputValues() {
 		}
 	}
 
+	// Parse incoming tolerate all taints setting, which takes the place of any TOLERATIONS
+	if len(tolerateAllTaintsEnv) != 0 {
+		tolerateAllTaints, err = strconv.ParseBool(tolerateAllTaintsEnv)
+		if err != nil {
+			log.Fatalln("error occurred attempting to parse TOLERATE_ALL_TAINTS:", err)
+		}
+		if tolerateAllTaints {
+			if len(tolerations) != 0 {
+				log.Warnln("TOLERATE_ALL_TAINTS is set, ignoring TOLERATIONS:", tolerations)
+			}
+			tolerations = daemonset.TolerateAllTolerations
+			log.Infoln("Parsed TOLERATE_ALL_TAINTS: tolerating every taint")
+		}
+	}
+
 	if len(allowedTaintsEnv) != 0 {
 		allowedTaints = make(map[string]corev1.TaintEffect)
 		splitEnvVars := strings.Split(allowedTaintsEnv, ",")
@@ -229,3 +282,60 @@ func parseInputValues() {
 		}
 	}
 }
+
+// parseTimeout parses a timeout of the daemonset check, such as 5m.  The supplied default is used when the timeout is
+// not set.
+func parseTimeout(env string, value string, defaultTimeout time.Duration) (time.Duration, error) {
+	if len(value) == 0 {
+		return defaultTimeout, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, errors.New("invalid " + env + ": " + err.Error())
+	}
+	if timeout <= 0 {
+		return 0, errors.New("invalid " + env + ": " + value + " is not a positive duration")
+	}
+	return timeout, nil
+}
+
+// parseResources parses the resource requests and limits of the daemonset pods.  The pods request no CPU or memory
+// unless requests are set, and are not limited unless limits are set.
+func parseResources(cpuRequest string, memoryRequest string, cpuLimit string, memoryLimit string) (corev1.ResourceRequirements, error) {
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("0"),
+			corev1.ResourceMemory: resource.MustParse("0"),
+		},
+	}
+	for _, r := range []struct {
+		env      string
+		value    string
+		list     *corev1.ResourceList
+		resource corev1.ResourceName
+	}{
+		{env: "DAEMONSET_CPU_REQUEST", value: cpuRequest, list: &resources.Requests, resource: corev1.ResourceCPU},
+		{env: "DAEMONSET_MEMORY_REQUEST", value: memoryRequest, list: &resources.Requests, resource: corev1.ResourceMemory},
+		{env: "DAEMONSET_CPU_LIMIT", value: cpuLimit, list: &resources.Limits, resource: corev1.ResourceCPU},
+		{env: "DAEMONSET_MEMORY_LIMIT", value: memoryLimit, list: &resources.Limits, resource: corev1.ResourceMemory},
+	} {
+		if len(r.value) == 0 {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(r.value)
+		if err != nil {
+			return resources, errors.New("invalid " + r.env + ": " + err.Error())
+		}
+		if *r.list == nil {
+			*r.list = make(corev1.ResourceList)
+		}
+		(*r.list)[r.resource] = quantity
+	}
+
+	for name, limit := range resources.Limits {
+		if request := resources.Requests[name]; request.Cmp(limit) > 0 {
+			return resources, errors.New("the " + string(name) + " request " + request.String() + " of the daemonset pods is higher than the limit " + limit.String())
+		}
+	}
+	return resources, nil
+}
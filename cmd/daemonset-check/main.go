@@ -5,7 +5,6 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
-	"strconv"
 	"syscall"
 	"time"
 
@@ -13,6 +12,7 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/daemonset"
 	kh "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/nodeCheck"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
@@ -30,10 +30,18 @@ var (
 	dsPauseContainerImageEnv = os.Getenv("PAUSE_CONTAINER_IMAGE")
 	dsPauseContainerImage    string // specify an alternate location for the DSC pause container - see #114
 
+	// Pause container images used on nodes of specific architectures, as comma separated arch=image pairs
+	dsPauseContainerImageByArchEnv = os.Getenv("PAUSE_CONTAINER_IMAGE_BY_ARCH")
+	dsPauseContainerImageByArch    = make(map[string]string)
+
 	// Node selectors for the daemonset check
 	dsNodeSelectorsEnv = os.Getenv("NODE_SELECTOR")
 	dsNodeSelectors    = make(map[string]string)
 
+	// Node labels of node pools the daemonset check leaves out, as comma separated key=value or key entries
+	dsNodeExclusionsEnv = os.Getenv("EXCLUDE_NODE_LABELS")
+	dsNodeExclusions    []apiv1.NodeSelectorRequirement
+
 	// Minutes allowed for the shutdown process to complete
 	shutdownGracePeriodEnv = os.Getenv("SHUTDOWN_GRACE_PERIOD")
 	shutdownGracePeriod    time.Duration
@@ -46,23 +54,40 @@ var (
 	podPriorityClassNameEnv = os.Getenv("DAEMONSET_PRIORITY_CLASS_NAME")
 	podPriorityClassName    string
 
+	// Resource requests and limits of the daemonset pods, such as 10m or 16Mi
+	dsCPURequestEnv    = os.Getenv("DAEMONSET_CPU_REQUEST")
+	dsMemoryRequestEnv = os.Getenv("DAEMONSET_MEMORY_REQUEST")
+	dsCPULimitEnv      = os.Getenv("DAEMONSET_CPU_LIMIT")
+	dsMemoryLimitEnv   = os.Getenv("DAEMONSET_MEMORY_LIMIT")
+	dsResources        apiv1.ResourceRequirements
+
+	// Time allowed for the daemonset pods to come online and for the daemonset to be removed, such as 5m.  Both default
+	// to the time left until the check deadline.
+	deployTimeoutEnv = os.Getenv("DAEMONSET_DEPLOY_TIMEOUT")
+	deployTimeout    time.Duration
+	removeTimeoutEnv = os.Getenv("DAEMONSET_REMOVE_TIMEOUT")
+	removeTimeout    time.Duration
+
+	// Name of the khcheck from injected env variable KH_CHECK_NAME.  Daemonsets are labeled with it so that only the
+	// stale daemonsets of this khcheck are evicted.
+	checkNameEnv = os.Getenv("KH_CHECK_NAME")
+
 	// Check deadline from injected env variable KH_CHECK_RUN_DEADLINE
 	khDeadline    time.Time
 	checkDeadline time.Time
 
 	// Daemonset check configurations
-	hostName         string
 	tolerationsEnv   = os.Getenv("TOLERATIONS")
 	tolerations      []apiv1.Toleration
-	daemonSetName    string
 	allowedTaintsEnv = os.Getenv("ALLOWED_TAINTS")
 	allowedTaints    map[string]apiv1.TaintEffect
 
+	// Tolerate every taint, so that tainted node pools are always checked
+	tolerateAllTaintsEnv = os.Getenv("TOLERATE_ALL_TAINTS")
+	tolerateAllTaints    bool
+
 	// Time object used for the check.
 	now time.Time
-
-	// K8s client used for the check.
-	client *kubernetes.Clientset
 )
 
 const (
@@ -70,14 +95,10 @@ const (
 	defaultCheckDSName = "daemonset"
 	// Default namespace daemonset check will be performed in
 	defaultCheckNamespace = "kuberhealthy"
-	// Default pause container image used for the daemonset check
-	defaultDSPauseContainerImage = "gcr.io/google-containers/pause:3.1"
 	// Default shutdown termination grace period
 	defaultShutdownGracePeriod = time.Duration(time.Minute * 1) // grace period for the check to shutdown after receiving a shutdown signal
 	// Default daemonset check deadline
 	defaultCheckDeadline = time.Duration(time.Minute * 15)
-	// Default user
-	defaultUser = int64(1000)
 	// Default priority class name
 	defaultPodPriorityClassName = ""
 )
@@ -93,18 +114,16 @@ func init() {
 	// Parse all incoming input environment variables and crash if an error occurs
 	// during parsing process.
 	parseInputValues()
-
-	setCheckConfigurations(now)
 }
 
 func main() {
 	// Create a kubernetes client.
-	var err error
-	client, err = kubeClient.Create(kubeConfigFile)
+	kubernetesClient, err := kubeClient.Create(kubeConfigFile)
 	if err != nil {
 		log.Fatalln("Unable to create kubernetes client:" + err.Error())
 	}
 	log.Infoln("Kubernetes client created.")
+	dsc := newDaemonSetChecker(kubernetesClient)
 
 	// this check runs all the nodechecks to ensure node is ready before running the daemonset chek
 	err = checksNodeReady()
@@ -117,7 +136,7 @@ func main() {
 		r := recover()
 		if r != nil {
 			log.Infoln("Recovered panic:", r)
-			reportErrorsToKuberhealthy([]string{"kuberhealthy/daemonset: " + r.(string)})
+			reportErrorsToKuberhealthy(dsc, []string{"kuberhealthy/daemonset: " + r.(string)})
 		}
 	}()
 
@@ -136,35 +155,36 @@ func main() {
 	runCheckDoneChan := make(chan error, 1)
 	go func() {
 		// Run daemonset check and report errors
-		runCheckDoneChan <- runCheck(ctx)
+		runCheckDoneChan <- dsc.Run(ctx)
 	}()
 
 	// watch for either the check to complete or the OS to get a shutdown signal
 	select {
 	case err = <-runCheckDoneChan:
 		if err != nil {
-			reportErrorsToKuberhealthy([]string{"kuberhealthy/daemonset: " + err.Error()})
+			reportErrorsToKuberhealthy(dsc, []string{"kuberhealthy/daemonset: " + err.Error()})
 		} else {
-			reportOKToKuberhealthy()
+			reportOKToKuberhealthy(dsc)
 		}
 		log.Infoln("Done running daemonset check")
 	case <-signalChan:
 		// TO DO: figure out better way to report shutdown signals. Do we report "error" or "ok" to kuberhealthy when
 		// a shutdown signal is received? For now, report OK and wait for the next run.
-		reportOKToKuberhealthy()
+		reportOKToKuberhealthy(dsc)
 		log.Errorln("Received shutdown signal. Canceling context and proceeding directly to cleanup.")
 		ctxCancel() // Causes all functions within the check to return without error and abort. NOT an error
 	}
 
 	// at the end of the check run, we run a clean up for everything that may be left behind
 	log.Infoln("Running post-check cleanup")
+	log.Debugln("Allowing clean up", shutdownGracePeriod, "to finish.")
 	shutdownCtx, shutdownCtxCancel := context.WithTimeout(context.Background(), shutdownGracePeriod)
 	defer shutdownCtxCancel()
 
 	// start a background cleanup
 	cleanupDoneChan := make(chan error)
 	go func() {
-		cleanupDoneChan <- cleanUp(shutdownCtx)
+		cleanupDoneChan <- dsc.CleanUp(shutdownCtx)
 	}()
 
 	// wait for either the cleanup to complete or the shutdown grace period to expire
@@ -195,10 +215,23 @@ func checksNodeReady() error {
 	return nil
 }
 
-// setCheckConfigurations sets Daemonset configurations
-func setCheckConfigurations(now time.Time) {
-	hostName = getHostname()
-	daemonSetName = checkDSName + "-" + hostName + "-" + strconv.Itoa(int(now.Unix()))
+// newDaemonSetChecker creates a daemonset checker on the supplied client with the parsed configurations.  The run is
+// timed from the time the check started.
+func newDaemonSetChecker(client kubernetes.Interface) *daemonset.Checker {
+	dsc := daemonset.NewDaemonSetCheckerWithClock(client, checkNamespace, checkDSName, khDeadline.Sub(now), func() time.Time { return now })
+	dsc.PauseContainerImage = dsPauseContainerImage
+	dsc.PauseContainerImageByArch = dsPauseContainerImageByArch
+	dsc.NodeSelectors = dsNodeSelectors
+	dsc.NodeExclusions = dsNodeExclusions
+	dsc.PriorityClassName = podPriorityClassName
+	dsc.Resources = dsResources
+	dsc.Tolerations = tolerations
+	dsc.AllowedTaints = allowedTaints
+	dsc.TolerateAllTaints = tolerateAllTaints
+	dsc.DeployTimeout = deployTimeout
+	dsc.RemoveTimeout = removeTimeout
+	dsc.CheckName = checkNameEnv
+	return dsc
 }
 
 // waitForShutdown watches the signal and done channels for termination.
@@ -213,28 +246,29 @@ func listenForInterrupts(signalChan chan os.Signal) {
 }
 
 // reportErrorsToKuberhealthy reports the specified errors for this check run.
-func reportErrorsToKuberhealthy(errs []string) {
+func reportErrorsToKuberhealthy(dsc *daemonset.Checker, errs []string) {
 	log.Errorln("Reporting errors to Kuberhealthy:", errs)
-	reportToKuberhealthy(false, errs)
+	reportToKuberhealthy(dsc, false, errs)
 }
 
 // reportOKToKuberhealthy reports that there were no errors on this check run to Kuberhealthy.
-func reportOKToKuberhealthy() {
+func reportOKToKuberhealthy(dsc *daemonset.Checker) {
 	log.Infoln("Reporting success to Kuberhealthy.")
-	reportToKuberhealthy(true, []string{})
+	reportToKuberhealthy(dsc, true, []string{})
 }
 
-// reportToKuberhealthy reports the check status to Kuberhealthy.
-func reportToKuberhealthy(ok bool, errs []string) {
+// reportToKuberhealthy reports the check status to Kuberhealthy along with the nodes the check ran against.
+func reportToKuberhealthy(dsc *daemonset.Checker, ok bool, errs []string) {
 	var err error
+	details := dsc.NodeSetDetails()
 	if ok {
-		err = kh.ReportSuccess()
+		err = kh.ReportSuccessWithDetails(details)
 		if err != nil {
 			log.Fatalln("error reporting to kuberhealthy:", err.Error())
 		}
 		return
 	}
-	err = kh.ReportFailure(errs)
+	err = kh.ReportFailureWithDetails(errs, details)
 	if err != nil {
 		log.Fatalln("error reporting to kuberhealthy:", err.Error())
 	}
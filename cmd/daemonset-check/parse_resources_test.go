@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TestParseResources ensures the daemonset pods request nothing by default and take the requests and limits that
+// are set
+func TestParseResources(t *testing.T) {
+	resources, err := parseResources("", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resources.Requests.Cpu().IsZero() || !resources.Requests.Memory().IsZero() || len(resources.Limits) != 0 {
+		t.Fatalf("expected zero requests and no limits by default but got %+v", resources)
+	}
+
+	resources, err = parseResources("5m", "8Mi", "50m", "16Mi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resources.Requests.Cpu().String() != "5m" || resources.Requests.Memory().String() != "8Mi" {
+		t.Fatalf("expected the requests to be set but got %+v", resources.Requests)
+	}
+	if resources.Limits.Cpu().String() != "50m" || resources.Limits.Memory().String() != "16Mi" {
+		t.Fatalf("expected the limits to be set but got %+v", resources.Limits)
+	}
+
+	resources, err = parseResources("", "", "", "16Mi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resources.Limits[corev1.ResourceCPU]; ok || resources.Limits.Memory().String() != "16Mi" {
+		t.Fatalf("expected only a memory limit but got %+v", resources.Limits)
+	}
+
+	if _, err := parseResources("lots", "", "", ""); err == nil {
+		t.Fatal("expected an invalid quantity to fail")
+	}
+	if _, err := parseResources("", "32Mi", "", "16Mi"); err == nil {
+		t.Fatal("expected a request higher than the limit to fail")
+	}
+}
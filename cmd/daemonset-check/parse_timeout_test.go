@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseTimeout ensures timeouts that are not set use the default and that only positive durations are accepted
+func TestParseTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		expected time.Duration
+		valid    bool
+	}{
+		{"", time.Minute * 10, true},
+		{"5m", time.Minute * 5, true},
+		{"90s", time.Second * 90, true},
+		{"0s", 0, false},
+		{"-1m", 0, false},
+		{"5", 0, false},
+	} {
+		timeout, err := parseTimeout("DAEMONSET_DEPLOY_TIMEOUT", tc.value, time.Minute*10)
+		if (err == nil) != tc.valid {
+			t.Fatal("expected DAEMONSET_DEPLOY_TIMEOUT", tc.value, "to be valid:", tc.valid, "but got", err)
+		}
+		if tc.valid && timeout != tc.expected {
+			t.Fatal("expected DAEMONSET_DEPLOY_TIMEOUT", tc.value, "to be", tc.expected, "but got", timeout)
+		}
+	}
+}
@@ -4,28 +4,20 @@ package main
 
 import (
 	"context"
-	"errors"
-	"net"
 	"os"
 	"path/filepath"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	// required for oidc kubectl testing
-	v1 "k8s.io/api/core/v1"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
-
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/dnsresolution"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/nodeCheck"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
 )
 
-const maxTimeInFailure = 60 * time.Second
-const defaultCheckTimeout = 5 * time.Minute
-
 // KubeConfigFile is a variable containing file path of Kubernetes config files
 var KubeConfigFile = filepath.Join(os.Getenv("HOME"), ".kube", "config")
 
@@ -44,19 +36,26 @@ var namespace string
 // Label selector used for dns pods
 var labelSelector string
 
-var now time.Time
+// Lookups along the resolution path a workload uses
+var resolutionLookups []dnsresolution.ResolutionLookup
 
-// Checker validates that DNS is functioning correctly
-type Checker struct {
-	client           *kubernetes.Clientset
-	MaxTimeInFailure time.Duration
-	Hostname         string
-}
+// Endpoints declared internal or external with the record types they are expected to have.  The HOSTNAME is not
+// looked up when endpoints are declared.
+var dnsEndpoints []dnsresolution.DNSEndpoint
+
+// The error parsing DNS_ENDPOINTS, reported as the result of the check
+var dnsEndpointsErr error
+
+// How long a lookup can take before it fails the check, or zero when lookups are not timed against a maximum
+var maxLookupLatency time.Duration
+
+// The error parsing MAX_LOOKUP_LATENCY, reported as the result of the check
+var maxLookupLatencyErr error
 
 func init() {
 
 	// Set check time limit to default
-	CheckTimeout = defaultCheckTimeout
+	CheckTimeout = dnsresolution.DefaultTimeout
 	// Get the deadline time in unix from the env var
 	timeDeadline, err := checkclient.GetDeadline()
 	if err != nil {
@@ -65,6 +64,27 @@ func init() {
 	CheckTimeout = timeDeadline.Sub(time.Now().Add(time.Second * 5))
 	log.Infoln("Check time limit set to:", CheckTimeout)
 
+	resolutionLookups = dnsresolution.ResolutionLookupsFromEnv()
+	for _, lookup := range resolutionLookups {
+		log.Infoln("Looking up", lookup, "along the resolution path")
+	}
+
+	dnsEndpoints, dnsEndpointsErr = dnsresolution.ParseDNSEndpoints(os.Getenv("DNS_ENDPOINTS"))
+	if dnsEndpointsErr != nil {
+		log.Errorln("ERROR: Failed to parse the DNS_ENDPOINTS environment variable:", dnsEndpointsErr)
+	}
+	for _, e := range dnsEndpoints {
+		log.Infoln("Looking up", e)
+	}
+
+	maxLookupLatency, maxLookupLatencyErr = dnsresolution.MaxLookupLatencyFromEnv()
+	if maxLookupLatencyErr != nil {
+		log.Errorln("ERROR: Failed to parse the MAX_LOOKUP_LATENCY environment variable:", maxLookupLatencyErr)
+	}
+	if maxLookupLatency > 0 {
+		log.Infoln("Lookups can take at most", maxLookupLatency)
+	}
+
 	Hostname = os.Getenv("HOSTNAME")
 	if len(Hostname) == 0 {
 		log.Errorln("ERROR: The ENDPOINT environment variable has not been set.")
@@ -87,17 +107,29 @@ func init() {
 	if len(labelSelector) > 0 {
 		log.Infoln("Looking for DNS pods with label:", labelSelector)
 	}
-
-	now = time.Now()
 }
 
 func main() {
+	if dnsEndpointsErr != nil {
+		_ = reportKHFailure([]string{"DNS Status check has an invalid DNS_ENDPOINTS: " + dnsEndpointsErr.Error()}, nil)
+		return
+	}
+	if maxLookupLatencyErr != nil {
+		_ = reportKHFailure([]string{"DNS Status check has an invalid MAX_LOOKUP_LATENCY: " + maxLookupLatencyErr.Error()}, nil)
+		return
+	}
+
 	client, err := kubeClient.Create(KubeConfigFile)
 	if err != nil {
 		log.Fatalln("Unable to create kubernetes client", err)
 	}
 
-	dc := New()
+	dc := dnsresolution.NewDNSResolutionChecker(client, Hostname, CheckTimeout)
+	dc.Namespace = namespace
+	dc.LabelSelector = labelSelector
+	dc.ResolutionLookups = resolutionLookups
+	dc.Endpoints = dnsEndpoints
+	dc.MaxLookupLatency = maxLookupLatency
 
 	// Since this check runs often and very quickly, run all nodeChecks to make sure that:
 	// - check doesn't run on a node that's too young
@@ -118,163 +150,31 @@ func main() {
 		log.Errorln("Error waiting for Kuberhealthy to be ready:", err)
 	}
 
-	err = dc.Run(client)
+	err = run(dc)
 	if err != nil {
 		log.Errorln("Error running DNS Status check for hostname:", Hostname)
 	}
 	log.Infoln("Done running DNS Status check for hostname:", Hostname)
 }
 
-// New returns a new DNS Checker
-func New() *Checker {
-	return &Checker{
-		Hostname:         Hostname,
-		MaxTimeInFailure: maxTimeInFailure,
-	}
-}
-
-// Run implements the entrypoint for check execution
-func (dc *Checker) Run(client *kubernetes.Clientset) error {
-	log.Infoln("Running DNS status checker")
-	doneChan := make(chan error)
-
-	dc.client = client
-	// run the check in a goroutine and notify the doneChan when completed
-	go func(doneChan chan error) {
-		err := dc.doChecks()
-		doneChan <- err
-	}(doneChan)
-
-	// wait for either a timeout or job completion
-	select {
-	case <-time.After(CheckTimeout):
-		// The check has timed out after its specified timeout period
-		errorMessage := "Failed to complete DNS Status check in time! Timeout was reached."
-		err := checkclient.ReportFailure([]string{errorMessage})
-		if err != nil {
-			log.Println("Error reporting failure to Kuberhealthy servers:", err)
-			return err
-		}
-		return err
-	case err := <-doneChan:
-		if err != nil {
-			return reportKHFailure(err.Error())
-		}
-		return reportKHSuccess()
-	}
-}
-
-// create a Resolver object to use for DNS queries
-func createResolver(ip string) (*net.Resolver, error) {
-	r := &net.Resolver{}
-	// if we're supplied a null string, return an error
-	if len(ip) < 1 {
-		return r, errors.New("Need a valid ip to create Resolver")
-	}
-	// attempt to create the resolver based on the string
-	r = &net.Resolver{
-		PreferGo: true,
-		Dial: func(ctx context.Context, network, address2 string) (net.Conn, error) {
-			d := net.Dialer{
-				Timeout: time.Millisecond * time.Duration(10000),
-			}
-			return d.DialContext(ctx, "udp", ip+":53")
-		},
-	}
-	return r, nil
-}
-
-func getIpsFromEndpoint(endpoints *v1.EndpointsList) ([]string, error) {
-	var ipList []string
-	if len(endpoints.Items) == 0 {
-		return ipList, errors.New("No endpoints found")
-	}
-	// loop through endpoint list, subsets, and finally addresses to get backend DNS ip's to query
-	for ep := 0; ep < len(endpoints.Items); ep++ {
-		for sub := 0; sub < len(endpoints.Items[ep].Subsets); sub++ {
-			for address := 0; address < len(endpoints.Items[ep].Subsets[sub].Addresses); address++ {
-				// create resolver based on backends found in the dns endpoint
-				ipList = append(ipList, endpoints.Items[ep].Subsets[sub].Addresses[address].IP)
-			}
-		}
-	}
-	if len(ipList) != 0 {
-		return ipList, nil
-	}
-	return ipList, errors.New("No Ip's found in endpoints list")
-}
-
-func dnsLookup(r *net.Resolver, host string) error {
-	_, err := r.LookupHost(context.Background(), host)
-	if err != nil {
-		errorMessage := "DNS Status check determined that " + host + " is DOWN: " + err.Error()
-		return errors.New(errorMessage)
-	}
-	return nil
-}
-
-func (dc *Checker) checkEndpoints() error {
-	endpoints, err := dc.client.CoreV1().Endpoints(namespace).List(context.Background(), metav1.ListOptions{LabelSelector: labelSelector})
-	if err != nil {
-		message := "DNS status check unable to get dns endpoints from cluster: " + err.Error()
-		log.Errorln(message)
-		return errors.New(message)
-	}
-
-	//get ips from endpoint list to check
-	ips, err := getIpsFromEndpoint(endpoints)
-	if err != nil {
-		return err
+// run runs the check and reports its result to Kuberhealthy
+func run(dc *dnsresolution.Checker) error {
+	errs, details := dc.Check()
+	if len(errs) > 0 {
+		return reportKHFailure(errs, details)
 	}
-
-	//given that we got valid ips from endpoint list, parse them
-	if len(ips) > 0 {
-		for ip := 0; ip < len(ips); ip++ {
-			//create a resolver for each ip and return any error
-			r, err := createResolver(ips[ip])
-			if err != nil {
-				return err
-			}
-			//run a lookup for each ip if we successfully created a resolver, return error
-			err = dnsLookup(r, dc.Hostname)
-			if err != nil {
-				return err
-			}
-		}
-		log.Infoln("DNS Status check from service endpoint determined that", dc.Hostname, "was OK.")
-		return nil
-	}
-	return errors.New("No ips found in endpoint with label: " + labelSelector)
+	return reportKHSuccess(details)
 }
 
-// doChecks does validations on the DNS call to the endpoint
-func (dc *Checker) doChecks() error {
-
-	log.Infoln("DNS Status check testing hostname:", dc.Hostname)
-
-	// if there's a label selector, do checks against endpoints
-	if len(labelSelector) > 0 {
-		err := dc.checkEndpoints()
-		if err != nil {
-			return err
-		}
-		return nil
+// reportKHSuccess reports success to Kuberhealthy servers, along with any details, and verifies the report
+// successfully went through
+func reportKHSuccess(details map[string]string) error {
+	var err error
+	if len(details) > 0 {
+		err = checkclient.ReportSuccessWithDetails(details)
+	} else {
+		err = checkclient.ReportSuccess()
 	}
-
-	// otherwise do lookup against service endpoint
-	_, err := net.LookupHost(dc.Hostname)
-	if err != nil {
-		errorMessage := "DNS Status check determined that " + dc.Hostname + " is DOWN: " + err.Error()
-		log.Errorln(errorMessage)
-		return errors.New(errorMessage)
-	}
-	log.Infoln("DNS Status check from service endpoint determined that", dc.Hostname, "was OK.")
-	return nil
-}
-
-// reportKHSuccess reports success to Kuberhealthy servers and verifies the report successfully went through
-func reportKHSuccess() error {
-	err := checkclient.ReportSuccess()
 	if err != nil {
 		log.Println("Error reporting success to Kuberhealthy servers:", err)
 		return err
@@ -283,9 +183,15 @@ func reportKHSuccess() error {
 	return err
 }
 
-// reportKHFailure reports failure to Kuberhealthy servers and verifies the report successfully went through
-func reportKHFailure(errorMessage string) error {
-	err := checkclient.ReportFailure([]string{errorMessage})
+// reportKHFailure reports failure to Kuberhealthy servers, along with any details, and verifies the report
+// successfully went through
+func reportKHFailure(errorMessages []string, details map[string]string) error {
+	var err error
+	if len(details) > 0 {
+		err = checkclient.ReportFailureWithDetails(errorMessages, details)
+	} else {
+		err = checkclient.ReportFailure(errorMessages)
+	}
 	if err != nil {
 		log.Println("Error reporting failure to Kuberhealthy servers:", err)
 		return err
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// checkLabel labels the configmaps created by the check, so that those left behind by earlier runs can be found
+	// and removed
+	checkLabel = "kuberhealthy-etcd-check"
+
+	// cleanupTimeout is how long removing the configmap can take after the run
+	cleanupTimeout = time.Second * 30
+)
+
+// checker writes, reads and deletes a configmap to exercise the etcd write path through the API server, and
+// optionally checks the etcd readiness checks of the API server
+type checker struct {
+	namespace        string
+	latencyThreshold time.Duration // how long writing, reading and deleting the configmap can take together
+	checkReadyz      bool
+	client           kubernetes.Interface
+
+	// readyz returns the body of the verbose readiness endpoint of the API server
+	readyz func(ctx context.Context) ([]byte, error)
+}
+
+// run exercises the etcd write path and checks the etcd readiness checks of the API server.  The errors of the run
+// are returned.
+func (c *checker) run(ctx context.Context) []string {
+	var errs []string
+	err := c.writePath(ctx)
+	if err != nil {
+		errs = append(errs, err.Error())
+	}
+	if c.checkReadyz {
+		errs = append(errs, c.readyzErrors(ctx)...)
+	}
+	return errs
+}
+
+// writePath creates a configmap, reads it back and deletes it again.  An error is returned when any of the requests
+// fail, when the configmap read back differs from the one written or when the requests take longer than the latency
+// threshold.
+func (c *checker) writePath(ctx context.Context) error {
+	err := c.cleanUp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to remove the configmaps of earlier runs in namespace %s: %w", c.namespace, err)
+	}
+
+	name := "etcd-check-" + strconv.FormatInt(time.Now().Unix(), 10)
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		err := c.cleanUp(cleanupCtx)
+		if err != nil {
+			log.Errorln("Failed to remove the configmap of this run:", err)
+		}
+	}()
+
+	start := time.Now()
+	_, err = c.client.CoreV1().ConfigMaps(c.namespace).Create(ctx, &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.namespace,
+			Labels:    map[string]string{checkLabel: name},
+		},
+		Data: map[string]string{"run": name},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("Failed to create configmap %s in namespace %s: %w", name, c.namespace, err)
+	}
+	created := time.Since(start)
+
+	cm, err := c.client.CoreV1().ConfigMaps(c.namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("Failed to read back configmap %s in namespace %s: %w", name, c.namespace, err)
+	}
+	if cm.Data["run"] != name {
+		return fmt.Errorf("Configmap %s in namespace %s was read back with data %q instead of %q", name, c.namespace, cm.Data["run"], name)
+	}
+	read := time.Since(start) - created
+
+	err = c.client.CoreV1().ConfigMaps(c.namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("Failed to delete configmap %s in namespace %s: %w", name, c.namespace, err)
+	}
+	elapsed := time.Since(start)
+	log.Infoln("Created configmap", name, "in", created, "read it back in", read, "and deleted it in", elapsed-created-read)
+
+	if elapsed > c.latencyThreshold {
+		return fmt.Errorf("Writing, reading and deleting configmap %s in namespace %s took %s, which is above the threshold of %s", name, c.namespace, elapsed.Round(time.Millisecond), c.latencyThreshold)
+	}
+	return nil
+}
+
+// readyzErrors returns an error for each failed etcd readiness check of the API server
+func (c *checker) readyzErrors(ctx context.Context) []string {
+	// the API server responds with an error status when it is not ready, but still lists its readiness checks
+	body, err := c.readyz(ctx)
+	if err != nil && len(body) == 0 {
+		return []string{"Failed to query the readiness of the API server: " + err.Error()}
+	}
+	failed, found := failedEtcdChecks(string(body))
+	if !found {
+		log.Warnln("The API server readiness endpoint does not list any etcd checks")
+	}
+	var errs []string
+	for _, check := range failed {
+		errs = append(errs, "API server readiness check "+check)
+	}
+	return errs
+}
+
+// failedEtcdChecks parses the body of the verbose readiness endpoint of the API server, which has a line such as
+// "[+]etcd ok" or "[-]etcd failed: reason withheld" for each check.  The failed etcd checks are returned along with
+// whether any etcd check was listed.
+func failedEtcdChecks(body string) ([]string, bool) {
+	var failed []string
+	var found bool
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) < 3 || !strings.Contains(line, "etcd") {
+			continue
+		}
+		switch line[:3] {
+		case "[+]":
+			found = true
+		case "[-]":
+			found = true
+			failed = append(failed, line[3:])
+		}
+	}
+	return failed, found
+}
+
+// cleanUp removes the configmaps created by the check
+func (c *checker) cleanUp(ctx context.Context) error {
+	configMaps, err := c.client.CoreV1().ConfigMaps(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: checkLabel})
+	if err != nil {
+		return err
+	}
+	for _, cm := range configMaps.Items {
+		log.Infoln("Removing configmap", cm.Name)
+		err := c.client.CoreV1().ConfigMaps(c.namespace).Delete(ctx, cm.Name, metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// readyBody is the verbose readiness endpoint of a healthy API server
+const readyBody = `[+]ping ok
+[+]log ok
+[+]etcd ok
+[+]etcd-readiness ok
+[+]informer-sync ok
+readyz check passed
+`
+
+// newTestChecker returns a checker with a fake client and a readiness endpoint that responds with the supplied body
+// and error
+func newTestChecker(client *fake.Clientset, body string, err error) *checker {
+	return &checker{
+		namespace:        "kuberhealthy-etcd-check",
+		latencyThreshold: time.Second,
+		checkReadyz:      true,
+		client:           client,
+		readyz: func(ctx context.Context) ([]byte, error) {
+			return []byte(body), err
+		},
+	}
+}
+
+// assertCleanedUp ensures the configmaps of the check were removed
+func assertCleanedUp(t *testing.T, client *fake.Clientset) {
+	configMaps, _ := client.CoreV1().ConfigMaps("kuberhealthy-etcd-check").List(context.Background(), metav1.ListOptions{})
+	if len(configMaps.Items) != 0 {
+		t.Fatal("expected the configmaps to be removed but found", len(configMaps.Items))
+	}
+}
+
+func TestCheckerRun(t *testing.T) {
+	// a configmap left behind by an earlier run is removed
+	client := fake.NewSimpleClientset(&apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "etcd-check-1", Namespace: "kuberhealthy-etcd-check", Labels: map[string]string{checkLabel: "etcd-check-1"}},
+	})
+	c := newTestChecker(client, readyBody, nil)
+
+	errs := c.run(context.Background())
+	if len(errs) != 0 {
+		t.Fatal("expected the check to pass but got", errs)
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestCheckerRunWriteFailed(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8sErrors.NewInternalError(errors.New("etcdserver: request timed out"))
+	})
+	c := newTestChecker(client, readyBody, nil)
+
+	errs := c.run(context.Background())
+	if len(errs) != 1 || !strings.Contains(errs[0], "Failed to create configmap") || !strings.Contains(errs[0], "etcdserver: request timed out") {
+		t.Fatal("expected the failed write but got", errs)
+	}
+}
+
+func TestCheckerRunReadMismatch(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("get", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		name := action.(k8stesting.GetAction).GetName()
+		return true, &apiv1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name}, Data: map[string]string{"run": "etcd-check-1"}}, nil
+	})
+	c := newTestChecker(client, readyBody, nil)
+
+	errs := c.run(context.Background())
+	if len(errs) != 1 || !strings.Contains(errs[0], `was read back with data "etcd-check-1"`) {
+		t.Fatal("expected the configmap read back to differ but got", errs)
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestCheckerRunSlow(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("delete", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		time.Sleep(time.Millisecond * 20)
+		return false, nil, nil
+	})
+	c := newTestChecker(client, readyBody, nil)
+	c.latencyThreshold = time.Millisecond * 10
+
+	errs := c.run(context.Background())
+	if len(errs) != 1 || !strings.Contains(errs[0], "which is above the threshold of 10ms") {
+		t.Fatal("expected the write path to be too slow but got", errs)
+	}
+}
+
+func TestCheckerRunReadyz(t *testing.T) {
+	// the API server responds with an error status when a readiness check fails
+	body := strings.Replace(readyBody, "[+]etcd ok", "[-]etcd failed: reason withheld", 1)
+	c := newTestChecker(fake.NewSimpleClientset(), body, errors.New("the server is currently unable to handle the request"))
+	errs := c.run(context.Background())
+	if len(errs) != 1 || errs[0] != "API server readiness check etcd failed: reason withheld" {
+		t.Fatal("expected the failed etcd readiness check but got", errs)
+	}
+
+	c = newTestChecker(fake.NewSimpleClientset(), "", errors.New("forbidden"))
+	errs = c.run(context.Background())
+	if len(errs) != 1 || !strings.Contains(errs[0], "Failed to query the readiness of the API server: forbidden") {
+		t.Fatal("expected the failed readiness request but got", errs)
+	}
+
+	c.checkReadyz = false
+	errs = c.run(context.Background())
+	if len(errs) != 0 {
+		t.Fatal("expected the readiness endpoint not to be checked but got", errs)
+	}
+}
+
+func TestFailedEtcdChecks(t *testing.T) {
+	failed, found := failedEtcdChecks("[-]etcd failed: reason withheld\n[+]etcd-readiness ok\n[-]informer-sync failed: reason withheld\n")
+	if !found || len(failed) != 1 || failed[0] != "etcd failed: reason withheld" {
+		t.Fatal("expected only the failed etcd check but got", failed, found)
+	}
+
+	_, found = failedEtcdChecks("[+]ping ok\nreadyz check passed\n")
+	if found {
+		t.Fatal("expected no etcd checks to be found")
+	}
+}
@@ -0,0 +1,115 @@
+// Package main implements a check that exercises the etcd write path through the API server and checks the etcd
+// readiness checks of the API server.
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	checkclient "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/nodeCheck"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
+)
+
+const (
+	// defaultNamespace is the dedicated namespace the configmap is written to
+	defaultNamespace = "kuberhealthy-etcd-check"
+
+	// defaultLatencyThreshold is how long writing, reading and deleting the configmap can take together
+	defaultLatencyThreshold = time.Second * 2
+)
+
+// kubeConfigFile is a variable containing file path of Kubernetes config files
+var kubeConfigFile = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+
+func init() {
+	// set debug mode for nodeCheck pkg
+	nodeCheck.EnableDebugOutput()
+}
+
+func main() {
+	checker, err := newCheckerFromEnv()
+	if err != nil {
+		log.Errorln("Error parsing the check configuration:", err)
+		reportToKuberhealthy([]string{err.Error()})
+		return
+	}
+
+	// leave time to clean up and report before the deadline of the run
+	ctx := context.Background()
+	deadline, err := checkclient.GetDeadline()
+	if err != nil {
+		log.Warnln("There was an issue getting the check deadline:", err.Error())
+	} else {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-time.Second*30))
+		defer cancel()
+	}
+
+	client, err := kubeClient.Create(kubeConfigFile)
+	if err != nil {
+		reportToKuberhealthy([]string{"Failed to create a kubernetes client: " + err.Error()})
+		return
+	}
+	checker.client = client
+	checker.readyz = func(ctx context.Context) ([]byte, error) {
+		return client.Discovery().RESTClient().Get().AbsPath("/readyz").Param("verbose", "true").DoRaw(ctx)
+	}
+
+	// hits kuberhealthy endpoint to see if node is ready
+	err = nodeCheck.WaitForKuberhealthy(ctx)
+	if err != nil {
+		log.Errorln("Error waiting for kuberhealthy endpoint to be contactable by checker pod with error:" + err.Error())
+	}
+
+	reportToKuberhealthy(checker.run(ctx))
+}
+
+// newCheckerFromEnv creates a checker from the environment variables of the checker pod
+func newCheckerFromEnv() (*checker, error) {
+	c := &checker{
+		namespace:        defaultNamespace,
+		latencyThreshold: defaultLatencyThreshold,
+		checkReadyz:      true,
+	}
+	if namespace := os.Getenv("CHECK_NAMESPACE"); len(namespace) > 0 {
+		c.namespace = namespace
+	}
+
+	var err error
+	if threshold := os.Getenv("LATENCY_THRESHOLD"); len(threshold) > 0 {
+		c.latencyThreshold, err = time.ParseDuration(threshold)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if readyz := os.Getenv("CHECK_READYZ"); len(readyz) > 0 {
+		c.checkReadyz, err = strconv.ParseBool(readyz)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	log.Infoln("Writing to namespace", c.namespace, "with a latency threshold of", c.latencyThreshold, "and readiness checks enabled:", c.checkReadyz)
+	return c, nil
+}
+
+// reportToKuberhealthy reports the errors of the run to Kuberhealthy, or success when there are none
+func reportToKuberhealthy(errorMessages []string) {
+	var err error
+	if len(errorMessages) == 0 {
+		err = checkclient.ReportSuccess()
+	} else {
+		log.Errorln("Reporting errors to Kuberhealthy:", errorMessages)
+		err = checkclient.ReportFailure(errorMessages)
+	}
+	if err != nil {
+		log.Fatalln("error reporting to kuberhealthy:", err.Error())
+	}
+	log.Infoln("Successfully reported to Kuberhealthy")
+}
@@ -0,0 +1,168 @@
+// Package main implements kubectl-kuberhealthy, a kubectl plugin for day-two operations on Kuberhealthy checks.
+// Installed on the PATH, it runs as kubectl kuberhealthy.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/integrii/flaggy"
+	"k8s.io/client-go/kubernetes"
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
+	"k8s.io/client-go/tools/clientcmd"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// kubeConfigFile is the kubeconfig to use instead of the default loading rules of kubectl
+var kubeConfigFile string
+
+// kubeContext is the kubeconfig context to use instead of the current context
+var kubeContext string
+
+// namespace is the namespace of the checks.  The namespace of the kubeconfig context is used when blank.
+var namespace string
+
+// allNamespaces lists the checks of every namespace
+var allNamespaces bool
+
+// requestTimeout limits how long the plugin waits for the Kubernetes API and the Kuberhealthy web server
+var requestTimeout = time.Second * 30
+
+// logLines is the number of lines of checker pod logs shown by describe
+var logLines = 50
+
+// kuberhealthyURL is the Kuberhealthy web server that check runs are triggered on
+var kuberhealthyURL string
+
+// kuberhealthyToken is the bearer token sent to the Kuberhealthy web server.  The token of the kubeconfig is used when
+// blank.
+var kuberhealthyToken string
+
+func main() {
+	flaggy.SetName("kubectl kuberhealthy")
+	flaggy.SetDescription("Lists, inspects, runs and pauses Kuberhealthy checks.")
+	flaggy.String(&kubeConfigFile, "", "kubeconfig", "The kubeconfig file to use.")
+	flaggy.String(&kubeContext, "", "context", "The kubeconfig context to use.")
+	flaggy.String(&namespace, "n", "namespace", "The namespace of the checks. Defaults to the namespace of the kubeconfig context.")
+	flaggy.Duration(&requestTimeout, "", "request-timeout", "How long to wait for the Kubernetes API and the Kuberhealthy web server.")
+
+	list := flaggy.NewSubcommand("list")
+	list.Description = "Lists checks with their current state. Lists every namespace unless --namespace is set."
+	list.Bool(&allNamespaces, "A", "all-namespaces", "List the checks of every namespace, even when --namespace is set.")
+	flaggy.AttachSubcommand(list, 1)
+
+	var checkName string
+	describe := flaggy.NewSubcommand("describe")
+	describe.Description = "Shows the details of a check, including its last errors and the logs of its last checker pod."
+	describe.AddPositionalValue(&checkName, "check", 1, true, "The name of the check.")
+	describe.Int(&logLines, "", "tail", "The number of lines of checker pod logs to show. Set to 0 to leave out the logs.")
+	flaggy.AttachSubcommand(describe, 1)
+
+	run := flaggy.NewSubcommand("run")
+	run.Description = "Starts a run of a check right away through the Kuberhealthy web server."
+	run.AddPositionalValue(&checkName, "check", 1, true, "The name of the check.")
+	run.String(&kuberhealthyURL, "", "url", "The URL of the Kuberhealthy web server, such as http://localhost:8080 while port-forwarding to the kuberhealthy service.")
+	run.String(&kuberhealthyToken, "", "token", "The bearer token for the Kuberhealthy web server. Defaults to the token of the kubeconfig.")
+	flaggy.AttachSubcommand(run, 1)
+
+	pause := flaggy.NewSubcommand("pause")
+	pause.Description = "Pauses a check with its comcast.github.io/paused annotation."
+	pause.AddPositionalValue(&checkName, "check", 1, true, "The name of the check.")
+	flaggy.AttachSubcommand(pause, 1)
+
+	resume := flaggy.NewSubcommand("resume")
+	resume.Description = "Resumes a check paused with its comcast.github.io/paused annotation."
+	resume.AddPositionalValue(&checkName, "check", 1, true, "The name of the check.")
+	flaggy.AttachSubcommand(resume, 1)
+
+	flaggy.Parse()
+
+	p, err := newPlugin()
+	if err != nil {
+		fail(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	switch {
+	case list.Used:
+		listNamespace := namespace
+		if allNamespaces {
+			listNamespace = ""
+		}
+		err = p.list(listNamespace)
+	case describe.Used:
+		err = p.describe(ctx, p.namespace, checkName, logLines)
+	case run.Used:
+		err = p.run(ctx, p.namespace, checkName, kuberhealthyURL, kuberhealthyToken)
+	case pause.Used:
+		err = p.setPaused(p.namespace, checkName, true)
+	case resume.Used:
+		err = p.setPaused(p.namespace, checkName, false)
+	default:
+		flaggy.ShowHelpAndExit("")
+	}
+	if err != nil {
+		cancel()
+		fail(err)
+	}
+}
+
+// newPlugin creates the Kubernetes clients of the plugin with the same kubeconfig loading rules as kubectl
+func newPlugin() (*plugin, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeConfigFile
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+	}
+	defaultNamespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine the namespace of the kubeconfig context: %w", err)
+	}
+	if len(namespace) > 0 {
+		defaultNamespace = namespace
+	}
+
+	kubeClient, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	checkClient, err := khcheckv1.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create khcheck client: %w", err)
+	}
+	stateClient, err := khstatev1.NewForConfig(restConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create khstate client: %w", err)
+	}
+	token := restConfig.BearerToken
+	if len(token) == 0 && len(restConfig.BearerTokenFile) > 0 {
+		b, err := os.ReadFile(restConfig.BearerTokenFile)
+		if err == nil {
+			token = string(b)
+		}
+	}
+
+	return &plugin{
+		kubeClient:  kubeClient,
+		checkClient: checkClient,
+		stateClient: stateClient,
+		namespace:   defaultNamespace,
+		kubeToken:   token,
+		out:         os.Stdout,
+	}, nil
+}
+
+// fail prints an error the way kubectl does and exits
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(1)
+}
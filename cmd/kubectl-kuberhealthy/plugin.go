@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/kubernetes"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// pausedAnnotation pauses a khcheck when set to true.  It is read by Kuberhealthy the same way as the paused field.
+const pausedAnnotation = "comcast.github.io/paused"
+
+// checkNameLabel is the label Kuberhealthy sets on checker pods with the name of their check
+const checkNameLabel = "kuberhealthy-check-name"
+
+// runClient triggers check runs on the Kuberhealthy web server
+var runClient = &http.Client{}
+
+// plugin holds the clients the commands of the plugin use
+type plugin struct {
+	kubeClient  kubernetes.Interface
+	checkClient khcheckv1.KuberhealthyChecksGetter
+	stateClient khstatev1.KuberhealthyStatesGetter
+	namespace   string    // the namespace of the checks when none is given
+	kubeToken   string    // the bearer token of the kubeconfig
+	out         io.Writer // where command output is written
+}
+
+// list writes a table of the checks in a namespace, or in every namespace when the namespace is blank, with their
+// current state
+func (p *plugin) list(namespace string) error {
+	checks, err := p.checkClient.KuberhealthyChecks(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list khchecks: %w", err)
+	}
+	states, err := p.stateClient.KuberhealthyStates(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list khstates: %w", err)
+	}
+	stateByCheck := make(map[string]khstatev1.KuberhealthyState)
+	for _, state := range states.Items {
+		stateByCheck[state.Namespace+"/"+state.Name] = state
+	}
+
+	sort.Slice(checks.Items, func(i, j int) bool {
+		if checks.Items[i].Namespace != checks.Items[j].Namespace {
+			return checks.Items[i].Namespace < checks.Items[j].Namespace
+		}
+		return checks.Items[i].Name < checks.Items[j].Name
+	})
+	if len(checks.Items) == 0 {
+		if len(namespace) == 0 {
+			fmt.Fprintln(p.out, "No khchecks found.")
+		} else {
+			fmt.Fprintln(p.out, "No khchecks found in", namespace, "namespace.")
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(p.out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tSTATUS\tLAST RUN\tDURATION\tLAST ERROR")
+	now := time.Now()
+	for _, kc := range checks.Items {
+		state, exists := stateByCheck[kc.Namespace+"/"+kc.Name]
+		var stateSpec *khstatev1.WorkloadDetails
+		if exists {
+			stateSpec = &state.Spec
+		}
+		lastRun, runDuration, lastError := "<none>", "", ""
+		if stateSpec != nil {
+			if stateSpec.LastRun != nil && !stateSpec.LastRun.IsZero() {
+				lastRun = duration.HumanDuration(now.Sub(stateSpec.LastRun.Time)) + " ago"
+			}
+			runDuration = stateSpec.RunDuration
+			if !stateSpec.OK {
+				lastError = stateSpec.LastError
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", kc.Namespace, kc.Name, checkStatus(kc, stateSpec), lastRun, runDuration, lastError)
+	}
+	return w.Flush()
+}
+
+// checkStatus summarizes the state of a check in one word.  The details are nil when the check has no khstate yet.
+func checkStatus(kc khcheckv1.KuberhealthyCheck, details *khstatev1.WorkloadDetails) string {
+	if checkPaused(kc) || (details != nil && details.Paused) {
+		return "Paused"
+	}
+	if details == nil || (details.LastRun == nil && len(details.AuthoritativePod) == 0) {
+		return "Pending"
+	}
+	if details.OK {
+		return "OK"
+	}
+	if details.Suppressed || len(details.SuppressedByDependencies) > 0 {
+		return "Suppressed"
+	}
+	if details.Severity == "Warning" {
+		return "Warning"
+	}
+	return "Failed"
+}
+
+// checkPaused determines if a khcheck is paused by its paused field or its paused annotation, the same way
+// Kuberhealthy does
+func checkPaused(kc khcheckv1.KuberhealthyCheck) bool {
+	if kc.Spec.Paused {
+		return true
+	}
+	paused, err := strconv.ParseBool(kc.Annotations[pausedAnnotation])
+	return err == nil && paused
+}
+
+// describe writes the details of a check, its last errors, the logs captured when it last failed and the end of the
+// logs of its most recent checker pod
+func (p *plugin) describe(ctx context.Context, namespace string, name string, tailLines int) error {
+	kc, err := p.checkClient.KuberhealthyChecks(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get khcheck %s in namespace %s: %w", name, namespace, err)
+	}
+	var details *khstatev1.WorkloadDetails
+	state, err := p.stateClient.KuberhealthyStates(namespace).Get(name, metav1.GetOptions{})
+	switch {
+	case err == nil:
+		details = &state.Spec
+	case errors.IsNotFound(err):
+	default:
+		return fmt.Errorf("failed to get khstate %s in namespace %s: %w", name, namespace, err)
+	}
+	writeCheckDetails(p.out, kc, details, time.Now())
+
+	if tailLines <= 0 {
+		return nil
+	}
+	pod, err := latestCheckerPod(ctx, p.kubeClient, namespace, name)
+	if err != nil {
+		return err
+	}
+	if pod == nil {
+		fmt.Fprintln(p.out, "\nChecker Pod Logs: <no checker pods found>")
+		return nil
+	}
+	fmt.Fprintf(p.out, "\nChecker Pod Logs (%s, last %d lines):\n", pod.Name, tailLines)
+	lines := int64(tailLines)
+	logs, err := p.kubeClient.CoreV1().Pods(namespace).GetLogs(pod.Name, &corev1.PodLogOptions{TailLines: &lines}).DoRaw(ctx)
+	if err != nil {
+		fmt.Fprintln(p.out, "  <failed to get logs:", err.Error()+">")
+		return nil
+	}
+	writeIndented(p.out, string(logs))
+	return nil
+}
+
+// writeCheckDetails writes the configuration and state of a check.  The details are nil when the check has no khstate
+// yet.
+func writeCheckDetails(out io.Writer, kc khcheckv1.KuberhealthyCheck, details *khstatev1.WorkloadDetails, now time.Time) {
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", kc.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", kc.Namespace)
+	fmt.Fprintf(w, "Status:\t%s\n", checkStatus(kc, details))
+	if len(kc.Spec.Schedule) > 0 {
+		fmt.Fprintf(w, "Schedule:\t%s\n", kc.Spec.Schedule)
+	} else {
+		fmt.Fprintf(w, "Run Interval:\t%s\n", kc.Spec.RunInterval)
+	}
+	fmt.Fprintf(w, "Timeout:\t%s\n", kc.Spec.Timeout)
+	if len(kc.Spec.DependsOn) > 0 {
+		fmt.Fprintf(w, "Depends On:\t%s\n", strings.Join(kc.Spec.DependsOn, ", "))
+	}
+	if details == nil {
+		fmt.Fprintf(w, "Last Run:\t<none>\n")
+		w.Flush()
+		return
+	}
+	if details.LastRun != nil && !details.LastRun.IsZero() {
+		fmt.Fprintf(w, "Last Run:\t%s (%s ago)\n", details.LastRun.UTC().Format(time.RFC3339), duration.HumanDuration(now.Sub(details.LastRun.Time)))
+	} else {
+		fmt.Fprintf(w, "Last Run:\t<none>\n")
+	}
+	fmt.Fprintf(w, "Run Duration:\t%s\n", details.RunDuration)
+	fmt.Fprintf(w, "Node:\t%s\n", details.Node)
+	fmt.Fprintf(w, "Authoritative Pod:\t%s\n", details.AuthoritativePod)
+	if len(details.Severity) > 0 {
+		fmt.Fprintf(w, "Severity:\t%s\n", details.Severity)
+	}
+	if details.ConsecutiveFailures > 0 {
+		fmt.Fprintf(w, "Consecutive Failures:\t%d\n", details.ConsecutiveFailures)
+	}
+	if len(details.SuppressedByDependencies) > 0 {
+		fmt.Fprintf(w, "Suppressed By:\t%s\n", strings.Join(details.SuppressedByDependencies, ", "))
+	}
+	w.Flush()
+
+	if len(details.Errors) > 0 {
+		fmt.Fprintln(out, "Errors:")
+		for _, e := range details.Errors {
+			fmt.Fprintln(out, "  -", e)
+		}
+	}
+	if len(details.History) > 0 {
+		fmt.Fprintln(out, "History:")
+		hw := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+		fmt.Fprintln(hw, "  TIME\tOK\tDURATION\tERRORS")
+		for _, result := range details.History {
+			fmt.Fprintf(hw, "  %s\t%t\t%s\t%s\n", result.Time.UTC().Format(time.RFC3339), result.OK, result.RunDuration, strings.Join(result.Errors, "; "))
+		}
+		hw.Flush()
+	}
+	if len(details.LogExcerpt) > 0 {
+		fmt.Fprintln(out, "\nLogs Captured At Last Failure:")
+		writeIndented(out, details.LogExcerpt)
+	}
+}
+
+// writeIndented writes text indented by two spaces, ending with a newline
+func writeIndented(out io.Writer, text string) {
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		fmt.Fprintln(out, "  "+line)
+	}
+}
+
+// latestCheckerPod returns the most recently created checker pod of a check, or nil when it has none
+func latestCheckerPod(ctx context.Context, client kubernetes.Interface, namespace string, name string) (*corev1.Pod, error) {
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: checkNameLabel + "=" + name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checker pods of check %s in namespace %s: %w", name, namespace, err)
+	}
+	var latest *corev1.Pod
+	for i := range pods.Items {
+		if latest == nil || latest.CreationTimestamp.Before(&pods.Items[i].CreationTimestamp) {
+			latest = &pods.Items[i]
+		}
+	}
+	return latest, nil
+}
+
+// run triggers a run of a check on the /run endpoint of the Kuberhealthy web server.  The Kubernetes API server
+// removes credentials from requests it proxies, and runs require authentication, so the web server is reached
+// directly, such as through kubectl port-forward.
+func (p *plugin) run(ctx context.Context, namespace string, name string, serverURL string, token string) error {
+	if len(serverURL) == 0 {
+		return fmt.Errorf("--url is required to trigger runs, such as http://localhost:8080 while running kubectl -n kuberhealthy port-forward service/kuberhealthy 8080:80")
+	}
+	if len(token) == 0 {
+		token = p.kubeToken
+	}
+	u := strings.TrimRight(serverURL, "/") + "/run/" + url.PathEscape(namespace) + "/" + url.PathEscape(name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create run request for %s: %w", u, err)
+	}
+	if len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(token))
+	}
+	resp, err := runClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to trigger a run of check %s in namespace %s: %w", name, namespace, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("kuberhealthy refused to run check %s in namespace %s: %s: %s", name, namespace, resp.Status, strings.TrimSpace(string(body)))
+	}
+	fmt.Fprintln(p.out, "khcheck", namespace+"/"+name, "run triggered")
+	return nil
+}
+
+// setPaused pauses or resumes a check by setting its paused annotation.  A check paused by its paused field stays
+// paused until the field is changed.
+func (p *plugin) setPaused(namespace string, name string, paused bool) error {
+	patch := []byte(`{"metadata":{"annotations":{"` + pausedAnnotation + `":"` + strconv.FormatBool(paused) + `"}}}`)
+	kc, err := p.checkClient.KuberhealthyChecks(namespace).Patch(name, types.MergePatchType, patch)
+	if err != nil {
+		return fmt.Errorf("failed to set the %s annotation of khcheck %s in namespace %s: %w", pausedAnnotation, name, namespace, err)
+	}
+	if paused {
+		fmt.Fprintln(p.out, "khcheck", namespace+"/"+name, "paused")
+		return nil
+	}
+	if kc.Spec.Paused {
+		return fmt.Errorf("khcheck %s in namespace %s is still paused by its spec.paused field", name, namespace)
+	}
+	fmt.Fprintln(p.out, "khcheck", namespace+"/"+name, "resumed")
+	return nil
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// newTestPlugin creates a plugin whose khcheck and khstate clients are served by handler
+func newTestPlugin(t *testing.T, handler http.HandlerFunc) (*plugin, *bytes.Buffer) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	checkClient, err := khcheckv1.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stateClient, err := khstatev1.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &bytes.Buffer{}
+	return &plugin{
+		kubeClient:  fake.NewSimpleClientset(),
+		checkClient: checkClient,
+		stateClient: stateClient,
+		namespace:   "kuberhealthy",
+		out:         out,
+	}, out
+}
+
+func TestCheckStatus(t *testing.T) {
+	lastRun := metav1.Now()
+	tests := []struct {
+		name     string
+		check    khcheckv1.KuberhealthyCheck
+		details  *khstatev1.WorkloadDetails
+		expected string
+	}{
+		{name: "no khstate", expected: "Pending"},
+		{name: "never ran", details: &khstatev1.WorkloadDetails{}, expected: "Pending"},
+		{name: "ok", details: &khstatev1.WorkloadDetails{OK: true, LastRun: &lastRun}, expected: "OK"},
+		{name: "failed", details: &khstatev1.WorkloadDetails{LastRun: &lastRun}, expected: "Failed"},
+		{name: "warning", details: &khstatev1.WorkloadDetails{LastRun: &lastRun, Severity: "Warning"}, expected: "Warning"},
+		{name: "suppressed", details: &khstatev1.WorkloadDetails{LastRun: &lastRun, SuppressedByDependencies: []string{"dns"}}, expected: "Suppressed"},
+		{name: "paused khstate", details: &khstatev1.WorkloadDetails{LastRun: &lastRun, Paused: true}, expected: "Paused"},
+		{name: "paused field", check: khcheckv1.KuberhealthyCheck{Spec: khcheckv1.CheckConfig{Paused: true}}, expected: "Paused"},
+		{name: "paused annotation", check: khcheckv1.KuberhealthyCheck{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{pausedAnnotation: "true"}}}, details: &khstatev1.WorkloadDetails{OK: true, LastRun: &lastRun}, expected: "Paused"},
+		{name: "resumed annotation", check: khcheckv1.KuberhealthyCheck{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{pausedAnnotation: "false"}}}, details: &khstatev1.WorkloadDetails{OK: true, LastRun: &lastRun}, expected: "OK"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := checkStatus(tt.check, tt.details)
+			if status != tt.expected {
+				t.Fatalf("expected status %s, got %s", tt.expected, status)
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	lastRun := metav1.NewTime(time.Now().Add(-time.Minute * 3))
+	checks := khcheckv1.KuberhealthyCheckList{Items: []khcheckv1.KuberhealthyCheck{
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod-restarts", Namespace: "kuberhealthy"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "dns-status", Namespace: "kuberhealthy"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "deployment", Namespace: "team-a"}},
+	}}
+	states := khstatev1.KuberhealthyStateList{Items: []khstatev1.KuberhealthyState{
+		{ObjectMeta: metav1.ObjectMeta{Name: "dns-status", Namespace: "kuberhealthy"}, Spec: khstatev1.WorkloadDetails{LastRun: &lastRun, RunDuration: "1.2s", LastError: "lookup failed"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "pod-restarts", Namespace: "kuberhealthy"}, Spec: khstatev1.WorkloadDetails{OK: true, LastRun: &lastRun, RunDuration: "3s", LastError: "old error"}},
+	}}
+	p, out := newTestPlugin(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/apis/comcast.github.io/v1/khchecks":
+			json.NewEncoder(w).Encode(checks)
+		case "/apis/comcast.github.io/v1/khstates":
+			json.NewEncoder(w).Encode(states)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	err := p.list("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected a header and 3 checks, got:\n%s", out.String())
+	}
+	expected := [][]string{
+		{"NAMESPACE", "NAME", "STATUS", "LAST", "RUN", "DURATION", "LAST", "ERROR"},
+		{"kuberhealthy", "dns-status", "Failed", "3m", "ago", "1.2s", "lookup", "failed"},
+		{"kuberhealthy", "pod-restarts", "OK", "3m", "ago", "3s"},
+		{"team-a", "deployment", "Pending", "<none>"},
+	}
+	for i, line := range lines {
+		fields := strings.Fields(line)
+		if strings.Join(fields, " ") != strings.Join(expected[i], " ") {
+			t.Errorf("expected line %d to be %q, got %q", i, strings.Join(expected[i], " "), line)
+		}
+	}
+}
+
+func TestWriteCheckDetails(t *testing.T) {
+	now := time.Date(2026, 10, 14, 9, 35, 0, 0, time.UTC)
+	lastRun := metav1.NewTime(now.Add(-time.Minute * 5))
+	kc := khcheckv1.KuberhealthyCheck{
+		ObjectMeta: metav1.ObjectMeta{Name: "dns-status", Namespace: "kuberhealthy"},
+		Spec:       khcheckv1.CheckConfig{RunInterval: "2m", Timeout: "1m", DependsOn: []string{"kube-apiserver"}},
+	}
+	details := &khstatev1.WorkloadDetails{
+		LastRun:             &lastRun,
+		RunDuration:         "1.2s",
+		AuthoritativePod:    "kuberhealthy-abc",
+		Errors:              []string{"lookup of kubernetes.default failed"},
+		ConsecutiveFailures: 2,
+		History:             []khstatev1.RunResult{{Time: lastRun, Errors: []string{"lookup of kubernetes.default failed"}, RunDuration: "1.2s"}},
+		LogExcerpt:          "resolving kubernetes.default\nno such host\n",
+	}
+
+	var out bytes.Buffer
+	writeCheckDetails(&out, kc, details, now)
+	for _, expected := range []string{
+		"Status:               Failed",
+		"Run Interval:         2m",
+		"Depends On:           kube-apiserver",
+		"Last Run:             2026-10-14T09:30:00Z (5m ago)",
+		"Consecutive Failures: 2",
+		"  - lookup of kubernetes.default failed",
+		"  2026-10-14T09:30:00Z   false",
+		"Logs Captured At Last Failure:\n  resolving kubernetes.default\n  no such host\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("expected details to contain %q, got:\n%s", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	writeCheckDetails(&out, kc, nil, now)
+	if !strings.Contains(out.String(), "Status:       Pending") || !strings.Contains(out.String(), "Last Run:     <none>") {
+		t.Errorf("expected a check without a khstate to be pending, got:\n%s", out.String())
+	}
+}
+
+func TestLatestCheckerPod(t *testing.T) {
+	now := time.Now()
+	pod := func(name string, check string, created time.Time) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "kuberhealthy",
+			Labels:            map[string]string{checkNameLabel: check},
+			CreationTimestamp: metav1.NewTime(created),
+		}}
+	}
+	client := fake.NewSimpleClientset(
+		pod("dns-status-old", "dns-status", now.Add(-time.Hour)),
+		pod("dns-status-new", "dns-status", now),
+		pod("pod-restarts-newest", "pod-restarts", now.Add(time.Minute)),
+	)
+
+	latest, err := latestCheckerPod(context.Background(), client, "kuberhealthy", "dns-status")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest == nil || latest.Name != "dns-status-new" {
+		t.Fatalf("expected the newest pod of the check, got %v", latest)
+	}
+
+	latest, err = latestCheckerPod(context.Background(), client, "kuberhealthy", "deployment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest != nil {
+		t.Fatalf("expected no pod for a check without checker pods, got %s", latest.Name)
+	}
+}
+
+func TestRun(t *testing.T) {
+	var path, authorization string
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		path, authorization = r.URL.Path, r.Header.Get("Authorization")
+		w.WriteHeader(status)
+		io.WriteString(w, "check is not running\n")
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	p := &plugin{kubeToken: "kube-token", out: &out}
+	err := p.run(context.Background(), "kuberhealthy", "dns-status", server.URL+"/", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/run/kuberhealthy/dns-status" {
+		t.Errorf("expected the run to be triggered on /run/kuberhealthy/dns-status, got %s", path)
+	}
+	if authorization != "Bearer kube-token" {
+		t.Errorf("expected the token of the kubeconfig to be sent, got %q", authorization)
+	}
+
+	err = p.run(context.Background(), "kuberhealthy", "dns-status", server.URL, "flag-token\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if authorization != "Bearer flag-token" {
+		t.Errorf("expected the token flag to be sent, got %q", authorization)
+	}
+
+	status = http.StatusNotFound
+	err = p.run(context.Background(), "kuberhealthy", "dns-status", server.URL, "")
+	if err == nil || !strings.Contains(err.Error(), "check is not running") {
+		t.Fatalf("expected the refusal of kuberhealthy to be returned, got %v", err)
+	}
+
+	err = p.run(context.Background(), "kuberhealthy", "dns-status", "", "")
+	if err == nil {
+		t.Fatal("expected an error without a url")
+	}
+}
+
+func TestSetPaused(t *testing.T) {
+	var patch map[string]map[string]map[string]string
+	specPaused := false
+	p, out := newTestPlugin(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPatch || r.URL.Path != "/apis/comcast.github.io/v1/namespaces/kuberhealthy/khchecks/dns-status" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		patch = nil
+		err := json.NewDecoder(r.Body).Decode(&patch)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		kc := khcheckv1.KuberhealthyCheck{
+			ObjectMeta: metav1.ObjectMeta{Name: "dns-status", Namespace: "kuberhealthy", Annotations: patch["metadata"]["annotations"]},
+			Spec:       khcheckv1.CheckConfig{Paused: specPaused},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(kc)
+	})
+
+	err := p.setPaused("kuberhealthy", "dns-status", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patch["metadata"]["annotations"][pausedAnnotation] != "true" {
+		t.Fatalf("expected the paused annotation to be set to true, got %v", patch)
+	}
+	if !strings.Contains(out.String(), "khcheck kuberhealthy/dns-status paused") {
+		t.Errorf("expected the check to be reported as paused, got %q", out.String())
+	}
+
+	err = p.setPaused("kuberhealthy", "dns-status", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patch["metadata"]["annotations"][pausedAnnotation] != "false" {
+		t.Fatalf("expected the paused annotation to be set to false, got %v", patch)
+	}
+
+	specPaused = true
+	err = p.setPaused("kuberhealthy", "dns-status", false)
+	if err == nil || !strings.Contains(err.Error(), "spec.paused") {
+		t.Fatalf("expected resuming a check paused by its spec to fail, got %v", err)
+	}
+}
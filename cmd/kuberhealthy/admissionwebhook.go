@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+)
+
+// admissionPath is the path the API server sends khcheck admission reviews to
+const admissionPath = "/validate-khcheck"
+
+// admissionTLSReloadInterval is how often the certificate files of the admission webhook are checked for a rotated
+// certificate
+const admissionTLSReloadInterval = time.Minute
+
+// admissionListenAddress is the address the validating admission webhook for khchecks and the conversion webhook for
+// khchecks and khstates listen on.  When blank, the webhooks are not started.
+var admissionListenAddress string
+
+// admissionTLSCertFile and admissionTLSKeyFile are the certificate and key the admission webhook is served with.  The
+// API server only calls webhooks over TLS, so they are required.
+var admissionTLSCertFile string
+var admissionTLSKeyFile string
+
+// admissionDenyUnsafe rejects khchecks whose checker pods would run privileged or share the namespaces or
+// filesystem of their node.  They are admitted with a warning otherwise.
+var admissionDenyUnsafe bool
+
+// validateAdmissionFlags ensures the admission webhook has a certificate when it is enabled
+func validateAdmissionFlags() error {
+	if len(admissionListenAddress) == 0 {
+		return nil
+	}
+	if len(admissionTLSCertFile) == 0 || len(admissionTLSKeyFile) == 0 {
+		return errors.New("admissionListenAddress requires admissionTLSCertFile and admissionTLSKeyFile")
+	}
+	return nil
+}
+
+// validateUnsafePodSpec lists the fields of a khcheck pod spec that give checker pods access to their node.  Checks
+// that need this access exist, so these are only rejected when admissionDenyUnsafe is set.
+func validateUnsafePodSpec(podSpec apiv1.PodSpec) []khCheckIssue {
+	var issues []khCheckIssue
+	if podSpec.HostPID {
+		issues = append(issues, khCheckIssue{Field: "spec.podSpec.hostPID", Message: "checker pods share the process namespace of their node"})
+	}
+	if podSpec.HostIPC {
+		issues = append(issues, khCheckIssue{Field: "spec.podSpec.hostIPC", Message: "checker pods share the IPC namespace of their node"})
+	}
+	for i, volume := range podSpec.Volumes {
+		if volume.HostPath != nil {
+			issues = append(issues, khCheckIssue{Field: "spec.podSpec.volumes[" + strconv.Itoa(i) + "].hostPath", Message: "checker pods mount " + volume.HostPath.Path + " from their node"})
+		}
+	}
+
+	unsafeContainers := func(field string, containers []apiv1.Container) {
+		for i, c := range containers {
+			sc := c.SecurityContext
+			if sc == nil {
+				continue
+			}
+			containerField := field + "[" + strconv.Itoa(i) + "].securityContext"
+			if sc.Privileged != nil && *sc.Privileged {
+				issues = append(issues, khCheckIssue{Field: containerField + ".privileged", Message: "container " + c.Name + " runs privileged"})
+			}
+			if sc.AllowPrivilegeEscalation != nil && *sc.AllowPrivilegeEscalation {
+				issues = append(issues, khCheckIssue{Field: containerField + ".allowPrivilegeEscalation", Message: "container " + c.Name + " allows privilege escalation"})
+			}
+			if sc.Capabilities != nil {
+				for _, capability := range sc.Capabilities.Add {
+					if capability == "ALL" || capability == "SYS_ADMIN" || capability == "NET_ADMIN" {
+						issues = append(issues, khCheckIssue{Field: containerField + ".capabilities.add", Message: "container " + c.Name + " adds the " + string(capability) + " capability"})
+					}
+				}
+			}
+		}
+	}
+	unsafeContainers("spec.podSpec.initContainers", podSpec.InitContainers)
+	unsafeContainers("spec.podSpec.containers", podSpec.Containers)
+	return issues
+}
+
+// reviewKHCheck decides whether a khcheck in an admission request is admitted.  khchecks with validation errors
+// are rejected and their warnings are returned to the client, as kubectl prints them.  Deletions are always
+// admitted.
+func reviewKHCheck(req *admissionv1.AdmissionRequest) *admissionv1.AdmissionResponse {
+	resp := &admissionv1.AdmissionResponse{UID: req.UID, Allowed: true}
+	if req.Operation == admissionv1.Delete {
+		return resp
+	}
+
+	var kc khcheckv1.KuberhealthyCheck
+	err := json.Unmarshal(req.Object.Raw, &kc)
+	if err != nil {
+		resp.Allowed = false
+		resp.Result = &metav1.Status{Code: http.StatusBadRequest, Reason: metav1.StatusReasonBadRequest, Message: "failed to parse khcheck: " + err.Error()}
+		return resp
+	}
+	if len(kc.Namespace) == 0 {
+		kc.Namespace = req.Namespace
+	}
+	if len(kc.Name) == 0 {
+		kc.Name = req.Name
+	}
+
+	v := validateKHCheck(kc)
+	issues := v.Errors
+	for _, issue := range validateUnsafePodSpec(kc.Spec.PodSpec) {
+		if admissionDenyUnsafe {
+			issues = append(issues, issue)
+			continue
+		}
+		v.Warnings = append(v.Warnings, issue)
+	}
+	for _, warning := range v.Warnings {
+		resp.Warnings = append(resp.Warnings, warning.Field+": "+warning.Message)
+	}
+	if len(issues) == 0 {
+		return resp
+	}
+
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.Field+": "+issue.Message)
+	}
+	resp.Allowed = false
+	resp.Result = &metav1.Status{
+		Code:    http.StatusUnprocessableEntity,
+		Reason:  metav1.StatusReasonInvalid,
+		Message: "khcheck " + v.Check + " is invalid: " + strings.Join(messages, "; "),
+	}
+	return resp
+}
+
+// admissionHandler answers an AdmissionReview of a khcheck sent by the API server
+func admissionHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 3<<20))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return fmt.Errorf("failed to read admission review: %w", err)
+	}
+	var review admissionv1.AdmissionReview
+	err = json.Unmarshal(body, &review)
+	if err != nil || review.Request == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return fmt.Errorf("failed to parse admission review: %v", err)
+	}
+
+	review.Response = reviewKHCheck(review.Request)
+	if !review.Response.Allowed {
+		log.Infoln("admission: rejected", review.Response.Result.Message)
+	}
+	review.Request = nil
+	b, err := json.Marshal(review)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return fmt.Errorf("failed to marshal admission review: %w", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(b)
+	return err
+}
+
+// StartAdmissionServer serves the validating admission webhook for khchecks and the conversion webhook for khchecks
+// and khstates on the admissionListenAddress until the context is canceled.  It is served separately from the web server, because the API server does not authenticate
+// to the web server and only calls webhooks over TLS.
+func (k *Kuberhealthy) StartAdmissionServer(ctx context.Context) {
+	certificate, err := newCertificateReloader(admissionTLSCertFile, admissionTLSKeyFile, admissionTLSReloadInterval)
+	if err != nil {
+		log.Errorln("Admission webhook ERROR:", err)
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(admissionPath, func(w http.ResponseWriter, r *http.Request) {
+		err := admissionHandler(w, r)
+		if err != nil {
+			log.Errorln("admission webhook error:", err)
+		}
+	})
+	mux.HandleFunc(conversionPath, func(w http.ResponseWriter, r *http.Request) {
+		err := conversionHandler(w, r)
+		if err != nil {
+			log.Errorln("conversion webhook error:", err)
+		}
+	})
+	server := &http.Server{
+		Addr:      admissionListenAddress,
+		Handler:   mux,
+		TLSConfig: &tls.Config{GetCertificate: certificate.getCertificate, MinVersion: tls.VersionTLS12},
+	}
+	go func() {
+		<-ctx.Done()
+		log.Infoln("shutdown: stopping admission webhook")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	log.Infoln("Starting khcheck admission and conversion webhooks on", admissionListenAddress)
+	err = server.ListenAndServeTLS("", "")
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Errorln("Admission webhook ERROR:", err)
+	}
+}
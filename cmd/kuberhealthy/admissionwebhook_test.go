@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	admissionv1 "k8s.io/api/admission/v1"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// admissionRequest returns an admission request that creates the khcheck of a manifest
+func admissionRequest(t *testing.T, manifest string) *admissionv1.AdmissionRequest {
+	raw, err := yaml.YAMLToJSON([]byte(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &admissionv1.AdmissionRequest{
+		UID:       "1234",
+		Operation: admissionv1.Create,
+		Namespace: "kuberhealthy",
+		Object:    runtime.RawExtension{Raw: raw},
+	}
+}
+
+// TestReviewKHCheck ensures invalid khchecks are rejected with their errors and warnings are returned
+func TestReviewKHCheck(t *testing.T) {
+	resp := reviewKHCheck(admissionRequest(t, validKHCheckManifest))
+	if !resp.Allowed || resp.UID != "1234" || len(resp.Warnings) > 0 {
+		t.Fatal("expected the valid khcheck to be admitted without warnings but got", resp)
+	}
+
+	resp = reviewKHCheck(admissionRequest(t, invalidKHCheckManifest))
+	if resp.Allowed {
+		t.Fatal("expected the invalid khcheck to be rejected")
+	}
+	for _, field := range []string{"metadata.name", "spec.runInterval", "spec.timeout", "spec.concurrencyPolicy", "spec.podSpec.containers[0].image"} {
+		if !strings.Contains(resp.Result.Message, field+":") {
+			t.Fatal("expected the rejection to list", field, "but got", resp.Result.Message)
+		}
+	}
+	if !strings.Contains(strings.Join(resp.Warnings, "\n"), "spec.podSpec.restartPolicy:") {
+		t.Fatal("expected the restartPolicy warning but got", resp.Warnings)
+	}
+
+	req := admissionRequest(t, invalidKHCheckManifest)
+	req.Operation = admissionv1.Delete
+	if !reviewKHCheck(req).Allowed {
+		t.Fatal("expected the deletion of an invalid khcheck to be admitted")
+	}
+}
+
+// TestReviewKHCheckUnsafe ensures checker pods with access to their node are admitted with warnings unless
+// admissionDenyUnsafe is set
+func TestReviewKHCheckUnsafe(t *testing.T) {
+	manifest := validKHCheckManifest + `      securityContext:
+        privileged: true
+    hostPID: true
+    volumes:
+    - name: root
+      hostPath:
+        path: /
+`
+	resp := reviewKHCheck(admissionRequest(t, manifest))
+	if !resp.Allowed || len(resp.Warnings) != 3 {
+		t.Fatal("expected the unsafe khcheck to be admitted with three warnings but got", resp)
+	}
+
+	admissionDenyUnsafe = true
+	defer func() { admissionDenyUnsafe = false }()
+	resp = reviewKHCheck(admissionRequest(t, manifest))
+	if resp.Allowed {
+		t.Fatal("expected the unsafe khcheck to be rejected")
+	}
+	for _, field := range []string{"spec.podSpec.containers[0].securityContext.privileged", "spec.podSpec.hostPID", "spec.podSpec.volumes[0].hostPath"} {
+		if !strings.Contains(resp.Result.Message, field+":") {
+			t.Fatal("expected the rejection to list", field, "but got", resp.Result.Message)
+		}
+	}
+}
+
+// TestValidateUnsafePodSpec ensures capabilities that give access to the node are reported
+func TestValidateUnsafePodSpec(t *testing.T) {
+	podSpec := apiv1.PodSpec{Containers: []apiv1.Container{{
+		Name: "main",
+		SecurityContext: &apiv1.SecurityContext{Capabilities: &apiv1.Capabilities{
+			Add: []apiv1.Capability{"NET_RAW", "SYS_ADMIN"},
+		}},
+	}}}
+	issues := validateUnsafePodSpec(podSpec)
+	if issueFields(issues) != "spec.podSpec.containers[0].securityContext.capabilities.add" {
+		t.Fatal("expected only SYS_ADMIN to be reported but got", issues)
+	}
+}
+
+// TestAdmissionHandler ensures admission reviews are answered with the UID of their request
+func TestAdmissionHandler(t *testing.T) {
+	review := admissionv1.AdmissionReview{Request: admissionRequest(t, invalidKHCheckManifest)}
+	review.APIVersion = "admission.k8s.io/v1"
+	review.Kind = "AdmissionReview"
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	err = admissionHandler(recorder, httptest.NewRequest(http.MethodPost, admissionPath, bytes.NewReader(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out admissionv1.AdmissionReview
+	err = json.Unmarshal(recorder.Body.Bytes(), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Kind != "AdmissionReview" || out.Request != nil || out.Response == nil || out.Response.UID != "1234" || out.Response.Allowed {
+		t.Fatal("expected a rejecting admission review response but got", recorder.Body.String())
+	}
+
+	recorder = httptest.NewRecorder()
+	err = admissionHandler(recorder, httptest.NewRequest(http.MethodPost, admissionPath, strings.NewReader("{}")))
+	if err == nil || recorder.Code != http.StatusBadRequest {
+		t.Fatal("expected a review without a request to be refused but got", recorder.Code)
+	}
+}
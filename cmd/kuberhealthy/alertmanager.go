@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/alertmanager"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// alertmanagerURL is the base URL of the Alertmanager that alerts are pushed to.  Alerts are disabled when blank.
+var alertmanagerURL string
+
+// alertmanagerHeadersFlag holds comma separated key=value headers sent with every request to Alertmanager
+var alertmanagerHeadersFlag string
+var alertmanagerHeaders map[string]string
+
+// alertmanagerLabelsFlag holds comma separated key=value labels added to every alert, such as severity=critical
+var alertmanagerLabelsFlag string
+var alertmanagerLabels map[string]string
+
+// alertExpiryRuns is the number of run intervals a firing alert stays active in Alertmanager without being sent again.
+// Failing checks send their alert again on every run, so the alert only resolves on its own when Kuberhealthy
+// stops reporting the check, such as when the khcheck is deleted.
+const alertExpiryRuns = 3
+
+// parseAlertmanagerFlags parses the alertmanagerHeaders and alertmanagerLabels flags
+func parseAlertmanagerFlags() error {
+	var err error
+	alertmanagerHeaders, err = parseKeyValueFlag(alertmanagerHeadersFlag)
+	if err != nil {
+		return fmt.Errorf("unable to parse alertmanagerHeaders flag: %w", err)
+	}
+	alertmanagerLabels, err = parseKeyValueFlag(alertmanagerLabelsFlag)
+	if err != nil {
+		return fmt.Errorf("unable to parse alertmanagerLabels flag: %w", err)
+	}
+	return nil
+}
+
+// newAlertmanagerNotifier creates the notifier alerts are pushed to Alertmanager with.  nil is returned when
+// alerts are disabled.
+func newAlertmanagerNotifier() *alertmanager.Notifier {
+	if len(alertmanagerURL) == 0 {
+		return nil
+	}
+	log.Infoln("alertmanager: sending alerts to", alertmanagerURL)
+	return alertmanager.NewNotifier(alertmanager.NotifierConfig{
+		URL:     alertmanagerURL,
+		Headers: alertmanagerHeaders,
+		Proxy:   outboundProxy(),
+	})
+}
+
+// alertExpiry returns how long the firing alert of the workload with the supplied namespace/name stays active in
+// Alertmanager.  Jobs and built-in checks use the DefaultRunInterval.
+func alertExpiry(name string) time.Duration {
+	checkRunIntervalsMu.RLock()
+	defer checkRunIntervalsMu.RUnlock()
+	interval, ok := checkRunIntervals[name]
+	if !ok {
+		interval = DefaultRunInterval
+	}
+	return interval * alertExpiryRuns
+}
+
+// stateChangeAlert builds the Alertmanager alert for a run of a workload.  Failed runs fire the alert until the
+// supplied expiry passes.  OK runs resolve it.
+func stateChangeAlert(name string, namespace string, details khstatev1.WorkloadDetails, now time.Time, expiry time.Duration) alertmanager.Alert {
+	kind := "check"
+	alertName := "KuberhealthyCheckFailed"
+	if details.GetKHWorkload() == khstatev1.KHJob {
+		kind = "job"
+		alertName = "KuberhealthyJobFailed"
+	}
+
+	labels := make(map[string]string)
+	for k, v := range alertmanagerLabels {
+		labels[k] = v
+	}
+	if len(clusterName) > 0 {
+		labels["cluster"] = clusterName
+	}
+	labels["alertname"] = alertName
+	labels["check"] = name
+	labels["namespace"] = namespace
+
+	alert := alertmanager.Alert{
+		Labels: labels,
+		Annotations: map[string]string{
+			"summary": fmt.Sprintf("Kuberhealthy %s %s/%s is failing", kind, namespace, name),
+		},
+		StartsAt: now,
+		EndsAt:   now.Add(expiry),
+	}
+	if details.OK {
+		alert.Annotations["summary"] = fmt.Sprintf("Kuberhealthy %s %s/%s is OK again", kind, namespace, name)
+		alert.EndsAt = now
+		return alert
+	}
+	if len(details.Errors) > 0 {
+		alert.Annotations["description"] = strings.Join(details.Errors, "; ")
+		alert.Annotations["errors"] = strings.Join(details.Errors, "\n")
+	}
+	// the severity is an annotation rather than a label, so that the resolved alert matches the firing one
+	if len(details.Severity) > 0 {
+		alert.Annotations["severity"] = details.Severity
+	}
+	return alert
+}
+
+// sendStateChangeAlert pushes a firing alert to Alertmanager for every failed run of a workload, so that the errors
+// stay current and the alert does not expire, and a resolved alert when the workload recovers from the previous
+// OK state.  true is returned when an alert was pushed.
+func (k *Kuberhealthy) sendStateChangeAlert(name string, namespace string, previousOK bool, details khstatev1.WorkloadDetails) bool {
+	if k.alertmanagerNotifier == nil {
+		return false
+	}
+	if details.OK && detectStateTransition(previousOK, details.OK) != transitionRecovered {
+		return false
+	}
+
+	alert := stateChangeAlert(name, namespace, details, time.Now().UTC(), alertExpiry(namespace+"/"+name))
+	log.Debugln("alertmanager: sending", alert.Labels["alertname"], "alert for", namespace+"/"+name, "resolved:", details.OK)
+	k.alertmanagerNotifier.Send(alert)
+	return true
+}
+
+// alertmanagerMetrics returns the Prometheus metrics of the Alertmanager notifier.  A blank string is returned when
+// alerts are disabled.
+func (k *Kuberhealthy) alertmanagerMetrics() string {
+	if k.alertmanagerNotifier == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("# HELP kuberhealthy_alertmanager_dropped_total The number of alerts that could not be delivered to Alertmanager\n")
+	b.WriteString("# TYPE kuberhealthy_alertmanager_dropped_total counter\n")
+	b.WriteString(fmt.Sprintf("kuberhealthy_alertmanager_dropped_total %d\n", k.alertmanagerNotifier.Dropped()))
+	return b.String()
+}
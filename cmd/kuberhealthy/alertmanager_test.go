@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/alertmanager"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+)
+
+// TestStateChangeAlert ensures failed runs fire an alert with the check labels and errors until the expiry, and OK
+// runs resolve the same alert
+func TestStateChangeAlert(t *testing.T) {
+	previousClusterName, previousLabels := clusterName, alertmanagerLabels
+	clusterName, alertmanagerLabels = "test-cluster", map[string]string{"severity": "critical", "check": "overridden"}
+	defer func() { clusterName, alertmanagerLabels = previousClusterName, previousLabels }()
+
+	now := time.Now()
+	failing := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	failing.Errors = []string{"lookup failed", "timed out"}
+	firing := stateChangeAlert("dns-status", "kuberhealthy", failing, now, time.Minute*30)
+
+	expectedLabels := map[string]string{
+		"alertname": "KuberhealthyCheckFailed",
+		"check":     "dns-status",
+		"namespace": "kuberhealthy",
+		"cluster":   "test-cluster",
+		"severity":  "critical",
+	}
+	if len(firing.Labels) != len(expectedLabels) {
+		t.Fatal("expected labels", expectedLabels, "but got", firing.Labels)
+	}
+	for k, v := range expectedLabels {
+		if firing.Labels[k] != v {
+			t.Fatal("expected labels", expectedLabels, "but got", firing.Labels)
+		}
+	}
+	if firing.Annotations["errors"] != "lookup failed\ntimed out" || firing.Annotations["description"] != "lookup failed; timed out" {
+		t.Fatal("expected the errors as annotations but got", firing.Annotations)
+	}
+	if !firing.EndsAt.Equal(now.Add(time.Minute * 30)) {
+		t.Fatal("expected the alert to fire until the expiry but it ends at", firing.EndsAt)
+	}
+
+	ok := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	ok.OK = true
+	resolved := stateChangeAlert("dns-status", "kuberhealthy", ok, now, time.Minute*30)
+	if !resolved.EndsAt.Equal(now) || resolved.Labels["alertname"] != "KuberhealthyCheckFailed" || resolved.Labels["check"] != "dns-status" {
+		t.Fatal("expected the same alert to be resolved but got", resolved)
+	}
+
+	if _, ok := firing.Annotations["severity"]; ok {
+		t.Fatal("expected no severity annotation for a failure without a severity")
+	}
+	failing.Severity = status.SeverityWarning
+	warning := stateChangeAlert("dns-status", "kuberhealthy", failing, now, time.Minute*30)
+	if warning.Annotations["severity"] != status.SeverityWarning || warning.Labels["severity"] != "critical" {
+		t.Fatal("expected the severity as an annotation but got", warning.Annotations, warning.Labels)
+	}
+
+	job := khstatev1.NewWorkloadDetails(khstatev1.KHJob)
+	if stateChangeAlert("migration", "kuberhealthy", job, now, time.Minute).Labels["alertname"] != "KuberhealthyJobFailed" {
+		t.Fatal("expected jobs to fire a job alert")
+	}
+}
+
+// TestSendStateChangeAlert ensures every failed run fires an alert and only a recovery resolves it
+func TestSendStateChangeAlert(t *testing.T) {
+	received := make(chan alertmanager.Alert, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alerts []alertmanager.Alert
+		err := json.NewDecoder(r.Body).Decode(&alerts)
+		if err != nil {
+			t.Error("failed to decode alerts:", err)
+		}
+		for _, a := range alerts {
+			received <- a
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kh := &Kuberhealthy{alertmanagerNotifier: alertmanager.NewNotifier(alertmanager.NotifierConfig{URL: server.URL})}
+	go kh.alertmanagerNotifier.Start(ctx)
+
+	failing := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	failing.Errors = []string{"lookup failed"}
+	ok := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	ok.OK = true
+
+	kh.sendStateChangeAlert("dns-status", "kuberhealthy", true, failing)
+	kh.sendStateChangeAlert("dns-status", "kuberhealthy", false, failing)
+	kh.sendStateChangeAlert("dns-status", "kuberhealthy", false, ok)
+	kh.sendStateChangeAlert("dns-status", "kuberhealthy", true, ok)
+
+	var alerts []alertmanager.Alert
+	for len(alerts) < 3 {
+		select {
+		case a := <-received:
+			alerts = append(alerts, a)
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for alert delivery, got", alerts)
+		}
+	}
+	if !alerts[0].EndsAt.After(time.Now()) || !alerts[1].EndsAt.After(time.Now()) {
+		t.Fatal("expected both failed runs to fire the alert but got", alerts)
+	}
+	if alerts[2].EndsAt.After(time.Now()) {
+		t.Fatal("expected the recovery to resolve the alert but got", alerts[2])
+	}
+	select {
+	case a := <-received:
+		t.Fatal("expected no alert for an OK run without a state change but got", a)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	if !strings.Contains(kh.alertmanagerMetrics(), "kuberhealthy_alertmanager_dropped_total 0") {
+		t.Fatal("unexpected alertmanager metrics:", kh.alertmanagerMetrics())
+	}
+}
+
+// TestAlertExpiry ensures firing alerts outlive several runs of their check
+func TestAlertExpiry(t *testing.T) {
+	previous := checkRunIntervals
+	defer replaceCheckRunIntervals(previous)
+	replaceCheckRunIntervals(map[string]time.Duration{"kuberhealthy/dns-status": time.Minute * 2})
+
+	if alertExpiry("kuberhealthy/dns-status") != time.Minute*2*alertExpiryRuns {
+		t.Fatal("expected the expiry to follow the run interval but got", alertExpiry("kuberhealthy/dns-status"))
+	}
+	if alertExpiry("kuberhealthy/migration") != DefaultRunInterval*alertExpiryRuns {
+		t.Fatal("expected the default run interval for unknown workloads but got", alertExpiry("kuberhealthy/migration"))
+	}
+}
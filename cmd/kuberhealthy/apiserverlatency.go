@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// apiServerLatencyCheckName is the name of the khstate the built-in API server latency check writes its results to
+// in the Kuberhealthy namespace
+const apiServerLatencyCheckName = "apiserver-latency"
+
+// apiServerLatencyCheckInterval is how often the API server latency check runs
+const apiServerLatencyCheckInterval = time.Minute
+
+// maxAPIRequestSamples is the most requests of each verb that are kept in the rolling window
+const maxAPIRequestSamples = 10000
+
+// apiServerLatencyCheck enables the built-in API server latency check
+var apiServerLatencyCheck bool
+
+// apiServerLatencyThreshold is the p99 latency of any verb above which the API server latency check fails
+var apiServerLatencyThreshold = time.Second * 5
+
+// apiServerErrorRateThreshold is the percentage of failed API server requests above which the API server latency
+// check fails
+var apiServerErrorRateThreshold float64 = 10
+
+// apiServerLatencyWindow is how far back the API server request statistics reach
+var apiServerLatencyWindow = time.Minute * 5
+
+// apiServerProbe enables probing the API server with lightweight requests, so that the API server latency check and
+// metrics have requests to measure even when Kuberhealthy is otherwise idle
+var apiServerProbe bool
+
+// apiServerProbeInterval is how often the API server is probed
+var apiServerProbeInterval = time.Second * 10
+
+// apiServerProbeLabel labels the configmaps created by the API server probe
+const apiServerProbeLabel = "kuberhealthy-apiserver-probe"
+
+// apiServerLatency tracks the requests of all the kubernetes clients of Kuberhealthy, including those of checker pods
+var apiServerLatency = newAPILatencyTracker(apiServerLatencyWindow)
+
+// apiRequestSample is a single request made to the API server
+type apiRequestSample struct {
+	at      time.Time
+	latency time.Duration
+	failed  bool
+}
+
+// apiVerbStats are the statistics of the requests of one verb over the rolling window
+type apiVerbStats struct {
+	Requests int
+	Errors   int
+	P50      time.Duration
+	P90      time.Duration
+	P99      time.Duration
+}
+
+// apiLatencyTracker keeps the API server requests of the rolling window for each verb
+type apiLatencyTracker struct {
+	mu      sync.Mutex
+	window  time.Duration
+	samples map[string][]apiRequestSample
+}
+
+// newAPILatencyTracker creates a tracker that keeps the requests of the last window
+func newAPILatencyTracker(window time.Duration) *apiLatencyTracker {
+	return &apiLatencyTracker{window: window, samples: map[string][]apiRequestSample{}}
+}
+
+// validateAPIServerLatencyFlags ensures the API server latency check flags are usable
+func validateAPIServerLatencyFlags() error {
+	if apiServerLatencyWindow <= 0 || apiServerLatencyThreshold <= 0 {
+		return errors.New("apiServerLatencyWindow and apiServerLatencyThreshold must be positive")
+	}
+	if apiServerErrorRateThreshold < 0 || apiServerErrorRateThreshold > 100 {
+		return errors.New("apiServerErrorRateThreshold must be a percentage between 0 and 100")
+	}
+	if apiServerProbe && apiServerProbeInterval <= 0 {
+		return errors.New("apiServerProbeInterval must be positive")
+	}
+	return nil
+}
+
+// record adds a request that completed at the supplied time to the window of its verb
+func (t *apiLatencyTracker) record(verb string, at time.Time, latency time.Duration, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	samples := append(t.samples[verb], apiRequestSample{at: at, latency: latency, failed: failed})
+	if len(samples) > maxAPIRequestSamples {
+		samples = samples[len(samples)-maxAPIRequestSamples:]
+	}
+	t.samples[verb] = t.prune(samples, at)
+}
+
+// prune drops the samples that fell out of the window.  Samples are kept in the order they were recorded.
+func (t *apiLatencyTracker) prune(samples []apiRequestSample, now time.Time) []apiRequestSample {
+	cutoff := now.Add(-t.window)
+	for len(samples) > 0 && samples[0].at.Before(cutoff) {
+		samples = samples[1:]
+	}
+	return samples
+}
+
+// stats returns the statistics of each verb that had requests in the window
+func (t *apiLatencyTracker) stats(now time.Time) map[string]apiVerbStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := map[string]apiVerbStats{}
+	for verb, samples := range t.samples {
+		samples = t.prune(samples, now)
+		t.samples[verb] = samples
+		if len(samples) == 0 {
+			delete(t.samples, verb)
+			continue
+		}
+
+		s := apiVerbStats{Requests: len(samples)}
+		latencies := make([]time.Duration, 0, len(samples))
+		for _, sample := range samples {
+			latencies = append(latencies, sample.latency)
+			if sample.failed {
+				s.Errors++
+			}
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		s.P50 = latencyPercentile(latencies, 50)
+		s.P90 = latencyPercentile(latencies, 90)
+		s.P99 = latencyPercentile(latencies, 99)
+		stats[verb] = s
+	}
+	return stats
+}
+
+// latencyPercentile returns the nearest-rank percentile of sorted latencies
+func latencyPercentile(sorted []time.Duration, percentile int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (percentile*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// wrap returns a round tripper that records the requests made with rt.  It is used as the WrapTransport of the
+// kubernetes client configuration.
+func (t *apiLatencyTracker) wrap(rt http.RoundTripper) http.RoundTripper {
+	return &apiLatencyRoundTripper{next: rt, tracker: t}
+}
+
+// apiLatencyRoundTripper records the latency of each API server request until its response headers arrive
+type apiLatencyRoundTripper struct {
+	next    http.RoundTripper
+	tracker *apiLatencyTracker
+}
+
+// RoundTrip makes the request and records it.  Watches and followed logs are long running and are not recorded.
+func (rt *apiLatencyRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isLongRunningAPIRequest(req) {
+		return rt.next.RoundTrip(req)
+	}
+	start := time.Now()
+	resp, err := rt.next.RoundTrip(req)
+	// client errors such as not found are answers of a healthy API server
+	failed := err != nil || resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	rt.tracker.record(apiRequestVerb(req), time.Now(), time.Since(start), failed)
+	return resp, err
+}
+
+// isLongRunningAPIRequest indicates the request is held open by the API server, so its latency says nothing about the
+// health of the API server
+func isLongRunningAPIRequest(req *http.Request) bool {
+	query := req.URL.Query()
+	if query.Get("watch") == "true" || query.Get("watch") == "1" || query.Get("follow") == "true" {
+		return true
+	}
+	return strings.Contains(req.URL.Path, "/watch/")
+}
+
+// apiRequestVerb returns the kubernetes verb of a request, such as list for a GET of a collection of resources
+func apiRequestVerb(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	var resource []string
+	switch {
+	case len(segments) > 2 && segments[0] == "api":
+		resource = segments[2:]
+	case len(segments) > 3 && segments[0] == "apis":
+		resource = segments[3:]
+	}
+	if len(resource) > 2 && resource[0] == "namespaces" {
+		resource = resource[2:]
+	}
+	collection := len(resource) == 1
+
+	switch req.Method {
+	case http.MethodGet:
+		if collection {
+			return "list"
+		}
+		return "get"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		if collection {
+			return "deletecollection"
+		}
+		return "delete"
+	}
+	return strings.ToLower(req.Method)
+}
+
+// apiServerLatencyErrors returns an error for each verb with a p99 latency above the threshold and an error when
+// the error rate of all requests is above the errorRateThreshold percentage
+func apiServerLatencyErrors(stats map[string]apiVerbStats, threshold time.Duration, errorRateThreshold float64, window time.Duration) []string {
+	var errs []string
+	var requests, failed int
+	for _, verb := range sortedVerbs(stats) {
+		s := stats[verb]
+		requests += s.Requests
+		failed += s.Errors
+		if s.P99 > threshold {
+			errs = append(errs, fmt.Sprintf("API server %s requests took %s at p99 over the last %s, above the %s threshold (p50 %s, p90 %s, %d requests)",
+				strings.ToUpper(verb), s.P99.Round(time.Millisecond), window, threshold, s.P50.Round(time.Millisecond), s.P90.Round(time.Millisecond), s.Requests))
+		}
+	}
+	if requests == 0 {
+		return errs
+	}
+	errorRate := float64(failed) / float64(requests) * 100
+	if errorRate > errorRateThreshold {
+		errs = append(errs, fmt.Sprintf("API server requests failed at a rate of %.1f%% over the last %s, above the %.1f%% threshold (%d of %d requests failed)",
+			errorRate, window, errorRateThreshold, failed, requests))
+	}
+	return errs
+}
+
+// sortedVerbs returns the verbs of the statistics in alphabetical order
+func sortedVerbs(stats map[string]apiVerbStats) []string {
+	verbs := make([]string, 0, len(stats))
+	for verb := range stats {
+		verbs = append(verbs, verb)
+	}
+	sort.Strings(verbs)
+	return verbs
+}
+
+// apiServerLatencyMetrics returns the rolling window statistics of the API server requests in the prometheus format
+func apiServerLatencyMetrics() string {
+	stats := apiServerLatency.stats(time.Now())
+	if len(stats) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("# HELP kuberhealthy_apiserver_request_latency_seconds The latency of the API server requests made by Kuberhealthy over the rolling window\n")
+	b.WriteString("# TYPE kuberhealthy_apiserver_request_latency_seconds gauge\n")
+	for _, verb := range sortedVerbs(stats) {
+		s := stats[verb]
+		b.WriteString(fmt.Sprintf("kuberhealthy_apiserver_request_latency_seconds{verb=\"%s\",quantile=\"0.5\"} %g\n", verb, s.P50.Seconds()))
+		b.WriteString(fmt.Sprintf("kuberhealthy_apiserver_request_latency_seconds{verb=\"%s\",quantile=\"0.9\"} %g\n", verb, s.P90.Seconds()))
+		b.WriteString(fmt.Sprintf("kuberhealthy_apiserver_request_latency_seconds{verb=\"%s\",quantile=\"0.99\"} %g\n", verb, s.P99.Seconds()))
+	}
+	b.WriteString("# HELP kuberhealthy_apiserver_requests The number of API server requests made by Kuberhealthy over the rolling window\n")
+	b.WriteString("# TYPE kuberhealthy_apiserver_requests gauge\n")
+	for _, verb := range sortedVerbs(stats) {
+		b.WriteString(fmt.Sprintf("kuberhealthy_apiserver_requests{verb=\"%s\"} %d\n", verb, stats[verb].Requests))
+	}
+	b.WriteString("# HELP kuberhealthy_apiserver_request_errors The number of failed API server requests made by Kuberhealthy over the rolling window\n")
+	b.WriteString("# TYPE kuberhealthy_apiserver_request_errors gauge\n")
+	for _, verb := range sortedVerbs(stats) {
+		b.WriteString(fmt.Sprintf("kuberhealthy_apiserver_request_errors{verb=\"%s\"} %d\n", verb, stats[verb].Errors))
+	}
+	return b.String()
+}
+
+// runAPIServerLatencyCheck checks the API server request statistics every apiServerLatencyCheckInterval and stores
+// its results in the apiserver-latency khstate until the context is canceled.  When checks are sharded, only the
+// kuberhealthy pod the check is assigned to runs it.
+func (k *Kuberhealthy) runAPIServerLatencyCheck(ctx context.Context) {
+	defer k.wg.Done()
+
+	log.Infoln("Starting check:", podNamespace, "/", apiServerLatencyCheckName)
+	name := podNamespace + "/" + apiServerLatencyCheckName
+	schedule := k.checkSchedules.add(name, checkTiming{interval: apiServerLatencyCheckInterval})
+	defer k.checkSchedules.remove(name, schedule)
+
+	for {
+		if !schedule.wait(ctx) {
+			log.Infoln("Shutting down check run due to context cancellation:", apiServerLatencyCheckName, "in namespace", podNamespace)
+			return
+		}
+
+		if shardChecks && shardOwner(name) != podHostname {
+			log.Debugln("sharding: skipping run of check", name, "because it is assigned to another pod")
+			continue
+		}
+
+		start := time.Now()
+		details := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+		details.Namespace = podNamespace
+		details.Errors = apiServerLatencyErrors(apiServerLatency.stats(start), apiServerLatencyThreshold, apiServerErrorRateThreshold, apiServerLatencyWindow)
+		if k.apiServerProber != nil {
+			details.Errors = append(details.Errors, k.apiServerProber.errors()...)
+		}
+		details.OK = len(details.Errors) == 0
+		details.RunDuration = time.Since(start).String()
+		details.AuthoritativePod = podHostname
+
+		log.Infoln("Setting state of check", apiServerLatencyCheckName, "in namespace", podNamespace, "to", details.OK, details.Errors, details.RunDuration)
+		err := k.storeCheckState(apiServerLatencyCheckName, podNamespace, details)
+		if err != nil {
+			log.Errorln("Error storing CRD state for check:", apiServerLatencyCheckName, "in namespace", podNamespace, err)
+		}
+	}
+}
+
+// apiServerProber makes lightweight requests to the API server.  The requests are timed by the kubernetes client like
+// every other request, so they are part of the API server request statistics.
+type apiServerProber struct {
+	client    kubernetes.Interface
+	namespace string
+
+	// lastErr is why the most recent probe was refused, such as missing permissions.  Refused requests are answers
+	// of a healthy API server and do not count as errors in the statistics, so they are reported by the check instead.
+	mu      sync.Mutex
+	lastErr string
+}
+
+// probe gets the namespace of the prober, lists a few of its configmaps and creates and deletes a configmap.  The
+// first error is returned.
+func (p *apiServerProber) probe(ctx context.Context) error {
+	_, err := p.client.CoreV1().Namespaces().Get(ctx, p.namespace, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to get namespace %s: %w", p.namespace, err)
+	}
+	_, err = p.client.CoreV1().ConfigMaps(p.namespace).List(ctx, metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return fmt.Errorf("failed to list configmaps in namespace %s: %w", p.namespace, err)
+	}
+	cm, err := p.client.CoreV1().ConfigMaps(p.namespace).Create(ctx, &apiv1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{GenerateName: apiServerProbeLabel + "-", Labels: map[string]string{apiServerProbeLabel: "true"}},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create a configmap in namespace %s: %w", p.namespace, err)
+	}
+	err = p.client.CoreV1().ConfigMaps(p.namespace).Delete(ctx, cm.Name, metav1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to delete configmap %s in namespace %s: %w", cm.Name, p.namespace, err)
+	}
+	return nil
+}
+
+// run probes the API server and remembers probes that were refused
+func (p *apiServerProber) run(ctx context.Context) {
+	err := p.probe(ctx)
+	if err != nil && ctx.Err() == nil {
+		log.Warningln("API server probe:", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastErr = ""
+	if k8sErrors.IsForbidden(err) || k8sErrors.IsUnauthorized(err) {
+		p.lastErr = "API server probe was refused: " + err.Error()
+	}
+}
+
+// errors returns why the most recent probe was refused
+func (p *apiServerProber) errors() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.lastErr) == 0 {
+		return nil
+	}
+	return []string{p.lastErr}
+}
+
+// runAPIServerProbe probes the API server every apiServerProbeInterval until the context is canceled.  When checks
+// are sharded, only the kuberhealthy pod the API server latency check is assigned to probes the API server.
+func (k *Kuberhealthy) runAPIServerProbe(ctx context.Context) {
+	defer k.wg.Done()
+
+	log.Infoln("Probing the API server every", apiServerProbeInterval)
+	ticker := time.NewTicker(apiServerProbeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infoln("Shutting down the API server probe due to context cancellation")
+			return
+		case <-ticker.C:
+		}
+
+		if shardChecks && shardOwner(podNamespace+"/"+apiServerLatencyCheckName) != podHostname {
+			continue
+		}
+		probeCtx, cancel := context.WithTimeout(ctx, apiServerProbeInterval)
+		k.apiServerProber.run(probeCtx)
+		cancel()
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestAPILatencyTrackerStats ensures percentiles and errors are calculated per verb over the rolling window only
+func TestAPILatencyTrackerStats(t *testing.T) {
+	tracker := newAPILatencyTracker(time.Minute)
+	now := time.Now()
+
+	// an old slow request that falls out of the window
+	tracker.record("list", now.Add(-time.Minute*2), time.Second*30, true)
+	for i := 1; i <= 100; i++ {
+		tracker.record("list", now.Add(-time.Second*30), time.Duration(i)*time.Millisecond, i == 100)
+	}
+	tracker.record("get", now, time.Millisecond*5, false)
+
+	stats := tracker.stats(now)
+	list := stats["list"]
+	if list.Requests != 100 || list.Errors != 1 {
+		t.Fatal("expected 100 list requests with 1 error in the window but got", list)
+	}
+	if list.P50 != time.Millisecond*50 || list.P90 != time.Millisecond*90 || list.P99 != time.Millisecond*99 {
+		t.Fatal("expected the p50, p90 and p99 of the list requests to be 50ms, 90ms and 99ms but got", list)
+	}
+	if stats["get"].Requests != 1 || stats["get"].P99 != time.Millisecond*5 {
+		t.Fatal("expected 1 get request but got", stats["get"])
+	}
+
+	stats = tracker.stats(now.Add(time.Minute + time.Second))
+	if len(stats) != 0 {
+		t.Fatal("expected no requests once the window passed but got", stats)
+	}
+}
+
+// TestAPIRequestVerb ensures requests are classified by their kubernetes verb
+func TestAPIRequestVerb(t *testing.T) {
+	tests := []struct {
+		method string
+		path   string
+		verb   string
+	}{
+		{method: http.MethodGet, path: "/api/v1/namespaces/kuberhealthy/pods", verb: "list"},
+		{method: http.MethodGet, path: "/api/v1/namespaces/kuberhealthy/pods/checker", verb: "get"},
+		{method: http.MethodGet, path: "/api/v1/namespaces/kuberhealthy/pods/checker/log", verb: "get"},
+		{method: http.MethodGet, path: "/api/v1/namespaces", verb: "list"},
+		{method: http.MethodGet, path: "/api/v1/namespaces/kuberhealthy", verb: "get"},
+		{method: http.MethodGet, path: "/apis/comcast.github.io/v1/namespaces/kuberhealthy/khchecks", verb: "list"},
+		{method: http.MethodPut, path: "/apis/comcast.github.io/v1/namespaces/kuberhealthy/khstates/check", verb: "update"},
+		{method: http.MethodPost, path: "/api/v1/namespaces/kuberhealthy/pods", verb: "create"},
+		{method: http.MethodDelete, path: "/api/v1/namespaces/kuberhealthy/pods", verb: "deletecollection"},
+		{method: http.MethodDelete, path: "/api/v1/namespaces/kuberhealthy/pods/checker", verb: "delete"},
+		{method: http.MethodGet, path: "/version", verb: "get"},
+	}
+	for _, test := range tests {
+		req := httptest.NewRequest(test.method, test.path, nil)
+		verb := apiRequestVerb(req)
+		if verb != test.verb {
+			t.Error("expected", test.method, test.path, "to be a", test.verb, "but got", verb)
+		}
+	}
+}
+
+// TestAPILatencyRoundTripper ensures server errors are counted as failures, client errors are not, and watches are
+// not recorded
+func TestAPILatencyRoundTripper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/missing"):
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasSuffix(r.URL.Path, "/broken"):
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	tracker := newAPILatencyTracker(time.Minute)
+	client := &http.Client{Transport: tracker.wrap(http.DefaultTransport)}
+	for _, path := range []string{"/api/v1/pods", "/api/v1/namespaces/default/pods/missing", "/api/v1/namespaces/default/pods/broken", "/api/v1/pods?watch=true"} {
+		resp, err := client.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	stats := tracker.stats(time.Now())
+	if stats["list"].Requests != 1 || stats["list"].Errors != 0 {
+		t.Fatal("expected 1 list request without the watch but got", stats["list"])
+	}
+	if stats["get"].Requests != 2 || stats["get"].Errors != 1 {
+		t.Fatal("expected 2 get requests with only the unavailable one failed but got", stats["get"])
+	}
+}
+
+// TestAPIServerLatencyErrors ensures slow verbs and high error rates fail the check with the observed numbers
+func TestAPIServerLatencyErrors(t *testing.T) {
+	stats := map[string]apiVerbStats{
+		"list": {Requests: 40, Errors: 4, P50: time.Millisecond * 120, P90: time.Second * 3, P99: time.Millisecond * 6200},
+		"get":  {Requests: 60, Errors: 8, P50: time.Millisecond * 10, P90: time.Millisecond * 20, P99: time.Millisecond * 50},
+	}
+
+	errs := apiServerLatencyErrors(stats, time.Second*5, 10, time.Minute*5)
+	if len(errs) != 2 {
+		t.Fatal("expected a latency error and an error rate error but got", errs)
+	}
+	if errs[0] != "API server LIST requests took 6.2s at p99 over the last 5m0s, above the 5s threshold (p50 120ms, p90 3s, 40 requests)" {
+		t.Fatal("unexpected latency error:", errs[0])
+	}
+	if errs[1] != "API server requests failed at a rate of 12.0% over the last 5m0s, above the 10.0% threshold (12 of 100 requests failed)" {
+		t.Fatal("unexpected error rate error:", errs[1])
+	}
+
+	errs = apiServerLatencyErrors(stats, time.Second*10, 20, time.Minute*5)
+	if len(errs) != 0 {
+		t.Fatal("expected no errors below the thresholds but got", errs)
+	}
+	if len(apiServerLatencyErrors(map[string]apiVerbStats{}, time.Second, 0, time.Minute)) != 0 {
+		t.Fatal("expected no errors without requests")
+	}
+}
+
+// TestAPIServerLatencyMetrics ensures the rolling window statistics are exported for each verb
+func TestAPIServerLatencyMetrics(t *testing.T) {
+	previous := apiServerLatency
+	defer func() { apiServerLatency = previous }()
+	apiServerLatency = newAPILatencyTracker(time.Minute)
+	if apiServerLatencyMetrics() != "" {
+		t.Fatal("expected no metrics without requests")
+	}
+
+	apiServerLatency.record("list", time.Now(), time.Millisecond*250, true)
+	metrics := apiServerLatencyMetrics()
+	for _, line := range []string{
+		`kuberhealthy_apiserver_request_latency_seconds{verb="list",quantile="0.99"} 0.25`,
+		`kuberhealthy_apiserver_requests{verb="list"} 1`,
+		`kuberhealthy_apiserver_request_errors{verb="list"} 1`,
+	} {
+		if !strings.Contains(metrics, line+"\n") {
+			t.Fatal("expected the metrics to contain", line, "but got", metrics)
+		}
+	}
+}
+
+// TestAPIServerProber ensures the prober gets, lists, creates and deletes without leaving configmaps behind, and
+// reports probes that were refused
+func TestAPIServerProber(t *testing.T) {
+	client := fake.NewSimpleClientset(&apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kuberhealthy"}})
+	p := &apiServerProber{client: client, namespace: "kuberhealthy"}
+
+	p.run(context.Background())
+	if errs := p.errors(); len(errs) != 0 {
+		t.Fatal("expected the probe to succeed but got", errs)
+	}
+	var verbs []string
+	for _, action := range client.Actions() {
+		verbs = append(verbs, action.GetVerb()+" "+action.GetResource().Resource)
+	}
+	if strings.Join(verbs, ", ") != "get namespaces, list configmaps, create configmaps, delete configmaps" {
+		t.Fatal("expected the probe to get, list, create and delete but it made the requests", verbs)
+	}
+	configMaps, _ := client.CoreV1().ConfigMaps("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if len(configMaps.Items) != 0 {
+		t.Fatal("expected the probe configmap to be deleted but found", len(configMaps.Items), "configmaps")
+	}
+
+	client.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8sErrors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "", nil)
+	})
+	p.run(context.Background())
+	if errs := p.errors(); len(errs) != 1 || !strings.Contains(errs[0], "API server probe was refused: failed to create a configmap") {
+		t.Fatal("expected the refused probe to be reported but got", errs)
+	}
+
+	// a probe that fails for other reasons is counted by the request statistics instead
+	client.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, k8sErrors.NewServiceUnavailable("etcdserver: request timed out")
+	})
+	p.run(context.Background())
+	if errs := p.errors(); len(errs) != 0 {
+		t.Fatal("expected only refused probes to be reported but got", errs)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+)
+
+// auditLog writes an audit record of every check run to stdout as a JSON line
+var auditLog bool
+
+// auditLogFile is a file an audit record of every check run is appended to as a JSON line.  No file is written
+// when blank.
+var auditLogFile string
+
+// the reasons a check run is started for
+const (
+	triggerSchedule = "schedule" // the interval or cron schedule of the check
+	triggerRetry    = "retry"    // a retry of a failed run before the next run is due
+	triggerManual   = "manual"   // a run requested through the run endpoint or the gRPC API
+)
+
+// the results of check runs in the audit trail
+const (
+	auditResultPassed  = "passed"  // the run reported success
+	auditResultFailed  = "failed"  // the run reported errors
+	auditResultError   = "error"   // the run could not be completed, such as when its checker pod timed out
+	auditResultAborted = "aborted" // the run was stopped because Kuberhealthy is shutting down
+	auditResultStopped = "stopped" // the run was stopped before completion, such as when a newer run replaced it
+	auditResultSkipped = "skipped" // the run was due but not started
+)
+
+// auditNotificationTTL is how long the notifications sent for a run are kept for its audit record.  Notifications of
+// runs that are never recorded, such as runs of other Kuberhealthy pods, are forgotten after it.
+const auditNotificationTTL = time.Hour * 24
+
+// runTrigger is what started a check run
+type runTrigger struct {
+	reason string // schedule, retry or manual
+	by     string // the user of manual runs or the run UUID of the failed run a retry follows. blank for scheduled runs
+}
+
+// auditRecord is the audit record of a check run
+type auditRecord struct {
+	Check           string    `json:"check"`
+	Namespace       string    `json:"namespace"`
+	RunUUID         string    `json:"runUUID,omitempty"`
+	Trigger         string    `json:"trigger"`
+	TriggeredBy     string    `json:"triggeredBy,omitempty"`
+	KuberhealthyPod string    `json:"kuberhealthyPod"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	Duration        string    `json:"duration"`
+	Result          string    `json:"result"`
+	Reason          string    `json:"reason,omitempty"`
+	Errors          []string  `json:"errors,omitempty"`
+	Notifications   []string  `json:"notifications"` // the notifications the run fired, such as slack or webhooks
+}
+
+// auditNotifications are the notifications sent for a run that has not been recorded yet
+type auditNotifications struct {
+	names   []string
+	updated time.Time
+}
+
+// auditTrail writes an append-only audit record of every check run.  A nil audit trail records nothing.
+type auditTrail struct {
+	mu            sync.Mutex
+	writers       []io.Writer
+	notifications map[string]auditNotifications // the notifications sent for runs by run UUID
+	now           func() time.Time
+}
+
+// newAuditTrail creates the audit trail of the audit flags.  Nil is returned when the audit trail is disabled.
+func newAuditTrail(toStdout bool, file string) (*auditTrail, error) {
+	if !toStdout && len(file) == 0 {
+		return nil, nil
+	}
+	a := &auditTrail{notifications: make(map[string]auditNotifications), now: time.Now}
+	if toStdout {
+		a.writers = append(a.writers, os.Stdout)
+	}
+	if len(file) > 0 {
+		f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open auditLogFile %s: %w", file, err)
+		}
+		a.writers = append(a.writers, f)
+	}
+	return a, nil
+}
+
+// notified remembers the notifications that were sent for a run until the run is recorded
+func (a *auditTrail) notified(runUUID string, names []string) {
+	if a == nil || len(runUUID) == 0 || len(names) == 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now()
+	for id, n := range a.notifications {
+		if now.Sub(n.updated) > auditNotificationTTL {
+			delete(a.notifications, id)
+		}
+	}
+	n := a.notifications[runUUID]
+	for _, name := range names {
+		if !containsString(name, n.names) {
+			n.names = append(n.names, name)
+		}
+	}
+	n.updated = now
+	a.notifications[runUUID] = n
+}
+
+// record writes the audit record of a run along with the notifications that were sent for it
+func (a *auditTrail) record(r auditRecord) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(r.KuberhealthyPod) == 0 {
+		r.KuberhealthyPod = podHostname
+	}
+	r.Duration = r.End.Sub(r.Start).String()
+	r.Notifications = []string{}
+	if n, ok := a.notifications[r.RunUUID]; ok && len(r.RunUUID) > 0 {
+		r.Notifications = n.names
+		delete(a.notifications, r.RunUUID)
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		log.Errorln("audit: failed to marshal the audit record of check", r.Namespace+"/"+r.Check+":", err)
+		return
+	}
+	b = append(b, '\n')
+	for _, w := range a.writers {
+		_, err = w.Write(b)
+		if err != nil {
+			log.Errorln("audit: failed to write the audit record of check", r.Namespace+"/"+r.Check+":", err)
+		}
+	}
+}
+
+// auditCheckRun records a run of a check that started at the supplied time in the audit trail
+func (k *Kuberhealthy) auditCheckRun(c *external.Checker, trigger runTrigger, runUUID string, start time.Time, result string, errs []string) {
+	k.auditTrail.record(auditRecord{
+		Check:       c.Name(),
+		Namespace:   c.CheckNamespace(),
+		RunUUID:     runUUID,
+		Trigger:     trigger.reason,
+		TriggeredBy: trigger.by,
+		Start:       start,
+		End:         time.Now(),
+		Result:      result,
+		Errors:      errs,
+	})
+}
+
+// auditSkippedRun records a run of a check that was due but not started in the audit trail
+func (k *Kuberhealthy) auditSkippedRun(c *external.Checker, trigger runTrigger, reason string) {
+	now := time.Now()
+	k.auditTrail.record(auditRecord{
+		Check:       c.Name(),
+		Namespace:   c.CheckNamespace(),
+		Trigger:     trigger.reason,
+		TriggeredBy: trigger.by,
+		Start:       now,
+		End:         now,
+		Result:      auditResultSkipped,
+		Reason:      reason,
+	})
+}
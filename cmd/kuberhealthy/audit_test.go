@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAuditTrail(t *testing.T) {
+	a, err := newAuditTrail(false, "")
+	if err != nil || a != nil {
+		t.Fatal("expected no audit trail when it is disabled but got", a, err)
+	}
+	// a disabled audit trail records nothing
+	a.notified("1234", []string{"slack"})
+	a.record(auditRecord{Check: "dns", Namespace: "kuberhealthy"})
+
+	file := filepath.Join(t.TempDir(), "audit.log")
+	a, err = newAuditTrail(false, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	a.notified("1234", []string{"events", "slack"})
+	a.notified("1234", []string{"slack", "webhooks"})
+	a.record(auditRecord{
+		Check:       "dns",
+		Namespace:   "kuberhealthy",
+		RunUUID:     "1234",
+		Trigger:     triggerManual,
+		TriggeredBy: "operator",
+		Start:       start,
+		End:         start.Add(time.Second * 41),
+		Result:      auditResultFailed,
+		Errors:      []string{"dns lookup failed"},
+	})
+
+	// the audit file is appended to when it is opened again
+	a, err = newAuditTrail(false, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.record(auditRecord{Check: "dns", Namespace: "kuberhealthy", RunUUID: "5678", Trigger: triggerSchedule, Start: start, End: start, Result: auditResultSkipped, Reason: "the previous run is still in flight"})
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := bytes.Split(bytes.TrimSpace(b), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatal("expected two audit records but got", string(b))
+	}
+	var first, second auditRecord
+	err = json.Unmarshal(lines[0], &first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = json.Unmarshal(lines[1], &second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.RunUUID != "1234" || first.Trigger != triggerManual || first.TriggeredBy != "operator" || first.Result != auditResultFailed || first.Duration != "41s" {
+		t.Fatal("expected the audit record of the run but got", string(lines[0]))
+	}
+	if len(first.Notifications) != 3 || first.Notifications[0] != "events" || first.Notifications[1] != "slack" || first.Notifications[2] != "webhooks" {
+		t.Fatal("expected the notifications sent for the run but got", first.Notifications)
+	}
+	if second.Result != auditResultSkipped || len(second.Reason) == 0 || second.Notifications == nil || len(second.Notifications) != 0 {
+		t.Fatal("expected a skipped run without notifications but got", string(lines[1]))
+	}
+}
+
+func TestAuditTrailForgetsNotifications(t *testing.T) {
+	now := time.Now()
+	a := &auditTrail{notifications: make(map[string]auditNotifications), now: func() time.Time { return now }}
+	a.notified("1234", []string{"slack"})
+	now = now.Add(auditNotificationTTL + time.Minute)
+	a.notified("5678", []string{"webhooks"})
+	if _, ok := a.notifications["1234"]; ok {
+		t.Fatal("expected the notifications of a run that was never recorded to be forgotten")
+	}
+	if _, ok := a.notifications["5678"]; !ok {
+		t.Fatal("expected the notifications of a recent run to be kept")
+	}
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// certCheckName is the name of the khstate the built-in certificate expiry check writes its results to in the
+// Kuberhealthy namespace
+const certCheckName = "certificate-expiry"
+
+// certChecks enables the built-in certificate expiry check
+var certChecks bool
+
+// certCheckAPIServer adds the serving certificate of the API server Kuberhealthy talks to to the certificate expiry
+// check
+var certCheckAPIServer = true
+
+// apiServerAddress is the host:port of the API server Kuberhealthy talks to.  It is blank when the API server is not
+// served over TLS.
+var apiServerAddress string
+
+// certCheckTargets are the host:port TLS endpoints whose certificates are checked
+var certCheckTargets []string
+
+// certCheckSecrets are the namespace/name TLS secrets whose tls.crt certificates are checked
+var certCheckSecrets []string
+
+// certWarningPeriod is how long before a certificate expires the certificate expiry check fails
+var certWarningPeriod = time.Hour * 24 * 14
+
+// certCheckTimeout is how long the certificate expiry check waits to connect to each target
+var certCheckTimeout = time.Second * 10
+
+// certificateChecker checks that the leaf certificates of TLS endpoints and TLS secrets are not expired or about to
+// expire
+type certificateChecker struct {
+	targets       []string // host:port TLS endpoints
+	secrets       []string // namespace/name TLS secrets
+	warningPeriod time.Duration
+	dialTimeout   time.Duration
+	client        kubernetes.Interface
+}
+
+// newCertificateChecker creates a certificate checker from the certificate check flags.  The API server is checked
+// first when certCheckAPIServer is set.
+func newCertificateChecker(client kubernetes.Interface) *certificateChecker {
+	targets := make([]string, 0, len(certCheckTargets)+1)
+	if certCheckAPIServer && len(apiServerAddress) > 0 {
+		targets = append(targets, apiServerAddress)
+	}
+	for _, target := range certCheckTargets {
+		if certCheckAPIServer && target == apiServerAddress {
+			continue
+		}
+		targets = append(targets, target)
+	}
+	return &certificateChecker{
+		targets:       targets,
+		secrets:       certCheckSecrets,
+		warningPeriod: certWarningPeriod,
+		dialTimeout:   certCheckTimeout,
+		client:        client,
+	}
+}
+
+// validateCertCheckFlags ensures the certificate check flags are usable when the certificate check is enabled
+func validateCertCheckFlags() error {
+	if !certChecks {
+		return nil
+	}
+	if !certCheckAPIServer && len(certCheckTargets) == 0 && len(certCheckSecrets) == 0 {
+		return errors.New("certChecks requires certCheckAPIServer or at least one certCheckTargets or certCheckSecrets")
+	}
+	for _, target := range certCheckTargets {
+		_, _, err := net.SplitHostPort(target)
+		if err != nil {
+			return fmt.Errorf("certCheckTargets %s is not host:port: %w", target, err)
+		}
+	}
+	for _, secret := range certCheckSecrets {
+		parts := strings.Split(secret, "/")
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return fmt.Errorf("certCheckSecrets %s is not namespace/name", secret)
+		}
+	}
+	if certWarningPeriod < 0 || certCheckTimeout <= 0 {
+		return errors.New("certWarningPeriod can not be negative and certCheckTimeout must be positive")
+	}
+	return nil
+}
+
+// apiServerTarget returns the host:port of an API server from the host of a kubernetes client configuration, such as
+// https://10.96.0.1:443.  A blank target is returned for API servers that are not served over TLS.
+func apiServerTarget(host string) (string, error) {
+	if !strings.Contains(host, "://") {
+		host = "https://" + host
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the API server address %s: %w", host, err)
+	}
+	if u.Scheme != "https" {
+		return "", nil
+	}
+	port := u.Port()
+	if len(port) == 0 {
+		port = "443"
+	}
+	return net.JoinHostPort(u.Hostname(), port), nil
+}
+
+// run checks every target and secret at the same time and returns the check details with a sub-entry for each of
+// them.  The check fails when any target fails.
+func (cc *certificateChecker) run(ctx context.Context) khstatev1.WorkloadDetails {
+	results := make([]khstatev1.TargetDetails, len(cc.targets)+len(cc.secrets))
+
+	var wg sync.WaitGroup
+	for i, target := range cc.targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			cert, err := cc.dialCertificate(ctx, target)
+			results[i] = cc.targetDetails(target, cert, err)
+		}(i, target)
+	}
+	for i, secret := range cc.secrets {
+		wg.Add(1)
+		go func(i int, secret string) {
+			defer wg.Done()
+			cert, err := cc.secretCertificate(ctx, secret)
+			results[len(cc.targets)+i] = cc.targetDetails(secret, cert, err)
+		}(i, secret)
+	}
+	wg.Wait()
+
+	details := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	details.Namespace = podNamespace
+	details.OK = true
+	details.Targets = results
+	for _, result := range results {
+		if !result.OK {
+			details.OK = false
+			details.Errors = append(details.Errors, result.Errors...)
+		}
+	}
+	return details
+}
+
+// targetDetails returns the result of a target from its leaf certificate, or the error fetching it
+func (cc *certificateChecker) targetDetails(target string, cert *x509.Certificate, err error) khstatev1.TargetDetails {
+	result := khstatev1.TargetDetails{Target: target}
+	if err != nil {
+		result.Errors = []string{err.Error()}
+		return result
+	}
+
+	notAfter := metav1.NewTime(cert.NotAfter)
+	remaining := time.Until(cert.NotAfter)
+	result.NotAfter = &notAfter
+	result.DaysRemaining = int(remaining.Hours() / 24)
+	switch {
+	case remaining <= 0:
+		result.Errors = []string{fmt.Sprintf("Certificate of %s expired %d days ago on %s", target, -result.DaysRemaining, cert.NotAfter.UTC().Format(time.RFC3339))}
+	case remaining <= cc.warningPeriod:
+		result.Errors = []string{fmt.Sprintf("Certificate of %s expires in %d days on %s", target, result.DaysRemaining, cert.NotAfter.UTC().Format(time.RFC3339))}
+	default:
+		result.OK = true
+	}
+	return result
+}
+
+// dialCertificate connects to a host:port target with TLS and returns the leaf certificate it serves.  The
+// certificate is not verified, so that expired and self-signed certificates are still reported.
+func (cc *certificateChecker) dialCertificate(ctx context.Context, target string) (*x509.Certificate, error) {
+	host, _, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to %s to check its certificate: %w", target, err)
+	}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: cc.dialTimeout},
+		Config:    &tls.Config{ServerName: host, InsecureSkipVerify: true},
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, cc.dialTimeout)
+	defer cancel()
+	conn, err := dialer.DialContext(dialCtx, "tcp", target)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to %s to check its certificate: %w", target, err)
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("Failed to connect to %s to check its certificate: no certificate was served", target)
+	}
+	return certs[0], nil
+}
+
+// secretCertificate returns the leaf certificate of the tls.crt of a namespace/name TLS secret
+func (cc *certificateChecker) secretCertificate(ctx context.Context, secret string) (*x509.Certificate, error) {
+	parts := strings.Split(secret, "/")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("Failed to read the certificate of secret %s: the secret must be namespace/name", secret)
+	}
+	s, err := cc.client.CoreV1().Secrets(parts[0]).Get(ctx, parts[1], metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the certificate of secret %s: %w", secret, err)
+	}
+
+	// the leaf certificate is the first certificate of the chain
+	block, _ := pem.Decode(s.Data["tls.crt"])
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("Failed to read the certificate of secret %s: tls.crt does not contain a PEM certificate", secret)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the certificate of secret %s: %w", secret, err)
+	}
+	return cert, nil
+}
+
+// runCertificateCheck runs the certificate expiry check on the DefaultRunInterval and stores its results in the
+// certificate-expiry khstate until the context is canceled.  When checks are sharded, only the kuberhealthy pod the
+// check is assigned to runs it.
+func (k *Kuberhealthy) runCertificateCheck(ctx context.Context) {
+	defer k.wg.Done()
+
+	log.Infoln("Starting check:", podNamespace, "/", certCheckName)
+	checker := newCertificateChecker(kubernetesClient)
+	name := podNamespace + "/" + certCheckName
+	schedule := k.checkSchedules.add(name, checkTiming{interval: DefaultRunInterval})
+	defer k.checkSchedules.remove(name, schedule)
+
+	for {
+		if shardChecks && shardOwner(name) != podHostname {
+			log.Debugln("sharding: skipping run of check", name, "because it is assigned to another pod")
+		} else {
+			start := time.Now()
+			details := checker.run(ctx)
+			if ctx.Err() != nil {
+				log.Infoln("Shutting down check run due to context cancellation:", certCheckName, "in namespace", podNamespace)
+				return
+			}
+			details.RunDuration = time.Since(start).String()
+			details.AuthoritativePod = podHostname
+
+			log.Infoln("Setting state of check", certCheckName, "in namespace", podNamespace, "to", details.OK, details.Errors, details.RunDuration)
+			err := k.storeCheckState(certCheckName, podNamespace, details)
+			if err != nil {
+				log.Errorln("Error storing CRD state for check:", certCheckName, "in namespace", podNamespace, err)
+			}
+		}
+
+		if !schedule.wait(ctx) {
+			log.Infoln("Shutting down check run due to context cancellation:", certCheckName, "in namespace", podNamespace)
+			return
+		}
+	}
+}
+
+// isBuiltinCheck indicates a khstate belongs to a check built into Kuberhealthy rather than to a khcheck or khjob
+func isBuiltinCheck(name string, namespace string) bool {
+	if namespace != podNamespace {
+		return false
+	}
+	return (certChecks && name == certCheckName) || (apiServerLatencyCheck && name == apiServerLatencyCheckName) ||
+		(nodeChecks && name == nodeCheckName) || (rolloutChecks && name == rolloutCheckName)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// certificatePEM returns a self-signed PEM certificate that expires at notAfter
+func certificatePEM(t *testing.T, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ingress.example.com"},
+		NotBefore:    notAfter.Add(-time.Hour * 24 * 365),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// unreachableTarget returns a host:port that refuses connections
+func unreachableTarget(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := listener.Addr().String()
+	listener.Close()
+	return target
+}
+
+// TestCertificateCheckerRun ensures each target and secret gets its own result, and that connection failures are
+// reported distinctly from certificates that are about to expire
+func TestCertificateCheckerRun(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	healthy := strings.TrimPrefix(server.URL, "https://")
+	unreachable := unreachableTarget(t)
+
+	client := fake.NewSimpleClientset(
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "expiring", Namespace: "ingress"},
+			Data:       map[string][]byte{"tls.crt": certificatePEM(t, time.Now().Add(time.Hour*24*3+time.Hour))},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "expired", Namespace: "ingress"},
+			Data:       map[string][]byte{"tls.crt": certificatePEM(t, time.Now().Add(-time.Hour*24*2-time.Hour))},
+		},
+	)
+	checker := &certificateChecker{
+		targets:       []string{healthy, unreachable},
+		secrets:       []string{"ingress/expiring", "ingress/expired", "ingress/missing"},
+		warningPeriod: time.Hour * 24 * 14,
+		dialTimeout:   time.Second * 5,
+		client:        client,
+	}
+
+	details := checker.run(context.Background())
+	if details.OK || len(details.Targets) != 5 || len(details.Errors) != 4 {
+		t.Fatal("expected a failing check with 5 targets and 4 errors but got", details)
+	}
+
+	results := make(map[string]int)
+	for i, result := range details.Targets {
+		results[result.Target] = i
+	}
+	if result := details.Targets[results[healthy]]; !result.OK || result.NotAfter == nil || result.DaysRemaining < 14 {
+		t.Fatal("expected the TLS server's certificate to be OK but got", result)
+	}
+	if result := details.Targets[results[unreachable]]; result.OK || result.NotAfter != nil || !strings.HasPrefix(result.Errors[0], "Failed to connect to "+unreachable) {
+		t.Fatal("expected a connection failure for the unreachable target but got", result)
+	}
+	if result := details.Targets[results["ingress/expiring"]]; result.OK || result.DaysRemaining != 3 || !strings.Contains(result.Errors[0], "expires in 3 days") {
+		t.Fatal("expected the expiring certificate to fail with the days remaining but got", result)
+	}
+	if result := details.Targets[results["ingress/expired"]]; result.OK || !strings.Contains(result.Errors[0], "expired 2 days ago") {
+		t.Fatal("expected the expired certificate to fail but got", result)
+	}
+	if result := details.Targets[results["ingress/missing"]]; result.OK || !strings.HasPrefix(result.Errors[0], "Failed to read the certificate of secret ingress/missing") {
+		t.Fatal("expected a read failure for the missing secret but got", result)
+	}
+}
+
+// TestCertificateCheckerDialTimeout ensures a target that accepts connections but never completes a TLS handshake
+// fails once the dial timeout passes
+func TestCertificateCheckerDialTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	checker := &certificateChecker{dialTimeout: time.Millisecond * 200}
+	start := time.Now()
+	_, err = checker.dialCertificate(context.Background(), listener.Addr().String())
+	if err == nil || !strings.HasPrefix(err.Error(), "Failed to connect to") {
+		t.Fatal("expected a connection failure but got", err)
+	}
+	if time.Since(start) > time.Second*2 {
+		t.Fatal("expected the dial timeout to be respected but the dial took", time.Since(start))
+	}
+}
+
+// TestValidateCertCheckFlags ensures certificate check targets and secrets are validated when the check is enabled
+func TestValidateCertCheckFlags(t *testing.T) {
+	defer func() {
+		certChecks = false
+		certCheckAPIServer = true
+		certCheckTargets = nil
+		certCheckSecrets = nil
+	}()
+
+	certChecks = true
+	if err := validateCertCheckFlags(); err != nil {
+		t.Fatal("expected the API server to be checked without targets or secrets but got", err)
+	}
+	certCheckAPIServer = false
+	if validateCertCheckFlags() == nil {
+		t.Fatal("expected an error without the API server, targets or secrets")
+	}
+	certCheckTargets = []string{"kubernetes.default:443"}
+	certCheckSecrets = []string{"ingress/tls"}
+	if err := validateCertCheckFlags(); err != nil {
+		t.Fatal(err)
+	}
+	certCheckTargets = []string{"kubernetes.default"}
+	if validateCertCheckFlags() == nil {
+		t.Fatal("expected an error for a target without a port")
+	}
+	certCheckTargets = nil
+	certCheckSecrets = []string{"tls"}
+	if validateCertCheckFlags() == nil {
+		t.Fatal("expected an error for a secret without a namespace")
+	}
+}
+
+func TestAPIServerTarget(t *testing.T) {
+	tests := map[string]string{
+		"https://10.96.0.1:443":         "10.96.0.1:443",
+		"https://api.example.com":       "api.example.com:443",
+		"api.example.com:6443":          "api.example.com:6443",
+		"https://[fd00::1]:6443/prefix": "[fd00::1]:6443",
+		"http://localhost:8080":         "",
+	}
+	for host, expected := range tests {
+		target, err := apiServerTarget(host)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != expected {
+			t.Error("expected the API server", host, "to be checked at", expected, "but got", target)
+		}
+	}
+}
+
+// TestNewCertificateCheckerAPIServer ensures the API server is checked first and only once
+func TestNewCertificateCheckerAPIServer(t *testing.T) {
+	defer func() {
+		apiServerAddress = ""
+		certCheckAPIServer = true
+		certCheckTargets = nil
+	}()
+
+	apiServerAddress = "10.96.0.1:443"
+	certCheckTargets = []string{"ingress.example.com:443", "10.96.0.1:443"}
+	checker := newCertificateChecker(fake.NewSimpleClientset())
+	if len(checker.targets) != 2 || checker.targets[0] != "10.96.0.1:443" || checker.targets[1] != "ingress.example.com:443" {
+		t.Fatal("expected the API server and the ingress to be checked but got", checker.targets)
+	}
+
+	certCheckAPIServer = false
+	certCheckTargets = []string{"ingress.example.com:443"}
+	checker = newCertificateChecker(fake.NewSimpleClientset())
+	if len(checker.targets) != 1 || checker.targets[0] != "ingress.example.com:443" {
+		t.Fatal("expected only the ingress to be checked but got", checker.targets)
+	}
+}
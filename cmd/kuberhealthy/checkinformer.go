@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/tools/cache"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+)
+
+// checkInformerResyncPeriod is how often the khcheck informer replays every cached khcheck through its event
+// handlers, as a fallback for changes that were missed
+const checkInformerResyncPeriod = time.Minute * 5
+
+// checkInformer keeps a local cache of khcheck resources up to date with a shared informer for each namespace that
+// Kuberhealthy operates on.  Whenever a khcheck is added or deleted, or changes a setting that requires its check to be
+// reloaded, a notification is sent on the changes channel.  A khcheck that only changes its run interval or schedule is
+// passed to onScheduleChange instead, so that its check can be retimed without reloading all checks.  The external check
+// loader and the khState reaper read khchecks from the cache instead of listing them from the API.
+type checkInformer struct {
+	informers        []cache.SharedIndexInformer          // one informer for each namespace
+	changes          chan struct{}                        // notified when the khcheck configuration changes
+	dirty            chan struct{}                        // coalesces changes seen by the event handlers until they are processed
+	knownSettings    map[string]string                    // the settings fingerprint of each khcheck by namespace/name, without its timing
+	knownTimings     map[string]string                    // the run interval and schedule of each khcheck by namespace/name
+	mu               sync.Mutex                           // guards knownSettings and knownTimings
+	onScheduleChange func(kc khcheckv1.KuberhealthyCheck) // called when only the run interval or schedule of a khcheck changes. set before Run
+}
+
+// newCheckInformer creates an informer for the khchecks in the namespaces.  To include all namespaces, pass a single
+// blank namespace.
+func newCheckInformer(namespaces []string) *checkInformer {
+	var lws []cache.ListerWatcher
+	for _, namespace := range namespaces {
+		lws = append(lws, cache.NewListWatchFromClient(khCheckClient.RESTClient(), checkCRDResource, namespace, fields.Everything()))
+	}
+	return newCheckInformerFromListWatches(lws, checkInformerResyncPeriod)
+}
+
+// newCheckInformerFromListWatches creates an informer for the khchecks served by each of the supplied ListerWatchers
+func newCheckInformerFromListWatches(lws []cache.ListerWatcher, resyncPeriod time.Duration) *checkInformer {
+	ci := &checkInformer{
+		changes:       make(chan struct{}, 50),
+		dirty:         make(chan struct{}, 1),
+		knownSettings: make(map[string]string),
+		knownTimings:  make(map[string]string),
+	}
+	for _, lw := range lws {
+		informer := cache.NewSharedIndexInformer(lw, &khcheckv1.KuberhealthyCheck{}, resyncPeriod, cache.Indexers{})
+		ci.addEventHandlers(informer)
+		ci.informers = append(ci.informers, informer)
+	}
+	return ci
+}
+
+// addEventHandlers sets up an informer to keep the cache of khchecks and signal their changes
+func (ci *checkInformer) addEventHandlers(informer cache.SharedIndexInformer) {
+	// managed fields are never used by kuberhealthy, so they are not kept in the cache
+	err := informer.SetTransform(func(obj interface{}) (interface{}, error) {
+		accessor, err := meta.Accessor(obj)
+		if err == nil {
+			accessor.SetManagedFields(nil)
+		}
+		return obj, nil
+	})
+	if err != nil {
+		log.Errorln("khcheck informer: failed to set transform:", err)
+	}
+
+	_, err = informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			kc, ok := obj.(*khcheckv1.KuberhealthyCheck)
+			if !ok {
+				return
+			}
+			log.Debugln("khcheck informer saw an added event for", kc.Namespace+"/"+kc.Name)
+			ci.observe(kc)
+		},
+		UpdateFunc: func(oldObj interface{}, newObj interface{}) {
+			kc, ok := newObj.(*khcheckv1.KuberhealthyCheck)
+			if !ok {
+				return
+			}
+			ci.observe(kc)
+		},
+		DeleteFunc: func(obj interface{}) {
+			// deletions missed while the watch was down arrive as tombstones
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			kc, ok := obj.(*khcheckv1.KuberhealthyCheck)
+			if !ok {
+				return
+			}
+			log.Debugln("khcheck informer saw a deleted event for", kc.Namespace+"/"+kc.Name)
+			ci.forget(kc)
+		},
+	})
+	if err != nil {
+		log.Errorln("khcheck informer: failed to add event handler:", err)
+	}
+}
+
+// Run fills the cache and keeps it up to date until the context is canceled
+func (ci *checkInformer) Run(ctx context.Context) {
+	log.Infoln("khcheck informer starting")
+	go ci.processChanges(ctx)
+	var wg sync.WaitGroup
+	for _, informer := range ci.informers {
+		wg.Add(1)
+		go func(informer cache.SharedIndexInformer) {
+			defer wg.Done()
+			informer.Run(ctx.Done())
+		}(informer)
+	}
+	wg.Wait()
+	log.Infoln("khcheck informer stopped")
+}
+
+// processChanges refreshes the run intervals and the number of active checks from the cache each time the event
+// handlers see a change, then notifies the changes channel without blocking.  A full channel already holds a
+// pending notification.  Changes seen while the previous change is processed are coalesced, so that the initial
+// list of many khchecks does not rescan the cache for each of them.
+func (ci *checkInformer) processChanges(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ci.dirty:
+		}
+
+		ci.refreshCheckSettings()
+		log.Debugln("Signaling that a change was found in external check configuration")
+		select {
+		case ci.changes <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// refreshCheckSettings keeps the run intervals used for startup grace periods, the dependencies of checks and the
+// number of checks limited by maxChecks up to date with the cache and the check overrides of the configuration
+func (ci *checkInformer) refreshCheckSettings() {
+	runIntervals := make(map[string]time.Duration)
+	var activeChecks int
+	khChecks := ci.List()
+	replaceCheckDependencies(khChecks)
+	for _, kc := range khChecks {
+		kc = applyCheckOverrides(configuredCheckOverrides(), kc)
+		runIntervals[kc.Namespace+"/"+kc.Name] = parseCheckRunInterval(kc)
+		if !checkPaused(kc) {
+			activeChecks++
+		}
+	}
+	replaceCheckRunIntervals(runIntervals)
+	setActiveCheckCount(activeChecks)
+}
+
+// HasSynced indicates that the cache has been filled with the initial list of khchecks in every namespace
+func (ci *checkInformer) HasSynced() bool {
+	for _, informer := range ci.informers {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+	return true
+}
+
+// List returns the cached khchecks ordered by namespace and name, the same order the API lists them in
+func (ci *checkInformer) List() []khcheckv1.KuberhealthyCheck {
+	var checks []khcheckv1.KuberhealthyCheck
+	for _, informer := range ci.informers {
+		for _, obj := range informer.GetStore().List() {
+			kc, ok := obj.(*khcheckv1.KuberhealthyCheck)
+			if !ok {
+				continue
+			}
+			checks = append(checks, *kc)
+		}
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		if checks[i].Namespace != checks[j].Namespace {
+			return checks[i].Namespace < checks[j].Namespace
+		}
+		return checks[i].Name < checks[j].Name
+	})
+	return checks
+}
+
+// observe records the settings of an added or updated khcheck and signals a change if the check is new or its
+// settings changed.  A check that only changed its run interval or schedule is retimed instead.  Resyncs replay unchanged
+// khchecks, which do not signal a change.
+func (ci *checkInformer) observe(kc *khcheckv1.KuberhealthyCheck) {
+	mapName := kc.Namespace + "/" + kc.Name
+	if len(kc.Namespace) < 1 || len(kc.Name) < 1 {
+		log.Warningln("khcheck informer got an update from an object with no name or namespace:", mapName)
+		return
+	}
+
+	settings := kc.Spec
+	settings.Paused = checkPaused(*kc)
+	settings.RunInterval = ""
+	settings.Schedule = ""
+	settings.ScheduleTimezone = ""
+	fingerprint := checkConfigFingerprint(settings)
+	timing := strings.Join([]string{kc.Spec.RunInterval, kc.Spec.Schedule, kc.Spec.ScheduleTimezone}, "|")
+	ci.mu.Lock()
+	known, exists := ci.knownSettings[mapName]
+	knownTiming := ci.knownTimings[mapName]
+	ci.knownSettings[mapName] = fingerprint
+	ci.knownTimings[mapName] = timing
+	ci.mu.Unlock()
+
+	if exists && known == fingerprint {
+		if knownTiming != timing {
+			log.Debugln("The run interval or schedule of khcheck", mapName, "has changed")
+			ci.refreshCheckSettings()
+			if ci.onScheduleChange != nil {
+				ci.onScheduleChange(*kc)
+			}
+		}
+		return
+	}
+	if !exists {
+		log.Debugln("First time seeing khcheck of name", mapName)
+	} else {
+		log.Debugln("The khcheck for", mapName, "has changed.")
+	}
+	ci.signalChange()
+}
+
+// forget removes a deleted khcheck and signals a change
+func (ci *checkInformer) forget(kc *khcheckv1.KuberhealthyCheck) {
+	mapName := kc.Namespace + "/" + kc.Name
+	ci.mu.Lock()
+	delete(ci.knownSettings, mapName)
+	delete(ci.knownTimings, mapName)
+	ci.mu.Unlock()
+
+	log.Debugln("Detected khcheck deletion for", mapName)
+	ci.signalChange()
+}
+
+// signalChange marks the cache as changed without blocking
+func (ci *checkInformer) signalChange() {
+	select {
+	case ci.dirty <- struct{}{}:
+	default:
+	}
+}
+
+// updateCheckSchedule changes the timing of a running check to the run interval or schedule of its khcheck,
+// without restarting it
+func (k *Kuberhealthy) updateCheckSchedule(kc khcheckv1.KuberhealthyCheck) {
+	kc = applyCheckOverrides(configuredCheckOverrides(), kc)
+	k.checkSchedules.setTiming(kc.Namespace+"/"+kc.Name, khCheckTiming(kc))
+}
+
+// forEachKHCheck calls fn with each khcheck.  Khchecks are read from the informer cache once it has synced, and
+// listed from the API a page at a time before then.
+func (k *Kuberhealthy) forEachKHCheck(fn func(kc khcheckv1.KuberhealthyCheck)) error {
+	if k.checkInformer == nil || !k.checkInformer.HasSynced() {
+		for _, namespace := range k.TargetNamespaces {
+			err := forEachKHCheck(khCheckClient, namespace, fn)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, kc := range k.checkInformer.List() {
+		fn(kc)
+	}
+	return nil
+}
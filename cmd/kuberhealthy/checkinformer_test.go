@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+)
+
+// testKHCheck returns a khcheck with the supplied run interval
+func testKHCheck(name string, namespace string, runInterval string) *khcheckv1.KuberhealthyCheck {
+	return &khcheckv1.KuberhealthyCheck{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, ResourceVersion: "1"},
+		Spec:       khcheckv1.CheckConfig{RunInterval: runInterval, Timeout: "1m"},
+	}
+}
+
+// expectCheckChange waits for a change notification from the informer, or ensures none arrives
+func expectCheckChange(t *testing.T, ci *checkInformer, expected bool, reason string) {
+	t.Helper()
+	select {
+	case <-ci.changes:
+		if !expected {
+			t.Fatal("expected no change notification when", reason)
+		}
+	case <-time.After(time.Millisecond * 500):
+		if expected {
+			t.Fatal("timed out waiting for a change notification when", reason)
+		}
+	}
+}
+
+// TestCheckInformer ensures new, changed and deleted khchecks signal a change while updates that do not change the
+// check settings do not, that run interval changes retime the check instead, and that the cache serves khchecks in
+// listing order
+func TestCheckInformer(t *testing.T) {
+	previousIntervals := checkRunIntervals
+	defer replaceCheckRunIntervals(previousIntervals)
+	defer setActiveCheckCount(getActiveCheckCount())
+
+	watcher := watch.NewFake()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return &khcheckv1.KuberhealthyCheckList{
+				ListMeta: metav1.ListMeta{ResourceVersion: "1"},
+				Items:    []khcheckv1.KuberhealthyCheck{*testKHCheck("pod-restarts", "kuberhealthy", "5m")},
+			}, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return watcher, nil
+		},
+	}
+	ci := newCheckInformerFromListWatches([]cache.ListerWatcher{lw}, 0)
+	retimed := make(chan string, 1)
+	ci.onScheduleChange = func(kc khcheckv1.KuberhealthyCheck) {
+		retimed <- kc.Spec.RunInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ci.Run(ctx)
+
+	expectCheckChange(t, ci, true, "the initial khchecks are listed")
+	if !cache.WaitForCacheSync(ctx.Done(), ci.HasSynced) {
+		t.Fatal("expected the cache to sync")
+	}
+	if checkGracePeriod("kuberhealthy/pod-restarts") != time.Minute*5 || getActiveCheckCount() != 1 {
+		t.Fatal("expected the run interval and active count of the listed khcheck but got", checkGracePeriod("kuberhealthy/pod-restarts"), getActiveCheckCount())
+	}
+
+	watcher.Add(testKHCheck("dns-status", "kuberhealthy", "2m"))
+	expectCheckChange(t, ci, true, "a khcheck is added")
+
+	relabeled := testKHCheck("dns-status", "kuberhealthy", "2m")
+	relabeled.Labels = map[string]string{"team": "sre"}
+	relabeled.ResourceVersion = "2"
+	watcher.Modify(relabeled)
+	expectCheckChange(t, ci, false, "only the labels of a khcheck change")
+
+	changed := testKHCheck("dns-status", "kuberhealthy", "10m")
+	changed.ResourceVersion = "3"
+	watcher.Modify(changed)
+	expectCheckChange(t, ci, false, "only the run interval of a khcheck changes")
+	select {
+	case interval := <-retimed:
+		if interval != "10m" {
+			t.Fatal("expected the check to be retimed to 10m but got", interval)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the check to be retimed when its run interval changes")
+	}
+	if checkGracePeriod("kuberhealthy/dns-status") != time.Minute*10 || getActiveCheckCount() != 2 {
+		t.Fatal("expected the changed run interval and two active checks but got", checkGracePeriod("kuberhealthy/dns-status"), getActiveCheckCount())
+	}
+
+	changed = testKHCheck("dns-status", "kuberhealthy", "10m")
+	changed.Spec.Timeout = "5m"
+	changed.ResourceVersion = "4"
+	watcher.Modify(changed)
+	expectCheckChange(t, ci, true, "the timeout of a khcheck changes")
+
+	kh := &Kuberhealthy{checkInformer: ci}
+	var names []string
+	err := kh.forEachKHCheck(func(kc khcheckv1.KuberhealthyCheck) {
+		names = append(names, kc.Name)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "dns-status" || names[1] != "pod-restarts" {
+		t.Fatal("expected the cached khchecks in listing order but got", names)
+	}
+
+	watcher.Delete(changed)
+	expectCheckChange(t, ci, true, "a khcheck is deleted")
+	if len(ci.List()) != 1 || getActiveCheckCount() != 1 {
+		t.Fatal("expected only one khcheck to be left but got", ci.List())
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+)
+
+// CheckOverride changes the settings of matching khchecks from the configuration, so that checks can be turned
+// off or tuned by editing the Kuberhealthy configmap instead of each khcheck.  The khchecks themselves are not
+// modified.  When several overrides match a check, later overrides take precedence.
+type CheckOverride struct {
+	Checks      []string          `yaml:"checks"`                // check names or namespace/name pairs to override. "*" matches all checks
+	Disabled    bool              `yaml:"disabled,omitempty"`    // stop running matching checks, the same as pausing them
+	RunInterval string            `yaml:"runInterval,omitempty"` // replaces the runInterval of matching checks, such as "5m"
+	Timeout     string            `yaml:"timeout,omitempty"`     // replaces the timeout of matching checks, such as "2m"
+	Env         map[string]string `yaml:"env,omitempty"`         // environment variables set on every container of matching checks
+}
+
+// validate ensures the override matches checks and that its durations can be parsed
+func (o CheckOverride) validate() error {
+	if len(o.Checks) == 0 {
+		return errors.New("check override must list at least one check")
+	}
+	for _, d := range []struct{ name, value string }{{"runInterval", o.RunInterval}, {"timeout", o.Timeout}} {
+		if len(d.value) == 0 {
+			continue
+		}
+		duration, err := time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("check override for %s has an invalid %s %q: %w", strings.Join(o.Checks, ","), d.name, d.value, err)
+		}
+		if duration <= 0 {
+			return fmt.Errorf("check override for %s must have a %s greater than zero", strings.Join(o.Checks, ","), d.name)
+		}
+	}
+	for name := range o.Env {
+		if len(strings.TrimSpace(name)) == 0 {
+			return fmt.Errorf("check override for %s has an env var with a blank name", strings.Join(o.Checks, ","))
+		}
+	}
+	return nil
+}
+
+// matchesCheck determines if the override applies to the check with the supplied name and namespace
+func (o CheckOverride) matchesCheck(name string, namespace string) bool {
+	for _, c := range o.Checks {
+		c = strings.TrimSpace(c)
+		if c == "*" || c == name || c == namespace+"/"+name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCheckOverrides validates all check overrides and returns all validation errors found
+func validateCheckOverrides(overrides []CheckOverride) error {
+	var errs []string
+	for _, o := range overrides {
+		err := o.validate()
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("invalid check overrides: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// applyCheckOverrides returns the khcheck with the matching overrides applied.  The supplied khcheck is not
+// modified.  Disabled checks are returned as paused.
+func applyCheckOverrides(overrides []CheckOverride, kc khcheckv1.KuberhealthyCheck) khcheckv1.KuberhealthyCheck {
+	var matched []CheckOverride
+	for _, o := range overrides {
+		if o.matchesCheck(kc.Name, kc.Namespace) {
+			matched = append(matched, o)
+		}
+	}
+	if len(matched) == 0 {
+		return kc
+	}
+
+	out := kc.DeepCopy()
+	for _, o := range matched {
+		if o.Disabled {
+			out.Spec.Paused = true
+		}
+		if len(o.RunInterval) > 0 {
+			out.Spec.RunInterval = o.RunInterval
+		}
+		if len(o.Timeout) > 0 {
+			out.Spec.Timeout = o.Timeout
+		}
+		for i := range out.Spec.PodSpec.Containers {
+			out.Spec.PodSpec.Containers[i].Env = setEnvVars(out.Spec.PodSpec.Containers[i].Env, o.Env)
+		}
+	}
+	log.Debugln("Applied", len(matched), "check overrides from the configuration to", kc.Namespace+"/"+kc.Name)
+	return *out
+}
+
+// setEnvVars sets the supplied environment variables on a container's env, replacing variables of the same name
+func setEnvVars(env []apiv1.EnvVar, vars map[string]string) []apiv1.EnvVar {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		replaced := false
+		for i := range env {
+			if env[i].Name == name {
+				env[i] = apiv1.EnvVar{Name: name, Value: vars[name]}
+				replaced = true
+			}
+		}
+		if !replaced {
+			env = append(env, apiv1.EnvVar{Name: name, Value: vars[name]})
+		}
+	}
+	return env
+}
+
+// configuredCheckOverrides returns the check overrides of the current configuration
+func configuredCheckOverrides() []CheckOverride {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.CheckOverrides
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+)
+
+// testOverrideCheck returns a khcheck with a single container that has an env var
+func testOverrideCheck() khcheckv1.KuberhealthyCheck {
+	return khcheckv1.KuberhealthyCheck{
+		ObjectMeta: metav1.ObjectMeta{Name: "dns-status-internal", Namespace: "kuberhealthy"},
+		Spec: khcheckv1.CheckConfig{
+			RunInterval: "2m",
+			Timeout:     "15m",
+			PodSpec: apiv1.PodSpec{
+				Containers: []apiv1.Container{{
+					Name: "main",
+					Env:  []apiv1.EnvVar{{Name: "HOSTNAME", Value: "kubernetes.default"}},
+				}},
+			},
+		},
+	}
+}
+
+func TestApplyCheckOverrides(t *testing.T) {
+	kc := testOverrideCheck()
+	overrides := []CheckOverride{
+		{Checks: []string{"*"}, RunInterval: "10m"},
+		{Checks: []string{"kuberhealthy/dns-status-internal"}, RunInterval: "5m", Env: map[string]string{
+			"HOSTNAME":  "example.com",
+			"NAMESPACE": "kube-system",
+		}},
+		{Checks: []string{"other"}, Disabled: true},
+	}
+
+	overridden := applyCheckOverrides(overrides, kc)
+	if overridden.Spec.RunInterval != "5m" {
+		t.Fatalf("expected the later override to set the run interval, got %s", overridden.Spec.RunInterval)
+	}
+	if overridden.Spec.Timeout != "15m" {
+		t.Fatalf("expected the timeout to be left alone, got %s", overridden.Spec.Timeout)
+	}
+	if overridden.Spec.Paused {
+		t.Fatal("expected the check to not be disabled by an override of another check")
+	}
+
+	env := overridden.Spec.PodSpec.Containers[0].Env
+	if len(env) != 2 || env[0].Name != "HOSTNAME" || env[0].Value != "example.com" || env[1].Name != "NAMESPACE" || env[1].Value != "kube-system" {
+		t.Fatalf("unexpected env vars: %+v", env)
+	}
+
+	// the khcheck is not modified
+	if kc.Spec.RunInterval != "2m" || kc.Spec.PodSpec.Containers[0].Env[0].Value != "kubernetes.default" || len(kc.Spec.PodSpec.Containers[0].Env) != 1 {
+		t.Fatalf("expected the original khcheck to be left alone: %+v", kc.Spec)
+	}
+}
+
+func TestApplyCheckOverridesDisabled(t *testing.T) {
+	overridden := applyCheckOverrides([]CheckOverride{{Checks: []string{"dns-status-internal"}, Disabled: true}}, testOverrideCheck())
+	if !overridden.Spec.Paused {
+		t.Fatal("expected disabled checks to be paused")
+	}
+}
+
+func TestValidateCheckOverrides(t *testing.T) {
+	err := validateCheckOverrides([]CheckOverride{{Checks: []string{"*"}, RunInterval: "5m", Timeout: "1m"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, o := range []CheckOverride{
+		{RunInterval: "5m"},
+		{Checks: []string{"*"}, RunInterval: "often"},
+		{Checks: []string{"*"}, Timeout: "-1m"},
+		{Checks: []string{"*"}, Env: map[string]string{" ": "x"}},
+	} {
+		err := validateCheckOverrides([]CheckOverride{o})
+		if err == nil {
+			t.Fatalf("expected an error for the check override %+v", o)
+		}
+		t.Log(err)
+	}
+}
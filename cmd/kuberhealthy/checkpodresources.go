@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// CheckPodResources are the resource requests and limits given to the containers of checker pods that do not set
+// their own.  Quantities use the Kubernetes notation, such as 10m or 64Mi.
+type CheckPodResources struct {
+	Requests map[string]string `yaml:"requests,omitempty"`
+	Limits   map[string]string `yaml:"limits,omitempty"`
+}
+
+// parse parses the quantities of the requests and limits.  A request higher than the limit of the same resource is
+// an error, since no container could be given both.
+func (r CheckPodResources) parse() (apiv1.ResourceRequirements, error) {
+	var requirements apiv1.ResourceRequirements
+	var err error
+	requirements.Requests, err = parseResourceList("requests", r.Requests)
+	if err != nil {
+		return requirements, err
+	}
+	requirements.Limits, err = parseResourceList("limits", r.Limits)
+	if err != nil {
+		return requirements, err
+	}
+	for name, request := range requirements.Requests {
+		limit, ok := requirements.Limits[name]
+		if ok && request.Cmp(limit) > 0 {
+			return requirements, fmt.Errorf("checkPodResources request of %s %s is higher than its limit %s", name, request.String(), limit.String())
+		}
+	}
+	return requirements, nil
+}
+
+// parseResourceList parses the quantity of each resource in a list of checkPodResources
+func parseResourceList(field string, quantities map[string]string) (apiv1.ResourceList, error) {
+	if len(quantities) == 0 {
+		return nil, nil
+	}
+	list := make(apiv1.ResourceList, len(quantities))
+	for name, s := range quantities {
+		quantity, err := resource.ParseQuantity(s)
+		if err != nil {
+			return nil, fmt.Errorf("checkPodResources %s of %s is not a quantity: %w", field, name, err)
+		}
+		if quantity.Sign() < 0 {
+			return nil, fmt.Errorf("checkPodResources %s of %s can not be negative", field, name)
+		}
+		list[apiv1.ResourceName(name)] = quantity
+	}
+	return list, nil
+}
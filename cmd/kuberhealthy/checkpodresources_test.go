@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// TestCheckPodResources ensures checker pod resources are parsed from the configuration and validated
+func TestCheckPodResources(t *testing.T) {
+	c := &Config{}
+	err := yaml.Unmarshal([]byte("checkPodResources:\n  requests:\n    cpu: 10m\n    memory: 32Mi\n  limits:\n    memory: 64Mi\n"), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resources, err := c.CheckPodResources.parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resources.Requests.Cpu().String() != "10m" || resources.Requests.Memory().String() != "32Mi" || resources.Limits.Memory().String() != "64Mi" {
+		t.Fatal("expected the configured resources but got", resources)
+	}
+	if _, ok := resources.Limits[apiv1.ResourceCPU]; ok {
+		t.Fatal("expected no CPU limit but got", resources.Limits.Cpu())
+	}
+
+	resources, err = CheckPodResources{}.parse()
+	if err != nil || resources.Requests != nil || resources.Limits != nil {
+		t.Fatal("expected no resources when unset but got", resources, err)
+	}
+
+	for _, tc := range []struct {
+		resources CheckPodResources
+		err       string
+	}{
+		{resources: CheckPodResources{Requests: map[string]string{"cpu": "lots"}}, err: "requests of cpu is not a quantity"},
+		{resources: CheckPodResources{Limits: map[string]string{"memory": "-1Mi"}}, err: "limits of memory can not be negative"},
+		{resources: CheckPodResources{Requests: map[string]string{"memory": "128Mi"}, Limits: map[string]string{"memory": "64Mi"}}, err: "higher than its limit"},
+	} {
+		_, err := tc.resources.parse()
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Fatalf("expected %v to fail with %q but got %v", tc.resources, tc.err, err)
+		}
+	}
+}
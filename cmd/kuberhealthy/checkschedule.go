@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorhill/cronexpr"
+	log "github.com/sirupsen/logrus"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+)
+
+// checkTiming is when the runs of a check start: at a fixed interval, or at the times of a cron schedule
+type checkTiming struct {
+	interval time.Duration        // the time between the starts of two runs. only used without a cron schedule
+	cron     *cronexpr.Expression // the cron schedule runs start on. nil for checks that run at an interval
+	location *time.Location       // the timezone the cron schedule is evaluated in
+}
+
+// parseCronSchedule parses the cron schedule and timezone of a khcheck.  A nil expression is returned when the
+// khcheck has no schedule.
+func parseCronSchedule(schedule string, timezone string) (*cronexpr.Expression, *time.Location, error) {
+	if len(schedule) == 0 {
+		return nil, nil, nil
+	}
+	expr, err := cronexpr.Parse(schedule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid schedule %q: %w", schedule, err)
+	}
+	location := time.UTC
+	if len(timezone) > 0 {
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid scheduleTimezone %q: %w", timezone, err)
+		}
+	}
+	return expr, location, nil
+}
+
+// newCheckTiming returns the timing of a check with the supplied run interval and cron schedule.  Checks with a
+// schedule that can not be parsed fall back to the run interval.
+func newCheckTiming(name string, interval time.Duration, schedule string, timezone string) checkTiming {
+	expr, location, err := parseCronSchedule(schedule, timezone)
+	if err != nil {
+		log.Errorln("Error parsing the schedule of check", name+":", err, "Running the check every", interval, "instead")
+		return checkTiming{interval: interval}
+	}
+	return checkTiming{interval: interval, cron: expr, location: location}
+}
+
+// khCheckTiming returns the timing of a khcheck
+func khCheckTiming(kc khcheckv1.KuberhealthyCheck) checkTiming {
+	return newCheckTiming(kc.Namespace+"/"+kc.Name, parseCheckRunInterval(kc), kc.Spec.Schedule, kc.Spec.ScheduleTimezone)
+}
+
+// next returns when the run after a run started at the supplied time is due.  A zero time is returned when a cron
+// schedule has no more runs.
+func (t checkTiming) next(lastRun time.Time) time.Time {
+	if t.cron != nil {
+		return t.cron.Next(lastRun.In(t.location))
+	}
+	return lastRun.Add(t.interval)
+}
+
+// period returns the time between the next two runs after the supplied time.  For checks that run at an
+// interval, this is the interval.  It is used where a check needs a run interval, such as for startup grace
+// periods.
+func (t checkTiming) period(now time.Time) time.Duration {
+	if t.cron == nil {
+		return t.interval
+	}
+	times := t.cron.NextN(now.In(t.location), 2)
+	if len(times) < 2 {
+		return t.interval
+	}
+	return times[1].Sub(times[0])
+}
+
+// checkSchedule times the runs of a single check.  Each check has a schedule of its own, so that the timing of one
+// check can change without restarting the other checks.
+type checkSchedule struct {
+	mu           sync.Mutex
+	timing       checkTiming   // when runs start
+	lastRun      time.Time     // when the current run was started
+	trigger      runTrigger    // what started the current run
+	retryAt      time.Time     // when a failed run is retried before the next run is due. zero when no retry is scheduled
+	retryTrigger runTrigger    // what the retry was requested by
+	changed      chan struct{} // notified when the timing changes while waiting for the next run
+}
+
+// newCheckSchedule creates a schedule with the supplied timing, starting from now
+func newCheckSchedule(timing checkTiming) *checkSchedule {
+	return &checkSchedule{
+		timing:  timing,
+		lastRun: time.Now(),
+		trigger: runTrigger{reason: triggerSchedule},
+		changed: make(chan struct{}, 1),
+	}
+}
+
+// setTiming changes when runs start.  The next run is started when the new timing calls for a run after the
+// current run started, or right away if that time has already passed.
+func (s *checkSchedule) setTiming(timing checkTiming) {
+	s.mu.Lock()
+	s.timing = timing
+	s.mu.Unlock()
+	s.notify()
+}
+
+// retry starts the next run after the supplied backoff, unless the next run is due before then.  The run is recorded
+// as started by the trigger.
+func (s *checkSchedule) retry(backoff time.Duration, trigger runTrigger) {
+	s.mu.Lock()
+	s.retryAt = time.Now().Add(backoff)
+	s.retryTrigger = trigger
+	s.mu.Unlock()
+	s.notify()
+}
+
+// notify wakes a wait for the next run so that it recalculates when the next run is due
+func (s *checkSchedule) notify() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// next returns when the next run is due
+func (s *checkSchedule) next() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := s.timing.next(s.lastRun)
+	if !s.retryAt.IsZero() && (next.IsZero() || s.retryAt.Before(next)) {
+		return s.retryAt
+	}
+	return next
+}
+
+// wait blocks until the next run is due and records it as started.  false is returned if the context ends first.
+// A cron schedule without more runs waits until its timing changes.
+func (s *checkSchedule) wait(ctx context.Context) bool {
+	for {
+		var timer *time.Timer
+		var due <-chan time.Time
+		next := s.next()
+		if !next.IsZero() {
+			timer = time.NewTimer(time.Until(next))
+			due = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			stopTimer(timer)
+			return false
+		case <-s.changed:
+			stopTimer(timer)
+			continue
+		case now := <-due:
+			s.mu.Lock()
+			s.lastRun = now
+			s.trigger = runTrigger{reason: triggerSchedule}
+			if !s.retryAt.IsZero() && !now.Before(s.retryAt) {
+				s.trigger = s.retryTrigger
+			}
+			s.retryAt = time.Time{}
+			s.retryTrigger = runTrigger{}
+			s.mu.Unlock()
+			return true
+		}
+	}
+}
+
+// currentTrigger returns what started the current run
+func (s *checkSchedule) currentTrigger() runTrigger {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.trigger
+}
+
+// stopTimer stops a timer that may be nil
+func stopTimer(t *time.Timer) {
+	if t != nil {
+		t.Stop()
+	}
+}
+
+// checkSchedules holds the schedules of the running checks by namespace/name
+type checkSchedules struct {
+	mu        sync.Mutex
+	schedules map[string]*checkSchedule
+}
+
+// add creates the schedule of a check that is started
+func (cs *checkSchedules) add(name string, timing checkTiming) *checkSchedule {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.schedules == nil {
+		cs.schedules = make(map[string]*checkSchedule)
+	}
+	s := newCheckSchedule(timing)
+	cs.schedules[name] = s
+	return s
+}
+
+// remove removes the schedule of a check that stopped, unless the check was started again with another schedule
+func (cs *checkSchedules) remove(name string, s *checkSchedule) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if cs.schedules[name] == s {
+		delete(cs.schedules, name)
+	}
+}
+
+// retry retries a failed run of a running check after the supplied backoff.  false is returned if the check is not
+// running.
+func (cs *checkSchedules) retry(name string, backoff time.Duration, trigger runTrigger) bool {
+	cs.mu.Lock()
+	s, ok := cs.schedules[name]
+	cs.mu.Unlock()
+	if !ok {
+		return false
+	}
+	s.retry(backoff, trigger)
+	return true
+}
+
+// runNow starts the next run of a running check right away instead of waiting for its interval or schedule.  The run
+// is recorded as triggered by the supplied user.  false is returned if the check is not running.
+func (cs *checkSchedules) runNow(name string, by string) bool {
+	return cs.retry(name, 0, runTrigger{reason: triggerManual, by: by})
+}
+
+// setTiming changes when the runs of a running check start.  false is returned if the check is not running.
+func (cs *checkSchedules) setTiming(name string, timing checkTiming) bool {
+	cs.mu.Lock()
+	s, ok := cs.schedules[name]
+	cs.mu.Unlock()
+	if !ok {
+		return false
+	}
+	if timing.cron != nil {
+		log.Infoln("Changing the schedule of check", name)
+	} else {
+		log.Infoln("Changing the run interval of check", name, "to", timing.interval)
+	}
+	s.setTiming(timing)
+	return true
+}
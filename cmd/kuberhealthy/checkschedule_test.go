@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+)
+
+// TestCheckScheduleSetTiming ensures that shortening the run interval of a waiting check starts its next run
+// on the new interval instead of the old one
+func TestCheckScheduleSetTiming(t *testing.T) {
+	s := newCheckSchedule(checkTiming{interval: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ran := make(chan bool, 1)
+	go func() {
+		ran <- s.wait(ctx)
+	}()
+
+	time.Sleep(time.Millisecond * 50)
+	s.setTiming(checkTiming{interval: time.Millisecond * 100})
+	select {
+	case ok := <-ran:
+		if !ok {
+			t.Fatal("expected the next run to start")
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected the next run to start on the new interval")
+	}
+}
+
+// TestCheckScheduleCancel ensures that a waiting check stops right away when its context is canceled
+func TestCheckScheduleCancel(t *testing.T) {
+	s := newCheckSchedule(checkTiming{interval: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	ran := make(chan bool, 1)
+	go func() {
+		ran <- s.wait(ctx)
+	}()
+	cancel()
+	select {
+	case ok := <-ran:
+		if ok {
+			t.Fatal("expected no run after the context was canceled")
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected the wait to end when the context was canceled")
+	}
+}
+
+// TestCheckScheduleRetry ensures a retry starts a run before the next run is due, and only once
+func TestCheckScheduleRetry(t *testing.T) {
+	s := newCheckSchedule(checkTiming{interval: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ran := make(chan bool, 1)
+	go func() {
+		ran <- s.wait(ctx)
+	}()
+
+	time.Sleep(time.Millisecond * 50)
+	s.retry(time.Millisecond*100, runTrigger{reason: triggerRetry, by: "1234"})
+	select {
+	case ok := <-ran:
+		if !ok {
+			t.Fatal("expected the retry to start")
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("expected the retry to start before the next run is due")
+	}
+	if trigger := s.currentTrigger(); trigger.reason != triggerRetry || trigger.by != "1234" {
+		t.Fatal("expected the run to be recorded as a retry but got", trigger)
+	}
+	if next := s.next(); next.Sub(s.lastRun) != time.Hour {
+		t.Fatal("expected the run after the retry to be due one interval later but it is due in", time.Until(next))
+	}
+}
+
+func TestCheckSchedules(t *testing.T) {
+	var cs checkSchedules
+	if cs.setTiming("kuberhealthy/dns-status", checkTiming{interval: time.Minute}) {
+		t.Fatal("expected no schedule for a check that is not running")
+	}
+
+	first := cs.add("kuberhealthy/dns-status", checkTiming{interval: time.Hour})
+	second := cs.add("kuberhealthy/dns-status", checkTiming{interval: time.Hour})
+	cs.remove("kuberhealthy/dns-status", first)
+	if !cs.setTiming("kuberhealthy/dns-status", checkTiming{interval: time.Minute}) {
+		t.Fatal("expected the schedule of a check that was started again to be kept")
+	}
+	if second.next().Sub(second.lastRun) != time.Minute {
+		t.Fatal("expected the interval of the running check to change")
+	}
+	cs.remove("kuberhealthy/dns-status", second)
+	if cs.setTiming("kuberhealthy/dns-status", checkTiming{interval: time.Minute}) {
+		t.Fatal("expected the schedule to be removed")
+	}
+}
+
+// TestCheckTimingCron ensures that cron schedules are evaluated in their timezone, and that the time between runs
+// is used as the run interval of checks on a cron schedule
+func TestCheckTimingCron(t *testing.T) {
+	timing := newCheckTiming("kuberhealthy/business-hours", time.Minute, "0 9-17 * * MON-FRI", "America/New_York")
+	if timing.cron == nil {
+		t.Fatal("expected a cron schedule")
+	}
+
+	// a saturday evening in new york is followed by the first run on monday morning
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	saturday := time.Date(2024, time.March, 2, 20, 0, 0, 0, newYork)
+	expected := time.Date(2024, time.March, 4, 9, 0, 0, 0, newYork)
+	if next := timing.next(saturday); !next.Equal(expected) {
+		t.Fatal("expected the next run at", expected, "but got", next)
+	}
+
+	nightly := khcheckv1.KuberhealthyCheck{Spec: khcheckv1.CheckConfig{RunInterval: "5m", Schedule: "30 2 * * *"}}
+	if interval := parseCheckRunInterval(nightly); interval != time.Hour*24 {
+		t.Fatal("expected a nightly schedule to have a run interval of 24h but got", interval)
+	}
+
+	// invalid schedules fall back to the run interval
+	invalid := newCheckTiming("kuberhealthy/invalid", time.Minute, "not a schedule", "")
+	if invalid.cron != nil || invalid.next(saturday) != saturday.Add(time.Minute) {
+		t.Fatal("expected an invalid schedule to fall back to the run interval")
+	}
+	invalidTimezone := khcheckv1.KuberhealthyCheck{Spec: khcheckv1.CheckConfig{RunInterval: "5m", Schedule: "30 2 * * *", ScheduleTimezone: "Mars/Olympus"}}
+	if interval := parseCheckRunInterval(invalidTimezone); interval != time.Minute*5 {
+		t.Fatal("expected an invalid timezone to fall back to the run interval but got", interval)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/cloudevents"
+)
+
+// cloudEventsURL is the URL of the sink CloudEvents are sent to.  CloudEvents are disabled when blank.
+var cloudEventsURL string
+
+// cloudEventsHeadersFlag holds comma separated key=value headers sent with every CloudEvent
+var cloudEventsHeadersFlag string
+var cloudEventsHeaders map[string]string
+
+// cloudEventsOnEveryRun indicates that a CloudEvent is sent for every run instead of only when the state changes
+var cloudEventsOnEveryRun bool
+
+// clusterName is the name of the cluster used as the source of CloudEvents
+var clusterName string
+
+// defaultCloudEventsSource is the source of CloudEvents when no cluster name is set
+const defaultCloudEventsSource = "kuberhealthy"
+
+// cloudEventTypePrefix prefixes the type of all CloudEvents sent by Kuberhealthy
+const cloudEventTypePrefix = "io.kuberhealthy."
+
+// parseCloudEventsHeaders parses the cloudEventsHeaders flag
+func parseCloudEventsHeaders() error {
+	var err error
+	cloudEventsHeaders, err = parseKeyValueFlag(cloudEventsHeadersFlag)
+	return err
+}
+
+// newCloudEventsSink creates the sink CloudEvents are delivered to.  nil is returned when CloudEvents are disabled.
+func newCloudEventsSink() *cloudevents.Sink {
+	if len(cloudEventsURL) == 0 {
+		return nil
+	}
+	log.Infoln("cloudevents: sending events to", cloudEventsURL)
+	return cloudevents.NewSink(cloudevents.SinkConfig{
+		URL:     cloudEventsURL,
+		Headers: cloudEventsHeaders,
+		Proxy:   outboundProxy(),
+	})
+}
+
+// cloudEventSource returns the source of CloudEvents sent by this Kuberhealthy instance
+func cloudEventSource() string {
+	if len(clusterName) == 0 {
+		return defaultCloudEventsSource
+	}
+	return clusterName
+}
+
+// cloudEventType determines the type of the CloudEvent sent for a run of a workload, such as
+// io.kuberhealthy.check.failed.  A blank type is returned when no event should be sent for the run.
+func cloudEventType(workload khstatev1.KHWorkload, transition stateTransition, everyRun bool) string {
+	var kind string
+	switch workload {
+	case khstatev1.KHJob:
+		kind = "job"
+	default:
+		kind = "check"
+	}
+
+	switch transition {
+	case transitionFailed:
+		return cloudEventTypePrefix + kind + ".failed"
+	case transitionRecovered:
+		return cloudEventTypePrefix + kind + ".recovered"
+	}
+	if everyRun {
+		return cloudEventTypePrefix + kind + ".run"
+	}
+	return ""
+}
+
+// sendStateChangeCloudEvent sends a CloudEvent with the workload details if the workload state changed from the
+// previous OK state, or for every run when cloudEventsOnEveryRun is set.  true is returned when an event was sent.
+func (k *Kuberhealthy) sendStateChangeCloudEvent(name string, namespace string, previousOK bool, details khstatev1.WorkloadDetails) bool {
+	if k.cloudEventsSink == nil {
+		return false
+	}
+
+	eventType := cloudEventType(details.GetKHWorkload(), detectStateTransition(previousOK, details.OK), cloudEventsOnEveryRun)
+	if len(eventType) == 0 {
+		return false
+	}
+
+	log.Debugln("cloudevents: sending", eventType, "event for", namespace+"/"+name)
+	k.cloudEventsSink.Send(cloudevents.NewEvent(uuid.New().String(), cloudEventSource(), eventType, namespace+"/"+name, details))
+	return true
+}
+
+// cloudEventsMetrics returns the Prometheus metrics of the CloudEvents sink.  A blank string is returned when
+// CloudEvents are disabled.
+func (k *Kuberhealthy) cloudEventsMetrics() string {
+	if k.cloudEventsSink == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("# HELP kuberhealthy_cloudevents_dropped_total The number of CloudEvents that could not be delivered\n")
+	b.WriteString("# TYPE kuberhealthy_cloudevents_dropped_total counter\n")
+	b.WriteString(fmt.Sprintf("kuberhealthy_cloudevents_dropped_total %d\n", k.cloudEventsSink.Dropped()))
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/cloudevents"
+)
+
+// TestCloudEventType ensures CloudEvents are typed by workload and state transition
+func TestCloudEventType(t *testing.T) {
+	tests := []struct {
+		workload   khstatev1.KHWorkload
+		transition stateTransition
+		everyRun   bool
+		expected   string
+	}{
+		{khstatev1.KHCheck, transitionFailed, false, "io.kuberhealthy.check.failed"},
+		{khstatev1.KHCheck, transitionRecovered, false, "io.kuberhealthy.check.recovered"},
+		{khstatev1.KHJob, transitionFailed, false, "io.kuberhealthy.job.failed"},
+		{khstatev1.KHCheck, transitionNone, false, ""},
+		{khstatev1.KHCheck, transitionNone, true, "io.kuberhealthy.check.run"},
+		{khstatev1.KHCheck, transitionFailed, true, "io.kuberhealthy.check.failed"},
+	}
+	for _, tt := range tests {
+		eventType := cloudEventType(tt.workload, tt.transition, tt.everyRun)
+		if eventType != tt.expected {
+			t.Fatalf("expected event type %q for workload %s transition %d but got %q", tt.expected, tt.workload, tt.transition, eventType)
+		}
+	}
+}
+
+// TestSendStateChangeCloudEvent ensures a CloudEvent with the check details is sent when a check fails
+func TestSendStateChangeCloudEvent(t *testing.T) {
+	received := make(chan cloudevents.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var e cloudevents.Event
+		err := json.NewDecoder(r.Body).Decode(&e)
+		if err != nil {
+			t.Error("failed to decode event:", err)
+		}
+		received <- e
+	}))
+	defer server.Close()
+
+	previousClusterName := clusterName
+	clusterName = "test-cluster"
+	defer func() { clusterName = previousClusterName }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kh := &Kuberhealthy{cloudEventsSink: cloudevents.NewSink(cloudevents.SinkConfig{URL: server.URL})}
+	go kh.cloudEventsSink.Start(ctx)
+
+	failing := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	failing.Errors = []string{"lookup failed"}
+	kh.sendStateChangeCloudEvent("dns-status", "kuberhealthy", true, failing)
+
+	select {
+	case e := <-received:
+		if e.Type != "io.kuberhealthy.check.failed" || e.Source != "test-cluster" || e.Subject != "kuberhealthy/dns-status" {
+			t.Fatal("unexpected event received:", e)
+		}
+		data, ok := e.Data.(map[string]interface{})
+		if !ok || data["OK"] != false {
+			t.Fatal("expected check details as event data but got", e.Data)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for event delivery")
+	}
+
+	if !strings.Contains(kh.cloudEventsMetrics(), "kuberhealthy_cloudevents_dropped_total 0") {
+		t.Fatal("unexpected cloudevents metrics:", kh.cloudEventsMetrics())
+	}
+}
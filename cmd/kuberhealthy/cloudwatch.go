@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/metrics"
+)
+
+// cloudwatchEnabled publishes check results to CloudWatch as custom metrics
+var cloudwatchEnabled bool
+
+// cloudwatchNamespace is the CloudWatch namespace of the custom metrics
+var cloudwatchNamespace = metrics.DefaultCloudWatchNamespace
+
+// cloudwatchRegion is the AWS region metrics are published to.  The region of the environment is used when blank.
+var cloudwatchRegion string
+
+// cloudwatchDimensionsFlag holds comma separated key=value dimensions published with every metric
+var cloudwatchDimensionsFlag string
+var cloudwatchDimensions map[string]string
+
+// parseCloudWatchDimensions parses the cloudwatchDimensions flag
+func parseCloudWatchDimensions() error {
+	var err error
+	cloudwatchDimensions, err = parseKeyValueFlag(cloudwatchDimensionsFlag)
+	if err != nil {
+		return fmt.Errorf("unable to parse cloudwatchDimensions flag: %w", err)
+	}
+	return nil
+}
+
+// newCloudWatchClient creates the client check results are published to CloudWatch with.  nil is returned when
+// CloudWatch publishing is disabled.  The clusterName is published as the Cluster dimension unless
+// cloudwatchDimensions sets it.
+func newCloudWatchClient() (*metrics.CloudWatchClient, error) {
+	if !cloudwatchEnabled {
+		return nil, nil
+	}
+	dimensions := cloudwatchDimensions
+	if len(clusterName) > 0 {
+		dimensions = mergeStringMaps(map[string]string{"Cluster": clusterName}, cloudwatchDimensions)
+	}
+	log.Infoln("cloudwatch: publishing check results to the", cloudwatchNamespace, "namespace")
+	return metrics.NewCloudWatchClient(metrics.CloudWatchConfig{
+		Namespace:  cloudwatchNamespace,
+		Region:     cloudwatchRegion,
+		Dimensions: dimensions,
+		Proxy:      outboundProxy(),
+	})
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+)
+
+// checkRun is a run of a check that runs in the background
+type checkRun struct {
+	checker *external.Checker
+	cancel  context.CancelFunc // stops the run
+	done    chan struct{}      // closed when the run is done
+}
+
+// inFlight indicates that the run has not finished yet
+func (r *checkRun) inFlight() bool {
+	select {
+	case <-r.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// startCheckRun runs a check once in the background.  The run is queued while maxConcurrentChecks runs are in flight.
+func (k *Kuberhealthy) startCheckRun(ctx context.Context, c *external.Checker, trigger runTrigger) *checkRun {
+	runCtx, runCtxCancel := context.WithCancel(ctx)
+	run := &checkRun{
+		checker: c,
+		cancel:  runCtxCancel,
+		done:    make(chan struct{}),
+	}
+	slots := k.runSlots
+	k.checkRuns.add(run)
+	go func() {
+		defer k.checkRuns.remove(run)
+		defer close(run.done)
+		defer runCtxCancel()
+
+		// wait for a free slot when the most check runs allowed at once are in flight
+		release, ok := k.acquireRunSlot(runCtx, slots, c)
+		if !ok {
+			return
+		}
+		defer release()
+		k.runCheckOnce(runCtx, c, trigger)
+	}()
+	return run
+}
+
+// checkConcurrencyPolicy returns the concurrency policy of a khcheck.  Checks without a concurrency policy, or with
+// an unknown one, use Forbid.
+func checkConcurrencyPolicy(kc khcheckv1.KuberhealthyCheck) khcheckv1.ConcurrencyPolicy {
+	switch kc.Spec.ConcurrencyPolicy {
+	case khcheckv1.ForbidConcurrent, khcheckv1.ReplaceConcurrent, khcheckv1.AllowConcurrent:
+		return kc.Spec.ConcurrencyPolicy
+	case "":
+		return khcheckv1.ForbidConcurrent
+	}
+	log.Warningln("Unknown concurrencyPolicy", kc.Spec.ConcurrencyPolicy, "for check", kc.Namespace+"/"+kc.Name+". Defaulting to", khcheckv1.ForbidConcurrent)
+	return khcheckv1.ForbidConcurrent
+}
+
+// scheduleCheckRun starts a run of a check that is due and returns the runs of the check that are in flight
+// afterwards.  If a previous run is still in flight, the check's concurrency policy decides if the run that is due
+// is skipped (Forbid), replaces the run in flight (Replace) or runs next to it (Allow).
+func (k *Kuberhealthy) scheduleCheckRun(ctx context.Context, c *external.Checker, runs []*checkRun, trigger runTrigger) []*checkRun {
+	var inFlight []*checkRun
+	for _, run := range runs {
+		if run.inFlight() {
+			inFlight = append(inFlight, run)
+		}
+	}
+	if len(inFlight) == 0 {
+		return []*checkRun{k.startCheckRun(ctx, c, trigger)}
+	}
+
+	switch c.ConcurrencyPolicy {
+	case khcheckv1.AllowConcurrent:
+		c.Logger().Infoln("Starting run of check", c.Name(), "in namespace", c.CheckNamespace(), "next to", len(inFlight), "runs in flight")
+		runner := c
+		for _, run := range inFlight {
+			if run.checker == c {
+				runner = c.Clone()
+				break
+			}
+		}
+		return append(inFlight, k.startCheckRun(ctx, runner, trigger))
+	case khcheckv1.ReplaceConcurrent:
+		c.Logger().Infoln("Replacing run in flight of check", c.Name(), "in namespace", c.CheckNamespace())
+		for _, run := range inFlight {
+			run.cancel()
+			<-run.done
+		}
+		err := c.Invalidate(ctx)
+		if err != nil {
+			c.Logger().Errorln("Error invalidating run in flight of check", c.Name(), "in namespace", c.CheckNamespace()+":", err)
+		}
+		return []*checkRun{k.startCheckRun(ctx, c, trigger)}
+	}
+
+	c.Logger().Infoln("Skipping run of check", c.Name(), "in namespace", c.CheckNamespace(), "because the previous run is still in flight")
+	err := recordSkippedRun(c.Name(), c.CheckNamespace())
+	if err != nil {
+		c.Logger().Errorln("Error recording skipped run of check", c.Name(), "in namespace", c.CheckNamespace()+":", err)
+	}
+	k.statusCache.invalidate()
+	k.auditSkippedRun(c, trigger, "the previous run is still in flight")
+	return inFlight
+}
+
+// recordSkippedRun counts a run that was skipped because the previous run was still in flight on the khstate of a
+// check and sets the time it was skipped
+func recordSkippedRun(checkName string, checkNamespace string) error {
+	name := sanitizeResourceName(checkName)
+
+	// a report or run may update the khstate at the same time, so we retry on conflicts
+	var err error
+	for tries := 0; tries < 5; tries++ {
+		var khState khstatev1.KuberhealthyState
+		khState, err = khStateClient.KuberhealthyStates(checkNamespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return errors.New("Error retrieving CRD for: " + name + " " + err.Error())
+		}
+		now := metav1.Now()
+		khState.Spec.SkippedRuns++
+		khState.Spec.LastSkippedRun = &now
+		_, err = khStateClient.KuberhealthyStates(checkNamespace).Update(&khState)
+		if err == nil || !strings.Contains(err.Error(), "the object has been modified") {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+	return err
+}
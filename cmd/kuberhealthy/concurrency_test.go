@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+)
+
+// TestCheckConcurrencyPolicy ensures checks default to Forbid when no or an unknown concurrency policy is set
+func TestCheckConcurrencyPolicy(t *testing.T) {
+	tests := map[khcheckv1.ConcurrencyPolicy]khcheckv1.ConcurrencyPolicy{
+		"":                          khcheckv1.ForbidConcurrent,
+		khcheckv1.ForbidConcurrent:  khcheckv1.ForbidConcurrent,
+		khcheckv1.ReplaceConcurrent: khcheckv1.ReplaceConcurrent,
+		khcheckv1.AllowConcurrent:   khcheckv1.AllowConcurrent,
+		"Sometimes":                 khcheckv1.ForbidConcurrent,
+	}
+	for policy, expected := range tests {
+		kc := khcheckv1.KuberhealthyCheck{
+			ObjectMeta: metav1.ObjectMeta{Name: "dns", Namespace: "kuberhealthy"},
+			Spec:       khcheckv1.CheckConfig{ConcurrencyPolicy: policy},
+		}
+		if checkConcurrencyPolicy(kc) != expected {
+			t.Fatalf("expected concurrency policy %q for %q but got %q", expected, policy, checkConcurrencyPolicy(kc))
+		}
+	}
+}
+
+// TestCheckRunInFlight ensures runs are in flight until they are done
+func TestCheckRunInFlight(t *testing.T) {
+	run := &checkRun{done: make(chan struct{})}
+	if !run.inFlight() {
+		t.Fatal("expected run to be in flight")
+	}
+	close(run.done)
+	if run.inFlight() {
+		t.Fatal("expected done run to not be in flight")
+	}
+}
@@ -2,19 +2,39 @@ package main
 
 import (
 	"context"
+	"crypto/md5"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/codingsince1985/checksum"
+	"github.com/fsnotify/fsnotify"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/federation"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/metrics"
 	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
 )
 
+// configMapDataKey is the key in a configmap's data that holds the Kuberhealthy configuration
+const configMapDataKey = "kuberhealthy.yaml"
+
 // Config holds all configurable options
 type Config struct {
 	kubeConfigFile            string                    `yaml:"kubeConfigFile"`
 	ListenAddress             string                    `yaml:"listenAddress"`
+	TLSCertFile               string                    `yaml:"tlsCertFile,omitempty"`        // serve the web server over TLS with this certificate file
+	TLSKeyFile                string                    `yaml:"tlsKeyFile,omitempty"`         // the key file for the TLS certificate
+	TLSReloadInterval         time.Duration             `yaml:"tlsReloadInterval,omitempty"`  // how often the TLS certificate and key files are checked for a rotated certificate. zero disables reloading
+	TLSReportingCAFile        string                    `yaml:"tlsReportingCAFile,omitempty"` // a CA bundle that checker pods verify the TLS certificate with when they report
 	EnableForceMaster         bool                      `yaml:"enableForceMaster"`
 	LogLevel                  string                    `yaml:"logLevel"`
 	InfluxUsername            string                    `yaml:"influxUsername"`
@@ -22,15 +42,33 @@ type Config struct {
 	InfluxURL                 string                    `yaml:"influxURL"`
 	InfluxDB                  string                    `yaml:"influxDB"`
 	EnableInflux              bool                      `yaml:"enableInflux"`
+	InfluxVersion             int                       `yaml:"influxVersion,omitempty"` // the InfluxDB API version, 1 for username and password or 2 for an API token. defaults to 1
+	InfluxToken               string                    `yaml:"influxToken,omitempty"`   // the API token of InfluxDB 2.x
+	InfluxOrg                 string                    `yaml:"influxOrg,omitempty"`     // the organization of InfluxDB 2.x
+	InfluxBucket              string                    `yaml:"influxBucket,omitempty"`  // the bucket of InfluxDB 2.x
 	ExternalCheckReportingURL string                    `yaml:"externalCheckReportingURL"`
 	MaxKHJobAge               time.Duration             `yaml:"maxKHJobAge"`
 	MaxCheckPodAge            time.Duration             `yaml:"maxCheckPodAge"`
 	MaxCompletedPodCount      int                       `yaml:"maxCompletedPodCount"`
 	MaxErrorPodCount          int                       `yaml:"maxErrorPodCount"`
+	MaxFailureLogBytes        int                       `yaml:"maxFailureLogBytes"` // the amount of checker pod logs kept when a check fails. zero disables log capture
+	CheckHistoryLength        int                       `yaml:"checkHistoryLength"` // the number of recent run results kept in the khstate of each check. zero disables run history
 	StateMetadata             map[string]string         `yaml:"stateMetadata,omitempty"`
+	CheckPodLabels            map[string]string         `yaml:"checkPodLabels,omitempty"`      // labels applied to all checker pods
+	CheckPodAnnotations       map[string]string         `yaml:"checkPodAnnotations,omitempty"` // annotations applied to all checker pods
+	CheckPodResources         CheckPodResources         `yaml:"checkPodResources,omitempty"`   // resource requests and limits of checker pod containers that set none
+	MaintenanceWindows        []MaintenanceWindow       `yaml:"maintenanceWindows,omitempty"`  // recurring windows during which matching checks are suppressed
+	CheckOverrides            []CheckOverride           `yaml:"checkOverrides,omitempty"`      // settings that replace the settings of matching khchecks
+	FederatedClusters         []federation.Cluster      `yaml:"federatedClusters,omitempty"`   // the Kuberhealthy instances of other clusters whose status is aggregated
 	PromMetricsConfig         metrics.PromMetricsConfig `yaml:"promMetricsConfig,omitempty"`
-	TargetNamespace           string                    `yaml:"namespace"` // TargetNamespace sets the namespace that Kuberhealthy will operate in.  By default, this is blank, which means
+	CheckPodImagePullSecrets  []string                  `yaml:"checkPodImagePullSecrets,omitempty"` // names of pull secrets added to all checker pods
+	TargetNamespaces          []string                  `yaml:"namespaces,omitempty"`               // more namespaces to operate in along with TargetNamespace, so that Kuberhealthy can run with namespace-scoped RBAC
+	TargetNamespace           string                    `yaml:"namespace"`                          // TargetNamespace sets the namespace that Kuberhealthy will operate in.  By default, this is blank, which means
 	// all namespaces.  However, for multi-tennant environments you may wish to set this.
+	Flags map[string]interface{} `yaml:",inline"` // other settings set the command line flag of the same name
+
+	reportingCA       string                     // the PEM encoded contents of the TLSReportingCAFile
+	checkPodResources apiv1.ResourceRequirements // the parsed CheckPodResources
 }
 
 // Load loads file from disk
@@ -43,44 +81,216 @@ func (c *Config) Load(file string) error {
 	return yaml.Unmarshal(b, c)
 }
 
-// watchConfig watches the target file (not directory) and notfies the supplied channel with the new md5sum
-// when the content changes.  The interval supplied will be how often the file is polled.  To stop the
-// watcher, close the supplied channel.
+// LoadConfigMap loads the configuration from the data of the configmap with the supplied namespace and name
+func (c *Config) LoadConfigMap(ctx context.Context, client kubernetes.Interface, namespace string, name string) error {
+	data, err := fetchConfigMapData(ctx, client, namespace, name)
+	if err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal([]byte(data), c)
+}
+
+// validateTLS ensures that the TLS certificate and key files are configured together and loads the CA bundle that
+// checker pods verify the certificate with
+func (c *Config) validateTLS() error {
+	if (len(c.TLSCertFile) == 0) != (len(c.TLSKeyFile) == 0) {
+		return errors.New("tlsCertFile and tlsKeyFile must be configured together")
+	}
+	if c.TLSReloadInterval < 0 {
+		return errors.New("tlsReloadInterval can not be negative")
+	}
+	if len(c.TLSReportingCAFile) == 0 {
+		return nil
+	}
+	if len(c.TLSCertFile) == 0 {
+		return errors.New("tlsReportingCAFile requires tlsCertFile and tlsKeyFile")
+	}
+	pem, err := os.ReadFile(c.TLSReportingCAFile)
+	if err != nil {
+		return fmt.Errorf("failed to read tlsReportingCAFile: %w", err)
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM(pem) {
+		return errors.New("tlsReportingCAFile " + c.TLSReportingCAFile + " does not contain a PEM certificate")
+	}
+	c.reportingCA = string(pem)
+	return nil
+}
+
+// imagePullSecrets returns the pull secrets added to all checker pods.  Each secret must exist in the namespace of
+// every check.
+func (c *Config) imagePullSecrets() []apiv1.LocalObjectReference {
+	var secrets []apiv1.LocalObjectReference
+	for _, name := range c.CheckPodImagePullSecrets {
+		name = strings.TrimSpace(name)
+		if len(name) > 0 {
+			secrets = append(secrets, apiv1.LocalObjectReference{Name: name})
+		}
+	}
+	return secrets
+}
+
+// targetNamespaces returns the sorted namespaces that Kuberhealthy operates in, from the namespace setting, which may be
+// a comma separated list, and the namespaces setting.  A single blank namespace, meaning all namespaces, is returned
+// when none are set.
+func (c *Config) targetNamespaces() []string {
+	seen := make(map[string]bool)
+	var namespaces []string
+	for _, namespace := range append(strings.Split(c.TargetNamespace, ","), c.TargetNamespaces...) {
+		namespace = strings.TrimSpace(namespace)
+		if len(namespace) == 0 || seen[namespace] {
+			continue
+		}
+		seen[namespace] = true
+		namespaces = append(namespaces, namespace)
+	}
+	if len(namespaces) == 0 {
+		return []string{metav1.NamespaceAll}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// restartRequiredChanges returns the names of settings that differ between the two configurations but can only
+// take effect when Kuberhealthy restarts
+func restartRequiredChanges(previous *Config, current *Config) []string {
+	var changes []string
+	if strings.Join(previous.targetNamespaces(), ",") != strings.Join(current.targetNamespaces(), ",") {
+		changes = append(changes, "namespace")
+	}
+	if previous.EnableForceMaster != current.EnableForceMaster {
+		changes = append(changes, "enableForceMaster")
+	}
+	changes = append(changes, restartRequiredFlagChanges(previous.Flags, current.Flags)...)
+	return changes
+}
+
+// parseConfigMapFlag parses a configmap reference in the form of namespace/name.  If no namespace is given, the
+// namespace Kuberhealthy runs in is used.
+func parseConfigMapFlag(s string) (string, string, error) {
+	parts := strings.Split(strings.TrimSpace(s), "/")
+	switch {
+	case len(parts) == 1 && len(parts[0]) > 0:
+		return podNamespace, parts[0], nil
+	case len(parts) == 2 && len(parts[0]) > 0 && len(parts[1]) > 0:
+		return parts[0], parts[1], nil
+	}
+	return "", "", fmt.Errorf("invalid configmap %q. expected namespace/name", s)
+}
+
+// fetchConfigMapData fetches the Kuberhealthy configuration from a configmap.  The configuration is read from the
+// kuberhealthy.yaml key, or from the only key if the configmap holds a single value.
+func fetchConfigMapData(ctx context.Context, client kubernetes.Interface, namespace string, name string) (string, error) {
+	configMap, err := client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch configmap %s/%s: %w", namespace, name, err)
+	}
+
+	data, ok := configMap.Data[configMapDataKey]
+	if ok {
+		return data, nil
+	}
+	if len(configMap.Data) == 1 {
+		for _, data := range configMap.Data {
+			return data, nil
+		}
+	}
+	return "", fmt.Errorf("configmap %s/%s has no %s key", namespace, name, configMapDataKey)
+}
+
+// configMapHash fetches the configuration from a configmap and returns its md5sum
+func configMapHash(ctx context.Context, client kubernetes.Interface, namespace string, name string) (string, error) {
+	data, err := fetchConfigMapData(ctx, client, namespace, name)
+	if err != nil {
+		return "", err
+	}
+	sum := md5.Sum([]byte(data))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// configSourceHashFunc returns a func that calculates the hash of the configuration source.  When Kuberhealthy is
+// configured from a configmap, the configmap is hashed.  Otherwise, the file at the supplied path is hashed.
+func configSourceHashFunc(ctx context.Context, filePath string) func() (string, error) {
+	if len(configMapFlag) > 0 && kubernetesClient != nil {
+		return func() (string, error) {
+			namespace, name, err := parseConfigMapFlag(configMapFlag)
+			if err != nil {
+				return "", err
+			}
+			return configMapHash(ctx, kubernetesClient, namespace, name)
+		}
+	}
+	return func() (string, error) {
+		return hashCreator(filePath)
+	}
+}
+
+// watchConfig watches the target file and notfies the supplied channel with the new md5sum when the content
+// changes.  The directory of the file is watched for filesystem events, so that configmap mounts, which replace
+// the file by swapping a symlink, are noticed right away.  The file is also polled at the supplied interval in case
+// an event is missed.  To stop the watcher, cancel the supplied context.  When Kuberhealthy is configured from a
+// configmap, the configmap is polled instead of the file.
 func watchConfig(ctx context.Context, filePath string, interval time.Duration) (chan string, error) {
 
-	log.Infoln("watchConfig: Watching", filePath, "for changes")
+	hash := configSourceHashFunc(ctx, filePath)
+	source := filePath
+	if len(configMapFlag) > 0 {
+		source = "configmap " + configMapFlag
+	}
+
+	log.Infoln("watchConfig: Watching", source, "for changes")
 	c := make(chan string, 1)
 
-	md5sum, err := hashCreator(filePath)
+	md5sum, err := hash()
 	if err != nil {
 		return c, err
 	}
-	log.Infoln("watchConfig: initial hash for", filePath, "is", md5sum)
+	log.Infoln("watchConfig: initial hash for", source, "is", md5sum)
+
+	// filesystem events are only watched for the configuration file.  without them, the file is still polled.
+	var fsEvents chan fsnotify.Event
+	var fsErrors chan error
+	var watcher *fsnotify.Watcher
+	if len(configMapFlag) == 0 {
+		watcher, err = newConfigFileWatcher(filePath)
+		if err != nil {
+			log.Warningln("watchConfig: unable to watch", source, "for filesystem events. Polling it instead:", err)
+		} else {
+			fsEvents = watcher.Events
+			fsErrors = watcher.Errors
+		}
+	}
 
 	// start watching for changes until the context ends
 	go func() {
 		// make a new ticker
 		log.Debugln("watchConfig: starting a ticker with an interval of", interval)
 		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		if watcher != nil {
+			defer watcher.Close()
+		}
 
-		// watch the ticker and survey the file
-		for range ticker.C {
-			// log.Debugln("watchConfig: starting a tick")
-			// check if context is still valid and break the loop if it isnt
+		// watch the ticker and filesystem events and survey the file
+		for {
 			select {
 			case <-ctx.Done():
-				ticker.Stop()
 				close(c)
 				log.Debugln("watchConfig: context closed. shutting down output")
 				return
-			default:
-				// do nothing
+			case <-ticker.C:
+			case event := <-fsEvents:
+				log.Debugln("watchConfig: saw filesystem event", event.String())
+			case err := <-fsErrors:
+				log.Warningln("watchConfig: error watching", source, "for filesystem events:", err)
+				continue
 			}
 
 			// claculate md5sum differences
-			newMD5Sum, err := hashCreator(filePath)
+			newMD5Sum, err := hash()
 			if err != nil {
-				log.Errorln("Error when calculating hash of:", filePath, err)
+				log.Errorln("Error when calculating hash of:", source, err)
+				continue
 			}
 			if newMD5Sum != md5sum {
 				md5sum = newMD5Sum
@@ -94,12 +304,26 @@ func watchConfig(ctx context.Context, filePath string, interval time.Duration) (
 				log.Debugln("watchConfig: done sending file change notification")
 			}
 		}
-		log.Debugln("watchConfig: shutting down")
 	}()
 
 	return c, nil
 }
 
+// newConfigFileWatcher watches the directory of the configuration file for filesystem events.  The directory is
+// watched instead of the file, because editors and configmap mounts replace the file instead of writing to it.
+func newConfigFileWatcher(filePath string) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	err = watcher.Add(filepath.Dir(filePath))
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	return watcher, nil
+}
+
 // startConfigReloadMonitoring watches the target filepath for changes and smooths the output so
 // that multiple signals do not come too rapidly.  Call the returned CancelFunc to shutdown
 // all the background routines safely.
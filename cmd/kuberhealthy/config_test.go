@@ -2,12 +2,19 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
 	"gopkg.in/yaml.v2"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestRenderConfig(t *testing.T) {
@@ -122,3 +129,195 @@ func tempFileWriter(testFile string, t *testing.T) error {
 
 	return nil
 }
+
+// TestParseConfigMapFlag tests parsing of namespace/name configmap references
+func TestParseConfigMapFlag(t *testing.T) {
+	namespace, name, err := parseConfigMapFlag("monitoring/kuberhealthy")
+	if err != nil || namespace != "monitoring" || name != "kuberhealthy" {
+		t.Fatal("unexpected configmap reference:", namespace, name, err)
+	}
+
+	namespace, name, err = parseConfigMapFlag("kuberhealthy")
+	if err != nil || namespace != podNamespace || name != "kuberhealthy" {
+		t.Fatal("expected the pod namespace to be used when no namespace is given:", namespace, name, err)
+	}
+
+	for _, invalid := range []string{"", "/", "monitoring/", "a/b/c"} {
+		_, _, err = parseConfigMapFlag(invalid)
+		if err == nil {
+			t.Fatal("expected an error for configmap reference", invalid)
+		}
+	}
+}
+
+// TestImagePullSecrets ensures the pull secrets of all checker pods are read from the configuration
+func TestImagePullSecrets(t *testing.T) {
+	c := &Config{}
+	if secrets := c.imagePullSecrets(); len(secrets) != 0 {
+		t.Fatal("expected no pull secrets when unset but got", secrets)
+	}
+	c.CheckPodImagePullSecrets = []string{"registry-mirror", " ", " team-registry "}
+	secrets := c.imagePullSecrets()
+	if len(secrets) != 2 || secrets[0].Name != "registry-mirror" || secrets[1].Name != "team-registry" {
+		t.Fatal("expected the named pull secrets but got", secrets)
+	}
+}
+
+// TestLoadConfigMap tests loading configuration from a configmap
+func TestLoadConfigMap(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "kuberhealthy", Namespace: "monitoring"},
+			Data:       map[string]string{configMapDataKey: "logLevel: debug\nlistenAddress: \":8080\"\n"},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-key", Namespace: "monitoring"},
+			Data:       map[string]string{"config.yaml": "logLevel: warn\n"},
+		},
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ambiguous", Namespace: "monitoring"},
+			Data:       map[string]string{"a.yaml": "logLevel: warn\n", "b.yaml": "logLevel: info\n"},
+		},
+	)
+
+	c := Config{LogLevel: "info", EnableInflux: true}
+	err := c.LoadConfigMap(context.Background(), client, "monitoring", "kuberhealthy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.LogLevel != "debug" || c.ListenAddress != ":8080" {
+		t.Fatal("expected settings from the configmap to be loaded but got:", c.LogLevel, c.ListenAddress)
+	}
+	if !c.EnableInflux {
+		t.Fatal("expected settings missing from the configmap to keep their defaults")
+	}
+
+	c = Config{}
+	err = c.LoadConfigMap(context.Background(), client, "monitoring", "other-key")
+	if err != nil || c.LogLevel != "warn" {
+		t.Fatal("expected the only key of a configmap to be loaded:", c.LogLevel, err)
+	}
+
+	err = c.LoadConfigMap(context.Background(), client, "monitoring", "ambiguous")
+	if err == nil {
+		t.Fatal("expected an error for a configmap without a kuberhealthy.yaml key")
+	}
+
+	err = c.LoadConfigMap(context.Background(), client, "monitoring", "missing")
+	if err == nil {
+		t.Fatal("expected an error for a missing configmap")
+	}
+}
+
+// TestSetUpConfigFlagDefaults ensures flags are used as defaults that the configuration file overrides
+func TestSetUpConfigFlagDefaults(t *testing.T) {
+	t.Setenv(KHExternalReportingURL, "http://localhost:8006")
+
+	testFile := filepath.Join(t.TempDir(), "kuberhealthy.yaml")
+	err := os.WriteFile(testFile, []byte("logLevel: debug\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	previousConfigPath, previousLogLevel, previousListenAddress, previousConfig := configPath, logLevelFlag, listenAddressFlag, cfg
+	defer func() {
+		configPath, logLevelFlag, listenAddressFlag, cfg = previousConfigPath, previousLogLevel, previousListenAddress, previousConfig
+	}()
+	configPath = testFile
+	logLevelFlag = "warn"
+	listenAddressFlag = ":9000"
+
+	err = setUpConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LogLevel != "debug" {
+		t.Fatal("expected the configuration file to override the logLevel flag but got:", cfg.LogLevel)
+	}
+	if cfg.ListenAddress != ":9000" {
+		t.Fatal("expected the listenAddress flag to be used as a default but got:", cfg.ListenAddress)
+	}
+
+	// an invalid configuration keeps the current configuration
+	err = os.WriteFile(testFile, []byte("tlsCertFile: /etc/tls/tls.crt\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	current := cfg
+	err = setUpConfig()
+	if err == nil {
+		t.Fatal("expected an error for a TLS certificate without a key")
+	}
+	if cfg != current {
+		t.Fatal("expected the current configuration to be kept when the new configuration is invalid")
+	}
+}
+
+// TestRestartRequiredChanges tests detection of settings that need a restart to take effect
+func TestRestartRequiredChanges(t *testing.T) {
+	previous := &Config{LogLevel: "info", TargetNamespace: "kuberhealthy"}
+	current := &Config{LogLevel: "debug", TargetNamespace: "kuberhealthy"}
+	if len(restartRequiredChanges(previous, current)) != 0 {
+		t.Fatal("expected log level changes to not require a restart")
+	}
+
+	current = &Config{TargetNamespace: "monitoring", EnableForceMaster: true}
+	changes := restartRequiredChanges(previous, current)
+	if len(changes) != 2 || changes[0] != "namespace" || changes[1] != "enableForceMaster" {
+		t.Fatal("unexpected restart required changes:", changes)
+	}
+}
+
+// TestApplyConfigChangesRestartsWebServer ensures the web server restarts when its listen address changes
+func TestApplyConfigChangesRestartsWebServer(t *testing.T) {
+	k := &Kuberhealthy{
+		ListenAddr: "127.0.0.1:0",
+		config:     &Config{ListenAddress: "127.0.0.1:0"},
+	}
+	server := &http.Server{Addr: k.ListenAddr}
+	k.webServer = server
+	serverDoneChan := make(chan error, 1)
+	go func() {
+		serverDoneChan <- server.ListenAndServe()
+	}()
+	time.Sleep(time.Millisecond * 100)
+
+	// changes to other settings leave the web server running
+	k.applyConfigChanges(&Config{ListenAddress: "127.0.0.1:0", LogLevel: "debug"})
+	select {
+	case err := <-serverDoneChan:
+		t.Fatal("expected web server to keep running but it stopped:", err)
+	default:
+	}
+
+	k.applyConfigChanges(&Config{ListenAddress: "127.0.0.1:8081"})
+	select {
+	case err := <-serverDoneChan:
+		if !errors.Is(err, http.ErrServerClosed) {
+			t.Fatal("expected the web server to be shut down for a restart but got:", err)
+		}
+	case <-time.After(time.Second * 10):
+		t.Fatal("expected the web server to be shut down when the listen address changed")
+	}
+	if k.ListenAddr != "127.0.0.1:8081" {
+		t.Fatal("expected the new listen address to be used but got:", k.ListenAddr)
+	}
+}
+
+// TestTargetNamespaces tests combining the namespace and namespaces settings
+func TestTargetNamespaces(t *testing.T) {
+	c := &Config{}
+	if !reflect.DeepEqual(c.targetNamespaces(), []string{""}) {
+		t.Fatal("expected all namespaces when none are set but got", c.targetNamespaces())
+	}
+
+	c = &Config{TargetNamespace: "team-b, team-a", TargetNamespaces: []string{"kuberhealthy", "team-a"}}
+	if !reflect.DeepEqual(c.targetNamespaces(), []string{"kuberhealthy", "team-a", "team-b"}) {
+		t.Fatal("expected the sorted namespaces of both settings but got", c.targetNamespaces())
+	}
+
+	previous := &Config{TargetNamespace: "team-a"}
+	if changes := restartRequiredChanges(previous, c); len(changes) != 1 || changes[0] != "namespace" {
+		t.Fatal("expected a change of namespaces to require a restart but got", changes)
+	}
+}
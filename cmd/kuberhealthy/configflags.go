@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/integrii/flaggy"
+	log "github.com/sirupsen/logrus"
+)
+
+// configFlag is a command line flag that can also be set in the configuration file by its long name
+type configFlag struct {
+	value         reflect.Value // the variable the flag is parsed into
+	initial       interface{}   // the value of the variable after the command line was parsed
+	onCommandLine bool          // the flag was given on the command line, so the configuration file does not change it
+}
+
+// configFlags are the flags that can be set in the configuration file by their long name.  It is populated by
+// registerConfigFlags once the command line is parsed.
+var configFlags map[string]*configFlag
+
+// commandLineOnlyFlags can not be set in the configuration file because they choose the configuration source or
+// make Kuberhealthy exit before the configuration is loaded
+var commandLineOnlyFlags = map[string]bool{
+	"config":             true,
+	"configMap":          true,
+	"lintKHCheck":        true,
+	"lintSkipImageCheck": true,
+}
+
+// restartRequiredFlags are only read when Kuberhealthy starts, so changing them in the configuration file takes
+// effect on the next restart
+var restartRequiredFlags = map[string]bool{
+	"debug":                    true,
+	"emitEvents":               true,
+	"shardChecks":              true,
+	"shardPodSelector":         true,
+	"statusCacheTTL":           true,
+	"cloudEventsURL":           true,
+	"cloudEventsHeaders":       true,
+	"grpcListenAddress":        true,
+	"grpcTLSCertFile":          true,
+	"grpcTLSKeyFile":           true,
+	"grpcTLSClientCAFile":      true,
+	"grpcStatusAPI":            true,
+	"admissionListenAddress":   true,
+	"admissionTLSCertFile":     true,
+	"admissionTLSKeyFile":      true,
+	"admissionDenyUnsafe":      true,
+	"federationInterval":       true,
+	"installExampleChecks":     true,
+	"removeExampleChecks":      true,
+	"examplePassingCheckImage": true,
+	"exampleFailingCheckImage": true,
+	"apiServerLatencyWindow":   true,
+	"webhookURL":               true,
+	"webhookHeaders":           true,
+	"webhookSecret":            true,
+	"leaseName":                true,
+	"leaseDuration":            true,
+	"leaseRenewDeadline":       true,
+	"leaseRetryPeriod":         true,
+	"alertmanagerURL":          true,
+	"alertmanagerHeaders":      true,
+	"slackWebhookURL":          true,
+	"slackChannel":             true,
+	"slackNamespaces":          true,
+	"slackChecks":              true,
+	"authBasicUsersFile":       true,
+	"authTokenReview":          true,
+	"authTokenAudiences":       true,
+	"authOIDCIssuerURL":        true,
+	"authOIDCClientID":         true,
+	"authOIDCUsernameClaim":    true,
+	"authOIDCGroupsClaim":      true,
+	"authAllowedUsers":         true,
+	"authAllowedGroups":        true,
+	"statsdAddress":            true,
+	"statsdPrefix":             true,
+	"statsdTags":               true,
+	"statsdDogStatsD":          true,
+	"otlpEndpoint":             true,
+	"otlpProtocol":             true,
+	"otlpHeaders":              true,
+	"otlpInsecure":             true,
+	"otlpInterval":             true,
+	"cloudwatchEnabled":        true,
+	"cloudwatchNamespace":      true,
+	"cloudwatchRegion":         true,
+	"cloudwatchDimensions":     true,
+	"graphiteAddress":          true,
+	"graphiteProtocol":         true,
+	"graphitePathTemplate":     true,
+	"pushgatewayURL":           true,
+	"pushgatewayJob":           true,
+	"pushgatewayHeaders":       true,
+	"pushgatewayLabels":        true,
+	"kubeAPIQPS":               true,
+	"kubeAPIBurst":             true,
+	"logFormat":                true,
+	"auditLog":                 true,
+	"auditLogFile":             true,
+	"runStore":                 true,
+	"runStorePath":             true,
+	"httpProxy":                true,
+	"httpsProxy":               true,
+	"noProxy":                  true,
+}
+
+// configFieldFlags copy flags that have a field of their own in the configuration into that field.  The fields are
+// set from the configuration file by their yaml key, so the flags are only copied when given on the command line
+// or, for forceMaster, set in the file by the flag name.
+var configFieldFlags = map[string]func(c *Config){
+	"logLevel":       func(c *Config) { c.LogLevel = logLevelFlag },
+	"listenAddress":  func(c *Config) { c.ListenAddress = listenAddressFlag },
+	"tlsCertFile":    func(c *Config) { c.TLSCertFile = tlsCertFileFlag },
+	"tlsKeyFile":     func(c *Config) { c.TLSKeyFile = tlsKeyFileFlag },
+	"forceMaster":    func(c *Config) { c.EnableForceMaster = forceMasterFlag },
+	"enableInflux":   func(c *Config) { c.EnableInflux = enableInfluxFlag },
+	"influxURL":      func(c *Config) { c.InfluxURL = influxURLFlag },
+	"influxDB":       func(c *Config) { c.InfluxDB = influxDBFlag },
+	"influxUsername": func(c *Config) { c.InfluxUsername = influxUsernameFlag },
+}
+
+// registerConfigFlags records the flags that can be set in the configuration file, along with their values after
+// the command line args were parsed.  Flags given in args keep their command line value.
+func registerConfigFlags(flags []*flaggy.Flag, args []string) {
+	configFlags = make(map[string]*configFlag)
+	for _, f := range flags {
+		if len(f.LongName) == 0 || commandLineOnlyFlags[f.LongName] {
+			continue
+		}
+		v := reflect.ValueOf(f.AssignmentVar)
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			continue
+		}
+		configFlags[f.LongName] = &configFlag{
+			value:         v.Elem(),
+			initial:       copyFlagValue(v.Elem()),
+			onCommandLine: flagInArgs(f, args),
+		}
+	}
+}
+
+// flagInArgs determines if a flag was given in the command line args by its long or short name
+func flagInArgs(f *flaggy.Flag, args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name := strings.SplitN(strings.TrimLeft(arg, "-"), "=", 2)[0]
+		if name == f.LongName || (len(f.ShortName) > 0 && name == f.ShortName) {
+			return true
+		}
+	}
+	return false
+}
+
+// copyFlagValue copies the value of a flag variable.  Slices are copied so that appending to the variable does not
+// change the copy.
+func copyFlagValue(v reflect.Value) interface{} {
+	if v.Kind() == reflect.Slice && !v.IsNil() {
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(c, v)
+		return c.Interface()
+	}
+	return v.Interface()
+}
+
+// snapshotConfigFlags returns a func that sets every flag back to its current value
+func snapshotConfigFlags() func() {
+	values := make(map[string]interface{}, len(configFlags))
+	for name, f := range configFlags {
+		values[name] = copyFlagValue(f.value)
+	}
+	return func() {
+		for name, f := range configFlags {
+			f.value.Set(reflect.ValueOf(values[name]))
+		}
+	}
+}
+
+// resetConfigFlags sets every flag back to its command line or default value, so that flags removed from the
+// configuration file are undone when it is loaded again
+func resetConfigFlags() {
+	for _, f := range configFlags {
+		f.value.Set(reflect.ValueOf(copyFlagValue(reflect.ValueOf(f.initial))))
+	}
+}
+
+// applyConfigFlags sets flags from the flag values of the configuration file.  Flags given on the command line
+// take precedence over the file.  The fields of the configuration that are backed by flags are updated with the
+// results.
+func applyConfigFlags(c *Config) error {
+	if configFlags == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(c.Flags))
+	for name := range c.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f, ok := configFlags[name]
+		if !ok {
+			log.Warningln("Ignoring unknown configuration setting", name)
+			continue
+		}
+		if f.onCommandLine {
+			log.Debugln("Configuration setting", name, "is overridden by the command line flag")
+			continue
+		}
+		err := setFlagValue(f.value, c.Flags[name])
+		if err != nil {
+			return fmt.Errorf("invalid configuration setting %s: %w", name, err)
+		}
+	}
+
+	for name, apply := range configFieldFlags {
+		f, ok := configFlags[name]
+		if !ok {
+			continue
+		}
+		_, inFile := c.Flags[name]
+		if f.onCommandLine || inFile {
+			apply(c)
+		}
+	}
+	return nil
+}
+
+// setFlagValue sets a flag variable from a value decoded from yaml.  Maps are accepted for key=value flags, lists
+// for flags that can be given more than once, and strings for durations.
+func setFlagValue(v reflect.Value, value interface{}) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("expected a duration such as 30s, got %v", value)
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		switch t := value.(type) {
+		case string:
+			v.SetString(t)
+		case map[interface{}]interface{}:
+			s, err := keyValueString(t)
+			if err != nil {
+				return err
+			}
+			v.SetString(s)
+		case int, float64, bool:
+			v.SetString(fmt.Sprint(t))
+		default:
+			return fmt.Errorf("expected a string, got %v", value)
+		}
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("expected true or false, got %v", value)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		i, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("expected a whole number, got %v", value)
+		}
+		v.SetInt(int64(i))
+	case reflect.Float64:
+		switch t := value.(type) {
+		case int:
+			v.SetFloat(float64(t))
+		case float64:
+			v.SetFloat(t)
+		default:
+			return fmt.Errorf("expected a number, got %v", value)
+		}
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported flag type %s", v.Type())
+		}
+		var items []string
+		switch t := value.(type) {
+		case string:
+			items = []string{t}
+		case []interface{}:
+			for _, item := range t {
+				s, ok := item.(string)
+				if !ok {
+					return fmt.Errorf("expected a list of strings, got %v", value)
+				}
+				items = append(items, s)
+			}
+		default:
+			return fmt.Errorf("expected a list of strings, got %v", value)
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported flag type %s", v.Type())
+	}
+	return nil
+}
+
+// keyValueString formats a yaml map as the comma separated key=value pairs that key=value flags are given as
+func keyValueString(m map[interface{}]interface{}) (string, error) {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		key, ok := k.(string)
+		if !ok {
+			return "", fmt.Errorf("expected string keys, got %v", k)
+		}
+		pairs = append(pairs, key+"="+fmt.Sprint(v))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ","), nil
+}
+
+// restartRequiredFlagChanges returns the names of flags that differ between the two configuration files but are
+// only read when Kuberhealthy starts
+func restartRequiredFlagChanges(previous map[string]interface{}, current map[string]interface{}) []string {
+	var changes []string
+	for name := range restartRequiredFlags {
+		if f, ok := configFlags[name]; ok && f.onCommandLine {
+			continue
+		}
+		if !reflect.DeepEqual(previous[name], current[name]) {
+			changes = append(changes, name)
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
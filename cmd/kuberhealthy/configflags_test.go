@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/integrii/flaggy"
+	"gopkg.in/yaml.v2"
+)
+
+// testConfigFlags holds the variables of the flags parsed by newTestConfigFlags
+type testConfigFlags struct {
+	name     string
+	enabled  bool
+	count    int
+	ratio    float64
+	timeout  time.Duration
+	targets  []string
+	headers  string
+	excluded string
+}
+
+// newTestConfigFlags parses args with a parser of its own and registers its flags as the configuration file flags
+func newTestConfigFlags(t *testing.T, args []string) *testConfigFlags {
+	previous := configFlags
+	t.Cleanup(func() {
+		configFlags = previous
+	})
+
+	f := &testConfigFlags{name: "default", timeout: time.Second}
+	p := flaggy.NewParser("test")
+	p.String(&f.name, "n", "name", "")
+	p.Bool(&f.enabled, "", "enabled", "")
+	p.Int(&f.count, "", "count", "")
+	p.Float64(&f.ratio, "", "ratio", "")
+	p.Duration(&f.timeout, "", "timeout", "")
+	p.StringSlice(&f.targets, "", "targets", "")
+	p.String(&f.headers, "", "headers", "")
+	p.String(&f.excluded, "", "configMap", "")
+	err := p.ParseArgs(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registerConfigFlags(p.Flags, args)
+	return f
+}
+
+// testConfigWithFlags unmarshals a configuration file
+func testConfigWithFlags(t *testing.T, file string) *Config {
+	c := &Config{}
+	err := yaml.Unmarshal([]byte(file), c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestApplyConfigFlags(t *testing.T) {
+	f := newTestConfigFlags(t, []string{})
+
+	c := testConfigWithFlags(t, `
+logLevel: debug
+name: from-file
+enabled: true
+count: 3
+ratio: 2
+timeout: 30s
+targets:
+  - a:443
+  - b:443
+headers:
+  X-B: two
+  X-A: one
+unknown: ignored
+`)
+	if c.LogLevel != "debug" {
+		t.Fatalf("expected logLevel to be loaded into the config, got %q", c.LogLevel)
+	}
+	if _, ok := c.Flags["logLevel"]; ok {
+		t.Fatal("expected logLevel to not be loaded as a flag")
+	}
+
+	resetConfigFlags()
+	err := applyConfigFlags(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.name != "from-file" || !f.enabled || f.count != 3 || f.ratio != 2 || f.timeout != time.Second*30 {
+		t.Fatalf("flags were not set from the config file: %+v", f)
+	}
+	if !reflect.DeepEqual(f.targets, []string{"a:443", "b:443"}) {
+		t.Fatalf("expected targets to be set from a list, got %v", f.targets)
+	}
+	if f.headers != "X-A=one,X-B=two" {
+		t.Fatalf("expected headers to be set from a map, got %q", f.headers)
+	}
+
+	// a single string is accepted for flags that can be repeated
+	c = testConfigWithFlags(t, "targets: c:443\n")
+	resetConfigFlags()
+	err = applyConfigFlags(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f.targets, []string{"c:443"}) {
+		t.Fatalf("expected targets to be set from a string, got %v", f.targets)
+	}
+
+	// flags removed from the file are set back to their defaults
+	if f.name != "default" || f.enabled || f.count != 0 || f.timeout != time.Second {
+		t.Fatalf("expected flags that are not in the config file to be reset: %+v", f)
+	}
+}
+
+func TestApplyConfigFlagsInvalid(t *testing.T) {
+	newTestConfigFlags(t, []string{})
+
+	for _, file := range []string{
+		"enabled: yes-please\n",
+		"count: many\n",
+		"timeout: 30\n",
+		"timeout: soon\n",
+		"targets: [1, 2]\n",
+	} {
+		resetConfigFlags()
+		err := applyConfigFlags(testConfigWithFlags(t, file))
+		if err == nil {
+			t.Fatalf("expected an error for the config file %q", file)
+		}
+		t.Log(err)
+	}
+}
+
+func TestApplyConfigFlagsCommandLinePrecedence(t *testing.T) {
+	f := newTestConfigFlags(t, []string{"-n=from-flag", "--count=7"})
+
+	resetConfigFlags()
+	err := applyConfigFlags(testConfigWithFlags(t, "name: from-file\ncount: 3\nenabled: true\nconfigMap: other\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.name != "from-flag" || f.count != 7 {
+		t.Fatalf("expected command line flags to take precedence over the config file: %+v", f)
+	}
+	if !f.enabled {
+		t.Fatal("expected flags that were not on the command line to be set from the config file")
+	}
+	if f.excluded != "" {
+		t.Fatalf("expected configMap to not be set from the config file, got %q", f.excluded)
+	}
+}
+
+func TestSnapshotConfigFlags(t *testing.T) {
+	f := newTestConfigFlags(t, []string{})
+	f.targets = []string{"a:443"}
+	restore := snapshotConfigFlags()
+
+	err := applyConfigFlags(testConfigWithFlags(t, "name: changed\ntargets: [b:443]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore()
+	if f.name != "default" || !reflect.DeepEqual(f.targets, []string{"a:443"}) {
+		t.Fatalf("expected the flags to be restored: %+v", f)
+	}
+}
+
+func TestRestartRequiredFlagChanges(t *testing.T) {
+	previous := map[string]interface{}{"leaseName": "a", "maxChecks": 1}
+	current := map[string]interface{}{"leaseName": "b", "maxChecks": 2, "shardChecks": true}
+	changes := restartRequiredFlagChanges(previous, current)
+	if !reflect.DeepEqual(changes, []string{"leaseName", "shardChecks"}) {
+		t.Fatalf("unexpected restart required changes: %v", changes)
+	}
+}
+
+// TestWatchConfigFileEvents ensures that a configuration file change is noticed from filesystem events without
+// waiting for the file to be polled
+func TestWatchConfigFileEvents(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "kuberhealthy.yaml")
+	err := os.WriteFile(filePath, []byte("logLevel: info\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c, err := watchConfig(ctx, filePath, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.WriteFile(filePath, []byte("logLevel: debug\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-c:
+	case <-time.After(time.Second * 10):
+		t.Fatal("expected a change notification from filesystem events")
+	}
+}
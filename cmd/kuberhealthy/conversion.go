@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khcheckv2 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v2"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	khstatev2 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v2"
+)
+
+// conversionPath is the path the API server sends khcheck and khstate conversion reviews to
+const conversionPath = "/convert"
+
+// conversionReview is an apiextensions.k8s.io/v1 ConversionReview.  It is declared here so that Kuberhealthy does
+// not depend on the apiextensions API server module for it.
+type conversionReview struct {
+	metav1.TypeMeta `json:",inline"`
+	Request         *conversionRequest  `json:"request,omitempty"`
+	Response        *conversionResponse `json:"response,omitempty"`
+}
+
+// conversionRequest lists the objects the API server needs converted to the desired apiVersion
+type conversionRequest struct {
+	UID               types.UID              `json:"uid"`
+	DesiredAPIVersion string                 `json:"desiredAPIVersion"`
+	Objects           []runtime.RawExtension `json:"objects"`
+}
+
+// conversionResponse holds the converted objects in the order of the request
+type conversionResponse struct {
+	UID              types.UID              `json:"uid"`
+	ConvertedObjects []runtime.RawExtension `json:"convertedObjects"`
+	Result           metav1.Status          `json:"result"`
+}
+
+// convertObject converts a khcheck or khstate to the desired apiVersion.  Objects that already have it are returned
+// as they are.
+func convertObject(raw []byte, desiredAPIVersion string) ([]byte, error) {
+	var typeMeta metav1.TypeMeta
+	err := json.Unmarshal(raw, &typeMeta)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse object: %w", err)
+	}
+	if typeMeta.APIVersion == desiredAPIVersion {
+		return raw, nil
+	}
+
+	from, to := typeMeta.APIVersion, desiredAPIVersion
+	switch {
+	case typeMeta.Kind == "KuberhealthyCheck" && from == khcheckv2.V1APIVersion && to == khcheckv2.SchemeGroupVersion.String():
+		var src khcheckv1.KuberhealthyCheck
+		err = json.Unmarshal(raw, &src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse khcheck: %w", err)
+		}
+		var dst khcheckv2.KuberhealthyCheck
+		dst.ConvertFrom(&src)
+		return json.Marshal(dst)
+	case typeMeta.Kind == "KuberhealthyCheck" && from == khcheckv2.SchemeGroupVersion.String() && to == khcheckv2.V1APIVersion:
+		var src khcheckv2.KuberhealthyCheck
+		err = json.Unmarshal(raw, &src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse khcheck: %w", err)
+		}
+		var dst khcheckv1.KuberhealthyCheck
+		src.ConvertTo(&dst)
+		return json.Marshal(dst)
+	case typeMeta.Kind == "KuberhealthyState" && from == khstatev2.V1APIVersion && to == khstatev2.SchemeGroupVersion.String():
+		var src khstatev1.KuberhealthyState
+		err = json.Unmarshal(raw, &src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse khstate: %w", err)
+		}
+		var dst khstatev2.KuberhealthyState
+		dst.ConvertFrom(&src)
+		return json.Marshal(dst)
+	case typeMeta.Kind == "KuberhealthyState" && from == khstatev2.SchemeGroupVersion.String() && to == khstatev2.V1APIVersion:
+		var src khstatev2.KuberhealthyState
+		err = json.Unmarshal(raw, &src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse khstate: %w", err)
+		}
+		var dst khstatev1.KuberhealthyState
+		src.ConvertTo(&dst)
+		return json.Marshal(dst)
+	}
+	return nil, fmt.Errorf("unable to convert %s %s to %s", typeMeta.Kind, from, to)
+}
+
+// convertObjects answers a conversion request.  The request fails as a whole when any of its objects can not be
+// converted.
+func convertObjects(req *conversionRequest) *conversionResponse {
+	resp := &conversionResponse{UID: req.UID, Result: metav1.Status{Status: metav1.StatusSuccess}}
+	for _, obj := range req.Objects {
+		converted, err := convertObject(obj.Raw, req.DesiredAPIVersion)
+		if err != nil {
+			return &conversionResponse{UID: req.UID, Result: metav1.Status{Status: metav1.StatusFailure, Message: err.Error()}}
+		}
+		resp.ConvertedObjects = append(resp.ConvertedObjects, runtime.RawExtension{Raw: converted})
+	}
+	return resp
+}
+
+// conversionHandler answers a ConversionReview of khchecks or khstates sent by the API server
+func conversionHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, 32<<20))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return fmt.Errorf("failed to read conversion review: %w", err)
+	}
+	var review conversionReview
+	err = json.Unmarshal(body, &review)
+	if err != nil || review.Request == nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return fmt.Errorf("failed to parse conversion review: %v", err)
+	}
+
+	review.Response = convertObjects(review.Request)
+	if review.Response.Result.Status != metav1.StatusSuccess {
+		log.Errorln("conversion: failed to convert objects to", review.Request.DesiredAPIVersion+":", review.Response.Result.Message)
+	}
+	review.Request = nil
+	b, err := json.Marshal(review)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return fmt.Errorf("failed to marshal conversion review: %w", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(b)
+	return err
+}
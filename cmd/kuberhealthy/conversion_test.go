@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khcheckv2 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v2"
+)
+
+// TestConvertObject ensures khchecks convert between v1 and v2 and that unknown conversions are refused
+func TestConvertObject(t *testing.T) {
+	raw, err := yaml.YAMLToJSON([]byte(validKHCheckManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	converted, err := convertObject(raw, "comcast.github.io/v2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v2 khcheckv2.KuberhealthyCheck
+	err = json.Unmarshal(converted, &v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2.APIVersion != "comcast.github.io/v2" || v2.Spec.Schedule.Interval != "2m" || v2.Spec.Template.Spec.Containers[0].Image != "kuberhealthy/dns-resolution-check:v1.5.0" {
+		t.Fatal("expected a v2 khcheck but got", string(converted))
+	}
+
+	converted, err = convertObject(converted, "comcast.github.io/v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v1 khcheckv1.KuberhealthyCheck
+	err = json.Unmarshal(converted, &v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v1.APIVersion != "comcast.github.io/v1" || v1.Spec.RunInterval != "2m" || v1.Name != "dns" {
+		t.Fatal("expected the v1 khcheck back but got", string(converted))
+	}
+
+	same, err := convertObject(raw, "comcast.github.io/v1")
+	if err != nil || !bytes.Equal(same, raw) {
+		t.Fatal("expected an object with the desired apiVersion to be returned as it is but got", err)
+	}
+
+	_, err = convertObject([]byte(`{"apiVersion":"comcast.github.io/v1","kind":"KuberhealthyJob"}`), "comcast.github.io/v2")
+	if err == nil {
+		t.Fatal("expected a khjob conversion to be refused")
+	}
+}
+
+// TestConversionHandler ensures conversion reviews are answered with the converted objects in order
+func TestConversionHandler(t *testing.T) {
+	check, err := yaml.YAMLToJSON([]byte(validKHCheckManifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := []byte(`{"apiVersion":"comcast.github.io/v1","kind":"KuberhealthyState","metadata":{"name":"dns"},"spec":{"OK":true,"Errors":[],"uuid":"abc"}}`)
+	review := conversionReview{Request: &conversionRequest{
+		UID:               "1234",
+		DesiredAPIVersion: "comcast.github.io/v2",
+		Objects:           []runtime.RawExtension{{Raw: check}, {Raw: state}},
+	}}
+	review.APIVersion = "apiextensions.k8s.io/v1"
+	review.Kind = "ConversionReview"
+	body, err := json.Marshal(review)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	err = conversionHandler(recorder, httptest.NewRequest(http.MethodPost, conversionPath, bytes.NewReader(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out conversionReview
+	err = json.Unmarshal(recorder.Body.Bytes(), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Kind != "ConversionReview" || out.Request != nil || out.Response == nil || out.Response.UID != "1234" || out.Response.Result.Status != metav1.StatusSuccess {
+		t.Fatal("expected a successful conversion review response but got", recorder.Body.String())
+	}
+	if len(out.Response.ConvertedObjects) != 2 || !strings.Contains(string(out.Response.ConvertedObjects[1].Raw), `"currentUUID":"abc"`) {
+		t.Fatal("expected the khcheck and khstate to be converted but got", recorder.Body.String())
+	}
+
+	review.Request.Objects = append(review.Request.Objects, runtime.RawExtension{Raw: []byte(`{"apiVersion":"v1","kind":"Pod"}`)})
+	resp := convertObjects(review.Request)
+	if resp.Result.Status != metav1.StatusFailure || len(resp.ConvertedObjects) > 0 {
+		t.Fatal("expected the conversion to fail as a whole but got", resp)
+	}
+}
@@ -3,12 +3,14 @@ package main
 import (
 	"errors"
 	"strings"
+	"time"
 
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 
 	log "github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
 	khjobv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khjob/v1"
 	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
@@ -28,6 +30,12 @@ func setCheckStateResource(checkName string, checkNamespace string, state khstat
 	}
 	resourceVersion := existingState.GetResourceVersion()
 
+	// carry over the fields that are managed by the check scheduler instead of check runs
+	state.AllowedUUIDs = existingState.Spec.AllowedUUIDs
+	state.SkippedRuns = existingState.Spec.SkippedRuns
+	state.LastSkippedRun = existingState.Spec.LastSkippedRun
+	state.History = existingState.Spec.History
+
 	// set the pod name that wrote the khstate
 	state.AuthoritativePod = podHostname
 	now := metav1.Now() // set the time the khstate was last
@@ -35,13 +43,83 @@ func setCheckStateResource(checkName string, checkNamespace string, state khstat
 
 	khState := khstatev1.NewKuberhealthyState(name, state)
 	khState.SetResourceVersion(resourceVersion)
+
 	// TODO - if "try again" message found in error, then try again
 
 	log.Debugln(checkNamespace, checkName, "writing khstate with ok:", state.OK, "and errors:", state.Errors, "at last run:", state.LastRun)
-	_, err = khStateClient.KuberhealthyStates(checkNamespace).Update(&khState)
+	updatedState, err := khStateClient.KuberhealthyStates(checkNamespace).Update(&khState)
+	if err != nil {
+		return err
+	}
+	err = setKHStateConditions(checkNamespace, updatedState)
+	if err != nil {
+		return err
+	}
+
+	// the khcheck status only mirrors the khstate, so failing to write it does not fail the report
+	if state.GetKHWorkload() == khstatev1.KHCheck {
+		err = setCheckStatusResource(checkName, checkNamespace, updatedState.Spec)
+		if err != nil {
+			log.Errorln("Error setting the status of khcheck", checkName, "in namespace", checkNamespace+":", err)
+		}
+	}
+	return nil
+}
+
+// setKHStateConditions sets the conditions of a khstate from its spec through the status subresource.  The existing
+// conditions of the khstate are carried over so that their transition times only change when their status does.
+// khstate CRDs installed before the status subresource was added do not serve it, so the conditions are skipped for
+// them.
+func setKHStateConditions(checkNamespace string, khState khstatev1.KuberhealthyState) error {
+	khState.SetConditions()
+	_, err := khStateClient.KuberhealthyStates(checkNamespace).UpdateStatus(&khState)
+	if k8sErrors.IsNotFound(err) {
+		log.Debugln("Skipping khstate conditions for", checkNamespace+"/"+khState.Name, "because the khstate CRD has no status subresource")
+		return nil
+	}
 	return err
 }
 
+// setCheckStatusResource mirrors the result of the last run of a check from its khstate onto the status of its
+// khcheck.  Checks that are not run from a khcheck have none, so they are skipped.
+func setCheckStatusResource(checkName string, checkNamespace string, state khstatev1.WorkloadDetails) error {
+
+	// the khcheck spec may be updated at the same time, so we retry on conflicts
+	var err error
+	for tries := 0; tries < 5; tries++ {
+		var kc khcheckv1.KuberhealthyCheck
+		kc, err = khCheckClient.KuberhealthyChecks(checkNamespace).Get(checkName, metav1.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return errors.New("Error retrieving khcheck: " + checkName + " " + err.Error())
+		}
+		kc.Status = newCheckStatus(state)
+		_, err = khCheckClient.KuberhealthyChecks(checkNamespace).UpdateStatus(&kc)
+		if k8sErrors.IsNotFound(err) {
+			log.Debugln("Skipping khcheck status for", checkNamespace+"/"+checkName, "because the khcheck CRD has no status subresource")
+			return nil
+		}
+		if err == nil || !strings.Contains(err.Error(), "the object has been modified") {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+	return err
+}
+
+// newCheckStatus returns the khcheck status that shows the result of the last run stored in a khstate
+func newCheckStatus(state khstatev1.WorkloadDetails) khcheckv1.KuberhealthyCheckStatus {
+	state.SetErrorSummary()
+	return khcheckv1.KuberhealthyCheckStatus{
+		OK:         state.OK,
+		LastRun:    state.LastRun,
+		ErrorCount: state.ErrorCount,
+		LastError:  state.LastError,
+	}
+}
+
 // sanitizeResourceName cleans up the check names for use in CRDs.
 // DNS-1123 subdomains must consist of lower case alphanumeric characters, '-'
 // or '.', and must start and end with an alphanumeric character (e.g.
@@ -65,10 +143,14 @@ func ensureStateResourceExists(checkName string, checkNamespace string, workload
 			log.Infoln("Custom resource not found, creating resource:", name, " - ", err)
 			initialDetails := khstatev1.NewWorkloadDetails(workload)
 			initialState := khstatev1.NewKuberhealthyState(name, initialDetails)
-			_, err := khStateClient.KuberhealthyStates(checkNamespace).Create(&initialState)
+			createdState, err := khStateClient.KuberhealthyStates(checkNamespace).Create(&initialState)
 			if err != nil {
 				return errors.New("Error creating custom resource: " + name + ": " + err.Error())
 			}
+			err = setKHStateConditions(checkNamespace, createdState)
+			if err != nil {
+				return errors.New("Error setting conditions of custom resource: " + name + ": " + err.Error())
+			}
 		} else {
 			return err
 		}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// TestNewCheckStatus ensures the khcheck status mirrors the result of the last run stored in a khstate
+func TestNewCheckStatus(t *testing.T) {
+	details := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	details.Errors = []string{"first error", strings.Repeat("x", 100)}
+	now := metav1.Now()
+	details.LastRun = &now
+
+	status := newCheckStatus(details)
+	if status.OK || status.LastRun != &now {
+		t.Fatal("expected a failed run at the last run time but got", status)
+	}
+	if status.ErrorCount != 2 {
+		t.Fatal("expected an error count of 2 but got", status.ErrorCount)
+	}
+	if len(status.LastError) != khstatev1.LastErrorMaxLength || !strings.HasSuffix(status.LastError, "...") {
+		t.Fatal("expected the last error to be truncated but got", status.LastError)
+	}
+
+	details.OK = true
+	details.Errors = []string{}
+	status = newCheckStatus(details)
+	if !status.OK || status.ErrorCount != 0 || len(status.LastError) != 0 {
+		t.Fatal("expected a passing run without errors but got", status)
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"html/template"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// dashboardPath is the endpoint that serves the HTML status dashboard
+const dashboardPath = "/dashboard"
+
+// defaultDashboardRefresh is how often the dashboard reloads itself unless another refresh is requested
+const defaultDashboardRefresh = time.Second * 10
+
+// dashboardPage is the data the dashboard template is rendered with
+type dashboardPage struct {
+	OK                       bool
+	Errors                   []string
+	Warnings                 []string
+	CurrentMaster            string
+	ActiveMaintenanceWindows []string
+	Checks                   []dashboardRow
+	Jobs                     []dashboardRow
+	Refresh                  int // seconds between reloads of the page. zero disables reloading
+	Updated                  string
+}
+
+// dashboardRow is a single check or job on the dashboard
+type dashboardRow struct {
+	Name        string
+	Status      string // OK, Error, Warning, Paused, Pending, Stale or Suppressed
+	LastRun     string
+	LastRunAge  string
+	RunDuration string
+	Errors      []string
+	DetailsURL  string
+}
+
+// newDashboardPage builds the dashboard for a status page state.  Checks and jobs are sorted by namespace and name,
+// with failing ones first.
+func newDashboardPage(state health.State, refresh time.Duration, now time.Time) dashboardPage {
+	return dashboardPage{
+		OK:                       state.OK,
+		Errors:                   state.Errors,
+		CurrentMaster:            state.CurrentMaster,
+		ActiveMaintenanceWindows: state.ActiveMaintenanceWindows,
+		Checks:                   dashboardRows(state.CheckDetails, now),
+		Jobs:                     dashboardRows(state.JobDetails, now),
+		Refresh:                  int(refresh / time.Second),
+		Updated:                  now.UTC().Format(time.RFC3339),
+	}
+}
+
+// dashboardRows returns the dashboard rows for the details of checks or jobs
+func dashboardRows(details map[string]khstatev1.WorkloadDetails, now time.Time) []dashboardRow {
+	rows := make([]dashboardRow, 0, len(details))
+	for name, d := range details {
+		row := dashboardRow{
+			Name:        name,
+			Status:      dashboardStatus(d),
+			RunDuration: d.RunDuration,
+			Errors:      d.Errors,
+			DetailsURL:  checkDetailsPath + name,
+		}
+		if d.LastRun != nil && !d.LastRun.IsZero() {
+			row.LastRun = d.LastRun.UTC().Format(time.RFC3339)
+			row.LastRunAge = now.Sub(d.LastRun.Time).Truncate(time.Second).String() + " ago"
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if (rows[i].Status == "Error") != (rows[j].Status == "Error") {
+			return rows[i].Status == "Error"
+		}
+		return rows[i].Name < rows[j].Name
+	})
+	return rows
+}
+
+// dashboardStatus returns the status of a check or job as shown on the dashboard
+func dashboardStatus(d khstatev1.WorkloadDetails) string {
+	switch {
+	case d.Paused:
+		return "Paused"
+	case d.Pending:
+		return "Pending"
+	case d.Stale:
+		return "Stale"
+	case d.Suppressed || len(d.SuppressedByDependencies) > 0:
+		return "Suppressed"
+	case d.OK:
+		return "OK"
+	case d.Severity == status.SeverityWarning:
+		return "Warning"
+	}
+	return "Error"
+}
+
+// parseDashboardRefresh parses the refresh query parameter of the dashboard, in seconds
+func parseDashboardRefresh(value string) time.Duration {
+	if len(value) == 0 {
+		return defaultDashboardRefresh
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return defaultDashboardRefresh
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// renderDashboard writes the dashboard as HTML
+func renderDashboard(w io.Writer, page dashboardPage) error {
+	return dashboardTemplate.Execute(w, page)
+}
+
+// dashboardHandler serves the current status of checks and jobs as an HTML page that reloads itself.  Respects the
+// same namespace and check query parameters as the status page (i.e. /dashboard?namespace=default), and the number
+// of seconds between reloads (i.e. /dashboard?refresh=30).  A refresh of 0 disables reloading.
+func (k *Kuberhealthy) dashboardHandler(w http.ResponseWriter, r *http.Request) error {
+	log.Infoln("Client connected to dashboard from", r.RemoteAddr, r.UserAgent())
+
+	values := r.URL.Query()
+	state := k.getCurrentState(parseStatusFilter(values))
+	page := newDashboardPage(state, parseDashboardRefresh(values.Get("refresh")), time.Now())
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	err := renderDashboard(w, page)
+	if err != nil {
+		log.Warningln("Error writing dashboard to caller:", err)
+	}
+	return err
+}
+
+// dashboardTemplate renders the dashboard page
+var dashboardTemplate = template.Must(template.New("dashboard").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+{{if .Refresh}}<meta http-equiv="refresh" content="{{.Refresh}}">{{end}}
+<title>Kuberhealthy - {{if .OK}}OK{{else}}Error{{end}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.2em; margin-top: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 0.4em 0.8em; border-bottom: 1px solid #ddd; vertical-align: top; }
+th { background: #f5f5f5; }
+ul { margin: 0; padding-left: 1.2em; }
+.status { font-weight: bold; }
+.OK { color: #1a7f37; }
+.Error { color: #cf222e; }
+.Paused, .Pending, .Stale, .Suppressed, .Warning { color: #9a6700; }
+.meta { color: #666; font-size: 0.9em; }
+</style>
+</head>
+<body>
+<h1>Kuberhealthy: <span class="status {{if .OK}}OK{{else}}Error{{end}}">{{if .OK}}OK{{else}}Error{{end}}</span></h1>
+<p class="meta">Updated {{.Updated}}{{if .CurrentMaster}} by {{.CurrentMaster}}{{end}}.{{if .Refresh}} Reloads every {{.Refresh}}s.{{end}} <a href="/">JSON</a></p>
+{{with .Errors}}<ul>{{range .}}<li class="Error">{{.}}</li>{{end}}</ul>{{end}}
+{{with .Warnings}}<ul>{{range .}}<li class="Warning">{{.}}</li>{{end}}</ul>{{end}}
+{{with .ActiveMaintenanceWindows}}<p class="meta">Active maintenance windows: {{range $i, $w := .}}{{if $i}}, {{end}}{{$w}}{{end}}</p>{{end}}
+{{define "rows"}}<table>
+<tr><th>Name</th><th>Status</th><th>Last Run</th><th>Duration</th><th>Errors</th></tr>
+{{range .}}<tr>
+<td><a href="{{.DetailsURL}}">{{.Name}}</a></td>
+<td class="status {{.Status}}">{{.Status}}</td>
+<td>{{if .LastRun}}<span title="{{.LastRun}}">{{.LastRunAge}}</span>{{else}}never{{end}}</td>
+<td>{{.RunDuration}}</td>
+<td>{{with .Errors}}<ul>{{range .}}<li>{{.}}</li>{{end}}</ul>{{end}}</td>
+</tr>
+{{end}}</table>{{end}}
+<h2>Checks</h2>
+{{if .Checks}}{{template "rows" .Checks}}{{else}}<p class="meta">No checks have run yet.</p>{{end}}
+{{if .Jobs}}<h2>Jobs</h2>
+{{template "rows" .Jobs}}{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// TestDashboard ensures failing checks are listed first with their errors, and that reported errors are escaped
+func TestDashboard(t *testing.T) {
+	now := time.Now()
+	lastRun := metav1.NewTime(now.Add(-time.Minute * 3))
+
+	ok := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	ok.OK = true
+	ok.LastRun = &lastRun
+	ok.RunDuration = "12s"
+	failing := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	failing.Errors = []string{"<script>alert(1)</script>"}
+	stale := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	stale.OK = true
+	stale.Stale = true
+
+	state := health.NewState()
+	state.OK = false
+	state.CheckDetails["kuberhealthy/a-dns"] = ok
+	state.CheckDetails["kuberhealthy/z-deployment"] = failing
+	state.CheckDetails["kuberhealthy/b-daemonset"] = stale
+
+	page := newDashboardPage(state, time.Second*30, now)
+	var names []string
+	for _, row := range page.Checks {
+		names = append(names, row.Name+"="+row.Status)
+	}
+	if strings.Join(names, ",") != "kuberhealthy/z-deployment=Error,kuberhealthy/a-dns=OK,kuberhealthy/b-daemonset=Stale" {
+		t.Fatal("unexpected dashboard rows:", names)
+	}
+	if page.Checks[1].LastRunAge != "3m0s ago" {
+		t.Fatal("unexpected last run age:", page.Checks[1].LastRunAge)
+	}
+
+	var b bytes.Buffer
+	err := renderDashboard(&b, page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	html := b.String()
+	for _, expected := range []string{
+		`<meta http-equiv="refresh" content="30">`,
+		`<a href="/api/v1/checks/kuberhealthy/a-dns">kuberhealthy/a-dns</a>`,
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+		"<td>12s</td>",
+	} {
+		if !strings.Contains(html, expected) {
+			t.Fatal("expected the dashboard to contain", expected, "but got", html)
+		}
+	}
+	if strings.Contains(html, "<script>") {
+		t.Fatal("expected reported errors to be escaped")
+	}
+
+	b.Reset()
+	err = renderDashboard(&b, newDashboardPage(health.NewState(), 0, now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "http-equiv") || !strings.Contains(b.String(), "No checks have run yet.") {
+		t.Fatal("expected an empty dashboard without reloading but got", b.String())
+	}
+}
+
+func TestParseDashboardRefresh(t *testing.T) {
+	for value, expected := range map[string]time.Duration{"": defaultDashboardRefresh, "30": time.Second * 30, "0": 0, "-1": defaultDashboardRefresh, "soon": defaultDashboardRefresh} {
+		if refresh := parseDashboardRefresh(value); refresh != expected {
+			t.Fatal("expected a refresh of", expected, "for", value, "but got", refresh)
+		}
+	}
+}
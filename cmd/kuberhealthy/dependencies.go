@@ -0,0 +1,151 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// checkDependencies holds the dependencies of each khcheck that has any by namespace/name.  It is kept up to date by
+// all Kuberhealthy instances, so instances that are not running checks suppress dependent checks on their status page.
+var checkDependencies = make(map[string]checkDependency)
+var checkDependenciesMu sync.RWMutex
+
+// checkDependency is the checks a khcheck depends on and what happens to it while they fail
+type checkDependency struct {
+	dependsOn []string // the checks the khcheck depends on by namespace/name
+	policy    khcheckv1.DependencyPolicy
+}
+
+// khCheckDependency returns the dependencies of a khcheck.  Dependencies without a namespace are in the namespace of
+// the khcheck.
+func khCheckDependency(kc khcheckv1.KuberhealthyCheck) checkDependency {
+	d := checkDependency{policy: kc.Spec.DependencyPolicy}
+	if len(d.policy) == 0 {
+		d.policy = khcheckv1.SuppressOnDependencyFailure
+	}
+	for _, name := range kc.Spec.DependsOn {
+		if !strings.Contains(name, "/") {
+			name = kc.Namespace + "/" + name
+		}
+		d.dependsOn = append(d.dependsOn, name)
+	}
+	return d
+}
+
+// replaceCheckDependencies replaces the recorded dependencies with the dependencies of the supplied khchecks
+func replaceCheckDependencies(khChecks []khcheckv1.KuberhealthyCheck) {
+	dependencies := make(map[string]checkDependency)
+	for _, kc := range khChecks {
+		if len(kc.Spec.DependsOn) > 0 {
+			dependencies[kc.Namespace+"/"+kc.Name] = khCheckDependency(kc)
+		}
+	}
+	checkDependenciesMu.Lock()
+	defer checkDependenciesMu.Unlock()
+	checkDependencies = dependencies
+}
+
+// currentCheckDependencies returns the recorded dependencies by namespace/name.  The map is replaced rather than
+// modified, so it can be read without holding the lock.
+func currentCheckDependencies() map[string]checkDependency {
+	checkDependenciesMu.RLock()
+	defer checkDependenciesMu.RUnlock()
+	return checkDependencies
+}
+
+// failingDependencies returns the dependencies of a check that fail, in the order they are declared.  A dependency
+// fails when its khstate is not OK.  Paused dependencies and dependencies without a khstate are not known to fail.  A
+// dependency that depends on the check itself is left out, so that checks that depend on each other can not hide
+// each other's failures or skip each other's runs forever.
+func failingDependencies(name string, dependencies map[string]checkDependency, lookup func(name string) (khstatev1.WorkloadDetails, bool)) []string {
+	var failing []string
+	for _, dependency := range dependencies[name].dependsOn {
+		details, ok := lookup(dependency)
+		if !ok || details.OK || details.Paused {
+			continue
+		}
+		if dependsOn(dependencies, dependency, name, map[string]bool{}) {
+			continue
+		}
+		failing = append(failing, dependency)
+	}
+	return failing
+}
+
+// dependsOn determines if a check depends on another check directly or through the checks it depends on
+func dependsOn(dependencies map[string]checkDependency, name string, other string, seen map[string]bool) bool {
+	if name == other {
+		return true
+	}
+	if seen[name] {
+		return false
+	}
+	seen[name] = true
+	for _, dependency := range dependencies[name].dependsOn {
+		if dependsOn(dependencies, dependency, other, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// reflectorLookup looks up the details of a check by namespace/name in the khstates of the state reflector
+func (k *Kuberhealthy) reflectorLookup(name string) (khstatev1.WorkloadDetails, bool) {
+	if k.stateReflector == nil {
+		return khstatev1.WorkloadDetails{}, false
+	}
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) != 2 {
+		return khstatev1.WorkloadDetails{}, false
+	}
+	return k.stateReflector.WorkloadDetails(parts[1], parts[0])
+}
+
+// skipForFailingDependencies determines if the run of a check should be skipped because its dependencyPolicy is Skip
+// and a check it depends on fails
+func (k *Kuberhealthy) skipForFailingDependencies(name string, namespace string) bool {
+	dependencies := currentCheckDependencies()
+	check := namespace + "/" + name
+	if dependencies[check].policy != khcheckv1.SkipOnDependencyFailure {
+		return false
+	}
+	failing := failingDependencies(check, dependencies, k.reflectorLookup)
+	if len(failing) == 0 {
+		return false
+	}
+	log.Infoln("Skipping run of check", check, "because the checks it depends on fail:", failing)
+	return true
+}
+
+// applyCheckDependencies flags failing checks that depend on a failing check as suppressed by their failing
+// dependencies and recalculates the global OK state and errors of the supplied state without them, so that one root
+// cause does not fail every check that depends on it.  Dependencies are looked up with the supplied function, so
+// that they are found even when the state is filtered.
+func applyCheckDependencies(state health.State, dependencies map[string]checkDependency, lookup func(name string) (khstatev1.WorkloadDetails, bool)) health.State {
+	if len(dependencies) == 0 {
+		return state
+	}
+	for name, details := range state.CheckDetails {
+		if details.OK {
+			continue
+		}
+		failing := failingDependencies(name, dependencies, lookup)
+		if len(failing) == 0 {
+			continue
+		}
+		details.SuppressedByDependencies = failing
+		state.CheckDetails[name] = details
+		state.DependencySuppressedChecks = append(state.DependencySuppressedChecks, name)
+	}
+	if len(state.DependencySuppressedChecks) == 0 {
+		return state
+	}
+	log.Debugln("Status page: checks suppressed by failing dependencies:", state.DependencySuppressedChecks)
+	return rebuildGlobalState(state)
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// testDependencyCheck returns a khcheck in the kuberhealthy namespace that depends on the supplied checks
+func testDependencyCheck(name string, policy khcheckv1.DependencyPolicy, dependsOn ...string) khcheckv1.KuberhealthyCheck {
+	return khcheckv1.KuberhealthyCheck{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kuberhealthy"},
+		Spec:       khcheckv1.CheckConfig{DependsOn: dependsOn, DependencyPolicy: policy},
+	}
+}
+
+func TestKHCheckDependency(t *testing.T) {
+	d := khCheckDependency(testDependencyCheck("deployment", "", "dns", "kube-system/node-readiness"))
+	if d.policy != khcheckv1.SuppressOnDependencyFailure {
+		t.Fatal("expected the dependency policy to default to Suppress but got", d.policy)
+	}
+	if !reflect.DeepEqual(d.dependsOn, []string{"kuberhealthy/dns", "kube-system/node-readiness"}) {
+		t.Fatal("expected dependencies without a namespace to be in the namespace of the check but got", d.dependsOn)
+	}
+}
+
+// TestApplyCheckDependencies ensures failures of checks are suppressed while a check they depend on fails, that the
+// root cause is still reported and that checks depending on each other do not hide each other
+func TestApplyCheckDependencies(t *testing.T) {
+	previous := currentCheckDependencies()
+	defer func() {
+		checkDependenciesMu.Lock()
+		checkDependencies = previous
+		checkDependenciesMu.Unlock()
+	}()
+	replaceCheckDependencies([]khcheckv1.KuberhealthyCheck{
+		testDependencyCheck("deployment", "", "dns"),
+		testDependencyCheck("ingress", "", "deployment", "dns"),
+		testDependencyCheck("storage", "", "node"),
+		testDependencyCheck("a", "", "b"),
+		testDependencyCheck("b", "", "a"),
+		testDependencyCheck("dns", ""),
+	})
+	if _, ok := currentCheckDependencies()["kuberhealthy/dns"]; ok {
+		t.Fatal("expected checks without dependencies to not be recorded")
+	}
+
+	state := health.NewState()
+	failed := func(err string) khstatev1.WorkloadDetails {
+		return khstatev1.WorkloadDetails{Namespace: "kuberhealthy", Errors: []string{err}}
+	}
+	state.CheckDetails["kuberhealthy/dns"] = failed("dns failed")
+	state.CheckDetails["kuberhealthy/deployment"] = failed("deployment failed")
+	state.CheckDetails["kuberhealthy/ingress"] = failed("ingress failed")
+	state.CheckDetails["kuberhealthy/storage"] = failed("storage failed")
+	state.CheckDetails["kuberhealthy/node"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", Errors: []string{"node failed"}, Paused: true}
+	state.CheckDetails["kuberhealthy/a"] = failed("a failed")
+	state.CheckDetails["kuberhealthy/b"] = failed("b failed")
+	lookup := func(name string) (khstatev1.WorkloadDetails, bool) {
+		details, ok := state.CheckDetails[name]
+		return details, ok
+	}
+
+	state = applyPausedChecks(state)
+	state = applyCheckDependencies(state, currentCheckDependencies(), lookup)
+	if !reflect.DeepEqual(state.CheckDetails["kuberhealthy/ingress"].SuppressedByDependencies, []string{"kuberhealthy/deployment", "kuberhealthy/dns"}) {
+		t.Fatal("expected the ingress check to be suppressed by both failing dependencies but got", state.CheckDetails["kuberhealthy/ingress"].SuppressedByDependencies)
+	}
+	if len(state.DependencySuppressedChecks) != 2 {
+		t.Fatal("expected the deployment and ingress checks to be suppressed but got", state.DependencySuppressedChecks)
+	}
+	if len(state.CheckDetails["kuberhealthy/storage"].SuppressedByDependencies) != 0 {
+		t.Fatal("expected a paused dependency to not suppress the storage check")
+	}
+	sort.Strings(state.Errors)
+	if state.OK || !reflect.DeepEqual(state.Errors, []string{"a failed", "b failed", "dns failed", "storage failed"}) {
+		t.Fatal("expected the errors of the root cause and of checks depending on each other but got", state.Errors)
+	}
+	if dashboardStatus(state.CheckDetails["kuberhealthy/deployment"]) != "Suppressed" {
+		t.Fatal("expected the suppressed check to show as suppressed on the dashboard")
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+)
+
+// emitEvents indicates that Kubernetes events should be recorded when checks fail or recover
+var emitEvents = true
+
+const (
+	// eventReasonCheckFailed is the event reason used when a check goes from OK to failing
+	eventReasonCheckFailed = "CheckFailed"
+	// eventReasonCheckRecovered is the event reason used when a failing check becomes OK again
+	eventReasonCheckRecovered = "CheckRecovered"
+	// eventReasonCheckTimedOut is the event reason used when a run does not complete within its timeout
+	eventReasonCheckTimedOut = "CheckTimedOut"
+	// eventReasonCheckMisconfigured is the event reason used when a khcheck is loaded with validation errors
+	eventReasonCheckMisconfigured = "CheckMisconfigured"
+	// eventComponent is the source component shown on events recorded by Kuberhealthy
+	eventComponent = "kuberhealthy"
+	// maxEventMessageLength is the longest event message we record.  Longer error messages are truncated.
+	maxEventMessageLength = 1024
+	// eventBurstSize is the number of events that can be recorded for a single check before rate limiting begins
+	eventBurstSize = 10
+	// eventQPS is the rate at which a rate limited check can record events again (one every five minutes)
+	eventQPS = 1.0 / 300.0
+)
+
+// newEventRecorder creates an event recorder that writes events to the cluster with the supplied client.  Events
+// are rate limited per involved object, so a flapping check can not flood the event stream.  Errors writing events,
+// such as missing RBAC permissions, are logged by the broadcaster and never returned to the caller.
+func newEventRecorder(client kubernetes.Interface) record.EventRecorder {
+	broadcaster := record.NewBroadcasterWithCorrelatorOptions(record.CorrelatorOptions{
+		BurstSize: eventBurstSize,
+		QPS:       eventQPS,
+	})
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	return broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: eventComponent, Host: podHostname})
+}
+
+// stateChangeEvent determines the type and reason of the event to record when a check goes from the previous OK
+// state to the current one.  A blank reason is returned when the state did not change.
+func stateChangeEvent(previousOK bool, currentOK bool) (string, string) {
+	switch detectStateTransition(previousOK, currentOK) {
+	case transitionFailed:
+		return v1.EventTypeWarning, eventReasonCheckFailed
+	case transitionRecovered:
+		return v1.EventTypeNormal, eventReasonCheckRecovered
+	}
+	return "", ""
+}
+
+// stateChangeEventMessage creates the message for a state change event from the check's errors
+func stateChangeEventMessage(details khstatev1.WorkloadDetails) string {
+	if details.OK {
+		return "Check is OK again"
+	}
+	return truncateEventMessage(strings.Join(details.Errors, "; "))
+}
+
+// truncateEventMessage shortens messages that are longer than the maximum event message length
+func truncateEventMessage(message string) string {
+	if len(message) <= maxEventMessageLength {
+		return message
+	}
+	suffix := "..."
+	cut := maxEventMessageLength - len(suffix)
+	// don't cut a multi-byte character in half
+	for cut > 0 && !isRuneStart(message[cut]) {
+		cut--
+	}
+	return message[:cut] + suffix
+}
+
+// isRuneStart determines if the byte is the first byte of a UTF-8 encoded character
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// workloadEventReference creates a reference to the object that state change events of a workload are attached
+// to.  Checks and jobs are referenced by their khcheck and khjob resources.  Workloads of an unknown type are
+// attached to this Kuberhealthy pod.
+func workloadEventReference(name string, namespace string, workload khstatev1.KHWorkload, uid types.UID) *v1.ObjectReference {
+	switch workload {
+	case khstatev1.KHCheck:
+		return &v1.ObjectReference{
+			APIVersion: checkCRDGroup + "/" + checkCRDVersion,
+			Kind:       "KuberhealthyCheck",
+			Name:       name,
+			Namespace:  namespace,
+			UID:        uid,
+		}
+	case khstatev1.KHJob:
+		return &v1.ObjectReference{
+			APIVersion: checkCRDGroup + "/" + checkCRDVersion,
+			Kind:       "KuberhealthyJob",
+			Name:       name,
+			Namespace:  namespace,
+			UID:        uid,
+		}
+	}
+	return &v1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Name:       podHostname,
+		Namespace:  podNamespace,
+	}
+}
+
+// workloadUID fetches the UID of the khcheck or khjob resource for a workload so that events show up when the
+// resource is described.  A blank UID is returned if the resource can not be fetched.
+func workloadUID(name string, namespace string, workload khstatev1.KHWorkload) types.UID {
+	switch {
+	case workload == khstatev1.KHCheck && khCheckClient != nil:
+		khCheck, err := khCheckClient.KuberhealthyChecks(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			log.Debugln("events: unable to fetch khcheck", namespace+"/"+name, "for event reference:", err)
+			return ""
+		}
+		return khCheck.GetUID()
+	case workload == khstatev1.KHJob && khJobClient != nil:
+		khJob, err := khJobClient.KuberhealthyJobs(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			log.Debugln("events: unable to fetch khjob", namespace+"/"+name, "for event reference:", err)
+			return ""
+		}
+		return khJob.GetUID()
+	}
+	return ""
+}
+
+// previousWorkloadOK returns the last known OK state of a workload from the state reflector.  Workloads that have
+// never reported are considered OK, so a new check that fails immediately records a failure event.
+func (k *Kuberhealthy) previousWorkloadOK(name string, namespace string) bool {
+	if k.stateReflector == nil {
+		return true
+	}
+	previous, exists := k.stateReflector.WorkloadDetails(name, namespace)
+	if !exists || len(previous.AuthoritativePod) == 0 {
+		return true
+	}
+	return previous.OK
+}
+
+// recordStateChangeEvent records a Kubernetes event if the workload state changed from the previous OK state.  true
+// is returned when an event was recorded.
+func (k *Kuberhealthy) recordStateChangeEvent(name string, namespace string, previousOK bool, details khstatev1.WorkloadDetails) bool {
+	if k.eventRecorder == nil {
+		return false
+	}
+
+	eventType, reason := stateChangeEvent(previousOK, details.OK)
+	if len(reason) == 0 {
+		return false
+	}
+
+	workload := details.GetKHWorkload()
+	ref := workloadEventReference(name, namespace, workload, workloadUID(name, namespace, workload))
+	log.Infoln("events: recording", reason, "event for", namespace+"/"+name)
+	k.eventRecorder.Event(ref, eventType, reason, stateChangeEventMessage(details))
+	return true
+}
+
+// recordTimeoutEvent records a Kubernetes event when a run failed because it did not complete within its timeout.
+// Unlike state change events, it is recorded for every run that times out.
+func (k *Kuberhealthy) recordTimeoutEvent(name string, namespace string, workload khstatev1.KHWorkload, runErr error) {
+	if k.eventRecorder == nil || !errors.Is(runErr, external.ErrRunTimedOut) {
+		return
+	}
+	ref := workloadEventReference(name, namespace, workload, workloadUID(name, namespace, workload))
+	log.Infoln("events: recording", eventReasonCheckTimedOut, "event for", namespace+"/"+name)
+	k.eventRecorder.Event(ref, v1.EventTypeWarning, eventReasonCheckTimedOut, truncateEventMessage(runErr.Error()))
+}
+
+// recordMisconfiguredEvent records a Kubernetes event on a khcheck that is loaded with validation errors, so that
+// describing the khcheck shows what is wrong with it
+func (k *Kuberhealthy) recordMisconfiguredEvent(kc khcheckv1.KuberhealthyCheck, v khCheckValidation) {
+	if k.eventRecorder == nil || len(v.Errors) == 0 {
+		return
+	}
+	var issues []string
+	for _, issue := range v.Errors {
+		issues = append(issues, issue.Field+": "+issue.Message)
+	}
+	ref := workloadEventReference(kc.GetName(), kc.GetNamespace(), khstatev1.KHCheck, kc.GetUID())
+	log.Infoln("events: recording", eventReasonCheckMisconfigured, "event for", kc.GetNamespace()+"/"+kc.GetName())
+	k.eventRecorder.Event(ref, v1.EventTypeWarning, eventReasonCheckMisconfigured, truncateEventMessage(strings.Join(issues, "; ")))
+}
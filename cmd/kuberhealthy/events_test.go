@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+)
+
+// TestStateChangeEvent ensures events are only recorded on OK to failing transitions and recoveries
+func TestStateChangeEvent(t *testing.T) {
+	tests := []struct {
+		previousOK bool
+		currentOK  bool
+		eventType  string
+		reason     string
+	}{
+		{previousOK: true, currentOK: false, eventType: v1.EventTypeWarning, reason: eventReasonCheckFailed},
+		{previousOK: false, currentOK: true, eventType: v1.EventTypeNormal, reason: eventReasonCheckRecovered},
+		{previousOK: true, currentOK: true},
+		{previousOK: false, currentOK: false},
+	}
+	for _, tt := range tests {
+		eventType, reason := stateChangeEvent(tt.previousOK, tt.currentOK)
+		if eventType != tt.eventType || reason != tt.reason {
+			t.Fatal("expected", tt.eventType, tt.reason, "going from", tt.previousOK, "to", tt.currentOK, "but got", eventType, reason)
+		}
+	}
+}
+
+// TestTruncateEventMessage ensures long messages are cut to the event size limit without splitting characters
+func TestTruncateEventMessage(t *testing.T) {
+	short := "check failed"
+	if truncateEventMessage(short) != short {
+		t.Fatal("expected short message to be unchanged")
+	}
+
+	long := strings.Repeat("ü", maxEventMessageLength)
+	truncated := truncateEventMessage(long)
+	if len(truncated) > maxEventMessageLength {
+		t.Fatal("expected message to be truncated to", maxEventMessageLength, "bytes but got", len(truncated))
+	}
+	if !strings.HasSuffix(truncated, "...") {
+		t.Fatal("expected truncated message to end with an ellipsis")
+	}
+	if !utf8.ValidString(truncated) {
+		t.Fatal("expected truncated message to be valid UTF-8")
+	}
+}
+
+// TestWorkloadEventReference ensures events are attached to the khcheck or khjob of the workload
+func TestWorkloadEventReference(t *testing.T) {
+	ref := workloadEventReference("dns-status", "kuberhealthy", khstatev1.KHCheck, "1234")
+	if ref.Kind != "KuberhealthyCheck" || ref.APIVersion != "comcast.github.io/v1" || ref.Name != "dns-status" || ref.Namespace != "kuberhealthy" || ref.UID != "1234" {
+		t.Fatal("unexpected khcheck event reference:", ref)
+	}
+
+	ref = workloadEventReference("nightly", "kuberhealthy", khstatev1.KHJob, "")
+	if ref.Kind != "KuberhealthyJob" {
+		t.Fatal("unexpected khjob event reference:", ref)
+	}
+
+	ref = workloadEventReference("unknown", "kuberhealthy", "", "")
+	if ref.Kind != "Pod" || ref.Name != podHostname {
+		t.Fatal("expected workloads of an unknown type to be attached to the kuberhealthy pod:", ref)
+	}
+}
+
+// TestRecordStateChangeEvent ensures a failure and a recovery event are recorded as a check changes state
+func TestRecordStateChangeEvent(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	kh := &Kuberhealthy{
+		eventRecorder:  recorder,
+		stateReflector: &StateReflector{store: cache.NewStore(cache.MetaNamespaceKeyFunc)},
+	}
+
+	// a check that has never reported is considered OK
+	if !kh.previousWorkloadOK("dns-status", "kuberhealthy") {
+		t.Fatal("expected a check without state to be considered OK")
+	}
+
+	failing := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	failing.Errors = []string{"lookup failed", "timed out"}
+	failing.AuthoritativePod = "kuberhealthy-abc"
+	kh.recordStateChangeEvent("dns-status", "kuberhealthy", true, failing)
+	event := <-recorder.Events
+	if event != "Warning CheckFailed lookup failed; timed out" {
+		t.Fatal("unexpected failure event:", event)
+	}
+
+	// store the failing state and ensure it is used as the previous state
+	khState := khstatev1.NewKuberhealthyState("dns-status", failing)
+	khState.SetNamespace("kuberhealthy")
+	err := kh.stateReflector.store.Add(&khState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousOK := kh.previousWorkloadOK("dns-status", "kuberhealthy")
+	if previousOK {
+		t.Fatal("expected the stored failing state to be used as the previous state")
+	}
+
+	// repeated failures do not record more events
+	kh.recordStateChangeEvent("dns-status", "kuberhealthy", previousOK, failing)
+	select {
+	case event := <-recorder.Events:
+		t.Fatal("expected no event for a check that keeps failing but got:", event)
+	default:
+	}
+
+	recovered := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	recovered.OK = true
+	kh.recordStateChangeEvent("dns-status", "kuberhealthy", previousOK, recovered)
+	event = <-recorder.Events
+	if !strings.HasPrefix(event, "Normal CheckRecovered") {
+		t.Fatal("unexpected recovery event:", event)
+	}
+}
+
+// TestRecordStateChangeEventDisabled ensures nothing happens when events are disabled
+func TestRecordStateChangeEventDisabled(t *testing.T) {
+	kh := &Kuberhealthy{}
+	kh.recordStateChangeEvent("dns-status", "kuberhealthy", true, khstatev1.NewWorkloadDetails(khstatev1.KHCheck))
+}
+
+// TestRecordTimeoutEvent ensures runs that time out record an event and other run errors do not
+func TestRecordTimeoutEvent(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	kh := &Kuberhealthy{eventRecorder: recorder}
+
+	kh.recordTimeoutEvent("dns-status", "kuberhealthy", khstatev1.KHCheck, errors.New("failed to create pod"))
+	select {
+	case event := <-recorder.Events:
+		t.Fatal("expected no event for a run error that is not a timeout but got:", event)
+	default:
+	}
+
+	kh.recordTimeoutEvent("dns-status", "kuberhealthy", khstatev1.KHCheck, fmt.Errorf("%w after 5m0s: timed out waiting for checker pod to report in", external.ErrRunTimedOut))
+	event := <-recorder.Events
+	if !strings.HasPrefix(event, "Warning CheckTimedOut check run timed out after 5m0s") {
+		t.Fatal("unexpected timeout event:", event)
+	}
+}
+
+// TestRecordMisconfiguredEvent ensures khchecks with validation errors record an event listing them
+func TestRecordMisconfiguredEvent(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	kh := &Kuberhealthy{eventRecorder: recorder}
+	kc := khcheckv1.KuberhealthyCheck{}
+	kc.SetName("dns-status")
+	kc.SetNamespace("kuberhealthy")
+
+	kh.recordMisconfiguredEvent(kc, khCheckValidation{Warnings: []khCheckIssue{{Field: "spec.timeout", Message: "ignored"}}})
+	select {
+	case event := <-recorder.Events:
+		t.Fatal("expected no event for a khcheck without errors but got:", event)
+	default:
+	}
+
+	v := khCheckValidation{Errors: []khCheckIssue{{Field: "spec.runInterval", Message: "invalid duration"}, {Field: "spec.podSpec", Message: "no containers"}}}
+	kh.recordMisconfiguredEvent(kc, v)
+	event := <-recorder.Events
+	if event != "Warning CheckMisconfigured spec.runInterval: invalid duration; spec.podSpec: no containers" {
+		t.Fatal("unexpected misconfigured event:", event)
+	}
+}
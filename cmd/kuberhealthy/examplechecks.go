@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+)
+
+// installExampleChecksFlag and removeExampleChecksFlag install or remove the example khchecks at startup
+var installExampleChecksFlag bool
+var removeExampleChecksFlag bool
+
+// examplePassingCheckImage and exampleFailingCheckImage are the images of the example khchecks.  The images are run
+// with the REPORT_FAILURE environment variable of the test-check.
+var examplePassingCheckImage = "kuberhealthy/test-check:v1.4.1"
+var exampleFailingCheckImage = "kuberhealthy/test-check:v1.4.1"
+
+// exampleCheckLabel marks the khchecks that were created as examples, so that only those are ever removed
+const exampleCheckLabel = "kuberhealthy.github.io/example-check"
+
+// the names of the example khchecks
+const examplePassingCheckName = "example-passing-check"
+const exampleFailingCheckName = "example-failing-check"
+
+// validateExampleCheckFlags ensures the example check flags do not conflict
+func validateExampleCheckFlags() error {
+	if installExampleChecksFlag && removeExampleChecksFlag {
+		return errors.New("installExampleChecks and removeExampleChecks can not be set together")
+	}
+	if installExampleChecksFlag && (len(examplePassingCheckImage) == 0 || len(exampleFailingCheckImage) == 0) {
+		return errors.New("the example check images can not be blank")
+	}
+	return nil
+}
+
+// exampleChecks returns the example khchecks for the namespace
+func exampleChecks(namespace string) []khcheckv1.KuberhealthyCheck {
+	return []khcheckv1.KuberhealthyCheck{
+		exampleCheck(examplePassingCheckName, namespace, examplePassingCheckImage, false),
+		exampleCheck(exampleFailingCheckName, namespace, exampleFailingCheckImage, true),
+	}
+}
+
+// exampleCheck returns an example khcheck that runs the test-check image and reports a failure when reportFailure
+// is set
+func exampleCheck(name string, namespace string, image string, reportFailure bool) khcheckv1.KuberhealthyCheck {
+	check := khcheckv1.NewKuberhealthyCheck(name, namespace, khcheckv1.CheckConfig{
+		RunInterval: "5m",
+		Timeout:     "2m",
+		PodSpec: apiv1.PodSpec{
+			Containers: []apiv1.Container{{
+				Name:            "main",
+				Image:           image,
+				ImagePullPolicy: apiv1.PullIfNotPresent,
+				Env: []apiv1.EnvVar{
+					{Name: "REPORT_FAILURE", Value: fmt.Sprint(reportFailure)},
+					{Name: "REPORT_DELAY", Value: "5s"},
+				},
+				Resources: apiv1.ResourceRequirements{
+					Requests: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("10m"),
+						apiv1.ResourceMemory: resource.MustParse("50Mi"),
+					},
+				},
+			}},
+			RestartPolicy: apiv1.RestartPolicyNever,
+		},
+	})
+	check.TypeMeta = metav1.TypeMeta{Kind: "KuberhealthyCheck", APIVersion: checkCRDGroup + "/" + checkCRDVersion}
+	check.Labels = map[string]string{exampleCheckLabel: "true"}
+	return check
+}
+
+// installExampleChecks creates the example khchecks in the namespace.  Khchecks that already exist are skipped, so
+// the examples can be installed on every startup.  The names of the khchecks that were created are returned.
+func installExampleChecks(client *khcheckv1.KHCheckV1Client, namespace string) ([]string, error) {
+	var created []string
+	var errs []string
+	for _, check := range exampleChecks(namespace) {
+		check := check
+		_, err := client.KuberhealthyChecks(namespace).Get(check.Name, metav1.GetOptions{})
+		if err == nil {
+			log.Infoln("examples: khcheck", namespace+"/"+check.Name, "already exists. skipping it")
+			continue
+		}
+		if !k8sErrors.IsNotFound(err) {
+			errs = append(errs, "failed to look up khcheck "+namespace+"/"+check.Name+": "+err.Error())
+			continue
+		}
+
+		_, err = client.KuberhealthyChecks(namespace).Create(&check)
+		if k8sErrors.IsAlreadyExists(err) {
+			// another kuberhealthy pod created it first
+			log.Infoln("examples: khcheck", namespace+"/"+check.Name, "already exists. skipping it")
+			continue
+		}
+		if err != nil {
+			errs = append(errs, "failed to create khcheck "+namespace+"/"+check.Name+": "+err.Error())
+			continue
+		}
+		log.Infoln("examples: created khcheck", namespace+"/"+check.Name, "with image", check.Spec.PodSpec.Containers[0].Image)
+		created = append(created, check.Name)
+	}
+	if len(errs) > 0 {
+		return created, errors.New(strings.Join(errs, "; "))
+	}
+	return created, nil
+}
+
+// removeExampleChecks deletes the example khchecks from the namespace.  Khchecks with the example names that were
+// not created as examples are left alone.  The names of the khchecks that were deleted are returned.
+func removeExampleChecks(client *khcheckv1.KHCheckV1Client, namespace string) ([]string, error) {
+	var removed []string
+	var errs []string
+	for _, name := range []string{examplePassingCheckName, exampleFailingCheckName} {
+		check, err := client.KuberhealthyChecks(namespace).Get(name, metav1.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, "failed to look up khcheck "+namespace+"/"+name+": "+err.Error())
+			continue
+		}
+		if check.Labels[exampleCheckLabel] != "true" {
+			log.Warningln("examples: khcheck", namespace+"/"+name, "was not created as an example. leaving it in place")
+			continue
+		}
+
+		err = client.KuberhealthyChecks(namespace).Delete(name, &metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			errs = append(errs, "failed to delete khcheck "+namespace+"/"+name+": "+err.Error())
+			continue
+		}
+		log.Infoln("examples: deleted khcheck", namespace+"/"+name)
+		removed = append(removed, name)
+	}
+	if len(errs) > 0 {
+		return removed, errors.New(strings.Join(errs, "; "))
+	}
+	return removed, nil
+}
+
+// setUpExampleChecks installs or removes the example khchecks in the Kuberhealthy namespace when requested with
+// flags.  Failures are logged and do not stop Kuberhealthy from starting.
+func setUpExampleChecks() {
+	switch {
+	case installExampleChecksFlag:
+		created, err := installExampleChecks(khCheckClient, podNamespace)
+		if err != nil {
+			log.Errorln("examples: failed to install example khchecks:", err)
+		}
+		log.Infoln("examples: installed", len(created), "example khchecks in namespace", podNamespace+":", created)
+	case removeExampleChecksFlag:
+		removed, err := removeExampleChecks(khCheckClient, podNamespace)
+		if err != nil {
+			log.Errorln("examples: failed to remove example khchecks:", err)
+		}
+		log.Infoln("examples: removed", len(removed), "example khchecks from namespace", podNamespace+":", removed)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync"
+	"testing"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+)
+
+// khCheckStoreServer serves GET, POST and DELETE calls for khchecks from memory the way the kubernetes API server
+// does
+type khCheckStoreServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	checks map[string]khcheckv1.KuberhealthyCheck
+}
+
+// newKHCheckStoreServer starts an API server that stores khchecks in memory
+func newKHCheckStoreServer(t *testing.T, checks ...khcheckv1.KuberhealthyCheck) *khCheckStoreServer {
+	s := &khCheckStoreServer{checks: map[string]khcheckv1.KuberhealthyCheck{}}
+	for _, c := range checks {
+		s.checks[c.Name] = c
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		resource := schema.GroupResource{Group: checkCRDGroup, Resource: checkCRDResource}
+		w.Header().Set("Content-Type", "application/json")
+
+		var body interface{}
+		switch r.Method {
+		case http.MethodGet, http.MethodDelete:
+			name := path.Base(r.URL.Path)
+			check, ok := s.checks[name]
+			if !ok {
+				body = k8sErrors.NewNotFound(resource, name).Status()
+				break
+			}
+			if r.Method == http.MethodDelete {
+				delete(s.checks, name)
+				body = metav1.Status{Status: metav1.StatusSuccess}
+				break
+			}
+			body = check
+		case http.MethodPost:
+			check := khcheckv1.KuberhealthyCheck{}
+			err := json.NewDecoder(r.Body).Decode(&check)
+			if err != nil {
+				t.Error("failed to decode khcheck:", err)
+			}
+			if check.Kind != "KuberhealthyCheck" || check.APIVersion != "comcast.github.io/v1" {
+				t.Error("expected the khcheck to be created with its kind and apiVersion but got", check.TypeMeta)
+			}
+			if _, ok := s.checks[check.Name]; ok {
+				body = k8sErrors.NewAlreadyExists(resource, check.Name).Status()
+				break
+			}
+			s.checks[check.Name] = check
+			body = check
+		}
+		if status, ok := body.(metav1.Status); ok && status.Code != 0 {
+			w.WriteHeader(int(status.Code))
+		}
+		err := json.NewEncoder(w).Encode(body)
+		if err != nil {
+			t.Error("failed to encode response:", err)
+		}
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// client returns a khcheck client for the API server
+func (s *khCheckStoreServer) client(t *testing.T) *khcheckv1.KHCheckV1Client {
+	client, err := khcheckv1.NewForConfig(&rest.Config{Host: s.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// TestInstallExampleChecks ensures the example khchecks are created once, with the configured images, and that
+// khchecks that already exist are left alone
+func TestInstallExampleChecks(t *testing.T) {
+	existing := khcheckv1.NewKuberhealthyCheck(exampleFailingCheckName, "kuberhealthy", khcheckv1.CheckConfig{RunInterval: "1m"})
+	s := newKHCheckStoreServer(t, existing)
+	client := s.client(t)
+
+	previousImage := examplePassingCheckImage
+	examplePassingCheckImage = "registry.example.com/passing:v1"
+	defer func() { examplePassingCheckImage = previousImage }()
+
+	created, err := installExampleChecks(client, "kuberhealthy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 1 || created[0] != examplePassingCheckName {
+		t.Fatal("expected only the passing example to be created but got", created)
+	}
+	passing := s.checks[examplePassingCheckName]
+	if passing.Labels[exampleCheckLabel] != "true" || passing.Spec.PodSpec.Containers[0].Image != "registry.example.com/passing:v1" {
+		t.Fatal("expected the passing example to be labeled and use the configured image but got", passing.ObjectMeta, passing.Spec.PodSpec.Containers)
+	}
+	if s.checks[exampleFailingCheckName].Spec.RunInterval != "1m" {
+		t.Fatal("expected the existing khcheck to be left alone")
+	}
+
+	created, err = installExampleChecks(client, "kuberhealthy")
+	if err != nil || len(created) != 0 {
+		t.Fatal("expected installing the examples again to create nothing but got", created, err)
+	}
+}
+
+// TestRemoveExampleChecks ensures only khchecks created as examples are removed
+func TestRemoveExampleChecks(t *testing.T) {
+	userCheck := khcheckv1.NewKuberhealthyCheck(exampleFailingCheckName, "kuberhealthy", khcheckv1.CheckConfig{RunInterval: "1m"})
+	s := newKHCheckStoreServer(t, exampleCheck(examplePassingCheckName, "kuberhealthy", examplePassingCheckImage, false), userCheck)
+
+	removed, err := removeExampleChecks(s.client(t), "kuberhealthy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != examplePassingCheckName {
+		t.Fatal("expected only the passing example to be removed but got", removed)
+	}
+	if _, ok := s.checks[exampleFailingCheckName]; !ok {
+		t.Fatal("expected the khcheck that was not created as an example to be left in place")
+	}
+
+	removed, err = removeExampleChecks(s.client(t), "kuberhealthy")
+	if err != nil || len(removed) != 0 {
+		t.Fatal("expected removing the examples again to remove nothing but got", removed, err)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+)
+
+// maxRetryBackoffDoublings limits how many times the retry backoff of a check is doubled
+const maxRetryBackoffDoublings = 10
+
+// checkFailureSettings returns the failureThreshold and retryBackoff of a khcheck.  Invalid settings are logged and
+// replaced by their defaults, which report every failed run right away.
+func checkFailureSettings(kc khcheckv1.KuberhealthyCheck) (int, time.Duration) {
+	threshold := 1
+	if kc.Spec.FailureThreshold > 1 {
+		threshold = kc.Spec.FailureThreshold
+	} else if kc.Spec.FailureThreshold < 0 {
+		log.Warningln("Invalid failureThreshold", kc.Spec.FailureThreshold, "for check", kc.Namespace+"/"+kc.Name+". Defaulting to 1")
+	}
+
+	var backoff time.Duration
+	if len(kc.Spec.RetryBackoff) > 0 {
+		var err error
+		backoff, err = time.ParseDuration(kc.Spec.RetryBackoff)
+		if err != nil || backoff < 0 {
+			log.Warningln("Invalid retryBackoff", kc.Spec.RetryBackoff, "for check", kc.Namespace+"/"+kc.Name+". Failed runs are not retried before the next run")
+			backoff = 0
+		}
+	}
+	return threshold, backoff
+}
+
+// applyFailureThreshold counts consecutive failed runs and holds back a failed state until the threshold is
+// reached.  A held back failure is stored as OK along with the number of consecutive failures, so a single
+// transient failure does not turn the check red.  Repeated reports of the same failed run are counted once.  An
+// empty runUUID counts the failure every time.  true is returned if the failure was held back.
+func applyFailureThreshold(details *khstatev1.WorkloadDetails, previous khstatev1.WorkloadDetails, runUUID string, threshold int) bool {
+	if details.OK || threshold <= 1 {
+		details.ConsecutiveFailures = 0
+		details.LastFailedUUID = ""
+		return false
+	}
+
+	details.ConsecutiveFailures = previous.ConsecutiveFailures + 1
+	if len(runUUID) > 0 && runUUID == previous.LastFailedUUID {
+		details.ConsecutiveFailures = previous.ConsecutiveFailures
+	}
+	details.LastFailedUUID = runUUID
+	if details.ConsecutiveFailures >= threshold {
+		return false
+	}
+	details.OK = true
+	details.Errors = []string{}
+	return true
+}
+
+// applyCheckFailureThreshold applies the failureThreshold of a check to a state reported by its checker pod
+func (k *Kuberhealthy) applyCheckFailureThreshold(details *khstatev1.WorkloadDetails, checkName string, checkNamespace string) {
+	c, err := k.getCheck(checkName, checkNamespace)
+	if err != nil || c.FailureThreshold <= 1 {
+		return
+	}
+	previous, err := getCheckState(c)
+	if err != nil {
+		log.Errorln("Error fetching the state of check", checkName, "in namespace", checkNamespace, "to count failed runs:", err)
+		return
+	}
+	errs := details.Errors
+	if applyFailureThreshold(details, previous, details.CurrentUUID, c.FailureThreshold) {
+		log.Infoln("Holding back failure", details.ConsecutiveFailures, "of", c.FailureThreshold, "of check", checkName, "in namespace", checkNamespace+":", errs)
+	}
+}
+
+// failureRetryBackoff returns how long to wait before retrying a check after its supplied number of consecutive
+// failures.  The backoff doubles with each failure.
+func failureRetryBackoff(backoff time.Duration, failures int) time.Duration {
+	for i := 1; i < failures && i <= maxRetryBackoffDoublings; i++ {
+		backoff *= 2
+	}
+	return backoff
+}
+
+// scheduleFailureRetry retries a check with a retryBackoff sooner than its next run when its last run failed without
+// reaching its failureThreshold.  Once the failure is reported, the check runs on its usual schedule.
+func (k *Kuberhealthy) scheduleFailureRetry(c *external.Checker) {
+	if c.FailureThreshold <= 1 || c.RetryBackoff <= 0 {
+		return
+	}
+	state, err := getCheckState(c)
+	if err != nil {
+		c.Logger().Errorln("Error fetching the state of check", c.Name(), "in namespace", c.CheckNamespace(), "to retry it:", err)
+		return
+	}
+	if state.ConsecutiveFailures == 0 || state.ConsecutiveFailures >= c.FailureThreshold {
+		return
+	}
+	backoff := failureRetryBackoff(c.RetryBackoff, state.ConsecutiveFailures)
+	if k.checkSchedules.retry(c.CheckNamespace()+"/"+c.Name(), backoff, runTrigger{reason: triggerRetry, by: c.CurrentUUID()}) {
+		c.Logger().Infoln("Retrying check", c.Name(), "in namespace", c.CheckNamespace(), "in", backoff, "after failure", state.ConsecutiveFailures, "of", c.FailureThreshold)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// failedRun returns the state reported by a failed run
+func failedRun(uuid string) khstatev1.WorkloadDetails {
+	details := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	details.Errors = []string{"lookup failed"}
+	details.CurrentUUID = uuid
+	return details
+}
+
+// TestApplyFailureThreshold ensures failed runs are only reported once the failureThreshold is reached and that a
+// successful run resets the count
+func TestApplyFailureThreshold(t *testing.T) {
+	previous := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	previous.OK = true
+	for i, uuid := range []string{"a", "b"} {
+		details := failedRun(uuid)
+		if !applyFailureThreshold(&details, previous, uuid, 3) {
+			t.Fatal("expected failure", i+1, "to be held back")
+		}
+		if !details.OK || len(details.Errors) != 0 || details.ConsecutiveFailures != i+1 {
+			t.Fatalf("unexpected state for held back failure %d: %+v", i+1, details)
+		}
+		previous = details
+	}
+
+	// a repeated report of the same run is not counted again
+	repeated := failedRun("b")
+	applyFailureThreshold(&repeated, previous, "b", 3)
+	if repeated.ConsecutiveFailures != 2 {
+		t.Fatal("expected a repeated report to not be counted but got", repeated.ConsecutiveFailures, "failures")
+	}
+
+	details := failedRun("c")
+	if applyFailureThreshold(&details, previous, "c", 3) {
+		t.Fatal("expected the third failure to be reported")
+	}
+	if details.OK || len(details.Errors) != 1 || details.ConsecutiveFailures != 3 {
+		t.Fatalf("unexpected state for the reported failure: %+v", details)
+	}
+
+	ok := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	ok.OK = true
+	applyFailureThreshold(&ok, details, "d", 3)
+	if ok.ConsecutiveFailures != 0 || len(ok.LastFailedUUID) != 0 {
+		t.Fatalf("expected a successful run to reset the failure count: %+v", ok)
+	}
+
+	// without a threshold, failures are reported right away
+	details = failedRun("e")
+	if applyFailureThreshold(&details, previous, "e", 1) || details.OK {
+		t.Fatal("expected failures to be reported right away without a failureThreshold")
+	}
+}
+
+func TestFailureRetryBackoff(t *testing.T) {
+	for failures, expected := range map[int]time.Duration{1: time.Second * 10, 2: time.Second * 20, 3: time.Second * 40} {
+		if backoff := failureRetryBackoff(time.Second*10, failures); backoff != expected {
+			t.Fatal("expected a backoff of", expected, "after", failures, "failures but got", backoff)
+		}
+	}
+	if backoff := failureRetryBackoff(time.Second, 1000); backoff != time.Second*1024 {
+		t.Fatal("expected the backoff to stop doubling but got", backoff)
+	}
+}
+
+func TestCheckFailureSettings(t *testing.T) {
+	kc := khcheckv1.KuberhealthyCheck{ObjectMeta: metav1.ObjectMeta{Name: "dns", Namespace: "kuberhealthy"}}
+	if threshold, backoff := checkFailureSettings(kc); threshold != 1 || backoff != 0 {
+		t.Fatal("unexpected default failure settings:", threshold, backoff)
+	}
+	kc.Spec.FailureThreshold = 3
+	kc.Spec.RetryBackoff = "30s"
+	if threshold, backoff := checkFailureSettings(kc); threshold != 3 || backoff != time.Second*30 {
+		t.Fatal("unexpected failure settings:", threshold, backoff)
+	}
+	kc.Spec.FailureThreshold = -2
+	kc.Spec.RetryBackoff = "soon"
+	if threshold, backoff := checkFailureSettings(kc); threshold != 1 || backoff != 0 {
+		t.Fatal("expected invalid failure settings to be defaulted but got", threshold, backoff)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/federation"
+)
+
+// federationPath serves the aggregated status of the federated clusters
+const federationPath = "/api/v1/federation"
+
+// federationInterval is how often the status pages of the federated clusters are polled
+var federationInterval = time.Second * 30
+
+// federationTimeout is the timeout of each poll of the federated clusters
+var federationTimeout = time.Second * 10
+
+// validateFederationFlags ensures the federation poll interval and timeout are usable
+func validateFederationFlags() error {
+	if federationInterval <= 0 || federationTimeout <= 0 {
+		return errors.New("federationInterval and federationTimeout must be positive")
+	}
+	return nil
+}
+
+// validateFederatedClusters ensures every federated cluster has a unique name and a usable URL
+func validateFederatedClusters(clusters []federation.Cluster) error {
+	var errs []string
+	names := make(map[string]bool)
+	for _, c := range clusters {
+		err := c.Validate()
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if names[c.Name] {
+			errs = append(errs, "cluster "+c.Name+" is listed more than once")
+		}
+		names[c.Name] = true
+	}
+	if len(errs) > 0 {
+		return errors.New("invalid federated clusters: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// configuredFederatedClusters returns the federated clusters of the current configuration
+func configuredFederatedClusters() []federation.Cluster {
+	if cfg == nil {
+		return nil
+	}
+	return cfg.FederatedClusters
+}
+
+// runFederation polls the federated clusters of the current configuration every federationInterval until the
+// context is canceled.  Every kuberhealthy pod polls them, so that any pod can serve the aggregated status.
+func (k *Kuberhealthy) runFederation(ctx context.Context) {
+	ticker := time.NewTicker(federationInterval)
+	defer ticker.Stop()
+	for {
+		clusters := configuredFederatedClusters()
+		if len(clusters) > 0 {
+			pollCtx, cancel := context.WithTimeout(ctx, federationTimeout)
+			k.federation.Poll(pollCtx, clusters)
+			cancel()
+		} else {
+			k.federation.Poll(ctx, nil)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// federationHandler serves the aggregated status of the federated clusters as JSON
+func (k *Kuberhealthy) federationHandler(w http.ResponseWriter, r *http.Request) error {
+	log.Infoln("Client connected to federation endpoint from", r.RemoteAddr, r.UserAgent())
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return nil
+	}
+	if len(configuredFederatedClusters()) == 0 {
+		http.Error(w, "no federated clusters are configured", http.StatusNotFound)
+		return nil
+	}
+
+	status := k.federation.Status()
+	b, err := json.MarshalIndent(status, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return fmt.Errorf("failed to marshal federated status: %w", err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(b)
+	return err
+}
+
+// federationMetrics returns the status of the federated clusters and their checks in the Prometheus format
+func (k *Kuberhealthy) federationMetrics() string {
+	if k.federation == nil {
+		return ""
+	}
+	status := k.federation.Status()
+	if len(status.Clusters) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(status.Clusters))
+	for name := range status.Clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("# HELP kuberhealthy_federated_cluster_up Shows if the status page of the federated cluster could be fetched\n")
+	b.WriteString("# TYPE kuberhealthy_federated_cluster_up gauge\n")
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("kuberhealthy_federated_cluster_up{cluster=\"%s\"} %d\n", name, boolToInt(status.Clusters[name].Reachable)))
+	}
+	b.WriteString("# HELP kuberhealthy_federated_cluster_state Shows the status of the federated cluster\n")
+	b.WriteString("# TYPE kuberhealthy_federated_cluster_state gauge\n")
+	for _, name := range names {
+		b.WriteString(fmt.Sprintf("kuberhealthy_federated_cluster_state{cluster=\"%s\"} %d\n", name, boolToInt(status.Clusters[name].OK)))
+	}
+	b.WriteString("# HELP kuberhealthy_federated_check Shows the status of a check in the federated cluster as of its last successful poll\n")
+	b.WriteString("# TYPE kuberhealthy_federated_check gauge\n")
+	for _, name := range names {
+		cs := status.Clusters[name]
+		if cs.Status == nil {
+			continue
+		}
+		checks := make([]string, 0, len(cs.Status.CheckDetails))
+		for check := range cs.Status.CheckDetails {
+			checks = append(checks, check)
+		}
+		sort.Strings(checks)
+		for _, check := range checks {
+			namespace, checkName := "", check
+			if i := strings.Index(check, "/"); i >= 0 {
+				namespace, checkName = check[:i], check[i+1:]
+			}
+			b.WriteString(fmt.Sprintf("kuberhealthy_federated_check{cluster=\"%s\",check=\"%s\",namespace=\"%s\"} %d\n", name, checkName, namespace, boolToInt(cs.Status.CheckDetails[check].OK)))
+		}
+	}
+	return b.String()
+}
+
+// boolToInt returns 1 for true and 0 for false, the value of a boolean gauge
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/federation"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// TestValidateFederatedClusters ensures federated clusters need unique names and usable URLs
+func TestValidateFederatedClusters(t *testing.T) {
+	err := validateFederatedClusters([]federation.Cluster{{Name: "east", URL: "https://east.example.com"}, {Name: "west", URL: "https://west.example.com"}})
+	if err != nil {
+		t.Fatal("expected valid federated clusters but got", err)
+	}
+	err = validateFederatedClusters([]federation.Cluster{{Name: "east", URL: "https://east.example.com"}, {Name: "east", URL: "https://west.example.com"}})
+	if err == nil {
+		t.Fatal("expected a duplicate cluster name to be refused")
+	}
+	err = validateFederatedClusters([]federation.Cluster{{Name: "east", URL: "east.example.com"}})
+	if err == nil {
+		t.Fatal("expected a URL without a scheme to be refused")
+	}
+}
+
+// TestFederationMetrics ensures the status of federated clusters and their checks is exported
+func TestFederationMetrics(t *testing.T) {
+	state := health.NewState()
+	state.CheckDetails["kuberhealthy/dns"] = khstatev1.WorkloadDetails{OK: true}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(state)
+	}))
+	defer server.Close()
+
+	k := &Kuberhealthy{federation: federation.NewPoller()}
+	if k.federationMetrics() != "" {
+		t.Fatal("expected no metrics before any cluster is polled")
+	}
+	k.federation.Poll(context.Background(), []federation.Cluster{{Name: "east", URL: server.URL}, {Name: "west", URL: "http://127.0.0.1:1"}})
+	metrics := k.federationMetrics()
+	for _, line := range []string{
+		`kuberhealthy_federated_cluster_up{cluster="east"} 1`,
+		`kuberhealthy_federated_cluster_up{cluster="west"} 0`,
+		`kuberhealthy_federated_cluster_state{cluster="east"} 1`,
+		`kuberhealthy_federated_check{cluster="east",check="dns",namespace="kuberhealthy"} 1`,
+	} {
+		if !strings.Contains(metrics, line+"\n") {
+			t.Fatal("expected metric", line, "in", metrics)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// startupTime is the time this Kuberhealthy instance started.  Checks that have not completed a run since then are
+// in their startup grace period.
+var startupTime = time.Now()
+
+// startupGracePeriodFlag is the grace period after startup during which checks that have not completed a run are
+// reported as stale.  When blank, the grace period of each check is its run interval.
+var startupGracePeriodFlag string
+
+// startupGracePeriod is the parsed startupGracePeriodFlag. It is only used when startupGracePeriodFlag is set.
+var startupGracePeriod time.Duration
+
+// omitStaleChecks omits checks in their startup grace period from the status page instead of reporting them as
+// stale
+var omitStaleChecks bool
+
+// checkRunIntervals holds the run interval of each khcheck by namespace/name.  It is kept up to date by all
+// Kuberhealthy instances, so instances that are not running checks know the grace period of each check.
+var checkRunIntervals = make(map[string]time.Duration)
+var checkRunIntervalsMu sync.RWMutex
+
+// parseStartupGracePeriod parses the startupGracePeriodFlag if it was set
+func parseStartupGracePeriod() error {
+	if len(startupGracePeriodFlag) == 0 {
+		return nil
+	}
+	var err error
+	startupGracePeriod, err = time.ParseDuration(startupGracePeriodFlag)
+	return err
+}
+
+// setCheckRunIntervals records the run intervals of the supplied khchecks.  Run intervals that can not be parsed
+// fall back to the DefaultRunInterval, same as when the checks are configured.
+func setCheckRunIntervals(khChecks []khcheckv1.KuberhealthyCheck) {
+	intervals := make(map[string]time.Duration)
+	for _, kc := range khChecks {
+		intervals[kc.Namespace+"/"+kc.Name] = parseCheckRunInterval(kc)
+	}
+	replaceCheckRunIntervals(intervals)
+}
+
+// parseCheckRunInterval returns the run interval of a khcheck, or the DefaultRunInterval if it can not be parsed or
+// is not positive.  For khchecks that run on a cron schedule, the time between their next two runs is returned.
+func parseCheckRunInterval(kc khcheckv1.KuberhealthyCheck) time.Duration {
+	interval, err := time.ParseDuration(kc.Spec.RunInterval)
+	if err != nil || interval <= 0 {
+		interval = DefaultRunInterval
+	}
+
+	// checks that run on a cron schedule use the time between their next two runs
+	expr, location, err := parseCronSchedule(kc.Spec.Schedule, kc.Spec.ScheduleTimezone)
+	if err == nil && expr != nil {
+		return checkTiming{interval: interval, cron: expr, location: location}.period(time.Now())
+	}
+	return interval
+}
+
+// replaceCheckRunIntervals replaces the recorded run intervals with the supplied run intervals by namespace/name
+func replaceCheckRunIntervals(intervals map[string]time.Duration) {
+	checkRunIntervalsMu.Lock()
+	defer checkRunIntervalsMu.Unlock()
+	checkRunIntervals = intervals
+}
+
+// checkGracePeriod returns the startup grace period of the check with the supplied namespace/name
+func checkGracePeriod(name string) time.Duration {
+	if len(startupGracePeriodFlag) > 0 {
+		return startupGracePeriod
+	}
+
+	checkRunIntervalsMu.RLock()
+	defer checkRunIntervalsMu.RUnlock()
+	interval, ok := checkRunIntervals[name]
+	if !ok {
+		return DefaultRunInterval
+	}
+	return interval
+}
+
+// applyStartupGracePeriod flags checks that have not completed a run since startup and are still within their grace
+// period as stale.  Stale checks are reported as OK without their outdated errors, or omitted entirely when
+// omitStaleChecks is set.  The global OK state and errors of the supplied state are recalculated without them.
+func applyStartupGracePeriod(state health.State, gracePeriod func(name string) time.Duration, now time.Time) health.State {
+	var stale []string
+	for name, details := range state.CheckDetails {
+		if details.Paused || (details.LastRun != nil && details.LastRun.Time.After(startupTime)) {
+			continue
+		}
+		if !now.Before(startupTime.Add(gracePeriod(name))) {
+			continue
+		}
+
+		stale = append(stale, name)
+		if omitStaleChecks {
+			delete(state.CheckDetails, name)
+			continue
+		}
+		details.OK = true
+		details.Errors = []string{}
+		details.ErrorCount = 0
+		details.LastError = ""
+		details.Stale = true
+		state.CheckDetails[name] = details
+	}
+	if len(stale) == 0 {
+		return state
+	}
+	log.Debugln("Status page: checks in their startup grace period:", stale)
+	state.StaleChecks = stale
+
+	// rebuild the global state from the details of checks and jobs that are not stale
+	return rebuildGlobalState(state)
+}
+
+// rebuildGlobalState recalculates the global OK state, errors and warnings of the supplied state from the details of
+// its checks and jobs.  Checks that are suppressed by a maintenance window or a failing dependency and paused checks
+// are left out.  Errors of checks and jobs that failed with the Warning severity are warnings, which do not affect the
+// global OK state.
+func rebuildGlobalState(state health.State) health.State {
+	state.OK = true
+	state.Errors = []string{}
+	state.Warnings = nil
+	for _, details := range state.CheckDetails {
+		if details.Suppressed || details.Paused || len(details.SuppressedByDependencies) > 0 {
+			continue
+		}
+		state = addGlobalErrors(state, details)
+	}
+	for _, details := range state.JobDetails {
+		state = addGlobalErrors(state, details)
+	}
+
+	return state
+}
+
+// addGlobalErrors adds the errors of a check or job to the global errors of the supplied state, or to its warnings
+// when they have the Warning severity
+func addGlobalErrors(state health.State, details khstatev1.WorkloadDetails) health.State {
+	for _, e := range details.Errors {
+		if len(strings.TrimSpace(e)) == 0 {
+			continue
+		}
+		if details.Severity == status.SeverityWarning {
+			state.AddWarning(e)
+			continue
+		}
+		state.AddError(e)
+		state.OK = false
+	}
+	return state
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// gracePeriodStateFixture returns a state with a check that failed before startup, a check that completed a run
+// since startup and a failed job from before startup
+func gracePeriodStateFixture() health.State {
+	beforeStartup := metav1.NewTime(startupTime.Add(-time.Minute))
+	afterStartup := metav1.NewTime(startupTime.Add(time.Minute))
+
+	state := health.NewState()
+	state.CheckDetails["kuberhealthy/daemonset"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", Errors: []string{"daemonset failed"}, ErrorCount: 1, LastRun: &beforeStartup}
+	state.CheckDetails["kuberhealthy/dns"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", Errors: []string{"dns failed"}, ErrorCount: 1, LastRun: &afterStartup}
+	state.JobDetails["kuberhealthy/job"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", Errors: []string{"job failed"}, ErrorCount: 1, LastRun: &beforeStartup}
+	return state
+}
+
+// TestApplyStartupGracePeriod ensures checks that have not completed a run since startup are reported as stale during
+// their grace period and that checks that have completed a run are reported as they are
+func TestApplyStartupGracePeriod(t *testing.T) {
+	gracePeriod := func(name string) time.Duration { return time.Minute * 10 }
+
+	state := applyStartupGracePeriod(gracePeriodStateFixture(), gracePeriod, startupTime.Add(time.Minute*5))
+	daemonset := state.CheckDetails["kuberhealthy/daemonset"]
+	if !daemonset.Stale || !daemonset.OK || len(daemonset.Errors) != 0 {
+		t.Fatal("expected check without a run since startup to be stale and OK but got", daemonset)
+	}
+	if state.CheckDetails["kuberhealthy/dns"].Stale {
+		t.Fatal("expected check with a run since startup to not be stale")
+	}
+	if len(state.StaleChecks) != 1 || state.StaleChecks[0] != "kuberhealthy/daemonset" {
+		t.Fatal("unexpected stale checks:", state.StaleChecks)
+	}
+	if state.OK || len(state.Errors) != 2 {
+		t.Fatal("expected only the errors of the dns check and job in the global state but got", state.Errors)
+	}
+
+	// after the grace period, the outdated state is reported
+	state = applyStartupGracePeriod(gracePeriodStateFixture(), gracePeriod, startupTime.Add(time.Minute*15))
+	if state.CheckDetails["kuberhealthy/daemonset"].Stale || len(state.StaleChecks) != 0 {
+		t.Fatal("expected no stale checks after the grace period but got", state.StaleChecks)
+	}
+}
+
+// TestApplyStartupGracePeriodOmit ensures checks in their grace period are omitted when omitStaleChecks is set
+func TestApplyStartupGracePeriodOmit(t *testing.T) {
+	omitStaleChecks = true
+	defer func() { omitStaleChecks = false }()
+
+	gracePeriod := func(name string) time.Duration { return time.Minute * 10 }
+	state := applyStartupGracePeriod(gracePeriodStateFixture(), gracePeriod, startupTime.Add(time.Minute*5))
+	if _, ok := state.CheckDetails["kuberhealthy/daemonset"]; ok {
+		t.Fatal("expected check in its grace period to be omitted")
+	}
+	if len(state.StaleChecks) != 1 {
+		t.Fatal("expected omitted check to be listed as stale but got", state.StaleChecks)
+	}
+}
+
+// TestCheckGracePeriod ensures the grace period defaults to the run interval of each check
+func TestCheckGracePeriod(t *testing.T) {
+	setCheckRunIntervals([]khcheckv1.KuberhealthyCheck{
+		{ObjectMeta: metav1.ObjectMeta{Name: "dns", Namespace: "kuberhealthy"}, Spec: khcheckv1.CheckConfig{RunInterval: "2m"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "daemonset", Namespace: "kuberhealthy"}, Spec: khcheckv1.CheckConfig{RunInterval: "bogus"}},
+	})
+	defer setCheckRunIntervals(nil)
+
+	if checkGracePeriod("kuberhealthy/dns") != time.Minute*2 {
+		t.Fatal("expected grace period of the run interval but got", checkGracePeriod("kuberhealthy/dns"))
+	}
+	if checkGracePeriod("kuberhealthy/daemonset") != DefaultRunInterval || checkGracePeriod("kuberhealthy/unknown") != DefaultRunInterval {
+		t.Fatal("expected default run interval as grace period of checks without a valid run interval")
+	}
+
+	startupGracePeriodFlag = "30s"
+	defer func() { startupGracePeriodFlag = "" }()
+	err := parseStartupGracePeriod()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checkGracePeriod("kuberhealthy/dns") != time.Second*30 {
+		t.Fatal("expected the startupGracePeriod flag to override the run interval but got", checkGracePeriod("kuberhealthy/dns"))
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/metrics"
+)
+
+// graphiteAddress is the host:port of the carbon receiver that check results are sent to.  Graphite forwarding is
+// disabled when blank.
+var graphiteAddress string
+
+// graphiteProtocol is how metrics are sent to carbon, either plaintext or pickle
+var graphiteProtocol = metrics.GraphiteProtocolPlaintext
+
+// graphitePathTemplate is the text/template metric paths are rendered with
+var graphitePathTemplate = metrics.DefaultGraphitePathTemplate
+
+// newGraphiteClient creates the client check results are sent to Graphite with.  nil is returned when Graphite
+// forwarding is disabled.  The clusterName is available to the path template as the cluster tag.
+func newGraphiteClient() (*metrics.GraphiteClient, error) {
+	if len(graphiteAddress) == 0 {
+		return nil, nil
+	}
+	log.Infoln("graphite: sending check results to", graphiteAddress, "over", graphiteProtocol)
+	return metrics.NewGraphiteClient(metrics.GraphiteConfig{
+		Address:      graphiteAddress,
+		Protocol:     graphiteProtocol,
+		PathTemplate: graphitePathTemplate,
+		Tags:         map[string]string{"cluster": clusterName},
+	})
+}
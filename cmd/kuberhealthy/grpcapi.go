@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/khapi"
+)
+
+// grpcStatusAPI serves the Kuberhealthy gRPC service of khapi.proto on the gRPC server next to the check reporter
+var grpcStatusAPI bool
+
+// validateGRPCStatusAPIFlags ensures the gRPC status API is only enabled with the gRPC server
+func validateGRPCStatusAPIFlags() error {
+	if grpcStatusAPI && len(grpcListenAddress) == 0 {
+		return errors.New("grpcStatusAPI requires grpcListenAddress")
+	}
+	return nil
+}
+
+// statusWatchInterval is how often the status page is assembled for WatchStatus streams to find out if it changed
+var statusWatchInterval = time.Second * 5
+
+// grpcKHAPI serves the Kuberhealthy gRPC service from the same state as the status page and the /run endpoint
+type grpcKHAPI struct {
+	khapi.UnimplementedKuberhealthyServer
+	k *Kuberhealthy
+}
+
+// GetStatus returns the status page of the requested namespaces and checks
+func (g grpcKHAPI) GetStatus(ctx context.Context, req *khapi.GetStatusRequest) (*khapi.Status, error) {
+	log.Infoln("Client connected to gRPC GetStatus")
+	err := g.requireReadAuth()
+	if err != nil {
+		return nil, err
+	}
+	state, _, err := g.k.cachedState(statusFilter{namespaces: req.Namespaces, names: req.Checks})
+	if err != nil {
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	return statusMessage(state), nil
+}
+
+// WatchStatus sends the status page of the requested namespaces and checks, and sends it again each time it changes
+// until the client goes away
+func (g grpcKHAPI) WatchStatus(req *khapi.GetStatusRequest, stream khapi.Kuberhealthy_WatchStatusServer) error {
+	log.Infoln("Client connected to gRPC WatchStatus")
+	err := g.requireReadAuth()
+	if err != nil {
+		return err
+	}
+	filter := statusFilter{namespaces: req.Namespaces, names: req.Checks}
+	ticker := time.NewTicker(statusWatchInterval)
+	defer ticker.Stop()
+
+	var lastETag string
+	for {
+		state, etag, err := g.k.cachedState(filter)
+		if err != nil {
+			return grpcstatus.Error(codes.Internal, err.Error())
+		}
+		if etag != lastETag {
+			err = stream.Send(statusMessage(state))
+			if err != nil {
+				return err
+			}
+			lastETag = etag
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetCheck returns the details of a check or job, including the logs captured when it last failed
+func (g grpcKHAPI) GetCheck(ctx context.Context, req *khapi.CheckRequest) (*khapi.Workload, error) {
+	err := g.requireReadAuth()
+	if err != nil {
+		return nil, err
+	}
+	details, exists := g.k.stateReflector.WorkloadDetails(req.Name, req.Namespace)
+	if !exists || len(details.AuthoritativePod) == 0 {
+		return nil, grpcstatus.Error(codes.NotFound, "check "+req.Namespace+"/"+req.Name+" not found")
+	}
+	w := workloadMessage(req.Namespace+"/"+req.Name, details)
+	w.LogExcerpt = details.LogExcerpt
+	return w, nil
+}
+
+// RunCheck starts a run of a check right away.  Checks are only run by one kuberhealthy pod, so requests to other
+// pods are forwarded to it.
+func (g grpcKHAPI) RunCheck(ctx context.Context, req *khapi.CheckRequest) (*khapi.RunCheckResponse, error) {
+	err := requireGRPCClientCerts()
+	if err != nil {
+		return nil, err
+	}
+	check := req.Namespace + "/" + req.Name
+
+	runner := checkRunner(check)
+	if len(runner) > 0 && runner != podHostname {
+		if forwardedBy := metadata.ValueFromIncomingContext(ctx, runForwardedHeader); len(forwardedBy) > 0 {
+			return nil, grpcstatus.Error(codes.Unavailable, "check "+check+" is run by kuberhealthy pod "+runner)
+		}
+		return g.forwardGRPCRun(ctx, kubernetesClient, runner, req)
+	}
+
+	if !g.k.checkSchedules.runNow(check, grpcClientName(ctx)) {
+		return nil, grpcstatus.Error(codes.NotFound, "check "+check+" is not running")
+	}
+	log.Infoln("Triggered a run of check", req.Name, "in namespace", req.Namespace, "over gRPC")
+	return &khapi.RunCheckResponse{}, nil
+}
+
+// PauseCheck pauses or resumes a khcheck with its paused annotation
+func (g grpcKHAPI) PauseCheck(ctx context.Context, req *khapi.PauseCheckRequest) (*khapi.PauseCheckResponse, error) {
+	err := requireGRPCClientCerts()
+	if err != nil {
+		return nil, err
+	}
+	check := req.Namespace + "/" + req.Name
+
+	// a run may update the khcheck status at the same time, so we retry on conflicts
+	for tries := 0; tries < 5; tries++ {
+		kc, err := khCheckClient.KuberhealthyChecks(req.Namespace).Get(req.Name, metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return nil, grpcstatus.Error(codes.NotFound, "khcheck "+check+" not found")
+		}
+		if err != nil {
+			return nil, grpcstatus.Error(codes.Internal, err.Error())
+		}
+		if kc.Spec.Paused && !req.Paused {
+			return nil, grpcstatus.Error(codes.FailedPrecondition, "khcheck "+check+" is paused by its spec")
+		}
+		if kc.Annotations == nil {
+			kc.Annotations = map[string]string{}
+		}
+		kc.Annotations[pausedAnnotation] = strconv.FormatBool(req.Paused)
+		_, err = khCheckClient.KuberhealthyChecks(req.Namespace).Update(&kc)
+		if err == nil {
+			log.Infoln("Set the", pausedAnnotation, "annotation of khcheck", check, "to", req.Paused, "over gRPC")
+			return &khapi.PauseCheckResponse{}, nil
+		}
+		if !k8serrors.IsConflict(err) {
+			return nil, grpcstatus.Error(codes.Internal, err.Error())
+		}
+		time.Sleep(time.Second)
+	}
+	return nil, grpcstatus.Error(codes.Aborted, "khcheck "+check+" was modified while pausing it")
+}
+
+// requireGRPCClientCerts refuses check control unless gRPC clients must present a client certificate, the same way
+// the /run endpoint requires web server authentication
+func requireGRPCClientCerts() error {
+	if len(grpcTLSClientCAFile) == 0 {
+		return grpcstatus.Error(codes.PermissionDenied, "controlling checks over gRPC requires grpcTLSClientCAFile")
+	}
+	return nil
+}
+
+// requireReadAuth refuses reading the status over gRPC while the web server requires authentication, unless gRPC
+// clients must present a client certificate.  Otherwise the status and the logs of checker pods would be served over
+// gRPC to clients that can not read them from the web server.
+func (g grpcKHAPI) requireReadAuth() error {
+	if g.k.webAuth == nil {
+		return nil
+	}
+	if len(grpcTLSClientCAFile) == 0 {
+		return grpcstatus.Error(codes.PermissionDenied, "reading the status over gRPC requires grpcTLSClientCAFile when the web server requires authentication")
+	}
+	return nil
+}
+
+// grpcClientName returns the common name of the client certificate of a gRPC request, which is who runs triggered
+// over gRPC are recorded as triggered by.  grpc is returned when the client presented no certificate.
+func grpcClientName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "grpc"
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.PeerCertificates) == 0 || len(info.State.PeerCertificates[0].Subject.CommonName) == 0 {
+		return "grpc"
+	}
+	return "grpc:" + info.State.PeerCertificates[0].Subject.CommonName
+}
+
+// forwardGRPCRun forwards a RunCheck request to the kuberhealthy pod that runs the check.  The pod is addressed by
+// its IP, so its certificate is verified against the DNS name of the kuberhealthy service and the gRPC client CA,
+// which RunCheck requires.  This pod presents its own serving certificate as its client certificate.
+func (g grpcKHAPI) forwardGRPCRun(ctx context.Context, client kubernetes.Interface, runner string, req *khapi.CheckRequest) (*khapi.RunCheckResponse, error) {
+	tlsConfig, err := grpcForwardTLSConfig(g.k.config)
+	if err != nil {
+		log.Errorln("Failed to forward a gRPC run request to kuberhealthy pod", runner+":", err)
+		return nil, grpcstatus.Error(codes.Unavailable, "check is run by kuberhealthy pod "+runner+", which run requests can not be forwarded to")
+	}
+	pod, err := client.CoreV1().Pods(podNamespace).Get(ctx, runner, metav1.GetOptions{})
+	if err != nil || len(pod.Status.PodIP) == 0 {
+		log.Errorln("Failed to find the IP of kuberhealthy pod", runner, "to forward a gRPC run request to:", err)
+		return nil, grpcstatus.Error(codes.Unavailable, "failed to find kuberhealthy pod "+runner+" that runs the check")
+	}
+	_, port, err := net.SplitHostPort(grpcListenAddress)
+	if err != nil {
+		return nil, grpcstatus.Error(codes.Internal, fmt.Sprintf("failed to parse the port of gRPC listen address %s: %s", grpcListenAddress, err))
+	}
+
+	creds := credentials.NewTLS(tlsConfig)
+	conn, err := grpc.DialContext(ctx, net.JoinHostPort(pod.Status.PodIP, port), grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, grpcstatus.Error(codes.Unavailable, "failed to connect to kuberhealthy pod "+runner+": "+err.Error())
+	}
+	defer conn.Close()
+
+	log.Infoln("Forwarding gRPC run request for check", req.Namespace+"/"+req.Name, "to kuberhealthy pod", runner)
+	ctx = metadata.AppendToOutgoingContext(ctx, runForwardedHeader, podHostname)
+	return khapi.NewKuberhealthyClient(conn).RunCheck(ctx, req)
+}
+
+// grpcForwardTLSConfig returns the TLS configuration gRPC run requests are forwarded to other kuberhealthy pods with.
+// Their certificate is verified with the gRPC client CA against the DNS name of the kuberhealthy service.
+func grpcForwardTLSConfig(c *Config) (*tls.Config, error) {
+	if len(grpcTLSCertFile) == 0 || len(grpcTLSClientCAFile) == 0 {
+		return nil, errors.New("forwarding gRPC run requests requires grpcTLSCertFile and grpcTLSClientCAFile")
+	}
+	serverName, err := kuberhealthyServiceHost(c)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(grpcTLSCertFile, grpcTLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gRPC TLS certificate: %w", err)
+	}
+	pem, err := os.ReadFile(grpcTLSClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gRPC client CA file: %w", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(pem) {
+		return nil, errors.New("gRPC client CA file " + grpcTLSClientCAFile + " does not contain a PEM certificate")
+	}
+	return &tls.Config{Certificates: []tls.Certificate{cert}, RootCAs: roots, ServerName: serverName, MinVersion: tls.VersionTLS12}, nil
+}
+
+// cachedState returns the state of the status page with the filter and its ETag.  It is served from the same status
+// cache as the status page.
+func (k *Kuberhealthy) cachedState(filter statusFilter) (health.State, string, error) {
+	var state health.State
+	b, etag, err := k.statusCache.get(filter.cacheKey(), func() ([]byte, error) {
+		return json.MarshalIndent(k.getCurrentState(filter), "", "  ")
+	})
+	if err != nil {
+		return state, "", fmt.Errorf("failed to assemble status page: %w", err)
+	}
+	err = json.Unmarshal(b, &state)
+	if err != nil {
+		return state, "", fmt.Errorf("failed to parse status page: %w", err)
+	}
+	return state, etag, nil
+}
+
+// statusMessage converts a state to its khapi message.  Checks and jobs are sorted by their namespace/name key.
+func statusMessage(state health.State) *khapi.Status {
+	return &khapi.Status{
+		Ok:                         state.OK,
+		Errors:                     state.Errors,
+		Checks:                     workloadMessages(state.CheckDetails),
+		Jobs:                       workloadMessages(state.JobDetails),
+		CurrentMaster:              state.CurrentMaster,
+		Metadata:                   state.Metadata,
+		Warnings:                   state.Warnings,
+		ActiveMaintenanceWindows:   state.ActiveMaintenanceWindows,
+		SuppressedChecks:           state.SuppressedChecks,
+		StaleChecks:                state.StaleChecks,
+		PausedChecks:               state.PausedChecks,
+		DependencySuppressedChecks: state.DependencySuppressedChecks,
+		WarningChecks:              state.WarningChecks,
+	}
+}
+
+// workloadMessages converts the details of checks or jobs by their namespace/name key to khapi messages sorted by key
+func workloadMessages(details map[string]khstatev1.WorkloadDetails) []*khapi.Workload {
+	keys := make([]string, 0, len(details))
+	for key := range details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	workloads := make([]*khapi.Workload, 0, len(keys))
+	for _, key := range keys {
+		workloads = append(workloads, workloadMessage(key, details[key]))
+	}
+	return workloads
+}
+
+// workloadMessage converts the details of the check or job with the namespace/name key to its khapi message
+func workloadMessage(key string, d khstatev1.WorkloadDetails) *khapi.Workload {
+	namespace, name := d.Namespace, key
+	if i := strings.Index(key, "/"); i >= 0 {
+		namespace, name = key[:i], key[i+1:]
+	}
+	w := &khapi.Workload{
+		Name:                     name,
+		Namespace:                namespace,
+		Ok:                       d.OK,
+		Errors:                   d.Errors,
+		RunDuration:              d.RunDuration,
+		Node:                     d.Node,
+		AuthoritativePod:         d.AuthoritativePod,
+		Paused:                   d.Paused,
+		Stale:                    d.Stale,
+		Suppressed:               d.Suppressed,
+		SuppressedByDependencies: d.SuppressedByDependencies,
+		Severity:                 d.Severity,
+		ConsecutiveFailures:      int32(d.ConsecutiveFailures),
+		Details:                  d.Details,
+	}
+	if d.LastRun != nil {
+		w.LastRun = timestamppb.New(d.LastRun.Time)
+	}
+	return w
+}
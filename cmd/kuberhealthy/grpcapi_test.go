@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcstatus "google.golang.org/grpc/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/khapi"
+)
+
+// fakeKHCheckAPI serves GET and PUT calls of khchecks the way the kubernetes API server does
+type fakeKHCheckAPI struct {
+	mu     sync.Mutex
+	checks map[string]khcheckv1.KuberhealthyCheck // khchecks by namespace/name
+}
+
+// useFakeKHCheckAPI serves the khchecks with a fake API server and points khCheckClient at it for the duration of a
+// test
+func useFakeKHCheckAPI(t *testing.T, checks ...khcheckv1.KuberhealthyCheck) *fakeKHCheckAPI {
+	api := &fakeKHCheckAPI{checks: make(map[string]khcheckv1.KuberhealthyCheck)}
+	for _, kc := range checks {
+		api.checks[kc.Namespace+"/"+kc.Name] = kc
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/apis/comcast.github.io/v1/namespaces/"), "/")
+		if len(parts) != 3 || parts[1] != "khchecks" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		key := parts[0] + "/" + parts[2]
+
+		api.mu.Lock()
+		defer api.mu.Unlock()
+		kc, exists := api.checks[key]
+		if !exists {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(metav1.Status{TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"}, Status: metav1.StatusFailure, Reason: metav1.StatusReasonNotFound, Code: http.StatusNotFound})
+			return
+		}
+		if r.Method == http.MethodPut {
+			err := json.NewDecoder(r.Body).Decode(&kc)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			api.checks[key] = kc
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(kc)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := khcheckv1.NewForConfig(&rest.Config{Host: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousClient := khCheckClient
+	khCheckClient = client
+	t.Cleanup(func() { khCheckClient = previousClient })
+	return api
+}
+
+// get returns a khcheck served by the fake API server
+func (api *fakeKHCheckAPI) get(namespace string, name string) khcheckv1.KuberhealthyCheck {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+	return api.checks[namespace+"/"+name]
+}
+
+// newTestKHCheck creates a khcheck with the name in the namespace
+func newTestKHCheck(namespace string, name string) khcheckv1.KuberhealthyCheck {
+	return khcheckv1.KuberhealthyCheck{
+		TypeMeta:   metav1.TypeMeta{Kind: "KuberhealthyCheck", APIVersion: "comcast.github.io/v1"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       khcheckv1.CheckConfig{RunInterval: "1m", Timeout: "2m"},
+	}
+}
+
+// addTestKHState stores a khstate of a check in the state reflector of k
+func addTestKHState(t *testing.T, k *Kuberhealthy, namespace string, name string, ok bool) {
+	details := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	details.OK = ok
+	details.Namespace = namespace
+	details.AuthoritativePod = "kuberhealthy-a"
+	details.LastRun = &metav1.Time{Time: time.Now()}
+	details.LogExcerpt = "dial tcp 10.0.0.1:53: i/o timeout"
+	if !ok {
+		details.Errors = []string{name + " failed"}
+	}
+	khState := khstatev1.NewKuberhealthyState(name, details)
+	khState.SetNamespace(namespace)
+	err := k.stateReflector.store.Add(&khState)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.statusCache.invalidate()
+}
+
+// dialTestGRPCServer connects to a gRPC server started with startTestGRPCServer
+func dialTestGRPCServer(t *testing.T, address string) *grpc.ClientConn {
+	conn, err := grpc.Dial(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// TestGRPCStatusAPI ensures the status page, check details and check runs are served over gRPC next to check reports
+func TestGRPCStatusAPI(t *testing.T) {
+	setRunPod(t, "kuberhealthy-a", "kuberhealthy-a")
+	previousCfg, previousInterval := cfg, statusWatchInterval
+	cfg, statusWatchInterval = &Config{}, time.Millisecond*100
+	t.Cleanup(func() { cfg, statusWatchInterval = previousCfg, previousInterval })
+
+	api := useFakeKHCheckAPI(t, newTestKHCheck("kuberhealthy", "dns"), newTestKHCheck("kuberhealthy", "daemonset"))
+	k := &Kuberhealthy{
+		stateReflector: &StateReflector{store: cache.NewStore(cache.MetaNamespaceKeyFunc)},
+		statusCache:    newStatusCache(time.Minute),
+	}
+	addTestKHState(t, k, "kuberhealthy", "dns", true)
+	k.checkSchedules.add("kuberhealthy/dns", checkTiming{interval: time.Hour})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newGRPCServer(nil, k.handleCheckReport, grpcKHAPI{k: k})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	conn := dialTestGRPCServer(t, listener.Addr().String())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	client := khapi.NewKuberhealthyClient(conn)
+	status, err := client.GetStatus(ctx, &khapi.GetStatusRequest{Namespaces: []string{"kuberhealthy"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Ok || len(status.Checks) != 1 || status.Checks[0].Name != "dns" || status.Checks[0].Namespace != "kuberhealthy" || len(status.Checks[0].LogExcerpt) > 0 {
+		t.Fatalf("expected the status page with the dns check but got %+v", status)
+	}
+
+	check, err := client.GetCheck(ctx, &khapi.CheckRequest{Namespace: "kuberhealthy", Name: "dns"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if check.Name != "dns" || check.LogExcerpt != "dial tcp 10.0.0.1:53: i/o timeout" || check.LastRun == nil {
+		t.Fatalf("expected the details of the dns check but got %+v", check)
+	}
+	_, err = client.GetCheck(ctx, &khapi.CheckRequest{Namespace: "kuberhealthy", Name: "missing"})
+	if grpcstatus.Code(err) != codes.NotFound {
+		t.Fatal("expected a missing check to be not found but got", err)
+	}
+
+	// check runs require client certificates
+	_, err = client.RunCheck(ctx, &khapi.CheckRequest{Namespace: "kuberhealthy", Name: "dns"})
+	if grpcstatus.Code(err) != codes.PermissionDenied {
+		t.Fatal("expected a run without client certificates to be denied but got", err)
+	}
+	setGRPCTLSFlags(t, "", "", "client-ca.pem")
+	_, err = client.RunCheck(ctx, &khapi.CheckRequest{Namespace: "kuberhealthy", Name: "dns"})
+	if err != nil {
+		t.Fatal("expected the run to be triggered but got", err)
+	}
+	_, err = client.RunCheck(ctx, &khapi.CheckRequest{Namespace: "kuberhealthy", Name: "missing"})
+	if grpcstatus.Code(err) != codes.NotFound {
+		t.Fatal("expected a run of a check that is not running to be not found but got", err)
+	}
+
+	// khchecks are paused and resumed with their paused annotation
+	_, err = client.PauseCheck(ctx, &khapi.PauseCheckRequest{Namespace: "kuberhealthy", Name: "dns", Paused: true})
+	if err != nil {
+		t.Fatal("expected the check to be paused but got", err)
+	}
+	if kc := api.get("kuberhealthy", "dns"); !checkPaused(kc) {
+		t.Fatal("expected the khcheck to be annotated as paused but got", kc.Annotations)
+	}
+	_, err = client.PauseCheck(ctx, &khapi.PauseCheckRequest{Namespace: "kuberhealthy", Name: "dns"})
+	if err != nil {
+		t.Fatal("expected the check to be resumed but got", err)
+	}
+	if kc := api.get("kuberhealthy", "dns"); checkPaused(kc) {
+		t.Fatal("expected the khcheck to be annotated as resumed but got", kc.Annotations)
+	}
+	_, err = client.PauseCheck(ctx, &khapi.PauseCheckRequest{Namespace: "kuberhealthy", Name: "missing", Paused: true})
+	if grpcstatus.Code(err) != codes.NotFound {
+		t.Fatal("expected pausing a missing khcheck to be not found but got", err)
+	}
+
+	// the status is streamed again once it changes
+	stream, err := client.WatchStatus(ctx, &khapi.GetStatusRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status, err = stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.Ok {
+		t.Fatalf("expected the first streamed status to be OK but got %+v", status)
+	}
+	addTestKHState(t, k, "kuberhealthy", "daemonset", false)
+	status, err = stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Ok || len(status.Checks) != 2 || status.Checks[0].Name != "daemonset" || status.Checks[0].Ok {
+		t.Fatalf("expected the failing daemonset check to be streamed but got %+v", status)
+	}
+}
+
+// TestGRPCStatusAPIAuth ensures the status and check details are only read over gRPC with client certificates while
+// the web server requires authentication
+func TestGRPCStatusAPIAuth(t *testing.T) {
+	setGRPCTLSFlags(t, "", "", "")
+	k := &Kuberhealthy{
+		stateReflector: &StateReflector{store: cache.NewStore(cache.MetaNamespaceKeyFunc)},
+		statusCache:    newStatusCache(time.Minute),
+	}
+	addTestKHState(t, k, "kuberhealthy", "dns", true)
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setWebAuthFlags(t, writeTestFile(t, t.TempDir(), "htpasswd", []byte("operator:"+string(hash)+"\n")), "", "", nil)
+	k.webAuth, err = newWebAuthenticator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := grpcKHAPI{k: k}
+	ctx := context.Background()
+
+	_, err = api.GetStatus(ctx, &khapi.GetStatusRequest{})
+	if grpcstatus.Code(err) != codes.PermissionDenied {
+		t.Fatal("expected reading the status without client certificates to be denied but got", err)
+	}
+	err = api.WatchStatus(&khapi.GetStatusRequest{}, nil)
+	if grpcstatus.Code(err) != codes.PermissionDenied {
+		t.Fatal("expected watching the status without client certificates to be denied but got", err)
+	}
+	_, err = api.GetCheck(ctx, &khapi.CheckRequest{Namespace: "kuberhealthy", Name: "dns"})
+	if grpcstatus.Code(err) != codes.PermissionDenied {
+		t.Fatal("expected reading the logs of a check without client certificates to be denied but got", err)
+	}
+
+	setGRPCTLSFlags(t, "", "", "client-ca.pem")
+	check, err := api.GetCheck(ctx, &khapi.CheckRequest{Namespace: "kuberhealthy", Name: "dns"})
+	if err != nil {
+		t.Fatal("expected the check to be read with client certificates but got", err)
+	}
+	if len(check.LogExcerpt) == 0 {
+		t.Fatalf("expected the logs of the check but got %+v", check)
+	}
+}
+
+// TestStatusMessage ensures checks and jobs are converted sorted by namespace and name
+func TestStatusMessage(t *testing.T) {
+	state := health.NewState()
+	state.OK = false
+	state.Errors = []string{"check failed"}
+	state.CheckDetails["team-a/dns"] = khstatev1.WorkloadDetails{OK: true, Namespace: "team-a"}
+	state.CheckDetails["kuberhealthy/dns"] = khstatev1.WorkloadDetails{Errors: []string{"check failed"}, ConsecutiveFailures: 2}
+	state.JobDetails["kuberhealthy/smoke"] = khstatev1.WorkloadDetails{OK: true, Namespace: "kuberhealthy"}
+
+	status := statusMessage(state)
+	if status.Ok || len(status.Errors) != 1 || len(status.Checks) != 2 || len(status.Jobs) != 1 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	first, second := status.Checks[0], status.Checks[1]
+	if first.Namespace != "kuberhealthy" || first.Name != "dns" || first.ConsecutiveFailures != 2 || second.Namespace != "team-a" || !second.Ok {
+		t.Fatalf("expected the checks sorted by namespace and name but got %+v", status.Checks)
+	}
+	if first.LastRun != nil {
+		t.Fatal("expected no last run for a check that has not run but got", first.LastRun)
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/khapi"
+)
+
+// grpcListenAddress is the address the gRPC check report server listens on.  When blank, the gRPC server is not
+// started.
+var grpcListenAddress string
+
+// grpcTLSCertFile and grpcTLSKeyFile are the certificate and key the gRPC server is served with.  When blank, the
+// gRPC server is served without TLS.
+var grpcTLSCertFile string
+var grpcTLSKeyFile string
+
+// grpcTLSClientCAFile is a CA bundle of client certificates.  When set, clients of the gRPC server must present a
+// certificate signed by one of its CAs (mTLS).
+var grpcTLSClientCAFile string
+
+// grpcCheckReporter serves the CheckReporter gRPC service.  Reports are handled by handle, which is
+// handleCheckReport, the same report handling as the /externalCheckStatus endpoint.
+type grpcCheckReporter struct {
+	status.UnimplementedCheckReporterServer
+	handle func(ctx context.Context, c checkReport) error
+}
+
+// ReportCheckStatus validates and stores a check report sent over gRPC
+func (g *grpcCheckReporter) ReportCheckStatus(ctx context.Context, req *status.ReportCheckStatusRequest) (*status.ReportCheckStatusResponse, error) {
+	requestID := "grpc: " + uuid.New().String()
+	log.Infoln(requestID, "Client connected to gRPC check report handler")
+
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+
+	err := g.handle(ctx, checkReport{
+		requestID:   requestID,
+		runUUID:     req.GetRunUuid(),
+		reportToken: req.GetReportToken(),
+		remoteIP:    ip,
+		report:      req.Report(),
+	})
+	switch {
+	case errors.Is(err, errCheckReportRejected):
+		return nil, grpcstatus.Error(codes.PermissionDenied, err.Error())
+	case errors.Is(err, errCheckReportInvalid):
+		return nil, grpcstatus.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		log.Errorln("gRPC check report error:", err)
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	return &status.ReportCheckStatusResponse{}, nil
+}
+
+// grpcServerTLSConfig loads the TLS configuration of the gRPC server from the grpcTLS flags.  Nil is returned when
+// the server is served without TLS.
+func grpcServerTLSConfig() (*tls.Config, error) {
+	if len(grpcTLSCertFile) == 0 && len(grpcTLSKeyFile) == 0 {
+		if len(grpcTLSClientCAFile) > 0 {
+			return nil, errors.New("grpcTLSClientCAFile requires grpcTLSCertFile and grpcTLSKeyFile")
+		}
+		return nil, nil
+	}
+	if len(grpcTLSCertFile) == 0 || len(grpcTLSKeyFile) == 0 {
+		return nil, errors.New("grpcTLSCertFile and grpcTLSKeyFile must be set together")
+	}
+
+	cert, err := tls.LoadX509KeyPair(grpcTLSCertFile, grpcTLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gRPC TLS certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+
+	if len(grpcTLSClientCAFile) > 0 {
+		pem, err := os.ReadFile(grpcTLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gRPC client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("gRPC client CA file " + grpcTLSClientCAFile + " does not contain a PEM certificate")
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return tlsConfig, nil
+}
+
+// newGRPCServer creates a gRPC check report server that handles reports with handle.  The Kuberhealthy service of
+// khapi.proto is served by api unless it is nil.
+func newGRPCServer(tlsConfig *tls.Config, handle func(ctx context.Context, c checkReport) error, api khapi.KuberhealthyServer) *grpc.Server {
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	server := grpc.NewServer(opts...)
+	status.RegisterCheckReporterServer(server, &grpcCheckReporter{handle: handle})
+	if api != nil {
+		khapi.RegisterKuberhealthyServer(server, api)
+	}
+	return server
+}
+
+// StartGRPCServer serves the gRPC check report server on the grpcListenAddress until the context is canceled
+func (k *Kuberhealthy) StartGRPCServer(ctx context.Context) {
+	tlsConfig, err := grpcServerTLSConfig()
+	if err != nil {
+		log.Errorln("gRPC server ERROR:", err)
+		return
+	}
+	listener, err := net.Listen("tcp", grpcListenAddress)
+	if err != nil {
+		log.Errorln("gRPC server ERROR: failed to listen on", grpcListenAddress+":", err)
+		return
+	}
+
+	var api khapi.KuberhealthyServer
+	if grpcStatusAPI {
+		api = grpcKHAPI{k: k}
+	}
+	server := newGRPCServer(tlsConfig, k.handleCheckReport, api)
+	go func() {
+		<-ctx.Done()
+		log.Infoln("shutdown: stopping gRPC server")
+		server.GracefulStop()
+	}()
+
+	log.Infoln("Starting gRPC check report server on", grpcListenAddress, "with TLS:", tlsConfig != nil, "and status API:", grpcStatusAPI)
+	err = server.Serve(listener)
+	if err != nil {
+		log.Errorln("gRPC server ERROR:", err)
+	}
+}
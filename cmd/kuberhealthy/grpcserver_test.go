@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checkclient"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+)
+
+// testKeyPair is a certificate and its key
+type testKeyPair struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestKeyPair creates a key pair from template.  It is signed by parent, or self-signed when parent is nil.
+func newTestKeyPair(t *testing.T, template *x509.Certificate, parent *testKeyPair) testKeyPair {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	signerCert, signerKey := template, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testKeyPair{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// writeTestFile writes content to a file in dir and returns its path
+func writeTestFile(t *testing.T, dir string, name string, content []byte) string {
+	path := filepath.Join(dir, name)
+	err := os.WriteFile(path, content, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// setGRPCTLSFlags sets the grpcTLS flags for the duration of a test
+func setGRPCTLSFlags(t *testing.T, certFile string, keyFile string, clientCAFile string) {
+	previousCert, previousKey, previousCA := grpcTLSCertFile, grpcTLSKeyFile, grpcTLSClientCAFile
+	grpcTLSCertFile, grpcTLSKeyFile, grpcTLSClientCAFile = certFile, keyFile, clientCAFile
+	t.Cleanup(func() {
+		grpcTLSCertFile, grpcTLSKeyFile, grpcTLSClientCAFile = previousCert, previousKey, previousCA
+	})
+}
+
+// startTestGRPCServer serves a gRPC check report server on a local port and returns its address
+func startTestGRPCServer(t *testing.T, tlsConfig *tls.Config, handle func(ctx context.Context, c checkReport) error) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newGRPCServer(tlsConfig, handle, nil)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+// TestGRPCCheckReport ensures reports sent with the checkclient reach the report handler with their details and the
+// run UUID and IP of the checker pod, and that rejected and invalid reports are returned to the client without retries
+func TestGRPCCheckReport(t *testing.T) {
+	t.Setenv(external.KHRunUUID, "run-uuid")
+
+	var reports []checkReport
+	var handleErr error
+	address := startTestGRPCServer(t, nil, func(ctx context.Context, c checkReport) error {
+		reports = append(reports, c)
+		return handleErr
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	err := checkclient.Report(ctx, []string{"node not ready"}, checkclient.Options{GRPCAddress: address, Details: map[string]string{"zone": "us-east-1a"}})
+	if err != nil {
+		t.Fatal("expected the report to be accepted but got:", err)
+	}
+	if len(reports) != 1 {
+		t.Fatal("expected 1 report but got", len(reports))
+	}
+	report := reports[0]
+	if report.runUUID != "run-uuid" || report.remoteIP != "127.0.0.1" {
+		t.Fatal("expected the run uuid and ip of the client but got", report.runUUID, report.remoteIP)
+	}
+	if report.report.OK || len(report.report.Errors) != 1 || report.report.Errors[0] != "node not ready" {
+		t.Fatal("expected the failed report but got", report.report)
+	}
+	if report.report.Details["zone"] != "us-east-1a" {
+		t.Fatal("expected the details of the report but got", report.report.Details)
+	}
+
+	tests := map[error]codes.Code{
+		fmt.Errorf("%w: pod not found", errCheckReportRejected): codes.PermissionDenied,
+		fmt.Errorf("%w: blank error", errCheckReportInvalid):    codes.InvalidArgument,
+	}
+	for handleErr = range tests {
+		reports = nil
+		err = checkclient.Report(ctx, nil, checkclient.Options{GRPCAddress: address})
+		if grpcstatus.Code(err) != tests[handleErr] {
+			t.Fatal("expected", tests[handleErr], "for", handleErr, "but got:", err)
+		}
+		if len(reports) != 1 {
+			t.Fatal("expected the refused report not to be retried but it was sent", len(reports), "times")
+		}
+	}
+}
+
+// TestGRPCCheckReportMTLS ensures the gRPC server requires client certificates signed by the client CA
+func TestGRPCCheckReportMTLS(t *testing.T) {
+	t.Setenv(external.KHRunUUID, "run-uuid")
+	dir := t.TempDir()
+
+	ca := newTestKeyPair(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kuberhealthy-ca"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil)
+	server := newTestKeyPair(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "kuberhealthy"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, &ca)
+	client := newTestKeyPair(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "checker"},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &ca)
+
+	setGRPCTLSFlags(t,
+		writeTestFile(t, dir, "tls.crt", server.certPEM),
+		writeTestFile(t, dir, "tls.key", server.keyPEM),
+		writeTestFile(t, dir, "ca.crt", ca.certPEM))
+	tlsConfig, err := grpcServerTLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reports int
+	address := startTestGRPCServer(t, tlsConfig, func(ctx context.Context, c checkReport) error {
+		reports++
+		return nil
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	clientCert, err := tls.X509KeyPair(client.certPEM, client.keyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	err = checkclient.Report(ctx, nil, checkclient.Options{
+		GRPCAddress: address,
+		TLSConfig:   &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{clientCert}},
+	})
+	if err != nil {
+		t.Fatal("expected the report with a client certificate to be accepted but got:", err)
+	}
+
+	shortCtx, shortCancel := context.WithTimeout(ctx, time.Second*2)
+	defer shortCancel()
+	err = checkclient.Report(shortCtx, nil, checkclient.Options{
+		GRPCAddress: address,
+		TLSConfig:   &tls.Config{RootCAs: roots},
+	})
+	if err == nil {
+		t.Fatal("expected the report without a client certificate to be refused")
+	}
+	if reports != 1 {
+		t.Fatal("expected only the report with a client certificate to be handled but got", reports)
+	}
+}
+
+// TestGRPCServerTLSConfig ensures partial TLS flags are refused
+func TestGRPCServerTLSConfig(t *testing.T) {
+	setGRPCTLSFlags(t, "", "", "")
+	tlsConfig, err := grpcServerTLSConfig()
+	if err != nil || tlsConfig != nil {
+		t.Fatal("expected no TLS without TLS flags but got", tlsConfig, err)
+	}
+
+	setGRPCTLSFlags(t, "tls.crt", "", "")
+	_, err = grpcServerTLSConfig()
+	if err == nil {
+		t.Fatal("expected an error for a certificate without a key")
+	}
+
+	setGRPCTLSFlags(t, "", "", "ca.crt")
+	_, err = grpcServerTLSConfig()
+	if err == nil {
+		t.Fatal("expected an error for a client CA without a server certificate")
+	}
+}
+
+// TestGRPCForwardTLSConfig ensures gRPC run requests are only forwarded to kuberhealthy pods with a certificate of the
+// gRPC client CA that is issued for the DNS name of the kuberhealthy service
+func TestGRPCForwardTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	c := &Config{ExternalCheckReportingURL: "http://kuberhealthy.kuberhealthy.svc.cluster.local/externalCheckStatus"}
+
+	setGRPCTLSFlags(t, "", "", "")
+	_, err := grpcForwardTLSConfig(c)
+	if err == nil {
+		t.Fatal("expected an error forwarding without gRPC TLS")
+	}
+
+	pair := newTestServerKeyPair(t, 1)
+	setGRPCTLSFlags(t,
+		writeTestFile(t, dir, "tls.crt", pair.certPEM),
+		writeTestFile(t, dir, "tls.key", pair.keyPEM),
+		writeTestFile(t, dir, "ca.crt", pair.certPEM))
+	tlsConfig, err := grpcForwardTLSConfig(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tlsConfig.InsecureSkipVerify || tlsConfig.RootCAs == nil || tlsConfig.ServerName != "kuberhealthy.kuberhealthy.svc.cluster.local" || len(tlsConfig.Certificates) != 1 {
+		t.Fatalf("expected the certificate to be verified against the service DNS name but got %+v", tlsConfig)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// configuredCheckHistoryLength returns the number of recent run results kept in the khstate of each check
+func configuredCheckHistoryLength() int {
+	if cfg == nil {
+		return DefaultCheckHistoryLength
+	}
+	return cfg.CheckHistoryLength
+}
+
+// updateRunHistory updates the result of the run with the supplied UUID in a run history, adding a result for the
+// run if it has none, and returns the most recent results up to the supplied length.  Runs without a UUID always add
+// a result.  update is passed the result of the run and whether the run already had that result.
+func updateRunHistory(history []khstatev1.RunResult, uuid string, length int, now time.Time, update func(r *khstatev1.RunResult, found bool)) []khstatev1.RunResult {
+	if length <= 0 {
+		return nil
+	}
+
+	i := -1
+	if len(uuid) > 0 {
+		for j := range history {
+			if history[j].UUID == uuid {
+				i = j
+			}
+		}
+	}
+	found := i >= 0
+	if !found {
+		history = append(history, khstatev1.RunResult{UUID: uuid})
+		i = len(history) - 1
+	}
+	update(&history[i], found)
+	history[i].Time = metav1.NewTime(now)
+
+	if len(history) > length {
+		history = history[len(history)-length:]
+	}
+	return history
+}
+
+// recordKHStateRunResult records the result of a check run in the history of its khstate.  Repeated reports of the
+// same run update its result instead of adding another one.
+func recordKHStateRunResult(checkName string, checkNamespace string, uuid string, update func(r *khstatev1.RunResult, found bool)) error {
+	length := configuredCheckHistoryLength()
+	name := sanitizeResourceName(checkName)
+
+	// a report or run may update the khstate at the same time, so we retry on conflicts
+	var err error
+	for tries := 0; tries < 5; tries++ {
+		var khState khstatev1.KuberhealthyState
+		khState, err = khStateClient.KuberhealthyStates(checkNamespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return errors.New("Error retrieving CRD for: " + name + " " + err.Error())
+		}
+		if length <= 0 && len(khState.Spec.History) == 0 {
+			return nil
+		}
+		khState.Spec.History = updateRunHistory(khState.Spec.History, uuid, length, time.Now(), update)
+		_, err = khStateClient.KuberhealthyStates(checkNamespace).Update(&khState)
+		if err == nil || !strings.Contains(err.Error(), "the object has been modified") {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+	return err
+}
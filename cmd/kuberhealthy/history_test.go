@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// TestUpdateRunHistory ensures run results are added oldest first, that the results of a run are updated in place,
+// and that only the most recent results are kept
+func TestUpdateRunHistory(t *testing.T) {
+	now := time.Now()
+	var history []khstatev1.RunResult
+	for _, uuid := range []string{"a", "b", "c"} {
+		history = updateRunHistory(history, uuid, 2, now, func(r *khstatev1.RunResult, found bool) {
+			if found {
+				t.Fatal("expected no result for run", uuid)
+			}
+			r.OK = true
+		})
+	}
+	if len(history) != 2 || history[0].UUID != "b" || history[1].UUID != "c" {
+		t.Fatalf("expected the 2 most recent results but got %+v", history)
+	}
+
+	// a second update of a run changes its result
+	history = updateRunHistory(history, "b", 2, now.Add(time.Minute), func(r *khstatev1.RunResult, found bool) {
+		if !found {
+			t.Fatal("expected a result for run b")
+		}
+		r.RunDuration = "30s"
+	})
+	if len(history) != 2 || history[0].RunDuration != "30s" || !history[0].OK || !history[0].Time.Time.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected the result of run b to be updated: %+v", history)
+	}
+
+	// runs without a uuid always add a result
+	for i := 0; i < 2; i++ {
+		history = updateRunHistory(history, "", 3, now, func(r *khstatev1.RunResult, _ bool) {
+			r.Errors = []string{"failed"}
+		})
+	}
+	if len(history) != 3 || history[1].UUID != "" || history[2].UUID != "" {
+		t.Fatalf("expected a result for each run without a uuid: %+v", history)
+	}
+
+	if updateRunHistory(history, "d", 0, now, func(*khstatev1.RunResult, bool) {}) != nil {
+		t.Fatal("expected no history when the history length is 0")
+	}
+}
@@ -2,12 +2,14 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"net/url"
 
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/metrics"
 )
 
-// configureInflux configures influxdb connection information
+// configureInflux configures influxdb connection information.  InfluxDB 2.x is written to with an API token when
+// influxVersion is 2 and InfluxDB 1.x with a username and password otherwise.
 func configureInflux() (metrics.Client, error) {
 
 	var metricClient metrics.Client
@@ -18,12 +20,31 @@ func configureInflux() (metrics.Client, error) {
 		return metricClient, errors.New("Unable to parse influxUrl: " + err.Error())
 	}
 
+	switch cfg.InfluxVersion {
+	case 0, 1:
+	case 2:
+		client, err := metrics.NewInfluxV2Client(metrics.InfluxV2Config{
+			URL:    *influxURLParsed,
+			Token:  cfg.InfluxToken,
+			Org:    cfg.InfluxOrg,
+			Bucket: cfg.InfluxBucket,
+			Proxy:  outboundProxy(),
+		})
+		if err != nil {
+			return metricClient, err
+		}
+		return client, nil
+	default:
+		return metricClient, fmt.Errorf("unsupported influxVersion %d. Use 1 or 2", cfg.InfluxVersion)
+	}
+
 	// return an influx client with the right configuration details in it
 	return metrics.NewInfluxClient(metrics.InfluxClientInput{
 		Config: metrics.InfluxConfig{
 			URL:      *influxURLParsed,
 			Password: cfg.InfluxPassword,
 			Username: cfg.InfluxUsername,
+			Proxy:    outboundProxy(),
 		},
 		Database: cfg.InfluxDB,
 	})
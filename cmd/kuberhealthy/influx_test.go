@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/metrics"
+)
+
+// TestConfigureInflux ensures the influxVersion selects the InfluxDB API
+func TestConfigureInflux(t *testing.T) {
+	previous := cfg
+	defer func() { cfg = previous }()
+
+	cfg = &Config{InfluxURL: "http://influxdb:8086", InfluxDB: "kuberhealthy"}
+	client, err := configureInflux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := client.(*metrics.InfluxClient); !ok {
+		t.Fatalf("expected an InfluxDB 1.x client by default but got %T", client)
+	}
+
+	cfg = &Config{InfluxURL: "http://influxdb:8086", InfluxVersion: 2, InfluxToken: "token", InfluxOrg: "sre", InfluxBucket: "kuberhealthy"}
+	client, err = configureInflux()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := client.(*metrics.InfluxV2Client); !ok {
+		t.Fatalf("expected an InfluxDB 2.x client but got %T", client)
+	}
+
+	cfg = &Config{InfluxURL: "http://influxdb:8086", InfluxVersion: 2, InfluxOrg: "sre", InfluxBucket: "kuberhealthy"}
+	client, err = configureInflux()
+	if err == nil || client != nil {
+		t.Fatal("expected an error without an API token but got", client)
+	}
+
+	cfg = &Config{InfluxURL: "http://influxdb:8086", InfluxVersion: 3}
+	_, err = configureInflux()
+	if err == nil {
+		t.Fatal("expected an error for an unsupported version")
+	}
+}
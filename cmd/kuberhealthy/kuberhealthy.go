@@ -2,13 +2,14 @@ package main
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
-	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -18,49 +19,129 @@ import (
 	log "github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/alertmanager"
 	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
 	khjobv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khjob/v1"
 	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/cloudevents"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/federation"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
-	"github.com/kuberhealthy/kuberhealthy/v2/pkg/masterCalculation"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/metrics"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/runstore"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/webauth"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/webhook"
 )
 
+// checkDetailsPath is the path of the endpoint that serves the details of single checks and jobs
+const checkDetailsPath = "/api/v1/checks/"
+
 // Kuberhealthy represents the kuberhealthy server and its checks
 type Kuberhealthy struct {
-	Checks             []*external.Checker
-	ListenAddr         string // the listen address, such as ":80"
-	MetricForwarder    metrics.Client
-	overrideKubeClient *kubernetes.Clientset
-	cancelChecksFunc   context.CancelFunc // invalidates the context of all running checks
-	cancelReaperFunc   context.CancelFunc // invalidates the context of the reaper
-	wg                 sync.WaitGroup     // used to track running checks
-	shutdownCtxFunc    context.CancelFunc // used to shutdown the main control select
-	stateReflector     *StateReflector    // a reflector that can cache the current state of the khState resources
-	TargetNamespace    string             // the namespace that this instance will operate on. to include all namespaces, set this to a blank
-	config             *Config            // the config struct loaded at setup
+	Checks               []*external.Checker
+	ListenAddr           string // the listen address, such as ":80"
+	MetricForwarder      metrics.Client
+	metricSinks          []metrics.Client           // other clients check and job results are forwarded to, such as StatsD. empty when none are enabled
+	pushgateway          *metrics.PushgatewayClient // pushes check and job results to a Prometheus Pushgateway. nil when disabled
+	overrideKubeClient   *kubernetes.Clientset
+	cancelChecksFunc     context.CancelFunc            // invalidates the context of all running checks
+	cancelReaperFunc     context.CancelFunc            // invalidates the context of the reaper
+	wg                   sync.WaitGroup                // used to track running checks
+	shutdownCtxFunc      context.CancelFunc            // used to shutdown the main control select
+	stateReflector       *StateReflector               // a reflector that can cache the current state of the khState resources
+	checkInformer        *checkInformer                // an informer that caches the khCheck resources and signals when they change
+	checkSchedules       checkSchedules                // the schedules of the running checks, used to change their timing
+	TargetNamespaces     []string                      // the namespaces that this instance will operate on. a single blank namespace includes all namespaces
+	config               *Config                       // the config struct loaded at setup
+	eventRecorder        record.EventRecorder          // records events when checks fail or recover. nil when events are disabled
+	cloudEventsSink      *cloudevents.Sink             // sends CloudEvents when checks fail or recover. nil when CloudEvents are disabled
+	webhookSinks         []*webhook.Sink               // post a JSON payload to each webhook when checks fail or recover. empty when webhooks are disabled
+	alertmanagerNotifier *alertmanager.Notifier        // pushes alerts to Alertmanager when checks fail or recover. nil when alerts are disabled
+	slackNotifier        *slackNotifier                // posts a message to Slack when checks fail. nil when Slack notifications are disabled
+	auditTrail           *auditTrail                   // records every check run. nil when the audit trail is disabled
+	runStore             runstore.Store                // keeps the results of check runs across restarts. nil when no run store is configured
+	statusCache          *statusCache                  // caches the assembled status page
+	runDurations         *metrics.RunDurationHistogram // the run durations of checks and jobs since startup, served on /metrics
+	loadedChecks         map[string]bool               // the namespace/name of each khcheck loaded by the last check configuration
+	webServer            *http.Server                  // the currently running web server
+	webServerMu          sync.Mutex                    // guards the web server and its listen address and TLS settings
+	tlsCertFile          string                        // the TLS certificate the web server is served with. blank for plain http
+	tlsKeyFile           string                        // the key of the TLS certificate
+	tlsReloadInterval    time.Duration                 // how often the TLS certificate is reloaded when it was rotated. zero disables reloading
+	webAuth              *webauth.Authenticator        // authenticates requests to the web server. nil when authentication is disabled
+	apiServerProber      *apiServerProber              // probes the API server for the API server latency check. nil when probing is disabled
+	federation           *federation.Poller            // polls the status pages of the federated clusters
+	stateWatchers        *stateWatchHub                // streams the state transitions observed by the state reflector to watch clients
+	readinessProbe       *readinessProbe               // checks whether the Kubernetes API can be reached for the readiness probe
+	shuttingDown         int32                         // set to 1 once Kuberhealthy starts to shut down. accessed atomically
+	checkRuns            checkRunTracker               // the check runs in flight, drained when Kuberhealthy shuts down
+	runSlots             runSlots                      // limits the check runs in flight at once. nil when runs are not limited
 }
 
-// NewKuberhealthy creates a new kuberhealthy checker instance restricted to the desired
-// namespace.  If this instance should apply to all namespaces, pass a blank here.
+// NewKuberhealthy creates a new kuberhealthy checker instance restricted to the namespaces of the configuration.  If
+// none are configured, the instance applies to all namespaces.
 func NewKuberhealthy(cfg *Config) *Kuberhealthy {
 	kh := &Kuberhealthy{
-		TargetNamespace: cfg.TargetNamespace,
-		ListenAddr:      cfg.ListenAddress,
-		config:          cfg,
+		TargetNamespaces:  cfg.targetNamespaces(),
+		ListenAddr:        cfg.ListenAddress,
+		config:            cfg,
+		tlsCertFile:       cfg.TLSCertFile,
+		tlsKeyFile:        cfg.TLSKeyFile,
+		tlsReloadInterval: cfg.TLSReloadInterval,
+	}
+	kh.stateReflector = NewStateReflector(kh.TargetNamespaces)
+	kh.stateWatchers = newStateWatchHub()
+	kh.stateWatchers.workload = kh.stateReflector.Workload
+	// the status page is assembled from the reflector, so it is assembled again once any khstate changes
+	kh.stateReflector.onChange = func(previous *khstatev1.KuberhealthyState, current *khstatev1.KuberhealthyState) {
+		kh.statusCache.invalidate()
+		kh.stateWatchers.observe(previous, current)
+	}
+	if emitEvents && kubernetesClient != nil {
+		kh.eventRecorder = newEventRecorder(kubernetesClient)
+	}
+	kh.cloudEventsSink = newCloudEventsSink()
+	kh.webhookSinks = newWebhookSinks()
+	kh.alertmanagerNotifier = newAlertmanagerNotifier()
+	kh.slackNotifier = newSlackNotifier()
+	var err error
+	kh.metricSinks, err = newMetricSinks()
+	if err != nil {
+		log.Fatalln("Error setting up metric forwarding:", err)
+	}
+	kh.pushgateway, err = newPushgatewayClient()
+	if err != nil {
+		log.Fatalln("Error setting up the Pushgateway:", err)
+	}
+	kh.webAuth, err = newWebAuthenticator()
+	if err != nil {
+		log.Fatalln("Error setting up web server authentication:", err)
+	}
+	kh.auditTrail, err = newAuditTrail(auditLog, auditLogFile)
+	if err != nil {
+		log.Fatalln("Error setting up the audit trail:", err)
+	}
+	kh.runStore, err = newRunStore()
+	if err != nil {
+		log.Fatalln("Error opening the run store:", err)
 	}
-	kh.stateReflector = NewStateReflector(kh.TargetNamespace)
+	kh.statusCache = newStatusCache(statusCacheTTL)
+	kh.federation = federation.NewPoller()
+	kh.federation.Proxy = outboundProxy()
+	kh.runDurations = metrics.NewRunDurationHistogram(metrics.DefaultRunDurationBuckets)
+	kh.readinessProbe = newReadinessProbe(kubernetesAPIReachable, readinessCacheTTL)
 	return kh
 }
 
 // setCheckExecutionError sets an execution error for a check name in
-// its crd status
-func (k *Kuberhealthy) setCheckExecutionError(checkName string, checkNamespace string, exErr error) error {
+// its crd status along with the checker pod logs and container statuses captured for the failed run
+func (k *Kuberhealthy) setCheckExecutionError(checkName string, checkNamespace string, exErr error, logExcerpt string, containers []khstatev1.ContainerRunStatus) error {
 	details := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
 	check, err := k.getCheck(checkName, checkNamespace)
 	if err != nil {
@@ -71,6 +152,8 @@ func (k *Kuberhealthy) setCheckExecutionError(checkName string, checkNamespace s
 	}
 	details.OK = false
 	details.Errors = []string{"Check execution error: " + exErr.Error()}
+	details.LogExcerpt = logExcerpt
+	details.Containers = containers
 
 	// we need to maintain the current UUID, which means fetching it first
 	khc, err := k.getCheck(checkName, checkNamespace)
@@ -83,6 +166,9 @@ func (k *Kuberhealthy) setCheckExecutionError(checkName string, checkNamespace s
 		return fmt.Errorf("error when setting execution error on check (getting check state for current UUID) %s %s %w", checkName, checkNamespace, err)
 	}
 	details.CurrentUUID = checkState.CurrentUUID
+	if applyFailureThreshold(&details, checkState, "", check.FailureThreshold) {
+		log.Infoln("Holding back failure", details.ConsecutiveFailures, "of", check.FailureThreshold, "of check", checkName, "in namespace", checkNamespace+":", exErr)
+	}
 	log.Debugln("Setting execution state of check", checkName, "to", details.OK, details.Errors, details.CurrentUUID, details.GetKHWorkload())
 
 	// store the check state with the CRD
@@ -93,8 +179,9 @@ func (k *Kuberhealthy) setCheckExecutionError(checkName string, checkNamespace s
 	return nil
 }
 
-// setJobExecutionError sets an execution error for a job name in its crd status
-func (k *Kuberhealthy) setJobExecutionError(jobName string, jobNamespace string, exErr error) error {
+// setJobExecutionError sets an execution error for a job name in its crd status along with the checker pod logs
+// and container statuses captured for the failed run
+func (k *Kuberhealthy) setJobExecutionError(jobName string, jobNamespace string, exErr error, logExcerpt string, containers []khstatev1.ContainerRunStatus) error {
 	details := khstatev1.NewWorkloadDetails(khstatev1.KHJob)
 	job, err := k.getJob(jobName, jobNamespace)
 	if err != nil {
@@ -105,6 +192,8 @@ func (k *Kuberhealthy) setJobExecutionError(jobName string, jobNamespace string,
 	}
 	details.OK = false
 	details.Errors = []string{"Job execution error: " + exErr.Error()}
+	details.LogExcerpt = logExcerpt
+	details.Containers = containers
 
 	// we need to maintain the current UUID, which means fetching it first
 	khj, err := k.getJob(jobName, jobNamespace)
@@ -135,6 +224,14 @@ func (k *Kuberhealthy) AddCheck(c *external.Checker) {
 
 // Shutdown causes the kuberhealthy chec k group to shutdown gracefully
 func (k *Kuberhealthy) Shutdown(doneChan chan struct{}) {
+	k.setShuttingDown() // fail the readiness probe and start no more check runs
+
+	// give the check runs in flight a chance to complete before they are aborted
+	log.Infoln("shutdown: waiting up to", shutdownGracePeriod, "for check runs in flight to complete")
+	if !k.drainCheckRuns(shutdownGracePeriod) {
+		log.Warningln("shutdown: aborting the check runs still in flight after", shutdownGracePeriod)
+	}
+
 	if k.shutdownCtxFunc != nil {
 		log.Infoln("shutdown: aborting control context")
 		k.shutdownCtxFunc() // stop the control system
@@ -142,6 +239,11 @@ func (k *Kuberhealthy) Shutdown(doneChan chan struct{}) {
 	time.Sleep(5 * time.Second) // help prevent more checks from starting in a race before control system stop happens
 	log.Infoln("shutdown: stopping checks")
 	k.StopChecks() // stop all checks
+
+	// wait for the aborted check runs to remove their checker pods
+	if !k.drainCheckRuns(shutdownCleanupTimeout) {
+		log.Warningln("shutdown: aborted check runs did not finish cleaning up within", shutdownCleanupTimeout)
+	}
 	log.Infoln("shutdown: ready for main program shutdown")
 	doneChan <- struct{}{}
 }
@@ -186,16 +288,65 @@ func (k *Kuberhealthy) Start(ctx context.Context) {
 		k.configureInfluxForwarding()
 	}
 
+	// deliver CloudEvents in the background
+	if k.cloudEventsSink != nil {
+		go k.cloudEventsSink.Start(ctx)
+	}
+
+	// deliver webhook payloads in the background
+	for _, sink := range k.webhookSinks {
+		go sink.Start(ctx)
+	}
+
+	// push alerts to Alertmanager in the background
+	if k.alertmanagerNotifier != nil {
+		go k.alertmanagerNotifier.Start(ctx)
+	}
+
+	// post Slack messages in the background
+	if k.slackNotifier != nil {
+		go k.slackNotifier.sink.Start(ctx)
+	}
+
+	// export Kuberhealthy internals to the OpenTelemetry collector in the background
+	for _, sink := range k.metricSinks {
+		if otlp, ok := sink.(*metrics.OTLPClient); ok {
+			go k.exportInternals(ctx, otlp)
+		}
+	}
+
 	// Start the web server and restart it if it crashes
 	go k.StartWebServer()
 
-	// find all the external checks from the khcheckcrd resources on the cluster and keep them in sync.
+	// serve check reports over gRPC if enabled
+	if len(grpcListenAddress) > 0 {
+		go k.StartGRPCServer(ctx)
+	}
+
+	// validate khchecks as they are applied if enabled
+	if len(admissionListenAddress) > 0 {
+		go k.StartAdmissionServer(ctx)
+	}
+
+	// poll the status pages of the federated clusters in the background
+	go k.runFederation(ctx)
+
+	// remove results older than the retention from the run store in the background
+	if k.runStore != nil {
+		go k.pruneRunStore(ctx)
+	}
+
+	// stream state transitions to watch clients
+	go k.stateWatchers.run(ctx.Done())
+
+	// keep a cache of the khcheck resources on the cluster with an informer that signals when they change.
 	// use rate limiting to avoid reconfiguration spam
 	maxUpdateInterval := time.Second * 10
-	externalChecksUpdateChan := make(chan struct{}, 50)
+	k.checkInformer = newCheckInformer(k.TargetNamespaces)
+	k.checkInformer.onScheduleChange = k.updateCheckSchedule
 	externalChecksUpdateChanLimited := make(chan struct{}, 50)
-	go notifyChanLimiter(maxUpdateInterval, externalChecksUpdateChan, externalChecksUpdateChanLimited)
-	go k.monitorExternalChecks(ctx, externalChecksUpdateChan)
+	go notifyChanLimiter(maxUpdateInterval, k.checkInformer.changes, externalChecksUpdateChanLimited)
+	go k.checkInformer.Run(ctx)
 
 	// we use two channels to indicate when we gain or lose master status. use rate limiting to avoid
 	// reconfiguration spam
@@ -204,12 +355,21 @@ func (k *Kuberhealthy) Start(ctx context.Context) {
 	go k.masterMonitor(ctx, becameMasterChan, lostMasterChan)
 
 	// monitor for kuberhealthy jobs and trigger when a new job is added
-	go k.monitorKHJobs(ctx)
+	for _, namespace := range k.TargetNamespaces {
+		go k.monitorKHJobs(ctx, namespace)
+	}
 
 	// get notified when kuberhealthy configuration is reloaded
 	configReloadChan := make(chan struct{})
 	go configReloadNotifier(ctx, configReloadChan)
 
+	// when checks are sharded, every kuberhealthy pod runs its share of the checks regardless of master status
+	if shardChecks {
+		log.Infoln("control: Checks are sharded across kuberhealthy pods. Starting checks.")
+		refreshShardMembers(kubernetesClient)
+		k.StartChecks(ctx)
+	}
+
 	// loop and select channels to do appropriate thing when:
 	// - master kuberhealthy pod changes
 	// - new khchecks are added or modified
@@ -220,32 +380,55 @@ func (k *Kuberhealthy) Start(ctx context.Context) {
 			log.Infoln("control: shutting down from context abort...")
 			return
 		case <-becameMasterChan: // we have become the current master instance and should run checks
+			if shardChecks {
+				log.Infoln("control: Became master. Starting reaper.")
+				k.StartReaper(ctx)
+				continue
+			}
 			// reset checks and re-add from configuration settings
 			log.Infoln("control: Became master. Reconfiguring and starting checks.")
 			k.StartChecks(ctx)
 			k.StartReaper(ctx)
 		case <-lostMasterChan: // we are no longer master
+			if shardChecks {
+				log.Infoln("control: Lost master. Stopping reaper.")
+				k.StopReaper()
+				continue
+			}
 			log.Infoln("control: Lost master. Stopping checks.")
 			k.StopChecks()
 			k.StopReaper()
 		case <-externalChecksUpdateChanLimited: // external check change detected
 			log.Infoln("control: Witnessed a khcheck resource change...")
 
-			// if we are master, stop, reconfigure our khchecks, and start again with the new configuration
-			if isMaster {
+			// if we are running checks, stop, reconfigure our khchecks, and start again with the new configuration
+			if isMaster.Load() || shardChecks {
 				log.Infoln("control: Reloading external check configurations due to khcheck update")
 				k.RestartChecks(ctx)
+			}
+			if isMaster.Load() {
 				k.RestartReaper(ctx)
 			}
 		case <-configReloadChan:
 			log.Infoln("control: Witnessed a kuberhealthy configuration change...")
 
-			// if we are master, stop, reconfigure our khchecks, and start again with the new configuration
-			if isMaster {
+			// check overrides may have changed the run intervals and paused state of khchecks
+			if k.checkInformer != nil {
+				k.checkInformer.refreshCheckSettings()
+			}
+
+			// if we are running checks, stop, reconfigure our khchecks, and start again with the new configuration
+			if isMaster.Load() || shardChecks {
 				log.Infoln("control: Reloading external check configurations due to kuberhealthy configuration update")
-				k.RestartChecks(ctx)
-				k.RestartReaper(ctx)
+				k.StopChecks()
+				k.applyConfigChanges(cfg)
+				k.StartChecks(ctx)
+				if isMaster.Load() {
+					k.RestartReaper(ctx)
+				}
+				continue
 			}
+			k.applyConfigChanges(cfg)
 		}
 	}
 }
@@ -254,7 +437,17 @@ func (k *Kuberhealthy) Start(ctx context.Context) {
 func (k *Kuberhealthy) StartReaper(ctx context.Context) {
 	reaperCtx, reaperCtxCancel := context.WithCancel(ctx)
 	k.cancelReaperFunc = reaperCtxCancel
-	go reaper(reaperCtx, k.TargetNamespace)
+	go reaper(reaperCtx, k.TargetNamespaces)
+
+	// when checks are sharded, every kuberhealthy pod runs checks, so only the master reaps khstates
+	if shardChecks {
+		go k.khStateResourceReaper(reaperCtx, k.TargetNamespaces)
+	}
+
+	// delete the checker pods and check resources left behind by interrupted runs
+	if orphanGC {
+		go k.orphanReaper(reaperCtx, k.TargetNamespaces)
+	}
 }
 
 // StopReaper stops the check reaper
@@ -277,7 +470,7 @@ func (k *Kuberhealthy) RestartChecks(ctx context.Context) {
 }
 
 // khStateResourceReaper runs reapKHStateResources on an interval until the context for it is canceled
-func (k *Kuberhealthy) khStateResourceReaper(ctx context.Context, namespace string) {
+func (k *Kuberhealthy) khStateResourceReaper(ctx context.Context, namespaces []string) {
 
 	ticker := time.NewTicker(time.Minute)
 	defer ticker.Stop()
@@ -287,7 +480,7 @@ func (k *Kuberhealthy) khStateResourceReaper(ctx context.Context, namespace stri
 		select {
 		case <-ticker.C:
 			log.Infoln("khState reaper: starting to run an audit")
-			err := k.reapKHStateResources(ctx, namespace)
+			err := k.reapKHStateResources(ctx, namespaces)
 			if err != nil {
 				log.Errorln("khState reaper: Error when reaping khState resources:", err)
 			}
@@ -299,73 +492,68 @@ func (k *Kuberhealthy) khStateResourceReaper(ctx context.Context, namespace stri
 
 }
 
-// reapKHStateResources runs a single audit on khState resources.  Any that don't have a matching khCheck are
-// deleted.
-func (k *Kuberhealthy) reapKHStateResources(ctx context.Context, namespace string) error {
-
-	// list all khStates in the cluster
-	khStates, err := khStateClient.KuberhealthyStates(namespace).List(metav1.ListOptions{})
-	if err != nil {
-		return fmt.Errorf("khState reaper: error listing khStates for reaping: %w", err)
-	}
+// reapKHStateResources runs a single audit on the khState resources in the namespaces.  Any that don't have a matching
+// khCheck are deleted.
+func (k *Kuberhealthy) reapKHStateResources(ctx context.Context, namespaces []string) error {
 
-	khChecks, err := k.listKHChecks(k.TargetNamespace)
+	// collect the khChecks and khJobs that khStates may belong to
+	owners := make(map[string]bool)
+	err := k.forEachKHCheck(func(kc khcheckv1.KuberhealthyCheck) {
+		owners[kc.GetNamespace()+"/"+kc.GetName()] = true
+	})
 	if err != nil {
-		return fmt.Errorf("khState reaper: error listing unstructured khChecks: %w", err)
+		return fmt.Errorf("khState reaper: error listing khChecks: %w", err)
 	}
-
-	khJobs, err := khJobClient.KuberhealthyJobs(namespace).List(metav1.ListOptions{})
-	if err != nil {
-		return fmt.Errorf("khState reaper: error listing khJobs for reaping: %w", err)
+	for _, namespace := range namespaces {
+		err = forEachKHJob(khJobClient, namespace, func(kj khjobv1.KuberhealthyJob) {
+			owners[kj.GetNamespace()+"/"+kj.GetName()] = true
+		})
+		if err != nil {
+			return fmt.Errorf("khState reaper: error listing khJobs for reaping: %w", err)
+		}
 	}
 
-	log.Infoln("khState reaper: analyzing", len(khStates.Items), "khState resources")
-
-	// any khState that does not have a matching khCheck should be deleted (ignore errors)
-	for _, khState := range khStates.Items {
-		log.Debugln("khState reaper: analyzing khState", khState.GetName(), "in", khState.GetName())
-		var foundKHCheck bool
-		for _, kc := range khChecks.Items {
-			if err != nil {
-				log.Errorln("Error converting unstructured object to khcheck:", err)
-				continue
-			}
-			log.Debugln("khState reaper:", kc.GetName(), "==", khState.GetName(), "&&", kc.GetNamespace(), "==", khState.GetNamespace())
-			if kc.GetName() == khState.GetName() && kc.GetNamespace() == khState.GetNamespace() {
-				log.Infoln("khState reaper:", khState.GetName(), "in", khState.GetNamespace(), "is still valid")
-				foundKHCheck = true
-				break
-			}
+	// the khStates of built-in checks belong to kuberhealthy itself
+	for _, name := range []string{certCheckName, apiServerLatencyCheckName, nodeCheckName, rolloutCheckName} {
+		if isBuiltinCheck(name, podNamespace) {
+			owners[podNamespace+"/"+name] = true
 		}
+	}
 
-		var foundKHJob bool
-		for _, kj := range khJobs.Items {
-			log.Debugln("khState reaper:", kj.GetName(), "==", khState.GetName(), "&&", kj.GetNamespace(), "==", khState.GetNamespace())
-			if kj.GetName() == khState.GetName() && kj.GetNamespace() == khState.GetNamespace() {
+	// any khState that does not have a matching khCheck or khJob should be deleted (ignore errors)
+	var analyzed int
+	for _, namespace := range namespaces {
+		err = forEachKHState(khStateClient, namespace, func(khState khstatev1.KuberhealthyState) {
+			analyzed++
+			log.Debugln("khState reaper: analyzing khState", khState.GetName(), "in", khState.GetNamespace())
+			if owners[khState.GetNamespace()+"/"+khState.GetName()] {
 				log.Infoln("khState reaper:", khState.GetName(), "in", khState.GetNamespace(), "is still valid")
-				foundKHJob = true
-				break
+				return
 			}
-		}
 
-		// if we didn't find a matching khCheck or khJob, delete the rogue khState
-		if !foundKHCheck && !foundKHJob {
 			log.Infoln("khState reaper: removing khState", khState.GetName(), "in", khState.GetNamespace())
 			err := khStateClient.KuberhealthyStates(khState.GetNamespace()).Delete(khState.GetName(), &metav1.DeleteOptions{})
 			if err != nil {
 				log.Errorln(fmt.Errorf("khState reaper: error when removing invalid khstate: %w", err))
+				return
 			}
+			k.deleteWorkloadMetrics(khState.GetName(), khState.GetNamespace())
+		})
+		if err != nil {
+			return fmt.Errorf("khState reaper: error listing khStates for reaping: %w", err)
 		}
 	}
+	log.Infoln("khState reaper: analyzed", analyzed, "khState resources")
 
 	return nil
 
 }
 
-// monitorKHJobs watches for newly added KHJobs and triggers them
-func (k *Kuberhealthy) monitorKHJobs(ctx context.Context) {
+// monitorKHJobs watches for newly added KHJobs in the namespace and triggers them.  To include all namespaces, pass a
+// blank namespace.
+func (k *Kuberhealthy) monitorKHJobs(ctx context.Context, namespace string) {
 
-	log.Debugln("Spawned watcher for KH jobs")
+	log.Debugln("Spawned watcher for KH jobs in namespace:", namespace)
 
 	for {
 		log.Debugln("Starting a watch for khcheck jobs")
@@ -373,7 +561,7 @@ func (k *Kuberhealthy) monitorKHJobs(ctx context.Context) {
 		// wait a second so we don't retry too quickly on error
 		time.Sleep(time.Second)
 
-		watcher, err := khJobClient.KuberhealthyJobs(k.TargetNamespace).Watch(metav1.ListOptions{})
+		watcher, err := khJobClient.KuberhealthyJobs(namespace).Watch(metav1.ListOptions{})
 		if err != nil {
 			log.Errorln("error watching for khjob objects:", err)
 			continue
@@ -429,11 +617,6 @@ func (k *Kuberhealthy) monitorKHJobs(ctx context.Context) {
 	}
 }
 
-// listKHChecks lists all kuberhealthy checks in the specified namespace
-func (k *Kuberhealthy) listKHChecks(namespace string) (khcheckv1.KuberhealthyCheckList, error) {
-	return khCheckClient.KuberhealthyChecks(namespace).List(metav1.ListOptions{})
-}
-
 // getKHCheck gets the specified khcheck in the specified namespace
 func (k *Kuberhealthy) getKHCheck(namespace string, checkName string) (khcheckv1.KuberhealthyCheck, error) {
 	return khCheckClient.KuberhealthyChecks(namespace).Get(checkName, metav1.GetOptions{})
@@ -449,72 +632,6 @@ func (k *Kuberhealthy) getKHState(namespace string, checkName string) (khstatev1
 	return khStateClient.KuberhealthyStates(namespace).Get(checkName, metav1.GetOptions{})
 }
 
-// watchForKHCheckChanges watches for changes to khcheck objects and returns them through the specified channel
-func (k *Kuberhealthy) watchForKHCheckChanges(ctx context.Context, c chan struct{}) {
-
-	log.Debugln("Spawned watcher for KH check changes")
-
-	for {
-		log.Debugln("Starting a watch for khcheck object changes")
-
-		// wait a second so we don't retry too quickly on error
-		time.Sleep(time.Second)
-
-		// start a watch on khcheck resources
-		watcher, err := khCheckClient.KuberhealthyChecks(k.TargetNamespace).Watch(metav1.ListOptions{})
-		// watcher, err := watchUnstructuredKHChecks(ctx, k.TargetNamespace)
-		if err != nil {
-			log.Errorln("error creating watcher for khcheck objects:", err)
-			continue
-		}
-
-		// watch for the watcher context to end, or the parent context.  If the parent context ends, we close the watcher.
-		// if the watcher context ends, we shut down this go routine to prevent a leak as it restarts
-		watcherCtx, watcherCtxCancel := context.WithCancel(context.Background())
-		go func(watchCtx context.Context, ctx context.Context, watcher watch.Interface) {
-			select {
-			case <-watchCtx.Done():
-				break
-			case <-ctx.Done():
-				watcher.Stop()
-			}
-			log.Debugln("khcheck change monitor watch stopping")
-		}(watcherCtx, ctx, watcher)
-
-		// loop over results and return them to the calling channel until we hit an error, then close and restart
-		for khc := range watcher.ResultChan() {
-			switch khc.Type {
-			case watch.Added:
-				log.Debugln("khcheck monitor saw an added event")
-				c <- struct{}{}
-			case watch.Modified:
-				log.Debugln("khcheck monitor saw a modified event")
-				c <- struct{}{}
-			case watch.Deleted:
-				log.Debugln("khcheck monitor saw a deleted event")
-				c <- struct{}{}
-			case watch.Error:
-				log.Debugln("khcheck monitor saw an error event")
-				e := khc.Object.(*metav1.Status)
-				log.Errorln("Error when watching for khcheck changes:", e.Reason)
-				continue
-			default:
-				log.Warningln("khcheck monitor saw an unknown event type and ignored it:", khc.Type)
-			}
-		}
-
-		// if the watcher breaks, shutdown the parent context monitor go routine
-		watcherCtxCancel()
-
-		select {
-		case <-ctx.Done():
-			log.Debugln("khcheck monitor closing due to context cancellation")
-			return
-		default:
-		}
-	}
-}
-
 func verifyNewKHJob(khJobName string, khJobNamespace string) bool {
 
 	kj, err := khJobClient.KuberhealthyJobs(khJobNamespace).Get(khJobName, metav1.GetOptions{})
@@ -527,119 +644,30 @@ func verifyNewKHJob(khJobName string, khJobNamespace string) bool {
 	return kj.Spec.Phase == ""
 }
 
-// monitorExternalChecks watches for changes to the external check CRDs
-func (k *Kuberhealthy) monitorExternalChecks(ctx context.Context, notify chan struct{}) {
-
-	// make a map of resource versions so we know when things change
-	knownSettings := make(map[string]khcheckv1.CheckConfig)
-
-	// start watching for events to changes in the background
-	c := make(chan struct{})
-	go k.watchForKHCheckChanges(ctx, c)
-
-	// each time  we see a change in our khcheck structs, we should look at every object to see if something has changed
-	for {
-
-		// wait for the change channel to detect a change before scanning again
-		<-c
-		log.Debugln("Change notification received. Scanning for external check changes...")
-
-		khChecks, err := k.listKHChecks(k.TargetNamespace)
-		if err != nil {
-			log.Errorln("error listing unstructured khChecks: %w", err)
-			continue
-		}
-
-		// this bool indicates if we should send a change signal to the channel
-		var foundChange bool
-
-		// if a khcheck has been deleted, then we signal for change and purge it from the knownSettings map.
-		for mapName := range knownSettings {
-			var existsInItems bool // indicates the item exists in the item listing
-
-			for _, kc := range khChecks.Items {
-
-				itemMapName := kc.Namespace + "/" + kc.Name
-				if itemMapName == mapName {
-					existsInItems = true
-					break
-				}
-			}
-			if !existsInItems {
-				log.Debugln("Detected khcheck deletion for", mapName)
-				delete(knownSettings, mapName)
-				foundChange = true
-			}
-		}
-
-		for _, kc := range khChecks.Items {
-			if err != nil {
-				log.Errorln("Error converting unstructured object to khcheck:", err)
-				continue
-			}
-
-			mapName := kc.Namespace + "/" + kc.Name
-
-			log.Debugln("Scanning khcheck CRD", mapName, "for changes since last seen...")
-
-			if len(kc.Namespace) < 1 {
-				log.Warning("Got khcheck update from object with no namespace...")
-				continue
-			}
-			if len(kc.Name) < 1 {
-				log.Warning("Got khcheck update from object with no name...")
-				continue
-			}
-
-			// if we don't know about this check yet, just store the state and continue.  The check is already
-			// loaded on the first check configuration run.
-			_, exists := knownSettings[mapName]
-			if !exists {
-				log.Debugln("First time seeing khcheck of name", mapName)
-				knownSettings[mapName] = kc.Spec
-				foundChange = true
-			}
-
-			// check if run interval has changed
-			if knownSettings[mapName].RunInterval != kc.Spec.RunInterval {
-				log.Debugln("The khcheck run interval for", mapName, "has changed.")
-				foundChange = true
-			}
-
-			// check if run timeout has changed
-			if knownSettings[mapName].Timeout != kc.Spec.Timeout {
-				log.Debugln("The khcheck timeout for", mapName, "has changed.")
-				foundChange = true
-			}
-
-			// check if extraLabels has changed
-			if !foundChange && !reflect.DeepEqual(knownSettings[mapName].ExtraLabels, kc.Spec.ExtraLabels) {
-				log.Debugln("The khcheck extra labels for", mapName, "has changed.")
-				foundChange = true
-			}
-
-			// check if extraAnnotations has changed
-			if !foundChange && !reflect.DeepEqual(knownSettings[mapName].ExtraAnnotations, kc.Spec.ExtraAnnotations) {
-				log.Debugln("The khcheck extra annotations for", mapName, "has changed.")
-				foundChange = true
-			}
-
-			// check if CheckConfig has changed (PodSpec)
-			if !foundChange && !reflect.DeepEqual(knownSettings[mapName].PodSpec, kc.Spec.PodSpec) {
-				log.Debugln("The khcheck for", mapName, "has changed.")
-				foundChange = true
-			}
-
-			// finally, update known settings before continuing to the next interval
-			knownSettings[mapName] = kc.Spec
-		}
-
-		// if a change was detected, we signal the notify channel
-		if foundChange {
-			log.Debugln("Signaling that a change was found in external check configuration")
-			notify <- struct{}{}
-		}
+// checkConfigFingerprint returns a fingerprint of the khcheck settings that require the check to be reloaded when
+// they change
+func checkConfigFingerprint(spec khcheckv1.CheckConfig) string {
+	b, err := json.Marshal(struct {
+		RunInterval       string
+		Timeout           string
+		ConcurrencyPolicy khcheckv1.ConcurrencyPolicy
+		FailureThreshold  int
+		RetryBackoff      string
+		Paused            bool
+		ExtraLabels       map[string]string
+		ExtraAnnotations  map[string]string
+		PodSpec           v1.PodSpec
+		RBAC              *khcheckv1.CheckRBAC
+		DependsOn         []string
+		DependencyPolicy  khcheckv1.DependencyPolicy
+	}{spec.RunInterval, spec.Timeout, spec.ConcurrencyPolicy, spec.FailureThreshold, spec.RetryBackoff, spec.Paused, spec.ExtraLabels, spec.ExtraAnnotations, spec.PodSpec, spec.RBAC, spec.DependsOn, spec.DependencyPolicy})
+	if err != nil {
+		// a check that can not be fingerprinted is always seen as changed
+		log.Errorln("Error fingerprinting khcheck settings:", err)
+		return ""
 	}
+	sum := sha256.Sum256(b)
+	return string(sum[:])
 }
 
 // setExternalChecks syncs up the state of the external-checks installed in this
@@ -648,65 +676,101 @@ func (k *Kuberhealthy) addExternalChecks(ctx context.Context) error {
 
 	log.Debugln("Fetching khcheck configurations...")
 
-	khChecks, err := k.listKHChecks(k.TargetNamespace)
+	// khchecks are loaded a page at a time.  When there are more than maxChecks, checks that were loaded before
+	// are kept over new ones.
+	admission := newCheckAdmission(maxChecks, k.loadedChecks)
+	var found int
+	err := k.forEachKHCheck(func(kc khcheckv1.KuberhealthyCheck) {
+		found++
+		kc = applyCheckOverrides(configuredCheckOverrides(), kc)
+
+		// paused checks are not loaded until they are resumed
+		if checkPaused(kc) {
+			log.Infoln("Skipping paused external check:", kc.Name, "in namespace", kc.Namespace)
+			k.setCheckPausedState(kc, true)
+			return
+		}
+		k.setCheckPausedState(kc, false)
+		if admission.admit(kc) {
+			k.addExternalCheck(kc)
+		}
+	})
 	if err != nil {
 		return err
 	}
+	for _, kc := range admission.admitPending() {
+		k.addExternalCheck(kc)
+	}
+	for _, name := range admission.rejected {
+		log.Warningln("Rejecting external check", name, "because the limit of", maxChecks, "checks set with --maxChecks was reached")
+	}
+	k.loadedChecks = admission.loaded
 
-	log.Debugln("Found", len(khChecks.Items), "external checks to load")
+	log.Debugln("Loaded", len(admission.loaded), "of", found, "external checks")
+	return nil
+}
 
-	// iterate on each check CRD resource and add it as a check
-	for _, kc := range khChecks.Items {
-		if err != nil {
-			log.Errorln("Error converting unstructured object to khcheck:", err)
-			continue
-		}
-		log.Debugln("Loading check CRD:", kc.Name)
+// addExternalCheck configures a khcheck as an external check and adds it to the checks of this Kuberhealthy
+func (k *Kuberhealthy) addExternalCheck(kc khcheckv1.KuberhealthyCheck) {
+	log.Debugln("Loading check CRD:", kc.Name)
 
-		log.Debugf("External check custom resource loaded: %v", kc)
+	log.Debugf("External check custom resource loaded: %v", kc)
+	k.recordMisconfiguredEvent(kc, logKHCheckValidation(kc))
 
-		// create a new kubernetes client for this external checker
-		log.Infoln("Enabling external check:", kc.Name)
-		c := external.New(kubernetesClient, &kc, khCheckClient, khStateClient, cfg.ExternalCheckReportingURL)
+	// create a new kubernetes client for this external checker
+	log.Infoln("Enabling external check:", kc.Name)
+	c := external.New(kubernetesClient, &kc, khCheckClient, khStateClient, cfg.ExternalCheckReportingURL)
+	c.KuberhealthyReportingCA = cfg.reportingCA
 
-		// parse the run interval string from the custom resource and setup the run interval
-		c.RunInterval, err = time.ParseDuration(kc.Spec.RunInterval)
-		if err != nil {
-			log.Errorln("Error parsing duration for check", c.CheckName, "in namespace", c.Namespace, err)
-			log.Errorln("Defaulting check to a runtime of ten minutes.")
-			c.RunInterval = DefaultRunInterval
-		}
+	// parse the run interval string from the custom resource and setup the run interval.  invalid run intervals
+	// were logged by the validation and fall back to the DefaultRunInterval.
+	c.RunInterval = parseCheckRunInterval(kc)
 
-		log.Debugln("RunInterval for check:", c.CheckName, "set to", c.RunInterval)
+	log.Debugln("RunInterval for check:", c.CheckName, "set to", c.RunInterval)
+	c.Schedule = kc.Spec.Schedule
+	c.ScheduleTimezone = kc.Spec.ScheduleTimezone
+	if len(c.Schedule) > 0 {
+		log.Debugln("Schedule for check:", c.CheckName, "set to", c.Schedule, c.ScheduleTimezone)
+	}
 
-		// parse the user specified timeout if present
-		c.RunTimeout = DefaultTimeout
-		if len(kc.Spec.Timeout) > 0 {
-			c.RunTimeout, err = time.ParseDuration(kc.Spec.Timeout)
-			if err != nil {
-				log.Errorln("Error parsing timeout for check", c.CheckName, "in namespace", c.Namespace, err)
-				log.Errorln("Defaulting check to a timeout of", DefaultTimeout)
-			}
+	// parse the user specified timeout if present
+	c.RunTimeout = DefaultTimeout
+	if len(kc.Spec.Timeout) > 0 {
+		timeout, err := time.ParseDuration(kc.Spec.Timeout)
+		if err != nil || timeout <= 0 {
+			log.Errorln("Error parsing timeout for check", c.CheckName, "in namespace", c.Namespace, err)
+			log.Errorln("Defaulting check to a timeout of", DefaultTimeout)
+		} else {
+			c.RunTimeout = timeout
 		}
+	}
 
-		log.Debugln("RunTimeout for check:", c.CheckName, "set to", c.RunTimeout)
+	log.Debugln("RunTimeout for check:", c.CheckName, "set to", c.RunTimeout)
 
-		// add on extra annotations and labels
-		if c.ExtraAnnotations != nil {
-			log.Debugln("External check setting extra annotations:", c.ExtraAnnotations)
-			c.ExtraAnnotations = kc.Spec.ExtraAnnotations
-		}
-		if c.ExtraLabels != nil {
-			log.Debugln("External check setting extra labels:", c.ExtraLabels)
-			c.ExtraLabels = kc.Spec.ExtraLabels
-		}
-		log.Debugln("External check labels and annotations:", c.ExtraLabels, c.ExtraAnnotations)
+	// add on extra annotations and labels. check specific values take precedence over the global checker pod
+	// annotations and labels
+	if c.ExtraAnnotations != nil {
+		log.Debugln("External check setting extra annotations:", c.ExtraAnnotations)
+		c.ExtraAnnotations = mergeStringMaps(cfg.CheckPodAnnotations, kc.Spec.ExtraAnnotations)
+	}
+	if c.ExtraLabels != nil {
+		log.Debugln("External check setting extra labels:", c.ExtraLabels)
+		c.ExtraLabels = mergeStringMaps(cfg.CheckPodLabels, kc.Spec.ExtraLabels)
+	}
+	log.Debugln("External check labels and annotations:", c.ExtraLabels, c.ExtraAnnotations)
+	c.MaxFailureLogBytes = cfg.MaxFailureLogBytes
+	c.DefaultResources = cfg.checkPodResources
+	c.ImagePullSecrets = cfg.imagePullSecrets()
+	c.ConcurrencyPolicy = checkConcurrencyPolicy(kc)
+	c.FailureThreshold, c.RetryBackoff = checkFailureSettings(kc)
 
-		// add the check into the checker
-		k.AddCheck(c)
+	// when checks are sharded, the khstate records which kuberhealthy pod started each run
+	if shardChecks {
+		c.RunnerPod = podHostname
 	}
 
-	return nil
+	// add the check into the checker
+	k.AddCheck(c)
 }
 
 // addExternalJobs syncs up the state of the all jobs installed in this Kuberhealthy struct.
@@ -717,6 +781,7 @@ func (k *Kuberhealthy) configureJob(job khjobv1.KuberhealthyJob) *external.Check
 	// create a new kubernetes client for this external checker
 	log.Infoln("Enabling external job:", job.Name)
 	kj := external.NewJob(kubernetesClient, &job, khJobClient, khStateClient, cfg.ExternalCheckReportingURL)
+	kj.KuberhealthyReportingCA = cfg.reportingCA
 
 	var err error
 	// parse the user specified timeout if present
@@ -731,25 +796,29 @@ func (k *Kuberhealthy) configureJob(job khjobv1.KuberhealthyJob) *external.Check
 
 	log.Debugln("RunTimeout for job:", kj.CheckName, "set to", kj.RunTimeout)
 
-	// add on extra annotations and labels
+	// add on extra annotations and labels. job specific values take precedence over the global checker pod
+	// annotations and labels
 	if kj.ExtraAnnotations != nil {
 		log.Debugln("External job setting extra annotations:", kj.ExtraAnnotations)
-		kj.ExtraAnnotations = job.Spec.ExtraAnnotations
+		kj.ExtraAnnotations = mergeStringMaps(cfg.CheckPodAnnotations, job.Spec.ExtraAnnotations)
 	}
 	if kj.ExtraLabels != nil {
 		log.Debugln("External job setting extra labels:", kj.ExtraLabels)
-		kj.ExtraLabels = job.Spec.ExtraLabels
+		kj.ExtraLabels = mergeStringMaps(cfg.CheckPodLabels, job.Spec.ExtraLabels)
 	}
 	log.Debugln("External job labels and annotations:", kj.ExtraLabels, kj.ExtraAnnotations)
+	kj.MaxFailureLogBytes = cfg.MaxFailureLogBytes
+	kj.DefaultResources = cfg.checkPodResources
+	kj.ImagePullSecrets = cfg.imagePullSecrets()
 	return kj
 }
 
 // triggerKHJob checks if its master, sets the context, and runs the khjob in a goroutine
 func (k *Kuberhealthy) triggerKHJob(ctx context.Context, job khjobv1.KuberhealthyJob) {
 
-	log.Debugln("khjob trigger, isMaster:", isMaster)
+	log.Debugln("khjob trigger, isMaster:", isMaster.Load())
 	// only the master pod should be running khjobs or khjobs are duplicated
-	if isMaster {
+	if isMaster.Load() {
 		go k.runJob(ctx, job)
 	}
 }
@@ -768,6 +837,7 @@ func (k *Kuberhealthy) StartChecks(ctx context.Context) {
 	// create a context for checks to abort with
 	checkGroupCtx, cancelFunc := context.WithCancel(ctx)
 	k.cancelChecksFunc = cancelFunc
+	k.runSlots = newRunSlots(maxConcurrentChecks)
 
 	// start each check with this check group's context
 	for _, c := range k.Checks {
@@ -776,25 +846,55 @@ func (k *Kuberhealthy) StartChecks(ctx context.Context) {
 		go k.runCheck(checkGroupCtx, c)
 	}
 
-	// spin up the khState reaper with a context after checks have been configured and started
-	log.Infoln("control: reaper starting!")
-	go k.khStateResourceReaper(ctx, k.TargetNamespace)
+	// start the built-in certificate expiry check along with the khchecks
+	if certChecks {
+		k.wg.Add(1)
+		go k.runCertificateCheck(checkGroupCtx)
+	}
+	if apiServerProbe {
+		k.apiServerProber = &apiServerProber{client: kubernetesClient, namespace: podNamespace}
+		k.wg.Add(1)
+		go k.runAPIServerProbe(checkGroupCtx)
+	}
+	if apiServerLatencyCheck {
+		k.wg.Add(1)
+		go k.runAPIServerLatencyCheck(checkGroupCtx)
+	}
+	if nodeChecks {
+		k.wg.Add(1)
+		go k.runNodeCheck(checkGroupCtx)
+	}
+	if rolloutChecks {
+		k.wg.Add(1)
+		go k.runRolloutCheck(checkGroupCtx)
+	}
+
+	// spin up the khState reaper with a context after checks have been configured and started.  when checks are
+	// sharded, the khState reaper is run by the master along with the check reaper instead.
+	if !shardChecks {
+		log.Infoln("control: reaper starting!")
+		go k.khStateResourceReaper(ctx, k.TargetNamespaces)
+	}
 }
 
-// masterStatusWatcher watches for master change events and updates the global upcomingMasterState along
-// with the global lastMasterChangeTime
-func (k *Kuberhealthy) masterStatusWatcher(ctx context.Context) {
+// shardMemberWatcher watches for kuberhealthy pod changes and refreshes the pods that checks are sharded over
+func (k *Kuberhealthy) shardMemberWatcher(ctx context.Context) {
 
 	// continue reconnecting to the api to resume the pod watch if it ends
 	for {
-		log.Debugln("master status watcher starting up...")
+		log.Debugln("shard member watcher starting up...")
 
 		// don't retry our watch too fast
 		time.Sleep(time.Second * 5)
 
 		// setup a pod watching client for kuberhealthy pods
+		selector, err := shardPodLabelSelector(ctx, kubernetesClient)
+		if err != nil {
+			log.Errorln("sharding:", err)
+			continue
+		}
 		watcher, err := kubernetesClient.CoreV1().Pods(podNamespace).Watch(ctx, metav1.ListOptions{
-			LabelSelector: "app=kuberhealthy",
+			LabelSelector: selector,
 		})
 		if err != nil {
 			log.Errorln("error when attempting to watch for kuberhealthy pod changes:", err)
@@ -812,79 +912,27 @@ func (k *Kuberhealthy) masterStatusWatcher(ctx context.Context) {
 			case <-ctx.Done():
 				watcher.Stop()
 			}
-			log.Debugln("master status monitor watch stopping")
+			log.Debugln("shard member watch stopping")
 		}(watcherCtx, ctx, watcher)
 
-		// on each update from the watch, we re-check our master status.
+		// on each update from the watch, we refresh the pods that checks are sharded over
 		for range watcher.ResultChan() {
-
-			// update the time we last saw a master event
-			lastMasterChangeTime = time.Now()
-
-			// determine if we are becoming master or not
-			var err error
-			upcomingMasterState, err = masterCalculation.IAmMaster(kubernetesClient)
-			if err != nil {
-				log.Errorln(err)
-			}
-
-			// update the time we last saw a master event
-			log.Debugln("master status monitor saw a master event")
-			lastMasterChangeTime = time.Now()
+			refreshShardMembers(kubernetesClient)
 		}
 
 		// cancel the watcher by revoking its context
 		watcherCtxCancel()
 
-		// if the context has expired, then shut down the master status watcher entirely
+		// if the context has expired, then shut down the shard member watcher entirely
 		select {
 		case <-ctx.Done():
-			log.Debugln("master status monitor stopping due to context cancellation")
+			log.Debugln("shard member watcher stopping due to context cancellation")
 			return
 		default:
 		}
 	}
 }
 
-// masterMonitor periodically evaluates the current and upcoming master state
-// and makes it so when appropriate
-func (k *Kuberhealthy) masterMonitor(ctx context.Context, becameMasterChan chan struct{}, lostMasterChan chan struct{}) {
-
-	// watch master pod event changes and recalculate the current master state of this pdo with each
-	go k.masterStatusWatcher(ctx)
-
-	interval := time.Second * 10
-
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	// on each tick, we ensure that enough time has passed since the last master change
-	// event, then we calculate if we should become or lose master.
-	for range ticker.C {
-
-		if time.Since(lastMasterChangeTime) < interval {
-			log.Println("control: waiting for master changes to settle...")
-			continue
-		}
-
-		// dupe the global to prevent races
-		goingToBeMaster := upcomingMasterState
-
-		// stop checks if we are no longer the master
-		if goingToBeMaster && !isMaster {
-			becameMasterChan <- struct{}{}
-		}
-
-		// start checks if we are now master
-		if !goingToBeMaster && isMaster {
-			lostMasterChan <- struct{}{}
-		}
-
-		// refresh global isMaster state
-		isMaster = goingToBeMaster
-	}
-}
-
 // runJob runs the job and sets its status
 func (k *Kuberhealthy) runJob(ctx context.Context, job khjobv1.KuberhealthyJob) {
 
@@ -918,8 +966,9 @@ func (k *Kuberhealthy) runJob(ctx context.Context, job khjobv1.KuberhealthyJob)
 		if strings.Contains(err.Error(), "pod deleted expectedly") {
 			log.Infoln("Skipping this job due to expected pod removal before completion")
 		}
+		k.recordTimeoutEvent(j.Name(), j.CheckNamespace(), khstatev1.KHJob, err)
 		// set any job run errors in the CRD
-		err = k.setJobExecutionError(j.Name(), j.CheckNamespace(), err)
+		err = k.setJobExecutionError(j.Name(), j.CheckNamespace(), err, j.FailureLogs(), j.RunContainers())
 		if err != nil {
 			log.Errorln("Error setting job execution error:", err)
 		}
@@ -944,6 +993,12 @@ func (k *Kuberhealthy) runJob(ctx context.Context, job khjobv1.KuberhealthyJob)
 	details.OK, details.Errors = j.CurrentStatus()
 	details.RunDuration = jobRunDuration.String()
 	details.CurrentUUID = jobDetails.CurrentUUID
+	details.Containers = j.RunContainers()
+
+	// capture the checker pod logs when the run reported a failure
+	if !details.OK {
+		details.LogExcerpt = j.CaptureFailureLogs(ctx)
+	}
 
 	// Fetch node information from running check pod using kh run uuid
 	selector := "kuberhealthy-run-id=" + details.CurrentUUID
@@ -956,7 +1011,7 @@ func (k *Kuberhealthy) runJob(ctx context.Context, job khjobv1.KuberhealthyJob)
 	log.Debugln("node name:", details.Node, "nodeName", j.Node)
 
 	// send data to the metric forwarder if configured
-	if k.MetricForwarder != nil {
+	if k.forwardingMetrics() {
 		checkStatus := 0
 		if details.OK {
 			checkStatus = 1
@@ -977,12 +1032,11 @@ func (k *Kuberhealthy) runJob(ctx context.Context, job khjobv1.KuberhealthyJob)
 			{j.Name() + "." + j.CheckNamespace(): checkStatus},
 			{"RunDuration." + j.Name() + "." + j.CheckNamespace(): runDuration.Seconds()},
 		}
-		err = k.MetricForwarder.Push(metric, tags)
-		if err != nil {
-			log.Errorln("Error forwarding metrics", err)
-		}
+		k.forwardMetrics(metric, tags)
 	}
 
+	k.runDurations.Observe("job", j.CheckNamespace()+"/"+j.Name(), j.CheckNamespace(), jobRunDuration)
+
 	log.Infoln("Setting state of job", j.Name(), "in namespace", j.CheckNamespace(), "to", details.OK, details.Errors, details.RunDuration, details.CurrentUUID, details.GetKHWorkload())
 
 	// store the job state with the CRD
@@ -1001,10 +1055,25 @@ func (k *Kuberhealthy) runJob(ctx context.Context, job khjobv1.KuberhealthyJob)
 // runCheck runs a check on an interval and sets its status each run
 func (k *Kuberhealthy) runCheck(ctx context.Context, c *external.Checker) {
 
-	log.Println("Starting check:", c.CheckNamespace(), "/", c.Name())
+	c.Logger().Println("Starting check:", c.CheckNamespace(), "/", c.Name())
+
+	// run on the interval or cron schedule of the check.  each check has a schedule of its own, so that its timing
+	// can change without restarting it.
+	name := c.CheckNamespace() + "/" + c.Name()
+	timing := newCheckTiming(name, c.Interval(), c.Schedule, c.ScheduleTimezone)
+	schedule := k.checkSchedules.add(name, timing)
+	defer k.checkSchedules.remove(name, schedule)
 
-	// run on an interval specified by the package
-	ticker := time.NewTicker(c.Interval())
+	// checks on a cron schedule wait for their first scheduled time instead of running right away
+	if timing.cron != nil {
+		c.Logger().Infoln("Waiting for the first scheduled run of check", c.Name(), "in namespace", c.CheckNamespace(), "at", schedule.next())
+		if !schedule.wait(ctx) {
+			return
+		}
+	}
+
+	// the runs of this check that are in flight
+	var inFlight []*checkRun
 
 	// run the check forever and write its results to the kuberhealthy
 	// CRD resource for the check
@@ -1015,98 +1084,191 @@ func (k *Kuberhealthy) runCheck(ctx context.Context, c *external.Checker) {
 		case <-ctx.Done():
 			// we don't need to call a check shutdown here because the same func that cancels this context calls
 			// shutdown on all the checks configured in the kuberhealthy struct.
-			log.Infoln("Shutting down check run due to context cancellation:", c.Name(), "in namespace", c.CheckNamespace())
+			c.Logger().Infoln("Shutting down check run due to context cancellation:", c.Name(), "in namespace", c.CheckNamespace())
 			return
 		default:
 		}
 
-		// Run the check
-		log.Infoln("Running check:", c.Name())
-		// Record check run start time
-		checkStartTime := time.Now()
-		err := c.Run(ctx, kubernetesClient)
-		if err != nil {
-			log.Errorln("Error running check:", c.Name(), "in namespace", c.CheckNamespace()+":", err)
-			if strings.Contains(err.Error(), "pod deleted expectedly") {
-				log.Infoln("Skipping this run due to expected pod removal before completion")
-				<-ticker.C
-			}
-			// set any check run errors in the CRD
-			err = k.setCheckExecutionError(c.Name(), c.CheckNamespace(), err)
-			if err != nil {
-				log.Errorln("Error setting check execution error:", err)
+		// skip this run if the check is in a maintenance window that skips runs
+		if shouldSkipCheckRun(cfg.MaintenanceWindows, c.Name(), c.CheckNamespace(), time.Now()) {
+			k.auditSkippedRun(c, schedule.currentTrigger(), "the check is in a maintenance window")
+			if !schedule.wait(ctx) {
+				return
 			}
-			<-ticker.C
 			continue
 		}
-		log.Debugln("Done running check:", c.Name(), "in namespace", c.CheckNamespace())
 
-		// Record check run end time
-		// Subtract 10 seconds from run time since there are two 5 second sleeps during the check run where kuberhealthy
-		// waits for all pods to clear before running the check and waits for all pods to exit once the check has finished
-		// running. Both occur before and after the checker pod completes its run.
-		checkRunDuration := time.Since(checkStartTime) - time.Second*10
+		// skip this run while a check this check depends on fails, if its dependencyPolicy is Skip
+		if k.skipForFailingDependencies(c.Name(), c.CheckNamespace()) {
+			k.auditSkippedRun(c, schedule.currentTrigger(), "a check it depends on is failing")
+			if !schedule.wait(ctx) {
+				return
+			}
+			continue
+		}
 
-		// make a new state for this check and fill it from the check's current status
-		checkDetails, err := getCheckState(c)
-		if err != nil {
-			log.Errorln("Error setting check state after run:", c.Name(), "in namespace", c.CheckNamespace()+":", err)
+		// skip this run if checks are sharded and the check is run by another kuberhealthy pod
+		if shardChecks && !k.ownsCheckShard(ctx, c) {
+			if !schedule.wait(ctx) {
+				return
+			}
+			continue
 		}
-		details := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
-		details.Namespace = c.CheckNamespace()
-		details.OK, details.Errors = c.CurrentStatus()
-		details.RunDuration = checkRunDuration.String()
-		details.CurrentUUID = checkDetails.CurrentUUID
 
-		// Fetch node information from running check pod using kh run uuid
-		selector := "kuberhealthy-run-id=" + details.CurrentUUID
-		pod, err := k.fetchPodBySelector(ctx, selector)
-		if err != nil {
-			log.Errorln(err)
+		// start no more runs once Kuberhealthy is shutting down, so that the runs in flight can drain
+		if k.isShuttingDown() {
+			c.Logger().Infoln("Not starting run of check", c.Name(), "in namespace", c.CheckNamespace(), "because Kuberhealthy is shutting down")
+			return
 		}
-		details.Node = pod.Spec.NodeName
 
-		log.Debugln("node name:", details.Node, "nodeName", c.Node)
+		// start this run, handling runs that are still in flight according to the check's concurrency policy
+		inFlight = k.scheduleCheckRun(ctx, c, inFlight, schedule.currentTrigger())
 
-		// send data to the metric forwarder if configured
-		if k.MetricForwarder != nil {
-			checkStatus := 0
-			if details.OK {
-				checkStatus = 1
-			}
+		c.Logger().Infoln("Waiting for next run of check", c.Name(), "in namespace", c.CheckNamespace())
+		if !schedule.wait(ctx) { // wait for next run
+			c.Logger().Infoln("Shutting down check run due to context cancellation:", c.Name(), "in namespace", c.CheckNamespace())
+			return
+		}
+	}
+}
 
-			runDuration, err := time.ParseDuration(details.RunDuration)
-			if err != nil {
-				log.Errorln("Error parsing run duration", err)
-			}
+// runCheckOnce runs a check once and sets its status.  The run is recorded in the audit trail as started by the
+// trigger.
+func (k *Kuberhealthy) runCheckOnce(ctx context.Context, c *external.Checker, trigger runTrigger) {
 
-			tags := map[string]string{
-				"KuberhealthyPod": details.AuthoritativePod,
-				"Namespace":       c.CheckNamespace(),
-				"Name":            c.Name(),
-				"Errors":          strings.Join(details.Errors, ","),
-			}
-			metric := metrics.Metric{
-				{c.Name() + "." + c.CheckNamespace(): checkStatus},
-				{"RunDuration." + c.Name() + "." + c.CheckNamespace(): runDuration.Seconds()},
-			}
-			err = k.MetricForwarder.Push(metric, tags)
-			if err != nil {
-				log.Errorln("Error forwarding metrics", err)
-			}
+	// Run the check
+	c.Logger().Infoln("Running check:", c.Name())
+	// Record check run start time
+	checkStartTime := time.Now()
+	err := c.Run(ctx, kubernetesClient)
+
+	// a run that was stopped because Kuberhealthy is shutting down removes its checker pods, so that none are left
+	// behind for the next Kuberhealthy pod
+	if ctx.Err() != nil && k.isShuttingDown() {
+		k.abortCheckRun(c, checkStartTime, trigger)
+		return
+	}
+
+	// a run that was stopped, such as a run replaced by a newer run, leaves the check state to the newer run
+	if ctx.Err() != nil {
+		c.Logger().Infoln("Check run was stopped before completion:", c.Name(), "in namespace", c.CheckNamespace())
+		k.auditCheckRun(c, trigger, c.CurrentUUID(), checkStartTime, auditResultStopped, nil)
+		return
+	}
+
+	if err != nil {
+		c.Logger().Errorln("Error running check:", c.Name(), "in namespace", c.CheckNamespace()+":", err)
+		if strings.Contains(err.Error(), "pod deleted expectedly") {
+			c.Logger().Infoln("Skipping this run due to expected pod removal before completion")
+		}
+		k.recordTimeoutEvent(c.Name(), c.CheckNamespace(), khstatev1.KHCheck, err)
+		runErr := err
+		historyErr := k.recordRunResult(c.Name(), c.CheckNamespace(), c.CurrentUUID(), func(r *khstatev1.RunResult, _ bool) {
+			r.OK = false
+			r.Errors = []string{"Check execution error: " + runErr.Error()}
+			r.RunDuration = time.Since(checkStartTime).String()
+		})
+		if historyErr != nil {
+			c.Logger().Errorln("Error recording the run history of check", c.Name(), "in namespace", c.CheckNamespace()+":", historyErr)
 		}
+		// set any check run errors in the CRD
+		err = k.setCheckExecutionError(c.Name(), c.CheckNamespace(), err, c.FailureLogs(), c.RunContainers())
+		if err != nil {
+			c.Logger().Errorln("Error setting check execution error:", err)
+		}
+		k.auditCheckRun(c, trigger, c.CurrentUUID(), checkStartTime, auditResultError, []string{"Check execution error: " + runErr.Error()})
+		k.scheduleFailureRetry(c)
+		return
+	}
+	c.Logger().Debugln("Done running check:", c.Name(), "in namespace", c.CheckNamespace())
 
-		log.Infoln("Setting state of check", c.Name(), "in namespace", c.CheckNamespace(), "to", details.OK, details.Errors, details.RunDuration, details.CurrentUUID, details.GetKHWorkload())
+	// Record check run end time
+	// Subtract 10 seconds from run time since there are two 5 second sleeps during the check run where kuberhealthy
+	// waits for all pods to clear before running the check and waits for all pods to exit once the check has finished
+	// running. Both occur before and after the checker pod completes its run.
+	checkRunDuration := time.Since(checkStartTime) - time.Second*10
+
+	// make a new state for this check and fill it from the check's current status
+	checkDetails, err := getCheckState(c)
+	if err != nil {
+		c.Logger().Errorln("Error setting check state after run:", c.Name(), "in namespace", c.CheckNamespace()+":", err)
+	}
+	details := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	details.Namespace = c.CheckNamespace()
+	details.OK, details.Errors = c.CurrentStatus()
+	details.RunDuration = checkRunDuration.String()
+	details.CurrentUUID = checkDetails.CurrentUUID
+	details.ConsecutiveFailures = checkDetails.ConsecutiveFailures
+	details.LastFailedUUID = checkDetails.LastFailedUUID
+	details.Containers = c.RunContainers()
 
-		// store the check state with the CRD
-		err = k.storeCheckState(c.Name(), c.CheckNamespace(), details)
+	// capture the checker pod logs when the run reported a failure
+	if !details.OK {
+		details.LogExcerpt = c.CaptureFailureLogs(ctx)
+	}
+
+	// Fetch node information from running check pod using kh run uuid
+	selector := "kuberhealthy-run-id=" + details.CurrentUUID
+	pod, err := k.fetchPodBySelector(ctx, selector)
+	if err != nil {
+		c.Logger().Errorln(err)
+	}
+	details.Node = pod.Spec.NodeName
+
+	c.Logger().Debugln("node name:", details.Node, "nodeName", c.Node)
+
+	// send data to the metric forwarder if configured
+	if k.forwardingMetrics() {
+		checkStatus := 0
+		if details.OK {
+			checkStatus = 1
+		}
+
+		runDuration, err := time.ParseDuration(details.RunDuration)
 		if err != nil {
-			log.Errorln("Error storing CRD state for check:", c.Name(), "in namespace", c.CheckNamespace(), err)
+			c.Logger().Errorln("Error parsing run duration", err)
 		}
 
-		log.Infoln("Waiting for next run of check", c.Name(), "in namespace", c.CheckNamespace())
-		<-ticker.C // wait for next run
+		tags := map[string]string{
+			"KuberhealthyPod": details.AuthoritativePod,
+			"Namespace":       c.CheckNamespace(),
+			"Name":            c.Name(),
+			"Errors":          strings.Join(details.Errors, ","),
+		}
+		metric := metrics.Metric{
+			{c.Name() + "." + c.CheckNamespace(): checkStatus},
+			{"RunDuration." + c.Name() + "." + c.CheckNamespace(): runDuration.Seconds()},
+		}
+		k.forwardMetrics(metric, tags)
+	}
+
+	k.runDurations.Observe("check", c.CheckNamespace()+"/"+c.Name(), c.CheckNamespace(), checkRunDuration)
+
+	// the result of the run was recorded in the history when it was reported, so only its duration is added here
+	err = k.recordRunResult(c.Name(), c.CheckNamespace(), c.CurrentUUID(), func(r *khstatev1.RunResult, found bool) {
+		if !found {
+			r.OK = checkDetails.OK
+			r.Errors = checkDetails.Errors
+		}
+		r.RunDuration = checkRunDuration.String()
+	})
+	if err != nil {
+		c.Logger().Errorln("Error recording the run history of check", c.Name(), "in namespace", c.CheckNamespace()+":", err)
+	}
+
+	c.Logger().Infoln("Setting state of check", c.Name(), "in namespace", c.CheckNamespace(), "to", details.OK, details.Errors, details.RunDuration, details.CurrentUUID, details.GetKHWorkload())
+
+	// store the check state with the CRD
+	err = k.storeCheckState(c.Name(), c.CheckNamespace(), details)
+	if err != nil {
+		c.Logger().Errorln("Error storing CRD state for check:", c.Name(), "in namespace", c.CheckNamespace(), err)
 	}
+	result := auditResultPassed
+	if !details.OK {
+		result = auditResultFailed
+	}
+	k.auditCheckRun(c, trigger, c.CurrentUUID(), checkStartTime, result, details.Errors)
+	k.scheduleFailureRetry(c)
 }
 
 // storeCheckState stores the check state in its cluster CRD
@@ -1118,6 +1280,9 @@ func (k *Kuberhealthy) storeCheckState(checkName string, checkNamespace string,
 		return err
 	}
 
+	// remember the last known state so that we can record an event if it changes
+	previousOK := k.previousWorkloadOK(checkName, checkNamespace)
+
 	// put the status on the CRD from the check
 	err = setCheckStateResource(checkName, checkNamespace, details)
 
@@ -1149,21 +1314,64 @@ func (k *Kuberhealthy) storeCheckState(checkName string, checkNamespace string,
 		// count how many times we've retried
 		tries++
 	}
+	if err != nil {
+		return err
+	}
 
-	return err
+	// the status page is assembled again on the next request so that it shows the new state
+	k.statusCache.invalidate()
+
+	// the notifications that fire are recorded with the run in the audit trail
+	var notified []string
+	for _, n := range []struct {
+		name string
+		sent bool
+	}{
+		{"events", k.recordStateChangeEvent(checkName, checkNamespace, previousOK, details)},
+		{"cloudEvents", k.sendStateChangeCloudEvent(checkName, checkNamespace, previousOK, details)},
+		{"webhooks", k.sendStateChangeWebhooks(checkName, checkNamespace, previousOK, details)},
+		{"alertmanager", k.sendStateChangeAlert(checkName, checkNamespace, previousOK, details)},
+		{"slack", k.sendStateChangeSlack(checkName, checkNamespace, previousOK, details)},
+	} {
+		if n.sent {
+			notified = append(notified, n.name)
+		}
+	}
+	k.auditTrail.notified(details.CurrentUUID, notified)
+
+	// push in the background so that a slow Pushgateway does not hold up check reports
+	go k.pushWorkloadMetrics(checkName, checkNamespace, details)
+	return nil
 }
 
 // StartWebServer starts a JSON status web server at the specified listener.
 func (k *Kuberhealthy) StartWebServer() {
 	log.Infoln("Configuring web server")
-	http.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+
+	// Serve the liveness and readiness probes of Kuberhealthy itself.  They are not authenticated so that the kubelet
+	// can probe them, and they do not depend on the status of checks.
+	http.HandleFunc(healthzPath, func(w http.ResponseWriter, r *http.Request) {
+		err := k.healthzHandler(w, r)
+		if err != nil {
+			log.Errorln("healthz endpoint error:", err)
+		}
+	})
+	http.HandleFunc(readyzPath, func(w http.ResponseWriter, r *http.Request) {
+		err := k.readyzHandler(w, r)
+		if err != nil {
+			log.Errorln("readyz endpoint error:", err)
+		}
+	})
+
+	http.HandleFunc("/metrics", k.authenticated(func(w http.ResponseWriter, r *http.Request) {
 		err := k.prometheusMetricsHandler(w, r)
 		if err != nil {
 			log.Errorln(err)
 		}
-	})
+	}))
 
-	// Accept status reports coming from external checker pods
+	// Accept status reports coming from external checker pods.  Reports are authenticated by their run UUID instead
+	// of the web server authentication.
 	http.HandleFunc("/externalCheckStatus", func(w http.ResponseWriter, r *http.Request) {
 		err := k.externalCheckReportHandler(w, r)
 		if err != nil {
@@ -1171,30 +1379,171 @@ func (k *Kuberhealthy) StartWebServer() {
 		}
 	})
 
+	// Serve the details of single checks and jobs, including the logs captured when they failed
+	http.HandleFunc(checkDetailsPath, k.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		err := k.checkDetailsHandler(w, r)
+		if err != nil {
+			log.Errorln("check details endpoint error:", err)
+		}
+	}))
+
+	// Validate khcheck manifests without applying them
+	http.HandleFunc(validatePath, k.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		err := k.validateHandler(w, r)
+		if err != nil {
+			log.Errorln("validate endpoint error:", err)
+		}
+	}))
+
+	// Trigger runs of checks outside of their interval or schedule
+	http.HandleFunc(runPath, k.runAuthenticated(kubernetesClient, func(w http.ResponseWriter, r *http.Request) {
+		err := k.runHandler(w, r)
+		if err != nil {
+			log.Errorln("run endpoint error:", err)
+		}
+	}))
+
+	// Serve the stored run history of single checks with their uptime and trend
+	http.HandleFunc(runHistoryPath, k.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		err := k.runHistoryHandler(w, r)
+		if err != nil {
+			log.Errorln("run history endpoint error:", err)
+		}
+	}))
+
+	// Serve the aggregated status of the federated clusters
+	http.HandleFunc(federationPath, k.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		err := k.federationHandler(w, r)
+		if err != nil {
+			log.Errorln("federation endpoint error:", err)
+		}
+	}))
+
+	// Stream the state transitions of checks and jobs as server-sent events
+	http.HandleFunc(watchPath, k.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		err := k.watchHandler(w, r)
+		if err != nil {
+			log.Errorln("watch endpoint error:", err)
+		}
+	}))
+
+	// Serve the status of checks and jobs as an HTML dashboard
+	http.HandleFunc(dashboardPath, k.authenticated(func(w http.ResponseWriter, r *http.Request) {
+		err := k.dashboardHandler(w, r)
+		if err != nil {
+			log.Errorln("dashboard endpoint error:", err)
+		}
+	}))
+
 	// Assign all requests to be handled by the healthCheckHandler function
-	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	http.HandleFunc("/", k.authenticated(func(w http.ResponseWriter, r *http.Request) {
 		err := k.healthCheckHandler(w, r)
 		if err != nil {
 			log.Errorln(err)
 		}
-	})
+	}))
 
-	// start web server any time it exits
+	// start web server any time it exits or is restarted with new settings
 	for {
-		log.Infoln("Starting web services on port", k.ListenAddr)
-		err := http.ListenAndServe(k.ListenAddr, nil)
-		if err != nil {
+		k.webServerMu.Lock()
+		server := &http.Server{Addr: k.ListenAddr}
+		certFile, keyFile, reloadInterval := k.tlsCertFile, k.tlsKeyFile, k.tlsReloadInterval
+		k.webServer = server
+		k.webServerMu.Unlock()
+
+		var err error
+		if len(certFile) > 0 {
+			var certificate *certificateReloader
+			certificate, err = newCertificateReloader(certFile, keyFile, reloadInterval)
+			if err == nil {
+				server.TLSConfig = &tls.Config{GetCertificate: certificate.getCertificate, MinVersion: tls.VersionTLS12}
+				log.Infoln("Starting TLS web services on port", server.Addr)
+				err = server.ListenAndServeTLS("", "")
+			}
+		} else {
+			log.Infoln("Starting web services on port", server.Addr)
+			err = server.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Errorln("Web server ERROR:", err)
 		}
 		time.Sleep(time.Second / 2)
 	}
 }
 
+// RestartWebServer gracefully stops the running web server so that it is started again with the supplied listen
+// address and TLS settings.  The Kuberhealthy process keeps running.
+func (k *Kuberhealthy) RestartWebServer(listenAddr string, tlsCertFile string, tlsKeyFile string, tlsReloadInterval time.Duration) {
+	k.webServerMu.Lock()
+	k.ListenAddr = listenAddr
+	k.tlsCertFile = tlsCertFile
+	k.tlsKeyFile = tlsKeyFile
+	k.tlsReloadInterval = tlsReloadInterval
+	server := k.webServer
+	k.webServerMu.Unlock()
+
+	if server == nil {
+		return
+	}
+
+	log.Infoln("Restarting web server to listen on", listenAddr)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+	err := server.Shutdown(ctx)
+	if err != nil {
+		log.Errorln("Error shutting down web server for restart:", err)
+	}
+}
+
+// applyConfigChanges applies settings of a reloaded configuration that can change while Kuberhealthy runs.  The web
+// server is restarted when its listen address or TLS settings change and metric forwarding is reconfigured when the
+// InfluxDB settings change.  Changes to settings that require a restart of Kuberhealthy are logged.  Checks should
+// be stopped while the configuration is applied.
+func (k *Kuberhealthy) applyConfigChanges(newConfig *Config) {
+	previous := k.config
+	k.config = newConfig
+	if previous == nil {
+		return
+	}
+
+	k.webServerMu.Lock()
+	webServerChanged := newConfig.ListenAddress != k.ListenAddr || newConfig.TLSCertFile != k.tlsCertFile ||
+		newConfig.TLSKeyFile != k.tlsKeyFile || newConfig.TLSReloadInterval != k.tlsReloadInterval
+	k.webServerMu.Unlock()
+	if webServerChanged {
+		k.RestartWebServer(newConfig.ListenAddress, newConfig.TLSCertFile, newConfig.TLSKeyFile, newConfig.TLSReloadInterval)
+	}
+
+	influxChanged := previous.EnableInflux != newConfig.EnableInflux || previous.InfluxURL != newConfig.InfluxURL ||
+		previous.InfluxDB != newConfig.InfluxDB || previous.InfluxUsername != newConfig.InfluxUsername ||
+		previous.InfluxPassword != newConfig.InfluxPassword || previous.InfluxVersion != newConfig.InfluxVersion ||
+		previous.InfluxToken != newConfig.InfluxToken || previous.InfluxOrg != newConfig.InfluxOrg ||
+		previous.InfluxBucket != newConfig.InfluxBucket
+	if influxChanged {
+		k.MetricForwarder = nil
+		if newConfig.EnableInflux {
+			log.Infoln("Reconfiguring InfluxDB metric forwarding")
+			metricClient, err := configureInflux()
+			if err != nil {
+				log.Errorln("Error reconfiguring influx client. Metric forwarding is disabled:", err)
+			} else {
+				k.MetricForwarder = metricClient
+			}
+		}
+	}
+
+	for _, setting := range restartRequiredChanges(previous, newConfig) {
+		log.Warningln("Configuration setting", setting, "changed, but Kuberhealthy must be restarted for it to take effect")
+	}
+}
+
 // PodReportInfo holds info about an incoming IP to the external check reporting endpoint
 type PodReportInfo struct {
-	Name      string
-	UUID      string
-	Namespace string
+	Name        string
+	UUID        string
+	Namespace   string
+	reportToken string    // the KH_REPORT_TOKEN of the pod, read from its secret. blank for pods created before report tokens
+	deadline    time.Time // the KH_CHECK_RUN_DEADLINE of the pod, after which its report token expires
 }
 
 // validateExternalRequest calls the Kubernetes API to fetch details about a pod using a selector string.
@@ -1236,12 +1585,23 @@ func (k *Kuberhealthy) validateExternalRequest(ctx context.Context, selector str
 	// validate that the environment variables we expect are in place based on the check's name and UUID
 	// compared to what is in the khcheck custom resource
 	var foundUUID bool
+	var reportTokenRef *v1.SecretKeySelector
 	for _, e := range envVars {
-		log.Debugln("Checking environment variable on calling pod:", e.Name, e.Value)
-		if e.Name == external.KHRunUUID {
+		log.Debugln("Checking environment variable on calling pod:", e.Name)
+		switch e.Name {
+		case external.KHRunUUID:
 			log.Debugln("Found value on calling pod", selector, "value:", external.KHRunUUID, e.Value)
 			podUUID = e.Value
 			foundUUID = true
+		case external.KHReportToken:
+			if e.ValueFrom != nil {
+				reportTokenRef = e.ValueFrom.SecretKeyRef
+			}
+		case external.KHDeadline:
+			deadline, err := strconv.ParseInt(e.Value, 10, 64)
+			if err == nil {
+				reportInfo.deadline = time.Unix(deadline, 0)
+			}
 		}
 	}
 
@@ -1261,6 +1621,14 @@ func (k *Kuberhealthy) validateExternalRequest(ctx context.Context, selector str
 		return reportInfo, errors.New("pod uuid was invalid or unset")
 	}
 
+	// the report token is only accepted from the secret created for this run
+	if reportTokenRef != nil {
+		reportInfo.reportToken, err = fetchReportToken(ctx, kubernetesClient, podCheckNamespace, reportTokenRef, podUUID)
+		if err != nil {
+			return reportInfo, err
+		}
+	}
+
 	// create a report to send back to the function invoker
 	reportInfo.Name = podCheckName
 	reportInfo.Namespace = podCheckNamespace
@@ -1304,8 +1672,6 @@ func (k *Kuberhealthy) fetchPodBySelectorForDuration(ctx context.Context, select
 func (k *Kuberhealthy) fetchPodBySelector(ctx context.Context, selector string) (v1.Pod, error) {
 	var pod v1.Pod
 
-	podClient := kubernetesClient.CoreV1().Pods(k.TargetNamespace)
-
 	// Use either label selector or field selector depending on the selector string passed through
 	// LabelSelector: "kuberhealthy-run-id=" + uuid,
 	// FieldSelector: "status.podIP==" + remoteIP + ",status.phase==Running",
@@ -1322,140 +1688,165 @@ func (k *Kuberhealthy) fetchPodBySelector(ctx context.Context, selector string)
 		}
 	}
 
-	podList, err := podClient.List(ctx, listOptions)
+	// the pod is searched for in each namespace this instance operates on
+	var pods []v1.Pod
+	for _, namespace := range k.TargetNamespaces {
+		podList, err := kubernetesClient.CoreV1().Pods(namespace).List(ctx, listOptions)
+		if err != nil {
+			return pod, errors.New("failed to fetch pod with selector " + selector + " with error: " + err.Error())
+		}
+		pods = append(pods, podList.Items...)
+	}
+
+	// the status.podIP field only holds the primary IP of a pod, so a pod on a dual-stack cluster that reports from
+	// its secondary IP is looked for among the running pods by all of its IPs
+	fieldSelector, err := fields.ParseSelector(listOptions.FieldSelector)
 	if err != nil {
-		return pod, errors.New("failed to fetch pod with selector " + selector + " with error: " + err.Error())
+		return pod, errors.New("failed to parse field selector " + selector + " with error: " + err.Error())
+	}
+	if ip, ok := fieldSelector.RequiresExactMatch("status.podIP"); ok && len(pods) == 0 {
+		for _, namespace := range k.TargetNamespaces {
+			podList, err := kubernetesClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{FieldSelector: "status.phase==Running"})
+			if err != nil {
+				return pod, errors.New("failed to fetch running pods to find pod with IP " + ip + " with error: " + err.Error())
+			}
+			for _, p := range podList.Items {
+				if podHasIP(p, ip) {
+					pods = append(pods, p)
+				}
+			}
+		}
 	}
 
 	// ensure that we only got back one pod, because two means something awful has happened and 0 means we
 	// didnt find one
-	if len(podList.Items) == 0 {
+	if len(pods) == 0 {
 		return pod, errors.New("failed to find a pod with selector " + selector)
 	}
-	if len(podList.Items) > 1 {
+	if len(pods) > 1 {
 		return pod, errors.New("failed to fetch pod with selector " + selector + " - found two or more with same label")
 	}
 
 	// check if the pod has containers
-	if len(podList.Items[0].Spec.Containers) == 0 {
+	if len(pods[0].Spec.Containers) == 0 {
 		return pod, errors.New("failed to fetch environment variables from pod with selector" + selector + " - pod had no containers")
 	}
 
-	return podList.Items[0], nil
+	return pods[0], nil
 }
 
-func (k *Kuberhealthy) externalCheckReportHandlerLog(s ...interface{}) {
-	log.Infoln(s...)
-}
-
-// validatePodReportBySourceIP gets the header `kh-run-uuid` value from the request and forms a selector with it to
-// validate that the request is coming from a kuberhealthy check pod
-func (k *Kuberhealthy) validateUsingRequestHeader(ctx context.Context, r *http.Request) (PodReportInfo, bool, error) {
-
-	var podReport PodReportInfo
-	var err error
-	if len(r.Header.Get("kh-run-uuid")) == 0 {
-		return podReport, false, nil
+// podHasIP determines if any of the IPs of a pod is the supplied IP.  IPs are compared parsed, so that the differently
+// written forms of an IPv6 address match.
+func podHasIP(pod v1.Pod, ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
 	}
-	selector := "kuberhealthy-run-id=" + r.Header.Get("kh-run-uuid")
-	podReport, err = k.validateExternalRequest(ctx, selector)
-	if err != nil {
-		return podReport, false, err
+	podIPs := pod.Status.PodIPs
+	if len(podIPs) == 0 {
+		podIPs = []v1.PodIP{{IP: pod.Status.PodIP}}
+	}
+	for _, podIP := range podIPs {
+		if parsed.Equal(net.ParseIP(podIP.IP)) {
+			return true
+		}
 	}
-	return podReport, true, nil
+	return false
 }
 
-// validatePodReportBySourceIP parses the remoteAddr from the request and forms a selector with the remote IP to
-// validate that the request is coming from a kuberhealthy check pod
-func (k *Kuberhealthy) validatePodReportBySourceIP(ctx context.Context, r *http.Request) (PodReportInfo, error) {
+func (k *Kuberhealthy) externalCheckReportHandlerLog(s ...interface{}) {
+	log.Infoln(s...)
+}
 
-	var podReport PodReportInfo
-	ip, _, err := net.SplitHostPort(r.RemoteAddr)
-	if err != nil {
-		return podReport, err
-	}
-	selector := "status.podIP==" + ip + ",status.phase==Running"
-	podReport, err = k.validateExternalRequest(ctx, selector)
-	if err != nil {
-		return podReport, err
-	}
-	return podReport, nil
+// checkReport is a status report from an external checker pod, received over HTTP or gRPC
+type checkReport struct {
+	requestID   string        // identifies the request in logs
+	runUUID     string        // the kh-run-uuid of the reporting pod, if it was supplied
+	reportToken string        // the kh-report-token of the reporting pod, if it was supplied
+	remoteIP    string        // the IP of the reporting pod, used to look it up when the run uuid is missing or unknown
+	report      status.Report // the reported status
 }
 
-// externalCheckReportHandler handles requests coming from external checkers reporting their status.
-// This endpoint checks that the external check report is coming from the correct UUID or pod IP before recording
-// the reported status of the corresponding external check.  This endpoint expects a JSON payload of
-// the `State` struct found in the github.com/kuberhealthy/kuberhealthy/v2/pkg/health package.  The request
-// causes a check of the calling pod's spec via the API to ensure that the calling pod is expected
-// to be reporting its status.
-func (k *Kuberhealthy) externalCheckReportHandler(w http.ResponseWriter, r *http.Request) error {
-	// make a request ID for tracking this request
-	requestID := "web: " + uuid.New().String()
+// logger returns a logger for the report that sets the check, namespace and runUUID fields of the run of the
+// reporting pod and the requestID field of the report
+func (c checkReport) logger(pod PodReportInfo) *log.Entry {
+	return external.CheckLogger(pod.Name, pod.Namespace, pod.UUID).WithField("requestID", c.requestID)
+}
 
-	ctx := r.Context()
+// errCheckReportRejected is returned when the pod that sent a check report could not be validated as the checker pod
+// of a check run that is allowed to report in
+var errCheckReportRejected = errors.New("check report rejected")
 
-	k.externalCheckReportHandlerLog(requestID, "Client connected to check report handler from", r.UserAgent())
+// errCheckReportInvalid is returned when a check report is malformed
+var errCheckReportInvalid = errors.New("invalid check report")
 
-	// Validate request using the kh-run-uuid header. If the header doesn't exist, or there's an error with validation,
-	// validate using the pod's remote IP.
-	k.externalCheckReportHandlerLog(requestID, "validating external check status report from its reporting kuberhealthy run uuid:", r.Header.Get("kh-run-uuid"))
-	podReport, reportValidated, err := k.validateUsingRequestHeader(ctx, r)
-	if err != nil {
-		k.externalCheckReportHandlerLog(requestID, "Failed to look up pod by its kh-run-uuid header:", r.Header.Get("kh-run-uuid"), err)
-	}
+// validateCheckReportPod validates that a check report comes from the checker pod of a check run that is allowed to
+// report in.  The pod is looked up by the run uuid of the report, or by its IP when the run uuid is missing or unknown.
+func (k *Kuberhealthy) validateCheckReportPod(ctx context.Context, c checkReport) (PodReportInfo, error) {
 
-	// If the check uuid header is missing, attempt to validate using calling pod's source IP
-	if !reportValidated {
-		k.externalCheckReportHandlerLog(requestID, "validating external check status report from the pod's remote IP:", r.RemoteAddr)
-		podReport, err = k.validatePodReportBySourceIP(ctx, r)
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			k.externalCheckReportHandlerLog(requestID, "Failed to look up pod by its IP:", r.RemoteAddr, err)
-			return nil
+	// Validate request using the kh-run-uuid. If it doesn't exist, or there's an error with validation, validate
+	// using the pod's remote IP.
+	k.externalCheckReportHandlerLog(c.requestID, "validating external check status report from its reporting kuberhealthy run uuid:", c.runUUID)
+	if len(c.runUUID) > 0 {
+		podReport, err := k.validateExternalRequest(ctx, "kuberhealthy-run-id="+c.runUUID)
+		if err == nil {
+			return podReport, k.validateCheckReportToken(c, podReport, time.Now())
 		}
+		k.externalCheckReportHandlerLog(c.requestID, "Failed to look up pod by its kh-run-uuid header:", c.runUUID, err)
 	}
-	k.externalCheckReportHandlerLog(requestID, "Calling pod is", podReport.Name, "in namespace", podReport.Namespace)
-
-	// append pod info to request id for easy check tracing in logs
-	requestID = requestID + " (" + podReport.Namespace + "/" + podReport.Name + ")"
 
-	// ensure the client is sending a valid payload in the request body
-	b, err := io.ReadAll(r.Body)
+	// If the check uuid is missing, attempt to validate using calling pod's source IP
+	k.externalCheckReportHandlerLog(c.requestID, "validating external check status report from the pod's remote IP:", c.remoteIP)
+	podReport, err := k.validateExternalRequest(ctx, "status.podIP=="+c.remoteIP+",status.phase==Running")
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		k.externalCheckReportHandlerLog(requestID, "Failed to read request body:", err.Error(), r.RemoteAddr)
-		return nil
+		k.externalCheckReportHandlerLog(c.requestID, "Failed to look up pod by its IP:", c.remoteIP, err)
+		return podReport, fmt.Errorf("%w: %s", errCheckReportRejected, err)
 	}
-	log.Debugln("Check report body:", string(b))
+	return podReport, k.validateCheckReportToken(c, podReport, time.Now())
+}
 
-	// decode the bytes into a status struct as used by the client
-	state := status.Report{}
-	err = json.Unmarshal(b, &state)
+// handleCheckReport validates a check report and records the reported status of the corresponding external check.
+// It is shared by the HTTP and gRPC report transports so that reports are handled the same way by both.  Reports
+// from pods that can not be validated return errCheckReportRejected and malformed reports return
+// errCheckReportInvalid.  Repeated reports of the same run store the same state again.
+func (k *Kuberhealthy) handleCheckReport(ctx context.Context, c checkReport) error {
+	podReport, err := k.validateCheckReportPod(ctx, c)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		k.externalCheckReportHandlerLog(requestID, "Failed to unmarshal state json:", err, r.RemoteAddr)
-		return nil
+		return err
 	}
-	log.Debugf("Check report after unmarshal: +%v\n", state)
+
+	// log the report with the check and run of the calling pod for easy check tracing in logs
+	reportLog := c.logger(podReport)
+	reportLog.Infoln("Calling pod is", podReport.Name, "in namespace", podReport.Namespace)
+	state := c.report
+	reportLog.Debugf("Check report: +%v\n", state)
 
 	// ensure that if ok is set to false, then an error is provided
 	if !state.OK {
 		if len(state.Errors) == 0 {
-			w.WriteHeader(http.StatusBadRequest)
-			k.externalCheckReportHandlerLog(requestID, "Client attempted to report OK false without any error strings")
-			return nil
+			reportLog.Infoln("Client attempted to report OK false without any error strings")
+			return fmt.Errorf("%w: OK false was reported without any error strings", errCheckReportInvalid)
 		}
 		for _, e := range state.Errors {
 			if len(e) == 0 {
-				w.WriteHeader(http.StatusBadRequest)
-				k.externalCheckReportHandlerLog(requestID, "Client attempted to report a blank error string")
-				return nil
+				reportLog.Infoln("Client attempted to report a blank error string")
+				return fmt.Errorf("%w: a blank error string was reported", errCheckReportInvalid)
 			}
 		}
 	}
+	err = state.ValidateDetails()
+	if err != nil {
+		reportLog.Infoln("Client attempted to report invalid details:", err)
+		return fmt.Errorf("%w: %s", errCheckReportInvalid, err)
+	}
+	err = state.ValidateSeverity()
+	if err != nil {
+		reportLog.Infoln("Client attempted to report an invalid severity:", err)
+		return fmt.Errorf("%w: %s", errCheckReportInvalid, err)
+	}
 
 	checkRunDuration := time.Duration(0).String()
-	khWorkload := determineKHWorkload(podReport.Name, podReport.Namespace)
+	khWorkload := k.stateReflector.Workload(podReport.Name, podReport.Namespace)
 
 	switch khWorkload {
 	case khstatev1.KHCheck:
@@ -1470,22 +1861,90 @@ func (k *Kuberhealthy) externalCheckReportHandler(w http.ResponseWriter, r *http
 	details := khstatev1.NewWorkloadDetails(khWorkload)
 	details.Errors = state.Errors
 	details.OK = state.OK
+	details.Details = state.Details
 	details.RunDuration = checkRunDuration
 	details.Namespace = podReport.Namespace
 	details.CurrentUUID = podReport.UUID
+	if khWorkload == khstatev1.KHCheck {
+		// the history keeps the reported result, even when the failure threshold holds back a failure
+		err = k.recordRunResult(podReport.Name, podReport.Namespace, podReport.UUID, func(r *khstatev1.RunResult, _ bool) {
+			r.OK = state.OK
+			r.Errors = state.Errors
+		})
+		if err != nil {
+			reportLog.Infoln("failed to record the reported result in the run history of", podReport.Name+":", err)
+		}
+		k.applyCheckFailureThreshold(&details, podReport.Name, podReport.Namespace)
+	}
+	// successful and held back runs have no severity
+	if !details.OK {
+		details.Severity = state.Severity
+	}
 
 	// since the check is validated, we can proceed to update the status now
-	k.externalCheckReportHandlerLog(requestID, "Setting check with name", podReport.Name, "in namespace", podReport.Namespace, "to 'OK' state:", details.OK, "uuid", details.CurrentUUID, details.GetKHWorkload())
+	reportLog.Infoln("Setting check with name", podReport.Name, "in namespace", podReport.Namespace, "to 'OK' state:", details.OK, "uuid", details.CurrentUUID, details.GetKHWorkload())
 	err = k.storeCheckState(podReport.Name, podReport.Namespace, details)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		k.externalCheckReportHandlerLog(requestID, "failed to store check state for %s: %w", podReport.Name, err)
+		reportLog.Infoln("failed to store check state for", podReport.Name+":", err)
 		return fmt.Errorf("failed to store check state for %s: %w", podReport.Name, err)
 	}
 
+	reportLog.Infoln("Request completed successfully.")
+	return nil
+}
+
+// externalCheckReportHandler handles requests coming from external checkers reporting their status.
+// This endpoint checks that the external check report is coming from the correct UUID or pod IP before recording
+// the reported status of the corresponding external check.  This endpoint expects a JSON payload of
+// the `Report` struct found in the github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status package.  The
+// request causes a check of the calling pod's spec via the API to ensure that the calling pod is expected
+// to be reporting its status.
+func (k *Kuberhealthy) externalCheckReportHandler(w http.ResponseWriter, r *http.Request) error {
+	// make a request ID for tracking this request
+	requestID := "web: " + uuid.New().String()
+
+	k.externalCheckReportHandlerLog(requestID, "Client connected to check report handler from", r.UserAgent())
+
+	// ensure the client is sending a valid payload in the request body
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		k.externalCheckReportHandlerLog(requestID, "Failed to read request body:", err.Error(), r.RemoteAddr)
+		return nil
+	}
+	log.Debugln("Check report body:", string(b))
+
+	// decode the bytes into a status struct as used by the client
+	state := status.Report{}
+	err = json.Unmarshal(b, &state)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		k.externalCheckReportHandlerLog(requestID, "Failed to unmarshal state json:", err, r.RemoteAddr)
+		return nil
+	}
+
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	err = k.handleCheckReport(r.Context(), checkReport{
+		requestID:   requestID,
+		runUUID:     r.Header.Get("kh-run-uuid"),
+		reportToken: r.Header.Get("kh-report-token"),
+		remoteIP:    ip,
+		report:      state,
+	})
+	switch {
+	case errors.Is(err, errCheckReportRejected), errors.Is(err, errCheckReportInvalid):
+		w.WriteHeader(http.StatusBadRequest)
+		return nil
+	case err != nil:
+		w.WriteHeader(http.StatusInternalServerError)
+		return err
+	}
+
 	// write ok back to caller
 	w.WriteHeader(http.StatusOK)
-	k.externalCheckReportHandlerLog(requestID, "Request completed successfully.")
 	return nil
 }
 
@@ -1505,9 +1964,9 @@ func (k *Kuberhealthy) writeHealthCheckError(w http.ResponseWriter, r *http.Requ
 
 func (k *Kuberhealthy) prometheusMetricsHandler(w http.ResponseWriter, r *http.Request) error {
 	log.Infoln("Client connected to prometheus metrics endpoint from", r.RemoteAddr, r.UserAgent())
-	state := k.getCurrentState([]string{})
+	state := k.getCurrentState(statusFilter{})
 
-	m := metrics.GenerateMetrics(state, cfg.PromMetricsConfig)
+	m := metrics.GenerateMetrics(state, cfg.PromMetricsConfig) + k.runDurations.Metrics() + k.cloudEventsMetrics() + k.webhookMetrics() + k.alertmanagerMetrics() + k.slackMetrics() + apiServerLatencyMetrics() + kubeAPIThrottleMetrics() + k.federationMetrics() + k.watchMetrics()
 	// write summarized health check results back to caller
 	_, err := w.Write([]byte(m))
 	if err != nil {
@@ -1517,7 +1976,8 @@ func (k *Kuberhealthy) prometheusMetricsHandler(w http.ResponseWriter, r *http.R
 }
 
 // healthCheckHandler returns the current status of checks loaded into Kuberhealthy
-// as JSON to the client. Respects namespace requests via URL query parameters (i.e. /?namespace=default)
+// as JSON to the client. Respects namespace and check name requests via URL query parameters
+// (i.e. /?namespace=default&check=dns-status)
 func (k *Kuberhealthy) healthCheckHandler(w http.ResponseWriter, r *http.Request) error {
 	log.Infoln("Client connected to status page from", r.RemoteAddr, r.UserAgent())
 
@@ -1531,50 +1991,129 @@ func (k *Kuberhealthy) healthCheckHandler(w http.ResponseWriter, r *http.Request
 
 	// get URL query parameters if there are any
 	values := r.URL.Query()
-	namespaceValue := values.Get("namespace")
-	// .Get() will return an "" if there is no value associated -- we do not want to pass "" as a requested namespace
-	var namespaces []string
-	if len(namespaceValue) != 0 {
-		namespaceSplits := strings.Split(namespaceValue, ",")
-		// a query like (/?namespace=,) will cause .Split() to return an array of two empty strings ["", ""]
-		// so we need to filter those out
-		for _, namespaceSplit := range namespaceSplits {
-			if len(namespaceSplit) != 0 {
-				namespaces = append(namespaces, namespaceSplit)
-			}
+	filter := parseStatusFilter(values)
+
+	// large status pages can be requested a page of check and job details at a time (i.e. /?limit=100&continue=token)
+	page, err := parseStatusPage(values)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+
+	// fetch the current status from our khstate resources, or from the status cache if it was recently assembled
+	b, etag, err := k.statusCache.get(filter.cacheKey()+page.key(), func() ([]byte, error) {
+		state, err := paginateState(k.getCurrentState(filter), page)
+		if err != nil {
+			return nil, err
 		}
+		return json.MarshalIndent(state, "", "  ")
+	})
+	if err != nil {
+		k.writeHealthCheckError(w, r, fmt.Errorf("failed to marshal status page: %w", err), health.NewState())
+		return err
 	}
 
-	// fetch the current status from our khstate resources
-	state := k.getCurrentState(namespaces)
+	// clients that already have the current status page get no body
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
 
 	// write summarized health check results back to caller
-	err = state.WriteHTTPStatusResponse(w)
+	_, err = w.Write(b)
 	if err != nil {
 		log.Warningln("Error writing health check results to caller:", err)
 	}
 	return err
 }
 
-// getCurrentState fetches the current state of all checks from requested namespaces
-// their CRD objects and returns the summary as a health.State. Without a requested namespace,
-// this will return the state of ALL found checks.
-// Failures to fetch CRD state return an error.
-func (k *Kuberhealthy) getCurrentState(namespaces []string) health.State {
+// checkDetailsHandler returns the current details of a single check or job as JSON to the client.  The check is
+// requested with its namespace and name in the URL path (i.e. /api/v1/checks/kuberhealthy/daemonset)
+func (k *Kuberhealthy) checkDetailsHandler(w http.ResponseWriter, r *http.Request) error {
+	log.Infoln("Client connected to check details endpoint from", r.RemoteAddr, r.UserAgent())
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return nil
+	}
+
+	namespace, name, ok := parseCheckDetailsPath(r.URL.Path)
+	if !ok {
+		http.Error(w, "expected a check as "+checkDetailsPath+"{namespace}/{name}", http.StatusNotFound)
+		return nil
+	}
+
+	details, exists := k.stateReflector.WorkloadDetails(name, namespace)
+	if !exists || len(details.AuthoritativePod) == 0 {
+		http.Error(w, "check "+namespace+"/"+name+" not found", http.StatusNotFound)
+		return nil
+	}
 
-	currentMaster, err := masterCalculation.CalculateMaster(kubernetesClient)
+	b, err := json.MarshalIndent(details, "", "  ")
 	if err != nil {
-		log.Errorln("Failed to calculate master:", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return fmt.Errorf("failed to marshal details of check %s/%s: %w", namespace, name, err)
 	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(b)
+	return err
+}
+
+// parseCheckDetailsPath parses the namespace and name of a check from a check details URL path
+func parseCheckDetailsPath(path string) (string, string, bool) {
+	return parseNamespacedPath(checkDetailsPath, path)
+}
+
+// parseNamespacedPath parses the namespace and name of a check from a URL path below prefix
+func parseNamespacedPath(prefix string, path string) (string, string, bool) {
+	parts := strings.Split(strings.TrimPrefix(path, prefix), "/")
+	if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// withoutRunDetails removes the captured checker pod logs, container statuses and run history from the details of all
+// checks and jobs in the state.  They are only served by the check details endpoint to keep the status page small.
+func withoutRunDetails(state health.State) health.State {
+	for name, details := range state.CheckDetails {
+		details.LogExcerpt = ""
+		details.Containers = nil
+		details.History = nil
+		state.CheckDetails[name] = details
+	}
+	for name, details := range state.JobDetails {
+		details.LogExcerpt = ""
+		details.Containers = nil
+		details.History = nil
+		state.JobDetails[name] = details
+	}
+	return state
+}
+
+// getCurrentState fetches the current state of all checks included by the filter from
+// their CRD objects and returns the summary as a health.State. Without a filter,
+// this will return the state of ALL found checks.
+// Failures to fetch CRD state return an error.
+func (k *Kuberhealthy) getCurrentState(filter statusFilter) health.State {
 
 	var currentState health.State
-	if len(namespaces) != 0 {
-		currentState = k.getCurrentStatusForNamespaces(namespaces)
+	if !filter.empty() {
+		currentState = k.getFilteredStatus(filter)
 	} else {
 		currentState = k.stateReflector.CurrentStatus()
 	}
 
-	currentState.CurrentMaster = currentMaster
+	currentState = withoutRunDetails(currentState)
+	currentState.CurrentMaster = getCurrentLeader()
+	currentState = applyStartupGracePeriod(currentState, checkGracePeriod, time.Now())
+	currentState = applyMaintenanceWindows(currentState, cfg.MaintenanceWindows, time.Now())
+	currentState = applyPausedChecks(currentState)
+	currentState = applyPendingChecks(currentState)
+	currentState = applyCheckDependencies(currentState, currentCheckDependencies(), k.reflectorLookup)
+	currentState = applyWarningSeverity(currentState)
+	currentState = applyMaxChecks(currentState, getActiveCheckCount(), filter)
 	if len(cfg.StateMetadata) != 0 {
 		currentState.Metadata = cfg.StateMetadata
 	}
@@ -1582,33 +2121,31 @@ func (k *Kuberhealthy) getCurrentState(namespaces []string) health.State {
 	return currentState
 }
 
-// getCurrentState fetches the current state of all checks from the requested namespaces
-// their CRD objects and returns the summary as a health.State.
-// Failures to fetch CRD state return an error.
-func (k *Kuberhealthy) getCurrentStatusForNamespaces(namespaces []string) health.State {
-	// if there is are requested namespaces, then filter out checks from namespaces not matching those requested
+// getFilteredStatus fetches the current state of the checks and jobs included by the filter
+// from their CRD objects and returns the summary as a health.State.  The OK state and errors
+// only reflect the included checks and jobs.
+func (k *Kuberhealthy) getFilteredStatus(filter statusFilter) health.State {
+	// filter out checks and jobs that are not included by the filter
 	states := k.stateReflector.CurrentStatus()
-	statesForNamespaces := states
-	statesForNamespaces.Errors = []string{}
-	statesForNamespaces.OK = true
-	statesForNamespaces.CheckDetails = make(map[string]khstatev1.WorkloadDetails)
-	statesForNamespaces.JobDetails = make(map[string]khstatev1.WorkloadDetails)
-	if len(namespaces) != 0 {
-		statesForNamespaces = validateCurrentStatusForNamespaces(states.CheckDetails, namespaces, statesForNamespaces, khstatev1.KHCheck)
-		statesForNamespaces = validateCurrentStatusForNamespaces(states.JobDetails, namespaces, statesForNamespaces, khstatev1.KHJob)
-	}
-
-	log.Infoln("khState reflector returning current status on", len(statesForNamespaces.CheckDetails), "check khStates and", len(statesForNamespaces.JobDetails), "job khStates")
-	return statesForNamespaces
+	filtered := states
+	filtered.Errors = []string{}
+	filtered.OK = true
+	filtered.CheckDetails = make(map[string]khstatev1.WorkloadDetails)
+	filtered.JobDetails = make(map[string]khstatev1.WorkloadDetails)
+	filtered = validateCurrentStatusForFilter(states.CheckDetails, filter, filtered, khstatev1.KHCheck)
+	filtered = validateCurrentStatusForFilter(states.JobDetails, filter, filtered, khstatev1.KHJob)
+
+	log.Infoln("khState reflector returning current status on", len(filtered.CheckDetails), "check khStates and", len(filtered.JobDetails), "job khStates")
+	return filtered
 }
 
-// validateCurrentStatusForNamespaces ranges through all CheckDetails or JobDetails to store in a new health state for namespaces
-func validateCurrentStatusForNamespaces(details map[string]khstatev1.WorkloadDetails, namespaces []string, statesForNamespaces health.State, workload khstatev1.KHWorkload) health.State {
+// validateCurrentStatusForFilter ranges through all CheckDetails or JobDetails to store the ones included by the filter in a new health state
+func validateCurrentStatusForFilter(details map[string]khstatev1.WorkloadDetails, filter statusFilter, statesForFilter health.State, workload khstatev1.KHWorkload) health.State {
 
 	for checkName, checkState := range details {
-		// check if the namespace matches anything requested
-		if !containsString(checkState.Namespace, namespaces) {
-			log.Debugln("Skipping", checkName, "because it is not from the", namespaces, "namespace(s)")
+		// check if the namespace and name match anything requested
+		if !filter.matches(checkName, checkState.Namespace) {
+			log.Debugln("Skipping", checkName, "because it is not included by the", filter.namespaces, "namespace(s) and", filter.names, "check(s)")
 			continue
 		}
 
@@ -1625,21 +2162,21 @@ func validateCurrentStatusForNamespaces(details map[string]khstatev1.WorkloadDet
 				log.Warningln("Skipped an error that was blank when adding check details to current state.")
 				continue
 			}
-			statesForNamespaces.AddError(e)
+			statesForFilter.AddError(e)
 			log.Debugln("Status page: Setting global OK state to false due to check details not being OK")
-			statesForNamespaces.OK = false
+			statesForFilter.OK = false
 		}
 
 		// update details struct
 		switch workload {
 		case khstatev1.KHCheck:
-			statesForNamespaces.CheckDetails[checkName] = checkState
+			statesForFilter.CheckDetails[checkName] = checkState
 		case khstatev1.KHJob:
-			statesForNamespaces.JobDetails[checkName] = checkState
+			statesForFilter.JobDetails[checkName] = checkState
 		}
 	}
 
-	return statesForNamespaces
+	return statesForFilter
 }
 
 // getCheck returns a Kuberhealthy check object from its name, returns an error otherwise
@@ -1681,7 +2218,8 @@ func (k *Kuberhealthy) configureChecks(ctx context.Context) {
 }
 
 // isUUIDWhitelistedForCheck determines if the supplied uuid is whitelisted for the
-// check with the supplied name.  Only one UUID can be whitelisted at a time.
+// check with the supplied name.  Only one UUID is whitelisted at a time, except for overlapping runs of checks with a
+// concurrencyPolicy of Allow.
 // Operations are not atomic.  Whitelisting prevents expired or invalidated pods from
 // reporting into the status endpoint when they shouldn't be.
 func (k *Kuberhealthy) isUUIDWhitelistedForCheck(checkName string, checkNamespace string, uuid string) (bool, error) {
@@ -1696,6 +2234,12 @@ func (k *Kuberhealthy) isUUIDWhitelistedForCheck(checkName string, checkNamespac
 	if checkState.Spec.CurrentUUID == uuid {
 		return true, nil
 	}
+
+	// overlapping runs of checks with a concurrencyPolicy of Allow may report in as well
+	if containsString(uuid, checkState.Spec.AllowedUUIDs) {
+		log.Debugln("Incoming UUID", uuid, "is allowed as an overlapping run")
+		return true, nil
+	}
 	return false, nil
 }
 
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// leaseName is the name of the coordination.k8s.io Lease in the Kuberhealthy namespace that Kuberhealthy pods hold
+// to become master
+var leaseName = "kuberhealthy"
+
+// leaseDuration is how long other pods wait after the master last renewed the lease before taking it over
+var leaseDuration = time.Second * 15
+
+// leaseRenewDeadline is how long the master keeps trying to renew the lease before it gives up master
+var leaseRenewDeadline = time.Second * 10
+
+// leaseRetryPeriod is how often pods try to acquire or renew the lease
+var leaseRetryPeriod = time.Second * 2
+
+// currentLeader holds the identity of the pod that holds the master lease, as last observed by this pod
+var currentLeader string
+var currentLeaderMu sync.RWMutex
+
+// setCurrentLeader records the pod that holds the master lease
+func setCurrentLeader(identity string) {
+	currentLeaderMu.Lock()
+	defer currentLeaderMu.Unlock()
+	currentLeader = identity
+}
+
+// getCurrentLeader returns the pod that holds the master lease.  A blank string is returned until the lease has
+// been observed.
+func getCurrentLeader() string {
+	currentLeaderMu.RLock()
+	defer currentLeaderMu.RUnlock()
+	return currentLeader
+}
+
+// validateLeaderElectionFlags ensures the lease timings can be used for leader election.  The master must be able
+// to retry renewing the lease before the renew deadline, and must give up master before other pods take the lease.
+func validateLeaderElectionFlags() error {
+	if len(leaseName) == 0 {
+		return errors.New("leaseName must not be blank")
+	}
+	if leaseRetryPeriod <= 0 {
+		return errors.New("leaseRetryPeriod must be greater than zero")
+	}
+	if float64(leaseRenewDeadline) <= leaderelection.JitterFactor*float64(leaseRetryPeriod) {
+		return errors.New("leaseRenewDeadline must be more than 1.2 times the leaseRetryPeriod")
+	}
+	if leaseDuration <= leaseRenewDeadline {
+		return errors.New("leaseDuration must be greater than leaseRenewDeadline")
+	}
+	return nil
+}
+
+// newLeaderElectionConfig configures leader election over the master lease for this pod.  onStartedLeading and
+// onStoppedLeading are called when this pod gains and loses the lease.
+func newLeaderElectionConfig(client kubernetes.Interface, onStartedLeading func(), onStoppedLeading func()) leaderelection.LeaderElectionConfig {
+	// the callbacks run on the goroutines of the elector, so they use the namespace and identity captured here
+	namespace, identity := podNamespace, podHostname
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      leaseName,
+			Namespace: namespace,
+		},
+		Client: client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: identity,
+		},
+	}
+	return leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		Name:            leaseName,
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   leaseRenewDeadline,
+		RetryPeriod:     leaseRetryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				log.Infoln("leaderelection: acquired lease", namespace+"/"+leaseName)
+				onStartedLeading()
+			},
+			OnStoppedLeading: func() {
+				log.Infoln("leaderelection: lost lease", namespace+"/"+leaseName)
+				onStoppedLeading()
+			},
+			OnNewLeader: func(leader string) {
+				log.Infoln("leaderelection: lease", namespace+"/"+leaseName, "is held by", leader)
+				setCurrentLeader(leader)
+			},
+		},
+	}
+}
+
+// masterMonitor runs leader election over the master lease until the context is canceled.  becameMasterChan and
+// lostMasterChan are notified when this pod gains and loses master, and the global isMaster state is kept current.
+// With enableForceMaster set, this pod is always master and no lease is held.
+func (k *Kuberhealthy) masterMonitor(ctx context.Context, becameMasterChan chan struct{}, lostMasterChan chan struct{}) {
+
+	// when checks are sharded, keep the pods that checks are sharded over current
+	if shardChecks {
+		go k.shardMemberWatcher(ctx)
+	}
+
+	if cfg.EnableForceMaster {
+		log.Infoln("leaderelection: forced master mode is enabled. Not using the lease.")
+		setCurrentLeader(podHostname)
+		isMaster.Store(true)
+		becameMasterChan <- struct{}{}
+		return
+	}
+
+	config := newLeaderElectionConfig(kubernetesClient, func() {
+		isMaster.Store(true)
+		becameMasterChan <- struct{}{}
+	}, func() {
+		if isMaster.CompareAndSwap(true, false) {
+			lostMasterChan <- struct{}{}
+		}
+	})
+
+	// leader election ends when this pod loses the lease, so we run it again to stand for master again
+	for {
+		elector, err := leaderelection.NewLeaderElector(config)
+		if err != nil {
+			log.Errorln("leaderelection: failed to configure leader election:", err)
+			return
+		}
+		elector.Run(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Debugln("leaderelection: stopping due to context cancellation")
+			return
+		case <-time.After(leaseRetryPeriod):
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/leaderelection"
+)
+
+// setLeaseFlags sets the lease flags for the duration of a test
+func setLeaseFlags(t *testing.T, duration time.Duration, renewDeadline time.Duration, retryPeriod time.Duration) {
+	previousDuration, previousRenewDeadline, previousRetryPeriod := leaseDuration, leaseRenewDeadline, leaseRetryPeriod
+	leaseDuration, leaseRenewDeadline, leaseRetryPeriod = duration, renewDeadline, retryPeriod
+	t.Cleanup(func() {
+		leaseDuration, leaseRenewDeadline, leaseRetryPeriod = previousDuration, previousRenewDeadline, previousRetryPeriod
+	})
+}
+
+// TestValidateLeaderElectionFlags ensures lease timings that would let two pods be master at once are refused
+func TestValidateLeaderElectionFlags(t *testing.T) {
+	tests := []struct {
+		duration      time.Duration
+		renewDeadline time.Duration
+		retryPeriod   time.Duration
+		valid         bool
+	}{
+		{time.Second * 15, time.Second * 10, time.Second * 2, true},
+		{time.Second * 10, time.Second * 10, time.Second * 2, false},
+		{time.Second * 15, time.Second * 2, time.Second * 2, false},
+		{time.Second * 15, time.Second * 10, 0, false},
+	}
+	for _, test := range tests {
+		setLeaseFlags(t, test.duration, test.renewDeadline, test.retryPeriod)
+		err := validateLeaderElectionFlags()
+		if (err == nil) != test.valid {
+			t.Fatal("expected lease duration", test.duration, "renew deadline", test.renewDeadline, "and retry period", test.retryPeriod, "to be valid:", test.valid, "but got", err)
+		}
+	}
+}
+
+// TestLeaderElection ensures a pod becomes master by acquiring the lease, that other pods see it as the current
+// leader, and that it releases the lease when it stops
+func TestLeaderElection(t *testing.T) {
+	previousHostname, previousNamespace := podHostname, podNamespace
+	defer func() { podHostname, podNamespace = previousHostname, previousNamespace }()
+	defer setCurrentLeader("")
+	podNamespace = "kuberhealthy"
+	setLeaseFlags(t, time.Second*2, time.Second, time.Millisecond*100)
+	client := fake.NewSimpleClientset()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	// the electors must be done before the deferred restores of the globals run
+	var electors sync.WaitGroup
+	defer electors.Wait()
+
+	started := make(chan struct{}, 1)
+	stopped := make(chan struct{}, 1)
+	podHostname = "kuberhealthy-a"
+	leader, err := leaderelection.NewLeaderElector(newLeaderElectionConfig(client, func() { started <- struct{}{} }, func() { stopped <- struct{}{} }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaderCtx, leaderCancel := context.WithCancel(ctx)
+	defer leaderCancel()
+	electors.Add(1)
+	go func() {
+		defer electors.Done()
+		leader.Run(leaderCtx)
+	}()
+
+	select {
+	case <-started:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting to acquire the lease")
+	}
+	lease, err := client.CoordinationV1().Leases("kuberhealthy").Get(ctx, leaseName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != "kuberhealthy-a" {
+		t.Fatal("expected kuberhealthy-a to hold the lease but got", lease.Spec.HolderIdentity)
+	}
+
+	// another pod does not become master while the lease is held
+	podHostname = "kuberhealthy-b"
+	follower, err := leaderelection.NewLeaderElector(newLeaderElectionConfig(client, func() { t.Error("expected kuberhealthy-b not to become master") }, func() {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	followerCtx, followerCancel := context.WithCancel(ctx)
+	defer followerCancel()
+	electors.Add(1)
+	go func() {
+		defer electors.Done()
+		follower.Run(followerCtx)
+	}()
+	time.Sleep(time.Millisecond * 500)
+	if getCurrentLeader() != "kuberhealthy-a" {
+		t.Fatal("expected kuberhealthy-a to be the current leader but got", getCurrentLeader())
+	}
+	followerCancel()
+
+	leaderCancel()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		t.Fatal("timed out waiting to release the lease")
+	}
+	lease, err = client.CoordinationV1().Leases("kuberhealthy").Get(ctx, leaseName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lease.Spec.HolderIdentity != nil && len(*lease.Spec.HolderIdentity) != 0 {
+		t.Fatal("expected the lease to be released but it is held by", *lease.Spec.HolderIdentity)
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// logFormat is the format Kuberhealthy writes its logs in, text or json
+var logFormat = "text"
+
+// validateLogFormatFlag ensures logFormat is a known log format
+func validateLogFormatFlag() error {
+	_, err := newLogFormatter(logFormat)
+	return err
+}
+
+// newLogFormatter returns the log formatter of a log format.  The json format writes each log line as a JSON object
+// with its fields, such as the check, namespace and runUUID of check runs, so that logs can be indexed and queried.
+func newLogFormatter(format string) (log.Formatter, error) {
+	switch format {
+	case "text", "":
+		return &log.TextFormatter{}, nil
+	case "json":
+		return &log.JSONFormatter{TimestampFormat: time.RFC3339Nano}, nil
+	}
+	return nil, fmt.Errorf("unknown log format %q. must be text or json", format)
+}
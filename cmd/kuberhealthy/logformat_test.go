@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+)
+
+func TestNewLogFormatter(t *testing.T) {
+	for _, format := range []string{"", "text", "json"} {
+		_, err := newLogFormatter(format)
+		if err != nil {
+			t.Fatal("expected log format", format, "to be valid but got", err)
+		}
+	}
+	_, err := newLogFormatter("logfmt")
+	if err == nil {
+		t.Fatal("expected an unknown log format to be invalid")
+	}
+
+	// check run log lines carry the check, namespace and runUUID as fields of their own
+	formatter, _ := newLogFormatter("json")
+	entry := external.CheckLogger("dns", "kuberhealthy", "1234")
+	entry.Message = "Running check"
+	b, err := formatter.Format(entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var line map[string]string
+	err = json.Unmarshal(b, &line)
+	if err != nil {
+		t.Fatal("expected a JSON log line but got", string(b), err)
+	}
+	if line["msg"] != "Running check" || line["check"] != "dns" || line["namespace"] != "kuberhealthy" || line["runUUID"] != "1234" {
+		t.Fatal("expected the check run fields to be set on the log line but got", string(b))
+	}
+
+	// blank fields are left out
+	if _, ok := external.CheckLogger("dns", "kuberhealthy", "").Data[external.LogFieldRunUUID]; ok {
+		t.Fatal("expected a blank runUUID to be left out")
+	}
+}
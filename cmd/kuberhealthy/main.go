@@ -8,6 +8,7 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -15,13 +16,12 @@ import (
 	log "github.com/sirupsen/logrus"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/tools/clientcmd"
 
 	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
 	khjobv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khjob/v1"
 	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
-	"github.com/kuberhealthy/kuberhealthy/v2/pkg/masterCalculation"
 )
 
 // status represents the current Kuberhealthy OK:Error state
@@ -29,12 +29,29 @@ var cfg *Config
 var configPath = "/etc/config/kuberhealthy.yaml"
 
 var podNamespace = os.Getenv("POD_NAMESPACE")
-var isMaster bool                  // indicates this instance is the master and should be running checks
-var upcomingMasterState bool       // the upcoming master state on next interval
-var lastMasterChangeTime time.Time // indicates the last time a master change was seen
+var isMaster atomic.Bool // indicates this instance is the master and should be running checks
 // Interval for how often check pods should get reaped. Default is 30s.
 var checkReaperRunInterval = os.Getenv("CHECK_REAPER_RUN_INTERVAL")
 
+// checkPodLabelsFlag and checkPodAnnotationsFlag hold comma separated key=value pairs from the command line that
+// are applied to all checker pods on top of the configuration file values
+var checkPodLabelsFlag string
+var checkPodAnnotationsFlag string
+
+// setting flags take precedence over the matching configuration file settings when given on the command line
+var logLevelFlag = "info"
+var listenAddressFlag = ":80"
+var tlsCertFileFlag string
+var tlsKeyFileFlag string
+var forceMasterFlag bool
+var enableInfluxFlag bool
+var influxURLFlag string
+var influxDBFlag string
+var influxUsernameFlag string
+
+// configMapFlag references a configmap as namespace/name that is loaded and watched instead of the config file
+var configMapFlag string
+
 var terminationGracePeriod = time.Minute * 5 // keep calibrated with kubernetes terminationGracePeriodSeconds
 
 // the hostname of this pod
@@ -49,6 +66,9 @@ const DefaultRunInterval = time.Minute * 10
 // DefaultTimeout is the default timeout for external checks
 var DefaultTimeout = time.Minute * 5
 
+// DefaultCheckHistoryLength is the number of recent run results kept in the khstate of each check by default
+const DefaultCheckHistoryLength = 10
+
 // KHCheckNameAnnotationKey is the key used in the annotation that holds the check's short name
 const KHCheckNameAnnotationKey = "comcast.github.io/check-name"
 
@@ -85,6 +105,9 @@ func main() {
 		log.Fatalln("Error setting up Kuberhealthy:", err)
 	}
 
+	// install or remove the example khchecks when requested
+	setUpExampleChecks()
+
 	// Create a new Kuberhealthy struct
 	kuberhealthy := NewKuberhealthy(cfg)
 
@@ -137,40 +160,53 @@ func listenForInterrupts(k *Kuberhealthy) {
 // initKubernetesClients creates the appropriate CRD clients and kubernetes client to be used in all cases. Issue #181
 func initKubernetesClients() error {
 
+	// all clients share a configuration that tracks the latency of their API server requests
+	restConfig, err := kubeClient.Config(cfg.kubeConfigFile)
+	if err != nil {
+		return err
+	}
+	restConfig.Wrap(apiServerLatency.wrap)
+
+	// all clients also share one rate limit, so that it bounds the requests Kuberhealthy makes as a whole
+	restConfig.QPS = float32(kubeAPIQPS)
+	restConfig.Burst = kubeAPIBurst
+	restConfig.RateLimiter = newKubeAPIRateLimiter(kubeAPIQPS, kubeAPIBurst, kubeAPIThrottle)
+
+	// the certificate expiry check checks the serving certificate of the same API server
+	apiServerAddress, err = apiServerTarget(restConfig.Host)
+	if err != nil {
+		log.Warningln("The certificate expiry check can not check the API server:", err)
+	}
+
 	// make a new kuberhealthy client
-	kc, err := kubeClient.Create(cfg.kubeConfigFile)
+	kc, err := kubernetes.NewForConfig(restConfig)
 	if err != nil {
 		return err
 	}
 	kubernetesClient = kc
 
 	// make a new crd check client
-	checkClient, err := khcheckv1.Client(cfg.kubeConfigFile)
+	checkClient, err := khcheckv1.NewForConfig(restConfig)
 	if err != nil {
 		return err
 	}
 	khCheckClient = checkClient
 
 	// make a new crd state client
-	stateClient, err := khstatev1.Client(cfg.kubeConfigFile)
+	stateClient, err := khstatev1.NewForConfig(restConfig)
 	if err != nil {
 		return err
 	}
 	khStateClient = stateClient
 
 	// make a new crd job client
-	jobClient, err := khjobv1.Client(cfg.kubeConfigFile)
+	jobClient, err := khjobv1.NewForConfig(restConfig)
 	if err != nil {
 		return err
 	}
 	khJobClient = jobClient
 
 	// make a dynamicClient for kubernetes unstructured checks
-	restConfig, err := clientcmd.BuildConfigFromFlags(kc.RESTClient().Get().URL().Host, configPath)
-	if err != nil {
-		log.Fatalln("Failed to build kubernetes configuration from configuration flags:", err)
-	}
-
 	dynamicClient, err = dynamic.NewForConfig(restConfig)
 	if err != nil {
 		log.Fatalln("Failed to create kubernetes dynamic client configuration")
@@ -180,17 +216,77 @@ func initKubernetesClients() error {
 }
 
 // setUpConfig loads and sets default Kuberhealthy configurations
-// Everytime kuberhealthy sees a configuration change, configurations should reload and reset
+// Everytime kuberhealthy sees a configuration change, configurations should reload and reset.  Flags can also be
+// set in the configuration file or configmap, and flags given on the command line take precedence over it.  If the
+// new configuration is invalid, the current configuration is kept.
 func setUpConfig() error {
-	cfg = &Config{
-		kubeConfigFile: filepath.Join(os.Getenv("HOME"), ".kube", "config"),
-		LogLevel:       "info",
+	// flags set by a configuration that fails to load are set back, so that the current configuration is kept
+	restoreFlags := snapshotConfigFlags()
+	err := loadAndValidateConfig()
+	if err != nil {
+		restoreFlags()
+		_ = parseFlagValues()
+		return err
 	}
+	return nil
+}
 
-	// attempt to load config file from disk
-	err := cfg.Load(configPath)
+// loadAndValidateConfig loads the configuration and the flags it sets, then validates them and sets the global
+// config struct
+func loadAndValidateConfig() error {
+	resetConfigFlags()
+	c := &Config{
+		kubeConfigFile:     filepath.Join(os.Getenv("HOME"), ".kube", "config"),
+		LogLevel:           logLevelFlag,
+		ListenAddress:      listenAddressFlag,
+		TLSCertFile:        tlsCertFileFlag,
+		TLSKeyFile:         tlsKeyFileFlag,
+		EnableForceMaster:  forceMasterFlag,
+		EnableInflux:       enableInfluxFlag,
+		InfluxURL:          influxURLFlag,
+		InfluxDB:           influxDBFlag,
+		InfluxUsername:     influxUsernameFlag,
+		TargetNamespace:    os.Getenv("TARGET_NAMESPACE"),
+		MaxFailureLogBytes: external.DefaultMaxFailureLogBytes,
+		CheckHistoryLength: DefaultCheckHistoryLength,
+	}
+
+	err := loadConfig(c)
 	if err != nil {
-		log.Println("WARNING: Failed to read configuration file from disk:", err)
+		return err
+	}
+
+	// flags set in the configuration file are applied, unless they were given on the command line
+	err = applyConfigFlags(c)
+	if err != nil {
+		return err
+	}
+	err = validateFlags()
+	if err != nil {
+		return err
+	}
+
+	// maintenance windows with invalid schedules are never activated, so we surface them as a config error
+	err = validateMaintenanceWindows(c.MaintenanceWindows)
+	if err != nil {
+		return err
+	}
+	err = validateCheckOverrides(c.CheckOverrides)
+	if err != nil {
+		return err
+	}
+	err = validateFederatedClusters(c.FederatedClusters)
+	if err != nil {
+		return err
+	}
+
+	err = c.validateTLS()
+	if err != nil {
+		return err
+	}
+	c.checkPodResources, err = c.CheckPodResources.parse()
+	if err != nil {
+		return err
 	}
 
 	// set env variables into config if specified. otherwise set external check URL to default
@@ -200,10 +296,184 @@ func setUpConfig() error {
 			return errors.New("env KH_EXTERNAL_REPORTING_URL not set and POD_NAMESPACE environment variable was blank")
 		}
 		log.Infoln("KH_EXTERNAL_REPORTING_URL environment variable not set, using default value")
-		externalCheckURL = "http://kuberhealthy." + podNamespace + ".svc.cluster.local/externalCheckStatus"
+		scheme := "http"
+		if len(c.TLSCertFile) > 0 {
+			scheme = "https"
+		}
+		externalCheckURL = scheme + "://kuberhealthy." + podNamespace + ".svc.cluster.local/externalCheckStatus"
+	}
+	c.ExternalCheckReportingURL = externalCheckURL
+	log.Infoln("External check reporting URL set to:", c.ExternalCheckReportingURL)
+
+	// re-apply checker pod labels and annotations from flags so they survive configuration reloads
+	err = applyCheckPodMetadataFlags(c)
+	if err != nil {
+		return err
+	}
+
+	cfg = c
+	return nil
+}
+
+// loadConfig loads the configuration file from disk into the supplied config.  When a configmap is configured, the
+// configmap is loaded from the API instead.  A configuration file that can not be read is not an error, so the
+// defaults are used.  A configmap that can not be fetched is an error, so a transient API error does not reset the
+// configuration to defaults.
+func loadConfig(c *Config) error {
+	if len(configMapFlag) == 0 {
+		err := c.Load(configPath)
+		if err != nil {
+			log.Println("WARNING: Failed to read configuration file from disk:", err)
+		}
+		return nil
+	}
+
+	// the configmap is loaded again once the kubernetes clients are created during setup
+	if kubernetesClient == nil {
+		log.Debugln("Deferring load of configmap", configMapFlag, "until the kubernetes client is ready")
+		return nil
+	}
+
+	namespace, name, err := parseConfigMapFlag(configMapFlag)
+	if err != nil {
+		return err
+	}
+	err = c.LoadConfigMap(context.Background(), kubernetesClient, namespace, name)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
 	}
-	cfg.ExternalCheckReportingURL = externalCheckURL
-	log.Infoln("External check reporting URL set to:", cfg.ExternalCheckReportingURL)
+	return nil
+}
+
+// parseFlagValues parses the flags that hold values in a format of their own
+func parseFlagValues() error {
+	err := parseStartupGracePeriod()
+	if err != nil {
+		return fmt.Errorf("unable to parse startupGracePeriod flag: %s", err)
+	}
+	err = parseCloudEventsHeaders()
+	if err != nil {
+		return fmt.Errorf("unable to parse cloudEventsHeaders flag: %s", err)
+	}
+	err = parseWebhookHeaders()
+	if err != nil {
+		return fmt.Errorf("unable to parse webhookHeaders flag: %s", err)
+	}
+	err = parseAlertmanagerFlags()
+	if err != nil {
+		return err
+	}
+	err = parseStatsDTags()
+	if err != nil {
+		return err
+	}
+	err = parseOTLPHeaders()
+	if err != nil {
+		return err
+	}
+	err = parseCloudWatchDimensions()
+	if err != nil {
+		return err
+	}
+	return parsePushgatewayFlags()
+}
+
+// validateFlags parses and validates the flags, whether they were set on the command line or in the configuration
+// file
+func validateFlags() error {
+	err := parseFlagValues()
+	if err != nil {
+		return err
+	}
+	_, err = grpcServerTLSConfig()
+	if err != nil {
+		return fmt.Errorf("invalid gRPC TLS flags: %s", err)
+	}
+	err = validateGRPCStatusAPIFlags()
+	if err != nil {
+		return fmt.Errorf("invalid gRPC status API flags: %s", err)
+	}
+	err = validateAdmissionFlags()
+	if err != nil {
+		return fmt.Errorf("invalid admission webhook flags: %s", err)
+	}
+	err = validateCertCheckFlags()
+	if err != nil {
+		return fmt.Errorf("invalid certificate check flags: %s", err)
+	}
+	err = validateNodeCheckFlags()
+	if err != nil {
+		return fmt.Errorf("invalid node check flags: %s", err)
+	}
+	err = validateRolloutCheckFlags()
+	if err != nil {
+		return fmt.Errorf("invalid rollout check flags: %s", err)
+	}
+	err = validateExampleCheckFlags()
+	if err != nil {
+		return fmt.Errorf("invalid example check flags: %s", err)
+	}
+	err = validateFederationFlags()
+	if err != nil {
+		return fmt.Errorf("invalid federation flags: %s", err)
+	}
+	err = validateAPIServerLatencyFlags()
+	if err != nil {
+		return fmt.Errorf("invalid API server latency check flags: %s", err)
+	}
+	err = validateLeaderElectionFlags()
+	if err != nil {
+		return fmt.Errorf("invalid leader election flags: %s", err)
+	}
+	err = validateWebAuthFlags()
+	if err != nil {
+		return fmt.Errorf("invalid auth flags: %s", err)
+	}
+	err = validateShutdownFlags()
+	if err != nil {
+		return fmt.Errorf("invalid shutdown flags: %s", err)
+	}
+	err = validateOrphanGCFlags()
+	if err != nil {
+		return fmt.Errorf("invalid orphan garbage collector flags: %s", err)
+	}
+	err = validateMaxConcurrentChecksFlag()
+	if err != nil {
+		return fmt.Errorf("invalid maxConcurrentChecks flag: %s", err)
+	}
+	err = validateKubeAPIRateLimitFlags()
+	if err != nil {
+		return fmt.Errorf("invalid kubernetes API rate limit flags: %s", err)
+	}
+	err = validateLogFormatFlag()
+	if err != nil {
+		return fmt.Errorf("invalid logFormat flag: %s", err)
+	}
+	err = validateRunStoreFlags()
+	if err != nil {
+		return fmt.Errorf("invalid run store flags: %s", err)
+	}
+	err = validateProxyFlags()
+	if err != nil {
+		return fmt.Errorf("invalid proxy flags: %s", err)
+	}
+	return nil
+}
+
+// applyCheckPodMetadataFlags parses the checker pod label and annotation flags and merges them into the
+// supplied configuration.  Flag values take precedence over values from the configuration file.
+func applyCheckPodMetadataFlags(c *Config) error {
+	labels, err := parseKeyValueFlag(checkPodLabelsFlag)
+	if err != nil {
+		return fmt.Errorf("unable to parse checkPodLabels flag: %w", err)
+	}
+	c.CheckPodLabels = mergeStringMaps(c.CheckPodLabels, labels)
+
+	annotations, err := parseKeyValueFlag(checkPodAnnotationsFlag)
+	if err != nil {
+		return fmt.Errorf("unable to parse checkPodAnnotations flag: %w", err)
+	}
+	c.CheckPodAnnotations = mergeStringMaps(c.CheckPodAnnotations, annotations)
 	return nil
 }
 
@@ -212,18 +482,166 @@ func setUp() error {
 
 	var useDebugMode bool
 
+	// setup flaggy
+	flaggy.SetDescription("Kuberhealthy is an in-cluster synthetic health checker for Kubernetes.")
+	flaggy.String(&configPath, "c", "config", "Absolute path to the kuberhealthy config file. Any other flag can also be set in the file by its name.")
+	flaggy.String(&configMapFlag, "", "configMap", "A configmap as namespace/name to load and watch for configuration instead of the config file.")
+	flaggy.Bool(&useDebugMode, "d", "debug", "Set to true to enable debug.")
+	flaggy.Bool(&forceMasterFlag, "", "forceMaster", "Set to force master responsibilities on.")
+	flaggy.String(&logLevelFlag, "", "logLevel", "The log level to use. Takes precedence over logLevel in the configuration.")
+	flaggy.String(&logFormat, "", "logFormat", "The format of the logs, text or json.")
+	flaggy.Bool(&auditLog, "", "auditLog", "Set to write an audit record of every check run to stdout as a JSON line.")
+	flaggy.String(&auditLogFile, "", "auditLogFile", "A file to append an audit record of every check run to as a JSON line.")
+	flaggy.String(&runStoreBackend, "", "runStore", "The backend to store the results of check runs with, memory or bolt. Results are only kept in khstates when blank.")
+	flaggy.String(&runStorePath, "", "runStorePath", "The file the bolt run store keeps results in.")
+	flaggy.Duration(&runStoreRetention, "", "runStoreRetention", "How long the results of check runs are kept in the run store.")
+	flaggy.String(&httpProxy, "", "httpProxy", "The proxy outbound integrations reach http endpoints through. Falls back to HTTP_PROXY.")
+	flaggy.String(&httpsProxy, "", "httpsProxy", "The proxy outbound integrations reach https endpoints through. Falls back to HTTPS_PROXY.")
+	flaggy.String(&noProxy, "", "noProxy", "Comma separated hosts, domains and CIDRs outbound integrations reach without the proxy. Falls back to NO_PROXY.")
+	flaggy.String(&listenAddressFlag, "", "listenAddress", "The address to serve web requests on. Takes precedence over listenAddress in the configuration.")
+	flaggy.String(&tlsCertFileFlag, "", "tlsCertFile", "A TLS certificate file to serve web requests with. Takes precedence over tlsCertFile in the configuration.")
+	flaggy.String(&tlsKeyFileFlag, "", "tlsKeyFile", "The key file of the TLS certificate. Takes precedence over tlsKeyFile in the configuration.")
+	flaggy.Bool(&enableInfluxFlag, "", "enableInflux", "Set to forward metrics to InfluxDB. Takes precedence over enableInflux in the configuration.")
+	flaggy.String(&influxURLFlag, "", "influxURL", "The InfluxDB URL. Takes precedence over influxURL in the configuration.")
+	flaggy.String(&influxDBFlag, "", "influxDB", "The InfluxDB database. Takes precedence over influxDB in the configuration.")
+	flaggy.String(&influxUsernameFlag, "", "influxUsername", "The InfluxDB username. Takes precedence over influxUsername in the configuration.")
+	flaggy.String(&checkPodLabelsFlag, "", "checkPodLabels", "Comma separated key=value labels applied to all checker pods.")
+	flaggy.String(&checkPodAnnotationsFlag, "", "checkPodAnnotations", "Comma separated key=value annotations applied to all checker pods.")
+	flaggy.Bool(&emitEvents, "", "emitEvents", "Set to false to disable recording Kubernetes events when checks fail or recover.")
+	flaggy.Bool(&shardChecks, "", "shardChecks", "Set to spread checks across all running kuberhealthy pods instead of running them all on the master.")
+	flaggy.String(&shardPodSelector, "", "shardPodSelector", "The label selector of the kuberhealthy pods checks are sharded over. Defaults to the labels of this pod.")
+	flaggy.String(&startupGracePeriodFlag, "", "startupGracePeriod", "How long after startup checks that have not completed a run are reported as stale. Defaults to the run interval of each check.")
+	flaggy.Bool(&omitStaleChecks, "", "omitStaleChecks", "Set to omit checks in their startup grace period from the status page instead of reporting them as stale.")
+	flaggy.String(&cloudEventsURL, "", "cloudEventsURL", "The URL of a sink to send CloudEvents to when checks fail or recover.")
+	flaggy.String(&cloudEventsHeadersFlag, "", "cloudEventsHeaders", "Comma separated key=value headers sent with every CloudEvent.")
+	flaggy.Bool(&cloudEventsOnEveryRun, "", "cloudEventsOnEveryRun", "Set to send a CloudEvent for every check run instead of only when checks fail or recover.")
+	flaggy.String(&clusterName, "", "clusterName", "The name of the cluster used as the source of CloudEvents.")
+	flaggy.Duration(&statusCacheTTL, "", "statusCacheTTL", "How long the status page is served from cache. Set to 0 to disable the cache.")
+	flaggy.Int(&maxChecks, "", "maxChecks", "The most khchecks that are run. Khchecks beyond the limit are rejected. Set to 0 to run all khchecks.")
+	flaggy.Bool(&certChecks, "", "certChecks", "Set to enable the built-in certificate expiry check.")
+	flaggy.Bool(&certCheckAPIServer, "", "certCheckAPIServer", "Set to false to leave the serving certificate of the API server out of the certificate expiry check.")
+	flaggy.StringSlice(&certCheckTargets, "", "certCheckTargets", "A host:port TLS endpoint whose certificate is checked by the certificate expiry check. Can be repeated.")
+	flaggy.StringSlice(&certCheckSecrets, "", "certCheckSecrets", "A namespace/name TLS secret whose tls.crt is checked by the certificate expiry check. Can be repeated.")
+	flaggy.Duration(&certWarningPeriod, "", "certWarningPeriod", "How long before a certificate expires the certificate expiry check fails.")
+	flaggy.Duration(&certCheckTimeout, "", "certCheckTimeout", "How long the certificate expiry check waits to connect to each target.")
+	flaggy.Bool(&nodeChecks, "", "nodeChecks", "Set to enable the built-in check that fails when nodes are NotReady, under pressure or unschedulable.")
+	flaggy.Duration(&nodeCheckGracePeriod, "", "nodeCheckGracePeriod", "How long a node can be NotReady, under pressure or unschedulable before the node check fails.")
+	flaggy.StringSlice(&nodeCheckExcludedNodes, "", "nodeCheckExcludedNodes", "The name of a node the node check skips. Can be repeated.")
+	flaggy.String(&nodeCheckExcludeSelector, "", "nodeCheckExcludeSelector", "A label selector of nodes the node check skips.")
+	flaggy.Bool(&rolloutChecks, "", "rolloutChecks", "Set to enable the built-in check that rolls out a deployment and requests its service from a client pod.")
+	flaggy.String(&rolloutCheckImage, "", "rolloutCheckImage", "The image of the deployment of the rollout check. It must serve HTTP on port 8080.")
+	flaggy.String(&rolloutCheckClientImage, "", "rolloutCheckClientImage", "The image of the client pod of the rollout check. It needs sh and wget.")
+	flaggy.Int(&rolloutCheckReplicas, "", "rolloutCheckReplicas", "The number of replicas of the deployment of the rollout check.")
+	flaggy.Duration(&rolloutCheckTimeout, "", "rolloutCheckTimeout", "How long each step of the rollout check can take.")
+	flaggy.String(&lintKHCheckPath, "", "lintKHCheck", "A khcheck manifest to validate instead of starting Kuberhealthy. Exits non-zero when errors are found.")
+	flaggy.Bool(&lintSkipImageCheck, "", "lintSkipImageCheck", "Set to skip checking that the images of the linted khchecks can be pulled.")
+	flaggy.String(&grpcListenAddress, "", "grpcListenAddress", "The address to serve check reports over gRPC on. The gRPC server is off when blank.")
+	flaggy.String(&grpcTLSCertFile, "", "grpcTLSCertFile", "A TLS certificate file for the gRPC server.")
+	flaggy.String(&grpcTLSKeyFile, "", "grpcTLSKeyFile", "A TLS key file for the gRPC server.")
+	flaggy.String(&grpcTLSClientCAFile, "", "grpcTLSClientCAFile", "A CA bundle that gRPC clients must present a certificate signed by (mTLS).")
+	flaggy.Bool(&grpcStatusAPI, "", "grpcStatusAPI", "Set to serve the status of checks and check control over gRPC next to check reports.")
+	flaggy.String(&admissionListenAddress, "", "admissionListenAddress", "The address to serve the khcheck validating admission webhook on over TLS. The webhook is off when blank.")
+	flaggy.String(&admissionTLSCertFile, "", "admissionTLSCertFile", "A TLS certificate file for the admission webhook.")
+	flaggy.String(&admissionTLSKeyFile, "", "admissionTLSKeyFile", "A TLS key file for the admission webhook.")
+	flaggy.Bool(&admissionDenyUnsafe, "", "admissionDenyUnsafe", "Set to reject khchecks with privileged checker pods or pods that share the namespaces or filesystem of their node.")
+	flaggy.Bool(&installExampleChecksFlag, "", "installExampleChecks", "Set to create a passing and a failing example khcheck in the Kuberhealthy namespace at startup.")
+	flaggy.Bool(&removeExampleChecksFlag, "", "removeExampleChecks", "Set to delete the example khchecks from the Kuberhealthy namespace at startup.")
+	flaggy.String(&examplePassingCheckImage, "", "examplePassingCheckImage", "The image of the passing example khcheck.")
+	flaggy.String(&exampleFailingCheckImage, "", "exampleFailingCheckImage", "The image of the failing example khcheck.")
+	flaggy.Bool(&apiServerLatencyCheck, "", "apiServerLatencyCheck", "Set to enable the built-in check that fails when the API server requests of Kuberhealthy are slow or failing.")
+	flaggy.Duration(&apiServerLatencyThreshold, "", "apiServerLatencyThreshold", "The p99 latency of API server requests of any verb above which the API server latency check fails.")
+	flaggy.Float64(&apiServerErrorRateThreshold, "", "apiServerErrorRateThreshold", "The percentage of failed API server requests above which the API server latency check fails.")
+	flaggy.Duration(&apiServerLatencyWindow, "", "apiServerLatencyWindow", "How far back the API server request statistics reach.")
+	flaggy.Bool(&apiServerProbe, "", "apiServerProbe", "Set to probe the API server with lightweight get, list, create and delete requests so that its latency is measured even when Kuberhealthy is idle.")
+	flaggy.Duration(&apiServerProbeInterval, "", "apiServerProbeInterval", "How often the API server is probed.")
+	flaggy.StringSlice(&webhookURLs, "", "webhookURL", "A URL to post a JSON payload to when checks fail or recover. Can be repeated.")
+	flaggy.String(&webhookHeadersFlag, "", "webhookHeaders", "Comma separated key=value headers sent with every webhook payload.")
+	flaggy.String(&webhookSecret, "", "webhookSecret", "A secret that webhook payloads are signed with in the X-Kuberhealthy-Signature-256 header.")
+	flaggy.String(&leaseName, "", "leaseName", "The name of the Lease in the Kuberhealthy namespace that Kuberhealthy pods hold to become master.")
+	flaggy.Duration(&leaseDuration, "", "leaseDuration", "How long other Kuberhealthy pods wait after the master last renewed its lease before taking it over.")
+	flaggy.Duration(&leaseRenewDeadline, "", "leaseRenewDeadline", "How long the master keeps trying to renew its lease before it gives up master.")
+	flaggy.Duration(&leaseRetryPeriod, "", "leaseRetryPeriod", "How often Kuberhealthy pods try to acquire or renew the master lease.")
+	flaggy.String(&alertmanagerURL, "", "alertmanagerURL", "The base URL of an Alertmanager to push alerts to when checks fail or recover.")
+	flaggy.String(&alertmanagerHeadersFlag, "", "alertmanagerHeaders", "Comma separated key=value headers sent with every request to Alertmanager.")
+	flaggy.Bool(&allowReportsWithoutToken, "", "allowReportsWithoutToken", "Set to accept check reports that do not present the report token of their run, for checks built with an older checkclient.")
+	flaggy.String(&authBasicUsersFile, "", "authBasicUsersFile", "An htpasswd file of users with bcrypt passwords that can read the web server with basic auth.")
+	flaggy.Bool(&authTokenReview, "", "authTokenReview", "Set to allow reading the web server with bearer tokens that the Kubernetes API authenticates with a TokenReview.")
+	flaggy.StringSlice(&authTokenAudiences, "", "authTokenAudiences", "An audience bearer tokens validated with a TokenReview must be issued for. Can be repeated.")
+	flaggy.String(&authOIDCIssuerURL, "", "authOIDCIssuerURL", "The URL of an OIDC issuer whose ID tokens can read the web server as bearer tokens.")
+	flaggy.String(&authOIDCClientID, "", "authOIDCClientID", "The client ID OIDC ID tokens must be issued for.")
+	flaggy.String(&authOIDCUsernameClaim, "", "authOIDCUsernameClaim", "The claim of OIDC ID tokens used as the user name.")
+	flaggy.String(&authOIDCGroupsClaim, "", "authOIDCGroupsClaim", "The claim of OIDC ID tokens used as the groups of the user.")
+	flaggy.StringSlice(&authAllowedUsers, "", "authAllowedUsers", "A bearer token user allowed to read the web server. All authenticated users are allowed when no users or groups are listed. Can be repeated.")
+	flaggy.StringSlice(&authAllowedGroups, "", "authAllowedGroups", "A bearer token group allowed to read the web server. Can be repeated.")
+	flaggy.String(&alertmanagerLabelsFlag, "", "alertmanagerLabels", "Comma separated key=value labels added to every alert pushed to Alertmanager.")
+	flaggy.String(&slackWebhookURL, "", "slackWebhookURL", "The URL of a Slack incoming webhook to post a message to when checks fail.")
+	flaggy.String(&slackChannel, "", "slackChannel", "The Slack channel to post to instead of the default channel of the incoming webhook.")
+	flaggy.StringSlice(&slackNamespaces, "", "slackNamespaces", "A namespace whose failing checks are posted to Slack. All namespaces are posted when none are listed. Can be repeated.")
+	flaggy.StringSlice(&slackChecks, "", "slackChecks", "A check, by name or namespace/name, whose failures are posted to Slack. All checks are posted when none are listed. Can be repeated.")
+	flaggy.String(&statsdAddress, "", "statsdAddress", "The host:port of a StatsD server or agent to send check and job results to over UDP.")
+	flaggy.String(&statsdPrefix, "", "statsdPrefix", "The prefix of the names of metrics sent over StatsD.")
+	flaggy.String(&statsdTagsFlag, "", "statsdTags", "Comma separated key=value tags sent with every metric over StatsD when statsdDogStatsD is set.")
+	flaggy.Bool(&statsdDogStatsD, "", "statsdDogStatsD", "Set to send tags with the metrics sent over StatsD in the DogStatsD format.")
+	flaggy.String(&otlpEndpoint, "", "otlpEndpoint", "The OpenTelemetry collector to export check results and Kuberhealthy internals to over OTLP. A host:port for grpc or a URL for http.")
+	flaggy.String(&otlpProtocol, "", "otlpProtocol", "The OTLP transport used to reach otlpEndpoint, either grpc or http.")
+	flaggy.String(&otlpHeadersFlag, "", "otlpHeaders", "Comma separated key=value headers sent with every OTLP export.")
+	flaggy.Bool(&otlpInsecure, "", "otlpInsecure", "Set to connect to a grpc otlpEndpoint without TLS.")
+	flaggy.Duration(&otlpInterval, "", "otlpInterval", "How often Kuberhealthy internals are exported over OTLP.")
+	flaggy.Bool(&cloudwatchEnabled, "", "cloudwatchEnabled", "Set to publish check and job results to CloudWatch as custom metrics with the default AWS credentials, such as those of IAM roles for service accounts.")
+	flaggy.String(&cloudwatchNamespace, "", "cloudwatchNamespace", "The CloudWatch namespace of the custom metrics.")
+	flaggy.String(&cloudwatchRegion, "", "cloudwatchRegion", "The AWS region metrics are published to. The region of the environment is used when blank.")
+	flaggy.String(&cloudwatchDimensionsFlag, "", "cloudwatchDimensions", "Comma separated key=value dimensions published with every CloudWatch metric.")
+	flaggy.String(&graphiteAddress, "", "graphiteAddress", "The host:port of a Graphite carbon receiver to send check and job results to over TCP.")
+	flaggy.String(&graphiteProtocol, "", "graphiteProtocol", "How metrics are sent to Graphite, either plaintext or pickle.")
+	flaggy.String(&graphitePathTemplate, "", "graphitePathTemplate", "The Go template Graphite metric paths are rendered with.")
+	flaggy.String(&pushgatewayURL, "", "pushgatewayURL", "The URL of a Prometheus Pushgateway to push check and job results to when they complete.")
+	flaggy.String(&pushgatewayJob, "", "pushgatewayJob", "The job grouping label of the metrics pushed to the Pushgateway.")
+	flaggy.String(&pushgatewayHeadersFlag, "", "pushgatewayHeaders", "Comma separated key=value headers sent with every request to the Pushgateway.")
+	flaggy.String(&pushgatewayLabelsFlag, "", "pushgatewayLabels", "Comma separated key=value grouping labels added to every group pushed to the Pushgateway.")
+	flaggy.Duration(&federationInterval, "", "federationInterval", "How often the status pages of the federated clusters are polled.")
+	flaggy.Duration(&federationTimeout, "", "federationTimeout", "The timeout of each poll of the federated clusters.")
+	flaggy.Duration(&shutdownGracePeriod, "", "shutdownGracePeriod", "How long Kuberhealthy waits for check runs in flight to complete when it shuts down before it aborts them and removes their checker pods.")
+	flaggy.Int(&maxConcurrentChecks, "", "maxConcurrentChecks", "The most khcheck runs each Kuberhealthy pod has in flight at once. Runs beyond it are queued. 0 runs all checks as they are due.")
+	flaggy.Bool(&orphanGC, "", "orphanGC", "Set to false to disable deleting the checker pods and check resources left behind by interrupted runs.")
+	flaggy.Duration(&orphanGCInterval, "", "orphanGCInterval", "How often orphaned checker pods and check resources are looked for.")
+	flaggy.Duration(&orphanGCGracePeriod, "", "orphanGCGracePeriod", "How long past the deadline of their run checker pods are kept, and how long check resources are kept after the checker pod that created them stopped.")
+	flaggy.Float64(&kubeAPIQPS, "", "kubeAPIQPS", "The most requests per second the kubernetes clients of Kuberhealthy make to the API server together once their burst is used up.")
+	flaggy.Int(&kubeAPIBurst, "", "kubeAPIBurst", "The most requests the kubernetes clients of Kuberhealthy make to the API server at once before they are throttled to kubeAPIQPS.")
+	runCheck := newRunCheckCommand()
+	flaggy.AttachSubcommand(runCheck, 1)
+	flaggy.Parse()
+
+	// lint a khcheck manifest and exit instead of starting kuberhealthy
+	if len(lintKHCheckPath) > 0 {
+		os.Exit(lintKHCheck(lintKHCheckPath, !lintSkipImageCheck, os.Stdout))
+	}
+
+	// run a single khcheck in the foreground and exit instead of starting kuberhealthy
+	if runCheck.Used {
+		os.Exit(runCheckCommand(os.Stdout))
+	}
+
+	// flags can also be set in the configuration file
+	registerConfigFlags(flaggy.DefaultParser.Flags, os.Args[1:])
+
 	// setup global config struct
 	err := setUpConfig()
 	if err != nil {
 		return err
 	}
 
-	// setup flaggy
-	flaggy.SetDescription("Kuberhealthy is an in-cluster synthetic health checker for Kubernetes.")
-	flaggy.String(&configPath, "c", "config", "Absolute path to the kuberhealthy config file")
-	flaggy.Bool(&useDebugMode, "d", "debug", "Set to true to enable debug.")
-	flaggy.Bool(&cfg.EnableForceMaster, "", "forceMaster", "Set to force master responsibilities on.")
-	flaggy.Parse()
+	// when configured from a configmap, the kubernetes clients are needed before the configuration can be loaded
+	if len(configMapFlag) > 0 {
+		err = initKubernetesClients()
+		if err != nil {
+			return fmt.Errorf("failed to bootstrap kubernetes clients: %s", err)
+		}
+		err = setUpConfig()
+		if err != nil {
+			return err
+		}
+	}
+	apiServerLatency = newAPILatencyTracker(apiServerLatencyWindow)
 
 	// parse and set logging level
 	parsedLogLevel, err := log.ParseLevel(cfg.LogLevel)
@@ -232,8 +650,13 @@ func setUp() error {
 		return err
 	}
 
-	// log to stdout and set the level to info by default
+	// log to stdout in the configured format and set the level to info by default
+	logFormatter, err := newLogFormatter(logFormat)
+	if err != nil {
+		return err
+	}
 	log.SetOutput(os.Stdout)
+	log.SetFormatter(logFormatter)
 	log.SetLevel(parsedLogLevel)
 	log.Infoln("Startup Arguments:", os.Args)
 
@@ -243,12 +666,6 @@ func setUp() error {
 		log.SetLevel(log.DebugLevel)
 	}
 
-	// Handle force master mode
-	if cfg.EnableForceMaster {
-		log.Infoln("Enabling forced master mode")
-		masterCalculation.DebugAlwaysMasterOn()
-	}
-
 	// determine the name of this pod from the POD_NAME environment variable
 	podHostname, err = getEnvVar("POD_NAME")
 	if err != nil {
@@ -256,14 +673,13 @@ func setUp() error {
 		return err
 	}
 
-	// determine the name of this pod from the POD_NAME environment variable
-	cfg.TargetNamespace = os.Getenv("TARGET_NAMESPACE")
-
 	// setup all clients
-	err = initKubernetesClients()
-	if err != nil {
-		err := fmt.Errorf("failed to bootstrap kubernetes clients: %s", err)
-		return err
+	if kubernetesClient == nil {
+		err = initKubernetesClients()
+		if err != nil {
+			err := fmt.Errorf("failed to bootstrap kubernetes clients: %s", err)
+			return err
+		}
 	}
 
 	return nil
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // embed timezone data so maintenance window timezones work in minimal images
+
+	"github.com/gorhill/cronexpr"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// MaintenanceWindow describes a recurring period of time during which matching checks are suppressed.  Failures of
+// suppressed checks are shown with `Suppressed: true` on the status page and do not affect the global OK state.
+type MaintenanceWindow struct {
+	Name     string   `yaml:"name"`               // the name of the maintenance window shown on the status page
+	Start    string   `yaml:"start"`              // a cron expression for when the window starts, such as "0 2 * * SUN"
+	Duration string   `yaml:"duration"`           // how long the window lasts after each start, such as "2h"
+	Checks   []string `yaml:"checks,omitempty"`   // check names or namespace/name pairs to suppress. blank or "*" matches all checks
+	Timezone string   `yaml:"timezone,omitempty"` // the IANA timezone the start schedule is evaluated in. defaults to UTC
+	SkipRuns bool     `yaml:"skipRuns,omitempty"` // skip runs of matching checks entirely rather than suppressing their failures
+
+	schedule *cronexpr.Expression // the parsed start schedule
+	duration time.Duration        // the parsed duration
+	location *time.Location       // the parsed timezone
+}
+
+// validate parses the start schedule, duration and timezone of the maintenance window.  A window must be validated
+// before it can become active.
+func (mw *MaintenanceWindow) validate() error {
+	if len(mw.Name) == 0 {
+		return errors.New("maintenance window name can not be blank")
+	}
+
+	schedule, err := cronexpr.Parse(mw.Start)
+	if err != nil {
+		return fmt.Errorf("maintenance window %s has an invalid start schedule %q: %w", mw.Name, mw.Start, err)
+	}
+
+	duration, err := time.ParseDuration(mw.Duration)
+	if err != nil {
+		return fmt.Errorf("maintenance window %s has an invalid duration %q: %w", mw.Name, mw.Duration, err)
+	}
+	if duration <= 0 {
+		return fmt.Errorf("maintenance window %s must have a duration greater than zero", mw.Name)
+	}
+
+	location := time.UTC
+	if len(mw.Timezone) > 0 {
+		location, err = time.LoadLocation(mw.Timezone)
+		if err != nil {
+			return fmt.Errorf("maintenance window %s has an invalid timezone %q: %w", mw.Name, mw.Timezone, err)
+		}
+	}
+
+	mw.schedule = schedule
+	mw.duration = duration
+	mw.location = location
+	return nil
+}
+
+// isActive determines if the maintenance window is active at the supplied time.  Windows that have not been
+// validated are never active.
+func (mw *MaintenanceWindow) isActive(now time.Time) bool {
+	if mw.schedule == nil {
+		return false
+	}
+
+	// the window is active if the first start after (now - duration) has already happened
+	localNow := now.In(mw.location)
+	lastPossibleStart := mw.schedule.Next(localNow.Add(-mw.duration))
+	if lastPossibleStart.IsZero() {
+		return false
+	}
+	return !lastPossibleStart.After(localNow)
+}
+
+// matchesCheck determines if the maintenance window applies to the check with the supplied name and namespace
+func (mw *MaintenanceWindow) matchesCheck(name string, namespace string) bool {
+	if len(mw.Checks) == 0 {
+		return true
+	}
+	for _, c := range mw.Checks {
+		c = strings.TrimSpace(c)
+		if c == "*" || c == name || c == namespace+"/"+name {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMaintenanceWindows validates all maintenance windows in place and returns all validation errors found
+func validateMaintenanceWindows(windows []MaintenanceWindow) error {
+	var errs []string
+	for i := range windows {
+		err := windows[i].validate()
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("invalid maintenance windows: " + strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// activeMaintenanceWindows returns the maintenance windows that are active at the supplied time
+func activeMaintenanceWindows(windows []MaintenanceWindow, now time.Time) []MaintenanceWindow {
+	var active []MaintenanceWindow
+	for _, mw := range windows {
+		if mw.isActive(now) {
+			active = append(active, mw)
+		}
+	}
+	return active
+}
+
+// shouldSkipCheckRun determines if the run of a check should be skipped due to an active maintenance window
+func shouldSkipCheckRun(windows []MaintenanceWindow, name string, namespace string, now time.Time) bool {
+	for _, mw := range activeMaintenanceWindows(windows, now) {
+		if mw.SkipRuns && mw.matchesCheck(name, namespace) {
+			log.Infoln("maintenance: skipping run of check", namespace+"/"+name, "due to maintenance window", mw.Name)
+			return true
+		}
+	}
+	return false
+}
+
+// applyMaintenanceWindows flags checks matching an active maintenance window as suppressed and recalculates the
+// global OK state and errors of the supplied state without the suppressed checks
+func applyMaintenanceWindows(state health.State, windows []MaintenanceWindow, now time.Time) health.State {
+	active := activeMaintenanceWindows(windows, now)
+	if len(active) == 0 {
+		return state
+	}
+
+	for _, mw := range active {
+		state.ActiveMaintenanceWindows = append(state.ActiveMaintenanceWindows, mw.Name)
+	}
+
+	// rebuild the global state from the details of checks and jobs that are not suppressed
+	state.OK = true
+	state.Errors = []string{}
+	for name, details := range state.CheckDetails {
+		checkName := strings.TrimPrefix(name, details.Namespace+"/")
+		for _, mw := range active {
+			if mw.matchesCheck(checkName, details.Namespace) {
+				details.Suppressed = true
+				break
+			}
+		}
+		if details.Suppressed {
+			state.SuppressedChecks = append(state.SuppressedChecks, name)
+			state.CheckDetails[name] = details
+			continue
+		}
+		for _, e := range details.Errors {
+			if len(strings.TrimSpace(e)) == 0 {
+				continue
+			}
+			state.AddError(e)
+			state.OK = false
+		}
+	}
+	for _, details := range state.JobDetails {
+		for _, e := range details.Errors {
+			if len(strings.TrimSpace(e)) == 0 {
+				continue
+			}
+			state.AddError(e)
+			state.OK = false
+		}
+	}
+
+	return state
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// TestValidateMaintenanceWindows ensures invalid schedules, durations and timezones are reported
+func TestValidateMaintenanceWindows(t *testing.T) {
+	valid := []MaintenanceWindow{{Name: "patching", Start: "0 2 * * SUN", Duration: "2h", Timezone: "Europe/Berlin"}}
+	err := validateMaintenanceWindows(valid)
+	if err != nil {
+		t.Fatal("expected maintenance window to be valid:", err)
+	}
+
+	invalid := []MaintenanceWindow{
+		{Name: "bad-cron", Start: "not a cron", Duration: "2h"},
+		{Name: "bad-duration", Start: "0 2 * * SUN", Duration: "forever"},
+		{Name: "bad-timezone", Start: "0 2 * * SUN", Duration: "2h", Timezone: "Mars/Olympus"},
+	}
+	err = validateMaintenanceWindows(invalid)
+	if err == nil {
+		t.Fatal("expected invalid maintenance windows to fail validation")
+	}
+	for _, mw := range invalid {
+		if mw.isActive(time.Now()) {
+			t.Fatal("invalid maintenance window", mw.Name, "should never be active")
+		}
+	}
+}
+
+// TestMaintenanceWindowIsActive tests window activation in the configured timezone
+func TestMaintenanceWindowIsActive(t *testing.T) {
+	mw := MaintenanceWindow{Name: "patching", Start: "0 2 * * SUN", Duration: "2h", Timezone: "Europe/Berlin"}
+	err := mw.validate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	tests := []struct {
+		at     time.Time
+		active bool
+	}{
+		{time.Date(2024, 6, 2, 1, 59, 0, 0, berlin), false}, // sunday before the window
+		{time.Date(2024, 6, 2, 2, 0, 0, 0, berlin), true},   // sunday at the start of the window
+		{time.Date(2024, 6, 2, 3, 30, 0, 0, berlin), true},  // sunday during the window
+		{time.Date(2024, 6, 2, 4, 0, 0, 0, berlin), false},  // sunday at the end of the window
+		{time.Date(2024, 6, 3, 3, 0, 0, 0, berlin), false},  // monday
+		{time.Date(2024, 6, 2, 1, 0, 0, 0, time.UTC), true}, // 03:00 in berlin during summer time
+	}
+	for _, test := range tests {
+		if mw.isActive(test.at) != test.active {
+			t.Fatal("expected maintenance window active state to be", test.active, "at", test.at)
+		}
+	}
+}
+
+// TestApplyMaintenanceWindows ensures suppressed checks do not affect the global OK state
+func TestApplyMaintenanceWindows(t *testing.T) {
+	windows := []MaintenanceWindow{{Name: "patching", Start: "* * * * *", Duration: "2h", Checks: []string{"daemonset", "kuberhealthy/pod-restarts"}}}
+	err := validateMaintenanceWindows(windows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := health.NewState()
+	state.OK = false
+	state.Errors = []string{"daemonset failed", "pod restarts failed"}
+	state.CheckDetails["kuberhealthy/daemonset"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", Errors: []string{"daemonset failed"}}
+	state.CheckDetails["kuberhealthy/pod-restarts"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", Errors: []string{"pod restarts failed"}}
+	state.CheckDetails["kuberhealthy/dns"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", OK: true}
+
+	state = applyMaintenanceWindows(state, windows, time.Now())
+	if !state.OK || len(state.Errors) != 0 {
+		t.Fatal("expected suppressed failures to not affect global state but got", state.OK, state.Errors)
+	}
+	if len(state.SuppressedChecks) != 2 || len(state.ActiveMaintenanceWindows) != 1 {
+		t.Fatal("unexpected suppressed checks or active windows:", state.SuppressedChecks, state.ActiveMaintenanceWindows)
+	}
+	if !state.CheckDetails["kuberhealthy/daemonset"].Suppressed || state.CheckDetails["kuberhealthy/dns"].Suppressed {
+		t.Fatal("unexpected suppressed flags on check details")
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// maxChecks is the most khchecks that are run.  Khchecks beyond it are rejected.  When 0, all khchecks are run.
+var maxChecks int
+
+// maxChecksMetaCheckName is the name of the failing check shown on the status page while khchecks are rejected
+// because of maxChecks
+const maxChecksMetaCheckName = "max-checks"
+
+// activeCheckCount is the number of khchecks that are not paused as of the last khcheck scan.  It is kept up to date
+// by all Kuberhealthy instances, so the status page of every instance shows when khchecks are rejected.
+var activeCheckCount int64
+
+// setActiveCheckCount records the number of khchecks that are not paused
+func setActiveCheckCount(count int) {
+	atomic.StoreInt64(&activeCheckCount, int64(count))
+}
+
+// getActiveCheckCount returns the number of khchecks that are not paused as of the last khcheck scan
+func getActiveCheckCount() int {
+	return int(atomic.LoadInt64(&activeCheckCount))
+}
+
+// checkAdmission decides which khchecks are loaded when there are more than maxChecks.  Checks that were loaded
+// before are admitted first, so a new khcheck never takes the place of a check that is already running.  Other
+// checks are admitted in listing order while there is room.
+type checkAdmission struct {
+	max      int
+	previous map[string]bool               // the checks that were loaded before
+	loaded   map[string]bool               // the checks that are admitted
+	pending  []khcheckv1.KuberhealthyCheck // new checks that may be admitted once all checks loaded before are
+	rejected []string                      // the checks that are rejected
+}
+
+// newCheckAdmission creates a check admission for up to max checks.  The checks that were loaded before are
+// supplied by namespace/name.
+func newCheckAdmission(max int, previous map[string]bool) *checkAdmission {
+	return &checkAdmission{
+		max:      max,
+		previous: previous,
+		loaded:   make(map[string]bool),
+	}
+}
+
+// admit indicates that a khcheck can be loaded right away.  New checks are held until all checks have been seen and
+// then returned by admitPending if there is room for them.  At most max new checks are held, since no more than that
+// could ever be admitted.
+func (a *checkAdmission) admit(kc khcheckv1.KuberhealthyCheck) bool {
+	name := kc.Namespace + "/" + kc.Name
+	if a.max <= 0 || (a.previous[name] && len(a.loaded) < a.max) {
+		a.loaded[name] = true
+		return true
+	}
+	if len(a.pending) < a.max {
+		a.pending = append(a.pending, kc)
+		return false
+	}
+	a.rejected = append(a.rejected, name)
+	return false
+}
+
+// admitPending returns the new checks that there is room for once all checks have been seen.  The rest are rejected.
+func (a *checkAdmission) admitPending() []khcheckv1.KuberhealthyCheck {
+	var admitted []khcheckv1.KuberhealthyCheck
+	for _, kc := range a.pending {
+		name := kc.Namespace + "/" + kc.Name
+		if len(a.loaded) >= a.max {
+			a.rejected = append(a.rejected, name)
+			continue
+		}
+		a.loaded[name] = true
+		admitted = append(admitted, kc)
+	}
+	a.pending = nil
+	return admitted
+}
+
+// applyMaxChecks adds a failing meta-check to the state while there are more khchecks than maxChecks allows, so that
+// the overload is visible on the status page.  The meta-check belongs to the Kuberhealthy namespace and is only added
+// when that namespace was requested.
+func applyMaxChecks(state health.State, activeChecks int, filter statusFilter) health.State {
+	if maxChecks <= 0 || activeChecks <= maxChecks {
+		return state
+	}
+	if !filter.matches(podNamespace+"/"+maxChecksMetaCheckName, podNamespace) {
+		return state
+	}
+
+	details := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	details.Namespace = podNamespace
+	details.OK = false
+	details.Errors = []string{fmt.Sprintf("%d khchecks exceed the maxChecks limit of %d. %d khchecks are not run.", activeChecks, maxChecks, activeChecks-maxChecks)}
+	details.AuthoritativePod = podHostname
+	state.CheckDetails[podNamespace+"/"+maxChecksMetaCheckName] = details
+	state.OK = false
+	state.AddError(details.Errors...)
+	return state
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// TestCheckAdmission ensures checks that were loaded before are kept over new checks once maxChecks is reached
+func TestCheckAdmission(t *testing.T) {
+	previous := map[string]bool{"kuberhealthy/check-3": true, "kuberhealthy/check-4": true}
+	admission := newCheckAdmission(3, previous)
+
+	var admitted []string
+	for i := 0; i < 6; i++ {
+		kc := khcheckv1.KuberhealthyCheck{ObjectMeta: metav1.ObjectMeta{Name: "check-" + strconv.Itoa(i), Namespace: "kuberhealthy"}}
+		if admission.admit(kc) {
+			admitted = append(admitted, kc.Name)
+		}
+	}
+	for _, kc := range admission.admitPending() {
+		admitted = append(admitted, kc.Name)
+	}
+
+	if len(admitted) != 3 || admitted[0] != "check-3" || admitted[1] != "check-4" || admitted[2] != "check-0" {
+		t.Fatal("expected the previously loaded checks and the first new check to be admitted but got", admitted)
+	}
+	if len(admission.rejected) != 3 {
+		t.Fatal("expected 3 rejected checks but got", admission.rejected)
+	}
+
+	// without a limit, every check is admitted right away
+	admission = newCheckAdmission(0, nil)
+	for i := 0; i < 6; i++ {
+		if !admission.admit(khcheckv1.KuberhealthyCheck{ObjectMeta: metav1.ObjectMeta{Name: "check-" + strconv.Itoa(i)}}) {
+			t.Fatal("expected every check to be admitted without a limit")
+		}
+	}
+}
+
+// TestApplyMaxChecks ensures a failing meta-check is shown while there are more khchecks than maxChecks allows
+func TestApplyMaxChecks(t *testing.T) {
+	maxChecks = 10
+	defer func() { maxChecks = 0 }()
+
+	state := applyMaxChecks(health.NewState(), 10, statusFilter{})
+	if !state.OK || len(state.CheckDetails) != 0 {
+		t.Fatal("expected no meta-check within maxChecks")
+	}
+
+	state = applyMaxChecks(health.NewState(), 25, statusFilter{})
+	details, ok := state.CheckDetails[podNamespace+"/"+maxChecksMetaCheckName]
+	if !ok || details.OK || state.OK || len(state.Errors) != 1 {
+		t.Fatal("expected a failing meta-check beyond maxChecks but got", state)
+	}
+
+	state = applyMaxChecks(health.NewState(), 25, statusFilter{namespaces: []string{"other-namespace"}})
+	if !state.OK || len(state.CheckDetails) != 0 {
+		t.Fatal("expected no meta-check when the kuberhealthy namespace was not requested")
+	}
+}
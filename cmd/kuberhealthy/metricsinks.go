@@ -0,0 +1,62 @@
+package main
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/metrics"
+)
+
+// newMetricSinks creates the clients that check and job results are forwarded to next to InfluxDB, from the flags
+// that enable them
+func newMetricSinks() ([]metrics.Client, error) {
+	var sinks []metrics.Client
+	statsd, err := newStatsDClient()
+	if err != nil {
+		return nil, err
+	}
+	if statsd != nil {
+		sinks = append(sinks, statsd)
+	}
+	otlp, err := newOTLPClient()
+	if err != nil {
+		return nil, err
+	}
+	if otlp != nil {
+		sinks = append(sinks, otlp)
+	}
+	cloudwatch, err := newCloudWatchClient()
+	if err != nil {
+		return nil, err
+	}
+	if cloudwatch != nil {
+		sinks = append(sinks, cloudwatch)
+	}
+	graphite, err := newGraphiteClient()
+	if err != nil {
+		return nil, err
+	}
+	if graphite != nil {
+		sinks = append(sinks, graphite)
+	}
+	return sinks, nil
+}
+
+// forwardingMetrics determines if check and job results are forwarded to any metric client
+func (k *Kuberhealthy) forwardingMetrics() bool {
+	return k.MetricForwarder != nil || len(k.metricSinks) > 0
+}
+
+// forwardMetrics pushes the metrics of a check or job run to InfluxDB and every other metric sink.  Failures are
+// logged, so that one sink that is down does not keep the others from receiving the metrics.
+func (k *Kuberhealthy) forwardMetrics(metric metrics.Metric, tags map[string]string) {
+	clients := k.metricSinks
+	if k.MetricForwarder != nil {
+		clients = append([]metrics.Client{k.MetricForwarder}, clients...)
+	}
+	for _, client := range clients {
+		err := client.Push(metric, tags)
+		if err != nil {
+			log.Errorln("Error forwarding metrics", err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/metrics"
+)
+
+// fakeMetricClient records the metrics pushed to it and fails every push when err is set
+type fakeMetricClient struct {
+	pushed []metrics.Metric
+	err    error
+}
+
+func (f *fakeMetricClient) Push(points metrics.Metric, tags map[string]string) error {
+	f.pushed = append(f.pushed, points)
+	return f.err
+}
+
+// TestForwardMetrics ensures metrics reach every sink, even when another sink fails
+func TestForwardMetrics(t *testing.T) {
+	kh := &Kuberhealthy{}
+	if kh.forwardingMetrics() {
+		t.Fatal("expected no forwarding without metric clients")
+	}
+
+	influx := &fakeMetricClient{err: errors.New("influx is down")}
+	statsd := &fakeMetricClient{}
+	kh.MetricForwarder = influx
+	kh.metricSinks = []metrics.Client{statsd}
+	if !kh.forwardingMetrics() {
+		t.Fatal("expected forwarding with metric clients")
+	}
+	kh.forwardMetrics(metrics.Metric{{"dns-status.kuberhealthy": 1}}, nil)
+	if len(influx.pushed) != 1 || len(statsd.pushed) != 1 {
+		t.Fatal("expected the metrics to be pushed to both clients but got", influx.pushed, statsd.pushed)
+	}
+}
+
+// TestNewStatsDClientDisabled ensures StatsD forwarding is off without an address
+func TestNewStatsDClientDisabled(t *testing.T) {
+	previous := statsdAddress
+	defer func() { statsdAddress = previous }()
+	statsdAddress = ""
+	client, err := newStatsDClient()
+	if err != nil || client != nil {
+		t.Fatal("expected no StatsD client without an address but got", client, err)
+	}
+}
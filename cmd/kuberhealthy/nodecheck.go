@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// nodeCheckName is the name of the khstate the built-in node check writes its results to in the Kuberhealthy
+// namespace
+const nodeCheckName = "node-readiness"
+
+// nodeCheckInterval is how often the node check runs
+const nodeCheckInterval = time.Minute
+
+// nodeChecks enables the built-in node check
+var nodeChecks bool
+
+// nodeCheckGracePeriod is how long a node can be NotReady, under pressure or unschedulable before the node check fails
+var nodeCheckGracePeriod = time.Minute * 5
+
+// nodeCheckExcludedNodes are the names of nodes the node check skips
+var nodeCheckExcludedNodes []string
+
+// nodeCheckExcludeSelector is a label selector of nodes the node check skips
+var nodeCheckExcludeSelector string
+
+// nodePressureConditions are the node conditions that indicate a problem when they are True
+var nodePressureConditions = []apiv1.NodeConditionType{
+	apiv1.NodeDiskPressure,
+	apiv1.NodeMemoryPressure,
+	apiv1.NodePIDPressure,
+}
+
+// nodeChecker checks that the nodes of the cluster are ready, not under pressure and schedulable
+type nodeChecker struct {
+	gracePeriod time.Duration
+	excluded    map[string]bool
+	selector    labels.Selector // nodes matching the selector are skipped. nil when no nodes are skipped by label
+	client      kubernetes.Interface
+
+	// unschedulableSince is when each node was first seen cordoned.  Nodes do not record when they were cordoned, so
+	// the grace period of unschedulable nodes starts when the check first sees them.
+	mu                 sync.Mutex
+	unschedulableSince map[string]time.Time
+}
+
+// newNodeChecker creates a node checker from the node check flags
+func newNodeChecker(client kubernetes.Interface) (*nodeChecker, error) {
+	nc := &nodeChecker{
+		gracePeriod:        nodeCheckGracePeriod,
+		excluded:           make(map[string]bool),
+		client:             client,
+		unschedulableSince: make(map[string]time.Time),
+	}
+	for _, name := range nodeCheckExcludedNodes {
+		nc.excluded[name] = true
+	}
+	if len(nodeCheckExcludeSelector) > 0 {
+		selector, err := labels.Parse(nodeCheckExcludeSelector)
+		if err != nil {
+			return nil, err
+		}
+		nc.selector = selector
+	}
+	return nc, nil
+}
+
+// validateNodeCheckFlags ensures the node check flags are usable when the node check is enabled
+func validateNodeCheckFlags() error {
+	if !nodeChecks {
+		return nil
+	}
+	if nodeCheckGracePeriod < 0 {
+		return errors.New("nodeCheckGracePeriod can not be negative")
+	}
+	_, err := labels.Parse(nodeCheckExcludeSelector)
+	if err != nil {
+		return fmt.Errorf("nodeCheckExcludeSelector %q is not a label selector: %w", nodeCheckExcludeSelector, err)
+	}
+	return nil
+}
+
+// run lists the nodes and returns the check details with a sub-entry for each node that is checked.  The check fails
+// when any node has been NotReady, under pressure or unschedulable for longer than the grace period, or when the
+// nodes can not be listed.
+func (nc *nodeChecker) run(ctx context.Context, now time.Time) khstatev1.WorkloadDetails {
+	details := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	details.Namespace = podNamespace
+
+	var nodes []apiv1.Node
+	err := forEachNode(ctx, nc.client, func(node apiv1.Node) {
+		if nc.excluded[node.Name] || (nc.selector != nil && nc.selector.Matches(labels.Set(node.Labels))) {
+			return
+		}
+		nodes = append(nodes, node)
+	})
+	if err != nil {
+		details.Errors = []string{"Failed to list nodes: " + err.Error()}
+		return details
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+
+	details.OK = true
+	seen := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		seen[node.Name] = true
+		result := nc.nodeDetails(node, now)
+		details.Targets = append(details.Targets, result)
+		if !result.OK {
+			details.OK = false
+			details.Errors = append(details.Errors, result.Errors...)
+		}
+	}
+
+	// forget nodes that were removed so that they start a new grace period if they come back
+	nc.mu.Lock()
+	for name := range nc.unschedulableSince {
+		if !seen[name] {
+			delete(nc.unschedulableSince, name)
+		}
+	}
+	nc.mu.Unlock()
+	return details
+}
+
+// nodeDetails returns the result of a single node.  Problems that started less than the grace period ago are not
+// errors yet, so that nodes that are joining, rebooting or being drained for an upgrade do not fail the check.
+func (nc *nodeChecker) nodeDetails(node apiv1.Node, now time.Time) khstatev1.TargetDetails {
+	result := khstatev1.TargetDetails{Target: node.Name}
+
+	ready := false
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == apiv1.NodeReady {
+			ready = condition.Status == apiv1.ConditionTrue
+			if !ready && nc.beyondGracePeriod(condition.LastTransitionTime.Time, now) {
+				result.Errors = append(result.Errors, fmt.Sprintf("Node %s has been NotReady since %s: %s", node.Name, condition.LastTransitionTime.UTC().Format(time.RFC3339), conditionMessage(condition)))
+			}
+			continue
+		}
+		for _, pressure := range nodePressureConditions {
+			if condition.Type == pressure && condition.Status == apiv1.ConditionTrue && nc.beyondGracePeriod(condition.LastTransitionTime.Time, now) {
+				result.Errors = append(result.Errors, fmt.Sprintf("Node %s has had %s since %s: %s", node.Name, condition.Type, condition.LastTransitionTime.UTC().Format(time.RFC3339), conditionMessage(condition)))
+			}
+		}
+	}
+
+	// a node that has never reported a Ready condition is NotReady from the time it was created
+	if !hasNodeCondition(node, apiv1.NodeReady) && nc.beyondGracePeriod(node.CreationTimestamp.Time, now) {
+		result.Errors = append(result.Errors, fmt.Sprintf("Node %s has not reported a Ready condition since it was created on %s", node.Name, node.CreationTimestamp.UTC().Format(time.RFC3339)))
+	}
+
+	nc.mu.Lock()
+	since, cordoned := nc.unschedulableSince[node.Name]
+	switch {
+	case !node.Spec.Unschedulable:
+		delete(nc.unschedulableSince, node.Name)
+	case !cordoned:
+		since = now
+		nc.unschedulableSince[node.Name] = since
+	}
+	nc.mu.Unlock()
+	if node.Spec.Unschedulable && nc.beyondGracePeriod(since, now) {
+		result.Errors = append(result.Errors, fmt.Sprintf("Node %s has been unschedulable since %s", node.Name, since.UTC().Format(time.RFC3339)))
+	}
+
+	result.OK = len(result.Errors) == 0
+	return result
+}
+
+// beyondGracePeriod determines if a problem that started at the supplied time has lasted longer than the grace period
+func (nc *nodeChecker) beyondGracePeriod(since time.Time, now time.Time) bool {
+	return now.Sub(since) > nc.gracePeriod
+}
+
+// hasNodeCondition determines if the node reports a condition of the supplied type
+func hasNodeCondition(node apiv1.Node, conditionType apiv1.NodeConditionType) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == conditionType {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionMessage returns the reason and message of a node condition for error messages
+func conditionMessage(condition apiv1.NodeCondition) string {
+	if len(condition.Message) == 0 {
+		return condition.Reason
+	}
+	return condition.Reason + " " + condition.Message
+}
+
+// forEachNode calls fn with each node of the cluster, fetching them a page at a time
+func forEachNode(ctx context.Context, client kubernetes.Interface, fn func(node apiv1.Node)) error {
+	opts := metav1.ListOptions{Limit: listPageSize}
+	for {
+		page, err := client.CoreV1().Nodes().List(ctx, opts)
+		if err != nil {
+			return err
+		}
+		for _, node := range page.Items {
+			fn(node)
+		}
+		if len(page.Continue) == 0 {
+			return nil
+		}
+		opts.Continue = page.Continue
+	}
+}
+
+// runNodeCheck runs the node check every nodeCheckInterval and stores its results in the node-readiness khstate until
+// the context is canceled.  When checks are sharded, only the kuberhealthy pod the check is assigned to runs it.
+func (k *Kuberhealthy) runNodeCheck(ctx context.Context) {
+	defer k.wg.Done()
+
+	log.Infoln("Starting check:", podNamespace, "/", nodeCheckName)
+	checker, err := newNodeChecker(kubernetesClient)
+	if err != nil {
+		log.Errorln("Error creating the node check:", err)
+		return
+	}
+	name := podNamespace + "/" + nodeCheckName
+	schedule := k.checkSchedules.add(name, checkTiming{interval: nodeCheckInterval})
+	defer k.checkSchedules.remove(name, schedule)
+
+	for {
+		if shardChecks && shardOwner(name) != podHostname {
+			log.Debugln("sharding: skipping run of check", name, "because it is assigned to another pod")
+		} else {
+			start := time.Now()
+			details := checker.run(ctx, start)
+			if ctx.Err() != nil {
+				log.Infoln("Shutting down check run due to context cancellation:", nodeCheckName, "in namespace", podNamespace)
+				return
+			}
+			details.RunDuration = time.Since(start).String()
+			details.AuthoritativePod = podHostname
+
+			log.Infoln("Setting state of check", nodeCheckName, "in namespace", podNamespace, "to", details.OK, details.Errors, details.RunDuration)
+			err := k.storeCheckState(nodeCheckName, podNamespace, details)
+			if err != nil {
+				log.Errorln("Error storing CRD state for check:", nodeCheckName, "in namespace", podNamespace, err)
+			}
+		}
+
+		if !schedule.wait(ctx) {
+			log.Infoln("Shutting down check run due to context cancellation:", nodeCheckName, "in namespace", podNamespace)
+			return
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testNode returns a node with the supplied conditions, each of which transitioned at the supplied time
+func testNode(name string, since time.Time, conditions map[apiv1.NodeConditionType]apiv1.ConditionStatus) *apiv1.Node {
+	node := &apiv1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.NewTime(since)}}
+	for conditionType, status := range conditions {
+		node.Status.Conditions = append(node.Status.Conditions, apiv1.NodeCondition{
+			Type:               conditionType,
+			Status:             status,
+			LastTransitionTime: metav1.NewTime(since),
+			Reason:             "KubeletNotReady",
+		})
+	}
+	return node
+}
+
+// TestNodeCheckerRun ensures nodes fail only once a problem outlasts the grace period, and that excluded nodes are
+// skipped
+func TestNodeCheckerRun(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-time.Hour)
+	recent := now.Add(-time.Minute)
+
+	excludedByLabel := testNode("spot-1", old, map[apiv1.NodeConditionType]apiv1.ConditionStatus{apiv1.NodeReady: apiv1.ConditionFalse})
+	excludedByLabel.Labels = map[string]string{"lifecycle": "spot"}
+	cordoned := testNode("cordoned", old, map[apiv1.NodeConditionType]apiv1.ConditionStatus{apiv1.NodeReady: apiv1.ConditionTrue})
+	cordoned.Spec.Unschedulable = true
+	client := fake.NewSimpleClientset(
+		testNode("healthy", old, map[apiv1.NodeConditionType]apiv1.ConditionStatus{apiv1.NodeReady: apiv1.ConditionTrue, apiv1.NodeDiskPressure: apiv1.ConditionFalse}),
+		testNode("not-ready", old, map[apiv1.NodeConditionType]apiv1.ConditionStatus{apiv1.NodeReady: apiv1.ConditionFalse}),
+		testNode("rebooting", recent, map[apiv1.NodeConditionType]apiv1.ConditionStatus{apiv1.NodeReady: apiv1.ConditionUnknown}),
+		testNode("pressure", old, map[apiv1.NodeConditionType]apiv1.ConditionStatus{apiv1.NodeReady: apiv1.ConditionTrue, apiv1.NodeMemoryPressure: apiv1.ConditionTrue}),
+		testNode("excluded", old, map[apiv1.NodeConditionType]apiv1.ConditionStatus{apiv1.NodeReady: apiv1.ConditionFalse}),
+		excludedByLabel,
+		cordoned,
+	)
+	checker := &nodeChecker{
+		gracePeriod:        time.Minute * 5,
+		excluded:           map[string]bool{"excluded": true},
+		selector:           labels.SelectorFromSet(labels.Set{"lifecycle": "spot"}),
+		client:             client,
+		unschedulableSince: make(map[string]time.Time),
+	}
+
+	details := checker.run(context.Background(), now)
+	if details.OK {
+		t.Fatal("expected the node check to fail")
+	}
+	expected := map[string]string{
+		"cordoned":  "",
+		"healthy":   "",
+		"not-ready": "NotReady",
+		"pressure":  "MemoryPressure",
+		"rebooting": "",
+	}
+	if len(details.Targets) != len(expected) {
+		t.Fatal("expected a result for each node that is not excluded but got", details.Targets)
+	}
+	for _, result := range details.Targets {
+		want, ok := expected[result.Target]
+		if !ok {
+			t.Fatal("unexpected result for node", result.Target)
+		}
+		if len(want) == 0 {
+			if !result.OK {
+				t.Error("expected node", result.Target, "to pass but got", result.Errors)
+			}
+			continue
+		}
+		if result.OK || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], want) {
+			t.Error("expected node", result.Target, "to fail with", want, "but got", result.Errors)
+		}
+	}
+	if len(details.Errors) != 2 {
+		t.Fatal("expected the errors of the failing nodes but got", details.Errors)
+	}
+
+	// the cordoned node fails once it has been unschedulable for longer than the grace period
+	details = checker.run(context.Background(), now.Add(time.Minute*6))
+	for _, result := range details.Targets {
+		if result.Target == "cordoned" && (result.OK || !strings.Contains(result.Errors[0], "unschedulable")) {
+			t.Fatal("expected the cordoned node to fail after the grace period but got", result.Errors)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+)
+
+// orphanGC enables the garbage collector that deletes the checker pods and check resources left behind by runs that
+// ended without cleaning up after themselves, such as the runs of a crashed Kuberhealthy pod or checker pod
+var orphanGC = true
+
+// orphanGCInterval is how often orphaned checker pods and check resources are looked for
+var orphanGCInterval = time.Minute * 5
+
+// orphanGCGracePeriod is how long a checker pod is left running past the deadline of its run, and how long check
+// resources are kept after the checker pod that created them stopped, before they are deleted
+var orphanGCGracePeriod = time.Minute * 5
+
+// checkResourceSelector selects the resources created by checks, such as the daemonsets of the daemonset check.  They
+// are labeled with the name of the checker pod that created them.
+const checkResourceSelector = "source=kuberhealthy," + checkResourceInstanceLabel
+
+// checkResourceInstanceLabel is the label of check resources that holds the name of the checker pod that created them
+const checkResourceInstanceLabel = "creatingInstance"
+
+// orphanedResource is a checker pod or check resource that is deleted by the orphan garbage collector
+type orphanedResource struct {
+	kind      string
+	namespace string
+	name      string
+	instance  string // the name of the checker pod that created the check resource
+	delete    func(ctx context.Context) error
+}
+
+// validateOrphanGCFlags ensures the orphan garbage collector interval and grace period are usable
+func validateOrphanGCFlags() error {
+	if orphanGCInterval <= 0 {
+		return errors.New("orphanGCInterval must be positive")
+	}
+	if orphanGCGracePeriod < 0 {
+		return errors.New("orphanGCGracePeriod must not be negative")
+	}
+	return nil
+}
+
+// orphanReaper deletes orphaned checker pods and check resources in the namespaces on an interval until the context
+// for it is canceled.  It is run by the master.
+func (k *Kuberhealthy) orphanReaper(ctx context.Context, namespaces []string) {
+	ticker := time.NewTicker(orphanGCInterval)
+	defer ticker.Stop()
+	log.Infoln("orphan reaper: starting up")
+
+	for {
+		select {
+		case <-ticker.C:
+			deleted, err := reapOrphans(ctx, kubernetesClient, namespaces, time.Now())
+			if err != nil {
+				log.Errorln("orphan reaper: Error when reaping orphaned resources:", err)
+			}
+			log.Infoln("orphan reaper: deleted", deleted, "orphaned resources")
+		case <-ctx.Done():
+			log.Infoln("orphan reaper: stopping")
+			return
+		}
+	}
+}
+
+// reapOrphans deletes the checker pods still running well past the deadline of their run and the check resources of
+// checker pods that are no longer running in the namespaces.  Returns the number of resources deleted.
+func reapOrphans(ctx context.Context, client kubernetes.Interface, namespaces []string, now time.Time) (int, error) {
+	var orphans []orphanedResource
+
+	// list the check resources before the checker pods, so that every checker pod that created a listed resource is
+	// also listed
+	var resources []orphanedResource
+	for _, namespace := range namespaces {
+		found, err := listCheckResources(ctx, client, namespace, now)
+		if err != nil {
+			return 0, err
+		}
+		resources = append(resources, found...)
+	}
+
+	// checker pods that run past the deadline of their run are orphaned.  the others may still report in.
+	running := make(map[string]bool)
+	for _, namespace := range namespaces {
+		pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: "kuberhealthy-check-name"})
+		if err != nil {
+			return 0, fmt.Errorf("error listing checker pods in namespace %q: %w", namespace, err)
+		}
+		for i := range pods.Items {
+			p := pods.Items[i]
+			if p.Status.Phase == apiv1.PodSucceeded || p.Status.Phase == apiv1.PodFailed {
+				continue
+			}
+			if !checkerPodOrphaned(p, now) {
+				running[p.Name] = true
+				continue
+			}
+			orphans = append(orphans, orphanedResource{kind: "checker pod", namespace: p.Namespace, name: p.Name, delete: func(ctx context.Context) error {
+				return client.CoreV1().Pods(p.Namespace).Delete(ctx, p.Name, metav1.DeleteOptions{})
+			}})
+		}
+	}
+
+	// check resources are orphaned once the checker pod that created them stopped running
+	for _, r := range resources {
+		if running[r.instance] {
+			continue
+		}
+		orphans = append(orphans, r)
+	}
+
+	var deleted int
+	var errs []error
+	for _, o := range orphans {
+		log.Infoln("orphan reaper: deleting orphaned", o.kind, o.name, "in namespace", o.namespace)
+		err := o.delete(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("error deleting orphaned %s %s in namespace %s: %w", o.kind, o.name, o.namespace, err))
+			continue
+		}
+		deleted++
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// listCheckResources lists the daemonsets, deployments, services and pods created by checks in the namespace that
+// are older than the orphan grace period.  Pods that are owned by another resource are left to their owner.
+func listCheckResources(ctx context.Context, client kubernetes.Interface, namespace string, now time.Time) ([]orphanedResource, error) {
+	opts := metav1.ListOptions{LabelSelector: checkResourceSelector}
+	var resources []orphanedResource
+	add := func(kind string, meta metav1.ObjectMeta, del func(ctx context.Context, name string, opts metav1.DeleteOptions) error) {
+		if meta.DeletionTimestamp != nil || now.Sub(meta.CreationTimestamp.Time) < orphanGCGracePeriod {
+			return
+		}
+		name := meta.Name
+		resources = append(resources, orphanedResource{kind: kind, namespace: meta.Namespace, name: name, instance: meta.Labels[checkResourceInstanceLabel], delete: func(ctx context.Context) error {
+			propagation := metav1.DeletePropagationBackground
+			return del(ctx, name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		}})
+	}
+
+	daemonSets, err := client.AppsV1().DaemonSets(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing check daemonsets in namespace %q: %w", namespace, err)
+	}
+	for _, ds := range daemonSets.Items {
+		add("daemonset", ds.ObjectMeta, client.AppsV1().DaemonSets(ds.Namespace).Delete)
+	}
+
+	deployments, err := client.AppsV1().Deployments(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing check deployments in namespace %q: %w", namespace, err)
+	}
+	for _, d := range deployments.Items {
+		add("deployment", d.ObjectMeta, client.AppsV1().Deployments(d.Namespace).Delete)
+	}
+
+	services, err := client.CoreV1().Services(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing check services in namespace %q: %w", namespace, err)
+	}
+	for _, s := range services.Items {
+		add("service", s.ObjectMeta, client.CoreV1().Services(s.Namespace).Delete)
+	}
+
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error listing check pods in namespace %q: %w", namespace, err)
+	}
+	for _, p := range pods.Items {
+		if metav1.GetControllerOf(&p) != nil {
+			continue
+		}
+		add("pod", p.ObjectMeta, client.CoreV1().Pods(p.Namespace).Delete)
+	}
+
+	return resources, nil
+}
+
+// checkerPodOrphaned returns true when a checker pod that has not completed is still around well past the deadline
+// of its run, by when Kuberhealthy would have stopped it had the run not been interrupted.  Checker pods without a
+// deadline are never considered orphaned.
+func checkerPodOrphaned(p apiv1.Pod, now time.Time) bool {
+	if p.DeletionTimestamp != nil {
+		return false
+	}
+	for _, c := range p.Spec.Containers {
+		for _, env := range c.Env {
+			if env.Name != external.KHDeadline {
+				continue
+			}
+			deadline, err := strconv.ParseInt(env.Value, 10, 64)
+			if err != nil {
+				return false
+			}
+			return now.After(time.Unix(deadline, 0).Add(orphanGCGracePeriod))
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+)
+
+func TestReapOrphans(t *testing.T) {
+	now := time.Now()
+	old := metav1.NewTime(now.Add(-time.Hour))
+
+	checkerPod := func(name string, phase apiv1.PodPhase, deadline time.Time) *apiv1.Pod {
+		return &apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kuberhealthy", CreationTimestamp: old, Labels: map[string]string{"kuberhealthy-check-name": "daemonset"}},
+			Spec: apiv1.PodSpec{Containers: []apiv1.Container{{
+				Name: "main",
+				Env:  []apiv1.EnvVar{{Name: external.KHDeadline, Value: strconv.FormatInt(deadline.Unix(), 10)}},
+			}}},
+			Status: apiv1.PodStatus{Phase: phase},
+		}
+	}
+	daemonSet := func(name string, instance string, created metav1.Time) *appsv1.DaemonSet {
+		return &appsv1.DaemonSet{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "kuberhealthy",
+			CreationTimestamp: created,
+			Labels:            map[string]string{"source": "kuberhealthy", "khcheck": "daemonset", checkResourceInstanceLabel: instance},
+		}}
+	}
+
+	controller := true
+	client := fake.NewSimpleClientset(
+		checkerPod("running", apiv1.PodRunning, now.Add(time.Minute)),
+		checkerPod("overdue", apiv1.PodRunning, now.Add(-time.Hour)),
+		checkerPod("completed", apiv1.PodSucceeded, now.Add(-time.Hour)),
+		daemonSet("ds-of-running", "running", old),
+		daemonSet("ds-of-overdue", "overdue", old),
+		daemonSet("ds-of-completed", "completed", old),
+		daemonSet("ds-of-missing-new", "missing", metav1.NewTime(now)),
+		&apiv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc-of-missing", Namespace: "kuberhealthy", CreationTimestamp: old,
+			Labels: map[string]string{"source": "kuberhealthy", checkResourceInstanceLabel: "missing"}}},
+		&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "ds-pod", Namespace: "kuberhealthy", CreationTimestamp: old,
+			Labels:          map[string]string{"source": "kuberhealthy", checkResourceInstanceLabel: "missing"},
+			OwnerReferences: []metav1.OwnerReference{{Kind: "DaemonSet", Name: "ds-of-missing", Controller: &controller}}}},
+	)
+
+	deleted, err := reapOrphans(context.Background(), client, []string{"kuberhealthy"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 4 {
+		t.Fatal("expected 4 orphaned resources to be deleted but got", deleted)
+	}
+
+	pods, err := client.CoreV1().Pods("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var podNames []string
+	for _, p := range pods.Items {
+		podNames = append(podNames, p.Name)
+	}
+	if len(podNames) != 3 || containsString("overdue", podNames) {
+		t.Fatal("expected only the checker pod past its deadline to be deleted but got", podNames)
+	}
+
+	daemonSets, err := client.AppsV1().DaemonSets("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dsNames []string
+	for _, ds := range daemonSets.Items {
+		dsNames = append(dsNames, ds.Name)
+	}
+	if len(dsNames) != 2 || !containsString("ds-of-running", dsNames) || !containsString("ds-of-missing-new", dsNames) {
+		t.Fatal("expected the daemonsets of stopped checker pods to be deleted but got", dsNames)
+	}
+
+	services, err := client.CoreV1().Services("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(services.Items) != 0 {
+		t.Fatal("expected the service of a missing checker pod to be deleted")
+	}
+}
+
+func TestCheckerPodOrphaned(t *testing.T) {
+	now := time.Now()
+	pod := func(deadline string) apiv1.Pod {
+		return apiv1.Pod{Spec: apiv1.PodSpec{Containers: []apiv1.Container{{Env: []apiv1.EnvVar{{Name: external.KHDeadline, Value: deadline}}}}}}
+	}
+
+	if checkerPodOrphaned(pod(strconv.FormatInt(now.Add(-orphanGCGracePeriod/2).Unix(), 10)), now) {
+		t.Fatal("expected a checker pod within the grace period after its deadline not to be orphaned")
+	}
+	if !checkerPodOrphaned(pod(strconv.FormatInt(now.Add(-orphanGCGracePeriod*2).Unix(), 10)), now) {
+		t.Fatal("expected a checker pod past the grace period after its deadline to be orphaned")
+	}
+	if checkerPodOrphaned(pod("soon"), now) || checkerPodOrphaned(apiv1.Pod{}, now) {
+		t.Fatal("expected checker pods without a deadline not to be orphaned")
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/metrics"
+)
+
+// otlpEndpoint is the OpenTelemetry collector that check results and Kuberhealthy internals are exported to over
+// OTLP.  OTLP exporting is disabled when blank.
+var otlpEndpoint string
+
+// otlpProtocol is the OTLP transport, either grpc or http
+var otlpProtocol = metrics.OTLPProtocolHTTP
+
+// otlpHeadersFlag holds comma separated key=value headers sent with every export, such as authorization
+var otlpHeadersFlag string
+var otlpHeaders map[string]string
+
+// otlpInsecure connects to a gRPC collector without TLS
+var otlpInsecure bool
+
+// otlpInterval is how often Kuberhealthy internals are exported
+var otlpInterval = time.Minute
+
+// parseOTLPHeaders parses the otlpHeaders flag
+func parseOTLPHeaders() error {
+	var err error
+	otlpHeaders, err = parseKeyValueFlag(otlpHeadersFlag)
+	if err != nil {
+		return fmt.Errorf("unable to parse otlpHeaders flag: %w", err)
+	}
+	return nil
+}
+
+// newOTLPClient creates the client check results are exported over OTLP with.  nil is returned when OTLP exporting is
+// disabled.  Metrics are exported as part of the kuberhealthy service, with the clusterName as k8s.cluster.name.
+func newOTLPClient() (*metrics.OTLPClient, error) {
+	if len(otlpEndpoint) == 0 {
+		return nil, nil
+	}
+	log.Infoln("otlp: exporting check results to", otlpEndpoint, "over", otlpProtocol)
+	return metrics.NewOTLPClient(metrics.OTLPConfig{
+		Endpoint: otlpEndpoint,
+		Protocol: otlpProtocol,
+		Headers:  otlpHeaders,
+		Insecure: otlpInsecure,
+		ResourceAttributes: map[string]string{
+			"service.name":     "kuberhealthy",
+			"k8s.cluster.name": clusterName,
+		},
+		Proxy: outboundProxy(),
+	})
+}
+
+// exportInternals exports the running state of Kuberhealthy and the counts of its checks to the client every
+// otlpInterval until the context is canceled
+func (k *Kuberhealthy) exportInternals(ctx context.Context, client metrics.Client) {
+	ticker := time.NewTicker(otlpInterval)
+	defer ticker.Stop()
+	for {
+		err := client.Push(internalsMetric(k.getCurrentState(statusFilter{})), map[string]string{"KuberhealthyPod": podHostname})
+		if err != nil {
+			log.Errorln("otlp: error exporting Kuberhealthy internals:", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// internalsMetric returns the metrics that describe Kuberhealthy itself, the same way the running and cluster state
+// gauges do on the metrics endpoint
+func internalsMetric(state health.State) metrics.Metric {
+	clusterState := 0
+	if state.OK {
+		clusterState = 1
+	}
+	failing := 0
+	for _, details := range state.CheckDetails {
+		if !details.OK {
+			failing++
+		}
+	}
+	master := 0
+	if len(podHostname) > 0 && state.CurrentMaster == podHostname {
+		master = 1
+	}
+	return metrics.Metric{
+		{"kuberhealthy.running": 1},
+		{"kuberhealthy.master": master},
+		{"kuberhealthy.cluster_state": clusterState},
+		{"kuberhealthy.checks": len(state.CheckDetails)},
+		{"kuberhealthy.checks_failing": failing},
+		{"kuberhealthy.checks_warning": len(state.WarningChecks)},
+		{"kuberhealthy.jobs": len(state.JobDetails)},
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+func TestInternalsMetric(t *testing.T) {
+	state := health.NewState()
+	state.CheckDetails["kuberhealthy/dns-status"] = khstatev1.WorkloadDetails{OK: true}
+	state.CheckDetails["kuberhealthy/deployment"] = khstatev1.WorkloadDetails{OK: false}
+	state.WarningChecks = []string{"kuberhealthy/deployment"}
+	state.OK = false
+
+	values := map[string]interface{}{}
+	for _, point := range internalsMetric(state) {
+		for k, v := range point {
+			values[k] = v
+		}
+	}
+	if values["kuberhealthy.running"] != 1 || values["kuberhealthy.checks"] != 2 || values["kuberhealthy.checks_failing"] != 1 {
+		t.Fatal("expected the running state and check counts but got", values)
+	}
+	if values["kuberhealthy.checks_warning"] != 1 || values["kuberhealthy.cluster_state"] != 0 {
+		t.Fatal("expected the warning count and a failed cluster state but got", values)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khjobv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khjob/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// listPageSize is the number of resources fetched with each paginated LIST call.  Only one page of resources is held
+// in memory at a time while scanning them.
+const listPageSize int64 = 250
+
+// maxStatusPageLimit is the largest page of check and job details the status page serves
+const maxStatusPageLimit = 1000
+
+// forEachKHCheck calls fn with each khcheck in the namespace, fetching them a page at a time
+func forEachKHCheck(client *khcheckv1.KHCheckV1Client, namespace string, fn func(kc khcheckv1.KuberhealthyCheck)) error {
+	opts := metav1.ListOptions{Limit: listPageSize}
+	for {
+		page, err := client.KuberhealthyChecks(namespace).List(opts)
+		if err != nil {
+			return err
+		}
+		for _, kc := range page.Items {
+			fn(kc)
+		}
+		if len(page.Continue) == 0 {
+			return nil
+		}
+		opts.Continue = page.Continue
+	}
+}
+
+// forEachKHState calls fn with each khstate in the namespace, fetching them a page at a time
+func forEachKHState(client *khstatev1.KHStateV1Client, namespace string, fn func(khState khstatev1.KuberhealthyState)) error {
+	opts := metav1.ListOptions{Limit: listPageSize}
+	for {
+		page, err := client.KuberhealthyStates(namespace).List(opts)
+		if err != nil {
+			return err
+		}
+		for _, khState := range page.Items {
+			fn(khState)
+		}
+		if len(page.Continue) == 0 {
+			return nil
+		}
+		opts.Continue = page.Continue
+	}
+}
+
+// forEachKHJob calls fn with each khjob in the namespace, fetching them a page at a time
+func forEachKHJob(client *khjobv1.KHJobV1Client, namespace string, fn func(kj khjobv1.KuberhealthyJob)) error {
+	opts := metav1.ListOptions{Limit: listPageSize}
+	for {
+		page, err := client.KuberhealthyJobs(namespace).List(opts)
+		if err != nil {
+			return err
+		}
+		for _, kj := range page.Items {
+			fn(kj)
+		}
+		if len(page.Continue) == 0 {
+			return nil
+		}
+		opts.Continue = page.Continue
+	}
+}
+
+// statusPage is a page of check and job details requested from the status page with the limit and continue query
+// parameters.  A limit of 0 requests all details.
+type statusPage struct {
+	limit         int
+	continueToken string
+}
+
+// parseStatusPage parses the limit and continue query parameters of a status page request
+func parseStatusPage(values url.Values) (statusPage, error) {
+	var page statusPage
+	limit := values.Get("limit")
+	if len(limit) > 0 {
+		var err error
+		page.limit, err = strconv.Atoi(limit)
+		if err != nil || page.limit < 1 || page.limit > maxStatusPageLimit {
+			return page, errors.New("limit must be a number from 1 to " + strconv.Itoa(maxStatusPageLimit))
+		}
+	}
+	page.continueToken = values.Get("continue")
+	if len(page.continueToken) > 0 && page.limit == 0 {
+		return page, errors.New("continue requires a limit")
+	}
+	_, err := decodeContinueToken(page.continueToken)
+	return page, err
+}
+
+// decodeContinueToken returns the position of the last check or job of the previous page from a continue token.  A
+// blank token returns the position before the first page.
+func decodeContinueToken(token string) (string, error) {
+	if len(token) == 0 {
+		return "", nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || !(strings.HasPrefix(string(b), "c|") || strings.HasPrefix(string(b), "j|")) {
+		return "", errors.New("invalid continue token")
+	}
+	return string(b), nil
+}
+
+// key returns the status cache key of the page
+func (p statusPage) key() string {
+	if p.limit == 0 {
+		return ""
+	}
+	return "?limit=" + strconv.Itoa(p.limit) + "&continue=" + p.continueToken
+}
+
+// statusPageKey returns the position of a check or job in the pages of the status page.  Checks are paged before
+// jobs, each sorted by namespace/name.
+func statusPageKey(workload khstatev1.KHWorkload, name string) string {
+	if workload == khstatev1.KHJob {
+		return "j|" + name
+	}
+	return "c|" + name
+}
+
+// paginateState returns the state with only the check and job details of the requested page, and the continue token
+// of the next page when there are more details.  The global OK state and errors still cover all checks and jobs.
+// Continue tokens are positions rather than offsets, so checks that are added or removed between requests do not
+// shift the following pages.
+func paginateState(state health.State, page statusPage) (health.State, error) {
+	if page.limit == 0 {
+		return state, nil
+	}
+
+	after, err := decodeContinueToken(page.continueToken)
+	if err != nil {
+		return state, err
+	}
+
+	keys := make([]string, 0, len(state.CheckDetails)+len(state.JobDetails))
+	for name := range state.CheckDetails {
+		keys = append(keys, statusPageKey(khstatev1.KHCheck, name))
+	}
+	for name := range state.JobDetails {
+		keys = append(keys, statusPageKey(khstatev1.KHJob, name))
+	}
+	sort.Strings(keys)
+
+	checkDetails := make(map[string]khstatev1.WorkloadDetails)
+	jobDetails := make(map[string]khstatev1.WorkloadDetails)
+	start := sort.SearchStrings(keys, after)
+	if start < len(keys) && keys[start] == after {
+		start++
+	}
+	end := start + page.limit
+	if end > len(keys) {
+		end = len(keys)
+	}
+	for _, key := range keys[start:end] {
+		name := key[2:]
+		if strings.HasPrefix(key, "j|") {
+			jobDetails[name] = state.JobDetails[name]
+			continue
+		}
+		checkDetails[name] = state.CheckDetails[name]
+	}
+
+	state.CheckDetails = checkDetails
+	state.JobDetails = jobDetails
+	state.Continue = ""
+	if end < len(keys) {
+		state.Continue = base64.RawURLEncoding.EncodeToString([]byte(keys[end-1]))
+	}
+	return state, nil
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// khCheckAPIServer serves the supplied number of generated khchecks with paginated LIST calls the way the
+// kubernetes API server does.  It records the largest page it served.
+type khCheckAPIServer struct {
+	*httptest.Server
+	count       int
+	largestPage int64
+}
+
+// newKHCheckAPIServer starts an API server that serves count generated khchecks
+func newKHCheckAPIServer(t testing.TB, count int) *khCheckAPIServer {
+	s := &khCheckAPIServer{count: count}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit == 0 {
+			limit = s.count
+		}
+		start, _ := strconv.Atoi(r.URL.Query().Get("continue"))
+		end := start + limit
+		if end > s.count {
+			end = s.count
+		}
+
+		list := khcheckv1.KuberhealthyCheckList{TypeMeta: metav1.TypeMeta{Kind: "KuberhealthyCheckList", APIVersion: "comcast.github.io/v1"}}
+		for i := start; i < end; i++ {
+			list.Items = append(list.Items, khcheckv1.KuberhealthyCheck{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("check-%05d", i), Namespace: "kuberhealthy"},
+				Spec:       khcheckv1.CheckConfig{RunInterval: "1m", Timeout: "2m"},
+			})
+		}
+		if end < s.count {
+			list.Continue = strconv.Itoa(end)
+		}
+		for {
+			largest := atomic.LoadInt64(&s.largestPage)
+			if int64(len(list.Items)) <= largest || atomic.CompareAndSwapInt64(&s.largestPage, largest, int64(len(list.Items))) {
+				break
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(list)
+		if err != nil {
+			t.Error("failed to encode khcheck list:", err)
+		}
+	}))
+	return s
+}
+
+// client returns a khcheck client for the API server
+func (s *khCheckAPIServer) client(t testing.TB) *khcheckv1.KHCheckV1Client {
+	client, err := khcheckv1.NewForConfig(&rest.Config{Host: s.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// TestForEachKHCheck ensures all khchecks are scanned with LIST calls of at most one page at a time
+func TestForEachKHCheck(t *testing.T) {
+	server := newKHCheckAPIServer(t, int(listPageSize)*2+10)
+	defer server.Close()
+
+	seen := make(map[string]bool)
+	err := forEachKHCheck(server.client(t), "kuberhealthy", func(kc khcheckv1.KuberhealthyCheck) {
+		seen[kc.Namespace+"/"+kc.Name] = true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != server.count {
+		t.Fatal("expected to scan", server.count, "khchecks but scanned", len(seen))
+	}
+	if server.largestPage != listPageSize {
+		t.Fatal("expected pages of at most", listPageSize, "khchecks but the largest page was", server.largestPage)
+	}
+}
+
+// BenchmarkScanKHChecks scans several thousand khchecks for changes the way the khcheck monitor does.  Only one page
+// of khchecks and a fingerprint per khcheck are held, so the heap grows with the fingerprints rather than with full
+// copies of every khcheck.
+func BenchmarkScanKHChecks(b *testing.B) {
+	for _, count := range []int{1000, 5000} {
+		b.Run(strconv.Itoa(count), func(b *testing.B) {
+			server := newKHCheckAPIServer(b, count)
+			defer server.Close()
+			client := server.client(b)
+
+			b.ReportAllocs()
+			var heapGrowth uint64
+			for i := 0; i < b.N; i++ {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+
+				knownSettings := make(map[string]string)
+				err := forEachKHCheck(client, "kuberhealthy", func(kc khcheckv1.KuberhealthyCheck) {
+					knownSettings[kc.Namespace+"/"+kc.Name] = checkConfigFingerprint(kc.Spec)
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				if after.HeapAlloc > before.HeapAlloc {
+					heapGrowth += after.HeapAlloc - before.HeapAlloc
+				}
+				if len(knownSettings) != count {
+					b.Fatal("expected", count, "known settings but got", len(knownSettings))
+				}
+			}
+			b.ReportMetric(float64(heapGrowth)/float64(b.N)/float64(count), "retained-B/check")
+			b.ReportMetric(float64(server.largestPage), "largest-page")
+		})
+	}
+}
+
+// pagedStateFixture returns a state with three checks and two jobs
+func pagedStateFixture() health.State {
+	state := health.NewState()
+	state.OK = false
+	state.Errors = []string{"dns failed"}
+	for _, name := range []string{"kuberhealthy/daemonset", "kuberhealthy/dns", "kuberhealthy/deployment"} {
+		state.CheckDetails[name] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy"}
+	}
+	for _, name := range []string{"kuberhealthy/job-a", "kuberhealthy/job-b"} {
+		state.JobDetails[name] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy"}
+	}
+	return state
+}
+
+// TestPaginateState ensures the status page is paged through checks and then jobs, and that the global state is kept
+// on every page
+func TestPaginateState(t *testing.T) {
+	var pages []health.State
+	page := statusPage{limit: 2}
+	for {
+		state, err := paginateState(pagedStateFixture(), page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, state)
+		if len(state.Continue) == 0 {
+			break
+		}
+		page.continueToken = state.Continue
+	}
+
+	if len(pages) != 3 {
+		t.Fatal("expected 3 pages but got", len(pages))
+	}
+	if _, ok := pages[0].CheckDetails["kuberhealthy/daemonset"]; !ok || len(pages[0].CheckDetails) != 2 {
+		t.Fatal("unexpected first page:", pages[0].CheckDetails)
+	}
+	if len(pages[1].CheckDetails) != 1 || len(pages[1].JobDetails) != 1 {
+		t.Fatal("expected the second page to have the last check and the first job but got", pages[1].CheckDetails, pages[1].JobDetails)
+	}
+	if _, ok := pages[2].JobDetails["kuberhealthy/job-b"]; !ok || len(pages[2].CheckDetails) != 0 {
+		t.Fatal("unexpected last page:", pages[2].CheckDetails, pages[2].JobDetails)
+	}
+	for _, p := range pages {
+		if p.OK || len(p.Errors) != 1 {
+			t.Fatal("expected the global state on every page but got", p.OK, p.Errors)
+		}
+	}
+
+	// without a limit, the full state is returned
+	state, err := paginateState(pagedStateFixture(), statusPage{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.CheckDetails) != 3 || len(state.JobDetails) != 2 || len(state.Continue) != 0 {
+		t.Fatal("expected the full state without a limit")
+	}
+
+	_, err = paginateState(pagedStateFixture(), statusPage{limit: 2, continueToken: "bogus"})
+	if err == nil {
+		t.Fatal("expected an error for an invalid continue token")
+	}
+}
+
+// TestParseStatusPage ensures the limit and continue query parameters are validated
+func TestParseStatusPage(t *testing.T) {
+	tests := map[string]bool{
+		"":          true,
+		"limit=100": true,
+		"limit=100&continue=Y3xrdWJlcmhlYWx0aHkvZG5z": true,
+		"limit=100&continue=abc":                      false,
+		"limit=0":                                     false,
+		"limit=5000":                                  false,
+		"limit=ten":                                   false,
+		"continue=abc":                                false,
+	}
+	for query, valid := range tests {
+		values, err := url.ParseQuery(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = parseStatusPage(values)
+		if (err == nil) != valid {
+			t.Fatal("expected query", query, "to be valid:", valid, "but got", err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// pausedAnnotation pauses a khcheck like its paused field when set to true, so a check can be paused with kubectl
+// annotate without editing its spec
+const pausedAnnotation = "comcast.github.io/paused"
+
+// checkPaused determines if a khcheck is paused by its paused field or its paused annotation
+func checkPaused(kc khcheckv1.KuberhealthyCheck) bool {
+	if kc.Spec.Paused {
+		return true
+	}
+	value, ok := kc.Annotations[pausedAnnotation]
+	if !ok {
+		return false
+	}
+	paused, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Warningln("Ignoring the", pausedAnnotation, "annotation of khcheck", kc.Namespace+"/"+kc.Name, "because", value, "is not true or false")
+		return false
+	}
+	return paused
+}
+
+// setCheckPausedState marks the khstate of a check as paused or clears the mark once the check is resumed.  The
+// khstate is only written by the kuberhealthy pod that runs the check, and only when the mark changes.  The khstate of
+// a paused check that never ran is created, so that it shows on the status page as paused.
+func (k *Kuberhealthy) setCheckPausedState(kc khcheckv1.KuberhealthyCheck, paused bool) {
+	if k.stateReflector == nil || checkRunner(kc.Namespace+"/"+kc.Name) != podHostname {
+		return
+	}
+	details, exists := k.stateReflector.WorkloadDetails(kc.Name, kc.Namespace)
+	if details.Paused == paused && (exists || !paused) {
+		return
+	}
+
+	log.Infoln("Setting paused state of check", kc.Name, "in namespace", kc.Namespace, "to", paused)
+	err := setKHStatePaused(kc.Name, kc.Namespace, paused)
+	if err != nil {
+		log.Errorln("Error storing the paused state of check", kc.Name, "in namespace", kc.Namespace+":", err)
+		return
+	}
+	k.statusCache.invalidate()
+}
+
+// setKHStatePaused sets the paused mark of a khstate, creating the khstate if it does not exist yet.  The rest of the
+// khstate is left as the last run stored it.
+func setKHStatePaused(checkName string, checkNamespace string, paused bool) error {
+	err := ensureStateResourceExists(checkName, checkNamespace, khstatev1.KHCheck)
+	if err != nil {
+		return err
+	}
+
+	// a run may update the khstate at the same time, so we retry on conflicts
+	name := sanitizeResourceName(checkName)
+	for tries := 0; tries < 5; tries++ {
+		var khState khstatev1.KuberhealthyState
+		khState, err = khStateClient.KuberhealthyStates(checkNamespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return errors.New("Error retrieving CRD for: " + name + " " + err.Error())
+		}
+		if len(khState.Spec.Namespace) == 0 {
+			khState.Spec.Namespace = checkNamespace
+		}
+		khState.Spec.Paused = paused
+		khState, err = khStateClient.KuberhealthyStates(checkNamespace).Update(&khState)
+		if err == nil {
+			err = setKHStateConditions(checkNamespace, khState)
+		}
+		if err == nil || !strings.Contains(err.Error(), "the object has been modified") {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+	return err
+}
+
+// applyPausedChecks lists the paused checks of the state and recalculates its global OK state and errors without
+// them, so the last result of a paused check does not fail the status page
+func applyPausedChecks(state health.State) health.State {
+	for name, details := range state.CheckDetails {
+		if details.Paused {
+			state.PausedChecks = append(state.PausedChecks, name)
+		}
+	}
+	if len(state.PausedChecks) == 0 {
+		return state
+	}
+
+	// rebuild the global state from the details of checks and jobs that are not paused
+	return rebuildGlobalState(state)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+func TestCheckPaused(t *testing.T) {
+	tests := []struct {
+		name        string
+		paused      bool
+		annotations map[string]string
+		expected    bool
+	}{
+		{name: "running"},
+		{name: "paused field", paused: true, expected: true},
+		{name: "paused annotation", annotations: map[string]string{pausedAnnotation: "true"}, expected: true},
+		{name: "resumed annotation", annotations: map[string]string{pausedAnnotation: "false"}},
+		{name: "field over annotation", paused: true, annotations: map[string]string{pausedAnnotation: "false"}, expected: true},
+		{name: "invalid annotation", annotations: map[string]string{pausedAnnotation: "yes please"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			kc := khcheckv1.KuberhealthyCheck{
+				ObjectMeta: metav1.ObjectMeta{Name: "daemonset", Namespace: "kuberhealthy", Annotations: test.annotations},
+				Spec:       khcheckv1.CheckConfig{Paused: test.paused},
+			}
+			if checkPaused(kc) != test.expected {
+				t.Fatal("expected the check to be paused:", test.expected)
+			}
+		})
+	}
+}
+
+// TestApplyPausedChecks ensures the last result of a paused check does not fail the status page and that paused
+// checks are not reported as stale
+func TestApplyPausedChecks(t *testing.T) {
+	beforeStartup := metav1.NewTime(startupTime.Add(-time.Minute))
+	afterStartup := metav1.NewTime(startupTime.Add(time.Minute))
+	state := health.NewState()
+	state.CheckDetails["kuberhealthy/daemonset"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", Errors: []string{"daemonset failed"}, ErrorCount: 1, LastRun: &beforeStartup, Paused: true}
+	state.CheckDetails["kuberhealthy/dns"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", OK: true, Errors: []string{}, LastRun: &afterStartup}
+	state.JobDetails["kuberhealthy/job"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", Errors: []string{"job failed"}, ErrorCount: 1}
+	state.OK = false
+	state.Errors = []string{"daemonset failed", "job failed"}
+
+	state = applyStartupGracePeriod(state, func(name string) time.Duration { return time.Minute * 10 }, startupTime.Add(time.Minute))
+	if state.CheckDetails["kuberhealthy/daemonset"].Stale || len(state.StaleChecks) != 0 {
+		t.Fatal("expected the paused check to not be stale but got", state.StaleChecks)
+	}
+
+	state = applyPausedChecks(state)
+	if len(state.PausedChecks) != 1 || state.PausedChecks[0] != "kuberhealthy/daemonset" {
+		t.Fatal("unexpected paused checks:", state.PausedChecks)
+	}
+	if state.OK || len(state.Errors) != 1 || state.Errors[0] != "job failed" {
+		t.Fatal("expected only the errors of the job in the global state but got", state.Errors)
+	}
+	if len(state.CheckDetails["kuberhealthy/daemonset"].Errors) != 1 {
+		t.Fatal("expected the paused check to keep the errors of its last run")
+	}
+	if dashboardStatus(state.CheckDetails["kuberhealthy/daemonset"]) != "Paused" {
+		t.Fatal("expected the paused check to show as paused on the dashboard")
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// healthzPath is the liveness probe of Kuberhealthy itself.  It succeeds for as long as the web server serves it.
+const healthzPath = "/healthz"
+
+// readyzPath is the readiness probe of Kuberhealthy itself.  It reflects whether Kuberhealthy can reach the Kubernetes
+// API and is serving, not whether checks are failing.
+const readyzPath = "/readyz"
+
+// readinessProbeTimeout is how long the readiness probe waits for the Kubernetes API
+const readinessProbeTimeout = time.Second * 5
+
+// readinessCacheTTL is how long the result of reaching the Kubernetes API is reused, so that frequent probes and
+// several probing kubelets do not each make a request to the API server
+var readinessCacheTTL = time.Second * 5
+
+// readinessProbe checks whether the Kubernetes API can be reached and caches the result for a short while
+type readinessProbe struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	checkAPI  func(ctx context.Context) error // reaches the Kubernetes API. returns an error when it can not be reached
+	checkedAt time.Time                       // when the Kubernetes API was last reached
+	lastErr   error                           // the result of reaching the Kubernetes API at checkedAt
+}
+
+// newReadinessProbe creates a readiness probe that reaches the Kubernetes API with checkAPI and reuses the result for
+// the ttl
+func newReadinessProbe(checkAPI func(ctx context.Context) error, ttl time.Duration) *readinessProbe {
+	return &readinessProbe{checkAPI: checkAPI, ttl: ttl}
+}
+
+// apiReachable returns an error when the Kubernetes API could not be reached, reusing the last result when it is
+// recent enough
+func (p *readinessProbe) apiReachable(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.checkedAt.IsZero() && time.Since(p.checkedAt) < p.ttl {
+		return p.lastErr
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessProbeTimeout)
+	defer cancel()
+	p.lastErr = p.checkAPI(ctx)
+	p.checkedAt = time.Now()
+	return p.lastErr
+}
+
+// kubernetesAPIReachable requests the version of the Kubernetes API server, which every client that can authenticate
+// is allowed to read
+func kubernetesAPIReachable(ctx context.Context) error {
+	if kubernetesClient == nil {
+		return errors.New("no kubernetes client is configured")
+	}
+	return kubernetesClient.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error()
+}
+
+// setShuttingDown marks Kuberhealthy as shutting down, which makes the readiness probe fail so that no more traffic is
+// sent to it
+func (k *Kuberhealthy) setShuttingDown() {
+	atomic.StoreInt32(&k.shuttingDown, 1)
+}
+
+// isShuttingDown returns true once Kuberhealthy started to shut down
+func (k *Kuberhealthy) isShuttingDown() bool {
+	return atomic.LoadInt32(&k.shuttingDown) == 1
+}
+
+// healthzHandler serves the liveness probe.  Answering at all shows that the web server is up, so it always succeeds.
+func (k *Kuberhealthy) healthzHandler(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, err := fmt.Fprintln(w, "ok")
+	return err
+}
+
+// readyzHandler serves the readiness probe.  Kuberhealthy is ready when it is not shutting down, its web server is
+// running and the Kubernetes API can be reached.  Failing checks do not make Kuberhealthy unready.
+func (k *Kuberhealthy) readyzHandler(w http.ResponseWriter, r *http.Request) error {
+	err := k.readiness(r.Context())
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err != nil {
+		log.Warningln("Readiness probe failed:", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, writeErr := fmt.Fprintln(w, "not ready:", err)
+		return writeErr
+	}
+	w.WriteHeader(http.StatusOK)
+	_, err = fmt.Fprintln(w, "ok")
+	return err
+}
+
+// readiness returns the reason Kuberhealthy is not ready, or nil when it is
+func (k *Kuberhealthy) readiness(ctx context.Context) error {
+	if k.isShuttingDown() {
+		return errors.New("shutting down")
+	}
+
+	k.webServerMu.Lock()
+	serving := k.webServer != nil
+	k.webServerMu.Unlock()
+	if !serving {
+		return errors.New("the web server is not running")
+	}
+
+	if k.readinessProbe == nil {
+		return nil
+	}
+	err := k.readinessProbe.apiReachable(ctx)
+	if err != nil {
+		return fmt.Errorf("the kubernetes API can not be reached: %w", err)
+	}
+	return nil
+}
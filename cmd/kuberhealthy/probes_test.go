@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadinessProbeCachesAPIResult(t *testing.T) {
+	calls := 0
+	apiErr := errors.New("connection refused")
+	probe := newReadinessProbe(func(ctx context.Context) error {
+		calls++
+		return apiErr
+	}, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		err := probe.apiReachable(context.Background())
+		if !errors.Is(err, apiErr) {
+			t.Fatal("expected the error of the Kubernetes API but got", err)
+		}
+	}
+	if calls != 1 {
+		t.Fatal("expected the Kubernetes API to be reached once within the ttl but it was reached", calls, "times")
+	}
+
+	probe.ttl = 0
+	apiErr = nil
+	err := probe.apiReachable(context.Background())
+	if err != nil || calls != 2 {
+		t.Fatal("expected the Kubernetes API to be reached again after the ttl but got", err, calls)
+	}
+}
+
+func TestProbeHandlers(t *testing.T) {
+	var apiErr error
+	kh := &Kuberhealthy{webServer: &http.Server{}}
+	kh.readinessProbe = newReadinessProbe(func(ctx context.Context) error { return apiErr }, 0)
+
+	probe := func(handler func(w http.ResponseWriter, r *http.Request) error, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		err := handler(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rec
+	}
+
+	if rec := probe(kh.healthzHandler, healthzPath); rec.Code != http.StatusOK {
+		t.Fatal("expected the liveness probe to succeed but got", rec.Code)
+	}
+	if rec := probe(kh.readyzHandler, readyzPath); rec.Code != http.StatusOK {
+		t.Fatal("expected the readiness probe to succeed but got", rec.Code)
+	}
+
+	apiErr = errors.New("connection refused")
+	rec := probe(kh.readyzHandler, readyzPath)
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "connection refused") {
+		t.Fatal("expected the readiness probe to fail when the Kubernetes API can not be reached but got", rec.Code, rec.Body.String())
+	}
+	if rec := probe(kh.healthzHandler, healthzPath); rec.Code != http.StatusOK {
+		t.Fatal("expected the liveness probe to succeed when the Kubernetes API can not be reached but got", rec.Code)
+	}
+
+	apiErr = nil
+	kh.setShuttingDown()
+	rec = probe(kh.readyzHandler, readyzPath)
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), "shutting down") {
+		t.Fatal("expected the readiness probe to fail while shutting down but got", rec.Code, rec.Body.String())
+	}
+
+	kh = &Kuberhealthy{}
+	if rec := probe(kh.readyzHandler, readyzPath); rec.Code != http.StatusServiceUnavailable {
+		t.Fatal("expected the readiness probe to fail before the web server runs but got", rec.Code)
+	}
+}
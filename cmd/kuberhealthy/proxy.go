@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// httpProxy, httpsProxy and noProxy set the proxy that outbound integrations, such as InfluxDB, webhooks and
+// Alertmanager, reach their endpoints through.  Each one that is blank falls back to the HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY environment variables.  Requests to the Kubernetes API and to other Kuberhealthy pods do not use them.
+var httpProxy string
+var httpsProxy string
+var noProxy string
+
+// validateProxyFlags ensures the proxy flags are proxy URLs
+func validateProxyFlags() error {
+	for flag, proxy := range map[string]string{"httpProxy": httpProxy, "httpsProxy": httpsProxy} {
+		if len(proxy) == 0 {
+			continue
+		}
+		u, err := url.Parse(proxy)
+		if err != nil || len(u.Host) == 0 {
+			return fmt.Errorf("%s %q must be a proxy URL such as http://proxy.example.com:3128", flag, proxy)
+		}
+	}
+	return nil
+}
+
+// outboundProxyConfig returns the proxy configuration of the proxy flags, with the proxy environment variables for
+// the flags that are not set
+func outboundProxyConfig() *httpproxy.Config {
+	c := httpproxy.FromEnvironment()
+	if len(httpProxy) > 0 {
+		c.HTTPProxy = httpProxy
+	}
+	if len(httpsProxy) > 0 {
+		c.HTTPSProxy = httpsProxy
+	}
+	if len(noProxy) > 0 {
+		c.NoProxy = noProxy
+	}
+	return c
+}
+
+// outboundProxy returns the proxy function of the HTTP clients of outbound integrations
+func outboundProxy() func(*http.Request) (*url.URL, error) {
+	proxyFunc := outboundProxyConfig().ProxyFunc()
+	return func(r *http.Request) (*url.URL, error) {
+		return proxyFunc(r.URL)
+	}
+}
+
+// outboundTransport returns a transport for the HTTP clients of outbound integrations that sends requests through
+// the outbound proxy
+func outboundTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = outboundProxy()
+	return transport
+}
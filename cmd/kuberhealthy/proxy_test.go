@@ -0,0 +1,59 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestOutboundProxy(t *testing.T) {
+	previousHTTP, previousHTTPS, previousNo := httpProxy, httpsProxy, noProxy
+	defer func() { httpProxy, httpsProxy, noProxy = previousHTTP, previousHTTPS, previousNo }()
+	t.Setenv("HTTP_PROXY", "http://env-proxy:3128")
+	t.Setenv("HTTPS_PROXY", "http://env-proxy:3128")
+	t.Setenv("NO_PROXY", "")
+
+	// the proxy environment variables are used without the flags
+	httpProxy, httpsProxy, noProxy = "", "", ""
+	proxy := outboundProxy()
+	r, _ := http.NewRequest(http.MethodPost, "http://influxdb.example.com:8086/write", nil)
+	u, err := proxy(r)
+	if err != nil || u == nil || u.Host != "env-proxy:3128" {
+		t.Fatal("expected the proxy of the environment but got", u, err)
+	}
+
+	// the flags take precedence over the environment
+	httpsProxy, noProxy = "http://flag-proxy:8080", ".internal"
+	proxy = outboundProxy()
+	r, _ = http.NewRequest(http.MethodPost, "https://hooks.slack.com/services/x", nil)
+	u, err = proxy(r)
+	if err != nil || u == nil || u.Host != "flag-proxy:8080" {
+		t.Fatal("expected the proxy of the httpsProxy flag but got", u, err)
+	}
+	r, _ = http.NewRequest(http.MethodPost, "https://alertmanager.monitoring.internal/api/v2/alerts", nil)
+	u, err = proxy(r)
+	if err != nil || u != nil {
+		t.Fatal("expected hosts in noProxy to be reached without the proxy but got", u, err)
+	}
+}
+
+func TestValidateProxyFlags(t *testing.T) {
+	previousHTTP, previousHTTPS := httpProxy, httpsProxy
+	defer func() { httpProxy, httpsProxy = previousHTTP, previousHTTPS }()
+
+	for _, tc := range []struct {
+		httpProxy  string
+		httpsProxy string
+		valid      bool
+	}{
+		{"", "", true},
+		{"http://proxy:3128", "http://proxy:3128", true},
+		{"proxy:3128", "", false},
+		{"", "://proxy", false},
+	} {
+		httpProxy, httpsProxy = tc.httpProxy, tc.httpsProxy
+		err := validateProxyFlags()
+		if (err == nil) != tc.valid {
+			t.Fatal("expected httpProxy", tc.httpProxy, "and httpsProxy", tc.httpsProxy, "to be valid:", tc.valid, "but got", err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+
+	log "github.com/sirupsen/logrus"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/metrics"
+)
+
+// pushgatewayURL is the Prometheus Pushgateway that check results are pushed to.  Pushing is disabled when blank.
+var pushgatewayURL string
+
+// pushgatewayJob is the job grouping label of the pushed metrics
+var pushgatewayJob = metrics.DefaultPushgatewayJob
+
+// pushgatewayHeadersFlag holds comma separated key=value headers sent with every request to the Pushgateway
+var pushgatewayHeadersFlag string
+var pushgatewayHeaders map[string]string
+
+// pushgatewayLabelsFlag holds comma separated key=value grouping labels added to every pushed group
+var pushgatewayLabelsFlag string
+var pushgatewayLabels map[string]string
+
+// parsePushgatewayFlags parses the pushgatewayHeaders and pushgatewayLabels flags
+func parsePushgatewayFlags() error {
+	var err error
+	pushgatewayHeaders, err = parseKeyValueFlag(pushgatewayHeadersFlag)
+	if err != nil {
+		return fmt.Errorf("unable to parse pushgatewayHeaders flag: %w", err)
+	}
+	pushgatewayLabels, err = parseKeyValueFlag(pushgatewayLabelsFlag)
+	if err != nil {
+		return fmt.Errorf("unable to parse pushgatewayLabels flag: %w", err)
+	}
+	return nil
+}
+
+// newPushgatewayClient creates the client check results are pushed to the Pushgateway with.  nil is returned when
+// pushing is disabled.
+func newPushgatewayClient() (*metrics.PushgatewayClient, error) {
+	if len(pushgatewayURL) == 0 {
+		return nil, nil
+	}
+	u, err := url.Parse(pushgatewayURL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse pushgatewayURL flag: %w", err)
+	}
+	log.Infoln("pushgateway: pushing check results to", pushgatewayURL)
+	return metrics.NewPushgatewayClient(metrics.PushgatewayConfig{
+		URL:     *u,
+		Job:     pushgatewayJob,
+		Headers: pushgatewayHeaders,
+		Proxy:   outboundProxy(),
+	})
+}
+
+// pushgatewayGrouping returns the grouping labels of the metrics of a workload.  Each workload has a group of its
+// own, so that it can be deleted along with the workload.  The check and namespace labels match those of the metrics
+// themselves.
+func pushgatewayGrouping(name string, namespace string) map[string]string {
+	grouping := make(map[string]string)
+	if len(clusterName) > 0 {
+		grouping["cluster"] = clusterName
+	}
+	for k, v := range pushgatewayLabels {
+		grouping[k] = v
+	}
+	grouping["namespace"] = namespace
+	grouping["check"] = namespace + "/" + name
+	return grouping
+}
+
+// pushWorkloadMetrics replaces the metrics of a workload on the Pushgateway with those of its latest run
+func (k *Kuberhealthy) pushWorkloadMetrics(name string, namespace string, details khstatev1.WorkloadDetails) {
+	if k.pushgateway == nil {
+		return
+	}
+	details.Namespace = namespace
+	m := metrics.GenerateWorkloadMetrics(details.GetKHWorkload(), namespace+"/"+name, details, cfg.PromMetricsConfig)
+	err := k.pushgateway.Push(pushgatewayGrouping(name, namespace), m)
+	if err != nil {
+		log.Errorln("pushgateway: error pushing metrics of", namespace+"/"+name+":", err)
+	}
+}
+
+// deleteWorkloadMetrics removes the metrics of a workload that no longer exists from the Pushgateway
+func (k *Kuberhealthy) deleteWorkloadMetrics(name string, namespace string) {
+	if k.pushgateway == nil {
+		return
+	}
+	log.Infoln("pushgateway: deleting metrics of", namespace+"/"+name)
+	err := k.pushgateway.Delete(pushgatewayGrouping(name, namespace))
+	if err != nil {
+		log.Errorln("pushgateway: error deleting metrics of", namespace+"/"+name+":", err)
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPushgatewayGrouping(t *testing.T) {
+	previousCluster, previousLabels := clusterName, pushgatewayLabels
+	defer func() { clusterName, pushgatewayLabels = previousCluster, previousLabels }()
+	clusterName = "prod-east"
+	pushgatewayLabels = map[string]string{"env": "prod", "check": "overridden"}
+
+	expected := map[string]string{"cluster": "prod-east", "env": "prod", "namespace": "kuberhealthy", "check": "kuberhealthy/dns-status"}
+	grouping := pushgatewayGrouping("dns-status", "kuberhealthy")
+	if !reflect.DeepEqual(grouping, expected) {
+		t.Fatal("expected the grouping labels of the check to take precedence but got", grouping)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// kubeAPIQPS is the most requests per second the kubernetes clients of Kuberhealthy make to the API server together,
+// once their burst is used up
+var kubeAPIQPS float64 = 20
+
+// kubeAPIBurst is the most requests the kubernetes clients of Kuberhealthy make to the API server at once before
+// they are throttled to kubeAPIQPS
+var kubeAPIBurst = 40
+
+// minThrottleWait is how long a request must wait for the rate limiter to count as throttled.  Shorter waits are
+// the rate limiter handing out a token it had to hand.
+const minThrottleWait = time.Millisecond
+
+// kubeAPIThrottle records the requests of all the kubernetes clients of Kuberhealthy that were throttled by the client
+// side rate limiter
+var kubeAPIThrottle = &throttleTracker{}
+
+// validateKubeAPIRateLimitFlags ensures the rate limit of the kubernetes clients is usable
+func validateKubeAPIRateLimitFlags() error {
+	if kubeAPIQPS <= 0 {
+		return errors.New("kubeAPIQPS must be positive")
+	}
+	if kubeAPIBurst < 1 {
+		return errors.New("kubeAPIBurst must be at least 1")
+	}
+	return nil
+}
+
+// throttleTracker counts the requests that waited on the client side rate limiter and how long they waited
+type throttleTracker struct {
+	throttled int64
+	waited    int64 // nanoseconds
+}
+
+// record records a request that waited on the rate limiter for the duration
+func (t *throttleTracker) record(wait time.Duration) {
+	if wait < minThrottleWait {
+		return
+	}
+	atomic.AddInt64(&t.throttled, 1)
+	atomic.AddInt64(&t.waited, int64(wait))
+}
+
+// stats returns the number of throttled requests and the total time they waited
+func (t *throttleTracker) stats() (int64, time.Duration) {
+	return atomic.LoadInt64(&t.throttled), time.Duration(atomic.LoadInt64(&t.waited))
+}
+
+// newKubeAPIRateLimiter creates the rate limiter shared by all the kubernetes clients of Kuberhealthy.  The time
+// requests wait on it is recorded with the tracker.
+func newKubeAPIRateLimiter(qps float64, burst int, tracker *throttleTracker) flowcontrol.RateLimiter {
+	return &throttleRecordingRateLimiter{
+		RateLimiter: flowcontrol.NewTokenBucketRateLimiter(float32(qps), burst),
+		tracker:     tracker,
+	}
+}
+
+// throttleRecordingRateLimiter is a rate limiter that records how long requests wait on it
+type throttleRecordingRateLimiter struct {
+	flowcontrol.RateLimiter
+	tracker *throttleTracker
+}
+
+// Accept blocks until a request may be made and records how long it waited
+func (r *throttleRecordingRateLimiter) Accept() {
+	start := time.Now()
+	r.RateLimiter.Accept()
+	r.tracker.record(time.Since(start))
+}
+
+// Wait blocks until a request may be made or the context is canceled and records how long it waited
+func (r *throttleRecordingRateLimiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := r.RateLimiter.Wait(ctx)
+	r.tracker.record(time.Since(start))
+	return err
+}
+
+// kubeAPIThrottleMetrics returns the client side throttling of the kubernetes clients in the prometheus format
+func kubeAPIThrottleMetrics() string {
+	throttled, waited := kubeAPIThrottle.stats()
+	var b strings.Builder
+	b.WriteString("# HELP kuberhealthy_apiserver_client_throttled_requests_total The number of API server requests delayed by the client side rate limit of Kuberhealthy\n")
+	b.WriteString("# TYPE kuberhealthy_apiserver_client_throttled_requests_total counter\n")
+	b.WriteString(fmt.Sprintf("kuberhealthy_apiserver_client_throttled_requests_total %d\n", throttled))
+	b.WriteString("# HELP kuberhealthy_apiserver_client_throttle_wait_seconds_total The time API server requests waited on the client side rate limit of Kuberhealthy\n")
+	b.WriteString("# TYPE kuberhealthy_apiserver_client_throttle_wait_seconds_total counter\n")
+	b.WriteString(fmt.Sprintf("kuberhealthy_apiserver_client_throttle_wait_seconds_total %g\n", waited.Seconds()))
+	b.WriteString("# HELP kuberhealthy_apiserver_client_qps The requests per second the client side rate limit of Kuberhealthy allows\n")
+	b.WriteString("# TYPE kuberhealthy_apiserver_client_qps gauge\n")
+	b.WriteString(fmt.Sprintf("kuberhealthy_apiserver_client_qps %g\n", kubeAPIQPS))
+	b.WriteString("# HELP kuberhealthy_apiserver_client_burst The requests the client side rate limit of Kuberhealthy allows at once\n")
+	b.WriteString("# TYPE kuberhealthy_apiserver_client_burst gauge\n")
+	b.WriteString(fmt.Sprintf("kuberhealthy_apiserver_client_burst %d\n", kubeAPIBurst))
+	return b.String()
+}
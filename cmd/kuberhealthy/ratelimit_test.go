@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKubeAPIRateLimiter(t *testing.T) {
+	tracker := &throttleTracker{}
+	limiter := newKubeAPIRateLimiter(50, 2, tracker)
+	defer limiter.Stop()
+
+	// the burst is handed out without waiting
+	limiter.Accept()
+	err := limiter.Wait(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if throttled, _ := tracker.stats(); throttled != 0 {
+		t.Fatal("expected requests within the burst to not be throttled but got", throttled)
+	}
+
+	// requests beyond the burst wait for the next token
+	limiter.Accept()
+	err = limiter.Wait(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	throttled, waited := tracker.stats()
+	if throttled != 2 {
+		t.Fatal("expected the requests beyond the burst to be throttled but got", throttled)
+	}
+	if waited < time.Millisecond*20 {
+		t.Fatal("expected the throttled requests to wait for their tokens but they waited", waited)
+	}
+
+	metrics := kubeAPIThrottleMetrics()
+	if !strings.Contains(metrics, "kuberhealthy_apiserver_client_throttled_requests_total ") || !strings.Contains(metrics, "kuberhealthy_apiserver_client_burst 40\n") {
+		t.Fatal("expected the throttling metrics but got", metrics)
+	}
+}
+
+func TestValidateKubeAPIRateLimitFlags(t *testing.T) {
+	previousQPS, previousBurst := kubeAPIQPS, kubeAPIBurst
+	defer func() { kubeAPIQPS, kubeAPIBurst = previousQPS, previousBurst }()
+
+	for _, tc := range []struct {
+		qps   float64
+		burst int
+		valid bool
+	}{
+		{20, 40, true},
+		{0.5, 1, true},
+		{0, 40, false},
+		{20, 0, false},
+	} {
+		kubeAPIQPS, kubeAPIBurst = tc.qps, tc.burst
+		err := validateKubeAPIRateLimitFlags()
+		if (err == nil) != tc.valid {
+			t.Fatal("expected kubeAPIQPS", tc.qps, "and kubeAPIBurst", tc.burst, "to be valid:", tc.valid, "but got", err)
+		}
+	}
+}
@@ -50,9 +50,9 @@ func parseDurationOrUseDefault(d string, defaultDuration time.Duration) (time.Du
 
 }
 
-// reaper runs until the supplied context expires and reaps khjobs and khchecks.  To target all
-// namespace, set the namespace string to ""
-func reaper(ctx context.Context, namespace string) {
+// reaper runs until the supplied context expires and reaps khjobs and khchecks in the namespaces.  To target all
+// namespaces, pass a single blank namespace
+func reaper(ctx context.Context, namespaces []string) {
 
 	reaperRunInterval, err := parseDurationOrUseDefault(checkReaperRunInterval, checkReaperRunIntervalDefault)
 	if err != nil {
@@ -93,9 +93,11 @@ func reaper(ctx context.Context, namespace string) {
 		runCtx, runCtxCancel := context.WithTimeout(ctx, time.Minute*3)
 		defer runCtxCancel()
 
-		// run our check and job reapers
-		runCheckReap(runCtx, namespace)
-		runJobReap(runCtx, namespace)
+		// run our check and job reapers in each namespace
+		for _, namespace := range namespaces {
+			runCheckReap(runCtx, namespace)
+			runJobReap(runCtx, namespace)
+		}
 
 		// check if the parent context has expired
 		select {
@@ -137,14 +139,9 @@ func runCheckReap(ctx context.Context, namespace string) {
 
 // runJobReap runs a process to reap jobs that need deleted (those that were created by a khjob)
 func runJobReap(ctx context.Context, namespace string) {
-	jobClient, err := khjobv1.Client(cfg.kubeConfigFile)
-	if err != nil {
-		log.Errorln("checkReaper: Unable to create khJob client", err)
-	}
-
 	log.Infoln("checkReaper: Beginning to search for khjobs.")
 	// fetch and delete khjobs that meet criteria
-	err = khJobDelete(jobClient, namespace)
+	err := khJobDelete(khJobClient, namespace)
 	if err != nil {
 		log.Errorln("checkReaper: Failed to reap khjobs with error: ", err)
 	}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"strings"
+	"sync"
 	"time"
 
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -19,38 +20,236 @@ import (
 // state of checks is requested, the CurrentStatus func can serve it rapidly from cache.  Needs to run in the
 // background and can be stopped/started by simply calling `Stop()` on it.
 type StateReflector struct {
-	reflector        *cache.Reflector
-	reflectorSigChan chan struct{} // the channel that indicates when the cache sync should stop
-	resyncPeriod     time.Duration // the period for full API re-syncs
+	reflectors       []*cache.Reflector // one reflector for each namespace, all filling the same store
+	reflectorSigChan chan struct{}      // the channel that indicates when the cache sync should stop
+	resyncPeriod     time.Duration      // the period for full API re-syncs
 	store            cache.Store
+	onChange         func(previous *khstatev1.KuberhealthyState, current *khstatev1.KuberhealthyState) // called with each khstate change observed by the reflectors. set before Start
+	workloads        map[string]khstatev1.KHWorkload                                                   // the workload of each khstate by namespace/name, so that it is only looked up once
+	workloadsMu      sync.Mutex                                                                        // guards workloads
+	lookupWorkload   func(name string, namespace string) khstatev1.KHWorkload                          // looks up the workload of a khstate from the API. determineKHWorkload when nil
 }
 
-// NewStateReflector creates a new StateReflector for watching the state of khstate resources on the server
-func NewStateReflector(namespace string) *StateReflector {
+// NewStateReflector creates a new StateReflector for watching the state of khstate resources in the namespaces on the
+// server.  To include all namespaces, pass a single blank namespace.
+func NewStateReflector(namespaces []string) *StateReflector {
 	sr := StateReflector{}
 	sr.reflectorSigChan = make(chan struct{})
 	sr.resyncPeriod = time.Minute * 5
 
-	// structure the reflector and its required elements
-	khStateListWatch := cache.NewListWatchFromClient(khStateClient.RESTClient(), stateCRDResource, namespace, fields.Everything())
+	// structure the reflectors and their required elements
 	sr.store = cache.NewStore(cache.MetaNamespaceKeyFunc)
-	sr.reflector = cache.NewReflector(khStateListWatch, &khstatev1.KuberhealthyState{}, sr.store, sr.resyncPeriod)
+	changes := changeStore{Store: sr.store, notify: sr.notifyChange}
+	for _, namespace := range namespaces {
+		khStateListWatch := cache.NewListWatchFromClient(khStateClient.RESTClient(), stateCRDResource, namespace, fields.Everything())
+		var store cache.Store = changes
+		if len(namespaces) > 1 {
+			store = namespaceStore{Store: changes, namespace: namespace}
+		}
+		sr.reflectors = append(sr.reflectors, cache.NewReflector(khStateListWatch, &khstatev1.KuberhealthyState{}, store, sr.resyncPeriod))
+	}
 
 	return &sr
 }
 
-// Stop halts cache sync operations.  this is async and we don't know exactly when the sync worker fully stops
+// Stop halts cache sync operations.  this is async and we don't know exactly when the sync workers fully stop
 func (sr *StateReflector) Stop() {
 	log.Infoln("khState reflector stopping")
 	if sr.reflectorSigChan != nil {
-		sr.reflectorSigChan <- struct{}{}
+		close(sr.reflectorSigChan)
 	}
 }
 
 // Start begins the store and resync operations in the background
 func (sr *StateReflector) Start() {
 	log.Infoln("khState reflector starting")
-	sr.reflector.Run(sr.reflectorSigChan)
+	var wg sync.WaitGroup
+	for _, reflector := range sr.reflectors {
+		wg.Add(1)
+		go func(reflector *cache.Reflector) {
+			defer wg.Done()
+			reflector.Run(sr.reflectorSigChan)
+		}(reflector)
+	}
+	wg.Wait()
+}
+
+// namespaceStore is the part of a shared store that holds the objects of one namespace, so that the reflectors of
+// several namespaces can fill the same store without replacing the objects of each other
+type namespaceStore struct {
+	cache.Store
+	namespace string
+}
+
+// Replace replaces the objects of the namespace in the shared store with the supplied list
+func (ns namespaceStore) Replace(list []interface{}, resourceVersion string) error {
+	listed := make(map[string]bool, len(list))
+	for _, obj := range list {
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return err
+		}
+		listed[key] = true
+		err = ns.Store.Update(obj)
+		if err != nil {
+			return err
+		}
+	}
+	for _, key := range ns.Store.ListKeys() {
+		if listed[key] || !strings.HasPrefix(key, ns.namespace+"/") {
+			continue
+		}
+		obj, exists, err := ns.Store.GetByKey(key)
+		if err != nil || !exists {
+			continue
+		}
+		err = ns.Store.Delete(obj)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// notifyChange passes a khstate change to the onChange func of the reflector, if any.  The workload of a deleted
+// khstate is forgotten.
+func (sr *StateReflector) notifyChange(previous *khstatev1.KuberhealthyState, current *khstatev1.KuberhealthyState) {
+	if current == nil && previous != nil {
+		sr.workloadsMu.Lock()
+		delete(sr.workloads, previous.GetNamespace()+"/"+previous.GetName())
+		sr.workloadsMu.Unlock()
+	}
+	if sr.onChange != nil {
+		sr.onChange(previous, current)
+	}
+}
+
+// changeStore notifies about each khstate added, updated or deleted in the store it wraps, along with the khstate it
+// replaced.  current is nil for deleted khstates and previous is nil for new ones.
+type changeStore struct {
+	cache.Store
+	notify func(previous *khstatev1.KuberhealthyState, current *khstatev1.KuberhealthyState)
+}
+
+// Add adds a khstate to the store and notifies about it
+func (cs changeStore) Add(obj interface{}) error {
+	previous := cs.get(obj)
+	err := cs.Store.Add(obj)
+	if err == nil {
+		cs.notify(previous, asKHState(obj))
+	}
+	return err
+}
+
+// Update updates a khstate in the store and notifies about it
+func (cs changeStore) Update(obj interface{}) error {
+	previous := cs.get(obj)
+	err := cs.Store.Update(obj)
+	if err == nil {
+		cs.notify(previous, asKHState(obj))
+	}
+	return err
+}
+
+// Delete deletes a khstate from the store and notifies about it
+func (cs changeStore) Delete(obj interface{}) error {
+	previous := cs.get(obj)
+	err := cs.Store.Delete(obj)
+	if err == nil && previous != nil {
+		cs.notify(previous, nil)
+	}
+	return err
+}
+
+// Replace replaces the khstates of the store with the supplied list and notifies about each listed khstate and each
+// khstate that is no longer listed
+func (cs changeStore) Replace(list []interface{}, resourceVersion string) error {
+	previous := make(map[string]*khstatev1.KuberhealthyState)
+	for _, obj := range cs.Store.List() {
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err == nil {
+			previous[key] = asKHState(obj)
+		}
+	}
+	err := cs.Store.Replace(list, resourceVersion)
+	if err != nil {
+		return err
+	}
+	for _, obj := range list {
+		key, err := cache.MetaNamespaceKeyFunc(obj)
+		if err != nil {
+			continue
+		}
+		cs.notify(previous[key], asKHState(obj))
+		delete(previous, key)
+	}
+	for _, khState := range previous {
+		if khState != nil {
+			cs.notify(khState, nil)
+		}
+	}
+	return nil
+}
+
+// get returns the khstate stored with the key of obj, or nil if there is none
+func (cs changeStore) get(obj interface{}) *khstatev1.KuberhealthyState {
+	item, exists, err := cs.Store.Get(obj)
+	if err != nil || !exists {
+		return nil
+	}
+	return asKHState(item)
+}
+
+// asKHState returns the khstate held by a store object, or nil if it does not hold one
+func asKHState(obj interface{}) *khstatev1.KuberhealthyState {
+	khState, _ := obj.(*khstatev1.KuberhealthyState)
+	return khState
+}
+
+// WorkloadDetails returns the cached state of the check or job with the supplied name and namespace and whether it
+// was found in the cache
+func (sr *StateReflector) WorkloadDetails(name string, namespace string) (khstatev1.WorkloadDetails, bool) {
+	if sr.store == nil {
+		return khstatev1.WorkloadDetails{}, false
+	}
+	item, exists, err := sr.store.GetByKey(namespace + "/" + sanitizeResourceName(name))
+	if err != nil || !exists {
+		return khstatev1.WorkloadDetails{}, false
+	}
+	khState, ok := item.(*khstatev1.KuberhealthyState)
+	if !ok {
+		return khstatev1.WorkloadDetails{}, false
+	}
+	return khState.Spec, true
+}
+
+// Workload returns whether the khstate with the supplied name and namespace belongs to a khcheck or a khjob.  The
+// workload is looked up from the API the first time and remembered until the khstate is deleted, so that assembling
+// the status page does not make requests to the API server.  Blank is returned when neither is found.
+func (sr *StateReflector) Workload(name string, namespace string) khstatev1.KHWorkload {
+	key := namespace + "/" + name
+	sr.workloadsMu.Lock()
+	workload, ok := sr.workloads[key]
+	sr.workloadsMu.Unlock()
+	if ok {
+		return workload
+	}
+
+	lookup := sr.lookupWorkload
+	if lookup == nil {
+		lookup = determineKHWorkload
+	}
+	workload = lookup(name, namespace)
+	if len(workload) == 0 {
+		return workload
+	}
+	sr.workloadsMu.Lock()
+	defer sr.workloadsMu.Unlock()
+	if sr.workloads == nil {
+		sr.workloads = make(map[string]khstatev1.KHWorkload)
+	}
+	sr.workloads[key] = workload
+	return workload
 }
 
 // CurrentStatus returns the current summary of checks as known by the cache.
@@ -94,7 +293,7 @@ func (sr *StateReflector) CurrentStatus() health.State {
 			state.OK = false
 		}
 
-		khWorkload := determineKHWorkload(khState.Name, khState.Namespace)
+		khWorkload := sr.Workload(khState.Name, khState.Namespace)
 		switch khWorkload {
 		case khstatev1.KHCheck:
 			state.CheckDetails[khState.GetNamespace()+"/"+khState.GetName()] = khState.Spec
@@ -114,6 +313,10 @@ func determineKHWorkload(name string, namespace string) khstatev1.KHWorkload {
 	var khWorkload khstatev1.KHWorkload
 	log.Debugln("determineKHWorkload: determining workload:", name)
 
+	if isBuiltinCheck(name, namespace) {
+		return khstatev1.KHCheck
+	}
+
 	checkPod, err := khCheckClient.KuberhealthyChecks(namespace).Get(name, v1.GetOptions{})
 	if err != nil {
 		if k8sErrors.IsNotFound(err) || strings.Contains(err.Error(), "not found") {
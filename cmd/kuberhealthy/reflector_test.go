@@ -1,6 +1,8 @@
 package main
 
 import (
+	"reflect"
+	"sort"
 	"testing"
 	"time"
 
@@ -42,7 +44,7 @@ func makeTestStateReflector(fakeWatcher *watch.FakeWatcher) *StateReflector {
 		},
 	}
 
-	sr.reflector = cache.NewReflector(listerWatcher, &khstatev1.KuberhealthyState{}, sr.store, sr.resyncPeriod)
+	sr.reflectors = []*cache.Reflector{cache.NewReflector(listerWatcher, &khstatev1.KuberhealthyState{}, sr.store, sr.resyncPeriod)}
 
 	return &sr
 }
@@ -91,3 +93,113 @@ func TestStop(t *testing.T) {
 		break
 	}
 }
+
+// TestNamespaceStoreReplace ensures that replacing the khstates of one namespace keeps those of other namespaces
+func TestNamespaceStoreReplace(t *testing.T) {
+	store := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	state := func(namespace string, name string) *khstatev1.KuberhealthyState {
+		return &khstatev1.KuberhealthyState{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+	teamA := namespaceStore{Store: store, namespace: "team-a"}
+	teamB := namespaceStore{Store: store, namespace: "team-b"}
+
+	err := teamA.Replace([]interface{}{state("team-a", "dns"), state("team-a", "deployment")}, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = teamB.Replace([]interface{}{state("team-b", "dns")}, "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = teamA.Replace([]interface{}{state("team-a", "dns")}, "3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := store.ListKeys()
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, []string{"team-a/dns", "team-b/dns"}) {
+		t.Fatal("expected only the unlisted khstate of team-a to be removed but got", keys)
+	}
+}
+
+// TestChangeStore ensures every khstate change is notified along with the khstate it replaced, including changes
+// made by relists
+func TestChangeStore(t *testing.T) {
+	var changes []string
+	store := changeStore{Store: cache.NewStore(cache.MetaNamespaceKeyFunc), notify: func(previous *khstatev1.KuberhealthyState, current *khstatev1.KuberhealthyState) {
+		change := "nil->"
+		if previous != nil {
+			change = previous.Name + ":" + previous.ResourceVersion + "->"
+		}
+		if current != nil {
+			change += current.Name + ":" + current.ResourceVersion
+		} else {
+			change += "nil"
+		}
+		changes = append(changes, change)
+	}}
+	state := func(name string, version string) *khstatev1.KuberhealthyState {
+		return &khstatev1.KuberhealthyState{ObjectMeta: metav1.ObjectMeta{Namespace: "kuberhealthy", Name: name, ResourceVersion: version}}
+	}
+
+	err := store.Add(state("dns", "1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Update(state("dns", "2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Add(state("daemonset", "3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Replace([]interface{}{state("dns", "4")}, "4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Delete(state("dns", "4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"nil->dns:1", "dns:1->dns:2", "nil->daemonset:3", "dns:2->dns:4", "daemonset:3->nil", "dns:4->nil"}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Fatal("expected changes", expected, "but got", changes)
+	}
+}
+
+// TestReflectorWorkload ensures that the workload of a khstate is only looked up once until the khstate is deleted
+func TestReflectorWorkload(t *testing.T) {
+	lookups := 0
+	sr := StateReflector{lookupWorkload: func(name string, namespace string) khstatev1.KHWorkload {
+		lookups++
+		if name == "missing" {
+			return ""
+		}
+		return khstatev1.KHJob
+	}}
+
+	for i := 0; i < 3; i++ {
+		if workload := sr.Workload("backup", "kuberhealthy"); workload != khstatev1.KHJob {
+			t.Fatal("expected the khstate to belong to a khjob but got", workload)
+		}
+	}
+	if lookups != 1 {
+		t.Fatal("expected the workload to be looked up once but it was looked up", lookups, "times")
+	}
+
+	// khstates that belong to neither are looked up again, since their khcheck or khjob may not be created yet
+	sr.Workload("missing", "kuberhealthy")
+	sr.Workload("missing", "kuberhealthy")
+	if lookups != 3 {
+		t.Fatal("expected unknown workloads to be looked up every time but they were looked up", lookups-1, "times")
+	}
+
+	sr.notifyChange(&khstatev1.KuberhealthyState{ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "kuberhealthy"}}, nil)
+	sr.Workload("backup", "kuberhealthy")
+	if lookups != 4 {
+		t.Fatal("expected the workload of a deleted khstate to be looked up again")
+	}
+}
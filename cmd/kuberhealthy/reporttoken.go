@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// allowReportsWithoutToken accepts check reports that do not present the report token of their run, so that checks
+// built with an older checkclient keep working.  A report that presents the wrong token is always rejected.
+var allowReportsWithoutToken bool
+
+// validateCheckReportToken validates that a check report presents the report token of the checker pod it was sent
+// from, and that the token has not expired with the deadline of its run
+func (k *Kuberhealthy) validateCheckReportToken(c checkReport, podReport PodReportInfo, now time.Time) error {
+	if len(c.reportToken) == 0 || len(podReport.reportToken) == 0 {
+		if !allowReportsWithoutToken {
+			c.logger(podReport).Infoln("Rejected a check report of", podReport.Namespace+"/"+podReport.Name, "without a report token")
+			return fmt.Errorf("%w: report token is required", errCheckReportRejected)
+		}
+		if len(podReport.reportToken) > 0 {
+			c.logger(podReport).Infoln("Accepted a check report of", podReport.Namespace+"/"+podReport.Name,
+				"without a report token. Update the checkclient of the check to send its report token")
+		}
+		return nil
+	}
+
+	if subtle.ConstantTimeCompare([]byte(c.reportToken), []byte(podReport.reportToken)) != 1 {
+		c.logger(podReport).Infoln("Rejected a check report of", podReport.Namespace+"/"+podReport.Name, "with the wrong report token")
+		return fmt.Errorf("%w: report token does not match the run %s", errCheckReportRejected, podReport.UUID)
+	}
+	if !podReport.deadline.IsZero() && now.After(podReport.deadline) {
+		c.logger(podReport).Infoln("Rejected a check report of", podReport.Namespace+"/"+podReport.Name, "after the deadline of its run")
+		return fmt.Errorf("%w: report token of the run %s expired at %s", errCheckReportRejected, podReport.UUID, podReport.deadline)
+	}
+	return nil
+}
+
+// fetchReportToken reads the report token of a checker pod from the secret its KH_REPORT_TOKEN refers to.  The secret
+// must be labeled with the UUID of the run, so that a pod can not present the token secret of another run.
+func fetchReportToken(ctx context.Context, client kubernetes.Interface, namespace string, ref *v1.SecretKeySelector, uuid string) (string, error) {
+	secret, err := client.CoreV1().Secrets(namespace).Get(ctx, ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch the report token secret %s/%s: %w", namespace, ref.Name, err)
+	}
+	if secret.Labels["kuberhealthy-run-id"] != uuid {
+		return "", fmt.Errorf("report token secret %s/%s does not belong to the run %s", namespace, ref.Name, uuid)
+	}
+	token := secret.Data[ref.Key]
+	if len(token) == 0 {
+		return "", fmt.Errorf("report token secret %s/%s has no %s", namespace, ref.Name, ref.Key)
+	}
+	return string(token), nil
+}
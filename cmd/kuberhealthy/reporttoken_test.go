@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateCheckReportToken(t *testing.T) {
+	now := time.Now()
+	pod := PodReportInfo{Name: "dns-status", Namespace: "kuberhealthy", UUID: "run-uuid", reportToken: "token", deadline: now.Add(time.Minute)}
+	oldPod := PodReportInfo{Name: "dns-status", Namespace: "kuberhealthy", UUID: "run-uuid"}
+	k := &Kuberhealthy{}
+
+	for _, c := range []struct {
+		name     string
+		token    string
+		pod      PodReportInfo
+		now      time.Time
+		allowed  bool
+		rejected bool
+	}{
+		{name: "the token of the run", token: "token", pod: pod, now: now},
+		{name: "the wrong token", token: "other", pod: pod, now: now, rejected: true},
+		{name: "the wrong token when reports without a token are allowed", token: "other", pod: pod, now: now, allowed: true, rejected: true},
+		{name: "an expired token", token: "token", pod: pod, now: now.Add(time.Hour), rejected: true},
+		{name: "no token", pod: pod, now: now, rejected: true},
+		{name: "no token when reports without a token are allowed", pod: pod, now: now, allowed: true},
+		{name: "a pod without a token", token: "token", pod: oldPod, now: now, rejected: true},
+		{name: "a pod without a token when reports without a token are allowed", token: "token", pod: oldPod, now: now, allowed: true},
+	} {
+		allowReportsWithoutToken = c.allowed
+		err := k.validateCheckReportToken(checkReport{requestID: "test", reportToken: c.token}, c.pod, c.now)
+		if errors.Is(err, errCheckReportRejected) != c.rejected {
+			t.Fatalf("expected a report with %s to be rejected: %v, but got %v", c.name, c.rejected, err)
+		}
+	}
+	allowReportsWithoutToken = false
+}
+
+func TestFetchReportToken(t *testing.T) {
+	client := fake.NewSimpleClientset(
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "dns-status-report-token", Namespace: "kuberhealthy", Labels: map[string]string{"kuberhealthy-run-id": "run-uuid"}},
+			Data:       map[string][]byte{"token": []byte("secret-token")},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "unlabeled", Namespace: "kuberhealthy"},
+			Data:       map[string][]byte{"token": []byte("other-token")},
+		},
+	)
+	ref := func(name string) *v1.SecretKeySelector {
+		return &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: name}, Key: "token"}
+	}
+
+	token, err := fetchReportToken(context.Background(), client, "kuberhealthy", ref("dns-status-report-token"), "run-uuid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "secret-token" {
+		t.Fatal("expected the token of the secret but got:", token)
+	}
+
+	for name, c := range map[string]struct {
+		secret string
+		uuid   string
+	}{
+		"another run":      {secret: "dns-status-report-token", uuid: "other-uuid"},
+		"unlabeled secret": {secret: "unlabeled", uuid: "run-uuid"},
+		"missing secret":   {secret: "missing", uuid: "run-uuid"},
+	} {
+		_, err := fetchReportToken(context.Background(), client, "kuberhealthy", ref(c.secret), c.uuid)
+		if err == nil {
+			t.Fatal("expected the token of", name, "to be refused")
+		}
+	}
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+)
+
+// rolloutCheckName is the name of the khstate the built-in rollout check writes its results to in the Kuberhealthy
+// namespace.  It is also the name of the deployment, service and client pod the check creates.
+const rolloutCheckName = "deployment-rollout"
+
+// rolloutCheckLabel labels the resources created by the rollout check, so that those left behind by an interrupted
+// run can be found and removed
+const rolloutCheckLabel = "kuberhealthy-rollout-check"
+
+// rolloutCheckPort is the port the deployment of the rollout check serves on and the service exposes
+const rolloutCheckPort = 8080
+
+// rolloutCheckPollInterval is how often the rollout check looks at the resources it created while it waits for them
+var rolloutCheckPollInterval = time.Second * 2
+
+// rolloutChecks enables the built-in rollout check
+var rolloutChecks bool
+
+// rolloutCheckImage is the image of the deployment of the rollout check.  It must serve HTTP on rolloutCheckPort.
+var rolloutCheckImage = "nginxinc/nginx-unprivileged:1.25"
+
+// rolloutCheckClientImage is the image of the client pod that requests the service.  It needs sh and wget.
+var rolloutCheckClientImage = "busybox:1.36"
+
+// rolloutCheckReplicas is the number of replicas of the deployment of the rollout check
+var rolloutCheckReplicas = 2
+
+// rolloutCheckTimeout is how long each step of the rollout check can take
+var rolloutCheckTimeout = time.Minute * 5
+
+// rolloutChecker creates a deployment and a service, waits for the deployment to roll out and the service to have
+// endpoints, requests the service from a client pod and removes everything again
+type rolloutChecker struct {
+	namespace    string
+	image        string
+	clientImage  string
+	replicas     int32
+	timeout      time.Duration
+	pollInterval time.Duration
+	resources    apiv1.ResourceRequirements // the resources of the containers of the check
+	client       kubernetes.Interface
+}
+
+// newRolloutChecker creates a rollout checker from the rollout check flags and the checker pod resources of the
+// configuration
+func newRolloutChecker(client kubernetes.Interface) *rolloutChecker {
+	return &rolloutChecker{
+		namespace:    podNamespace,
+		image:        rolloutCheckImage,
+		clientImage:  rolloutCheckClientImage,
+		replicas:     int32(rolloutCheckReplicas),
+		timeout:      rolloutCheckTimeout,
+		pollInterval: rolloutCheckPollInterval,
+		resources:    rolloutCheckResources(cfg.checkPodResources),
+		client:       client,
+	}
+}
+
+// validateRolloutCheckFlags ensures the rollout check flags are usable when the rollout check is enabled
+func validateRolloutCheckFlags() error {
+	if !rolloutChecks {
+		return nil
+	}
+	if len(rolloutCheckImage) == 0 || len(rolloutCheckClientImage) == 0 {
+		return errors.New("rolloutCheckImage and rolloutCheckClientImage are required")
+	}
+	if rolloutCheckReplicas < 1 {
+		return errors.New("rolloutCheckReplicas must be at least 1")
+	}
+	if rolloutCheckTimeout <= 0 {
+		return errors.New("rolloutCheckTimeout must be positive")
+	}
+	return nil
+}
+
+// run creates the resources of the check, waits for each step and removes them again.  The check details are
+// returned with the errors of the first step that failed.
+func (rc *rolloutChecker) run(ctx context.Context) khstatev1.WorkloadDetails {
+	details := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	details.Namespace = rc.namespace
+
+	err := rc.cleanUp(ctx)
+	if err != nil {
+		details.Errors = []string{"Failed to remove the resources of an earlier run: " + err.Error()}
+		return details
+	}
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), rc.timeout)
+		defer cancel()
+		err := rc.cleanUp(cleanupCtx)
+		if err != nil {
+			log.Errorln("Failed to remove the resources of check", rolloutCheckName+":", err)
+		}
+	}()
+
+	for _, step := range []func(context.Context) error{
+		rc.createDeployment,
+		rc.createService,
+		rc.waitForRollout,
+		rc.waitForEndpoints,
+		rc.createClientPod,
+		rc.waitForClientPod,
+	} {
+		err := step(ctx)
+		if err != nil {
+			details.Errors = []string{err.Error()}
+			return details
+		}
+	}
+	details.OK = true
+	return details
+}
+
+// labels returns the labels of the resources of the check
+func (rc *rolloutChecker) labels(component string) map[string]string {
+	return map[string]string{rolloutCheckLabel: component}
+}
+
+// createDeployment creates the deployment of the check
+func (rc *rolloutChecker) createDeployment(ctx context.Context) error {
+	allowPrivilegeEscalation := false
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: rolloutCheckName, Namespace: rc.namespace, Labels: rc.labels("server")},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &rc.replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: rc.labels("server")},
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: rc.labels("server")},
+				Spec: apiv1.PodSpec{
+					Containers: []apiv1.Container{{
+						Name:      "server",
+						Image:     rc.image,
+						Ports:     []apiv1.ContainerPort{{ContainerPort: rolloutCheckPort}},
+						Resources: *rc.resources.DeepCopy(),
+						SecurityContext: &apiv1.SecurityContext{
+							AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+						},
+						ReadinessProbe: &apiv1.Probe{
+							ProbeHandler: apiv1.ProbeHandler{
+								HTTPGet: &apiv1.HTTPGetAction{Path: "/", Port: intstr.FromInt(rolloutCheckPort)},
+							},
+							PeriodSeconds: 2,
+						},
+					}},
+				},
+			},
+		},
+	}
+	_, err := rc.client.AppsV1().Deployments(rc.namespace).Create(ctx, deployment, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("Failed to create deployment %s: %w", rolloutCheckName, err)
+	}
+	return nil
+}
+
+// createService creates the service of the check in front of the deployment
+func (rc *rolloutChecker) createService(ctx context.Context) error {
+	service := &apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: rolloutCheckName, Namespace: rc.namespace, Labels: rc.labels("service")},
+		Spec: apiv1.ServiceSpec{
+			Type:     apiv1.ServiceTypeClusterIP,
+			Selector: rc.labels("server"),
+			Ports:    []apiv1.ServicePort{{Port: 80, TargetPort: intstr.FromInt(rolloutCheckPort)}},
+		},
+	}
+	_, err := rc.client.CoreV1().Services(rc.namespace).Create(ctx, service, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("Failed to create service %s: %w", rolloutCheckName, err)
+	}
+	return nil
+}
+
+// waitForRollout waits for every replica of the deployment to be updated and available
+func (rc *rolloutChecker) waitForRollout(ctx context.Context) error {
+	var deployment *appsv1.Deployment
+	err := rc.poll(ctx, func(ctx context.Context) (bool, error) {
+		d, err := rc.client.AppsV1().Deployments(rc.namespace).Get(ctx, rolloutCheckName, metav1.GetOptions{})
+		if err != nil {
+			log.Warnln("Failed to get deployment", rolloutCheckName+":", err)
+			return false, nil
+		}
+		deployment = d
+		return d.Status.ObservedGeneration >= d.Generation && d.Status.UpdatedReplicas == rc.replicas && d.Status.AvailableReplicas == rc.replicas, nil
+	})
+	if err != nil {
+		available := int32(0)
+		if deployment != nil {
+			available = deployment.Status.AvailableReplicas
+		}
+		return fmt.Errorf("Deployment %s did not roll out within %s: %d of %d replicas are available%s", rolloutCheckName, rc.timeout, available, rc.replicas, rc.podProblem(ctx))
+	}
+	return nil
+}
+
+// waitForEndpoints waits for the endpoints controller to add every replica to the endpoints of the service
+func (rc *rolloutChecker) waitForEndpoints(ctx context.Context) error {
+	ready := 0
+	err := rc.poll(ctx, func(ctx context.Context) (bool, error) {
+		endpoints, err := rc.client.CoreV1().Endpoints(rc.namespace).Get(ctx, rolloutCheckName, metav1.GetOptions{})
+		if err != nil {
+			log.Debugln("Failed to get the endpoints of service", rolloutCheckName+":", err)
+			return false, nil
+		}
+		ready = 0
+		for _, subset := range endpoints.Subsets {
+			ready += len(subset.Addresses)
+		}
+		return ready >= int(rc.replicas), nil
+	})
+	if err != nil {
+		return fmt.Errorf("Service %s did not have an endpoint for each replica within %s: %d of %d endpoints are ready", rolloutCheckName, rc.timeout, ready, rc.replicas)
+	}
+	return nil
+}
+
+// createClientPod creates a pod that requests the service by its DNS name until it responds
+func (rc *rolloutChecker) createClientPod(ctx context.Context) error {
+	allowPrivilegeEscalation, runAsNonRoot, runAsUser := false, true, int64(999)
+	url := "http://" + rolloutCheckName + "." + rc.namespace + ".svc"
+	script := fmt.Sprintf(`for i in $(seq 1 10); do wget -q -T 5 -O /dev/null %s && exit 0; sleep 3; done; exit 1`, url)
+	pod := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: rolloutCheckName, Namespace: rc.namespace, Labels: rc.labels("client")},
+		Spec: apiv1.PodSpec{
+			RestartPolicy: apiv1.RestartPolicyNever,
+			Containers: []apiv1.Container{{
+				Name:      "client",
+				Image:     rc.clientImage,
+				Command:   []string{"sh", "-c", script},
+				Resources: *rc.resources.DeepCopy(),
+				SecurityContext: &apiv1.SecurityContext{
+					AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+					RunAsNonRoot:             &runAsNonRoot,
+					RunAsUser:                &runAsUser,
+				},
+			}},
+		},
+	}
+	_, err := rc.client.CoreV1().Pods(rc.namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("Failed to create client pod %s: %w", rolloutCheckName, err)
+	}
+	return nil
+}
+
+// waitForClientPod waits for the client pod to complete and fails when it could not reach the service
+func (rc *rolloutChecker) waitForClientPod(ctx context.Context) error {
+	var pod *apiv1.Pod
+	err := rc.poll(ctx, func(ctx context.Context) (bool, error) {
+		p, err := rc.client.CoreV1().Pods(rc.namespace).Get(ctx, rolloutCheckName, metav1.GetOptions{})
+		if err != nil {
+			log.Warnln("Failed to get client pod", rolloutCheckName+":", err)
+			return false, nil
+		}
+		pod = p
+		return p.Status.Phase == apiv1.PodSucceeded || p.Status.Phase == apiv1.PodFailed, nil
+	})
+	if err != nil {
+		return fmt.Errorf("Client pod %s did not complete within %s", rolloutCheckName, rc.timeout)
+	}
+	if pod.Status.Phase == apiv1.PodFailed {
+		return fmt.Errorf("Client pod %s could not reach service %s at http://%s.%s.svc", rolloutCheckName, rolloutCheckName, rolloutCheckName, rc.namespace)
+	}
+	return nil
+}
+
+// poll calls condition until it is done or the step timeout passes
+func (rc *rolloutChecker) poll(ctx context.Context, condition wait.ConditionWithContextFunc) error {
+	return wait.PollUntilContextTimeout(ctx, rc.pollInterval, rc.timeout, true, condition)
+}
+
+// podProblem returns why a pod of the deployment is not running, such as a pod that can not be scheduled or an image
+// that can not be pulled, for error messages
+func (rc *rolloutChecker) podProblem(ctx context.Context) string {
+	pods, err := rc.client.CoreV1().Pods(rc.namespace).List(ctx, metav1.ListOptions{LabelSelector: rolloutCheckLabel + "=server"})
+	if err != nil {
+		return ""
+	}
+	for _, pod := range pods.Items {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == apiv1.PodScheduled && condition.Status == apiv1.ConditionFalse {
+				return fmt.Sprintf(": pod %s is not scheduled: %s", pod.Name, condition.Message)
+			}
+		}
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting != nil && len(status.State.Waiting.Reason) > 0 {
+				return fmt.Sprintf(": pod %s is waiting: %s %s", pod.Name, status.State.Waiting.Reason, status.State.Waiting.Message)
+			}
+		}
+	}
+	return ""
+}
+
+// cleanUp removes the deployment, service and client pod of the check and waits for them to be gone, so that the next
+// run can create them again
+func (rc *rolloutChecker) cleanUp(ctx context.Context) error {
+	propagation := metav1.DeletePropagationForeground
+	opts := metav1.DeleteOptions{PropagationPolicy: &propagation}
+	deletes := []func() error{
+		func() error { return rc.client.AppsV1().Deployments(rc.namespace).Delete(ctx, rolloutCheckName, opts) },
+		func() error { return rc.client.CoreV1().Services(rc.namespace).Delete(ctx, rolloutCheckName, opts) },
+		func() error { return rc.client.CoreV1().Pods(rc.namespace).Delete(ctx, rolloutCheckName, opts) },
+	}
+	for _, del := range deletes {
+		err := del()
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return rc.poll(ctx, func(ctx context.Context) (bool, error) {
+		for _, get := range []func() error{
+			func() error {
+				_, err := rc.client.AppsV1().Deployments(rc.namespace).Get(ctx, rolloutCheckName, metav1.GetOptions{})
+				return err
+			},
+			func() error {
+				_, err := rc.client.CoreV1().Services(rc.namespace).Get(ctx, rolloutCheckName, metav1.GetOptions{})
+				return err
+			},
+			func() error {
+				_, err := rc.client.CoreV1().Pods(rc.namespace).Get(ctx, rolloutCheckName, metav1.GetOptions{})
+				return err
+			},
+		} {
+			if !k8sErrors.IsNotFound(get()) {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// rolloutCheckResources returns the resources of the containers of the rollout check.  The containers request a
+// little CPU and memory, and get the checker pod resources of the configuration for everything else.
+func rolloutCheckResources(defaults apiv1.ResourceRequirements) apiv1.ResourceRequirements {
+	c := apiv1.Container{
+		Resources: apiv1.ResourceRequirements{
+			Requests: apiv1.ResourceList{
+				apiv1.ResourceCPU:    resource.MustParse("10m"),
+				apiv1.ResourceMemory: resource.MustParse("20Mi"),
+			},
+		},
+	}
+	external.ApplyDefaultResources(&c, defaults)
+	return c.Resources
+}
+
+// runRolloutCheck runs the rollout check on the DefaultRunInterval and stores its results in the deployment-rollout
+// khstate until the context is canceled.  When checks are sharded, only the kuberhealthy pod the check is assigned to
+// runs it.
+func (k *Kuberhealthy) runRolloutCheck(ctx context.Context) {
+	defer k.wg.Done()
+
+	log.Infoln("Starting check:", podNamespace, "/", rolloutCheckName)
+	checker := newRolloutChecker(kubernetesClient)
+	name := podNamespace + "/" + rolloutCheckName
+	schedule := k.checkSchedules.add(name, checkTiming{interval: DefaultRunInterval})
+	defer k.checkSchedules.remove(name, schedule)
+
+	for {
+		if shardChecks && shardOwner(name) != podHostname {
+			log.Debugln("sharding: skipping run of check", name, "because it is assigned to another pod")
+		} else {
+			start := time.Now()
+			details := checker.run(ctx)
+			if ctx.Err() != nil {
+				log.Infoln("Shutting down check run due to context cancellation:", rolloutCheckName, "in namespace", podNamespace)
+				return
+			}
+			details.RunDuration = time.Since(start).String()
+			details.AuthoritativePod = podHostname
+
+			log.Infoln("Setting state of check", rolloutCheckName, "in namespace", podNamespace, "to", details.OK, details.Errors, details.RunDuration)
+			err := k.storeCheckState(rolloutCheckName, podNamespace, details)
+			if err != nil {
+				log.Errorln("Error storing CRD state for check:", rolloutCheckName, "in namespace", podNamespace, err)
+			}
+		}
+
+		if !schedule.wait(ctx) {
+			log.Infoln("Shutting down check run due to context cancellation:", rolloutCheckName, "in namespace", podNamespace)
+			return
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newTestRolloutChecker returns a rollout checker with short timeouts and a fake client
+func newTestRolloutChecker(client *fake.Clientset) *rolloutChecker {
+	return &rolloutChecker{
+		namespace:    "kuberhealthy",
+		image:        rolloutCheckImage,
+		clientImage:  rolloutCheckClientImage,
+		replicas:     2,
+		timeout:      time.Millisecond * 300,
+		pollInterval: time.Millisecond * 10,
+		resources:    rolloutCheckResources(apiv1.ResourceRequirements{}),
+		client:       client,
+	}
+}
+
+// simulateRollout rolls out the deployment of the rollout check, adds its endpoints and completes the client pod
+// with the supplied phase, like the controllers and kubelets of a cluster would
+func simulateRollout(t *testing.T, client *fake.Clientset, clientPhase apiv1.PodPhase) {
+	go func() {
+		ctx := context.Background()
+		var rolledOut bool
+		for i := 0; i < 100; i++ {
+			time.Sleep(time.Millisecond * 5)
+			if !rolledOut {
+				// the deployment left behind by an earlier run has no labels
+				d, err := client.AppsV1().Deployments("kuberhealthy").Get(ctx, rolloutCheckName, metav1.GetOptions{})
+				if err != nil || len(d.Labels) == 0 {
+					continue
+				}
+				d.Status = appsv1.DeploymentStatus{UpdatedReplicas: 2, AvailableReplicas: 2}
+				_, err = client.AppsV1().Deployments("kuberhealthy").UpdateStatus(ctx, d, metav1.UpdateOptions{})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				_, err = client.CoreV1().Endpoints("kuberhealthy").Create(ctx, &apiv1.Endpoints{
+					ObjectMeta: metav1.ObjectMeta{Name: rolloutCheckName, Namespace: "kuberhealthy"},
+					Subsets:    []apiv1.EndpointSubset{{Addresses: []apiv1.EndpointAddress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}}}},
+				}, metav1.CreateOptions{})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				rolledOut = true
+			}
+
+			pod, err := client.CoreV1().Pods("kuberhealthy").Get(ctx, rolloutCheckName, metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+			pod.Status.Phase = clientPhase
+			_, err = client.CoreV1().Pods("kuberhealthy").UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+			if err != nil {
+				t.Error(err)
+			}
+			return
+		}
+	}()
+}
+
+// assertRolloutCleanedUp ensures the deployment, service and client pod of the rollout check were removed
+func assertRolloutCleanedUp(t *testing.T, client *fake.Clientset) {
+	deployments, _ := client.AppsV1().Deployments("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	services, _ := client.CoreV1().Services("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	pods, _ := client.CoreV1().Pods("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if len(deployments.Items) != 0 || len(services.Items) != 0 || len(pods.Items) != 0 {
+		t.Fatal("expected the resources of the rollout check to be removed but found", len(deployments.Items), "deployments,", len(services.Items), "services and", len(pods.Items), "pods")
+	}
+}
+
+func TestRolloutCheckerRun(t *testing.T) {
+	// a deployment left behind by an interrupted run is replaced
+	client := fake.NewSimpleClientset(&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: rolloutCheckName, Namespace: "kuberhealthy"}})
+	rc := newTestRolloutChecker(client)
+	simulateRollout(t, client, apiv1.PodSucceeded)
+
+	details := rc.run(context.Background())
+	if !details.OK {
+		t.Fatal("expected the rollout check to pass but got", details.Errors)
+	}
+	assertRolloutCleanedUp(t, client)
+}
+
+func TestRolloutCheckerRunClientFailed(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	rc := newTestRolloutChecker(client)
+	simulateRollout(t, client, apiv1.PodFailed)
+
+	details := rc.run(context.Background())
+	if details.OK || len(details.Errors) != 1 || !strings.Contains(details.Errors[0], "could not reach service deployment-rollout") {
+		t.Fatal("expected the client pod to fail to reach the service but got", details.Errors)
+	}
+	assertRolloutCleanedUp(t, client)
+}
+
+// TestRolloutCheckerRunNotRolledOut ensures a deployment that does not roll out fails with the reason its pods are
+// not running
+func TestRolloutCheckerRunNotRolledOut(t *testing.T) {
+	client := fake.NewSimpleClientset(&apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "deployment-rollout-6d4cf56db6-x7k2p", Namespace: "kuberhealthy", Labels: map[string]string{rolloutCheckLabel: "server"}},
+		Status: apiv1.PodStatus{Conditions: []apiv1.PodCondition{{
+			Type:    apiv1.PodScheduled,
+			Status:  apiv1.ConditionFalse,
+			Message: "0/3 nodes are available: 3 Insufficient cpu.",
+		}}},
+	})
+	rc := newTestRolloutChecker(client)
+
+	details := rc.run(context.Background())
+	if details.OK || len(details.Errors) != 1 || !strings.Contains(details.Errors[0], "did not roll out within 300ms: 0 of 2 replicas are available") || !strings.Contains(details.Errors[0], "Insufficient cpu") {
+		t.Fatal("expected a rollout timeout with the scheduling failure but got", details.Errors)
+	}
+}
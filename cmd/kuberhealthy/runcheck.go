@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/integrii/flaggy"
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
+)
+
+// runCheckFile is the khcheck manifest the run-check command runs once in the foreground
+var runCheckFile string
+
+// runCheckReportingURL is the URL the checker pod of the run-check command reports to.  It has to reach
+// runCheckListenAddress from inside the cluster.
+var runCheckReportingURL string
+
+// runCheckListenAddress is the address the run-check command receives the report of its checker pod on
+var runCheckListenAddress = ":8090"
+
+// runCheckKeepPod leaves the checker pod of the run-check command in the cluster after the run
+var runCheckKeepPod bool
+
+// runCheckPollInterval is how often the run-check command looks at its checker pod while it starts
+var runCheckPollInterval = time.Second
+
+// runCheckLogGracePeriod is how long the run-check command waits for the logs of its checker pod to end after the
+// report arrives, and for a report after the checker pod exits
+var runCheckLogGracePeriod = time.Second * 10
+
+// newRunCheckCommand creates the run-check command, which runs a single external check against the cluster of the
+// current kubeconfig without starting Kuberhealthy
+func newRunCheckCommand() *flaggy.Subcommand {
+	cmd := flaggy.NewSubcommand("run-check")
+	cmd.Description = "Runs a khcheck once in the foreground against the current kubeconfig, streaming its checker pod logs and printing its report. Kuberhealthy is not started."
+	cmd.String(&runCheckFile, "f", "file", "The khcheck manifest to run.")
+	cmd.String(&runCheckReportingURL, "", "reportingURL", "The URL the checker pod reports to, which must reach reportListenAddress from inside the cluster.")
+	cmd.String(&runCheckListenAddress, "", "reportListenAddress", "The address the report of the checker pod is received on.")
+	cmd.Bool(&runCheckKeepPod, "", "keepPod", "Set to leave the checker pod in the cluster after the run.")
+	return cmd
+}
+
+// runCheckCommand runs the khcheck of the run-check command and returns the exit code.  0 is returned when the check
+// reported success, 1 when it reported a failure or did not report, and 2 when it could not be run.
+func runCheckCommand(out io.Writer) int {
+	if len(runCheckFile) == 0 {
+		fmt.Fprintln(out, "--file is required")
+		return 2
+	}
+	kc, err := loadRunCheckManifest(runCheckFile)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return 2
+	}
+
+	v := validateKHCheck(kc)
+	for _, issue := range v.Errors {
+		fmt.Fprintf(out, "ERROR   %s %s: %s\n", v.Check, issue.Field, issue.Message)
+	}
+	for _, issue := range v.Warnings {
+		fmt.Fprintf(out, "WARNING %s %s: %s\n", v.Check, issue.Field, issue.Message)
+	}
+	if len(v.Errors) > 0 {
+		return 2
+	}
+	if len(runCheckReportingURL) == 0 {
+		fmt.Fprintln(out, "--reportingURL is required so that the checker pod can report to this process, such as http://host.docker.internal"+runCheckListenAddress+"/externalCheckStatus on kind or Docker Desktop")
+		return 2
+	}
+
+	kubeConfigFile := os.Getenv("KUBECONFIG")
+	if len(kubeConfigFile) == 0 {
+		kubeConfigFile = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+	}
+	client, err := kubeClient.Create(kubeConfigFile)
+	if err != nil {
+		fmt.Fprintln(out, "failed to create kubernetes client:", err)
+		return 2
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	return runCheckOnce(ctx, client, kc, out)
+}
+
+// loadRunCheckManifest reads the khcheck of a manifest that holds a single khcheck
+func loadRunCheckManifest(path string) (khcheckv1.KuberhealthyCheck, error) {
+	manifest, err := os.ReadFile(path)
+	if err != nil {
+		return khcheckv1.KuberhealthyCheck{}, fmt.Errorf("failed to read khcheck manifest: %w", err)
+	}
+	khChecks, err := parseKHCheckManifests(manifest)
+	if err != nil {
+		return khcheckv1.KuberhealthyCheck{}, err
+	}
+	if len(khChecks) > 1 {
+		return khcheckv1.KuberhealthyCheck{}, fmt.Errorf("the manifest contains %d khchecks, but run-check runs one", len(khChecks))
+	}
+	return khChecks[0], nil
+}
+
+// runCheckOnce creates the checker pod of a khcheck, streams its logs and prints the report it sends
+func runCheckOnce(ctx context.Context, client *kubernetes.Clientset, kc khcheckv1.KuberhealthyCheck, out io.Writer) int {
+	runUUID := uuid.New().String()
+	receiver := newCheckReportReceiver(runUUID)
+	listener, err := net.Listen("tcp", runCheckListenAddress)
+	if err != nil {
+		fmt.Fprintln(out, "failed to listen for the report of the checker pod:", err)
+		return 2
+	}
+	server := &http.Server{Handler: receiver, ReadHeaderTimeout: time.Second * 10}
+	go server.Serve(listener)
+	defer server.Close()
+
+	checker := external.New(client, &kc, nil, nil, runCheckReportingURL)
+	checker.RunTimeout = DefaultTimeout
+	if len(kc.Spec.Timeout) > 0 {
+		timeout, err := time.ParseDuration(kc.Spec.Timeout)
+		if err == nil && timeout > 0 {
+			checker.RunTimeout = timeout
+		}
+	}
+	checker.ExtraLabels = kc.Spec.ExtraLabels
+	checker.ExtraAnnotations = kc.Spec.ExtraAnnotations
+	deadline := time.Now().Add(checker.RunTimeout)
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	pod, err := checker.DebugPod(ctx, runUUID, deadline)
+	if err != nil {
+		fmt.Fprintln(out, "failed to prepare the checker pod:", err)
+		return 2
+	}
+	pod, err = client.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		fmt.Fprintln(out, "failed to create the checker pod:", err)
+		return 2
+	}
+	fmt.Fprintln(out, "Created checker pod", pod.Namespace+"/"+pod.Name, "for run", runUUID, "with a timeout of", checker.RunTimeout)
+	if !runCheckKeepPod {
+		defer func() {
+			deleteCtx, deleteCancel := context.WithTimeout(context.Background(), time.Second*30)
+			defer deleteCancel()
+			err := client.CoreV1().Pods(pod.Namespace).Delete(deleteCtx, pod.Name, metav1.DeleteOptions{})
+			if err != nil {
+				fmt.Fprintln(out, "failed to delete the checker pod:", err)
+			}
+		}()
+	}
+
+	podDone := make(chan error, 1)
+	go func() {
+		podDone <- followCheckerPod(ctx, client, pod.Namespace, pod.Name, out)
+	}()
+
+	select {
+	case report := <-receiver.reports:
+		select {
+		case <-podDone:
+		case <-time.After(runCheckLogGracePeriod):
+		}
+		return writeCheckReport(out, report)
+	case err := <-podDone:
+		if err != nil {
+			fmt.Fprintln(out, "Checker pod failed:", err)
+			return 1
+		}
+		select {
+		case report := <-receiver.reports:
+			return writeCheckReport(out, report)
+		case <-time.After(runCheckLogGracePeriod):
+			fmt.Fprintln(out, "Checker pod exited without reporting to", runCheckReportingURL)
+			return 1
+		}
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			fmt.Fprintln(out, "Timed out after", checker.RunTimeout, "waiting for the checker pod to report")
+		} else {
+			fmt.Fprintln(out, "Interrupted before the checker pod reported")
+		}
+		return 1
+	}
+}
+
+// checkReportReceiver receives the report of the checker pod of a single run
+type checkReportReceiver struct {
+	runUUID string
+	reports chan status.Report
+}
+
+// newCheckReportReceiver creates a receiver of the reports of a run
+func newCheckReportReceiver(runUUID string) *checkReportReceiver {
+	return &checkReportReceiver{runUUID: runUUID, reports: make(chan status.Report, 1)}
+}
+
+// ServeHTTP accepts a report of the run the same way as the external check report handler of Kuberhealthy.  Only the
+// first report is kept.
+func (rr *checkReportReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	if r.Header.Get("kh-run-uuid") != rr.runUUID {
+		log.Warningln("run-check: rejected a report from", r.RemoteAddr, "with run UUID", r.Header.Get("kh-run-uuid"))
+		http.Error(w, "unknown kh-run-uuid", http.StatusBadRequest)
+		return
+	}
+
+	var report status.Report
+	err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&report)
+	if err != nil {
+		log.Warningln("run-check: rejected a report that is not valid JSON:", err)
+		http.Error(w, "failed to decode report: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = report.ValidateSeverity()
+	if err == nil {
+		err = report.ValidateDetails()
+	}
+	if err != nil {
+		log.Warningln("run-check: rejected an invalid report:", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case rr.reports <- report:
+	default:
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// followCheckerPod waits for a checker pod to start, then writes the logs of its containers until they exit.  An
+// error is returned when the pod can not start.
+func followCheckerPod(ctx context.Context, client kubernetes.Interface, namespace string, name string, out io.Writer) error {
+	ticker := time.NewTicker(runCheckPollInterval)
+	defer ticker.Stop()
+	var pod *apiv1.Pod
+	for {
+		var err error
+		pod, err = client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get the checker pod: %w", err)
+		}
+		err = external.PodStartError(pod)
+		if err != nil {
+			return err
+		}
+		if pod.Status.Phase != apiv1.PodPending {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	w := &lineWriter{out: out}
+	var wg sync.WaitGroup
+	for _, c := range pod.Spec.Containers {
+		prefix := ""
+		if len(pod.Spec.Containers) > 1 {
+			prefix = "[" + c.Name + "] "
+		}
+		wg.Add(1)
+		go func(container string, prefix string) {
+			defer wg.Done()
+			stream, err := client.CoreV1().Pods(namespace).GetLogs(name, &apiv1.PodLogOptions{Container: container, Follow: true}).Stream(ctx)
+			if err != nil {
+				w.writeLine(prefix + "failed to stream logs: " + err.Error())
+				return
+			}
+			defer stream.Close()
+			scanner := bufio.NewScanner(stream)
+			for scanner.Scan() {
+				w.writeLine(prefix + scanner.Text())
+			}
+		}(c.Name, prefix)
+	}
+	wg.Wait()
+
+	pod, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil && pod.Status.Phase == apiv1.PodFailed {
+		return errors.New("the checker pod failed: " + pod.Status.Reason + " " + pod.Status.Message)
+	}
+	return nil
+}
+
+// lineWriter writes whole lines from several goroutines without interleaving them
+type lineWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+}
+
+// writeLine writes a line followed by a newline
+func (lw *lineWriter) writeLine(line string) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	fmt.Fprintln(lw.out, line)
+}
+
+// writeCheckReport writes the report of a run and returns the exit code of the run-check command for it
+func writeCheckReport(out io.Writer, report status.Report) int {
+	result := "OK"
+	if !report.OK {
+		result = "FAILED"
+		if report.Severity == status.SeverityWarning {
+			result = "FAILED (Warning)"
+		}
+	}
+	fmt.Fprintln(out, "Report:", result)
+	for _, e := range report.Errors {
+		fmt.Fprintln(out, "  error:", e)
+	}
+	keys := make([]string, 0, len(report.Details))
+	for key := range report.Details {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintln(out, "  "+key+":", strings.TrimSpace(report.Details[key]))
+	}
+	if report.OK {
+		return 0
+	}
+	return 1
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+)
+
+func TestLoadRunCheckManifest(t *testing.T) {
+	dir := t.TempDir()
+	check := `apiVersion: comcast.github.io/v1
+kind: KuberhealthyCheck
+metadata:
+  name: dns-status
+  namespace: team-a
+spec:
+  runInterval: 2m
+  timeout: 1m
+  podSpec:
+    containers:
+    - name: main
+      image: kuberhealthy/dns-resolution-check
+`
+	write := func(name string, manifest string) string {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, []byte(manifest), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	kc, err := loadRunCheckManifest(write("one.yaml", check))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kc.Name != "dns-status" || kc.Namespace != "team-a" || len(kc.Spec.PodSpec.Containers) != 1 {
+		t.Fatal("expected the khcheck of the manifest but got", kc.Namespace+"/"+kc.Name)
+	}
+
+	_, err = loadRunCheckManifest(write("two.yaml", check+"---\n"+check))
+	if err == nil || !strings.Contains(err.Error(), "contains 2 khchecks") {
+		t.Fatal("expected a manifest of two khchecks to be rejected but got", err)
+	}
+	_, err = loadRunCheckManifest(filepath.Join(dir, "missing.yaml"))
+	if err == nil {
+		t.Fatal("expected a missing manifest to be rejected")
+	}
+}
+
+func TestCheckReportReceiver(t *testing.T) {
+	receiver := newCheckReportReceiver("run-1")
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	post := func(runUUID string, body string) int {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/externalCheckStatus", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("kh-run-uuid", runUUID)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := post("run-2", `{"OK":true,"Errors":[]}`); code != http.StatusBadRequest {
+		t.Fatal("expected a report of another run to be rejected but got", code)
+	}
+	if code := post("run-1", `not json`); code != http.StatusBadRequest {
+		t.Fatal("expected a malformed report to be rejected but got", code)
+	}
+	if code := post("run-1", `{"OK":false,"Errors":["failed"],"Severity":"Info"}`); code != http.StatusBadRequest {
+		t.Fatal("expected a report with an unknown severity to be rejected but got", code)
+	}
+	resp, err := http.Get(server.URL + "/externalCheckStatus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Fatal("expected a GET to be refused but got", resp.StatusCode)
+	}
+	select {
+	case report := <-receiver.reports:
+		t.Fatal("expected no report to be received but got", report)
+	default:
+	}
+
+	if code := post("run-1", `{"OK":false,"Errors":["lookup failed"],"Details":{"records":"0"}}`); code != http.StatusOK {
+		t.Fatal("expected the report of the run to be accepted but got", code)
+	}
+	if code := post("run-1", `{"OK":true,"Errors":[]}`); code != http.StatusOK {
+		t.Fatal("expected a repeated report of the run to be accepted but got", code)
+	}
+	report := <-receiver.reports
+	if report.OK || len(report.Errors) != 1 || report.Details["records"] != "0" {
+		t.Fatal("expected the first report to be kept but got", report)
+	}
+}
+
+func TestFollowCheckerPod(t *testing.T) {
+	previousInterval := runCheckPollInterval
+	runCheckPollInterval = time.Millisecond * 10
+	defer func() { runCheckPollInterval = previousInterval }()
+
+	pod := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "dns-status-1", Namespace: "kuberhealthy"},
+		Spec:       apiv1.PodSpec{Containers: []apiv1.Container{{Name: "main"}, {Name: "sidecar"}}},
+		Status:     apiv1.PodStatus{Phase: apiv1.PodSucceeded},
+	}
+	client := fake.NewSimpleClientset(pod)
+	var out bytes.Buffer
+	err := followCheckerPod(context.Background(), client, "kuberhealthy", "dns-status-1", &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "[main] fake logs\n") || !strings.Contains(out.String(), "[sidecar] fake logs\n") {
+		t.Fatalf("expected the logs of each container to be prefixed with its name, got:\n%s", out.String())
+	}
+
+	pod.Name = "dns-status-2"
+	pod.Status = apiv1.PodStatus{
+		Phase: apiv1.PodPending,
+		ContainerStatuses: []apiv1.ContainerStatus{{
+			Name:  "main",
+			Image: "kuberhealthy/missing",
+			State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+		}},
+	}
+	_, err = client.CoreV1().Pods("kuberhealthy").Create(context.Background(), pod, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = followCheckerPod(context.Background(), client, "kuberhealthy", "dns-status-2", &out)
+	if err == nil || !strings.Contains(err.Error(), "failed to pull image") {
+		t.Fatal("expected a pod that can not pull its image to fail but got", err)
+	}
+}
+
+func TestWriteCheckReport(t *testing.T) {
+	var out bytes.Buffer
+	code := writeCheckReport(&out, status.Report{OK: true, Details: map[string]string{"records": "3", "latency": "12ms"}})
+	if code != 0 || out.String() != "Report: OK\n  latency: 12ms\n  records: 3\n" {
+		t.Fatalf("expected a successful report with sorted details and exit code 0, got %d:\n%s", code, out.String())
+	}
+
+	out.Reset()
+	code = writeCheckReport(&out, status.Report{Errors: []string{"certificate expires in 3 days"}, Severity: status.SeverityWarning})
+	if code != 1 || out.String() != "Report: FAILED (Warning)\n  error: certificate expires in 3 days\n" {
+		t.Fatalf("expected a failed report with exit code 1, got %d:\n%s", code, out.String())
+	}
+}
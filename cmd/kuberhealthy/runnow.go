@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/webauth"
+)
+
+// runPath is where runs of checks are triggered, with the namespace and name of the check in the URL path
+// (i.e. /run/kuberhealthy/daemonset)
+const runPath = "/run/"
+
+// runForwardedHeader marks run requests forwarded by another kuberhealthy pod with the name of that pod.  Forwarded
+// requests are not forwarded again.
+const runForwardedHeader = "kh-run-forwarded-by"
+
+// runRequestedByHeader carries the user that requested a forwarded run.  It is only trusted on requests that are
+// authenticated with the service account token of kuberhealthy.
+const runRequestedByHeader = "kh-run-requested-by"
+
+// serviceAccountTokenFile is the service account token kuberhealthy pods authenticate the run requests they forward
+// with, instead of the credentials of the user
+var serviceAccountTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+// runHandler starts a run of a check right away, outside of its interval or schedule.  Checks are only run by one
+// kuberhealthy pod, so requests to other pods are forwarded to it.  Since runs create pods in the cluster, runs can
+// only be triggered when the web server requires authentication.
+func (k *Kuberhealthy) runHandler(w http.ResponseWriter, r *http.Request) error {
+	log.Infoln("Client connected to run endpoint from", r.RemoteAddr, r.UserAgent())
+
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return nil
+	}
+	if k.webAuth == nil {
+		http.Error(w, "triggering check runs requires web server authentication", http.StatusForbidden)
+		return nil
+	}
+
+	namespace, name, ok := parseNamespacedPath(runPath, r.URL.Path)
+	if !ok {
+		http.Error(w, "expected a check as "+runPath+"{namespace}/{name}", http.StatusNotFound)
+		return nil
+	}
+	check := namespace + "/" + name
+
+	runner := checkRunner(check)
+	if len(runner) > 0 && runner != podHostname {
+		if forwardedBy := r.Header.Get(runForwardedHeader); len(forwardedBy) > 0 {
+			http.Error(w, "check "+check+" is run by kuberhealthy pod "+runner, http.StatusServiceUnavailable)
+			return fmt.Errorf("run of check %s forwarded by %s to a pod that does not run it", check, forwardedBy)
+		}
+		return k.forwardRun(w, r, kubernetesClient, runner)
+	}
+
+	var by string
+	if user, ok := webauth.UserFromContext(r.Context()); ok {
+		by = user.Name
+	}
+	if !k.checkSchedules.runNow(check, by) {
+		http.Error(w, "check "+check+" is not running", http.StatusNotFound)
+		return nil
+	}
+	log.Infoln("Triggered a run of check", name, "in namespace", namespace, "for", by)
+	w.WriteHeader(http.StatusAccepted)
+	_, err := fmt.Fprintln(w, "triggered a run of check", check)
+	return err
+}
+
+// checkRunner returns the name of the kuberhealthy pod that runs a check.  Checks are run by the master, or by the
+// pod they are assigned to when checks are sharded.  Blank is returned when the master is not known yet.
+func checkRunner(check string) string {
+	if shardChecks {
+		return shardOwner(check)
+	}
+	return getCurrentLeader()
+}
+
+// forwardRun forwards a run request to the kuberhealthy pod that runs the check and writes the response of that pod.
+// The request is authenticated with the service account token of this pod and names the user that requested the
+// run.  Requests are only forwarded over TLS, since the token is sent along.
+func (k *Kuberhealthy) forwardRun(w http.ResponseWriter, r *http.Request, client kubernetes.Interface, runner string) error {
+	k.webServerMu.Lock()
+	listenAddr, useTLS := k.ListenAddr, len(k.tlsCertFile) > 0
+	k.webServerMu.Unlock()
+	var tlsConfig *tls.Config
+	var err error
+	if useTLS {
+		tlsConfig, err = runForwardTLSConfig(k.config)
+	}
+	if !useTLS || err != nil {
+		http.Error(w, "check is run by kuberhealthy pod "+runner+", which run requests can not be forwarded to", http.StatusServiceUnavailable)
+		if err == nil {
+			err = errors.New("forwarding run requests requires tlsCertFile")
+		}
+		return fmt.Errorf("failed to forward run request to kuberhealthy pod %s: %w", runner, err)
+	}
+	_, port, err := net.SplitHostPort(listenAddr)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return fmt.Errorf("failed to parse the port of listen address %s: %w", listenAddr, err)
+	}
+	token, err := os.ReadFile(serviceAccountTokenFile)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return fmt.Errorf("failed to read the service account token to forward a run request with: %w", err)
+	}
+
+	pod, err := client.CoreV1().Pods(podNamespace).Get(r.Context(), runner, metav1.GetOptions{})
+	if err != nil || len(pod.Status.PodIP) == 0 {
+		http.Error(w, "failed to find kuberhealthy pod "+runner+" that runs the check", http.StatusBadGateway)
+		return fmt.Errorf("failed to find the IP of kuberhealthy pod %s to forward a run request to: %v", runner, err)
+	}
+
+	u := "https://" + net.JoinHostPort(pod.Status.PodIP, port) + r.URL.Path
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, u, nil)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return fmt.Errorf("failed to create run request for %s: %w", u, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set(runForwardedHeader, podHostname)
+	if user, ok := webauth.UserFromContext(r.Context()); ok {
+		req.Header.Set(runRequestedByHeader, user.Name)
+	}
+
+	log.Infoln("Forwarding run request", r.URL.Path, "to kuberhealthy pod", runner)
+	forwardClient := &http.Client{
+		Timeout:   time.Second * 10,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		// the service account token must not follow redirects to other hosts
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := forwardClient.Do(req)
+	if err != nil {
+		http.Error(w, "failed to forward the run request to kuberhealthy pod "+runner, http.StatusBadGateway)
+		return fmt.Errorf("failed to forward run request to kuberhealthy pod %s: %w", runner, err)
+	}
+	defer resp.Body.Close()
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// runForwardTLSConfig returns the TLS configuration run requests are forwarded to other kuberhealthy pods with.  Their
+// certificate is verified with the reporting CA, which checker pods verify it with as well.
+func runForwardTLSConfig(c *Config) (*tls.Config, error) {
+	if c == nil || len(c.reportingCA) == 0 {
+		return nil, errors.New("forwarding run requests requires tlsReportingCAFile to verify kuberhealthy pods with")
+	}
+	serverName, err := kuberhealthyServiceHost(c)
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM([]byte(c.reportingCA)) {
+		return nil, errors.New("tlsReportingCAFile does not contain a PEM certificate")
+	}
+	return &tls.Config{RootCAs: roots, ServerName: serverName, MinVersion: tls.VersionTLS12}, nil
+}
+
+// kuberhealthyServiceHost returns the DNS name of the kuberhealthy service, which is the host of the reporting URL.
+// Kuberhealthy pods forward requests to each other by their IP, so their certificates are verified against it.
+func kuberhealthyServiceHost(c *Config) (string, error) {
+	if c == nil {
+		return "", errors.New("no configuration to find the kuberhealthy service DNS name in")
+	}
+	u, err := url.Parse(c.ExternalCheckReportingURL)
+	if err != nil || len(u.Hostname()) == 0 {
+		return "", fmt.Errorf("failed to find the kuberhealthy service DNS name in the reporting URL %q", c.ExternalCheckReportingURL)
+	}
+	return u.Hostname(), nil
+}
+
+// runAuthenticated wraps the run handler so that run requests forwarded by other kuberhealthy pods are authenticated
+// with the service account token of the forwarding pod, and run the check for the user named by the request.  Other
+// requests are authenticated like all web requests.
+func (k *Kuberhealthy) runAuthenticated(client kubernetes.Interface, h http.HandlerFunc) http.HandlerFunc {
+	authenticated := k.authenticated(h)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(r.Header.Get(runForwardedHeader)) == 0 {
+			authenticated(w, r)
+			return
+		}
+		err := authenticateForwardedRun(r.Context(), client, r)
+		if err != nil {
+			log.Warningln("Rejected run request forwarded by", r.Header.Get(runForwardedHeader), "from", r.RemoteAddr+":", err)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		user := webauth.User{Name: r.Header.Get(runRequestedByHeader)}
+		h(w, r.WithContext(webauth.ContextWithUser(r.Context(), user)))
+	}
+}
+
+// authenticateForwardedRun ensures a forwarded run request is authenticated with a token of the service account this
+// kuberhealthy pod runs as.  The token is validated with a TokenReview.
+func authenticateForwardedRun(ctx context.Context, client kubernetes.Interface, r *http.Request) error {
+	if client == nil {
+		return errors.New("no kubernetes client to validate the token with")
+	}
+	token, ok := webauth.BearerToken(r)
+	if !ok {
+		return errors.New("no bearer token")
+	}
+	serviceAccount, err := serviceAccountUsername()
+	if err != nil {
+		return err
+	}
+	review := &authenticationv1.TokenReview{Spec: authenticationv1.TokenReviewSpec{Token: token}}
+	result, err := client.AuthenticationV1().TokenReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to create TokenReview: %w", err)
+	}
+	if !result.Status.Authenticated {
+		return errors.New("TokenReview did not authenticate the token: " + result.Status.Error)
+	}
+	if result.Status.User.Username != serviceAccount {
+		return errors.New("the token belongs to " + result.Status.User.Username + " instead of " + serviceAccount)
+	}
+	return nil
+}
+
+// serviceAccountUsername returns the user name of the service account this kuberhealthy pod runs as, such as
+// system:serviceaccount:kuberhealthy:kuberhealthy, from the subject of its token
+func serviceAccountUsername() (string, error) {
+	token, err := os.ReadFile(serviceAccountTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the service account token: %w", err)
+	}
+	parts := strings.Split(strings.TrimSpace(string(token)), ".")
+	if len(parts) != 3 {
+		return "", errors.New("the service account token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("failed to decode the service account token: %w", err)
+	}
+	var claims struct {
+		Subject string `json:"sub"`
+	}
+	err = json.Unmarshal(payload, &claims)
+	if err != nil || !strings.HasPrefix(claims.Subject, "system:serviceaccount:") {
+		return "", errors.New("the service account token does not have a service account subject")
+	}
+	return claims.Subject, nil
+}
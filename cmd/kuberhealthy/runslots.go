@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// maxConcurrentChecks is the most khcheck runs that each Kuberhealthy pod has in flight at once.  Runs that are due
+// while that many are in flight are queued until a run completes.  When 0, runs are never queued.
+var maxConcurrentChecks int
+
+// runSlots limits the khcheck runs in flight at once.  Each run in flight holds a slot.
+type runSlots chan struct{}
+
+// newRunSlots creates the slots for up to max runs in flight.  nil is returned when max is 0, which allows any number
+// of runs.
+func newRunSlots(max int) runSlots {
+	if max <= 0 {
+		return nil
+	}
+	return make(runSlots, max)
+}
+
+// validateMaxConcurrentChecksFlag ensures maxConcurrentChecks is not negative
+func validateMaxConcurrentChecksFlag() error {
+	if maxConcurrentChecks < 0 {
+		return errors.New("maxConcurrentChecks must not be negative")
+	}
+	return nil
+}
+
+// acquireRunSlot waits for a free slot for a run of the check and returns the func that frees it again once the run
+// is done.  While the run waits, the khstate of the check is marked pending.  Returns false when the context is
+// canceled before a slot is free.
+func (k *Kuberhealthy) acquireRunSlot(ctx context.Context, slots runSlots, c *external.Checker) (func(), bool) {
+	if slots == nil {
+		return func() {}, true
+	}
+	release := func() { <-slots }
+
+	select {
+	case slots <- struct{}{}:
+		return release, true
+	default:
+	}
+
+	c.Logger().Infoln("Queueing run of check", c.Name(), "in namespace", c.CheckNamespace(), "because", cap(slots), "check runs are in flight")
+	k.setCheckPendingState(c, true)
+	defer k.setCheckPendingState(c, false)
+	select {
+	case slots <- struct{}{}:
+		c.Logger().Infoln("Starting queued run of check", c.Name(), "in namespace", c.CheckNamespace())
+		return release, true
+	case <-ctx.Done():
+		return nil, false
+	}
+}
+
+// setCheckPendingState marks the khstate of a check as pending while its run is queued or clears the mark once the
+// run starts
+func (k *Kuberhealthy) setCheckPendingState(c *external.Checker, pending bool) {
+	err := setKHStatePending(c.Name(), c.CheckNamespace(), pending)
+	if err != nil {
+		c.Logger().Errorln("Error storing the pending state of check", c.Name(), "in namespace", c.CheckNamespace()+":", err)
+		return
+	}
+	k.statusCache.invalidate()
+}
+
+// setKHStatePending sets the pending mark of a khstate, creating the khstate if it does not exist yet.  The rest of
+// the khstate is left as the last run stored it.
+func setKHStatePending(checkName string, checkNamespace string, pending bool) error {
+	err := ensureStateResourceExists(checkName, checkNamespace, khstatev1.KHCheck)
+	if err != nil {
+		return err
+	}
+
+	// a run may update the khstate at the same time, so we retry on conflicts
+	name := sanitizeResourceName(checkName)
+	for tries := 0; tries < 5; tries++ {
+		var khState khstatev1.KuberhealthyState
+		khState, err = khStateClient.KuberhealthyStates(checkNamespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return errors.New("Error retrieving CRD for: " + name + " " + err.Error())
+		}
+		if khState.Spec.Pending == pending {
+			return nil
+		}
+		if len(khState.Spec.Namespace) == 0 {
+			khState.Spec.Namespace = checkNamespace
+		}
+		khState.Spec.Pending = pending
+		_, err = khStateClient.KuberhealthyStates(checkNamespace).Update(&khState)
+		if err == nil || !strings.Contains(err.Error(), "the object has been modified") {
+			return err
+		}
+		time.Sleep(time.Second)
+	}
+	return err
+}
+
+// applyPendingChecks lists the checks of the state whose run is queued.  Queued checks keep the result of their last
+// run, so the global OK state is not changed.
+func applyPendingChecks(state health.State) health.State {
+	for name, details := range state.CheckDetails {
+		if details.Pending {
+			state.PendingChecks = append(state.PendingChecks, name)
+		}
+	}
+	sort.Strings(state.PendingChecks)
+	return state
+}
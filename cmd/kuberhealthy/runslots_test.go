@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+func TestAcquireRunSlot(t *testing.T) {
+	kh := &Kuberhealthy{}
+	c := &external.Checker{CheckName: "dns", Namespace: "kuberhealthy"}
+
+	if newRunSlots(0) != nil {
+		t.Fatal("expected no run slots when runs are not limited")
+	}
+	release, ok := kh.acquireRunSlot(context.Background(), nil, c)
+	if !ok {
+		t.Fatal("expected a run to start right away when runs are not limited")
+	}
+	release()
+
+	slots := newRunSlots(2)
+	first, ok := kh.acquireRunSlot(context.Background(), slots, c)
+	if !ok {
+		t.Fatal("expected the first run to get a slot")
+	}
+	second, ok := kh.acquireRunSlot(context.Background(), slots, c)
+	if !ok {
+		t.Fatal("expected the second run to get a slot")
+	}
+	if len(slots) != 2 {
+		t.Fatal("expected both slots to be held but got", len(slots))
+	}
+	first()
+	second()
+	if len(slots) != 0 {
+		t.Fatal("expected both slots to be freed but got", len(slots))
+	}
+}
+
+func TestApplyPendingChecks(t *testing.T) {
+	state := health.NewState()
+	state.OK = true
+	state.CheckDetails["kuberhealthy/dns"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", OK: true, Pending: true}
+	state.CheckDetails["kuberhealthy/daemonset"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", OK: true, Pending: true}
+	state.CheckDetails["kuberhealthy/pod-status"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", OK: true}
+
+	state = applyPendingChecks(state)
+	if len(state.PendingChecks) != 2 || state.PendingChecks[0] != "kuberhealthy/daemonset" || state.PendingChecks[1] != "kuberhealthy/dns" {
+		t.Fatal("expected the queued checks to be listed as pending but got", state.PendingChecks)
+	}
+	if !state.OK {
+		t.Fatal("expected pending checks to not change the global OK state")
+	}
+	if status := dashboardStatus(state.CheckDetails["kuberhealthy/dns"]); status != "Pending" {
+		t.Fatal("expected a queued check to show as Pending on the dashboard but got", status)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/runstore"
+)
+
+// runHistoryPath is the path of the endpoint that serves the stored run history of a check with its uptime and trend,
+// with the namespace and name of the check in the URL path (i.e. /api/v1/history/kuberhealthy/daemonset)
+const runHistoryPath = "/api/v1/history/"
+
+// runStoreBackend is the backend the results of check runs are stored with, memory or bolt.  Results are only kept
+// in the khstate of checks when blank.
+var runStoreBackend string
+
+// runStorePath is the file the bolt backend keeps results in
+var runStorePath = "/var/lib/kuberhealthy/runs.db"
+
+// runStoreRetention is how long the results of check runs are kept in the run store
+var runStoreRetention = time.Hour * 24 * 30
+
+// runStorePruneInterval is how often results older than runStoreRetention are removed from the run store
+const runStorePruneInterval = time.Hour
+
+// defaultRunHistoryPeriod is the period the run history endpoint serves when no period is requested
+const defaultRunHistoryPeriod = time.Hour * 24
+
+// maxRunHistoryTrendPeriods is the most periods a trend of the run history endpoint is split into
+const maxRunHistoryTrendPeriods = 1000
+
+// validateRunStoreFlags ensures the run store flags name a known backend and a usable retention
+func validateRunStoreFlags() error {
+	switch runStoreBackend {
+	case "", runstore.BackendMemory:
+	case runstore.BackendBolt:
+		if len(runStorePath) == 0 {
+			return errors.New("runStorePath is required with the bolt run store")
+		}
+	default:
+		return fmt.Errorf("unknown runStore %q. must be %s or %s", runStoreBackend, runstore.BackendMemory, runstore.BackendBolt)
+	}
+	if runStoreRetention <= 0 {
+		return errors.New("runStoreRetention must be positive")
+	}
+	return nil
+}
+
+// newRunStore opens the run store of the run store flags.  Nil is returned when no run store is configured.
+func newRunStore() (runstore.Store, error) {
+	if len(runStoreBackend) == 0 {
+		return nil, nil
+	}
+	log.Infoln("Storing the results of check runs with the", runStoreBackend, "run store")
+	return runstore.Open(runStoreBackend, runStorePath)
+}
+
+// recordRunResult records the result of a check run in the run store, if one is configured, and in the history of
+// its khstate.  Repeated reports of the same run update its result instead of adding another one.
+func (k *Kuberhealthy) recordRunResult(checkName string, checkNamespace string, uuid string, update func(r *khstatev1.RunResult, found bool)) error {
+	if k.runStore != nil {
+		err := k.runStore.Update(checkNamespace+"/"+checkName, uuid, time.Now(), update)
+		if err != nil {
+			log.Errorln("Error storing the result of run", uuid, "of check", checkNamespace+"/"+checkName, "in the run store:", err)
+		}
+	}
+	return recordKHStateRunResult(checkName, checkNamespace, uuid, update)
+}
+
+// pruneRunStore removes results older than runStoreRetention from the run store every runStorePruneInterval until
+// the context is canceled
+func (k *Kuberhealthy) pruneRunStore(ctx context.Context) {
+	ticker := time.NewTicker(runStorePruneInterval)
+	defer ticker.Stop()
+	for {
+		pruned, err := k.runStore.Prune(time.Now().Add(-runStoreRetention))
+		if err != nil {
+			log.Errorln("Error pruning the run store:", err)
+		} else if pruned > 0 {
+			log.Infoln("Pruned", pruned, "check run results older than", runStoreRetention, "from the run store")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runHistory is the stored run history of a check served by the run history endpoint
+type runHistory struct {
+	Check   string
+	Since   time.Time
+	Summary runstore.Summary      // the uptime of the check over the whole period
+	Trend   []runstore.Summary    `json:",omitempty"` // the uptime of the check in each step of the period. only set when a step is requested
+	Results []khstatev1.RunResult `json:",omitempty"` // the results of the runs in the period, oldest first
+}
+
+// runHistoryHandler serves the results of the runs of a check kept in the run store, along with its uptime over the
+// requested period and, when a step is requested, its trend.  The period defaults to a day and is set with the period
+// query parameter (i.e. ?period=168h&step=1h).
+func (k *Kuberhealthy) runHistoryHandler(w http.ResponseWriter, r *http.Request) error {
+	log.Infoln("Client connected to run history endpoint from", r.RemoteAddr, r.UserAgent())
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return nil
+	}
+	if k.runStore == nil {
+		http.Error(w, "no run store is configured", http.StatusNotFound)
+		return nil
+	}
+
+	namespace, name, ok := parseNamespacedPath(runHistoryPath, r.URL.Path)
+	if !ok {
+		http.Error(w, "expected a check as "+runHistoryPath+"{namespace}/{name}", http.StatusNotFound)
+		return nil
+	}
+
+	period, step, err := parseRunHistoryQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil
+	}
+
+	now := time.Now()
+	since := now.Add(-period)
+	check := namespace + "/" + name
+	results, err := k.runStore.Results(check, since)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return fmt.Errorf("failed to read the run history of check %s: %w", check, err)
+	}
+	history := runHistory{
+		Check:   check,
+		Since:   since,
+		Summary: runstore.Summarize(since, results),
+		Results: results,
+	}
+	if step > 0 {
+		history.Trend = runstore.Trend(results, since, now, step)
+	}
+
+	b, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return fmt.Errorf("failed to marshal the run history of check %s: %w", check, err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(b)
+	return err
+}
+
+// parseRunHistoryQuery parses the period and step of a run history request.  The step is zero when no trend is
+// requested.
+func parseRunHistoryQuery(r *http.Request) (time.Duration, time.Duration, error) {
+	period := defaultRunHistoryPeriod
+	var step time.Duration
+	var err error
+	if v := r.URL.Query().Get("period"); len(v) > 0 {
+		period, err = time.ParseDuration(v)
+		if err != nil || period <= 0 {
+			return 0, 0, fmt.Errorf("invalid period %q: must be a positive duration such as 24h", v)
+		}
+	}
+	if v := r.URL.Query().Get("step"); len(v) > 0 {
+		step, err = time.ParseDuration(v)
+		if err != nil || step <= 0 {
+			return 0, 0, fmt.Errorf("invalid step %q: must be a positive duration such as 1h", v)
+		}
+		if period/step > maxRunHistoryTrendPeriods {
+			return 0, 0, fmt.Errorf("step %s splits the period of %s into more than %d steps", step, period, maxRunHistoryTrendPeriods)
+		}
+	}
+	return period, step, nil
+}
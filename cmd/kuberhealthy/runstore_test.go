@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/runstore"
+)
+
+func TestValidateRunStoreFlags(t *testing.T) {
+	previousBackend, previousPath, previousRetention := runStoreBackend, runStorePath, runStoreRetention
+	defer func() {
+		runStoreBackend, runStorePath, runStoreRetention = previousBackend, previousPath, previousRetention
+	}()
+
+	for _, tc := range []struct {
+		backend   string
+		path      string
+		retention time.Duration
+		valid     bool
+	}{
+		{"", "", time.Hour, true},
+		{"memory", "", time.Hour, true},
+		{"bolt", "/var/lib/kuberhealthy/runs.db", time.Hour, true},
+		{"bolt", "", time.Hour, false},
+		{"sqlite", "", time.Hour, false},
+		{"memory", "", 0, false},
+	} {
+		runStoreBackend, runStorePath, runStoreRetention = tc.backend, tc.path, tc.retention
+		err := validateRunStoreFlags()
+		if (err == nil) != tc.valid {
+			t.Fatal("expected runStore", tc.backend, "with path", tc.path, "and retention", tc.retention, "to be valid:", tc.valid, "but got", err)
+		}
+	}
+}
+
+func TestRunHistoryHandler(t *testing.T) {
+	kh := &Kuberhealthy{}
+	w := httptest.NewRecorder()
+	err := kh.runHistoryHandler(w, httptest.NewRequest(http.MethodGet, runHistoryPath+"kuberhealthy/dns", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusNotFound {
+		t.Fatal("expected a 404 without a run store but got", w.Code)
+	}
+
+	kh.runStore = runstore.NewMemory()
+	now := time.Now()
+	for i, ok := range []bool{true, false, true, true} {
+		err = kh.runStore.Update("kuberhealthy/dns", "", now.Add(-time.Duration(i)*time.Hour), func(r *khstatev1.RunResult, _ bool) {
+			r.OK = ok
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	err = kh.runHistoryHandler(w, httptest.NewRequest(http.MethodGet, runHistoryPath+"kuberhealthy/dns?period=150m&step=1h", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK {
+		t.Fatal("expected a 200 but got", w.Code, w.Body.String())
+	}
+	var history runHistory
+	err = json.Unmarshal(w.Body.Bytes(), &history)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history.Check != "kuberhealthy/dns" || history.Summary.Runs != 3 || history.Summary.Failed != 1 || len(history.Results) != 3 {
+		t.Fatalf("expected the 3 runs of the last 150 minutes but got %+v", history)
+	}
+	if len(history.Trend) != 3 {
+		t.Fatal("expected an hourly trend of the period but got", history.Trend)
+	}
+
+	for _, query := range []string{"?period=-1h", "?step=banana", "?period=24h&step=1s"} {
+		w = httptest.NewRecorder()
+		err = kh.runHistoryHandler(w, httptest.NewRequest(http.MethodGet, runHistoryPath+"kuberhealthy/dns"+query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != http.StatusBadRequest {
+			t.Fatal("expected a 400 for", query, "but got", w.Code)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// applyWarningSeverity lists the checks that failed with the Warning severity on the status page and moves their
+// errors from the global errors to the global warnings, so that they are visible without making the cluster
+// unhealthy
+func applyWarningSeverity(state health.State) health.State {
+	var warnings bool
+	for name, details := range state.CheckDetails {
+		if details.OK || details.Severity != status.SeverityWarning {
+			continue
+		}
+		warnings = true
+		if details.Suppressed || details.Paused || len(details.SuppressedByDependencies) > 0 {
+			continue
+		}
+		state.WarningChecks = append(state.WarningChecks, name)
+	}
+	for _, details := range state.JobDetails {
+		if !details.OK && details.Severity == status.SeverityWarning {
+			warnings = true
+		}
+	}
+	if !warnings {
+		return state
+	}
+	sort.Strings(state.WarningChecks)
+
+	// rebuild the global state so that the errors of the warning checks and jobs become warnings
+	return rebuildGlobalState(state)
+}
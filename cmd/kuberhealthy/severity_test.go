@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// TestApplyWarningSeverity ensures failures with the Warning severity are listed as warnings without making the
+// cluster unhealthy, and that critical failures still do
+func TestApplyWarningSeverity(t *testing.T) {
+	state := health.NewState()
+	state.OK = false
+	state.Errors = []string{"certificate expires in 10 days", "job warning"}
+	state.CheckDetails["kuberhealthy/cert"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", Errors: []string{"certificate expires in 10 days"}, Severity: status.SeverityWarning}
+	state.CheckDetails["kuberhealthy/dns"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", OK: true}
+	state.JobDetails["kuberhealthy/job"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", Errors: []string{"job warning"}, Severity: status.SeverityWarning}
+
+	state = applyWarningSeverity(state)
+	if !state.OK || len(state.Errors) != 0 {
+		t.Fatal("expected warnings to leave the cluster healthy but got", state.OK, state.Errors)
+	}
+	if len(state.Warnings) != 2 {
+		t.Fatal("expected the errors of the check and job as warnings but got", state.Warnings)
+	}
+	if !reflect.DeepEqual(state.WarningChecks, []string{"kuberhealthy/cert"}) {
+		t.Fatal("expected the certificate check to be listed as a warning but got", state.WarningChecks)
+	}
+
+	state.CheckDetails["kuberhealthy/dns"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", Errors: []string{"lookup failed"}, Severity: status.SeverityCritical}
+	state.WarningChecks = nil
+	state = applyWarningSeverity(state)
+	if state.OK || !reflect.DeepEqual(state.Errors, []string{"lookup failed"}) {
+		t.Fatal("expected a critical failure to make the cluster unhealthy but got", state.OK, state.Errors)
+	}
+}
+
+// TestApplyWarningSeverityNone ensures the state is left alone when no check failed with the Warning severity
+func TestApplyWarningSeverityNone(t *testing.T) {
+	state := health.NewState()
+	state.OK = false
+	state.Errors = []string{"lookup failed"}
+	state.CheckDetails["kuberhealthy/dns"] = khstatev1.WorkloadDetails{Namespace: "kuberhealthy", Errors: []string{"lookup failed"}}
+
+	state = applyWarningSeverity(state)
+	if state.OK || len(state.Warnings) != 0 || len(state.WarningChecks) != 0 {
+		t.Fatal("expected the state to be unchanged but got", state)
+	}
+}
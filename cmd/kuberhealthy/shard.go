@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/masterCalculation"
+)
+
+// shardChecks indicates that checks are spread over all running Kuberhealthy pods instead of all being run by the
+// master.  The master still runs khjobs and the reapers.
+var shardChecks bool
+
+// shardPodSelector is the label selector of the Kuberhealthy pods that checks are sharded over.  When blank, the pods
+// are selected by the labels of this pod.
+var shardPodSelector string
+
+// shardRevisionLabels are set by controllers on the pods of one revision of a workload.  They are left out when the
+// Kuberhealthy pods are selected by the labels of this pod, so that the pods of a rolling update find each other.
+var shardRevisionLabels = []string{
+	"pod-template-hash",
+	"controller-revision-hash",
+	"pod-template-generation",
+	"statefulset.kubernetes.io/pod-name",
+	"apps.kubernetes.io/pod-index",
+}
+
+// shardMembers holds the names of the running Kuberhealthy pods that checks are sharded over
+var shardMembers []string
+
+// shardRunningPods holds the names of all running Kuberhealthy pods, including those that are shutting down and no
+// longer have checks assigned but may still have runs in flight
+var shardRunningPods []string
+var shardMembersMu sync.RWMutex
+
+// setShardMembers replaces the running Kuberhealthy pods that checks are sharded over and all running Kuberhealthy
+// pods
+func setShardMembers(members []string, running []string) {
+	shardMembersMu.Lock()
+	defer shardMembersMu.Unlock()
+	shardMembers = members
+	shardRunningPods = running
+}
+
+// currentShardMembers returns the running Kuberhealthy pods that checks are sharded over
+func currentShardMembers() []string {
+	shardMembersMu.RLock()
+	defer shardMembersMu.RUnlock()
+	return shardMembers
+}
+
+// currentShardRunningPods returns all running Kuberhealthy pods, including those that are shutting down
+func currentShardRunningPods() []string {
+	shardMembersMu.RLock()
+	defer shardMembersMu.RUnlock()
+	return shardRunningPods
+}
+
+// refreshShardMembers fetches the running Kuberhealthy pods that checks are sharded over
+func refreshShardMembers(client kubernetes.Interface) {
+	selector, err := shardPodLabelSelector(context.TODO(), client)
+	if err != nil {
+		log.Errorln("sharding:", err)
+		return
+	}
+	members, running, err := listShardPods(context.TODO(), client, podNamespace, selector)
+	if err != nil {
+		log.Errorln("sharding: failed to list kuberhealthy pods:", err)
+		return
+	}
+	if len(members) == 0 {
+		log.Warnln("sharding: no running kuberhealthy pods match the selector", selector+". The master runs all checks.")
+	}
+	log.Debugln("sharding: checks are sharded over", members)
+	setShardMembers(members, running)
+}
+
+// shardPodLabelSelector returns the label selector of the Kuberhealthy pods that checks are sharded over.  Without a
+// shardPodSelector, the pods are selected by the labels of this pod.
+func shardPodLabelSelector(ctx context.Context, client kubernetes.Interface) (string, error) {
+	if len(shardPodSelector) > 0 {
+		return shardPodSelector, nil
+	}
+	pod, err := client.CoreV1().Pods(podNamespace).Get(ctx, podHostname, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch the labels of this pod to select the kuberhealthy pods by: %w", err)
+	}
+	return podLabelSelector(pod.Labels)
+}
+
+// podLabelSelector makes a label selector from the labels of a pod, without the labels of its revision
+func podLabelSelector(podLabels map[string]string) (string, error) {
+	set := labels.Set{}
+	for k, v := range podLabels {
+		set[k] = v
+	}
+	for _, k := range shardRevisionLabels {
+		delete(set, k)
+	}
+	if len(set) == 0 {
+		return "", errors.New("this pod has no labels to select the kuberhealthy pods by. Set shardPodSelector instead")
+	}
+	return labels.SelectorFromSet(set).String(), nil
+}
+
+// listShardPods lists the names of the running Kuberhealthy pods matching the selector in the namespace in
+// alphabetical order.  members leaves out the pods that are shutting down, so that their checks move to the other
+// pods right away instead of once they are gone.
+func listShardPods(ctx context.Context, client kubernetes.Interface, namespace string, selector string) (members []string, running []string, err error) {
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector, FieldSelector: "status.phase=Running",
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, p := range pods.Items {
+		if p.Status.Phase != v1.PodRunning {
+			continue
+		}
+		running = append(running, p.Name)
+		if p.DeletionTimestamp == nil {
+			members = append(members, p.Name)
+		}
+	}
+	sort.Strings(members)
+	sort.Strings(running)
+	return members, running, nil
+}
+
+// shardOwner returns the Kuberhealthy pod that a check is assigned to.  When there are no pods to shard checks over,
+// such as when no pods match the shard pod selector, the master runs the check.  Blank is returned when the master is
+// not known yet.
+func shardOwner(key string) string {
+	members := currentShardMembers()
+	if len(members) == 0 {
+		return getCurrentLeader()
+	}
+	return masterCalculation.ShardOwner(key, members)
+}
+
+// ownsCheckShard determines if this Kuberhealthy pod should run the next run of a check.  The check must be
+// assigned to this pod and no other running Kuberhealthy pod may still have a run of the check in flight.  A check
+// that was reassigned after the pod set changed is only picked up once the previous owner's run has finished.
+func (k *Kuberhealthy) ownsCheckShard(ctx context.Context, c *external.Checker) bool {
+	owner := shardOwner(c.CheckNamespace() + "/" + c.Name())
+	if owner != podHostname {
+		log.Debugln("sharding: skipping run of check", c.CheckNamespace()+"/"+c.Name(), "because it is assigned to", owner)
+		return false
+	}
+
+	state, err := getCheckState(c)
+	if err != nil {
+		log.Errorln("sharding: failed to fetch khstate of check", c.CheckNamespace()+"/"+c.Name()+":", err)
+		return false
+	}
+	if runInFlightElsewhere(ctx, kubernetesClient, c.CheckNamespace(), state, currentShardRunningPods()) {
+		log.Infoln("sharding: skipping run of check", c.CheckNamespace()+"/"+c.Name(), "because", state.AuthoritativePod, "is still running it")
+		return false
+	}
+	return true
+}
+
+// runInFlightElsewhere determines if another running Kuberhealthy pod has a run of a check in flight.  The khstate
+// records the pod that started the current run and the UUID of that run, which labels its checker pod.  Pods that
+// are shutting down are among the running pods, since they finish or abort their runs before they are gone.
+func runInFlightElsewhere(ctx context.Context, client kubernetes.Interface, namespace string, state khstatev1.WorkloadDetails, running []string) bool {
+	if len(state.AuthoritativePod) == 0 || state.AuthoritativePod == podHostname || len(state.CurrentUUID) == 0 {
+		return false
+	}
+
+	// if the pod that started the run has gone away, its run will never finish
+	if !containsString(state.AuthoritativePod, running) {
+		return false
+	}
+
+	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: "kuberhealthy-run-id=" + state.CurrentUUID,
+	})
+	if err != nil {
+		// without knowing if the run has finished, we assume it is still in flight
+		log.Errorln("sharding: failed to list checker pods of run", state.CurrentUUID+":", err)
+		return true
+	}
+	for _, p := range pods.Items {
+		if p.Status.Phase == v1.PodPending || p.Status.Phase == v1.PodRunning {
+			return true
+		}
+	}
+	return false
+}
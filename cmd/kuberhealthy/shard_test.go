@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// TestRunInFlightElsewhere ensures that a check moved to this pod is not run while the previous owner's checker
+// pod is still running
+func TestRunInFlightElsewhere(t *testing.T) {
+	previousHostname := podHostname
+	podHostname = "kuberhealthy-a"
+	defer func() { podHostname = previousHostname }()
+
+	checkerPod := func(phase v1.PodPhase) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-check-1234",
+				Namespace: "test",
+				Labels:    map[string]string{"kuberhealthy-run-id": "1234"},
+			},
+			Status: v1.PodStatus{Phase: phase},
+		}
+	}
+	members := []string{"kuberhealthy-a", "kuberhealthy-b"}
+
+	tests := []struct {
+		name     string
+		state    khstatev1.WorkloadDetails
+		members  []string
+		pods     []*v1.Pod
+		expected bool
+	}{
+		{
+			name:     "never run",
+			state:    khstatev1.WorkloadDetails{},
+			members:  members,
+			expected: false,
+		},
+		{
+			name:     "run by this pod",
+			state:    khstatev1.WorkloadDetails{AuthoritativePod: "kuberhealthy-a", CurrentUUID: "1234"},
+			members:  members,
+			pods:     []*v1.Pod{checkerPod(v1.PodRunning)},
+			expected: false,
+		},
+		{
+			name:     "running on other pod",
+			state:    khstatev1.WorkloadDetails{AuthoritativePod: "kuberhealthy-b", CurrentUUID: "1234"},
+			members:  members,
+			pods:     []*v1.Pod{checkerPod(v1.PodRunning)},
+			expected: true,
+		},
+		{
+			name:     "pending on other pod",
+			state:    khstatev1.WorkloadDetails{AuthoritativePod: "kuberhealthy-b", CurrentUUID: "1234"},
+			members:  members,
+			pods:     []*v1.Pod{checkerPod(v1.PodPending)},
+			expected: true,
+		},
+		{
+			name:     "completed on other pod",
+			state:    khstatev1.WorkloadDetails{AuthoritativePod: "kuberhealthy-b", CurrentUUID: "1234"},
+			members:  members,
+			pods:     []*v1.Pod{checkerPod(v1.PodSucceeded)},
+			expected: false,
+		},
+		{
+			name:     "other pod gone",
+			state:    khstatev1.WorkloadDetails{AuthoritativePod: "kuberhealthy-c", CurrentUUID: "1234"},
+			members:  members,
+			pods:     []*v1.Pod{checkerPod(v1.PodRunning)},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset()
+			for _, p := range tt.pods {
+				_, err := client.CoreV1().Pods(p.Namespace).Create(context.Background(), p, metav1.CreateOptions{})
+				if err != nil {
+					t.Fatal("Failed to create checker pod:", err)
+				}
+			}
+
+			inFlight := runInFlightElsewhere(context.Background(), client, "test", tt.state, tt.members)
+			if inFlight != tt.expected {
+				t.Fatalf("expected run in flight elsewhere to be %t, got %t", tt.expected, inFlight)
+			}
+		})
+	}
+}
+
+// TestListShardPods ensures that Kuberhealthy pods that are shutting down are not assigned checks
+func TestListShardPods(t *testing.T) {
+	now := metav1.Now()
+	kuberhealthyPod := func(name string, phase v1.PodPhase, deleted *metav1.Time) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "kuberhealthy", Labels: map[string]string{"app": "kuberhealthy"}, DeletionTimestamp: deleted},
+			Status:     v1.PodStatus{Phase: phase},
+		}
+	}
+	otherPod := kuberhealthyPod("other", v1.PodRunning, nil)
+	otherPod.Labels = map[string]string{"app": "other"}
+	client := fake.NewSimpleClientset(
+		kuberhealthyPod("kuberhealthy-c", v1.PodRunning, nil),
+		kuberhealthyPod("kuberhealthy-a", v1.PodRunning, nil),
+		kuberhealthyPod("kuberhealthy-b", v1.PodRunning, &now),
+		kuberhealthyPod("kuberhealthy-d", v1.PodPending, nil),
+		otherPod,
+	)
+
+	members, running, err := listShardPods(context.Background(), client, "kuberhealthy", "app=kuberhealthy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(members, []string{"kuberhealthy-a", "kuberhealthy-c"}) {
+		t.Fatal("expected the pods shutting down to not be shard members but got", members)
+	}
+	if !reflect.DeepEqual(running, []string{"kuberhealthy-a", "kuberhealthy-b", "kuberhealthy-c"}) {
+		t.Fatal("expected the pods shutting down to be among the running pods but got", running)
+	}
+
+	members, running, err = listShardPods(context.Background(), client, "kuberhealthy", "app=renamed")
+	if err != nil || len(members) != 0 || len(running) != 0 {
+		t.Fatal("expected no pods without an error when no pods match the selector but got", members, running, err)
+	}
+}
+
+// TestShardPodLabelSelector ensures that Kuberhealthy pods are selected by the shardPodSelector or by the labels of
+// this pod without the labels of its revision
+func TestShardPodLabelSelector(t *testing.T) {
+	previousHostname, previousNamespace, previousSelector := podHostname, podNamespace, shardPodSelector
+	podHostname, podNamespace, shardPodSelector = "kuberhealthy-a", "kuberhealthy", ""
+	defer func() {
+		podHostname, podNamespace, shardPodSelector = previousHostname, previousNamespace, previousSelector
+	}()
+
+	client := fake.NewSimpleClientset(&v1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "kuberhealthy-a",
+		Namespace: "kuberhealthy",
+		Labels:    map[string]string{"app.kubernetes.io/name": "kh", "release": "prod", "pod-template-hash": "5d8f7c"},
+	}})
+	selector, err := shardPodLabelSelector(context.Background(), client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if selector != "app.kubernetes.io/name=kh,release=prod" {
+		t.Fatal("expected the pods to be selected by the labels of this pod but got", selector)
+	}
+
+	shardPodSelector = "app=kuberhealthy"
+	selector, err = shardPodLabelSelector(context.Background(), client)
+	if err != nil || selector != "app=kuberhealthy" {
+		t.Fatal("expected the pods to be selected by the shardPodSelector but got", selector, err)
+	}
+
+	_, err = podLabelSelector(map[string]string{"pod-template-hash": "5d8f7c"})
+	if err == nil {
+		t.Fatal("expected an error for a pod with only the labels of its revision")
+	}
+}
+
+// TestShardOwnerFallsBackToMaster ensures that the master runs the checks when there are no pods to shard them over
+func TestShardOwnerFallsBackToMaster(t *testing.T) {
+	previousLeader := getCurrentLeader()
+	previousMembers, previousRunning := currentShardMembers(), currentShardRunningPods()
+	defer func() {
+		setCurrentLeader(previousLeader)
+		setShardMembers(previousMembers, previousRunning)
+	}()
+	setCurrentLeader("kuberhealthy-master")
+
+	setShardMembers(nil, nil)
+	if owner := shardOwner("test/check"); owner != "kuberhealthy-master" {
+		t.Fatal("expected the master to own checks without shard members but got", owner)
+	}
+
+	setShardMembers([]string{"kuberhealthy-a"}, []string{"kuberhealthy-a"})
+	if owner := shardOwner("test/check"); owner != "kuberhealthy-a" {
+		t.Fatal("expected the only shard member to own checks but got", owner)
+	}
+}
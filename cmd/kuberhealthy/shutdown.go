@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+)
+
+// shutdownGracePeriod is how long Kuberhealthy waits for the check runs in flight to complete when it shuts down.
+// Runs still in flight afterwards are aborted and their checker pods removed.
+var shutdownGracePeriod = time.Second * 30
+
+// shutdownCleanupTimeout is how long the checker pods and khstate of an aborted check run are cleaned up for
+const shutdownCleanupTimeout = time.Second * 15
+
+// abortedRunError is recorded in the run history of checks whose run was aborted because Kuberhealthy shut down
+const abortedRunError = "Run aborted because Kuberhealthy shut down"
+
+// checkRunTracker tracks the check runs in flight, so that they can be drained when Kuberhealthy shuts down
+type checkRunTracker struct {
+	mu   sync.Mutex
+	runs map[*checkRun]bool
+}
+
+// add starts tracking a check run
+func (t *checkRunTracker) add(run *checkRun) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.runs == nil {
+		t.runs = make(map[*checkRun]bool)
+	}
+	t.runs[run] = true
+}
+
+// remove stops tracking a check run once it is done
+func (t *checkRunTracker) remove(run *checkRun) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.runs, run)
+}
+
+// inFlight returns the tracked check runs
+func (t *checkRunTracker) inFlight() []*checkRun {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	runs := make([]*checkRun, 0, len(t.runs))
+	for run := range t.runs {
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// validateShutdownFlags validates the shutdown grace period, which has to leave time to abort the check runs in
+// flight before Kuberhealthy is forced to exit
+func validateShutdownFlags() error {
+	if shutdownGracePeriod < 0 {
+		return errors.New("shutdownGracePeriod must not be negative")
+	}
+	if shutdownGracePeriod >= terminationGracePeriod {
+		return fmt.Errorf("shutdownGracePeriod must be less than %s", terminationGracePeriod)
+	}
+	return nil
+}
+
+// drainCheckRuns waits up to the timeout for the check runs in flight to complete.  Returns false when runs were
+// still in flight at the timeout.
+func (k *Kuberhealthy) drainCheckRuns(timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		runs := k.checkRuns.inFlight()
+		if len(runs) == 0 {
+			return true
+		}
+		log.Infoln("shutdown: waiting for", len(runs), "check runs in flight to complete")
+		for _, run := range runs {
+			select {
+			case <-run.done:
+			case <-deadline:
+				return false
+			}
+		}
+	}
+}
+
+// abortCheckRun cleans up after a run of a check that was stopped because Kuberhealthy is shutting down.  The checker
+// pods of the run are evicted, a late report of the run is rejected and the run is recorded as aborted in the run
+// history of the check and the audit trail.  The result of the previous run is left on the khstate.
+func (k *Kuberhealthy) abortCheckRun(c *external.Checker, startTime time.Time, trigger runTrigger) {
+	// aborting the run moves the check to a new run UUID, so the run is logged with the UUID it ran with
+	runUUID := c.CurrentUUID()
+	runLog := external.CheckLogger(c.Name(), c.CheckNamespace(), runUUID)
+	runLog.Infoln("Aborting run of check", c.Name(), "in namespace", c.CheckNamespace(), "because Kuberhealthy is shutting down")
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownCleanupTimeout)
+	defer cancel()
+
+	err := c.Abort(ctx)
+	if err != nil {
+		runLog.Errorln("Error aborting run of check", c.Name(), "in namespace", c.CheckNamespace()+":", err)
+	}
+	err = k.recordRunResult(c.Name(), c.CheckNamespace(), runUUID, func(r *khstatev1.RunResult, _ bool) {
+		r.OK = false
+		r.Errors = []string{abortedRunError}
+		r.RunDuration = time.Since(startTime).String()
+	})
+	if err != nil {
+		runLog.Errorln("Error recording the aborted run of check", c.Name(), "in namespace", c.CheckNamespace()+":", err)
+	}
+	k.auditCheckRun(c, trigger, runUUID, startTime, auditResultAborted, []string{abortedRunError})
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDrainCheckRuns(t *testing.T) {
+	kh := &Kuberhealthy{}
+	if !kh.drainCheckRuns(time.Millisecond) {
+		t.Fatal("expected draining without check runs in flight to succeed")
+	}
+
+	first := &checkRun{done: make(chan struct{})}
+	second := &checkRun{done: make(chan struct{})}
+	kh.checkRuns.add(first)
+	kh.checkRuns.add(second)
+	if kh.drainCheckRuns(time.Millisecond * 20) {
+		t.Fatal("expected draining to time out while check runs are in flight")
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		close(first.done)
+		kh.checkRuns.remove(first)
+		time.Sleep(time.Millisecond * 10)
+		close(second.done)
+		kh.checkRuns.remove(second)
+	}()
+	if !kh.drainCheckRuns(time.Second * 5) {
+		t.Fatal("expected draining to succeed once the check runs in flight completed")
+	}
+	if runs := kh.checkRuns.inFlight(); len(runs) != 0 {
+		t.Fatal("expected no check runs to be tracked after they completed but got", len(runs))
+	}
+}
+
+func TestValidateShutdownFlags(t *testing.T) {
+	previous := shutdownGracePeriod
+	defer func() { shutdownGracePeriod = previous }()
+
+	for _, tc := range []struct {
+		period time.Duration
+		valid  bool
+	}{
+		{0, true},
+		{time.Second * 30, true},
+		{-time.Second, false},
+		{terminationGracePeriod, false},
+	} {
+		shutdownGracePeriod = tc.period
+		err := validateShutdownFlags()
+		if (err == nil) != tc.valid {
+			t.Fatal("expected shutdownGracePeriod", tc.period, "to be valid:", tc.valid, "but got", err)
+		}
+	}
+}
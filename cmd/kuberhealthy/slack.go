@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/webhook"
+)
+
+// slackWebhookURL is the Slack incoming webhook that a message is posted to when a check or job fails.  Slack
+// notifications are disabled when blank.
+var slackWebhookURL string
+
+// slackChannel overrides the channel of the incoming webhook when set
+var slackChannel string
+
+// slackNamespaces limits Slack notifications to workloads in these namespaces.  All namespaces notify when empty.
+var slackNamespaces []string
+
+// slackChecks limits Slack notifications to these workloads, given by name or namespace/name.  All workloads notify
+// when empty.
+var slackChecks []string
+
+// slackNotifier posts a message to Slack when a workload that matches its filters fails
+type slackNotifier struct {
+	sink       *webhook.Sink
+	channel    string
+	namespaces map[string]bool
+	checks     map[string]bool
+}
+
+// slackMessage is the payload of a Slack incoming webhook
+type slackMessage struct {
+	Channel     string            `json:"channel,omitempty"`
+	Text        string            `json:"text"`
+	Attachments []slackAttachment `json:"attachments,omitempty"`
+}
+
+// slackAttachment shows the details of a failed workload under the message text
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Fields []slackField `json:"fields"`
+}
+
+// slackField is a titled value of a slackAttachment
+type slackField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
+
+// newSlackNotifier creates the notifier that posts failures to Slack.  nil is returned when Slack notifications are
+// disabled.
+func newSlackNotifier() *slackNotifier {
+	if len(slackWebhookURL) == 0 {
+		return nil
+	}
+	n := &slackNotifier{
+		sink:       webhook.NewSink(webhook.SinkConfig{URL: slackWebhookURL, Proxy: outboundProxy()}),
+		channel:    slackChannel,
+		namespaces: make(map[string]bool),
+		checks:     make(map[string]bool),
+	}
+	for _, ns := range slackNamespaces {
+		n.namespaces[ns] = true
+	}
+	for _, c := range slackChecks {
+		n.checks[c] = true
+	}
+	log.Infoln("slack: sending failures to", n.sink.Name())
+	return n
+}
+
+// matches determines if a workload passes the namespace and check filters of the notifier
+func (n *slackNotifier) matches(name string, namespace string) bool {
+	if len(n.namespaces) > 0 && !n.namespaces[namespace] {
+		return false
+	}
+	if len(n.checks) > 0 && !n.checks[name] && !n.checks[namespace+"/"+name] {
+		return false
+	}
+	return true
+}
+
+// newSlackMessage builds the Slack message for a failed workload
+func newSlackMessage(name string, namespace string, channel string, details khstatev1.WorkloadDetails) slackMessage {
+	kind := "check"
+	if details.GetKHWorkload() == khstatev1.KHJob {
+		kind = "job"
+	}
+	text := fmt.Sprintf(":red_circle: Kuberhealthy %s *%s/%s* is failing", kind, namespace, name)
+	color := "danger"
+	if details.Severity == status.SeverityWarning {
+		text = fmt.Sprintf(":warning: Kuberhealthy %s *%s/%s* is failing with a warning", kind, namespace, name)
+		color = "warning"
+	}
+	if len(clusterName) > 0 {
+		text = text + " in cluster *" + clusterName + "*"
+	}
+
+	errors := "No errors were reported"
+	if len(details.Errors) > 0 {
+		errors = "• " + strings.Join(details.Errors, "\n• ")
+	}
+	duration := details.RunDuration
+	if len(duration) == 0 {
+		duration = "unknown"
+	}
+
+	return slackMessage{
+		Channel: channel,
+		Text:    text,
+		Attachments: []slackAttachment{{
+			Color: color,
+			Fields: []slackField{
+				{Title: "Check", Value: name, Short: true},
+				{Title: "Namespace", Value: namespace, Short: true},
+				{Title: "Duration", Value: duration, Short: true},
+				{Title: "Errors", Value: errors},
+			},
+		}},
+	}
+}
+
+// sendStateChangeSlack posts a message to Slack when a workload that matches the Slack filters changes from OK to
+// failing.  true is returned when a message was posted.
+func (k *Kuberhealthy) sendStateChangeSlack(name string, namespace string, previousOK bool, details khstatev1.WorkloadDetails) bool {
+	if k.slackNotifier == nil {
+		return false
+	}
+	if detectStateTransition(previousOK, details.OK) != transitionFailed {
+		return false
+	}
+	if !k.slackNotifier.matches(name, namespace) {
+		log.Debugln("slack: not sending failure of", namespace+"/"+name, "because it does not match the slack filters")
+		return false
+	}
+
+	log.Debugln("slack: sending failure of", namespace+"/"+name)
+	k.slackNotifier.sink.Send(newSlackMessage(name, namespace, k.slackNotifier.channel, details))
+	return true
+}
+
+// slackMetrics returns the Prometheus metrics of the Slack notifier.  A blank string is returned when Slack
+// notifications are disabled.
+func (k *Kuberhealthy) slackMetrics() string {
+	if k.slackNotifier == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("# HELP kuberhealthy_slack_dropped_total The number of messages that could not be delivered to Slack\n")
+	b.WriteString("# TYPE kuberhealthy_slack_dropped_total counter\n")
+	b.WriteString(fmt.Sprintf("kuberhealthy_slack_dropped_total %d\n", k.slackNotifier.sink.Dropped()))
+	return b.String()
+}
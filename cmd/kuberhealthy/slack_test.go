@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/webhook"
+)
+
+// TestSendStateChangeSlack ensures a message is posted to Slack only when a check that matches the filters fails
+func TestSendStateChangeSlack(t *testing.T) {
+	received := make(chan slackMessage, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message slackMessage
+		err := json.NewDecoder(r.Body).Decode(&message)
+		if err != nil {
+			t.Error("failed to decode message:", err)
+		}
+		received <- message
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kh := &Kuberhealthy{slackNotifier: &slackNotifier{
+		sink:       webhook.NewSink(webhook.SinkConfig{URL: server.URL}),
+		channel:    "#alerts",
+		namespaces: map[string]bool{"kuberhealthy": true, "payments": true},
+		checks:     map[string]bool{"dns-status": true, "payments/deployment": true},
+	}}
+	go kh.slackNotifier.sink.Start(ctx)
+
+	failing := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	failing.Errors = []string{"lookup failed", "timed out"}
+	failing.RunDuration = "1.5s"
+	ok := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	ok.OK = true
+
+	kh.sendStateChangeSlack("dns-status", "kuberhealthy", true, ok)
+	kh.sendStateChangeSlack("dns-status", "kuberhealthy", false, failing)
+	kh.sendStateChangeSlack("dns-status", "kuberhealthy", false, ok)
+	kh.sendStateChangeSlack("dns-status", "other", true, failing)
+	kh.sendStateChangeSlack("deployment", "kuberhealthy", true, failing)
+	kh.sendStateChangeSlack("dns-status", "kuberhealthy", true, failing)
+	kh.sendStateChangeSlack("deployment", "payments", true, failing)
+
+	var messages []slackMessage
+	for len(messages) < 2 {
+		select {
+		case message := <-received:
+			messages = append(messages, message)
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for message delivery, got", messages)
+		}
+	}
+	select {
+	case message := <-received:
+		t.Fatal("expected no message for passing, recovered or filtered checks but got", message)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	var sawDNS, sawDeployment bool
+	for _, message := range messages {
+		if message.Channel != "#alerts" || len(message.Attachments) != 1 {
+			t.Fatal("unexpected message received:", message)
+		}
+		switch {
+		case strings.Contains(message.Text, "kuberhealthy/dns-status"):
+			sawDNS = true
+		case strings.Contains(message.Text, "payments/deployment"):
+			sawDeployment = true
+		}
+		fields := make(map[string]string)
+		for _, f := range message.Attachments[0].Fields {
+			fields[f.Title] = f.Value
+		}
+		if fields["Duration"] != "1.5s" || !strings.Contains(fields["Errors"], "lookup failed") || !strings.Contains(fields["Errors"], "timed out") {
+			t.Fatal("expected the duration and errors of the failed check but got", fields)
+		}
+	}
+	if !sawDNS || !sawDeployment {
+		t.Fatal("expected messages for both matching checks but got", messages)
+	}
+
+	metrics := kh.slackMetrics()
+	if !strings.Contains(metrics, "kuberhealthy_slack_dropped_total 0") {
+		t.Fatal("unexpected slack metrics:", metrics)
+	}
+}
+
+// TestNewSlackMessageWarning ensures failures with the Warning severity are posted as warnings
+func TestNewSlackMessageWarning(t *testing.T) {
+	details := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	details.Errors = []string{"certificate expires in 10 days"}
+	details.Severity = status.SeverityWarning
+	message := newSlackMessage("certificate", "kuberhealthy", "", details)
+	if !strings.HasPrefix(message.Text, ":warning:") || message.Attachments[0].Color != "warning" {
+		t.Fatal("expected a warning message but got", message)
+	}
+}
+
+// TestSlackNotifierMatches ensures the namespace and check filters allow everything when empty
+func TestSlackNotifierMatches(t *testing.T) {
+	n := &slackNotifier{namespaces: map[string]bool{}, checks: map[string]bool{}}
+	if !n.matches("dns-status", "kuberhealthy") {
+		t.Fatal("expected a notifier without filters to match every check")
+	}
+
+	n.checks["kuberhealthy/dns-status"] = true
+	if !n.matches("dns-status", "kuberhealthy") || n.matches("dns-status", "other") {
+		t.Fatal("expected a namespace/name check filter to only match the check in that namespace")
+	}
+
+	n.checks = map[string]bool{}
+	n.namespaces["kuberhealthy"] = true
+	if !n.matches("deployment", "kuberhealthy") || n.matches("deployment", "other") {
+		t.Fatal("expected the namespace filter to only match checks in its namespaces")
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/metrics"
+)
+
+// statsdAddress is the host:port of the StatsD server or agent that check results are sent to.  StatsD forwarding is
+// disabled when blank.
+var statsdAddress string
+
+// statsdPrefix is the prefix of the names of metrics sent over StatsD
+var statsdPrefix = metrics.DefaultStatsDPrefix
+
+// statsdTagsFlag holds comma separated key=value tags sent with every metric in the DogStatsD format
+var statsdTagsFlag string
+var statsdTags map[string]string
+
+// statsdDogStatsD sends tags in the DogStatsD format
+var statsdDogStatsD bool
+
+// parseStatsDTags parses the statsdTags flag
+func parseStatsDTags() error {
+	var err error
+	statsdTags, err = parseKeyValueFlag(statsdTagsFlag)
+	if err != nil {
+		return fmt.Errorf("unable to parse statsdTags flag: %w", err)
+	}
+	return nil
+}
+
+// newStatsDClient creates the client check results are sent to StatsD with.  nil is returned when StatsD forwarding
+// is disabled.  The clusterName is sent as the cluster tag unless statsdTags sets it.
+func newStatsDClient() (*metrics.StatsDClient, error) {
+	if len(statsdAddress) == 0 {
+		return nil, nil
+	}
+	tags := statsdTags
+	if len(clusterName) > 0 {
+		tags = mergeStringMaps(map[string]string{"cluster": clusterName}, statsdTags)
+	}
+	log.Infoln("statsd: sending check results to", statsdAddress, "with prefix", statsdPrefix)
+	return metrics.NewStatsDClient(metrics.StatsDConfig{
+		Address:   statsdAddress,
+		Prefix:    statsdPrefix,
+		Tags:      tags,
+		DogStatsD: statsdDogStatsD,
+	})
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultStatusCacheTTL is how long the assembled status page is served from cache by default
+const DefaultStatusCacheTTL = time.Second * 5
+
+// statusCacheTTL is how long the assembled status page is served from cache.  Set to 0 to disable the cache.
+var statusCacheTTL = DefaultStatusCacheTTL
+
+// statusCache caches the assembled status page for each requested set of namespaces and page, so that frequent
+// polling of the status page does not assemble it from scratch on every request.  Readers that miss the cache while
+// it is refreshed wait for the refresh instead of assembling the status page themselves.
+type statusCache struct {
+	ttl        time.Duration
+	mu         sync.Mutex
+	entries    map[string]*statusCacheEntry
+	generation uint64 // incremented on each invalidation so refreshes started before it are not cached
+}
+
+// statusCacheEntry is an assembled status page and its ETag
+type statusCacheEntry struct {
+	body    []byte
+	etag    string
+	err     error
+	expires time.Time
+	ready   chan struct{} // closed once the status page has been assembled
+}
+
+// newStatusCache creates a status cache that serves status pages for the supplied TTL
+func newStatusCache(ttl time.Duration) *statusCache {
+	return &statusCache{
+		ttl:     ttl,
+		entries: make(map[string]*statusCacheEntry),
+	}
+}
+
+// statusCacheKey returns the cache key of a request for the supplied namespaces
+func statusCacheKey(namespaces []string) string {
+	sorted := append([]string{}, namespaces...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// statusETag returns the ETag of a status page
+func statusETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// get returns the status page cached with the supplied key and its ETag.  The status page is assembled with build
+// when it is not cached or its cached copy has expired.  When the cache is disabled, every call assembles it.
+func (sc *statusCache) get(key string, build func() ([]byte, error)) ([]byte, string, error) {
+	if sc == nil || sc.ttl <= 0 {
+		body, err := build()
+		if err != nil {
+			return nil, "", err
+		}
+		return body, statusETag(body), nil
+	}
+
+	sc.mu.Lock()
+	entry, ok := sc.entries[key]
+	if ok {
+		select {
+		case <-entry.ready:
+			// use the cached status page unless it has expired or failed to assemble
+			if entry.err == nil && time.Now().Before(entry.expires) {
+				sc.mu.Unlock()
+				return entry.body, entry.etag, nil
+			}
+			ok = false
+		default:
+			// another reader is assembling the status page, so we wait for it below
+		}
+	}
+	if !ok {
+		entry = &statusCacheEntry{ready: make(chan struct{})}
+		sc.entries[key] = entry
+		generation := sc.generation
+		sc.mu.Unlock()
+
+		entry.body, entry.err = build()
+		if entry.err == nil {
+			entry.etag = statusETag(entry.body)
+		}
+		entry.expires = time.Now().Add(sc.ttl)
+
+		// a refresh that raced with an invalidation still serves the readers waiting on it, but is not kept
+		sc.mu.Lock()
+		if sc.generation != generation && sc.entries[key] == entry {
+			delete(sc.entries, key)
+		}
+		sc.mu.Unlock()
+		close(entry.ready)
+		return entry.body, entry.etag, entry.err
+	}
+	sc.mu.Unlock()
+
+	<-entry.ready
+	return entry.body, entry.etag, entry.err
+}
+
+// invalidate drops all cached status pages, so that the next request assembles a fresh one
+func (sc *statusCache) invalidate() {
+	if sc == nil {
+		return
+	}
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.generation++
+	sc.entries = make(map[string]*statusCacheEntry)
+}
+
+// etagMatches indicates that an If-None-Match header matches the supplied ETag
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
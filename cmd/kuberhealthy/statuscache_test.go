@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestStatusCacheConcurrentReaders ensures readers that arrive while the status page is assembled wait for it and
+// are served the same status page instead of assembling it themselves
+func TestStatusCacheConcurrentReaders(t *testing.T) {
+	sc := newStatusCache(time.Minute)
+
+	var builds int32
+	release := make(chan struct{})
+	build := func() ([]byte, error) {
+		atomic.AddInt32(&builds, 1)
+		<-release
+		return []byte(`{"OK": true}`), nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan string, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, etag, err := sc.get(statusCacheKey([]string{"kuberhealthy"}), build)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- string(body) + etag
+		}()
+	}
+
+	// let the readers pile up on the refresh before it completes
+	time.Sleep(time.Millisecond * 100)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if atomic.LoadInt32(&builds) != 1 {
+		t.Fatal("expected the status page to be assembled once but it was assembled", builds, "times")
+	}
+	var first string
+	for result := range results {
+		if len(first) == 0 {
+			first = result
+		}
+		if result != first {
+			t.Fatal("expected all readers to get the same status page but got", result, "and", first)
+		}
+	}
+}
+
+// TestStatusCacheExpiryAndInvalidation ensures cached status pages are assembled again once they expire or are
+// invalidated, and that each set of namespaces is cached separately
+func TestStatusCacheExpiryAndInvalidation(t *testing.T) {
+	sc := newStatusCache(time.Millisecond * 50)
+
+	var builds int
+	build := func() ([]byte, error) {
+		builds++
+		return []byte(strconv.Itoa(builds)), nil
+	}
+
+	first, firstETag, _ := sc.get(statusCacheKey([]string{"a", "b"}), build)
+	cached, cachedETag, _ := sc.get(statusCacheKey([]string{"b", "a"}), build)
+	if !bytes.Equal(first, cached) || firstETag != cachedETag {
+		t.Fatal("expected the cached status page to be served for the same namespaces")
+	}
+
+	other, _, _ := sc.get(statusCacheKey([]string{"a"}), build)
+	if bytes.Equal(first, other) {
+		t.Fatal("expected a separate status page for other namespaces")
+	}
+
+	sc.invalidate()
+	invalidated, invalidatedETag, _ := sc.get(statusCacheKey([]string{"a", "b"}), build)
+	if bytes.Equal(first, invalidated) || firstETag == invalidatedETag {
+		t.Fatal("expected the status page to be assembled again after invalidation")
+	}
+
+	time.Sleep(time.Millisecond * 100)
+	expired, _, _ := sc.get(statusCacheKey([]string{"a", "b"}), build)
+	if bytes.Equal(invalidated, expired) {
+		t.Fatal("expected the status page to be assembled again after it expired")
+	}
+}
+
+// TestStatusCacheDisabled ensures the status page is assembled on every request when the TTL is 0
+func TestStatusCacheDisabled(t *testing.T) {
+	sc := newStatusCache(0)
+
+	var builds int
+	build := func() ([]byte, error) {
+		builds++
+		return []byte(`{"OK": true}`), nil
+	}
+	sc.get("", build)
+	_, etag, _ := sc.get("", build)
+	if builds != 2 {
+		t.Fatal("expected the status page to be assembled on every request but it was assembled", builds, "times")
+	}
+	if len(etag) == 0 {
+		t.Fatal("expected an ETag even when the cache is disabled")
+	}
+}
+
+// TestEtagMatches ensures If-None-Match headers with lists, weak ETags and wildcards are matched
+func TestEtagMatches(t *testing.T) {
+	tests := map[string]bool{
+		`"abc"`:        true,
+		`W/"abc"`:      true,
+		`"def", "abc"`: true,
+		`*`:            true,
+		`"def"`:        false,
+		``:             false,
+	}
+	for header, expected := range tests {
+		if etagMatches(header, `"abc"`) != expected {
+			t.Fatal("expected If-None-Match", header, "match to be", expected)
+		}
+	}
+}
+
+// TestHealthCheckHandlerNotModified ensures the status page is not sent again to clients that already have it
+func TestHealthCheckHandlerNotModified(t *testing.T) {
+	kh := &Kuberhealthy{statusCache: newStatusCache(time.Minute)}
+	_, etag, _ := kh.statusCache.get("", func() ([]byte, error) { return []byte(`{"OK": true}`), nil })
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+	err := kh.healthCheckHandler(recorder, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Code != http.StatusOK || recorder.Body.String() != `{"OK": true}` || recorder.Header().Get("ETag") != etag {
+		t.Fatal("expected the cached status page with its ETag but got", recorder.Code, recorder.Body.String(), recorder.Header().Get("ETag"))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("If-None-Match", etag)
+	recorder = httptest.NewRecorder()
+	err = kh.healthCheckHandler(recorder, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Code != http.StatusNotModified || recorder.Body.Len() != 0 {
+		t.Fatal("expected 304 without a body but got", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// statusFilter limits the status page to the checks and jobs in the requested namespaces and with the requested
+// names.  The overall OK state and errors of a filtered status page only reflect the checks and jobs it includes.
+type statusFilter struct {
+	namespaces []string // the namespaces to include. all namespaces are included when empty
+	names      []string // the names or namespace/name pairs of the checks and jobs to include. all are included when empty
+}
+
+// parseStatusFilter parses the namespace and check query parameters of the status page
+// (i.e. /?namespace=team-a,team-b&check=dns-status)
+func parseStatusFilter(values url.Values) statusFilter {
+	return statusFilter{
+		namespaces: parseListQuery(values.Get("namespace")),
+		names:      parseListQuery(values.Get("check")),
+	}
+}
+
+// parseListQuery parses a comma separated query parameter value
+func parseListQuery(value string) []string {
+	// .Get() will return an "" if there is no value associated -- we do not want to pass "" as a requested item
+	var items []string
+	if len(value) != 0 {
+		splits := strings.Split(value, ",")
+		// a query like (/?namespace=,) will cause .Split() to return an array of two empty strings ["", ""]
+		// so we need to filter those out
+		for _, split := range splits {
+			if len(split) != 0 {
+				items = append(items, split)
+			}
+		}
+	}
+	return items
+}
+
+// empty determines if the filter includes all checks and jobs
+func (f statusFilter) empty() bool {
+	return len(f.namespaces) == 0 && len(f.names) == 0
+}
+
+// matches determines if the check or job with the supplied namespace/name key and namespace is included
+func (f statusFilter) matches(key string, namespace string) bool {
+	if len(f.namespaces) != 0 && !containsString(namespace, f.namespaces) {
+		return false
+	}
+	if len(f.names) == 0 {
+		return true
+	}
+	return containsString(key, f.names) || containsString(strings.TrimPrefix(key, namespace+"/"), f.names)
+}
+
+// cacheKey returns the status cache key of the status page with this filter
+func (f statusFilter) cacheKey() string {
+	if len(f.names) == 0 {
+		return statusCacheKey(f.namespaces)
+	}
+	return statusCacheKey(f.namespaces) + "?check=" + statusCacheKey(f.names)
+}
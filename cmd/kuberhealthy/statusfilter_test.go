@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+func TestParseStatusFilter(t *testing.T) {
+	values, err := url.ParseQuery("namespace=team-a,,team-b&check=dns-status,team-b/pod-restarts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filter := parseStatusFilter(values)
+	if !reflect.DeepEqual(filter.namespaces, []string{"team-a", "team-b"}) {
+		t.Fatalf("unexpected namespaces: %v", filter.namespaces)
+	}
+	if !reflect.DeepEqual(filter.names, []string{"dns-status", "team-b/pod-restarts"}) {
+		t.Fatalf("unexpected checks: %v", filter.names)
+	}
+	if !parseStatusFilter(url.Values{"check": []string{","}}).empty() {
+		t.Fatal("expected a filter of blank values to be empty")
+	}
+
+	for _, c := range []struct {
+		key, namespace string
+		expected       bool
+	}{
+		{"team-a/dns-status", "team-a", true},
+		{"team-b/dns-status", "team-b", true},
+		{"team-b/pod-restarts", "team-b", true},
+		{"team-a/pod-restarts", "team-a", false},
+		{"team-c/dns-status", "team-c", false},
+	} {
+		if filter.matches(c.key, c.namespace) != c.expected {
+			t.Fatalf("expected the filter to match %s: %v", c.key, c.expected)
+		}
+	}
+
+	if filter.cacheKey() == (statusFilter{namespaces: filter.namespaces}).cacheKey() {
+		t.Fatal("expected filters on check names to have a cache key of their own")
+	}
+}
+
+// TestValidateCurrentStatusForFilter ensures that the OK state of a filtered status page only reflects the included
+// checks
+func TestValidateCurrentStatusForFilter(t *testing.T) {
+	details := map[string]khstatev1.WorkloadDetails{
+		"team-a/dns-status": {OK: true, Namespace: "team-a", AuthoritativePod: "kuberhealthy-1"},
+		"team-b/dns-status": {OK: false, Errors: []string{"dns lookup failed"}, Namespace: "team-b", AuthoritativePod: "kuberhealthy-1"},
+	}
+	newState := func() health.State {
+		s := health.NewState()
+		s.CheckDetails = make(map[string]khstatev1.WorkloadDetails)
+		return s
+	}
+
+	state := validateCurrentStatusForFilter(details, statusFilter{namespaces: []string{"team-a"}, names: []string{"dns-status"}}, newState(), khstatev1.KHCheck)
+	if !state.OK || len(state.Errors) != 0 || len(state.CheckDetails) != 1 {
+		t.Fatalf("expected only the healthy check to be included: %+v", state)
+	}
+
+	state = validateCurrentStatusForFilter(details, statusFilter{names: []string{"dns-status"}}, newState(), khstatev1.KHCheck)
+	if state.OK || len(state.Errors) != 1 || len(state.CheckDetails) != 2 {
+		t.Fatalf("expected both checks to be included: %+v", state)
+	}
+}
@@ -0,0 +1,25 @@
+package main
+
+// stateTransition describes how the OK state of a workload changed between two runs.  It is shared by everything
+// that notifies about changes in workload state, such as Kubernetes events and CloudEvents.
+type stateTransition int
+
+const (
+	// transitionNone means the OK state of the workload did not change
+	transitionNone stateTransition = iota
+	// transitionFailed means the workload went from OK to failing
+	transitionFailed
+	// transitionRecovered means the workload went from failing to OK
+	transitionRecovered
+)
+
+// detectStateTransition determines how the OK state of a workload changed from the previous run to the current one
+func detectStateTransition(previousOK bool, currentOK bool) stateTransition {
+	switch {
+	case previousOK && !currentOK:
+		return transitionFailed
+	case !previousOK && currentOK:
+		return transitionRecovered
+	}
+	return transitionNone
+}
@@ -69,3 +69,33 @@ func containsString(s string, list []string) bool {
 	}
 	return false
 }
+
+// parseKeyValueFlag parses a comma separated list of key=value pairs, such as
+// "cluster-autoscaler.kubernetes.io/safe-to-evict=true,team=sre", into a map.
+func parseKeyValueFlag(s string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || len(strings.TrimSpace(kv[0])) == 0 {
+			return out, errors.New("invalid key=value pair: " + pair)
+		}
+		out[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return out, nil
+}
+
+// mergeStringMaps merges the supplied maps into a new map.  Keys in later maps take precedence over
+// keys in earlier maps.
+func mergeStringMaps(maps ...map[string]string) map[string]string {
+	out := make(map[string]string)
+	for _, m := range maps {
+		for k, v := range m {
+			out[k] = v
+		}
+	}
+	return out
+}
@@ -5,9 +5,11 @@ import (
 	"errors"
 	"io/ioutil"
 	"os"
+	"testing"
 
 	"github.com/ghodss/yaml"
 	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/client-go/kubernetes"
 
 	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
@@ -62,3 +64,66 @@ func loadTestPodSpecFile(path string) (*khcheckv1.KuberhealthyCheck, error) {
 	err = json.Unmarshal(j, &podSpec)
 	return &podSpec, err
 }
+
+// TestParseKeyValueFlag tests parsing of comma separated key=value flag values
+func TestParseKeyValueFlag(t *testing.T) {
+	m, err := parseKeyValueFlag("cluster-autoscaler.kubernetes.io/safe-to-evict=true, team=sre,,empty=")
+	if err != nil {
+		t.Fatal("unexpected error parsing key value flag:", err)
+	}
+	if len(m) != 3 {
+		t.Fatal("expected 3 parsed pairs but got", len(m), m)
+	}
+	if m["cluster-autoscaler.kubernetes.io/safe-to-evict"] != "true" || m["team"] != "sre" || m["empty"] != "" {
+		t.Fatal("parsed key value flag had unexpected values:", m)
+	}
+
+	_, err = parseKeyValueFlag("novalue")
+	if err == nil {
+		t.Fatal("expected an error when parsing a pair without an equals sign")
+	}
+
+	m, err = parseKeyValueFlag("")
+	if err != nil || len(m) != 0 {
+		t.Fatal("expected an empty flag to parse into an empty map", m, err)
+	}
+}
+
+// TestMergeStringMaps tests that later maps take precedence when merging
+func TestMergeStringMaps(t *testing.T) {
+	global := map[string]string{"a": "global", "b": "global"}
+	check := map[string]string{"b": "check"}
+	merged := mergeStringMaps(global, nil, check)
+	if merged["a"] != "global" || merged["b"] != "check" {
+		t.Fatal("unexpected merged map:", merged)
+	}
+	if global["b"] != "global" {
+		t.Fatal("merging modified an input map")
+	}
+}
+
+func TestPodHasIP(t *testing.T) {
+	pod := v1.Pod{Status: v1.PodStatus{
+		PodIP:  "10.0.0.1",
+		PodIPs: []v1.PodIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}},
+	}}
+	for ip, expected := range map[string]bool{
+		"10.0.0.1":                true,
+		"fd00::1":                 true,
+		"fd00:0:0:0:0:0:0:1":      true,
+		"10.0.0.2":                false,
+		"":                        false,
+		"not-an-ip":               false,
+		"fd00:0000:0000::0000:02": false,
+	} {
+		if podHasIP(pod, ip) != expected {
+			t.Fatal("expected pod with IPs", pod.Status.PodIPs, "to have IP", ip+":", expected)
+		}
+	}
+
+	// pods without a list of IPs are matched by their primary IP
+	pod.Status.PodIPs = nil
+	if !podHasIP(pod, "10.0.0.1") {
+		t.Fatal("expected a pod without a list of IPs to have its primary IP")
+	}
+}
@@ -0,0 +1,520 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/gorhill/cronexpr"
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+)
+
+// validatePath is the endpoint that validates khcheck manifests without applying them
+const validatePath = "/api/v1/validate"
+
+// lintKHCheckPath is a khcheck manifest to validate instead of starting Kuberhealthy
+var lintKHCheckPath string
+
+// lintSkipImageCheck skips checking that the images of linted khchecks can be pulled
+var lintSkipImageCheck bool
+
+// imageCheckTimeout is how long checking that a single image can be pulled may take
+const imageCheckTimeout = time.Second * 15
+
+// reservedEnvVars are the environment variables Kuberhealthy sets on every checker container.  Values set for them
+// in a khcheck are overwritten.
+var reservedEnvVars = []string{external.KHReportingURL, external.KHReportingCA, external.KHRunUUID, external.KHReportToken, external.KHPodNamespace, external.KHDeadline}
+
+// khCheckIssue is a problem found when validating a khcheck, with the path of the field it was found in
+type khCheckIssue struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// khCheckValidation is the result of validating a khcheck.  Errors prevent the check from running as intended.
+// Warnings are settings that Kuberhealthy replaces or ignores.
+type khCheckValidation struct {
+	Check    string         `json:"check"`
+	Errors   []khCheckIssue `json:"errors"`
+	Warnings []khCheckIssue `json:"warnings"`
+}
+
+// addError adds an error for a field
+func (v *khCheckValidation) addError(field string, message string) {
+	v.Errors = append(v.Errors, khCheckIssue{Field: field, Message: message})
+}
+
+// addWarning adds a warning for a field
+func (v *khCheckValidation) addWarning(field string, message string) {
+	v.Warnings = append(v.Warnings, khCheckIssue{Field: field, Message: message})
+}
+
+// validateKHCheck validates a khcheck the same way it is interpreted when it is loaded.  It does not check that the
+// images of the check can be pulled.  See validateKHCheckImages.
+func validateKHCheck(kc khcheckv1.KuberhealthyCheck) khCheckValidation {
+	v := khCheckValidation{Check: kc.Namespace + "/" + kc.Name, Errors: []khCheckIssue{}, Warnings: []khCheckIssue{}}
+
+	if len(kc.Kind) > 0 && kc.Kind != "KuberhealthyCheck" {
+		v.addError("kind", "kind must be KuberhealthyCheck but is "+kc.Kind)
+	}
+	if len(kc.APIVersion) > 0 && kc.APIVersion != "comcast.github.io/v1" {
+		v.addError("apiVersion", "apiVersion must be comcast.github.io/v1 but is "+kc.APIVersion)
+	}
+
+	// the check name is used as a label value on checker pods, so it must be a valid label value as well as a
+	// valid resource name
+	msgs := validation.IsDNS1123Subdomain(kc.Name)
+	switch {
+	case len(kc.Name) == 0:
+		v.addError("metadata.name", "a name is required")
+	case len(msgs) > 0:
+		for _, msg := range msgs {
+			v.addError("metadata.name", msg)
+		}
+	case len(kc.Name) > validation.LabelValueMaxLength:
+		v.addError("metadata.name", "must be no more than "+strconv.Itoa(validation.LabelValueMaxLength)+" characters")
+	}
+	if len(kc.Namespace) == 0 {
+		v.addWarning("metadata.namespace", "no namespace is set, so the check is created in the namespace it is applied to")
+	}
+	if value, ok := kc.Annotations[pausedAnnotation]; ok {
+		if _, err := strconv.ParseBool(value); err != nil {
+			v.addWarning("metadata.annotations", pausedAnnotation+" must be true or false, so "+value+" is ignored")
+		}
+	}
+
+	var runInterval time.Duration
+	if len(kc.Spec.Schedule) > 0 {
+		runInterval = validateKHCheckSchedule(kc, &v)
+	} else {
+		if len(kc.Spec.ScheduleTimezone) > 0 {
+			v.addWarning("spec.scheduleTimezone", "scheduleTimezone is ignored because no schedule is set")
+		}
+		if len(kc.Spec.RunInterval) == 0 {
+			v.addWarning("spec.runInterval", "no runInterval or schedule is set, so the check runs every "+DefaultRunInterval.String())
+		} else {
+			var err error
+			runInterval, err = time.ParseDuration(kc.Spec.RunInterval)
+			if err != nil {
+				v.addError("spec.runInterval", "runInterval is not a duration: "+err.Error())
+			} else if runInterval <= 0 {
+				v.addError("spec.runInterval", "runInterval must be greater than 0")
+			}
+		}
+	}
+
+	if len(kc.Spec.Timeout) > 0 {
+		timeout, err := time.ParseDuration(kc.Spec.Timeout)
+		switch {
+		case err != nil:
+			v.addError("spec.timeout", "timeout is not a duration: "+err.Error())
+		case timeout <= 0:
+			v.addError("spec.timeout", "timeout must be greater than 0")
+		case runInterval > 0 && timeout > runInterval && (kc.Spec.ConcurrencyPolicy == "" || kc.Spec.ConcurrencyPolicy == khcheckv1.ForbidConcurrent):
+			v.addWarning("spec.timeout", "timeout is longer than runInterval, so runs that take longer than runInterval skip the next run")
+		}
+	}
+
+	switch kc.Spec.ConcurrencyPolicy {
+	case "", khcheckv1.ForbidConcurrent, khcheckv1.ReplaceConcurrent, khcheckv1.AllowConcurrent:
+	default:
+		v.addError("spec.concurrencyPolicy", "concurrencyPolicy must be Forbid, Replace or Allow but is "+string(kc.Spec.ConcurrencyPolicy))
+	}
+
+	if kc.Spec.FailureThreshold < 0 {
+		v.addError("spec.failureThreshold", "failureThreshold must not be negative")
+	}
+	if len(kc.Spec.RetryBackoff) > 0 {
+		backoff, err := time.ParseDuration(kc.Spec.RetryBackoff)
+		switch {
+		case err != nil:
+			v.addError("spec.retryBackoff", "retryBackoff is not a duration: "+err.Error())
+		case backoff <= 0:
+			v.addError("spec.retryBackoff", "retryBackoff must be greater than 0")
+		case kc.Spec.FailureThreshold <= 1:
+			v.addWarning("spec.retryBackoff", "retryBackoff is ignored because failureThreshold is not greater than 1")
+		}
+	}
+
+	validatePodSpec(kc.Spec.PodSpec, &v)
+	validateRBAC(kc.Spec.RBAC, &v)
+	validateDependencies(kc, &v)
+	return v
+}
+
+// validateDependencies validates the checks a khcheck depends on and its dependencyPolicy
+func validateDependencies(kc khcheckv1.KuberhealthyCheck, v *khCheckValidation) {
+	switch kc.Spec.DependencyPolicy {
+	case "", khcheckv1.SuppressOnDependencyFailure, khcheckv1.SkipOnDependencyFailure:
+		if len(kc.Spec.DependencyPolicy) > 0 && len(kc.Spec.DependsOn) == 0 {
+			v.addWarning("spec.dependencyPolicy", "dependencyPolicy is ignored because no dependsOn is set")
+		}
+	default:
+		v.addError("spec.dependencyPolicy", "dependencyPolicy must be Suppress or Skip but is "+string(kc.Spec.DependencyPolicy))
+	}
+
+	seen := make(map[string]bool)
+	for i, dependency := range kc.Spec.DependsOn {
+		field := "spec.dependsOn[" + strconv.Itoa(i) + "]"
+		namespace, name := kc.Namespace, dependency
+		if parts := strings.SplitN(dependency, "/", 2); len(parts) == 2 {
+			namespace, name = parts[0], parts[1]
+			if msgs := validation.IsDNS1123Label(namespace); len(msgs) > 0 {
+				v.addError(field, "namespace "+namespace+" is invalid: "+strings.Join(msgs, ", "))
+				continue
+			}
+		}
+		if msgs := validation.IsDNS1123Subdomain(name); len(msgs) > 0 {
+			v.addError(field, "check name "+name+" is invalid: "+strings.Join(msgs, ", "))
+			continue
+		}
+		if name == kc.Name && namespace == kc.Namespace {
+			v.addError(field, "a check can not depend on itself")
+			continue
+		}
+		if seen[namespace+"/"+name] {
+			v.addWarning(field, namespace+"/"+name+" is listed more than once")
+		}
+		seen[namespace+"/"+name] = true
+	}
+}
+
+// validateRBAC validates the rules of the Role created for the checker pods of a khcheck
+func validateRBAC(rbac *khcheckv1.CheckRBAC, v *khCheckValidation) {
+	if rbac == nil {
+		return
+	}
+	if len(rbac.Rules) == 0 {
+		v.addWarning("spec.rbac.rules", "the checker pods get no permissions without rules")
+	}
+	for i, rule := range rbac.Rules {
+		field := "spec.rbac.rules[" + strconv.Itoa(i) + "]"
+		if len(rule.Verbs) == 0 {
+			v.addError(field+".verbs", "at least one verb is required")
+		}
+		if len(rule.NonResourceURLs) > 0 {
+			v.addError(field+".nonResourceURLs", "nonResourceURLs can not be granted by the Role in the namespace of the khcheck")
+		}
+		if len(rule.Resources) == 0 {
+			v.addError(field+".resources", "at least one resource is required")
+		}
+	}
+}
+
+// validateKHCheckSchedule validates the cron schedule of a khcheck and returns the time between its next two runs
+func validateKHCheckSchedule(kc khcheckv1.KuberhealthyCheck, v *khCheckValidation) time.Duration {
+	if len(kc.Spec.RunInterval) > 0 {
+		v.addWarning("spec.runInterval", "runInterval is ignored because a schedule is set")
+	}
+	expr, err := cronexpr.Parse(kc.Spec.Schedule)
+	if err != nil {
+		v.addError("spec.schedule", "schedule is not a cron expression: "+err.Error())
+		return 0
+	}
+	location := time.UTC
+	if len(kc.Spec.ScheduleTimezone) > 0 {
+		location, err = time.LoadLocation(kc.Spec.ScheduleTimezone)
+		if err != nil {
+			v.addError("spec.scheduleTimezone", "scheduleTimezone is not an IANA timezone: "+err.Error())
+			return 0
+		}
+	}
+	period := checkTiming{cron: expr, location: location}.period(time.Now())
+	if period <= 0 {
+		v.addError("spec.schedule", "schedule has no upcoming runs")
+	}
+	return period
+}
+
+// validatePodSpec validates the pod spec of a khcheck
+func validatePodSpec(podSpec apiv1.PodSpec, v *khCheckValidation) {
+	if len(podSpec.Containers) == 0 {
+		v.addError("spec.podSpec.containers", "at least one container is required")
+	}
+	if len(podSpec.RestartPolicy) > 0 && podSpec.RestartPolicy != apiv1.RestartPolicyNever {
+		v.addWarning("spec.podSpec.restartPolicy", "restartPolicy is always set to Never on checker pods")
+	}
+
+	names := make(map[string]bool)
+	for i, c := range podSpec.Containers {
+		field := "spec.podSpec.containers[" + strconv.Itoa(i) + "]"
+		if len(c.Name) == 0 {
+			v.addError(field+".name", "a container name is required")
+		} else if names[c.Name] {
+			v.addError(field+".name", "container name "+c.Name+" is used more than once")
+		}
+		names[c.Name] = true
+
+		if len(c.Image) == 0 {
+			v.addError(field+".image", "an image is required")
+		} else if _, err := name.ParseReference(c.Image); err != nil {
+			v.addError(field+".image", "image is not a valid image reference: "+err.Error())
+		}
+
+		for j, env := range c.Env {
+			envField := field + ".env[" + strconv.Itoa(j) + "]"
+			if containsString(env.Name, reservedEnvVars) {
+				v.addWarning(envField+".name", env.Name+" is set by Kuberhealthy and the value in the khcheck is replaced")
+			}
+			if len(env.Value) > 0 && env.ValueFrom != nil {
+				v.addError(envField+".valueFrom", "value and valueFrom can not both be set")
+			}
+		}
+		for j, envFrom := range c.EnvFrom {
+			if (envFrom.ConfigMapRef == nil) == (envFrom.SecretRef == nil) {
+				v.addError(field+".envFrom["+strconv.Itoa(j)+"]", "exactly one of configMapRef and secretRef is required")
+			}
+		}
+	}
+
+	for i, secret := range podSpec.ImagePullSecrets {
+		if len(secret.Name) == 0 {
+			v.addError("spec.podSpec.imagePullSecrets["+strconv.Itoa(i)+"].name", "a secret name is required")
+		}
+	}
+
+	validatePodScheduling(podSpec, v)
+	validatePodVolumes(podSpec, v)
+}
+
+// validatePodScheduling validates the fields of a khcheck pod spec that place checker pods on nodes
+func validatePodScheduling(podSpec apiv1.PodSpec, v *khCheckValidation) {
+	for i, toleration := range podSpec.Tolerations {
+		field := "spec.podSpec.tolerations[" + strconv.Itoa(i) + "]"
+		switch toleration.Operator {
+		case "", apiv1.TolerationOpEqual:
+			if len(toleration.Key) == 0 {
+				v.addError(field+".key", "a key is required unless the operator is Exists")
+			}
+		case apiv1.TolerationOpExists:
+			if len(toleration.Value) > 0 {
+				v.addError(field+".value", "value must be empty when the operator is Exists")
+			}
+		default:
+			v.addError(field+".operator", "operator must be Equal or Exists")
+		}
+		switch toleration.Effect {
+		case "", apiv1.TaintEffectNoSchedule, apiv1.TaintEffectPreferNoSchedule, apiv1.TaintEffectNoExecute:
+		default:
+			v.addError(field+".effect", "effect must be NoSchedule, PreferNoSchedule or NoExecute")
+		}
+	}
+
+	if podSpec.HostNetwork && podSpec.DNSPolicy == apiv1.DNSDefault {
+		v.addWarning("spec.podSpec.dnsPolicy", "checker pods on the host network with dnsPolicy Default can not resolve the Kuberhealthy service to report to")
+	}
+}
+
+// validatePodVolumes validates that the volumes of a khcheck pod spec are unique and that every volume mount refers
+// to one of them
+func validatePodVolumes(podSpec apiv1.PodSpec, v *khCheckValidation) {
+	volumes := make(map[string]bool)
+	for i, volume := range podSpec.Volumes {
+		if volumes[volume.Name] {
+			v.addError("spec.podSpec.volumes["+strconv.Itoa(i)+"].name", "volume name "+volume.Name+" is used more than once")
+		}
+		volumes[volume.Name] = true
+	}
+
+	validateMounts := func(field string, containers []apiv1.Container) {
+		for i, c := range containers {
+			for j, mount := range c.VolumeMounts {
+				if !volumes[mount.Name] {
+					v.addError(field+"["+strconv.Itoa(i)+"].volumeMounts["+strconv.Itoa(j)+"].name", "volume "+mount.Name+" is not defined in spec.podSpec.volumes")
+				}
+			}
+		}
+	}
+	validateMounts("spec.podSpec.initContainers", podSpec.InitContainers)
+	validateMounts("spec.podSpec.containers", podSpec.Containers)
+}
+
+// validateKHCheckImages checks that the images of a khcheck can be pulled.  Images that do not exist are errors.
+// Images that can not be checked, such as private images without credentials, are warnings.
+func validateKHCheckImages(ctx context.Context, kc khcheckv1.KuberhealthyCheck, v *khCheckValidation) {
+	for i, c := range kc.Spec.PodSpec.Containers {
+		ref, err := name.ParseReference(c.Image)
+		if err != nil {
+			continue // already reported as an invalid image reference
+		}
+
+		field := "spec.podSpec.containers[" + strconv.Itoa(i) + "].image"
+		imageCtx, cancel := context.WithTimeout(ctx, imageCheckTimeout)
+		_, err = remote.Head(ref, remote.WithContext(imageCtx), remote.WithAuthFromKeychain(authn.DefaultKeychain))
+		cancel()
+		if err == nil {
+			continue
+		}
+
+		var terr *transport.Error
+		if errors.As(err, &terr) && terr.StatusCode == http.StatusNotFound {
+			v.addError(field, "image "+c.Image+" was not found")
+			continue
+		}
+		v.addWarning(field, "image "+c.Image+" could not be checked: "+err.Error())
+	}
+}
+
+// parseKHCheckManifests parses the khchecks in a YAML or JSON manifest.  Manifests may hold several khchecks
+// separated by ---.
+func parseKHCheckManifests(manifest []byte) ([]khcheckv1.KuberhealthyCheck, error) {
+	var khChecks []khcheckv1.KuberhealthyCheck
+	for i, doc := range splitYAMLDocuments(manifest) {
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		var kc khcheckv1.KuberhealthyCheck
+		err := yaml.Unmarshal(doc, &kc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse document %d of the manifest: %w", i+1, err)
+		}
+		khChecks = append(khChecks, kc)
+	}
+	if len(khChecks) == 0 {
+		return nil, errors.New("the manifest does not contain a khcheck")
+	}
+	return khChecks, nil
+}
+
+// splitYAMLDocuments splits a manifest into its YAML documents
+func splitYAMLDocuments(manifest []byte) [][]byte {
+	var docs [][]byte
+	var doc bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(manifest)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimRight(line, " \t") == "---" {
+			docs = append(docs, append([]byte(nil), doc.Bytes()...))
+			doc.Reset()
+			continue
+		}
+		doc.WriteString(line)
+		doc.WriteString("\n")
+	}
+	return append(docs, doc.Bytes())
+}
+
+// validateKHCheckManifest validates every khcheck of a manifest, optionally checking that their images can be pulled
+func validateKHCheckManifest(ctx context.Context, manifest []byte, checkImages bool) ([]khCheckValidation, error) {
+	khChecks, err := parseKHCheckManifests(manifest)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]khCheckValidation, 0, len(khChecks))
+	for _, kc := range khChecks {
+		v := validateKHCheck(kc)
+		if checkImages {
+			validateKHCheckImages(ctx, kc, &v)
+		}
+		results = append(results, v)
+	}
+	return results, nil
+}
+
+// lintKHCheck validates the khchecks of a manifest file and writes the errors and warnings found to out.  It returns
+// the exit code of the lint, which is 1 when errors were found and 2 when the manifest could not be read.
+func lintKHCheck(path string, checkImages bool, out io.Writer) int {
+	manifest, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintln(out, "failed to read khcheck manifest:", err)
+		return 2
+	}
+	results, err := validateKHCheckManifest(context.Background(), manifest, checkImages)
+	if err != nil {
+		fmt.Fprintln(out, err)
+		return 2
+	}
+
+	var errorCount int
+	for _, v := range results {
+		for _, issue := range v.Errors {
+			fmt.Fprintf(out, "ERROR   %s %s: %s\n", v.Check, issue.Field, issue.Message)
+		}
+		for _, issue := range v.Warnings {
+			fmt.Fprintf(out, "WARNING %s %s: %s\n", v.Check, issue.Field, issue.Message)
+		}
+		errorCount += len(v.Errors)
+	}
+	fmt.Fprintln(out, len(results), "khchecks validated with", errorCount, "errors")
+	if errorCount > 0 {
+		return 1
+	}
+	return 0
+}
+
+// validateHandler validates the khcheck manifest in the body of a POST request and responds with the errors and
+// warnings found for each khcheck.  The response is 200 when there are no errors and 422 when there are.  Image
+// checks are skipped with ?skipImageCheck=true.
+func (k *Kuberhealthy) validateHandler(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return nil
+	}
+
+	manifest, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return fmt.Errorf("failed to read khcheck manifest: %w", err)
+	}
+	checkImages := r.URL.Query().Get("skipImageCheck") != "true"
+	results, err := validateKHCheckManifest(r.Context(), manifest, checkImages)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		_, writeErr := w.Write([]byte(err.Error()))
+		return writeErr
+	}
+
+	valid := true
+	for _, v := range results {
+		if len(v.Errors) > 0 {
+			valid = false
+		}
+	}
+	body, err := json.MarshalIndent(struct {
+		Valid  bool                `json:"valid"`
+		Checks []khCheckValidation `json:"checks"`
+	}{valid, results}, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return fmt.Errorf("failed to marshal khcheck validation: %w", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !valid {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// logKHCheckValidation logs the errors and warnings of a khcheck as it is loaded and returns them
+func logKHCheckValidation(kc khcheckv1.KuberhealthyCheck) khCheckValidation {
+	v := validateKHCheck(kc)
+	for _, issue := range v.Errors {
+		log.Errorln("Invalid khcheck", v.Check, issue.Field+":", issue.Message)
+	}
+	for _, issue := range v.Warnings {
+		log.Warningln("khcheck", v.Check, issue.Field+":", issue.Message)
+	}
+	return v
+}
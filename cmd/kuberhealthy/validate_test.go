@@ -0,0 +1,333 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// validKHCheckManifest is a khcheck manifest without errors or warnings
+const validKHCheckManifest = `apiVersion: comcast.github.io/v1
+kind: KuberhealthyCheck
+metadata:
+  name: dns
+  namespace: kuberhealthy
+spec:
+  runInterval: 2m
+  timeout: 1m
+  podSpec:
+    containers:
+    - name: main
+      image: kuberhealthy/dns-resolution-check:v1.5.0
+`
+
+// invalidKHCheckManifest is a khcheck manifest with errors and warnings
+const invalidKHCheckManifest = `apiVersion: comcast.github.io/v1
+kind: KuberhealthyCheck
+metadata:
+  name: Bad_Name
+  namespace: kuberhealthy
+spec:
+  runInterval: ten minutes
+  timeout: -1m
+  concurrencyPolicy: Sometimes
+  podSpec:
+    restartPolicy: Always
+    containers:
+    - name: main
+      env:
+      - name: KH_RUN_UUID
+        value: abc
+    - name: main
+      image: kuberhealthy/dns-resolution-check:v1.5.0
+`
+
+// issueFields returns the fields of the issues
+func issueFields(issues []khCheckIssue) string {
+	var fields []string
+	for _, issue := range issues {
+		fields = append(fields, issue.Field)
+	}
+	return strings.Join(fields, ",")
+}
+
+// TestValidateKHCheck ensures errors and warnings are reported with the paths of the fields they were found in
+func TestValidateKHCheck(t *testing.T) {
+	results, err := validateKHCheckManifest(context.Background(), []byte(validKHCheckManifest+"---\n"+invalidKHCheckManifest), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatal("expected 2 validated khchecks but got", len(results))
+	}
+	if len(results[0].Errors) != 0 || len(results[0].Warnings) != 0 {
+		t.Fatal("expected no issues for the valid khcheck but got", results[0])
+	}
+
+	expectedErrors := "metadata.name,spec.runInterval,spec.timeout,spec.concurrencyPolicy,spec.podSpec.containers[0].image,spec.podSpec.containers[1].name"
+	if fields := issueFields(results[1].Errors); fields != expectedErrors {
+		t.Fatal("expected errors for", expectedErrors, "but got", fields)
+	}
+	expectedWarnings := "spec.podSpec.restartPolicy,spec.podSpec.containers[0].env[0].name"
+	if fields := issueFields(results[1].Warnings); fields != expectedWarnings {
+		t.Fatal("expected warnings for", expectedWarnings, "but got", fields)
+	}
+
+	_, err = validateKHCheckManifest(context.Background(), []byte("---\n"), false)
+	if err == nil {
+		t.Fatal("expected an error for a manifest without khchecks")
+	}
+}
+
+// TestValidateKHCheckSchedule ensures cron schedules and their timezones are validated
+func TestValidateKHCheckSchedule(t *testing.T) {
+	for _, tc := range []struct {
+		spec     string
+		errors   string
+		warnings string
+	}{
+		{spec: "schedule: \"0 9-17 * * MON-FRI\"\n  scheduleTimezone: America/New_York"},
+		{spec: "schedule: \"*/5 * * * *\"\n  runInterval: 2m", warnings: "spec.runInterval"},
+		{spec: "schedule: every day", errors: "spec.schedule"},
+		{spec: "schedule: \"0 2 * * *\"\n  scheduleTimezone: Mars/Olympus", errors: "spec.scheduleTimezone"},
+		{spec: "runInterval: 2m\n  scheduleTimezone: UTC", warnings: "spec.scheduleTimezone"},
+	} {
+		manifest := strings.Replace(validKHCheckManifest, "runInterval: 2m", tc.spec, 1)
+		results, err := validateKHCheckManifest(context.Background(), []byte(manifest), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fields := issueFields(results[0].Errors); fields != tc.errors {
+			t.Fatal("expected errors for", tc.errors, "with", tc.spec, "but got", fields)
+		}
+		if fields := issueFields(results[0].Warnings); fields != tc.warnings {
+			t.Fatal("expected warnings for", tc.warnings, "with", tc.spec, "but got", fields)
+		}
+	}
+}
+
+// TestValidateKHCheckFailureSettings ensures failure thresholds and retry backoffs are validated
+func TestValidateKHCheckFailureSettings(t *testing.T) {
+	for _, tc := range []struct {
+		spec     string
+		errors   string
+		warnings string
+	}{
+		{spec: "failureThreshold: 3\n  retryBackoff: 30s"},
+		{spec: "failureThreshold: -1", errors: "spec.failureThreshold"},
+		{spec: "failureThreshold: 3\n  retryBackoff: soon", errors: "spec.retryBackoff"},
+		{spec: "retryBackoff: 30s", warnings: "spec.retryBackoff"},
+	} {
+		manifest := strings.Replace(validKHCheckManifest, "timeout: 1m", "timeout: 1m\n  "+tc.spec, 1)
+		results, err := validateKHCheckManifest(context.Background(), []byte(manifest), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fields := issueFields(results[0].Errors); fields != tc.errors {
+			t.Fatal("expected errors for", tc.errors, "with", tc.spec, "but got", fields)
+		}
+		if fields := issueFields(results[0].Warnings); fields != tc.warnings {
+			t.Fatal("expected warnings for", tc.warnings, "with", tc.spec, "but got", fields)
+		}
+	}
+}
+
+// TestValidateKHCheckDependencies ensures the checks a khcheck depends on and its dependencyPolicy are validated
+func TestValidateKHCheckDependencies(t *testing.T) {
+	for _, tc := range []struct {
+		spec     string
+		errors   string
+		warnings string
+	}{
+		{spec: "dependsOn: [coredns, kube-system/node-readiness]\n  dependencyPolicy: Skip"},
+		{spec: "dependsOn: [coredns]\n  dependencyPolicy: Ignore", errors: "spec.dependencyPolicy"},
+		{spec: "dependencyPolicy: Skip", warnings: "spec.dependencyPolicy"},
+		{spec: "dependsOn: [dns]", errors: "spec.dependsOn[0]"},
+		{spec: "dependsOn: [kuberhealthy/dns]", errors: "spec.dependsOn[0]"},
+		{spec: "dependsOn: [Core_DNS, a/b/c]", errors: "spec.dependsOn[0],spec.dependsOn[1]"},
+		{spec: "dependsOn: [coredns, kuberhealthy/coredns]", warnings: "spec.dependsOn[1]"},
+	} {
+		manifest := strings.Replace(validKHCheckManifest, "timeout: 1m", "timeout: 1m\n  "+tc.spec, 1)
+		results, err := validateKHCheckManifest(context.Background(), []byte(manifest), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fields := issueFields(results[0].Errors); fields != tc.errors {
+			t.Fatal("expected errors for", tc.errors, "with", tc.spec, "but got", fields)
+		}
+		if fields := issueFields(results[0].Warnings); fields != tc.warnings {
+			t.Fatal("expected warnings for", tc.warnings, "with", tc.spec, "but got", fields)
+		}
+	}
+}
+
+// TestValidateKHCheckPodSpec ensures the scheduling fields and volumes of checker pods are validated
+func TestValidateKHCheckPodSpec(t *testing.T) {
+	for _, tc := range []struct {
+		podSpec  string
+		errors   string
+		warnings string
+	}{
+		{podSpec: "tolerations:\n    - key: dedicated\n      value: infra\n      effect: NoSchedule\n    - operator: Exists"},
+		{podSpec: "tolerations:\n    - value: infra", errors: "spec.podSpec.tolerations[0].key"},
+		{podSpec: "tolerations:\n    - key: dedicated\n      operator: Exists\n      value: infra", errors: "spec.podSpec.tolerations[0].value"},
+		{podSpec: "tolerations:\n    - key: dedicated\n      operator: Matches", errors: "spec.podSpec.tolerations[0].operator"},
+		{podSpec: "tolerations:\n    - operator: Exists\n      effect: NoRun", errors: "spec.podSpec.tolerations[0].effect"},
+		{podSpec: "hostNetwork: true\n    dnsPolicy: Default", warnings: "spec.podSpec.dnsPolicy"},
+		{podSpec: "imagePullSecrets:\n    - name: registry\n    - name: \"\"", errors: "spec.podSpec.imagePullSecrets[1].name"},
+		{podSpec: "containers:\n    - name: main\n      image: kuberhealthy/dns-resolution-check:v1.5.0\n      env:\n      - name: TOKEN\n        value: abc\n        valueFrom:\n          secretKeyRef: {name: credentials, key: token}\n      envFrom:\n      - prefix: CHECK_", errors: "spec.podSpec.containers[0].env[0].valueFrom,spec.podSpec.containers[0].envFrom[0]"},
+		{podSpec: "volumes:\n    - name: config\n      emptyDir: {}\n    - name: config\n      emptyDir: {}", errors: "spec.podSpec.volumes[1].name"},
+		{podSpec: "volumes:\n    - name: config\n      emptyDir: {}\n    containers:\n    - name: main\n      image: kuberhealthy/dns-resolution-check:v1.5.0\n      volumeMounts:\n      - name: config\n        mountPath: /config\n      - name: certs\n        mountPath: /certs", errors: "spec.podSpec.containers[0].volumeMounts[1].name"},
+	} {
+		manifest := strings.Replace(validKHCheckManifest, "  podSpec:\n    containers:\n", "  podSpec:\n    "+tc.podSpec+"\n    containers:\n", 1)
+		if strings.Contains(tc.podSpec, "containers:") {
+			manifest = strings.SplitAfter(validKHCheckManifest, "  podSpec:\n")[0] + "    " + tc.podSpec + "\n"
+		}
+		results, err := validateKHCheckManifest(context.Background(), []byte(manifest), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fields := issueFields(results[0].Errors); fields != tc.errors {
+			t.Fatal("expected errors for", tc.errors, "with", tc.podSpec, "but got", fields)
+		}
+		if fields := issueFields(results[0].Warnings); fields != tc.warnings {
+			t.Fatal("expected warnings for", tc.warnings, "with", tc.podSpec, "but got", fields)
+		}
+	}
+}
+
+// TestValidateKHCheckRBAC ensures the rules of the Role created for checker pods are validated
+func TestValidateKHCheckRBAC(t *testing.T) {
+	for _, tc := range []struct {
+		rbac     string
+		errors   string
+		warnings string
+	}{
+		{rbac: "rules:\n    - apiGroups: [\"\"]\n      resources: [pods]\n      verbs: [get, list]"},
+		{rbac: "rules: []", warnings: "spec.rbac.rules"},
+		{rbac: "rules:\n    - resources: [pods]", errors: "spec.rbac.rules[0].verbs"},
+		{rbac: "rules:\n    - nonResourceURLs: [/healthz]\n      verbs: [get]", errors: "spec.rbac.rules[0].nonResourceURLs,spec.rbac.rules[0].resources"},
+	} {
+		manifest := strings.Replace(validKHCheckManifest, "timeout: 1m", "timeout: 1m\n  rbac:\n    "+tc.rbac, 1)
+		results, err := validateKHCheckManifest(context.Background(), []byte(manifest), false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fields := issueFields(results[0].Errors); fields != tc.errors {
+			t.Fatal("expected errors for", tc.errors, "with", tc.rbac, "but got", fields)
+		}
+		if fields := issueFields(results[0].Warnings); fields != tc.warnings {
+			t.Fatal("expected warnings for", tc.warnings, "with", tc.rbac, "but got", fields)
+		}
+	}
+}
+
+// TestValidateKHCheckImages ensures images that do not exist are errors
+func TestValidateKHCheckImages(t *testing.T) {
+	server := httptest.NewServer(registry.New())
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	img, err := random.Image(64, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := name.ParseReference(host + "/checks/dns:v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = remote.Write(ref, img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	manifest := strings.Replace(validKHCheckManifest, "kuberhealthy/dns-resolution-check:v1.5.0", host+"/checks/dns:v1", 1)
+	results, err := validateKHCheckManifest(context.Background(), []byte(manifest), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results[0].Errors) != 0 {
+		t.Fatal("expected the pushed image to be found but got", results[0].Errors)
+	}
+
+	manifest = strings.Replace(validKHCheckManifest, "kuberhealthy/dns-resolution-check:v1.5.0", host+"/checks/dns:missing", 1)
+	results, err = validateKHCheckManifest(context.Background(), []byte(manifest), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if issueFields(results[0].Errors) != "spec.podSpec.containers[0].image" {
+		t.Fatal("expected an error for the missing image but got", results[0].Errors)
+	}
+}
+
+// TestLintKHCheck ensures linting exits non-zero when errors are found
+func TestLintKHCheck(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	invalid := filepath.Join(dir, "invalid.yaml")
+	os.WriteFile(valid, []byte(validKHCheckManifest), 0644)
+	os.WriteFile(invalid, []byte(invalidKHCheckManifest), 0644)
+
+	var out bytes.Buffer
+	if code := lintKHCheck(valid, false, &out); code != 0 {
+		t.Fatal("expected the valid khcheck to pass but got", code, out.String())
+	}
+	out.Reset()
+	if code := lintKHCheck(invalid, false, &out); code != 1 || !strings.Contains(out.String(), "ERROR   kuberhealthy/Bad_Name spec.runInterval:") {
+		t.Fatal("expected the invalid khcheck to fail but got", code, out.String())
+	}
+	if code := lintKHCheck(filepath.Join(dir, "missing.yaml"), false, &out); code != 2 {
+		t.Fatal("expected a missing manifest to exit 2 but got", code)
+	}
+}
+
+// TestValidateHandler ensures the validate endpoint responds with the issues of each khcheck
+func TestValidateHandler(t *testing.T) {
+	kh := &Kuberhealthy{}
+
+	req := httptest.NewRequest(http.MethodPost, validatePath+"?skipImageCheck=true", strings.NewReader(invalidKHCheckManifest))
+	recorder := httptest.NewRecorder()
+	err := kh.validateHandler(recorder, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response struct {
+		Valid  bool
+		Checks []khCheckValidation
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Code != http.StatusUnprocessableEntity || response.Valid || len(response.Checks) != 1 || len(response.Checks[0].Errors) == 0 {
+		t.Fatal("expected 422 with the errors of the khcheck but got", recorder.Code, recorder.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, validatePath+"?skipImageCheck=true", strings.NewReader(validKHCheckManifest))
+	recorder = httptest.NewRecorder()
+	err = kh.validateHandler(recorder, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `"valid": true`) {
+		t.Fatal("expected 200 for the valid khcheck but got", recorder.Code, recorder.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, validatePath, nil)
+	recorder = httptest.NewRecorder()
+	kh.validateHandler(recorder, req)
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Fatal("expected 405 for a GET but got", recorder.Code)
+	}
+}
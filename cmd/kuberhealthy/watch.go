@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// watchPath streams the state transitions of checks and jobs as server-sent events
+const watchPath = "/watch"
+
+// watchKeepAliveInterval is how often a comment is sent to idle watch streams, so that proxies do not close them
+var watchKeepAliveInterval = time.Second * 30
+
+// watchBufferSize is the number of transitions that can wait for a slow watch client before it is disconnected
+const watchBufferSize = 100
+
+// stateWatchEvent is a state transition sent to watch clients
+type stateWatchEvent struct {
+	id      uint64
+	payload webhookPayload
+}
+
+// stateChange is a khstate change observed by the state reflector
+type stateChange struct {
+	previous *khstatev1.KuberhealthyState
+	current  *khstatev1.KuberhealthyState
+}
+
+// stateWatchHub fans the state transitions observed by the state reflector of this kuberhealthy pod out to watch
+// clients.  Transitions are observed on every pod, no matter which pod ran the check.
+type stateWatchHub struct {
+	mu          sync.Mutex
+	subscribers map[chan stateWatchEvent]bool
+	changes     chan stateChange
+	lastID      uint64
+	workload    func(name string, namespace string) khstatev1.KHWorkload // determines if a khstate belongs to a check or a job
+}
+
+// newStateWatchHub creates a hub without subscribers.  It publishes nothing until it is run.
+func newStateWatchHub() *stateWatchHub {
+	return &stateWatchHub{
+		subscribers: make(map[chan stateWatchEvent]bool),
+		changes:     make(chan stateChange, watchBufferSize),
+		workload:    determineKHWorkload,
+	}
+}
+
+// observe queues a khstate change observed by the state reflector.  Changes are dropped while nobody watches, or when
+// the queue is full, so that the reflector is never held up.
+func (h *stateWatchHub) observe(previous *khstatev1.KuberhealthyState, current *khstatev1.KuberhealthyState) {
+	if h.watchers() == 0 {
+		return
+	}
+	select {
+	case h.changes <- stateChange{previous: previous, current: current}:
+	default:
+		log.Warningln("watch: dropped a khstate change because the queue is full")
+	}
+}
+
+// run publishes the queued khstate changes that are state transitions to the subscribers until stop is closed
+func (h *stateWatchHub) run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case change := <-h.changes:
+			payload, ok := h.transition(change)
+			if ok {
+				h.publish(payload)
+			}
+		}
+	}
+}
+
+// transition builds the payload of a khstate change when it changed the OK state of the check or job.  The first
+// run of a check is compared to OK, the same way as for webhooks and events.
+func (h *stateWatchHub) transition(change stateChange) (webhookPayload, bool) {
+	current := change.current
+	if current == nil || len(current.Spec.AuthoritativePod) == 0 {
+		return webhookPayload{}, false
+	}
+	previousOK := true
+	if change.previous != nil && len(change.previous.Spec.AuthoritativePod) > 0 {
+		previousOK = change.previous.Spec.OK
+	}
+	transition := detectStateTransition(previousOK, current.Spec.OK)
+	if transition == transitionNone {
+		return webhookPayload{}, false
+	}
+	workload := h.workload(current.Name, current.Namespace)
+	return newStateChangePayload(current.Name, current.Namespace, workload, previousOK, transition, current.Spec, time.Now().UTC()), true
+}
+
+// publish sends a transition to every subscriber.  Subscribers that can not keep up are disconnected, so that they
+// reconnect instead of silently missing transitions.
+func (h *stateWatchHub) publish(payload webhookPayload) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastID++
+	event := stateWatchEvent{id: h.lastID, payload: payload}
+	for ch := range h.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.Warningln("watch: disconnecting a client that is not keeping up with state transitions")
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe returns a channel the transitions are sent on and a func that ends the subscription.  The channel is
+// closed when the subscriber is disconnected for falling behind.
+func (h *stateWatchHub) subscribe() (chan stateWatchEvent, func()) {
+	ch := make(chan stateWatchEvent, watchBufferSize)
+	h.mu.Lock()
+	h.subscribers[ch] = true
+	h.mu.Unlock()
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if h.subscribers[ch] {
+			delete(h.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// watchers returns the number of subscribers
+func (h *stateWatchHub) watchers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
+
+// watchHandler streams the state transitions of checks and jobs as server-sent events.  Like the status page, the
+// stream can be limited to namespaces and checks (i.e. /watch?namespace=team-a&check=dns-status).
+func (k *Kuberhealthy) watchHandler(w http.ResponseWriter, r *http.Request) error {
+	log.Infoln("Client connected to watch endpoint from", r.RemoteAddr, r.UserAgent())
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return nil
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+		return fmt.Errorf("the response writer does not support streaming")
+	}
+	filter := parseStatusFilter(r.URL.Query())
+
+	events, unsubscribe := k.stateWatchers.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_, err := fmt.Fprint(w, ": watching state transitions\n\n")
+	if err != nil {
+		return err
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(watchKeepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return nil
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keep-alive\n\n")
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if !filter.matches(event.payload.Namespace+"/"+event.payload.Name, event.payload.Namespace) {
+				continue
+			}
+			var b []byte
+			b, err = json.Marshal(event.payload)
+			if err != nil {
+				return fmt.Errorf("failed to marshal state transition: %w", err)
+			}
+			_, err = fmt.Fprint(w, "id: "+strconv.FormatUint(event.id, 10)+"\nevent: "+event.payload.Transition+"\ndata: "+string(b)+"\n\n")
+		}
+		if err != nil {
+			return nil
+		}
+		flusher.Flush()
+	}
+}
+
+// watchMetrics returns the number of watch clients in the Prometheus format
+func (k *Kuberhealthy) watchMetrics() string {
+	if k.stateWatchers == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("# HELP kuberhealthy_watch_clients The number of clients streaming state transitions from this kuberhealthy pod\n")
+	b.WriteString("# TYPE kuberhealthy_watch_clients gauge\n")
+	b.WriteString(fmt.Sprintf("kuberhealthy_watch_clients %d\n", k.stateWatchers.watchers()))
+	return b.String()
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// newTestWatchState creates the khstate of a check that ran on kuberhealthy-a
+func newTestWatchState(namespace string, name string, ok bool) *khstatev1.KuberhealthyState {
+	details := khstatev1.WorkloadDetails{OK: ok, Namespace: namespace, AuthoritativePod: "kuberhealthy-a"}
+	if !ok {
+		details.Errors = []string{name + " failed"}
+	}
+	return &khstatev1.KuberhealthyState{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}, Spec: details}
+}
+
+// TestStateWatchHubTransition ensures only changes of the OK state are transitions, and that first runs are compared
+// to OK
+func TestStateWatchHubTransition(t *testing.T) {
+	hub := newStateWatchHub()
+	hub.workload = func(name string, namespace string) khstatev1.KHWorkload { return khstatev1.KHJob }
+
+	tests := []struct {
+		name       string
+		change     stateChange
+		transition string
+	}{
+		{name: "first run passed", change: stateChange{current: newTestWatchState("kuberhealthy", "dns", true)}},
+		{name: "first run failed", change: stateChange{current: newTestWatchState("kuberhealthy", "dns", false)}, transition: "failed"},
+		{name: "still failing", change: stateChange{previous: newTestWatchState("kuberhealthy", "dns", false), current: newTestWatchState("kuberhealthy", "dns", false)}},
+		{name: "recovered", change: stateChange{previous: newTestWatchState("kuberhealthy", "dns", false), current: newTestWatchState("kuberhealthy", "dns", true)}, transition: "recovered"},
+		{name: "deleted", change: stateChange{previous: newTestWatchState("kuberhealthy", "dns", false)}},
+		{name: "never ran", change: stateChange{current: &khstatev1.KuberhealthyState{ObjectMeta: metav1.ObjectMeta{Name: "dns"}}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, ok := hub.transition(test.change)
+			if ok != (len(test.transition) > 0) || payload.Transition != test.transition {
+				t.Fatal("expected transition", test.transition, "but got", ok, payload.Transition)
+			}
+			if ok && (payload.Kind != "job" || payload.Name != "dns" || payload.Namespace != "kuberhealthy") {
+				t.Fatal("unexpected payload", payload)
+			}
+		})
+	}
+}
+
+// TestWatchHandler ensures state transitions observed by the state reflector are streamed as server-sent events to
+// the clients watching their namespace
+func TestWatchHandler(t *testing.T) {
+	k := &Kuberhealthy{stateWatchers: newStateWatchHub()}
+	k.stateWatchers.workload = func(name string, namespace string) khstatev1.KHWorkload { return khstatev1.KHCheck }
+	stop := make(chan struct{})
+	defer close(stop)
+	go k.stateWatchers.run(stop)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := k.watchHandler(w, r)
+		if err != nil {
+			t.Error(err)
+		}
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + watchPath + "?namespace=kuberhealthy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatal("expected an event stream but got", resp.Status, resp.Header.Get("Content-Type"))
+	}
+	reader := bufio.NewReader(resp.Body)
+	_, err = reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k.stateWatchers.observe(nil, newTestWatchState("team-a", "dns", false))
+	k.stateWatchers.observe(newTestWatchState("kuberhealthy", "dns", true), newTestWatchState("kuberhealthy", "dns", true))
+	k.stateWatchers.observe(newTestWatchState("kuberhealthy", "dns", true), newTestWatchState("kuberhealthy", "dns", false))
+
+	lines := make(chan []string)
+	go func() {
+		var event []string
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSuffix(line, "\n")
+			if len(line) == 0 && len(event) > 0 {
+				lines <- event
+				return
+			}
+			if len(line) > 0 && !strings.HasPrefix(line, ":") {
+				event = append(event, line)
+			}
+		}
+	}()
+	select {
+	case event := <-lines:
+		if len(event) != 3 || event[0] != "id: 2" || event[1] != "event: failed" || !strings.HasPrefix(event[2], "data: ") {
+			t.Fatal("expected the failure of the dns check in the watched namespace but got", event)
+		}
+		var payload webhookPayload
+		err = json.Unmarshal([]byte(strings.TrimPrefix(event[2], "data: ")), &payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if payload.Name != "dns" || payload.Namespace != "kuberhealthy" || payload.Kind != "check" || payload.OK || !payload.PreviousOK || len(payload.Errors) != 1 {
+			t.Fatal("unexpected state transition", payload)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for the state transition")
+	}
+
+	if !strings.Contains(k.watchMetrics(), "kuberhealthy_watch_clients 1\n") {
+		t.Fatal("expected one watch client in the metrics but got", k.watchMetrics())
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/webauth"
+)
+
+// authBasicUsersFile is an htpasswd file of users that can read the web server with basic auth.  Basic auth is off
+// when blank.
+var authBasicUsersFile string
+
+// authTokenReview enables reading the web server with bearer tokens that the Kubernetes API authenticates with a
+// TokenReview, such as service account tokens
+var authTokenReview bool
+
+// authTokenAudiences are the audiences bearer tokens validated with a TokenReview must be issued for
+var authTokenAudiences []string
+
+// authOIDCIssuerURL and authOIDCClientID enable reading the web server with OIDC ID tokens of the issuer that were
+// issued for the client ID
+var authOIDCIssuerURL string
+var authOIDCClientID string
+var authOIDCUsernameClaim = webauth.DefaultOIDCUsernameClaim
+var authOIDCGroupsClaim = webauth.DefaultOIDCGroupsClaim
+
+// authAllowedUsers and authAllowedGroups restrict reading the web server with bearer tokens to the listed users and
+// groups.  All authenticated users can read it when both are empty.
+var authAllowedUsers []string
+var authAllowedGroups []string
+
+// webAuthEnabled determines if requests to the web server are authenticated
+func webAuthEnabled() bool {
+	return len(authBasicUsersFile) > 0 || authTokenReview || len(authOIDCIssuerURL) > 0
+}
+
+// webAuthFlagsConfig returns the authentication configuration of the auth flags.  The Kubernetes client TokenReviews
+// are created with is left unset.
+func webAuthFlagsConfig() (webauth.Config, error) {
+	c := webauth.Config{
+		Audiences:     authTokenAudiences,
+		AllowedUsers:  authAllowedUsers,
+		AllowedGroups: authAllowedGroups,
+	}
+	bearer := authTokenReview || len(authOIDCIssuerURL) > 0
+	if !bearer && (len(authAllowedUsers) > 0 || len(authAllowedGroups) > 0) {
+		return c, errors.New("authAllowedUsers and authAllowedGroups require authTokenReview or authOIDCIssuerURL")
+	}
+	if !authTokenReview && len(authTokenAudiences) > 0 {
+		return c, errors.New("authTokenAudiences requires authTokenReview")
+	}
+	if len(authOIDCIssuerURL) > 0 || len(authOIDCClientID) > 0 {
+		if len(authOIDCIssuerURL) == 0 || len(authOIDCClientID) == 0 {
+			return c, errors.New("authOIDCIssuerURL and authOIDCClientID must be set together")
+		}
+		c.OIDC = &webauth.OIDCConfig{
+			IssuerURL:     authOIDCIssuerURL,
+			ClientID:      authOIDCClientID,
+			UsernameClaim: authOIDCUsernameClaim,
+			GroupsClaim:   authOIDCGroupsClaim,
+		}
+	}
+	if len(authBasicUsersFile) > 0 {
+		b, err := os.ReadFile(authBasicUsersFile)
+		if err != nil {
+			return c, fmt.Errorf("failed to read authBasicUsersFile: %w", err)
+		}
+		c.BasicUsers, err = webauth.ParseHtpasswd(b)
+		if err != nil {
+			return c, fmt.Errorf("failed to parse authBasicUsersFile %s: %w", authBasicUsersFile, err)
+		}
+		if len(c.BasicUsers) == 0 {
+			return c, errors.New("authBasicUsersFile " + authBasicUsersFile + " does not contain any users")
+		}
+	}
+	return c, nil
+}
+
+// validateWebAuthFlags ensures the auth flags configure authentication that can be set up
+func validateWebAuthFlags() error {
+	_, err := webAuthFlagsConfig()
+	return err
+}
+
+// newWebAuthenticator creates the authenticator requests to the web server are authenticated with.  nil is returned
+// when authentication is disabled.
+func newWebAuthenticator() (*webauth.Authenticator, error) {
+	if !webAuthEnabled() {
+		return nil, nil
+	}
+	c, err := webAuthFlagsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if authTokenReview {
+		if kubernetesClient == nil {
+			return nil, errors.New("authTokenReview requires a kubernetes client")
+		}
+		c.TokenReviews = kubernetesClient
+	}
+	// OIDC discovery and keys are fetched from the issuer, which may only be reachable through the proxy
+	c.HTTPClient = &http.Client{Timeout: time.Second * 10, Transport: outboundTransport()}
+	log.Infoln("webauth: authenticating web requests with basic auth:", len(c.BasicUsers) > 0, "TokenReviews:", authTokenReview, "OIDC:", c.OIDC != nil)
+	return webauth.New(c)
+}
+
+// authenticated wraps a handler so that it only serves authenticated requests when authentication is enabled
+func (k *Kuberhealthy) authenticated(h http.HandlerFunc) http.HandlerFunc {
+	if k.webAuth == nil {
+		return h
+	}
+	return k.webAuth.Handler(h).ServeHTTP
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// setWebAuthFlags sets the basic auth and OIDC auth flags for the duration of a test
+func setWebAuthFlags(t *testing.T, basicUsersFile string, oidcIssuerURL string, oidcClientID string, allowedUsers []string) {
+	previousFile, previousIssuer, previousClient, previousUsers := authBasicUsersFile, authOIDCIssuerURL, authOIDCClientID, authAllowedUsers
+	authBasicUsersFile, authOIDCIssuerURL, authOIDCClientID, authAllowedUsers = basicUsersFile, oidcIssuerURL, oidcClientID, allowedUsers
+	t.Cleanup(func() {
+		authBasicUsersFile, authOIDCIssuerURL, authOIDCClientID, authAllowedUsers = previousFile, previousIssuer, previousClient, previousUsers
+	})
+}
+
+func TestValidateWebAuthFlags(t *testing.T) {
+	dir := t.TempDir()
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	usersFile := writeTestFile(t, dir, "htpasswd", []byte("reader:"+string(hash)+"\n"))
+
+	setWebAuthFlags(t, usersFile, "https://issuer.example.com", "kuberhealthy", []string{"reader@example.com"})
+	err = validateWebAuthFlags()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, flags := range map[string]func(){
+		"a missing users file":             func() { setWebAuthFlags(t, dir+"/missing", "", "", nil) },
+		"a users file without users":       func() { setWebAuthFlags(t, writeTestFile(t, dir, "empty", []byte("# nobody\n")), "", "", nil) },
+		"an OIDC issuer without client ID": func() { setWebAuthFlags(t, "", "https://issuer.example.com", "", nil) },
+		"allowed users without tokens":     func() { setWebAuthFlags(t, usersFile, "", "", []string{"reader"}) },
+	} {
+		flags()
+		err := validateWebAuthFlags()
+		if err == nil {
+			t.Fatalf("expected an error for %s", name)
+		}
+		t.Log(err)
+	}
+}
+
+// TestAuthenticatedHandler ensures handlers only serve authenticated requests when authentication is enabled
+func TestAuthenticatedHandler(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setWebAuthFlags(t, writeTestFile(t, t.TempDir(), "htpasswd", []byte("reader:"+string(hash)+"\n")), "", "", nil)
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	k := &Kuberhealthy{}
+	w := httptest.NewRecorder()
+	k.authenticated(ok)(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Fatal("expected requests to be served without authentication when it is disabled but got", w.Code)
+	}
+
+	k.webAuth, err = newWebAuthenticator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	k.authenticated(ok)(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatal("expected a 401 for a request without credentials but got", w.Code)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetBasicAuth("reader", "secret")
+	w = httptest.NewRecorder()
+	k.authenticated(ok)(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatal("expected an authenticated request to be served but got", w.Code)
+	}
+}
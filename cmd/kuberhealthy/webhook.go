@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/webhook"
+)
+
+// webhookURLs are the URLs a JSON payload is posted to when a check or job fails or recovers.  Webhooks are
+// disabled when empty.
+var webhookURLs []string
+
+// webhookHeadersFlag holds comma separated key=value headers sent with every webhook payload
+var webhookHeadersFlag string
+var webhookHeaders map[string]string
+
+// webhookSecret signs every webhook payload with HMAC-SHA256 when set
+var webhookSecret string
+
+// webhookPayload is the JSON payload posted to webhooks when the state of a check or job changes
+type webhookPayload struct {
+	Name       string                    `json:"name"`               // the name of the khcheck or khjob
+	Namespace  string                    `json:"namespace"`          // the namespace of the khcheck or khjob
+	Kind       string                    `json:"kind"`               // check or job
+	Cluster    string                    `json:"cluster,omitempty"`  // the clusterName flag
+	Transition string                    `json:"transition"`         // failed or recovered
+	PreviousOK bool                      `json:"previousOK"`         // the OK state before this run
+	OK         bool                      `json:"ok"`                 // the OK state after this run
+	Errors     []string                  `json:"errors"`             // the errors of this run
+	Severity   string                    `json:"severity,omitempty"` // the severity of the errors, Critical or Warning
+	Timestamp  time.Time                 `json:"timestamp"`          // when the state changed
+	LastRun    *metav1.Time              `json:"lastRun,omitempty"`  // when the run that changed the state happened
+	Status     khstatev1.WorkloadDetails `json:"status"`             // the full khstate of the check or job
+}
+
+// parseWebhookHeaders parses the webhookHeaders flag
+func parseWebhookHeaders() error {
+	var err error
+	webhookHeaders, err = parseKeyValueFlag(webhookHeadersFlag)
+	return err
+}
+
+// newWebhookSinks creates a sink for each webhook URL.  nil is returned when webhooks are disabled.
+func newWebhookSinks() []*webhook.Sink {
+	var sinks []*webhook.Sink
+	for _, u := range webhookURLs {
+		sink := webhook.NewSink(webhook.SinkConfig{
+			URL:     u,
+			Headers: webhookHeaders,
+			Secret:  webhookSecret,
+			Proxy:   outboundProxy(),
+		})
+		log.Infoln("webhook: sending state changes to", sink.Name())
+		sinks = append(sinks, sink)
+	}
+	return sinks
+}
+
+// newWebhookPayload builds the webhook payload for a state transition of a workload
+func newWebhookPayload(name string, namespace string, previousOK bool, transition stateTransition, details khstatev1.WorkloadDetails, now time.Time) webhookPayload {
+	return newStateChangePayload(name, namespace, details.GetKHWorkload(), previousOK, transition, details, now)
+}
+
+// newStateChangePayload builds the JSON payload of a state transition of a check or job of the supplied workload type
+func newStateChangePayload(name string, namespace string, workload khstatev1.KHWorkload, previousOK bool, transition stateTransition, details khstatev1.WorkloadDetails, now time.Time) webhookPayload {
+	kind := "check"
+	if workload == khstatev1.KHJob {
+		kind = "job"
+	}
+	t := "recovered"
+	if transition == transitionFailed {
+		t = "failed"
+	}
+	errors := details.Errors
+	if errors == nil {
+		errors = []string{}
+	}
+	return webhookPayload{
+		Name:       name,
+		Namespace:  namespace,
+		Kind:       kind,
+		Cluster:    clusterName,
+		Transition: t,
+		PreviousOK: previousOK,
+		OK:         details.OK,
+		Errors:     errors,
+		Severity:   details.Severity,
+		Timestamp:  now,
+		LastRun:    details.LastRun,
+		Status:     details,
+	}
+}
+
+// sendStateChangeWebhooks posts the workload details to every webhook if the workload state changed from the
+// previous OK state.  true is returned when the details were posted.
+func (k *Kuberhealthy) sendStateChangeWebhooks(name string, namespace string, previousOK bool, details khstatev1.WorkloadDetails) bool {
+	if len(k.webhookSinks) == 0 {
+		return false
+	}
+
+	transition := detectStateTransition(previousOK, details.OK)
+	if transition == transitionNone {
+		return false
+	}
+
+	payload := newWebhookPayload(name, namespace, previousOK, transition, details, time.Now().UTC())
+	log.Debugln("webhook: sending", payload.Transition, "state change for", namespace+"/"+name, "to", len(k.webhookSinks), "webhooks")
+	for _, sink := range k.webhookSinks {
+		sink.Send(payload)
+	}
+	return true
+}
+
+// webhookMetrics returns the Prometheus metrics of the webhook sinks.  A blank string is returned when webhooks are
+// disabled.
+func (k *Kuberhealthy) webhookMetrics() string {
+	if len(k.webhookSinks) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("# HELP kuberhealthy_webhook_dropped_total The number of webhook payloads that could not be delivered\n")
+	b.WriteString("# TYPE kuberhealthy_webhook_dropped_total counter\n")
+	for _, sink := range k.webhookSinks {
+		b.WriteString(fmt.Sprintf("kuberhealthy_webhook_dropped_total{url=\"%s\"} %d\n", sink.Name(), sink.Dropped()))
+	}
+	return b.String()
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/webhook"
+)
+
+// TestSendStateChangeWebhooks ensures every webhook receives the full status of a check when it fails or recovers,
+// and nothing for runs that do not change the state
+func TestSendStateChangeWebhooks(t *testing.T) {
+	received := make(chan webhookPayload, 10)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil {
+			t.Error("failed to decode payload:", err)
+		}
+		received <- payload
+	})
+	first := httptest.NewServer(handler)
+	defer first.Close()
+	second := httptest.NewServer(handler)
+	defer second.Close()
+
+	previousClusterName := clusterName
+	clusterName = "test-cluster"
+	defer func() { clusterName = previousClusterName }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	kh := &Kuberhealthy{webhookSinks: []*webhook.Sink{
+		webhook.NewSink(webhook.SinkConfig{URL: first.URL}),
+		webhook.NewSink(webhook.SinkConfig{URL: second.URL}),
+	}}
+	for _, sink := range kh.webhookSinks {
+		go sink.Start(ctx)
+	}
+
+	failing := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	failing.Errors = []string{"lookup failed"}
+	failing.Node = "node-1"
+	ok := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	ok.OK = true
+
+	kh.sendStateChangeWebhooks("dns-status", "kuberhealthy", true, ok)
+	kh.sendStateChangeWebhooks("dns-status", "kuberhealthy", true, failing)
+	kh.sendStateChangeWebhooks("dns-status", "kuberhealthy", false, failing)
+	kh.sendStateChangeWebhooks("dns-status", "kuberhealthy", false, ok)
+
+	var payloads []webhookPayload
+	for len(payloads) < 4 {
+		select {
+		case payload := <-received:
+			payloads = append(payloads, payload)
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for payload delivery, got", payloads)
+		}
+	}
+	select {
+	case payload := <-received:
+		t.Fatal("expected no payload for runs without a state change but got", payload)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	var failed, recovered int
+	for _, payload := range payloads {
+		if payload.Name != "dns-status" || payload.Namespace != "kuberhealthy" || payload.Kind != "check" || payload.Cluster != "test-cluster" {
+			t.Fatal("unexpected payload received:", payload)
+		}
+		switch payload.Transition {
+		case "failed":
+			failed++
+			if payload.OK || !payload.PreviousOK || len(payload.Errors) != 1 || payload.Errors[0] != "lookup failed" || payload.Status.Node != "node-1" {
+				t.Fatal("expected the errors and status of the failed check but got", payload)
+			}
+		case "recovered":
+			recovered++
+			if !payload.OK || payload.PreviousOK || payload.Errors == nil {
+				t.Fatal("expected the recovered check without errors but got", payload)
+			}
+		}
+	}
+	if failed != 2 || recovered != 2 {
+		t.Fatal("expected each webhook to receive the failure and recovery but got", payloads)
+	}
+
+	metrics := kh.webhookMetrics()
+	if !strings.Contains(metrics, "kuberhealthy_webhook_dropped_total{url=\""+first.URL+"\"} 0") {
+		t.Fatal("unexpected webhook metrics:", metrics)
+	}
+}
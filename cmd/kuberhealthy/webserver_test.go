@@ -13,7 +13,9 @@ import (
 	"github.com/Pallinder/go-randomdata"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
 )
 
@@ -147,3 +149,77 @@ func TestWebServerNotOK(t *testing.T) {
 	}
 
 }
+
+// TestCheckDetailsHandler tests serving the details and captured logs of a single check
+func TestCheckDetailsHandler(t *testing.T) {
+	kh := &Kuberhealthy{
+		stateReflector: &StateReflector{store: cache.NewStore(cache.MetaNamespaceKeyFunc)},
+	}
+
+	details := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	details.Errors = []string{"timed out waiting for checker pod to report in"}
+	details.AuthoritativePod = "kuberhealthy-abc"
+	details.LogExcerpt = "dial tcp 10.0.0.1:53: i/o timeout"
+	khState := khstatev1.NewKuberhealthyState("dns-status", details)
+	khState.SetNamespace("kuberhealthy")
+	err := kh.stateReflector.store.Add(&khState)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/checks/kuberhealthy/dns-status", nil)
+	err = kh.checkDetailsHandler(recorder, req)
+	if err != nil {
+		t.Fatal("Error from check details handler:", err)
+	}
+	if recorder.Code != http.StatusOK {
+		t.Fatal("Bad response from handler", recorder.Code)
+	}
+	served := khstatev1.WorkloadDetails{}
+	err = json.Unmarshal(recorder.Body.Bytes(), &served)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if served.LogExcerpt != details.LogExcerpt || len(served.Errors) != 1 {
+		t.Fatal("unexpected check details served:", served)
+	}
+
+	for _, path := range []string{"/api/v1/checks/kuberhealthy/missing", "/api/v1/checks/kuberhealthy", "/api/v1/checks/a/b/c"} {
+		recorder = httptest.NewRecorder()
+		err = kh.checkDetailsHandler(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+		if err != nil {
+			t.Fatal("Error from check details handler:", err)
+		}
+		if recorder.Code != http.StatusNotFound {
+			t.Fatal("expected a not found response for", path, "but got", recorder.Code)
+		}
+	}
+}
+
+// TestWithoutRunDetails ensures captured logs, container statuses and run history are left out of the status page,
+// while the details reported by checks are kept
+func TestWithoutRunDetails(t *testing.T) {
+	details := khstatev1.NewWorkloadDetails(khstatev1.KHCheck)
+	details.LogExcerpt = "panic: runtime error"
+	details.Details = map[string]string{"lastVerifiedBackup": "2024-05-01T02:00Z"}
+	details.Containers = []khstatev1.ContainerRunStatus{{Name: "main", OOMKilled: true}}
+	details.History = []khstatev1.RunResult{{UUID: "a", OK: true}}
+	state := health.NewState()
+	state.CheckDetails["kuberhealthy/dns-status"] = details
+	state.JobDetails["kuberhealthy/job"] = details
+
+	state = withoutRunDetails(state)
+	if len(state.CheckDetails["kuberhealthy/dns-status"].LogExcerpt) != 0 || len(state.JobDetails["kuberhealthy/job"].LogExcerpt) != 0 {
+		t.Fatal("expected log excerpts to be removed from the status page")
+	}
+	if state.CheckDetails["kuberhealthy/dns-status"].Containers != nil || state.JobDetails["kuberhealthy/job"].Containers != nil {
+		t.Fatal("expected container statuses to be removed from the status page")
+	}
+	if state.CheckDetails["kuberhealthy/dns-status"].History != nil || state.JobDetails["kuberhealthy/job"].History != nil {
+		t.Fatal("expected the run history to be removed from the status page")
+	}
+	if state.CheckDetails["kuberhealthy/dns-status"].Details["lastVerifiedBackup"] != "2024-05-01T02:00Z" {
+		t.Fatal("expected the reported details to be kept on the status page")
+	}
+}
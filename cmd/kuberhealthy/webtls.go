@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// certificateReloader serves the TLS certificate of the web server and loads it again when its files change, so
+// that rotated certificates, such as those of cert-manager secrets, are used without restarting the web server
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+	interval time.Duration // how often the files are checked for changes. zero never reloads the certificate
+
+	mu          sync.Mutex
+	certificate *tls.Certificate
+	modTime     time.Time // the latest modification time of the files when the certificate was loaded
+	checked     time.Time // when the files were last checked for changes
+}
+
+// newCertificateReloader loads the certificate and key files.  An error is returned if they can not be loaded.
+func newCertificateReloader(certFile string, keyFile string, interval time.Duration) (*certificateReloader, error) {
+	r := &certificateReloader{certFile: certFile, keyFile: keyFile, interval: interval}
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	err = r.load(modTime, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// latestModTime returns the latest modification time of the certificate and key files
+func (r *certificateReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to read TLS file: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// load loads the certificate and key files.  The current certificate is kept if they can not be loaded.
+func (r *certificateReloader) load(modTime time.Time, now time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	r.certificate = &cert
+	r.modTime = modTime
+	r.checked = now
+	return nil
+}
+
+// reload loads the certificate and key files again when they changed since they were loaded and the reload
+// interval has passed since they were last checked
+func (r *certificateReloader) reload(now time.Time) {
+	if r.interval <= 0 || now.Sub(r.checked) < r.interval {
+		return
+	}
+	r.checked = now
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		log.Errorln("Error checking the TLS certificate for changes. Serving the current certificate:", err)
+		return
+	}
+	if modTime.Equal(r.modTime) {
+		return
+	}
+	err = r.load(modTime, now)
+	if err != nil {
+		log.Errorln("Error reloading the TLS certificate. Serving the current certificate:", err)
+		return
+	}
+	log.Infoln("Reloaded the TLS certificate from", r.certFile)
+}
+
+// getCertificate returns the current certificate.  It is used as the GetCertificate func of the web server's TLS
+// configuration, so the files are checked for changes as connections are made.
+func (r *certificateReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reload(time.Now())
+	return r.certificate, nil
+}
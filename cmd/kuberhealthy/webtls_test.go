@@ -0,0 +1,149 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+	httpclient "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
+)
+
+// newTestServerKeyPair creates a self-signed server certificate for 127.0.0.1
+func newTestServerKeyPair(t *testing.T, serial int64) testKeyPair {
+	return newTestKeyPair(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "kuberhealthy"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}, nil)
+}
+
+// TestCertificateReloader ensures a rotated certificate is served once the reload interval passed, and that the
+// current certificate is kept when the rotated files can not be loaded
+func TestCertificateReloader(t *testing.T) {
+	dir := t.TempDir()
+	first := newTestServerKeyPair(t, 1)
+	second := newTestServerKeyPair(t, 2)
+	certFile := writeTestFile(t, dir, "tls.crt", first.certPEM)
+	keyFile := writeTestFile(t, dir, "tls.key", first.keyPEM)
+
+	r, err := newCertificateReloader(certFile, keyFile, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serial := func() int64 {
+		cert, err := x509.ParseCertificate(r.certificate.Certificate[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert.SerialNumber.Int64()
+	}
+
+	// rotate the certificate with a later modification time
+	rotated := time.Now().Add(time.Hour)
+	writeTestFile(t, dir, "tls.crt", second.certPEM)
+	writeTestFile(t, dir, "tls.key", second.keyPEM)
+	for _, file := range []string{certFile, keyFile} {
+		err = os.Chtimes(file, rotated, rotated)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r.reload(r.checked.Add(time.Second))
+	if serial() != 1 {
+		t.Fatal("expected the certificate to be kept until the reload interval passed")
+	}
+	r.reload(r.checked.Add(time.Minute))
+	if serial() != 2 {
+		t.Fatal("expected the rotated certificate to be loaded")
+	}
+
+	// a broken rotation keeps the current certificate
+	writeTestFile(t, dir, "tls.crt", []byte("not a certificate"))
+	err = os.Chtimes(certFile, rotated.Add(time.Hour), rotated.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.reload(r.checked.Add(time.Minute))
+	if serial() != 2 {
+		t.Fatal("expected the current certificate to be kept when the rotated certificate can not be loaded")
+	}
+
+	_, err = newCertificateReloader(certFile, keyFile, 0)
+	if err == nil {
+		t.Fatal("expected an error for a certificate that can not be loaded")
+	}
+}
+
+// TestReportOverTLS ensures checker pods report over https to a web server with a certificate signed by the
+// reporting CA, along with the report token of their run
+func TestReportOverTLS(t *testing.T) {
+	dir := t.TempDir()
+	pair := newTestServerKeyPair(t, 1)
+	r, err := newCertificateReloader(writeTestFile(t, dir, "tls.crt", pair.certPEM), writeTestFile(t, dir, "tls.key", pair.keyPEM), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{GetCertificate: r.getCertificate, MinVersion: tls.VersionTLS12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports := make(chan string, 1)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		reports <- req.Header.Get("kh-run-uuid") + " " + req.Header.Get("kh-report-token")
+	})}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	t.Setenv(external.KHReportingURL, "https://"+listener.Addr().String()+"/externalCheckStatus")
+	t.Setenv(external.KHRunUUID, "run-uuid")
+	t.Setenv(external.KHReportingCA, string(pair.certPEM))
+	t.Setenv(external.KHReportToken, "report-token")
+	err = httpclient.ReportSuccess()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report := <-reports; report != "run-uuid report-token" {
+		t.Fatal("expected the report of run-uuid with its report token but got", report)
+	}
+}
+
+func TestValidateTLS(t *testing.T) {
+	dir := t.TempDir()
+	pair := newTestServerKeyPair(t, 1)
+	caFile := writeTestFile(t, dir, "ca.crt", pair.certPEM)
+
+	c := &Config{TLSCertFile: "tls.crt", TLSKeyFile: "tls.key", TLSReportingCAFile: caFile}
+	err := c.validateTLS()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.reportingCA != string(pair.certPEM) {
+		t.Fatal("expected the reporting CA to be loaded")
+	}
+
+	for _, c := range []*Config{
+		{TLSCertFile: "tls.crt"},
+		{TLSCertFile: "tls.crt", TLSKeyFile: "tls.key", TLSReloadInterval: -time.Minute},
+		{TLSReportingCAFile: caFile},
+		{TLSCertFile: "tls.crt", TLSKeyFile: "tls.key", TLSReportingCAFile: writeTestFile(t, dir, "broken.crt", []byte("not a certificate"))},
+	} {
+		err := c.validateTLS()
+		if err == nil {
+			t.Fatalf("expected an error for the TLS settings %+v", c)
+		}
+		t.Log(err)
+	}
+}
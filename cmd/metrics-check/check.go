@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// nodeMetricsPath is the path of the node metrics of the resource metrics API
+	nodeMetricsPath = "/apis/metrics.k8s.io/v1beta1/nodes"
+
+	// podMetricsPathFormat is the path of the pod metrics of a namespace of the resource metrics API
+	podMetricsPathFormat = "/apis/metrics.k8s.io/v1beta1/namespaces/%s/pods"
+)
+
+// checker ensures the resource metrics API serves current node and pod metrics, and optionally that a horizontal pod
+// autoscaler scales a workload with them
+type checker struct {
+	namespace     string        // the namespace the HPA workload is created in
+	podNamespace  string        // the namespace whose pod metrics are expected
+	maxMetricsAge time.Duration // how old metrics can be before they are stale
+	checkHPA      bool
+	hpaImage      string        // the image of the HPA workload
+	scaleTimeout  time.Duration // how long the HPA can take to scale the workload
+	pollInterval  time.Duration
+	client        kubernetes.Interface
+
+	// getMetrics returns the body of a path of the resource metrics API
+	getMetrics func(ctx context.Context, path string) ([]byte, error)
+}
+
+// metricsList is the part of a NodeMetricsList or PodMetricsList of the resource metrics API used by the check
+type metricsList struct {
+	Items []struct {
+		Metadata  metav1.ObjectMeta `json:"metadata"`
+		Timestamp metav1.Time       `json:"timestamp"`
+	} `json:"items"`
+}
+
+// run checks the node and pod metrics and, when enabled, that the HPA scales.  The errors of the run are returned.
+func (c *checker) run(ctx context.Context) []string {
+	var errs []string
+	errs = append(errs, c.nodeMetricsErrors(ctx)...)
+	errs = append(errs, c.podMetricsErrors(ctx)...)
+
+	// the HPA can not scale without metrics, so only try it when they are served
+	if c.checkHPA && len(errs) == 0 {
+		err := c.checkScaling(ctx)
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	return errs
+}
+
+// listMetrics gets and decodes a list of metrics from the resource metrics API
+func (c *checker) listMetrics(ctx context.Context, path string) (*metricsList, error) {
+	body, err := c.getMetrics(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	list := &metricsList{}
+	err = json.Unmarshal(body, list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the response: %w", err)
+	}
+	return list, nil
+}
+
+// nodeMetricsErrors returns an error when the resource metrics API does not serve node metrics, and for each ready
+// node without current metrics
+func (c *checker) nodeMetricsErrors(ctx context.Context) []string {
+	metrics, err := c.listMetrics(ctx, nodeMetricsPath)
+	if err != nil {
+		return []string{"Failed to get node metrics from the metrics.k8s.io API. The metrics server may be down: " + err.Error()}
+	}
+	log.Infoln("Got metrics for", len(metrics.Items), "nodes")
+
+	nodes, err := c.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return []string{"Failed to list nodes: " + err.Error()}
+	}
+
+	current := make(map[string]bool, len(metrics.Items))
+	var stale []string
+	for _, m := range metrics.Items {
+		if time.Since(m.Timestamp.Time) > c.maxMetricsAge {
+			stale = append(stale, m.Metadata.Name)
+			continue
+		}
+		current[m.Metadata.Name] = true
+	}
+
+	var missing []string
+	for _, node := range nodes.Items {
+		if nodeReady(&node) && !current[node.Name] {
+			missing = append(missing, node.Name)
+		}
+	}
+
+	var errs []string
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		errs = append(errs, fmt.Sprintf("The metrics.k8s.io API has no current metrics for %d ready nodes: %s", len(missing), strings.Join(missing, ", ")))
+	}
+	if len(stale) > 0 {
+		sort.Strings(stale)
+		log.Warnln("Metrics of nodes", stale, "are older than", c.maxMetricsAge)
+	}
+	return errs
+}
+
+// podMetricsErrors returns an error when the resource metrics API does not serve current metrics for any pod of the
+// pod namespace
+func (c *checker) podMetricsErrors(ctx context.Context) []string {
+	metrics, err := c.listMetrics(ctx, fmt.Sprintf(podMetricsPathFormat, c.podNamespace))
+	if err != nil {
+		return []string{fmt.Sprintf("Failed to get pod metrics of namespace %s from the metrics.k8s.io API. The metrics server may be down: %s", c.podNamespace, err)}
+	}
+
+	var current int
+	for _, m := range metrics.Items {
+		if time.Since(m.Timestamp.Time) <= c.maxMetricsAge {
+			current++
+		}
+	}
+	log.Infoln("Got current metrics for", current, "of", len(metrics.Items), "pods in namespace", c.podNamespace)
+	if current == 0 {
+		return []string{fmt.Sprintf("The metrics.k8s.io API has no current pod metrics for namespace %s", c.podNamespace)}
+	}
+	return nil
+}
+
+// nodeReady determines if the Ready condition of a node is true
+func nodeReady(node *apiv1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == apiv1.NodeReady {
+			return condition.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
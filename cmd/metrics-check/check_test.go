@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testNode returns a ready node
+func testNode(name string) *apiv1.Node {
+	return &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     apiv1.NodeStatus{Conditions: []apiv1.NodeCondition{{Type: apiv1.NodeReady, Status: apiv1.ConditionTrue}}},
+	}
+}
+
+// metricsItem returns a metrics list item of the named object, sampled at the supplied time
+func metricsItem(name string, timestamp time.Time) string {
+	return fmt.Sprintf(`{"metadata":{"name":%q},"timestamp":%q,"window":"15s"}`, name, timestamp.UTC().Format(time.RFC3339))
+}
+
+// metricsBody returns a metrics list of the supplied items
+func metricsBody(items ...string) []byte {
+	return []byte(`{"kind":"List","items":[` + strings.Join(items, ",") + `]}`)
+}
+
+// newTestChecker returns a checker with a fake client and a metrics API that responds with the supplied bodies by
+// path.  Paths without a body fail.
+func newTestChecker(client *fake.Clientset, bodies map[string][]byte) *checker {
+	return &checker{
+		namespace:     "kuberhealthy",
+		podNamespace:  "kube-system",
+		maxMetricsAge: time.Minute * 5,
+		hpaImage:      "registry.k8s.io/pause:3.9",
+		scaleTimeout:  time.Millisecond * 300,
+		pollInterval:  time.Millisecond * 10,
+		client:        client,
+		getMetrics: func(ctx context.Context, path string) ([]byte, error) {
+			body, ok := bodies[path]
+			if !ok {
+				return nil, errors.New("the server is currently unable to handle the request")
+			}
+			return body, nil
+		},
+	}
+}
+
+func TestCheckerRun(t *testing.T) {
+	client := fake.NewSimpleClientset(testNode("node-a"), testNode("node-b"))
+	c := newTestChecker(client, map[string][]byte{
+		nodeMetricsPath: metricsBody(metricsItem("node-a", time.Now()), metricsItem("node-b", time.Now())),
+		"/apis/metrics.k8s.io/v1beta1/namespaces/kube-system/pods": metricsBody(metricsItem("coredns-1", time.Now())),
+	})
+
+	errs := c.run(context.Background())
+	if len(errs) != 0 {
+		t.Fatal("expected the check to pass but got", errs)
+	}
+}
+
+func TestCheckerRunMetricsServerDown(t *testing.T) {
+	client := fake.NewSimpleClientset(testNode("node-a"))
+	c := newTestChecker(client, map[string][]byte{})
+	c.checkHPA = true
+
+	errs := c.run(context.Background())
+	if len(errs) != 2 || !strings.Contains(errs[0], "Failed to get node metrics") || !strings.Contains(errs[1], "Failed to get pod metrics of namespace kube-system") {
+		t.Fatal("expected the node and pod metrics to fail but got", errs)
+	}
+	deployments, _ := client.AppsV1().Deployments("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if len(deployments.Items) != 0 {
+		t.Fatal("expected the HPA workload to not be created without metrics")
+	}
+}
+
+func TestCheckerRunMissingAndStaleMetrics(t *testing.T) {
+	notReady := testNode("node-not-ready")
+	notReady.Status.Conditions[0].Status = apiv1.ConditionFalse
+	client := fake.NewSimpleClientset(testNode("node-a"), testNode("node-b"), testNode("node-c"), notReady)
+
+	c := newTestChecker(client, map[string][]byte{
+		nodeMetricsPath: metricsBody(metricsItem("node-a", time.Now()), metricsItem("node-b", time.Now().Add(-time.Hour))),
+		"/apis/metrics.k8s.io/v1beta1/namespaces/kube-system/pods": metricsBody(metricsItem("coredns-1", time.Now().Add(-time.Hour))),
+	})
+
+	errs := c.run(context.Background())
+	if len(errs) != 2 {
+		t.Fatal("expected errors for the nodes and pods without current metrics but got", errs)
+	}
+	if !strings.Contains(errs[0], "no current metrics for 2 ready nodes: node-b, node-c") {
+		t.Fatal("expected the ready nodes without current metrics but got", errs[0])
+	}
+	if !strings.Contains(errs[1], "no current pod metrics for namespace kube-system") {
+		t.Fatal("expected the stale pod metrics to fail but got", errs[1])
+	}
+}
+
+// simulateHPA sets the status of every HPA once it is created, like the HPA controller would
+func simulateHPA(t *testing.T, client *fake.Clientset, status autoscalingv2.HorizontalPodAutoscalerStatus) {
+	go func() {
+		ctx := context.Background()
+		for i := 0; i < 100; i++ {
+			time.Sleep(time.Millisecond * 5)
+			hpas, err := client.AutoscalingV2().HorizontalPodAutoscalers("kuberhealthy").List(ctx, metav1.ListOptions{})
+			if err != nil || len(hpas.Items) == 0 {
+				continue
+			}
+			hpa := hpas.Items[0]
+			hpa.Status = status
+			_, err = client.AutoscalingV2().HorizontalPodAutoscalers("kuberhealthy").UpdateStatus(ctx, &hpa, metav1.UpdateOptions{})
+			if err != nil {
+				t.Log("failed to update hpa status:", err)
+			}
+			return
+		}
+	}()
+}
+
+// assertCleanedUp ensures the HPA workload was removed
+func assertCleanedUp(t *testing.T, client *fake.Clientset) {
+	deployments, _ := client.AppsV1().Deployments("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	hpas, _ := client.AutoscalingV2().HorizontalPodAutoscalers("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if len(deployments.Items) != 0 || len(hpas.Items) != 0 {
+		t.Fatal("expected the HPA workload to be removed but found", len(deployments.Items), "deployments and", len(hpas.Items), "HPAs")
+	}
+}
+
+func TestCheckScaling(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c := newTestChecker(client, nil)
+	now := metav1.Now()
+	simulateHPA(t, client, autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: 2, DesiredReplicas: 1, LastScaleTime: &now})
+
+	err := c.checkScaling(context.Background())
+	if err != nil {
+		t.Fatal("expected the HPA to scale down but got", err)
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestCheckScalingNoMetrics(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c := newTestChecker(client, nil)
+	simulateHPA(t, client, autoscalingv2.HorizontalPodAutoscalerStatus{
+		CurrentReplicas: 2,
+		DesiredReplicas: 2,
+		Conditions: []autoscalingv2.HorizontalPodAutoscalerCondition{{
+			Type:    autoscalingv2.ScalingActive,
+			Status:  apiv1.ConditionFalse,
+			Reason:  "FailedGetResourceMetric",
+			Message: "unable to get metrics for resource cpu",
+		}},
+	})
+
+	err := c.checkScaling(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "FailedGetResourceMetric: unable to get metrics for resource cpu") {
+		t.Fatal("expected the HPA to fail with the reason it is not scaling but got", err)
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestHPAWorkload(t *testing.T) {
+	c := newTestChecker(nil, nil)
+	deployment := c.deployment("metrics-check-1")
+	hpa := c.hpa("metrics-check-1")
+	if *deployment.Spec.Replicas != hpaStartReplicas || hpa.Spec.MaxReplicas != hpaStartReplicas || *hpa.Spec.MinReplicas != 1 {
+		t.Fatal("expected the deployment to start at the most replicas the HPA allows")
+	}
+	if hpa.Spec.ScaleTargetRef.Name != deployment.Name || hpa.Spec.ScaleTargetRef.Kind != "Deployment" {
+		t.Fatal("expected the HPA to target the deployment but got", hpa.Spec.ScaleTargetRef)
+	}
+	if deployment.Spec.Template.Spec.Containers[0].Resources.Requests.Cpu().IsZero() {
+		t.Fatal("expected the deployment pods to request CPU so that their utilization can be computed")
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// checkLabel labels the deployments and HPAs created by the check, so that those left behind by earlier runs can
+	// be found and removed
+	checkLabel = "kuberhealthy-metrics-check"
+
+	// cleanupTimeout is how long removing the HPA workload can take after the run
+	cleanupTimeout = time.Second * 30
+
+	// hpaStartReplicas is the number of replicas the HPA workload starts with.  It is idle, so the HPA scales it down
+	// to one replica once it can read its CPU usage.
+	hpaStartReplicas = 2
+)
+
+// checkScaling creates an idle deployment with more replicas than it needs and an HPA for it, and ensures the HPA
+// scales it down within the scale timeout.  The HPA can only decide to scale down when it gets the CPU usage of the
+// pods from the resource metrics API.
+func (c *checker) checkScaling(ctx context.Context) error {
+	err := c.cleanUp(ctx)
+	if err != nil {
+		return fmt.Errorf("Failed to remove the HPA workloads of earlier runs: %w", err)
+	}
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		err := c.cleanUp(cleanupCtx)
+		if err != nil {
+			log.Errorln("Failed to remove the HPA workload of this run:", err)
+		}
+	}()
+
+	name := "metrics-check-" + strconv.FormatInt(time.Now().Unix(), 10)
+	log.Infoln("Creating deployment", c.namespace+"/"+name, "with", hpaStartReplicas, "replicas")
+	_, err = c.client.AppsV1().Deployments(c.namespace).Create(ctx, c.deployment(name), metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("Failed to create deployment %s: %w", name, err)
+	}
+	log.Infoln("Creating horizontal pod autoscaler", c.namespace+"/"+name)
+	_, err = c.client.AutoscalingV2().HorizontalPodAutoscalers(c.namespace).Create(ctx, c.hpa(name), metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("Failed to create horizontal pod autoscaler %s: %w", name, err)
+	}
+
+	var hpa *autoscalingv2.HorizontalPodAutoscaler
+	err = wait.PollUntilContextTimeout(ctx, c.pollInterval, c.scaleTimeout, true, func(ctx context.Context) (bool, error) {
+		hpa, err = c.client.AutoscalingV2().HorizontalPodAutoscalers(c.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			log.Warnln("Failed to get horizontal pod autoscaler", name+":", err)
+			return false, nil
+		}
+		log.Debugln("Horizontal pod autoscaler", name, "has", hpa.Status.CurrentReplicas, "replicas and wants", hpa.Status.DesiredReplicas)
+		return hpa.Status.LastScaleTime != nil && hpa.Status.DesiredReplicas == 1, nil
+	})
+	if err != nil {
+		reason := "it did not report why"
+		if hpa != nil {
+			for _, condition := range hpa.Status.Conditions {
+				if condition.Type == autoscalingv2.ScalingActive && condition.Status != apiv1.ConditionTrue {
+					reason = fmt.Sprintf("%s: %s", condition.Reason, condition.Message)
+				}
+			}
+		}
+		return fmt.Errorf("Horizontal pod autoscaler %s did not scale its idle deployment down within %s, so autoscaling may be broken: %s", name, c.scaleTimeout, reason)
+	}
+	log.Infoln("Horizontal pod autoscaler", name, "scaled its deployment down to", hpa.Status.DesiredReplicas, "replica")
+	return nil
+}
+
+// deployment returns the idle deployment the HPA scales.  Its pods request CPU, so that the HPA can compute their CPU
+// utilization.
+func (c *checker) deployment(name string) *appsv1.Deployment {
+	replicas := int32(hpaStartReplicas)
+	automount := false
+	labels := map[string]string{checkLabel: name}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: c.namespace, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: apiv1.PodSpec{
+					AutomountServiceAccountToken: &automount,
+					Containers: []apiv1.Container{{
+						Name:            "idle",
+						Image:           c.hpaImage,
+						ImagePullPolicy: apiv1.PullIfNotPresent,
+						Resources: apiv1.ResourceRequirements{
+							Requests: apiv1.ResourceList{
+								apiv1.ResourceCPU:    resource.MustParse("10m"),
+								apiv1.ResourceMemory: resource.MustParse("10Mi"),
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// hpa returns the HPA of the idle deployment.  Scaling down is not stabilized, so that the HPA scales down as soon as
+// it reads the CPU usage of the pods.
+func (c *checker) hpa(name string) *autoscalingv2.HorizontalPodAutoscaler {
+	minReplicas := int32(1)
+	utilization := int32(80)
+	stabilization := int32(0)
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: c.namespace, Labels: map[string]string{checkLabel: name}},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{APIVersion: "apps/v1", Kind: "Deployment", Name: name},
+			MinReplicas:    &minReplicas,
+			MaxReplicas:    hpaStartReplicas,
+			Metrics: []autoscalingv2.MetricSpec{{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{
+					Name:   apiv1.ResourceCPU,
+					Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType, AverageUtilization: &utilization},
+				},
+			}},
+			Behavior: &autoscalingv2.HorizontalPodAutoscalerBehavior{
+				ScaleDown: &autoscalingv2.HPAScalingRules{StabilizationWindowSeconds: &stabilization},
+			},
+		},
+	}
+}
+
+// cleanUp removes the HPAs and deployments created by the check
+func (c *checker) cleanUp(ctx context.Context) error {
+	hpas, err := c.client.AutoscalingV2().HorizontalPodAutoscalers(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: checkLabel})
+	if err != nil {
+		return err
+	}
+	for _, hpa := range hpas.Items {
+		log.Infoln("Removing horizontal pod autoscaler", hpa.Name)
+		err := c.client.AutoscalingV2().HorizontalPodAutoscalers(c.namespace).Delete(ctx, hpa.Name, metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	deployments, err := c.client.AppsV1().Deployments(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: checkLabel})
+	if err != nil {
+		return err
+	}
+	background := metav1.DeletePropagationBackground
+	for _, deployment := range deployments.Items {
+		log.Infoln("Removing deployment", deployment.Name)
+		err := c.client.AppsV1().Deployments(c.namespace).Delete(ctx, deployment.Name, metav1.DeleteOptions{PropagationPolicy: &background})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
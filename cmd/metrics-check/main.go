@@ -0,0 +1,140 @@
+// Package main implements a check that ensures the resource metrics API serves node and pod metrics, and optionally
+// that a horizontal pod autoscaler scales a workload with them.
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	checkclient "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/nodeCheck"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/util"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
+)
+
+const (
+	// defaultNamespace is the namespace the HPA workload is created in when the check's namespace can not be found
+	defaultNamespace = "kuberhealthy"
+
+	// defaultPodNamespace is the namespace whose pod metrics are expected
+	defaultPodNamespace = "kube-system"
+
+	// defaultMaxMetricsAge is how old metrics can be before they are stale
+	defaultMaxMetricsAge = time.Minute * 5
+
+	// defaultHPAImage is the image of the idle HPA workload
+	defaultHPAImage = "registry.k8s.io/pause:3.9"
+
+	// defaultScaleTimeout is how long the HPA can take to scale the workload
+	defaultScaleTimeout = time.Minute * 3
+)
+
+// kubeConfigFile is a variable containing file path of Kubernetes config files
+var kubeConfigFile = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+
+func init() {
+	// set debug mode for nodeCheck pkg
+	nodeCheck.EnableDebugOutput()
+}
+
+func main() {
+	checker, err := newCheckerFromEnv()
+	if err != nil {
+		log.Errorln("Error parsing the check configuration:", err)
+		reportToKuberhealthy([]string{err.Error()})
+		return
+	}
+
+	// leave time to clean up and report before the deadline of the run
+	ctx := context.Background()
+	deadline, err := checkclient.GetDeadline()
+	if err != nil {
+		log.Warnln("There was an issue getting the check deadline:", err.Error())
+	} else {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-time.Second*30))
+		defer cancel()
+	}
+
+	client, err := kubeClient.Create(kubeConfigFile)
+	if err != nil {
+		reportToKuberhealthy([]string{"Failed to create a kubernetes client: " + err.Error()})
+		return
+	}
+	checker.client = client
+	checker.getMetrics = func(ctx context.Context, path string) ([]byte, error) {
+		return client.Discovery().RESTClient().Get().AbsPath(path).DoRaw(ctx)
+	}
+
+	// hits kuberhealthy endpoint to see if node is ready
+	err = nodeCheck.WaitForKuberhealthy(ctx)
+	if err != nil {
+		log.Errorln("Error waiting for kuberhealthy endpoint to be contactable by checker pod with error:" + err.Error())
+	}
+
+	reportToKuberhealthy(checker.run(ctx))
+}
+
+// newCheckerFromEnv creates a checker from the environment variables of the checker pod
+func newCheckerFromEnv() (*checker, error) {
+	c := &checker{
+		namespace:     util.GetInstanceNamespace(defaultNamespace),
+		podNamespace:  defaultPodNamespace,
+		maxMetricsAge: defaultMaxMetricsAge,
+		hpaImage:      defaultHPAImage,
+		scaleTimeout:  defaultScaleTimeout,
+		pollInterval:  time.Second * 5,
+	}
+	if namespace := os.Getenv("CHECK_NAMESPACE"); len(namespace) > 0 {
+		c.namespace = namespace
+	}
+	if namespace := os.Getenv("POD_METRICS_NAMESPACE"); len(namespace) > 0 {
+		c.podNamespace = namespace
+	}
+	if image := os.Getenv("HPA_IMAGE"); len(image) > 0 {
+		c.hpaImage = image
+	}
+
+	var err error
+	if age := os.Getenv("MAX_METRICS_AGE"); len(age) > 0 {
+		c.maxMetricsAge, err = time.ParseDuration(age)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if checkHPA := os.Getenv("CHECK_HPA"); len(checkHPA) > 0 {
+		c.checkHPA, err = strconv.ParseBool(checkHPA)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if timeout := os.Getenv("SCALE_TIMEOUT"); len(timeout) > 0 {
+		c.scaleTimeout, err = time.ParseDuration(timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	log.Infoln("Checking node metrics and pod metrics of namespace", c.podNamespace, "with HPA scaling enabled:", c.checkHPA)
+	return c, nil
+}
+
+// reportToKuberhealthy reports the errors of the run to Kuberhealthy, or success when there are none
+func reportToKuberhealthy(errorMessages []string) {
+	var err error
+	if len(errorMessages) == 0 {
+		err = checkclient.ReportSuccess()
+	} else {
+		log.Errorln("Reporting errors to Kuberhealthy:", errorMessages)
+		err = checkclient.ReportFailure(errorMessages)
+	}
+	if err != nil {
+		log.Fatalln("error reporting to kuberhealthy:", err.Error())
+	}
+	log.Infoln("Successfully reported to Kuberhealthy")
+}
@@ -145,16 +145,11 @@ func (ncc *Checker) Run(ctx context.Context, cancel context.CancelFunc, client *
 // doChecks does validations on the network connection call to the endpoint
 func (ncc *Checker) doChecks() error {
 
+	// tcp4, tcp6, udp4 and udp6 limit the connection to one address family, while tcp and udp use whichever
+	// addresses the target resolves to
 	network, address := splitAddress(ncc.connectionTarget)
 
-	var localAddr net.Addr
-	if network == "udp" {
-		localAddr = &net.UDPAddr{IP: net.ParseIP(ncc.connectionTarget)}
-	} else {
-		localAddr = &net.TCPAddr{IP: net.ParseIP(ncc.connectionTarget)}
-	}
-
-	d := net.Dialer{LocalAddr: localAddr, Timeout: time.Duration(checkTimeout)}
+	d := net.Dialer{Timeout: time.Duration(checkTimeout)}
 	conn, err := d.Dial(network, address)
 	if err != nil {
 		errorMessage := "Network connection check determined that " + ncc.connectionTarget + " is DOWN: " + err.Error()
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// agentHTTPPort is the port agents serve probe requests and their health on
+	agentHTTPPort = 8080
+
+	// agentEchoPort is the UDP port agents echo probe packets back on
+	agentEchoPort = 8081
+
+	// probePacketSize is the size of a probe packet, which holds its sequence number
+	probePacketSize = 8
+)
+
+// probeResult is the round trip latency and packet loss measured from an agent to another agent
+type probeResult struct {
+	Target   string        `json:"target"`          // the pod IP of the agent that was probed
+	Sent     int           `json:"sent"`            // the number of packets sent
+	Received int           `json:"received"`        // the number of packets echoed back in time
+	Average  time.Duration `json:"average"`         // the average round trip time of the packets that were echoed back
+	Max      time.Duration `json:"max"`             // the highest round trip time of the packets that were echoed back
+	Error    string        `json:"error,omitempty"` // why the target could not be probed at all
+}
+
+// packetLoss returns the percentage of packets that were not echoed back in time
+func (r probeResult) packetLoss() float64 {
+	if r.Sent == 0 {
+		return 100
+	}
+	return float64(r.Sent-r.Received) / float64(r.Sent) * 100
+}
+
+// runAgent echoes probe packets and probes other agents when the checker asks it to, until it is stopped
+func runAgent() error {
+	conn, err := net.ListenPacket("udp", fmt.Sprintf(":%d", agentEchoPort))
+	if err != nil {
+		return fmt.Errorf("failed to listen for probe packets: %w", err)
+	}
+	defer conn.Close()
+	go serveEcho(conn)
+
+	hostname, _ := os.Hostname()
+	log.Infoln("Agent", hostname, "echoing probe packets on port", agentEchoPort, "and serving probe requests on port", agentHTTPPort)
+	return http.ListenAndServe(fmt.Sprintf(":%d", agentHTTPPort), newAgentHandler())
+}
+
+// serveEcho sends every packet it receives back to its sender until the connection is closed
+func serveEcho(conn net.PacketConn) {
+	buf := make([]byte, 64)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			log.Warnln("Failed to read probe packet:", err)
+			continue
+		}
+		_, err = conn.WriteTo(buf[:n], addr)
+		if err != nil {
+			log.Warnln("Failed to echo probe packet to", addr.String()+":", err)
+		}
+	}
+}
+
+// newAgentHandler returns the handler of the agent web server.  /healthz is used as the readiness probe of the agent
+// pods and /probe?targets=<ip>,<ip>&count=<n>&interval=<duration>&timeout=<duration> probes the listed agents and
+// responds with a probeResult for each of them.
+func newAgentHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/probe", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		count, err := strconv.Atoi(q.Get("count"))
+		if err != nil || count <= 0 {
+			http.Error(w, "count must be a positive number", http.StatusBadRequest)
+			return
+		}
+		interval, err := time.ParseDuration(q.Get("interval"))
+		if err != nil {
+			http.Error(w, "invalid interval: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		timeout, err := time.ParseDuration(q.Get("timeout"))
+		if err != nil {
+			http.Error(w, "invalid timeout: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		targets := splitList(q.Get("targets"))
+
+		results := make([]probeResult, len(targets))
+		var wg sync.WaitGroup
+		for i, target := range targets {
+			wg.Add(1)
+			go func(i int, target string) {
+				defer wg.Done()
+				results[i] = probeTarget(r.Context(), net.JoinHostPort(target, strconv.Itoa(agentEchoPort)), count, interval, timeout)
+				results[i].Target = target
+			}(i, target)
+		}
+		wg.Wait()
+
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(results)
+		if err != nil {
+			log.Errorln("Failed to write probe results:", err)
+		}
+	})
+	return mux
+}
+
+// probeTarget sends count packets to the UDP echo server at address, one every interval, and measures how long each
+// takes to be echoed back.  Packets that are not echoed back within timeout are lost.
+func probeTarget(ctx context.Context, address string, count int, interval time.Duration, timeout time.Duration) probeResult {
+	result := probeResult{Target: address}
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+
+	var total time.Duration
+	sent := make([]byte, probePacketSize)
+	received := make([]byte, 64)
+	for seq := 0; seq < count; seq++ {
+		if seq > 0 {
+			select {
+			case <-ctx.Done():
+				result.Error = ctx.Err().Error()
+				return result
+			case <-time.After(interval):
+			}
+		}
+
+		binary.BigEndian.PutUint64(sent, uint64(seq))
+		start := time.Now()
+		_, err := conn.Write(sent)
+		result.Sent++
+		if err != nil {
+			log.Debugln("Failed to send probe packet to", address+":", err)
+			continue
+		}
+
+		// read until the packet of this sequence number arrives, skipping late replies to earlier packets
+		_ = conn.SetReadDeadline(start.Add(timeout))
+		for {
+			n, err := conn.Read(received)
+			if err != nil {
+				break
+			}
+			if n != probePacketSize || binary.BigEndian.Uint64(received[:n]) != uint64(seq) {
+				continue
+			}
+			rtt := time.Since(start)
+			result.Received++
+			total += rtt
+			if rtt > result.Max {
+				result.Max = rtt
+			}
+			break
+		}
+	}
+	if result.Received > 0 {
+		result.Average = total / time.Duration(result.Received)
+	}
+	return result
+}
+
+// splitList splits a comma separated list and drops blank entries
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
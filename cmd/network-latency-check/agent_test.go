@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProbeTarget(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("failed to listen:", err)
+	}
+	go serveEcho(conn)
+
+	result := probeTarget(context.Background(), conn.LocalAddr().String(), 5, time.Millisecond, time.Second)
+	if len(result.Error) > 0 || result.Sent != 5 || result.Received != 5 {
+		t.Fatal("expected every packet to be echoed back but got", result)
+	}
+	if result.Average <= 0 || result.Max < result.Average || result.packetLoss() != 0 {
+		t.Fatal("expected round trip times without packet loss but got", result)
+	}
+
+	// packets to a closed echo server are lost
+	conn.Close()
+	result = probeTarget(context.Background(), conn.LocalAddr().String(), 2, time.Millisecond, time.Millisecond*50)
+	if result.Received != 0 || result.packetLoss() != 100 {
+		t.Fatal("expected every packet to be lost but got", result)
+	}
+}
+
+func TestAgentHandler(t *testing.T) {
+	server := httptest.NewServer(newAgentHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/healthz")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatal("expected the agent to be healthy but got", resp, err)
+	}
+
+	resp, err = http.Get(server.URL + "/probe?targets=&count=0&interval=1ms&timeout=1s")
+	if err != nil || resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("expected an invalid count to be refused but got", resp, err)
+	}
+
+	// the probe port of the target is not listening, so its packets are lost
+	resp, err = http.Get(server.URL + "/probe?targets=127.0.0.1&count=2&interval=1ms&timeout=20ms")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatal("expected the probe to succeed but got", resp, err)
+	}
+	defer resp.Body.Close()
+	var results []probeResult
+	err = json.NewDecoder(resp.Body).Decode(&results)
+	if err != nil {
+		t.Fatal("failed to decode probe results:", err)
+	}
+	if len(results) != 1 || results[0].Target != "127.0.0.1" || results[0].Sent != 2 {
+		t.Fatal("expected a result for the target but got", results)
+	}
+}
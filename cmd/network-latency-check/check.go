@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// checkLabel labels the agent pods created by the check, so that those left behind by earlier runs can be found
+	// and removed
+	checkLabel = "kuberhealthy-network-latency-check"
+
+	// cleanupTimeout is how long removing the agent pods can take after the run
+	cleanupTimeout = time.Second * 30
+)
+
+// checker measures the round trip latency and packet loss between agent pods on a sample of nodes
+type checker struct {
+	namespace       string
+	agentImage      string         // the image of the agent pods, which is the image of the check
+	nodeSelector    string         // a label selector that limits the nodes agents are started on
+	sampleSize      int            // the number of nodes agents are started on
+	probeCount      int            // the number of packets each agent sends to each other agent
+	probeInterval   time.Duration  // the delay between packets
+	probeTimeout    time.Duration  // how long a packet can take to be echoed back before it is lost
+	maxLatency      time.Duration  // the average round trip time between two agents above which the check fails
+	maxPacketLoss   float64        // the percentage of lost packets between two agents above which the check fails
+	startTimeout    time.Duration  // how long the agent pods can take to become ready
+	ipFamily        apiv1.IPFamily // the address family agents probe each other over. the primary pod IP when empty
+	pollInterval    time.Duration
+	ownerReferences []metav1.OwnerReference
+	client          kubernetes.Interface
+	probe           func(ctx context.Context, agentIP string, targets []string) ([]probeResult, error)
+}
+
+// agent is an agent pod started on one of the sampled nodes
+type agent struct {
+	node string
+	pod  string
+	ip   string
+	err  string // set when the agent became ready without an IP of the address family
+}
+
+// run starts an agent on a sample of nodes, has every agent probe every other agent and removes the agents again.  The
+// errors of the run are returned.
+func (c *checker) run(ctx context.Context) []string {
+	err := c.cleanUp(ctx)
+	if err != nil {
+		return []string{"Failed to remove the agent pods of earlier runs: " + err.Error()}
+	}
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		err := c.cleanUp(cleanupCtx)
+		if err != nil {
+			log.Errorln("Failed to remove the agent pods of this run:", err)
+		}
+	}()
+
+	nodes, err := c.sampleNodes(ctx)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	agents, errs := c.startAgents(ctx, nodes)
+	if len(errs) > 0 {
+		return errs
+	}
+
+	for _, a := range agents {
+		var targets []string
+		for _, other := range agents {
+			if other != a {
+				targets = append(targets, other.ip)
+			}
+		}
+		results, err := c.probe(ctx, a.ip, targets)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Failed to probe from the agent on node %s: %s", a.node, err))
+			continue
+		}
+		errs = append(errs, c.evaluate(a, agents, results)...)
+	}
+	return errs
+}
+
+// evaluate returns an error for every agent that could not be reached from agent a within the latency and packet
+// loss thresholds
+func (c *checker) evaluate(a *agent, agents []*agent, results []probeResult) []string {
+	nodes := make(map[string]string, len(agents))
+	for _, other := range agents {
+		nodes[other.ip] = other.node
+	}
+
+	var errs []string
+	for _, r := range results {
+		to := nodes[r.Target]
+		if len(to) == 0 {
+			to = r.Target
+		}
+		loss := r.packetLoss()
+		log.Infof("Latency from node %s to node %s: average %s, max %s, packet loss %.1f%%", a.node, to, r.Average, r.Max, loss)
+		switch {
+		case len(r.Error) > 0:
+			errs = append(errs, fmt.Sprintf("Failed to probe node %s from node %s: %s", to, a.node, r.Error))
+		case loss > c.maxPacketLoss:
+			errs = append(errs, fmt.Sprintf("Packet loss from node %s to node %s is %.1f%%, above the threshold of %.1f%%", a.node, to, loss, c.maxPacketLoss))
+		case r.Average > c.maxLatency:
+			errs = append(errs, fmt.Sprintf("Average round trip time from node %s to node %s is %s, above the threshold of %s", a.node, to, r.Average, c.maxLatency))
+		}
+	}
+	return errs
+}
+
+// sampleNodes returns the names of up to sampleSize random nodes that are ready, schedulable and match the node
+// selector.  At least two nodes are needed to measure latency between them.
+func (c *checker) sampleNodes(ctx context.Context) ([]string, error) {
+	nodeList, err := c.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: c.nodeSelector})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list nodes: %w", err)
+	}
+
+	var nodes []string
+	for _, node := range nodeList.Items {
+		if node.Spec.Unschedulable || !nodeReady(&node) {
+			continue
+		}
+		nodes = append(nodes, node.Name)
+	}
+	if len(nodes) < 2 {
+		return nil, fmt.Errorf("Found %d ready and schedulable nodes, but at least two are needed to measure the latency between them", len(nodes))
+	}
+
+	rand.Shuffle(len(nodes), func(i, j int) { nodes[i], nodes[j] = nodes[j], nodes[i] })
+	if len(nodes) > c.sampleSize {
+		nodes = nodes[:c.sampleSize]
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+// nodeReady determines if the Ready condition of a node is true
+func nodeReady(node *apiv1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == apiv1.NodeReady {
+			return condition.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// startAgents creates an agent pod on each node and waits for all of them to become ready
+func (c *checker) startAgents(ctx context.Context, nodes []string) ([]*agent, []string) {
+	run := strconv.FormatInt(time.Now().Unix(), 10)
+	var agents []*agent
+	for i, node := range nodes {
+		name := fmt.Sprintf("network-latency-check-%s-%d", run, i)
+		log.Infoln("Creating agent pod", c.namespace+"/"+name, "on node", node)
+		_, err := c.client.CoreV1().Pods(c.namespace).Create(ctx, c.agentPod(name, node), metav1.CreateOptions{})
+		if err != nil {
+			return nil, []string{fmt.Sprintf("Failed to create agent pod %s on node %s: %s", name, node, err)}
+		}
+		agents = append(agents, &agent{node: node, pod: name})
+	}
+
+	_ = wait.PollUntilContextTimeout(ctx, c.pollInterval, c.startTimeout, true, func(ctx context.Context) (bool, error) {
+		done := true
+		for _, a := range agents {
+			if len(a.ip) > 0 || len(a.err) > 0 {
+				continue
+			}
+			pod, err := c.client.CoreV1().Pods(c.namespace).Get(ctx, a.pod, metav1.GetOptions{})
+			if err != nil {
+				log.Warnln("Failed to get agent pod", a.pod+":", err)
+				done = false
+				continue
+			}
+			if !podReady(pod) || len(pod.Status.PodIP) == 0 {
+				done = false
+				continue
+			}
+			a.ip = podIP(pod, c.ipFamily)
+			if len(a.ip) == 0 {
+				a.err = fmt.Sprintf("Agent pod %s on node %s has no %s address, only %v", a.pod, a.node, c.ipFamily, pod.Status.PodIPs)
+				continue
+			}
+			log.Infoln("Agent pod", a.pod, "on node", a.node, "is ready with IP", a.ip)
+		}
+		return done, nil
+	})
+
+	var errs []string
+	for _, a := range agents {
+		if len(a.err) > 0 {
+			errs = append(errs, a.err)
+			continue
+		}
+		if len(a.ip) == 0 {
+			errs = append(errs, fmt.Sprintf("Agent pod %s on node %s did not become ready within %s", a.pod, a.node, c.startTimeout))
+		}
+	}
+	return agents, errs
+}
+
+// podIP returns the IP of a pod of the address family, or its primary IP when no family is supplied.  It is blank
+// when the pod has no IP of the family.
+func podIP(pod *apiv1.Pod, family apiv1.IPFamily) string {
+	if len(family) == 0 {
+		return pod.Status.PodIP
+	}
+	for _, podIP := range pod.Status.PodIPs {
+		ip := net.ParseIP(podIP.IP)
+		if ip == nil {
+			continue
+		}
+		if (ip.To4() != nil) == (family == apiv1.IPv4Protocol) {
+			return podIP.IP
+		}
+	}
+	return ""
+}
+
+// podReady determines if the Ready condition of a pod is true
+func podReady(pod *apiv1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == apiv1.PodReady {
+			return condition.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// agentPod returns the agent pod for a node.  The pod is bound to the node directly and tolerates every taint, so
+// that nodes reserved for some workloads are measured too.
+func (c *checker) agentPod(name string, node string) *apiv1.Pod {
+	automount := false
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       c.namespace,
+			Labels:          map[string]string{checkLabel: name},
+			OwnerReferences: c.ownerReferences,
+		},
+		Spec: apiv1.PodSpec{
+			NodeName:                     node,
+			RestartPolicy:                apiv1.RestartPolicyNever,
+			AutomountServiceAccountToken: &automount,
+			Tolerations:                  []apiv1.Toleration{{Operator: apiv1.TolerationOpExists}},
+			Containers: []apiv1.Container{{
+				Name:            "agent",
+				Image:           c.agentImage,
+				ImagePullPolicy: apiv1.PullIfNotPresent,
+				Env:             []apiv1.EnvVar{{Name: "AGENT_MODE", Value: "true"}},
+				Ports: []apiv1.ContainerPort{
+					{Name: "http", ContainerPort: agentHTTPPort, Protocol: apiv1.ProtocolTCP},
+					{Name: "echo", ContainerPort: agentEchoPort, Protocol: apiv1.ProtocolUDP},
+				},
+				ReadinessProbe: &apiv1.Probe{
+					ProbeHandler: apiv1.ProbeHandler{
+						HTTPGet: &apiv1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(agentHTTPPort)},
+					},
+					PeriodSeconds: 1,
+				},
+				Resources: apiv1.ResourceRequirements{
+					Requests: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("10m"),
+						apiv1.ResourceMemory: resource.MustParse("20Mi"),
+					},
+				},
+			}},
+		},
+	}
+}
+
+// probeAgent asks the agent at agentIP to probe the supplied targets and returns its results
+func (c *checker) probeAgent(ctx context.Context, agentIP string, targets []string) ([]probeResult, error) {
+	q := url.Values{}
+	q.Set("targets", strings.Join(targets, ","))
+	q.Set("count", strconv.Itoa(c.probeCount))
+	q.Set("interval", c.probeInterval.String())
+	q.Set("timeout", c.probeTimeout.String())
+	u := url.URL{
+		Scheme:   "http",
+		Host:     net.JoinHostPort(agentIP, strconv.Itoa(agentHTTPPort)),
+		Path:     "/probe",
+		RawQuery: q.Encode(),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("agent responded with status %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var results []probeResult
+	err = json.NewDecoder(resp.Body).Decode(&results)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the probe results: %w", err)
+	}
+	return results, nil
+}
+
+// cleanUp removes the agent pods created by the check
+func (c *checker) cleanUp(ctx context.Context) error {
+	pods, err := c.client.CoreV1().Pods(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: checkLabel})
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		log.Infoln("Removing agent pod", pod.Name)
+		err := c.client.CoreV1().Pods(c.namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
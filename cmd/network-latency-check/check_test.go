@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testNode returns a node with the supplied readiness
+func testNode(name string, ready bool, unschedulable bool) *apiv1.Node {
+	status := apiv1.ConditionTrue
+	if !ready {
+		status = apiv1.ConditionFalse
+	}
+	return &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       apiv1.NodeSpec{Unschedulable: unschedulable},
+		Status:     apiv1.NodeStatus{Conditions: []apiv1.NodeCondition{{Type: apiv1.NodeReady, Status: status}}},
+	}
+}
+
+// newTestChecker returns a checker with short timeouts, a fake client and the supplied probe
+func newTestChecker(client *fake.Clientset, probe func(ctx context.Context, agentIP string, targets []string) ([]probeResult, error)) *checker {
+	return &checker{
+		namespace:     "kuberhealthy",
+		agentImage:    "kuberhealthy/network-latency-check:test",
+		sampleSize:    3,
+		maxLatency:    time.Millisecond * 50,
+		maxPacketLoss: 10,
+		startTimeout:  time.Millisecond * 300,
+		pollInterval:  time.Millisecond * 10,
+		client:        client,
+		probe:         probe,
+	}
+}
+
+// simulateAgents makes every agent pod ready with a pod IP once it is created, like a kubelet would.  The IP of each
+// pod is 10.0.0.<n> where n is the position of its node in nodes.
+func simulateAgents(t *testing.T, client *fake.Clientset, nodes []string) {
+	go func() {
+		ctx := context.Background()
+		for i := 0; i < 100; i++ {
+			time.Sleep(time.Millisecond * 5)
+			pods, err := client.CoreV1().Pods("kuberhealthy").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				continue
+			}
+			for _, pod := range pods.Items {
+				if len(pod.Status.PodIP) > 0 || len(pod.Spec.NodeName) == 0 {
+					continue
+				}
+				for n, node := range nodes {
+					if pod.Spec.NodeName == node {
+						pod.Status.PodIP = fmt.Sprintf("10.0.0.%d", n)
+					}
+				}
+				pod.Status.Conditions = []apiv1.PodCondition{{Type: apiv1.PodReady, Status: apiv1.ConditionTrue}}
+				_, err := client.CoreV1().Pods("kuberhealthy").UpdateStatus(ctx, &pod, metav1.UpdateOptions{})
+				if err != nil {
+					t.Log("failed to update pod status:", err)
+				}
+			}
+		}
+	}()
+}
+
+// assertCleanedUp ensures the agent pods were removed
+func assertCleanedUp(t *testing.T, client *fake.Clientset) {
+	pods, _ := client.CoreV1().Pods("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if len(pods.Items) != 0 {
+		t.Fatal("expected the agent pods to be removed but found", len(pods.Items))
+	}
+}
+
+func TestCheckerRun(t *testing.T) {
+	nodes := []string{"node-a", "node-b", "node-c"}
+	client := fake.NewSimpleClientset(
+		testNode("node-a", true, false),
+		testNode("node-b", true, false),
+		testNode("node-c", true, false),
+		testNode("node-not-ready", false, false),
+		testNode("node-cordoned", true, true),
+		// an agent pod left behind by an earlier run is removed
+		&apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "network-latency-check-1-0", Namespace: "kuberhealthy", Labels: map[string]string{checkLabel: "network-latency-check-1-0"}}},
+	)
+
+	probed := make(map[string][]string)
+	c := newTestChecker(client, func(ctx context.Context, agentIP string, targets []string) ([]probeResult, error) {
+		probed[agentIP] = targets
+		var results []probeResult
+		for _, target := range targets {
+			results = append(results, probeResult{Target: target, Sent: 10, Received: 10, Average: time.Millisecond, Max: time.Millisecond * 2})
+		}
+		return results, nil
+	})
+	simulateAgents(t, client, nodes)
+
+	errs := c.run(context.Background())
+	if len(errs) != 0 {
+		t.Fatal("expected the check to pass but got", errs)
+	}
+	if len(probed) != 3 {
+		t.Fatal("expected each of the three ready nodes to probe but got", probed)
+	}
+	for ip, targets := range probed {
+		if len(targets) != 2 {
+			t.Fatal("expected the agent at", ip, "to probe the two other agents but got", targets)
+		}
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestCheckerRunThresholds(t *testing.T) {
+	nodes := []string{"node-a", "node-b"}
+	client := fake.NewSimpleClientset(testNode("node-a", true, false), testNode("node-b", true, false))
+	c := newTestChecker(client, func(ctx context.Context, agentIP string, targets []string) ([]probeResult, error) {
+		if agentIP == "10.0.0.0" {
+			// node-a reaches node-b slowly
+			return []probeResult{{Target: "10.0.0.1", Sent: 10, Received: 10, Average: time.Millisecond * 80}}, nil
+		}
+		// node-b loses packets to node-a
+		return []probeResult{{Target: "10.0.0.0", Sent: 10, Received: 7, Average: time.Millisecond}}, nil
+	})
+	simulateAgents(t, client, nodes)
+
+	errs := c.run(context.Background())
+	if len(errs) != 2 {
+		t.Fatal("expected an error for each direction but got", errs)
+	}
+	joined := strings.Join(errs, "\n")
+	if !strings.Contains(joined, "from node node-a to node node-b is 80ms") || !strings.Contains(joined, "Packet loss from node node-b to node node-a is 30.0%") {
+		t.Fatal("expected the latency and packet loss errors but got", errs)
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestCheckerRunProbeFailure(t *testing.T) {
+	nodes := []string{"node-a", "node-b"}
+	client := fake.NewSimpleClientset(testNode("node-a", true, false), testNode("node-b", true, false))
+	c := newTestChecker(client, func(ctx context.Context, agentIP string, targets []string) ([]probeResult, error) {
+		if agentIP == "10.0.0.0" {
+			return nil, errors.New("connection refused")
+		}
+		return []probeResult{{Target: "10.0.0.0", Error: "network is unreachable"}}, nil
+	})
+	simulateAgents(t, client, nodes)
+
+	errs := c.run(context.Background())
+	if len(errs) != 2 || !strings.Contains(errs[0], "connection refused") || !strings.Contains(errs[1], "network is unreachable") {
+		t.Fatal("expected the probe errors but got", errs)
+	}
+}
+
+func TestCheckerRunAgentsNotReady(t *testing.T) {
+	client := fake.NewSimpleClientset(testNode("node-a", true, false), testNode("node-b", true, false))
+	c := newTestChecker(client, nil)
+
+	errs := c.run(context.Background())
+	if len(errs) != 2 || !strings.Contains(errs[0], "did not become ready") {
+		t.Fatal("expected an error for each agent that did not become ready but got", errs)
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestCheckerRunIPFamily(t *testing.T) {
+	client := fake.NewSimpleClientset(testNode("node-a", true, false), testNode("node-b", true, false))
+	probed := make(map[string][]string)
+	c := newTestChecker(client, func(ctx context.Context, agentIP string, targets []string) ([]probeResult, error) {
+		probed[agentIP] = targets
+		return []probeResult{{Target: targets[0], Sent: 10, Received: 10, Average: time.Millisecond}}, nil
+	})
+	c.ipFamily = apiv1.IPv6Protocol
+
+	// node-a is dual-stack and node-b only has an IPv4 address
+	go func() {
+		ctx := context.Background()
+		for i := 0; i < 100; i++ {
+			time.Sleep(time.Millisecond * 5)
+			pods, _ := client.CoreV1().Pods("kuberhealthy").List(ctx, metav1.ListOptions{})
+			for _, pod := range pods.Items {
+				if len(pod.Status.PodIP) > 0 {
+					continue
+				}
+				pod.Status.PodIPs = []apiv1.PodIP{{IP: "10.0.0.1"}}
+				if pod.Spec.NodeName == "node-a" {
+					pod.Status.PodIPs = append(pod.Status.PodIPs, apiv1.PodIP{IP: "fd00::1"})
+				}
+				pod.Status.PodIP = pod.Status.PodIPs[0].IP
+				pod.Status.Conditions = []apiv1.PodCondition{{Type: apiv1.PodReady, Status: apiv1.ConditionTrue}}
+				_, _ = client.CoreV1().Pods("kuberhealthy").UpdateStatus(ctx, &pod, metav1.UpdateOptions{})
+			}
+		}
+	}()
+
+	errs := c.run(context.Background())
+	if len(errs) != 1 || !strings.Contains(errs[0], "on node node-b has no IPv6 address") {
+		t.Fatal("expected the agent without an IPv6 address to fail but got", errs)
+	}
+	if len(probed) != 0 {
+		t.Fatal("expected no probes when an agent has no address of the family but got", probed)
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestPodIP(t *testing.T) {
+	pod := &apiv1.Pod{Status: apiv1.PodStatus{
+		PodIP:  "10.0.0.1",
+		PodIPs: []apiv1.PodIP{{IP: "10.0.0.1"}, {IP: "fd00::1"}},
+	}}
+	if podIP(pod, "") != "10.0.0.1" || podIP(pod, apiv1.IPv4Protocol) != "10.0.0.1" || podIP(pod, apiv1.IPv6Protocol) != "fd00::1" {
+		t.Fatal("expected the primary IP without a family and the IP of each family with one")
+	}
+	pod.Status.PodIPs = pod.Status.PodIPs[:1]
+	if podIP(pod, apiv1.IPv6Protocol) != "" {
+		t.Fatal("expected no IPv6 address of a single stack IPv4 pod")
+	}
+}
+
+func TestSampleNodes(t *testing.T) {
+	var objects []runtime.Object
+	for i := 0; i < 10; i++ {
+		objects = append(objects, testNode(fmt.Sprintf("node-%d", i), true, false))
+	}
+	c := newTestChecker(fake.NewSimpleClientset(objects...), nil)
+
+	nodes, err := c.sampleNodes(context.Background())
+	if err != nil {
+		t.Fatal("failed to sample nodes:", err)
+	}
+	if len(nodes) != 3 {
+		t.Fatal("expected a sample of three nodes but got", nodes)
+	}
+
+	c = newTestChecker(fake.NewSimpleClientset(testNode("node-a", true, false), testNode("node-b", false, false)), nil)
+	_, err = c.sampleNodes(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "at least two") {
+		t.Fatal("expected an error when fewer than two nodes are ready but got", err)
+	}
+}
+
+func TestAgentPod(t *testing.T) {
+	c := newTestChecker(nil, nil)
+	pod := c.agentPod("network-latency-check-1-0", "node-a")
+	if pod.Spec.NodeName != "node-a" || pod.Labels[checkLabel] != "network-latency-check-1-0" {
+		t.Fatal("expected the agent pod to be bound to its node and labeled but got", pod.Spec.NodeName, pod.Labels)
+	}
+	container := pod.Spec.Containers[0]
+	if container.Image != c.agentImage || len(container.Env) != 1 || container.Env[0].Name != "AGENT_MODE" {
+		t.Fatal("expected the agent pod to run the check image in agent mode but got", container.Image, container.Env)
+	}
+	if container.ReadinessProbe == nil || container.ReadinessProbe.HTTPGet.Path != "/healthz" {
+		t.Fatal("expected the agent pod to have a readiness probe")
+	}
+}
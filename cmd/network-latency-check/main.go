@@ -0,0 +1,181 @@
+// Package main implements a check that measures the pod to pod round trip latency and packet loss between agent pods
+// on a sample of nodes.  The same binary runs as the agent when AGENT_MODE is set.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+
+	checkclient "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/nodeCheck"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/util"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
+)
+
+const (
+	// defaultAgentImage is the image of the agent pods
+	defaultAgentImage = "kuberhealthy/network-latency-check:v1.0.0"
+
+	// defaultNamespace is the namespace the agent pods are created in when the check's namespace can not be found
+	defaultNamespace = "kuberhealthy"
+
+	// defaultSampleSize is the number of nodes agents are started on
+	defaultSampleSize = 3
+
+	// defaultProbeCount is the number of packets each agent sends to each other agent
+	defaultProbeCount = 20
+
+	// defaultMaxLatency is the average round trip time between two agents above which the check fails
+	defaultMaxLatency = time.Millisecond * 50
+
+	// defaultMaxPacketLoss is the percentage of lost packets between two agents above which the check fails
+	defaultMaxPacketLoss = 10
+
+	// defaultStartTimeout is how long the agent pods can take to become ready
+	defaultStartTimeout = time.Minute * 2
+)
+
+// kubeConfigFile is a variable containing file path of Kubernetes config files
+var kubeConfigFile = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+
+func init() {
+	// set debug mode for nodeCheck pkg
+	nodeCheck.EnableDebugOutput()
+}
+
+func main() {
+	// agent pods run the same image as the check
+	if agentMode, _ := strconv.ParseBool(os.Getenv("AGENT_MODE")); agentMode {
+		log.Fatalln(runAgent())
+	}
+
+	checker, err := newCheckerFromEnv()
+	if err != nil {
+		log.Errorln("Error parsing the check configuration:", err)
+		reportToKuberhealthy([]string{err.Error()})
+		return
+	}
+
+	// leave time to clean up and report before the deadline of the run
+	ctx := context.Background()
+	deadline, err := checkclient.GetDeadline()
+	if err != nil {
+		log.Warnln("There was an issue getting the check deadline:", err.Error())
+	} else {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-time.Second*30))
+		defer cancel()
+	}
+
+	client, err := kubeClient.Create(kubeConfigFile)
+	if err != nil {
+		reportToKuberhealthy([]string{"Failed to create a kubernetes client: " + err.Error()})
+		return
+	}
+	checker.client = client
+
+	// the agent pods are owned by the checker pod, so that they are garbage collected if cleanup is interrupted
+	checker.ownerReferences, err = util.GetOwnerRef(client, checker.namespace)
+	if err != nil {
+		log.Warnln("Failed to get the owner reference of the checker pod:", err)
+	}
+
+	// hits kuberhealthy endpoint to see if node is ready
+	err = nodeCheck.WaitForKuberhealthy(ctx)
+	if err != nil {
+		log.Errorln("Error waiting for kuberhealthy endpoint to be contactable by checker pod with error:" + err.Error())
+	}
+
+	reportToKuberhealthy(checker.run(ctx))
+}
+
+// newCheckerFromEnv creates a checker from the environment variables of the checker pod
+func newCheckerFromEnv() (*checker, error) {
+	c := &checker{
+		namespace:     util.GetInstanceNamespace(defaultNamespace),
+		agentImage:    defaultAgentImage,
+		nodeSelector:  os.Getenv("NODE_SELECTOR"),
+		sampleSize:    defaultSampleSize,
+		probeCount:    defaultProbeCount,
+		probeInterval: time.Millisecond * 100,
+		probeTimeout:  time.Second,
+		maxLatency:    defaultMaxLatency,
+		maxPacketLoss: defaultMaxPacketLoss,
+		startTimeout:  defaultStartTimeout,
+		pollInterval:  time.Second * 2,
+	}
+	c.probe = c.probeAgent
+	if image := os.Getenv("AGENT_IMAGE"); len(image) > 0 {
+		c.agentImage = image
+	}
+	if namespace := os.Getenv("CHECK_NAMESPACE"); len(namespace) > 0 {
+		c.namespace = namespace
+	}
+
+	var err error
+	if v := os.Getenv("NODE_SAMPLE_SIZE"); len(v) > 0 {
+		c.sampleSize, err = strconv.Atoi(v)
+		if err != nil || c.sampleSize < 2 {
+			return nil, fmt.Errorf("NODE_SAMPLE_SIZE must be a number of at least 2, got %q", v)
+		}
+	}
+	if v := os.Getenv("PROBE_COUNT"); len(v) > 0 {
+		c.probeCount, err = strconv.Atoi(v)
+		if err != nil || c.probeCount < 1 {
+			return nil, fmt.Errorf("PROBE_COUNT must be a positive number, got %q", v)
+		}
+	}
+	if v := os.Getenv("MAX_LATENCY"); len(v) > 0 {
+		c.maxLatency, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MAX_LATENCY: %w", err)
+		}
+	}
+	if v := os.Getenv("MAX_PACKET_LOSS"); len(v) > 0 {
+		c.maxPacketLoss, err = strconv.ParseFloat(v, 64)
+		if err != nil || c.maxPacketLoss < 0 || c.maxPacketLoss > 100 {
+			return nil, fmt.Errorf("MAX_PACKET_LOSS must be a percentage from 0 to 100, got %q", v)
+		}
+	}
+	switch v := os.Getenv("IP_FAMILY"); strings.ToLower(v) {
+	case "":
+	case "ipv4":
+		c.ipFamily = apiv1.IPv4Protocol
+	case "ipv6":
+		c.ipFamily = apiv1.IPv6Protocol
+	default:
+		return nil, fmt.Errorf("IP_FAMILY must be IPv4 or IPv6, got %q", v)
+	}
+	if v := os.Getenv("AGENT_START_TIMEOUT"); len(v) > 0 {
+		c.startTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AGENT_START_TIMEOUT: %w", err)
+		}
+	}
+
+	log.Infoln("Measuring latency between", c.sampleSize, "nodes in namespace", c.namespace, "with a threshold of", c.maxLatency, "and", c.maxPacketLoss, "percent packet loss")
+	return c, nil
+}
+
+// reportToKuberhealthy reports the errors of the run to Kuberhealthy, or success when there are none
+func reportToKuberhealthy(errorMessages []string) {
+	var err error
+	if len(errorMessages) == 0 {
+		err = checkclient.ReportSuccess()
+	} else {
+		log.Errorln("Reporting errors to Kuberhealthy:", errorMessages)
+		err = checkclient.ReportFailure(errorMessages)
+	}
+	if err != nil {
+		log.Fatalln("error reporting to kuberhealthy:", err.Error())
+	}
+	log.Infoln("Successfully reported to Kuberhealthy")
+}
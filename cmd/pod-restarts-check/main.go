@@ -8,25 +8,20 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
-	"strings"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
 
 	log "github.com/sirupsen/logrus"
-	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 
-	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 
 	checkclient "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/podrestarts"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
 )
 
-const defaultMaxFailuresAllowed = 10
-const defaultCheckTimeout = 10 * time.Minute
-
 // KubeConfigFile is a variable containing file path of Kubernetes config files
 var KubeConfigFile = filepath.Join(os.Getenv("HOME"), ".kube", "config")
 
@@ -39,13 +34,25 @@ var CheckTimeout time.Duration
 // MaxFailuresAllowed is a variable for how many times the pod should retry before stopping.
 var MaxFailuresAllowed int32
 
-// Checker represents a long running pod restart checker.
-type Checker struct {
-	Namespace          string
-	MaxFailuresAllowed int32
-	BadPods            map[string]string
-	client             *kubernetes.Clientset
-}
+// RestartWindow is how recently a pod must have had `BackOff` events for them to count against MaxFailuresAllowed.
+// All events kept by the API server count when zero.
+var RestartWindow time.Duration
+
+// NamespaceThresholds overrides MaxFailuresAllowed and RestartWindow for specific namespaces
+var NamespaceThresholds map[string]podrestarts.RestartThreshold
+
+// ExcludedNamespaces are namespaces with pods that restart by design, which are not checked
+var ExcludedNamespaces map[string]bool
+
+// ExcludedPodSelector selects pods that restart by design, such as batch workloads, which are not checked
+var ExcludedPodSelector = labels.Nothing()
+
+// RestartSamplesNamespace is the namespace of the config map the `BackOff` event counts seen by each run are kept in,
+// from the injected env variable KH_POD_NAMESPACE.  Without it, restarts within a restart window are undercounted.
+var RestartSamplesNamespace string
+
+// RestartSamplesConfigMap is the name of the config map the `BackOff` event counts seen by each run are kept in
+var RestartSamplesConfigMap = podrestarts.DefaultRestartSamplesConfigMap
 
 func init() {
 	// Grab and verify environment variables and set them as global vars
@@ -59,7 +66,7 @@ func init() {
 	}
 
 	// Set check time limit to default
-	CheckTimeout = defaultCheckTimeout
+	CheckTimeout = podrestarts.DefaultTimeout
 	// Get the deadline time in unix from the env var
 	timeDeadline, err := checkclient.GetDeadline()
 	if err != nil {
@@ -68,16 +75,56 @@ func init() {
 	CheckTimeout = timeDeadline.Sub(time.Now().Add(time.Second * 5))
 	log.Infoln("Check time limit set to:", CheckTimeout)
 
-	MaxFailuresAllowed = defaultMaxFailuresAllowed
+	MaxFailuresAllowed = podrestarts.DefaultMaxFailuresAllowed
 	maxFailuresAllowed := os.Getenv("MAX_FAILURES_ALLOWED")
 	if len(maxFailuresAllowed) != 0 {
 		conversion, err := strconv.ParseInt(maxFailuresAllowed, 10, 32)
+		if err != nil {
+			log.Fatalln("Error converting maxFailuresAllowed:", maxFailuresAllowed, "to int, err:", err)
+		}
 		MaxFailuresAllowed = int32(conversion)
+	}
+
+	restartWindow := os.Getenv("RESTART_WINDOW")
+	if len(restartWindow) != 0 {
+		RestartWindow, err = podrestarts.ParseWindow(restartWindow)
 		if err != nil {
-			log.Errorln("Error converting maxFailuresAllowed:", maxFailuresAllowed, "to int, err:", err)
-			return
+			log.Fatalln("Error parsing RESTART_WINDOW:", err)
 		}
 	}
+	log.Infoln("Pods fail the check with more `BackOff` events than:", podrestarts.RestartThreshold{MaxFailuresAllowed: MaxFailuresAllowed, Window: RestartWindow})
+
+	namespaceThresholds := os.Getenv("NAMESPACE_THRESHOLDS")
+	if len(namespaceThresholds) != 0 {
+		NamespaceThresholds, err = podrestarts.ParseNamespaceThresholds(namespaceThresholds, podrestarts.RestartThreshold{MaxFailuresAllowed: MaxFailuresAllowed, Window: RestartWindow})
+		if err != nil {
+			log.Fatalln("Error parsing NAMESPACE_THRESHOLDS:", err)
+		}
+		for namespace, threshold := range NamespaceThresholds {
+			log.Infoln("Pods in namespace", namespace, "fail the check with more `BackOff` events than:", threshold)
+		}
+	}
+
+	excludedNamespaces := os.Getenv("EXCLUDED_NAMESPACES")
+	if len(excludedNamespaces) != 0 {
+		ExcludedNamespaces = podrestarts.ParseNamespaces(excludedNamespaces)
+		log.Infoln("Not checking pods in namespaces:", excludedNamespaces)
+	}
+
+	excludedPodSelector := os.Getenv("EXCLUDED_POD_SELECTOR")
+	if len(excludedPodSelector) != 0 {
+		ExcludedPodSelector, err = labels.Parse(excludedPodSelector)
+		if err != nil {
+			log.Fatalln("Error parsing EXCLUDED_POD_SELECTOR:", err)
+		}
+		log.Infoln("Not checking pods selected by:", ExcludedPodSelector)
+	}
+
+	RestartSamplesNamespace = os.Getenv("KH_POD_NAMESPACE")
+	checkName := os.Getenv("KH_CHECK_NAME")
+	if len(checkName) != 0 {
+		RestartSamplesConfigMap = checkName + "-restart-samples"
+	}
 }
 
 func main() {
@@ -89,10 +136,17 @@ func main() {
 	}
 
 	// Create new pod restarts checker with Kubernetes client
-	prc := New(client)
+	prc := podrestarts.NewPodRestartsChecker(client, Namespace, CheckTimeout)
+	prc.MaxFailuresAllowed = MaxFailuresAllowed
+	prc.RestartWindow = RestartWindow
+	prc.NamespaceThresholds = NamespaceThresholds
+	prc.ExcludedNamespaces = ExcludedNamespaces
+	prc.ExcludedPodSelector = ExcludedPodSelector
+	prc.RestartSamplesNamespace = RestartSamplesNamespace
+	prc.RestartSamplesConfigMap = RestartSamplesConfigMap
 
 	// Run check
-	err = prc.Run()
+	err = run(prc)
 	if err != nil {
 		log.Errorln("Error running Pod Restarts check:", err)
 		os.Exit(2)
@@ -101,115 +155,13 @@ func main() {
 	os.Exit(0)
 }
 
-// New creates a new pod restart checker for a specific namespace, ready to use.
-func New(client *kubernetes.Clientset) *Checker {
-	return &Checker{
-		Namespace:          Namespace,
-		MaxFailuresAllowed: MaxFailuresAllowed,
-		BadPods:            make(map[string]string),
-		client:             client,
-	}
-}
-
-// Run starts the go routine to run checks, reports whether or not the check completely successfully, and finally checks
-// for any errors in the Checker struct and re
-func (prc *Checker) Run() error {
-	// TODO: refactor function to receive context on exported function in next breaking change.
-	ctx := context.TODO()
-
-	log.Infoln("Running Pod Restarts checker")
-	doneChan := make(chan error)
-
-	// run the check in a goroutine and notify the doneChan when completed
-	go func(doneChan chan error) {
-		err := prc.doChecks(ctx)
-		doneChan <- err
-	}(doneChan)
-
-	// wait for either a timeout or job completion
-	select {
-	case <-time.After(CheckTimeout):
-		// The check has timed out after its specified timeout period
-		errorMessage := "Failed to complete Pod Restart check in time! Timeout was reached."
-		err := reportKHFailure([]string{errorMessage})
-		if err != nil {
-			return err
-		}
-		return err
-	case err := <-doneChan:
-		if len(prc.BadPods) != 0 || err != nil {
-			var errorMessages []string
-			if err != nil {
-				log.Error(err)
-				errorMessages = append(errorMessages, err.Error())
-			}
-			for _, msg := range prc.BadPods {
-				errorMessages = append(errorMessages, msg)
-			}
-			return reportKHFailure(errorMessages)
-
-		}
-		return reportKHSuccess()
-	}
-}
-
-// doChecks grabs all events in a given namespace, then checks for pods with event type "Warning" with reason "BackOff",
-// and an event count greater than the MaxFailuresAllowed. If any of these pods are found, an error message is appended
-// to Checker struct errorMessages.
-func (prc *Checker) doChecks(ctx context.Context) error {
-
-	log.Infoln("Checking for pod BackOff events for all pods in the namespace:", prc.Namespace)
-
-	podWarningEvents, err := prc.client.CoreV1().Events(prc.Namespace).List(ctx, metav1.ListOptions{FieldSelector: "type=Warning"})
-	if err != nil {
-		return err
-	}
-
-	if len(podWarningEvents.Items) != 0 {
-		log.Infoln("Found `Warning` events in the namespace:", prc.Namespace)
-
-		for _, event := range podWarningEvents.Items {
-
-			// Checks for pods with BackOff events greater than the MaxFailuresAllowed
-			if event.InvolvedObject.Kind == "Pod" && event.Reason == "BackOff" && event.Count > prc.MaxFailuresAllowed {
-				errorMessage := "Found: " + strconv.FormatInt(int64(event.Count), 10) + " `BackOff` events for pod: " + event.InvolvedObject.Name + " in namespace: " + event.Namespace
-
-				log.Infoln(errorMessage)
-
-				// We could be checking for pods in all namespaces so prefix the namespace
-				prc.BadPods[event.InvolvedObject.Namespace+"/"+event.InvolvedObject.Name] = errorMessage
-			}
-		}
-	}
-
-	for pod := range prc.BadPods {
-		err := prc.verifyBadPodRestartExists(ctx, pod)
-		if err != nil {
-			return err
-		}
-	}
-	return err
-}
-
-// verifyBadPodRestartExists removes the bad pod found from the events list if the pod no longer exists
-func (prc *Checker) verifyBadPodRestartExists(ctx context.Context, pod string) error {
-
-	// Pod is in the form namespace/pod_name
-	parts := strings.Split(pod, "/")
-	namespace := parts[0]
-	podName := parts[1]
-
-	_, err := prc.client.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
-	if err != nil {
-		if k8sErrors.IsNotFound(err) || strings.Contains(err.Error(), "not found") {
-			log.Infoln("Bad Pod:", podName, "no longer exists. Removing from bad pods map")
-			delete(prc.BadPods, podName)
-		} else {
-			log.Infoln("Error getting bad pod:", podName, err)
-			return err
-		}
+// run runs the check and reports whether or not it completed successfully to Kuberhealthy
+func run(prc *podrestarts.Checker) error {
+	errorMessages := prc.Check(context.Background())
+	if len(errorMessages) != 0 {
+		return reportKHFailure(errorMessages)
 	}
-	return nil
+	return reportKHSuccess()
 }
 
 // reportKHSuccess reports success to Kuberhealthy servers and verifies the report successfully went through
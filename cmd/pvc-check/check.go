@@ -0,0 +1,259 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// checkLabel labels the claims and pods created by the check, so that those left behind by earlier runs can be
+	// found and removed
+	checkLabel = "kuberhealthy-pvc-check"
+
+	// mountPath is where the volume is mounted in the pod
+	mountPath = "/data"
+
+	// cleanupTimeout is how long removing the claim and pod can take after the run
+	cleanupTimeout = time.Second * 30
+)
+
+// checker provisions a PersistentVolumeClaim and mounts it in a pod that writes and reads a file on the volume
+type checker struct {
+	namespace        string
+	storageClass     string // the storage class of the claim. the default storage class is used when blank
+	size             resource.Quantity
+	image            string
+	provisionTimeout time.Duration // how long the claim can take to be bound
+	mountTimeout     time.Duration // how long the pod can take to complete once the claim is bound
+	pollInterval     time.Duration
+	ownerReferences  []metav1.OwnerReference
+	client           kubernetes.Interface
+}
+
+// run provisions a claim, mounts it in a pod that writes and reads a file on the volume and removes both again.  The
+// errors of the run are returned.
+func (c *checker) run(ctx context.Context) []string {
+	err := c.cleanUp(ctx)
+	if err != nil {
+		return []string{"Failed to remove the claims and pods of earlier runs: " + err.Error()}
+	}
+
+	name := "pvc-check-" + strconv.FormatInt(time.Now().Unix(), 10)
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		err := c.cleanUp(cleanupCtx)
+		if err != nil {
+			log.Errorln("Failed to remove the claim and pod of this run:", err)
+		}
+	}()
+
+	log.Infoln("Creating PersistentVolumeClaim", c.namespace+"/"+name)
+	_, err = c.client.CoreV1().PersistentVolumeClaims(c.namespace).Create(ctx, c.claim(name), metav1.CreateOptions{})
+	if err != nil {
+		return []string{fmt.Sprintf("Failed to create PersistentVolumeClaim %s of storage class %s: %s", name, storageClassName(c.storageClass), err)}
+	}
+
+	// the pod is created right away, because storage classes that wait for the first consumer only bind the claim
+	// once the pod is scheduled
+	log.Infoln("Creating pod", c.namespace+"/"+name)
+	_, err = c.client.CoreV1().Pods(c.namespace).Create(ctx, c.pod(name), metav1.CreateOptions{})
+	if err != nil {
+		return []string{fmt.Sprintf("Failed to create pod %s to mount PersistentVolumeClaim %s: %s", name, name, err)}
+	}
+
+	start := time.Now()
+	err = c.waitForClaim(ctx, name)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	log.Infoln("PersistentVolumeClaim", name, "was bound after", time.Since(start))
+
+	start = time.Now()
+	err = c.waitForPod(ctx, name)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	log.Infoln("Pod", name, "wrote and read the volume after", time.Since(start))
+	return nil
+}
+
+// claim returns the PersistentVolumeClaim of a run
+func (c *checker) claim(name string) *apiv1.PersistentVolumeClaim {
+	claim := &apiv1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       c.namespace,
+			Labels:          map[string]string{checkLabel: name},
+			OwnerReferences: c.ownerReferences,
+		},
+		Spec: apiv1.PersistentVolumeClaimSpec{
+			AccessModes: []apiv1.PersistentVolumeAccessMode{apiv1.ReadWriteOnce},
+			Resources: apiv1.VolumeResourceRequirements{
+				Requests: apiv1.ResourceList{apiv1.ResourceStorage: c.size},
+			},
+		},
+	}
+	if len(c.storageClass) > 0 {
+		claim.Spec.StorageClassName = &c.storageClass
+	}
+	return claim
+}
+
+// pod returns the pod of a run.  It writes the name of the run to a file on the volume and exits non-zero when it
+// can not read the same name back.
+func (c *checker) pod(name string) *apiv1.Pod {
+	file := mountPath + "/kuberhealthy"
+	script := fmt.Sprintf(`set -e; echo "$RUN" > %s; sync; test "$(cat %s)" = "$RUN"`, file, file)
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       c.namespace,
+			Labels:          map[string]string{checkLabel: name},
+			OwnerReferences: c.ownerReferences,
+		},
+		Spec: apiv1.PodSpec{
+			RestartPolicy: apiv1.RestartPolicyNever,
+			Containers: []apiv1.Container{{
+				Name:         "main",
+				Image:        c.image,
+				Command:      []string{"sh", "-c", script},
+				Env:          []apiv1.EnvVar{{Name: "RUN", Value: name}},
+				VolumeMounts: []apiv1.VolumeMount{{Name: "data", MountPath: mountPath}},
+				Resources: apiv1.ResourceRequirements{
+					Requests: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("10m"),
+						apiv1.ResourceMemory: resource.MustParse("10Mi"),
+					},
+				},
+			}},
+			Volumes: []apiv1.Volume{{
+				Name: "data",
+				VolumeSource: apiv1.VolumeSource{
+					PersistentVolumeClaim: &apiv1.PersistentVolumeClaimVolumeSource{ClaimName: name},
+				},
+			}},
+		},
+	}
+}
+
+// waitForClaim waits for the claim of a run to be bound to a volume
+func (c *checker) waitForClaim(ctx context.Context, name string) error {
+	err := wait.PollUntilContextTimeout(ctx, c.pollInterval, c.provisionTimeout, true, func(ctx context.Context) (bool, error) {
+		claim, err := c.client.CoreV1().PersistentVolumeClaims(c.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			log.Warnln("Failed to get PersistentVolumeClaim", name+":", err)
+			return false, nil
+		}
+		return claim.Status.Phase == apiv1.ClaimBound, nil
+	})
+	if err != nil {
+		return fmt.Errorf("PersistentVolumeClaim %s of storage class %s was not bound within %s%s", name, storageClassName(c.storageClass), c.provisionTimeout, c.lastWarning(name))
+	}
+	return nil
+}
+
+// waitForPod waits for the pod of a run to mount the volume and to write and read its file
+func (c *checker) waitForPod(ctx context.Context, name string) error {
+	var pod *apiv1.Pod
+	err := wait.PollUntilContextTimeout(ctx, c.pollInterval, c.mountTimeout, true, func(ctx context.Context) (bool, error) {
+		p, err := c.client.CoreV1().Pods(c.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			log.Warnln("Failed to get pod", name+":", err)
+			return false, nil
+		}
+		pod = p
+		return p.Status.Phase == apiv1.PodSucceeded || p.Status.Phase == apiv1.PodFailed, nil
+	})
+	if err != nil {
+		return fmt.Errorf("Pod %s did not mount PersistentVolumeClaim %s and complete within %s%s", name, name, c.mountTimeout, c.lastWarning(name))
+	}
+	if pod.Status.Phase == apiv1.PodFailed {
+		return fmt.Errorf("Pod %s failed to write and read a file on PersistentVolumeClaim %s%s", name, name, terminationMessage(pod))
+	}
+	return nil
+}
+
+// lastWarning returns the message of the latest warning event of the claim or pod of a run, such as a
+// ProvisioningFailed or FailedMount event, for error messages
+func (c *checker) lastWarning(name string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+	defer cancel()
+	events, err := c.client.CoreV1().Events(c.namespace).List(ctx, metav1.ListOptions{FieldSelector: "type=" + apiv1.EventTypeWarning})
+	if err != nil {
+		log.Warnln("Failed to list the events of", name+":", err)
+		return ""
+	}
+	var warnings []apiv1.Event
+	for _, event := range events.Items {
+		if event.InvolvedObject.Name == name && event.Type == apiv1.EventTypeWarning {
+			warnings = append(warnings, event)
+		}
+	}
+	if len(warnings) == 0 {
+		return ""
+	}
+	sort.Slice(warnings, func(i, j int) bool {
+		return warnings[i].LastTimestamp.Before(&warnings[j].LastTimestamp)
+	})
+	last := warnings[len(warnings)-1]
+	return fmt.Sprintf(": %s %s: %s", last.InvolvedObject.Kind, last.Reason, last.Message)
+}
+
+// terminationMessage returns the exit code and reason of the terminated container of a pod for error messages
+func terminationMessage(pod *apiv1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Terminated != nil {
+			return fmt.Sprintf(": container exited with code %d: %s", status.State.Terminated.ExitCode, status.State.Terminated.Reason)
+		}
+	}
+	return ""
+}
+
+// cleanUp removes the claims and pods created by the check
+func (c *checker) cleanUp(ctx context.Context) error {
+	opts := metav1.ListOptions{LabelSelector: checkLabel}
+	pods, err := c.client.CoreV1().Pods(c.namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		log.Infoln("Removing pod", pod.Name)
+		err := c.client.CoreV1().Pods(c.namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	claims, err := c.client.CoreV1().PersistentVolumeClaims(c.namespace).List(ctx, opts)
+	if err != nil {
+		return err
+	}
+	for _, claim := range claims.Items {
+		log.Infoln("Removing PersistentVolumeClaim", claim.Name)
+		err := c.client.CoreV1().PersistentVolumeClaims(c.namespace).Delete(ctx, claim.Name, metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// storageClassName returns the name of a storage class for log and error messages
+func storageClassName(storageClass string) string {
+	if len(storageClass) == 0 {
+		return "(default)"
+	}
+	return storageClass
+}
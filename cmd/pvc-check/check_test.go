@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newTestChecker returns a checker with short timeouts and a fake client
+func newTestChecker(client *fake.Clientset) *checker {
+	return &checker{
+		namespace:        "kuberhealthy",
+		storageClass:     "fast",
+		size:             resource.MustParse("1Gi"),
+		image:            defaultPodImage,
+		provisionTimeout: time.Millisecond * 300,
+		mountTimeout:     time.Millisecond * 300,
+		pollInterval:     time.Millisecond * 10,
+		client:           client,
+	}
+}
+
+// simulateCluster binds the claim and completes the pod of a run once they are created, like a storage provisioner
+// and a kubelet would.  Only the claim is bound when podPhase is blank.
+func simulateCluster(t *testing.T, client *fake.Clientset, podPhase apiv1.PodPhase) {
+	go func() {
+		ctx := context.Background()
+		for i := 0; i < 100; i++ {
+			time.Sleep(time.Millisecond * 5)
+			claims, err := client.CoreV1().PersistentVolumeClaims("kuberhealthy").List(ctx, metav1.ListOptions{})
+			if err != nil || len(claims.Items) == 0 || claims.Items[0].Name == "pvc-check-1" {
+				continue
+			}
+			claim := claims.Items[0]
+			claim.Status.Phase = apiv1.ClaimBound
+			_, err = client.CoreV1().PersistentVolumeClaims("kuberhealthy").UpdateStatus(ctx, &claim, metav1.UpdateOptions{})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if len(podPhase) == 0 {
+				return
+			}
+
+			pod, err := client.CoreV1().Pods("kuberhealthy").Get(ctx, claim.Name, metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+			pod.Status.Phase = podPhase
+			if podPhase == apiv1.PodFailed {
+				pod.Status.ContainerStatuses = []apiv1.ContainerStatus{{
+					State: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}},
+				}}
+			}
+			_, err = client.CoreV1().Pods("kuberhealthy").UpdateStatus(ctx, pod, metav1.UpdateOptions{})
+			if err != nil {
+				t.Error(err)
+			}
+			return
+		}
+	}()
+}
+
+// assertCleanedUp ensures the claim and pod of the run were removed
+func assertCleanedUp(t *testing.T, client *fake.Clientset) {
+	pods, _ := client.CoreV1().Pods("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	claims, _ := client.CoreV1().PersistentVolumeClaims("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if len(pods.Items) != 0 || len(claims.Items) != 0 {
+		t.Fatal("expected the claim and pod to be removed but found", len(claims.Items), "claims and", len(pods.Items), "pods")
+	}
+}
+
+func TestCheckerRun(t *testing.T) {
+	// a claim left behind by an earlier run is removed
+	client := fake.NewSimpleClientset(&apiv1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "pvc-check-1", Namespace: "kuberhealthy", Labels: map[string]string{checkLabel: "pvc-check-1"}},
+	})
+	c := newTestChecker(client)
+	simulateCluster(t, client, apiv1.PodSucceeded)
+
+	errs := c.run(context.Background())
+	if len(errs) != 0 {
+		t.Fatal("expected the check to pass but got", errs)
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestCheckerRunClaimNotBound(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c := newTestChecker(client)
+	go func() {
+		// the provisioner reports why the claim can not be bound
+		time.Sleep(time.Millisecond * 50)
+		claims, _ := client.CoreV1().PersistentVolumeClaims("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+		if len(claims.Items) == 0 {
+			return
+		}
+		_, err := client.CoreV1().Events("kuberhealthy").Create(context.Background(), &apiv1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "provisioning-failed", Namespace: "kuberhealthy"},
+			InvolvedObject: apiv1.ObjectReference{Kind: "PersistentVolumeClaim", Name: claims.Items[0].Name},
+			Type:           apiv1.EventTypeWarning,
+			Reason:         "ProvisioningFailed",
+			Message:        "storageclass.storage.k8s.io \"fast\" not found",
+		}, metav1.CreateOptions{})
+		if err != nil {
+			t.Error(err)
+		}
+	}()
+
+	errs := c.run(context.Background())
+	if len(errs) != 1 || !strings.Contains(errs[0], "of storage class fast was not bound within 300ms") || !strings.Contains(errs[0], "ProvisioningFailed") {
+		t.Fatal("expected a provisioning timeout with the provisioning failure but got", errs)
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestCheckerRunMountTimeout(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c := newTestChecker(client)
+	simulateCluster(t, client, "")
+
+	errs := c.run(context.Background())
+	if len(errs) != 1 || !strings.Contains(errs[0], "did not mount PersistentVolumeClaim") {
+		t.Fatal("expected a mount timeout but got", errs)
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestCheckerRunPodFailed(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c := newTestChecker(client)
+	simulateCluster(t, client, apiv1.PodFailed)
+
+	errs := c.run(context.Background())
+	if len(errs) != 1 || !strings.Contains(errs[0], "failed to write and read a file") || !strings.Contains(errs[0], "exited with code 1") {
+		t.Fatal("expected a failed pod but got", errs)
+	}
+	assertCleanedUp(t, client)
+}
+
+// TestCheckerClaimDefaultStorageClass ensures claims use the default storage class when none is configured
+func TestCheckerClaimDefaultStorageClass(t *testing.T) {
+	c := newTestChecker(nil)
+	if claim := c.claim("pvc-check-1"); *claim.Spec.StorageClassName != "fast" {
+		t.Fatal("expected the configured storage class but got", *claim.Spec.StorageClassName)
+	}
+	c.storageClass = ""
+	if claim := c.claim("pvc-check-1"); claim.Spec.StorageClassName != nil {
+		t.Fatal("expected no storage class so that the default storage class is used but got", *claim.Spec.StorageClassName)
+	}
+}
@@ -0,0 +1,148 @@
+// Package main implements a check that provisions a PersistentVolumeClaim and mounts it in a pod that writes and
+// reads a file on the volume.
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	checkclient "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/nodeCheck"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/util"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
+)
+
+const (
+	// defaultPVCSize is the size of the claim created by the check
+	defaultPVCSize = "1Gi"
+
+	// defaultPodImage is the image of the pod that writes and reads a file on the volume.  It needs a shell.
+	defaultPodImage = "busybox:1.36"
+
+	// defaultProvisionTimeout is how long the claim can take to be bound to a volume
+	defaultProvisionTimeout = time.Minute * 2
+
+	// defaultMountTimeout is how long the pod can take to mount the volume and write and read its file once the claim
+	// is bound
+	defaultMountTimeout = time.Minute * 3
+
+	// defaultNamespace is the namespace the claim and pod are created in when the check's namespace can not be found
+	defaultNamespace = "kuberhealthy"
+)
+
+// kubeConfigFile is a variable containing file path of Kubernetes config files
+var kubeConfigFile = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+
+func init() {
+	// set debug mode for nodeCheck pkg
+	nodeCheck.EnableDebugOutput()
+}
+
+func main() {
+	checker, err := newCheckerFromEnv()
+	if err != nil {
+		log.Errorln("Error parsing the check configuration:", err)
+		reportToKuberhealthy([]string{err.Error()})
+		return
+	}
+
+	// leave time to clean up and report before the deadline of the run
+	ctx := context.Background()
+	deadline, err := checkclient.GetDeadline()
+	if err != nil {
+		log.Warnln("There was an issue getting the check deadline:", err.Error())
+	} else {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-time.Second*30))
+		defer cancel()
+	}
+
+	client, err := kubeClient.Create(kubeConfigFile)
+	if err != nil {
+		reportToKuberhealthy([]string{"Failed to create a kubernetes client: " + err.Error()})
+		return
+	}
+	checker.client = client
+
+	// the claim and pod are owned by the checker pod, so that they are garbage collected if cleanup is interrupted
+	checker.ownerReferences, err = util.GetOwnerRef(client, checker.namespace)
+	if err != nil {
+		log.Warnln("Failed to get the owner reference of the checker pod:", err)
+	}
+
+	// hits kuberhealthy endpoint to see if node is ready
+	err = nodeCheck.WaitForKuberhealthy(ctx)
+	if err != nil {
+		log.Errorln("Error waiting for kuberhealthy endpoint to be contactable by checker pod with error:" + err.Error())
+	}
+
+	reportToKuberhealthy(checker.run(ctx))
+}
+
+// newCheckerFromEnv creates a checker from the environment variables of the checker pod
+func newCheckerFromEnv() (*checker, error) {
+	c := &checker{
+		namespace:        util.GetInstanceNamespace(defaultNamespace),
+		storageClass:     os.Getenv("CHECK_STORAGE_CLASS"),
+		image:            defaultPodImage,
+		provisionTimeout: defaultProvisionTimeout,
+		mountTimeout:     defaultMountTimeout,
+		pollInterval:     time.Second * 2,
+	}
+	if namespace := os.Getenv("CHECK_NAMESPACE"); len(namespace) > 0 {
+		c.namespace = namespace
+	}
+	if image := os.Getenv("CHECK_POD_IMAGE"); len(image) > 0 {
+		c.image = image
+	}
+
+	size := defaultPVCSize
+	if s := os.Getenv("CHECK_PVC_SIZE"); len(s) > 0 {
+		size = s
+	}
+	var err error
+	c.size, err = resource.ParseQuantity(size)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range []struct {
+		name  string
+		value *time.Duration
+	}{
+		{"PROVISION_TIMEOUT", &c.provisionTimeout},
+		{"MOUNT_TIMEOUT", &c.mountTimeout},
+	} {
+		value := os.Getenv(d.name)
+		if len(value) == 0 {
+			continue
+		}
+		*d.value, err = time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	log.Infoln("Provisioning a", c.size.String(), "claim of storage class", storageClassName(c.storageClass), "in namespace", c.namespace)
+	return c, nil
+}
+
+// reportToKuberhealthy reports the errors of the run to Kuberhealthy, or success when there are none
+func reportToKuberhealthy(errorMessages []string) {
+	var err error
+	if len(errorMessages) == 0 {
+		err = checkclient.ReportSuccess()
+	} else {
+		log.Errorln("Reporting errors to Kuberhealthy:", errorMessages)
+		err = checkclient.ReportFailure(errorMessages)
+	}
+	if err != nil {
+		log.Fatalln("error reporting to kuberhealthy:", err.Error())
+	}
+	log.Infoln("Successfully reported to Kuberhealthy")
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// checkLabel labels the pods created by the check, so that those left behind by earlier runs can be found and
+	// removed
+	checkLabel = "kuberhealthy-registry-pull-check"
+
+	// cleanupTimeout is how long removing the pods can take after the run
+	cleanupTimeout = time.Second * 30
+)
+
+// checker pulls an image from each configured registry by starting a pod for it that always pulls its image
+type checker struct {
+	namespace       string
+	images          []string // an image in each registry to pull
+	pullSecrets     []string // the image pull secrets of the pods, for private registries
+	pullTimeout     time.Duration
+	pollInterval    time.Duration
+	ownerReferences []metav1.OwnerReference
+	client          kubernetes.Interface
+}
+
+// pull is the pod that pulls one of the images during a run
+type pull struct {
+	image   string
+	pod     string
+	done    bool
+	err     string
+	waiting string // the reason and message the container of the pod was last waiting for, for error messages
+}
+
+// run starts a pod for each image, waits for all of them to pull their image and removes them again.  The errors of the
+// run are returned.
+func (c *checker) run(ctx context.Context) []string {
+	err := c.cleanUp(ctx)
+	if err != nil {
+		return []string{"Failed to remove the pods of earlier runs: " + err.Error()}
+	}
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		err := c.cleanUp(cleanupCtx)
+		if err != nil {
+			log.Errorln("Failed to remove the pods of this run:", err)
+		}
+	}()
+
+	run := strconv.FormatInt(time.Now().Unix(), 10)
+	var pulls []*pull
+	var errs []string
+	for i, image := range c.images {
+		name := fmt.Sprintf("registry-pull-check-%s-%d", run, i)
+		log.Infoln("Creating pod", c.namespace+"/"+name, "to pull image", image)
+		_, err := c.client.CoreV1().Pods(c.namespace).Create(ctx, c.pod(name, image), metav1.CreateOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Failed to create pod %s to pull image %s: %s", name, image, err))
+			continue
+		}
+		pulls = append(pulls, &pull{image: image, pod: name})
+	}
+
+	start := time.Now()
+	_ = wait.PollUntilContextTimeout(ctx, c.pollInterval, c.pullTimeout, true, func(ctx context.Context) (bool, error) {
+		done := true
+		for _, p := range pulls {
+			if p.done {
+				continue
+			}
+			pod, err := c.client.CoreV1().Pods(c.namespace).Get(ctx, p.pod, metav1.GetOptions{})
+			if err != nil {
+				log.Warnln("Failed to get pod", p.pod+":", err)
+				done = false
+				continue
+			}
+			p.update(pod)
+			if p.done && len(p.err) == 0 {
+				log.Infoln("Pulled image", p.image, "after", time.Since(start))
+			}
+			done = done && p.done
+		}
+		return done, nil
+	})
+
+	for _, p := range pulls {
+		switch {
+		case !p.done:
+			errs = append(errs, fmt.Sprintf("Image %s was not pulled within %s%s", p.image, c.pullTimeout, p.waiting))
+		case len(p.err) > 0:
+			errs = append(errs, p.err)
+		}
+	}
+	return errs
+}
+
+// update records whether the pod of a pull has pulled its image.  The pull fails when the kubelet backs off from
+// pulling the image or can not parse its name.
+func (p *pull) update(pod *apiv1.Pod) {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Running != nil || status.State.Terminated != nil {
+			// the container was started, so its image was pulled
+			p.done = true
+			return
+		}
+		waiting := status.State.Waiting
+		if waiting == nil || len(waiting.Reason) == 0 {
+			continue
+		}
+		switch waiting.Reason {
+		case "ErrImagePull":
+			// the kubelet retries the pull, and its message has the reason the pull failed
+			p.waiting = fmt.Sprintf(": %s: %s", waiting.Reason, waiting.Message)
+		case "ImagePullBackOff", "InvalidImageName":
+			p.done = true
+			p.err = fmt.Sprintf("Failed to pull image %s: %s: %s%s", p.image, waiting.Reason, waiting.Message, p.waiting)
+		default:
+			p.waiting = fmt.Sprintf(": %s: %s", waiting.Reason, waiting.Message)
+		}
+	}
+}
+
+// pod returns the pod that pulls an image.  The image is always pulled, so that images cached on the node do not hide
+// registry problems.
+func (c *checker) pod(name string, image string) *apiv1.Pod {
+	automount := false
+	pod := &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       c.namespace,
+			Labels:          map[string]string{checkLabel: name},
+			OwnerReferences: c.ownerReferences,
+		},
+		Spec: apiv1.PodSpec{
+			RestartPolicy:                apiv1.RestartPolicyNever,
+			AutomountServiceAccountToken: &automount,
+			Containers: []apiv1.Container{{
+				Name:            "main",
+				Image:           image,
+				ImagePullPolicy: apiv1.PullAlways,
+				Resources: apiv1.ResourceRequirements{
+					Requests: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("10m"),
+						apiv1.ResourceMemory: resource.MustParse("10Mi"),
+					},
+				},
+			}},
+		},
+	}
+	for _, secret := range c.pullSecrets {
+		pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, apiv1.LocalObjectReference{Name: secret})
+	}
+	return pod
+}
+
+// cleanUp removes the pods created by the check
+func (c *checker) cleanUp(ctx context.Context) error {
+	pods, err := c.client.CoreV1().Pods(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: checkLabel})
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		log.Infoln("Removing pod", pod.Name)
+		err := c.client.CoreV1().Pods(c.namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
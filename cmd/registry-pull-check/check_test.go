@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// newTestChecker returns a checker with a short timeout and a fake client
+func newTestChecker(client *fake.Clientset, images ...string) *checker {
+	return &checker{
+		namespace:    "kuberhealthy",
+		images:       images,
+		pullSecrets:  []string{"private-registry"},
+		pullTimeout:  time.Millisecond * 300,
+		pollInterval: time.Millisecond * 10,
+		client:       client,
+	}
+}
+
+// simulateKubelet sets the state of the container of each pod that pulls one of the supplied images once it is
+// created, like a kubelet would
+func simulateKubelet(t *testing.T, client *fake.Clientset, states map[string]apiv1.ContainerState) {
+	go func() {
+		ctx := context.Background()
+		updated := map[string]bool{}
+		for i := 0; i < 100 && len(updated) < len(states); i++ {
+			time.Sleep(time.Millisecond * 5)
+			pods, err := client.CoreV1().Pods("kuberhealthy").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				continue
+			}
+			for _, pod := range pods.Items {
+				if len(pod.Spec.Containers) == 0 {
+					// the pod left behind by an earlier run
+					continue
+				}
+				state, ok := states[pod.Spec.Containers[0].Image]
+				if !ok || updated[pod.Name] {
+					continue
+				}
+				pod.Status.ContainerStatuses = []apiv1.ContainerStatus{{Name: "main", State: state}}
+				_, err = client.CoreV1().Pods("kuberhealthy").UpdateStatus(ctx, &pod, metav1.UpdateOptions{})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				updated[pod.Name] = true
+			}
+		}
+	}()
+}
+
+// assertCleanedUp ensures the pods of the run were removed
+func assertCleanedUp(t *testing.T, client *fake.Clientset) {
+	pods, _ := client.CoreV1().Pods("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if len(pods.Items) != 0 {
+		t.Fatal("expected the pods to be removed but found", len(pods.Items))
+	}
+}
+
+func TestCheckerRun(t *testing.T) {
+	// a pod left behind by an earlier run is removed
+	client := fake.NewSimpleClientset(&apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "registry-pull-check-1-0", Namespace: "kuberhealthy", Labels: map[string]string{checkLabel: "registry-pull-check-1-0"}},
+	})
+	c := newTestChecker(client, "docker.io/library/busybox:1.36", "registry.example.com/busybox:1.36")
+	simulateKubelet(t, client, map[string]apiv1.ContainerState{
+		"docker.io/library/busybox:1.36":    {Terminated: &apiv1.ContainerStateTerminated{ExitCode: 0}},
+		"registry.example.com/busybox:1.36": {Running: &apiv1.ContainerStateRunning{}},
+	})
+
+	errs := c.run(context.Background())
+	if len(errs) != 0 {
+		t.Fatal("expected the check to pass but got", errs)
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestCheckerRunPullBackOff(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c := newTestChecker(client, "docker.io/library/busybox:1.36", "registry.example.com/busybox:1.36")
+	simulateKubelet(t, client, map[string]apiv1.ContainerState{
+		"docker.io/library/busybox:1.36": {Running: &apiv1.ContainerStateRunning{}},
+		"registry.example.com/busybox:1.36": {Waiting: &apiv1.ContainerStateWaiting{
+			Reason:  "ImagePullBackOff",
+			Message: `Back-off pulling image "registry.example.com/busybox:1.36"`,
+		}},
+	})
+
+	errs := c.run(context.Background())
+	if len(errs) != 1 || !strings.Contains(errs[0], "Failed to pull image registry.example.com/busybox:1.36: ImagePullBackOff") {
+		t.Fatal("expected the image pull to back off but got", errs)
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestCheckerRunTimeout(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	c := newTestChecker(client, "registry.example.com/busybox:1.36")
+	simulateKubelet(t, client, map[string]apiv1.ContainerState{
+		"registry.example.com/busybox:1.36": {Waiting: &apiv1.ContainerStateWaiting{
+			Reason:  "ErrImagePull",
+			Message: "pull access denied, repository does not exist or may require authorization",
+		}},
+	})
+
+	errs := c.run(context.Background())
+	if len(errs) != 1 || !strings.Contains(errs[0], "was not pulled within 300ms: ErrImagePull: pull access denied") {
+		t.Fatal("expected the image pull to time out with the pull error but got", errs)
+	}
+	assertCleanedUp(t, client)
+}
+
+// TestCheckerPod ensures the pods always pull their image with the configured pull secrets
+func TestCheckerPod(t *testing.T) {
+	c := newTestChecker(nil)
+	pod := c.pod("registry-pull-check-1-0", "registry.example.com/busybox:1.36")
+	if pod.Spec.Containers[0].ImagePullPolicy != apiv1.PullAlways {
+		t.Fatal("expected the image to always be pulled but got", pod.Spec.Containers[0].ImagePullPolicy)
+	}
+	if len(pod.Spec.ImagePullSecrets) != 1 || pod.Spec.ImagePullSecrets[0].Name != "private-registry" {
+		t.Fatal("expected the configured pull secret but got", pod.Spec.ImagePullSecrets)
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	items := splitList(" docker.io/library/busybox:1.36, ,registry.example.com/busybox:1.36,")
+	if strings.Join(items, "|") != "docker.io/library/busybox:1.36|registry.example.com/busybox:1.36" {
+		t.Fatal("expected the blank entries to be dropped but got", items)
+	}
+}
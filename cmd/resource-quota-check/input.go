@@ -44,7 +44,18 @@ func parseInputValues() {
 		log.Infoln("Parsed WHITELIST:", whitelist)
 	}
 
-	// Parse memory and CPU thresholds.
+	// Parse the resources of the resource quotas to examine.
+	if len(resourcesEnv) != 0 {
+		for _, resource := range strings.Split(resourcesEnv, ",") {
+			resource = strings.TrimSpace(resource)
+			if len(resource) != 0 {
+				resources = append(resources, resource)
+			}
+		}
+		log.Infoln("Parsed RESOURCES:", resources)
+	}
+
+	// Parse the usage threshold.
 	// (0.90 represents 90% and will alert if usage is at least 90% inclusive)
 	if len(thresholdEnv) != 0 {
 		var err error
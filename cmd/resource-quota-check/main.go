@@ -42,6 +42,11 @@ var (
 	thresholdEnv = os.Getenv("THRESHOLD")
 	threshold    float64
 
+	// Resources of the resource quotas to examine, such as "requests.cpu,limits.memory,pods".
+	// Every resource a quota tracks is examined when empty, including object counts.
+	resourcesEnv = os.Getenv("RESOURCES")
+	resources    []string
+
 	// Check time limit.
 	checkTimeLimitEnv = os.Getenv("CHECK_TIME_LIMIT")
 	checkTimeLimit    time.Duration
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -145,23 +146,46 @@ func examineResouceQuotasForNamespace(ctx context.Context, namespace string, c c
 	}
 	// Check if usage is at certain a threshold (percentage) of the limit.
 	for _, rq := range quotas.Items {
-		limits := rq.Status.Hard
-		status := rq.Status.Used
-		percentCPUUsed := float64(status.Cpu().MilliValue()) / float64(limits.Cpu().MilliValue())
-		percentMemoryUsed := float64(status.Memory().MilliValue()) / float64(limits.Memory().MilliValue())
-		log.Debugln("Current used for", namespace, "CPU:", status.Cpu().MilliValue(), "Memory:", status.Memory().MilliValue())
-		log.Debugln("Limits for", namespace, "CPU:", limits.Cpu().MilliValue(), "Memory:", limits.Memory().MilliValue())
-		if percentCPUUsed >= threshold {
-			err := fmt.Errorf("cpu for %s namespace has reached threshold of %4.2f: USED: %d LIMIT: %d PERCENT_USED: %6.3f",
-				namespace, threshold, status.Cpu().MilliValue(), limits.Cpu().MilliValue(), percentCPUUsed)
-			c <- err.Error()
+		for _, violation := range quotaViolations(namespace, rq, threshold, resources) {
+			c <- violation
 		}
-		if percentMemoryUsed >= threshold {
-			err := fmt.Errorf("memory for %s namespace has reached threshold of %4.2f: USED: %d LIMIT: %d PERCENT_USED: %6.3f",
-				namespace, threshold, status.Memory().MilliValue(), limits.Memory().MilliValue(), percentMemoryUsed)
-			c <- err.Error()
+	}
+}
+
+// quotaViolations returns an error message for each resource of a resource quota whose usage is at least the threshold
+// of its hard limit.  Every resource the quota tracks is examined, such as requests.cpu, limits.memory or object
+// counts like pods and count/deployments.apps, unless a list of resources to examine is given.
+func quotaViolations(namespace string, rq v1.ResourceQuota, threshold float64, resources []string) []string {
+	names := make([]string, 0, len(rq.Status.Hard))
+	for name := range rq.Status.Hard {
+		if len(resources) > 0 && !contains(string(name), resources) {
+			continue
+		}
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	var violations []string
+	for _, name := range names {
+		hard := rq.Status.Hard[v1.ResourceName(name)]
+		used, ok := rq.Status.Used[v1.ResourceName(name)]
+		if !ok {
+			log.Debugln("Resource quota", rq.Name, "in", namespace, "namespace has no usage for", name)
+			continue
+		}
+		log.Debugln("Resource quota", rq.Name, "in", namespace, "namespace uses", used.String(), "of", hard.String(), name)
+
+		// a hard limit of zero forbids the resource, so nothing can be used up
+		if hard.IsZero() {
+			continue
+		}
+		percentUsed := used.AsApproximateFloat64() / hard.AsApproximateFloat64()
+		if percentUsed >= threshold {
+			violations = append(violations, fmt.Sprintf("%s for %s namespace has reached threshold of %4.2f in resource quota %s: USED: %s LIMIT: %s PERCENT_USED: %6.3f",
+				name, namespace, threshold, rq.Name, used.String(), hard.String(), percentUsed))
 		}
 	}
+	return violations
 }
 
 // fillJobChan fills the job channel with namespace jobs.
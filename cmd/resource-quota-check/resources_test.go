@@ -0,0 +1,64 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestQuotaViolations(t *testing.T) {
+	rq := v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "team-a"},
+		Status: v1.ResourceQuotaStatus{
+			Hard: v1.ResourceList{
+				"requests.cpu":               resource.MustParse("4"),
+				"limits.memory":              resource.MustParse("8Gi"),
+				"pods":                       resource.MustParse("10"),
+				"count/deployments.apps":     resource.MustParse("5"),
+				"services.loadbalancers":     resource.MustParse("0"),
+				"persistentvolumeclaims":     resource.MustParse("2"),
+				"requests.nvidia.com/gpu":    resource.MustParse("1"),
+				"count/configmaps":           resource.MustParse("100"),
+				"requests.ephemeral-storage": resource.MustParse("10Gi"),
+			},
+			Used: v1.ResourceList{
+				"requests.cpu":               resource.MustParse("3800m"),
+				"limits.memory":              resource.MustParse("4Gi"),
+				"pods":                       resource.MustParse("9"),
+				"count/deployments.apps":     resource.MustParse("5"),
+				"services.loadbalancers":     resource.MustParse("0"),
+				"persistentvolumeclaims":     resource.MustParse("1"),
+				"count/configmaps":           resource.MustParse("12"),
+				"requests.ephemeral-storage": resource.MustParse("1Gi"),
+			},
+		},
+	}
+
+	violations := quotaViolations("team-a", rq, 0.9, nil)
+	expected := []string{"count/deployments.apps for team-a", "pods for team-a", "requests.cpu for team-a"}
+	if len(violations) != len(expected) {
+		t.Fatal("expected violations for", expected, "but got", violations)
+	}
+	for i, e := range expected {
+		if !strings.HasPrefix(violations[i], e) || !strings.Contains(violations[i], "resource quota compute") {
+			t.Fatalf("expected violation %q to start with %q", violations[i], e)
+		}
+	}
+	if !strings.Contains(violations[2], "USED: 3800m LIMIT: 4") {
+		t.Fatal("expected the used and hard amounts in the violation but got", violations[2])
+	}
+
+	// only the listed resources are examined
+	violations = quotaViolations("team-a", rq, 0.9, []string{"requests.cpu", "limits.memory"})
+	if len(violations) != 1 || !strings.HasPrefix(violations[0], "requests.cpu") {
+		t.Fatal("expected only the requests.cpu violation but got", violations)
+	}
+
+	violations = quotaViolations("team-a", rq, 0.4, []string{"limits.memory"})
+	if len(violations) != 1 || !strings.HasPrefix(violations[0], "limits.memory") {
+		t.Fatal("expected the limits.memory violation at a lower threshold but got", violations)
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// checkLabel labels the pods and services created by the check, so that those left behind by earlier runs can be
+	// found and removed
+	checkLabel = "kuberhealthy-service-connectivity-check"
+
+	// cleanupTimeout is how long removing the pods and service can take after the run
+	cleanupTimeout = time.Second * 30
+)
+
+// checker creates a test service and ensures it can be reached from client pods on a sample of nodes
+type checker struct {
+	namespace       string
+	image           string           // the image of the backend and client pods, which is the image of the check
+	nodeSelector    string           // a label selector that limits the nodes clients are started on
+	sampleSize      int              // the number of nodes clients are started on
+	nodePort        bool             // also reach the service on its node port on the node of each client
+	ipFamilies      []apiv1.IPFamily // the address families of the service. the cluster's default family when empty
+	startTimeout    time.Duration    // how long the backend pod can take to become ready
+	requestTimeout  time.Duration    // how long each client keeps trying to reach the service
+	pollInterval    time.Duration
+	ownerReferences []metav1.OwnerReference
+	client          kubernetes.Interface
+}
+
+// run creates the test service and its backend, starts a client on a sample of nodes and removes them all again.  The
+// errors of the run are returned.
+func (c *checker) run(ctx context.Context) []string {
+	err := c.cleanUp(ctx)
+	if err != nil {
+		return []string{"Failed to remove the pods and services of earlier runs: " + err.Error()}
+	}
+	defer func() {
+		cleanupCtx, cancel := context.WithTimeout(context.Background(), cleanupTimeout)
+		defer cancel()
+		err := c.cleanUp(cleanupCtx)
+		if err != nil {
+			log.Errorln("Failed to remove the pods and service of this run:", err)
+		}
+	}()
+
+	nodes, err := c.sampleNodes(ctx)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	name := "service-connectivity-check-" + strconv.FormatInt(time.Now().Unix(), 10)
+	service, err := c.createBackend(ctx, name)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	return c.runClients(ctx, name, service, nodes)
+}
+
+// createBackend creates the backend pod and the service in front of it, and waits for the backend to become ready
+func (c *checker) createBackend(ctx context.Context, name string) (*apiv1.Service, error) {
+	log.Infoln("Creating backend pod", c.namespace+"/"+name)
+	_, err := c.client.CoreV1().Pods(c.namespace).Create(ctx, c.backendPod(name), metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create backend pod %s: %w", name, err)
+	}
+
+	log.Infoln("Creating service", c.namespace+"/"+name)
+	service, err := c.client.CoreV1().Services(c.namespace).Create(ctx, c.service(name), metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create service %s: %w", name, err)
+	}
+	if len(service.Spec.ClusterIP) == 0 || service.Spec.ClusterIP == apiv1.ClusterIPNone {
+		return nil, fmt.Errorf("Service %s was not assigned a ClusterIP", name)
+	}
+	if len(c.ipFamilies) > 0 && len(clusterIPs(service)) < len(c.ipFamilies) {
+		return nil, fmt.Errorf("Service %s was assigned ClusterIPs %v instead of one for each of %v", name, clusterIPs(service), c.ipFamilies)
+	}
+	if c.nodePort && (len(service.Spec.Ports) == 0 || service.Spec.Ports[0].NodePort == 0) {
+		return nil, fmt.Errorf("Service %s was not assigned a node port", name)
+	}
+	log.Infoln("Service", name, "has ClusterIPs", clusterIPs(service))
+
+	err = wait.PollUntilContextTimeout(ctx, c.pollInterval, c.startTimeout, true, func(ctx context.Context) (bool, error) {
+		pod, err := c.client.CoreV1().Pods(c.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			log.Warnln("Failed to get backend pod", name+":", err)
+			return false, nil
+		}
+		return podReady(pod), nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Backend pod %s did not become ready within %s", name, c.startTimeout)
+	}
+	return service, nil
+}
+
+// runClients starts a client pod on each node and waits for all of them to finish.  The errors the clients wrote to
+// their termination message are returned with the node they ran on.
+func (c *checker) runClients(ctx context.Context, name string, service *apiv1.Service, nodes []string) []string {
+	clients := make(map[string]string, len(nodes))
+	var errs []string
+	for i, node := range nodes {
+		clientName := fmt.Sprintf("%s-client-%d", name, i)
+		log.Infoln("Creating client pod", c.namespace+"/"+clientName, "on node", node)
+		_, err := c.client.CoreV1().Pods(c.namespace).Create(ctx, c.clientPod(clientName, node, service), metav1.CreateOptions{})
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Failed to create client pod %s on node %s: %s", clientName, node, err))
+			continue
+		}
+		clients[clientName] = node
+	}
+
+	// clients keep trying for the request timeout, so leave them time to start and report on top of that
+	results := make(map[string]*apiv1.ContainerStateTerminated, len(clients))
+	_ = wait.PollUntilContextTimeout(ctx, c.pollInterval, c.startTimeout+c.requestTimeout, true, func(ctx context.Context) (bool, error) {
+		for clientName := range clients {
+			if results[clientName] != nil {
+				continue
+			}
+			pod, err := c.client.CoreV1().Pods(c.namespace).Get(ctx, clientName, metav1.GetOptions{})
+			if err != nil {
+				log.Warnln("Failed to get client pod", clientName+":", err)
+				continue
+			}
+			for _, status := range pod.Status.ContainerStatuses {
+				if status.State.Terminated != nil {
+					results[clientName] = status.State.Terminated
+				}
+			}
+		}
+		return len(results) == len(clients), nil
+	})
+
+	names := make([]string, 0, len(clients))
+	for clientName := range clients {
+		names = append(names, clientName)
+	}
+	sort.Strings(names)
+	for _, clientName := range names {
+		node := clients[clientName]
+		result := results[clientName]
+		switch {
+		case result == nil:
+			errs = append(errs, fmt.Sprintf("Client pod %s on node %s did not finish within %s", clientName, node, c.startTimeout+c.requestTimeout))
+		case result.ExitCode != 0:
+			message := strings.TrimSpace(result.Message)
+			if len(message) == 0 {
+				message = fmt.Sprintf("client exited with code %d: %s", result.ExitCode, result.Reason)
+			}
+			for _, line := range strings.Split(message, "\n") {
+				errs = append(errs, fmt.Sprintf("Service %s is not reachable from node %s: %s", name, node, line))
+			}
+		default:
+			log.Infoln("Service", name, "is reachable from node", node)
+		}
+	}
+	return errs
+}
+
+// sampleNodes returns the names of up to sampleSize random nodes that are ready, schedulable and match the node
+// selector
+func (c *checker) sampleNodes(ctx context.Context) ([]string, error) {
+	nodeList, err := c.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: c.nodeSelector})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list nodes: %w", err)
+	}
+
+	var nodes []string
+	for _, node := range nodeList.Items {
+		if node.Spec.Unschedulable || !nodeReady(&node) {
+			continue
+		}
+		nodes = append(nodes, node.Name)
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("Found no ready and schedulable nodes to reach the service from")
+	}
+
+	rand.Shuffle(len(nodes), func(i, j int) { nodes[i], nodes[j] = nodes[j], nodes[i] })
+	if len(nodes) > c.sampleSize {
+		nodes = nodes[:c.sampleSize]
+	}
+	sort.Strings(nodes)
+	return nodes, nil
+}
+
+// nodeReady determines if the Ready condition of a node is true
+func nodeReady(node *apiv1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == apiv1.NodeReady {
+			return condition.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podReady determines if the Ready condition of a pod is true
+func podReady(pod *apiv1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == apiv1.PodReady {
+			return condition.Status == apiv1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// service returns the test service in front of the backend pod.  It is a NodePort service when the node port is
+// checked too.
+func (c *checker) service(name string) *apiv1.Service {
+	serviceType := apiv1.ServiceTypeClusterIP
+	if c.nodePort {
+		serviceType = apiv1.ServiceTypeNodePort
+	}
+	service := &apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       c.namespace,
+			Labels:          map[string]string{checkLabel: name},
+			OwnerReferences: c.ownerReferences,
+		},
+		Spec: apiv1.ServiceSpec{
+			Type:     serviceType,
+			Selector: map[string]string{checkLabel: name},
+			Ports: []apiv1.ServicePort{{
+				Name:       "http",
+				Protocol:   apiv1.ProtocolTCP,
+				Port:       serverPort,
+				TargetPort: intstr.FromInt(serverPort),
+			}},
+		},
+	}
+
+	// a service with two families is given a ClusterIP of each, so that both are reached by the clients
+	if len(c.ipFamilies) > 0 {
+		policy := apiv1.IPFamilyPolicySingleStack
+		if len(c.ipFamilies) > 1 {
+			policy = apiv1.IPFamilyPolicyRequireDualStack
+		}
+		service.Spec.IPFamilies = c.ipFamilies
+		service.Spec.IPFamilyPolicy = &policy
+	}
+	return service
+}
+
+// clusterIPs returns the ClusterIPs of a service, with the primary ClusterIP first
+func clusterIPs(service *apiv1.Service) []string {
+	if len(service.Spec.ClusterIPs) > 0 {
+		return service.Spec.ClusterIPs
+	}
+	return []string{service.Spec.ClusterIP}
+}
+
+// backendPod returns the pod behind the test service
+func (c *checker) backendPod(name string) *apiv1.Pod {
+	pod := c.pod(name, "server")
+	pod.Spec.Containers[0].Ports = []apiv1.ContainerPort{{Name: "http", ContainerPort: serverPort, Protocol: apiv1.ProtocolTCP}}
+	pod.Spec.Containers[0].ReadinessProbe = &apiv1.Probe{
+		ProbeHandler: apiv1.ProbeHandler{
+			HTTPGet: &apiv1.HTTPGetAction{Path: "/", Port: intstr.FromInt(serverPort)},
+		},
+		PeriodSeconds: 1,
+	}
+	return pod
+}
+
+// clientPod returns the pod that reaches the service from a node.  The pod is bound to the node directly and
+// tolerates every taint, so that nodes reserved for some workloads are checked too.  It is not labeled like the
+// backend, so that the service does not select it.
+func (c *checker) clientPod(name string, node string, service *apiv1.Service) *apiv1.Pod {
+	pod := c.pod(name, "client")
+	pod.Labels = map[string]string{checkLabel: "client"}
+	pod.Spec.NodeName = node
+	pod.Spec.Tolerations = []apiv1.Toleration{{Operator: apiv1.TolerationOpExists}}
+	env := []apiv1.EnvVar{
+		{Name: "CLUSTER_IPS", Value: strings.Join(clusterIPs(service), ",")},
+		{Name: "REQUEST_TIMEOUT", Value: c.requestTimeout.String()},
+	}
+	if c.nodePort {
+		env = append(env,
+			apiv1.EnvVar{Name: "NODE_PORT", Value: strconv.Itoa(int(service.Spec.Ports[0].NodePort))},
+			apiv1.EnvVar{Name: "HOST_IP", ValueFrom: &apiv1.EnvVarSource{FieldRef: &apiv1.ObjectFieldSelector{FieldPath: "status.hostIP"}}},
+		)
+	}
+	pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, env...)
+	return pod
+}
+
+// pod returns a pod that runs the check image in the supplied mode
+func (c *checker) pod(name string, mode string) *apiv1.Pod {
+	automount := false
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       c.namespace,
+			Labels:          map[string]string{checkLabel: name},
+			OwnerReferences: c.ownerReferences,
+		},
+		Spec: apiv1.PodSpec{
+			RestartPolicy:                apiv1.RestartPolicyNever,
+			AutomountServiceAccountToken: &automount,
+			Containers: []apiv1.Container{{
+				Name:                     mode,
+				Image:                    c.image,
+				ImagePullPolicy:          apiv1.PullIfNotPresent,
+				Env:                      []apiv1.EnvVar{{Name: "MODE", Value: mode}},
+				TerminationMessagePolicy: apiv1.TerminationMessageReadFile,
+				Resources: apiv1.ResourceRequirements{
+					Requests: apiv1.ResourceList{
+						apiv1.ResourceCPU:    resource.MustParse("10m"),
+						apiv1.ResourceMemory: resource.MustParse("20Mi"),
+					},
+				},
+			}},
+		},
+	}
+}
+
+// cleanUp removes the pods and services created by the check
+func (c *checker) cleanUp(ctx context.Context) error {
+	services, err := c.client.CoreV1().Services(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: checkLabel})
+	if err != nil {
+		return err
+	}
+	for _, service := range services.Items {
+		log.Infoln("Removing service", service.Name)
+		err := c.client.CoreV1().Services(c.namespace).Delete(ctx, service.Name, metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	pods, err := c.client.CoreV1().Pods(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: checkLabel})
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods.Items {
+		log.Infoln("Removing pod", pod.Name)
+		err := c.client.CoreV1().Pods(c.namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
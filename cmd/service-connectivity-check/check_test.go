@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// testNode returns a ready node
+func testNode(name string) *apiv1.Node {
+	return &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     apiv1.NodeStatus{Conditions: []apiv1.NodeCondition{{Type: apiv1.NodeReady, Status: apiv1.ConditionTrue}}},
+	}
+}
+
+// newTestClient returns a fake client with the supplied objects that assigns a ClusterIP of each IP family and a node
+// port to services when they are created, like the API server would
+func newTestClient(objects ...runtime.Object) *fake.Clientset {
+	client := fake.NewSimpleClientset(objects...)
+	client.PrependReactor("create", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		service := action.(k8stesting.CreateAction).GetObject().(*apiv1.Service)
+		service.Spec.ClusterIPs = []string{"10.96.0.42"}
+		if len(service.Spec.IPFamilies) > 0 {
+			service.Spec.ClusterIPs = nil
+			for _, family := range service.Spec.IPFamilies {
+				if family == apiv1.IPv6Protocol {
+					service.Spec.ClusterIPs = append(service.Spec.ClusterIPs, "fd00:10:96::42")
+					continue
+				}
+				service.Spec.ClusterIPs = append(service.Spec.ClusterIPs, "10.96.0.42")
+			}
+		}
+		service.Spec.ClusterIP = service.Spec.ClusterIPs[0]
+		if service.Spec.Type == apiv1.ServiceTypeNodePort {
+			service.Spec.Ports[0].NodePort = 30042
+		}
+		return false, nil, nil
+	})
+	return client
+}
+
+// newTestChecker returns a checker with short timeouts and a fake client
+func newTestChecker(client *fake.Clientset) *checker {
+	return &checker{
+		namespace:      "kuberhealthy",
+		image:          "kuberhealthy/service-connectivity-check:test",
+		sampleSize:     3,
+		startTimeout:   time.Millisecond * 200,
+		requestTimeout: time.Millisecond * 100,
+		pollInterval:   time.Millisecond * 10,
+		client:         client,
+	}
+}
+
+// simulateKubelet makes the backend pod ready and terminates each client pod with the result for its node, like a
+// kubelet running them would
+func simulateKubelet(t *testing.T, client *fake.Clientset, results map[string]apiv1.ContainerStateTerminated) {
+	go func() {
+		ctx := context.Background()
+		for i := 0; i < 100; i++ {
+			time.Sleep(time.Millisecond * 5)
+			pods, err := client.CoreV1().Pods("kuberhealthy").List(ctx, metav1.ListOptions{})
+			if err != nil {
+				continue
+			}
+			for _, pod := range pods.Items {
+				if len(pod.Status.Conditions) > 0 || len(pod.Status.ContainerStatuses) > 0 {
+					continue
+				}
+				switch pod.Spec.Containers[0].Name {
+				case "server":
+					pod.Status.Conditions = []apiv1.PodCondition{{Type: apiv1.PodReady, Status: apiv1.ConditionTrue}}
+				case "client":
+					result, ok := results[pod.Spec.NodeName]
+					if !ok {
+						continue
+					}
+					pod.Status.ContainerStatuses = []apiv1.ContainerStatus{{Name: "client", State: apiv1.ContainerState{Terminated: &result}}}
+				}
+				_, err := client.CoreV1().Pods("kuberhealthy").UpdateStatus(ctx, &pod, metav1.UpdateOptions{})
+				if err != nil {
+					t.Log("failed to update pod status:", err)
+				}
+			}
+		}
+	}()
+}
+
+// assertCleanedUp ensures the pods and service were removed
+func assertCleanedUp(t *testing.T, client *fake.Clientset) {
+	pods, _ := client.CoreV1().Pods("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if len(pods.Items) != 0 {
+		t.Fatal("expected the pods to be removed but found", len(pods.Items))
+	}
+	services, _ := client.CoreV1().Services("kuberhealthy").List(context.Background(), metav1.ListOptions{})
+	if len(services.Items) != 0 {
+		t.Fatal("expected the service to be removed but found", len(services.Items))
+	}
+}
+
+func TestCheckerRun(t *testing.T) {
+	client := newTestClient(
+		testNode("node-a"),
+		testNode("node-b"),
+		// a service left behind by an earlier run is removed
+		&apiv1.Service{ObjectMeta: metav1.ObjectMeta{Name: "service-connectivity-check-1", Namespace: "kuberhealthy", Labels: map[string]string{checkLabel: "service-connectivity-check-1"}}},
+	)
+	c := newTestChecker(client)
+	c.nodePort = true
+
+	var clientPods []*apiv1.Pod
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		pod := action.(k8stesting.CreateAction).GetObject().(*apiv1.Pod)
+		if pod.Spec.Containers[0].Name == "client" {
+			clientPods = append(clientPods, pod.DeepCopy())
+		}
+		return false, nil, nil
+	})
+	simulateKubelet(t, client, map[string]apiv1.ContainerStateTerminated{
+		"node-a": {ExitCode: 0},
+		"node-b": {ExitCode: 0},
+	})
+
+	errs := c.run(context.Background())
+	if len(errs) != 0 {
+		t.Fatal("expected the check to pass but got", errs)
+	}
+	if len(clientPods) != 2 {
+		t.Fatal("expected a client on each node but got", len(clientPods))
+	}
+	env := make(map[string]string)
+	for _, e := range clientPods[0].Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	if env["MODE"] != "client" || env["CLUSTER_IPS"] != "10.96.0.42" || env["NODE_PORT"] != "30042" {
+		t.Fatal("expected the client to reach the ClusterIP and node port but got", env)
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestCheckerRunDualStack(t *testing.T) {
+	client := newTestClient(testNode("node-a"))
+	c := newTestChecker(client)
+	c.ipFamilies = []apiv1.IPFamily{apiv1.IPv6Protocol, apiv1.IPv4Protocol}
+
+	var clientPod *apiv1.Pod
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		pod := action.(k8stesting.CreateAction).GetObject().(*apiv1.Pod)
+		if pod.Spec.Containers[0].Name == "client" {
+			clientPod = pod.DeepCopy()
+		}
+		return false, nil, nil
+	})
+	simulateKubelet(t, client, map[string]apiv1.ContainerStateTerminated{"node-a": {ExitCode: 0}})
+
+	errs := c.run(context.Background())
+	if len(errs) != 0 {
+		t.Fatal("expected the check to pass but got", errs)
+	}
+	for _, e := range clientPod.Spec.Containers[0].Env {
+		if e.Name == "CLUSTER_IPS" && e.Value != "fd00:10:96::42,10.96.0.42" {
+			t.Fatal("expected the client to reach the ClusterIP of each family, IPv6 first, but got", e.Value)
+		}
+	}
+	assertCleanedUp(t, client)
+
+	// a cluster without dual-stack assigns a single ClusterIP
+	client = fake.NewSimpleClientset(testNode("node-a"))
+	client.PrependReactor("create", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		service := action.(k8stesting.CreateAction).GetObject().(*apiv1.Service)
+		service.Spec.ClusterIP = "10.96.0.42"
+		return false, nil, nil
+	})
+	c = newTestChecker(client)
+	c.ipFamilies = []apiv1.IPFamily{apiv1.IPv4Protocol, apiv1.IPv6Protocol}
+	errs = c.run(context.Background())
+	if len(errs) != 1 || !strings.Contains(errs[0], "instead of one for each of [IPv4 IPv6]") {
+		t.Fatal("expected a service without a ClusterIP of each family to fail but got", errs)
+	}
+}
+
+func TestCheckerRunUnreachable(t *testing.T) {
+	client := newTestClient(testNode("node-a"), testNode("node-b"), testNode("node-c"))
+	c := newTestChecker(client)
+	simulateKubelet(t, client, map[string]apiv1.ContainerStateTerminated{
+		"node-a": {ExitCode: 0},
+		"node-b": {ExitCode: 1, Message: "Failed to reach http://10.96.0.42:8080/: connection refused"},
+		// node-c never finishes
+	})
+
+	errs := c.run(context.Background())
+	if len(errs) != 2 {
+		t.Fatal("expected an error for each node the service can not be reached from but got", errs)
+	}
+	if !strings.Contains(errs[0], "not reachable from node node-b: Failed to reach http://10.96.0.42:8080/: connection refused") {
+		t.Fatal("expected the error of the client on node-b but got", errs[0])
+	}
+	if !strings.Contains(errs[1], "on node node-c did not finish") {
+		t.Fatal("expected the client on node-c to time out but got", errs[1])
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestCheckerRunBackendNotReady(t *testing.T) {
+	client := newTestClient(testNode("node-a"))
+	c := newTestChecker(client)
+
+	errs := c.run(context.Background())
+	if len(errs) != 1 || !strings.Contains(errs[0], "did not become ready") {
+		t.Fatal("expected the backend to not become ready but got", errs)
+	}
+	assertCleanedUp(t, client)
+}
+
+func TestServicePods(t *testing.T) {
+	c := newTestChecker(nil)
+	backend := c.backendPod("service-connectivity-check-1")
+	service := c.service("service-connectivity-check-1")
+	for k, v := range service.Spec.Selector {
+		if backend.Labels[k] != v {
+			t.Fatal("expected the service to select the backend pod but got selector", service.Spec.Selector, "and labels", backend.Labels)
+		}
+	}
+	if service.Spec.Type != apiv1.ServiceTypeClusterIP {
+		t.Fatal("expected a ClusterIP service but got", service.Spec.Type)
+	}
+
+	client := c.clientPod("service-connectivity-check-1-client-0", "node-a", service)
+	for k, v := range service.Spec.Selector {
+		if client.Labels[k] == v {
+			t.Fatal("expected the service to not select the client pod but got labels", client.Labels)
+		}
+	}
+	if client.Spec.NodeName != "node-a" || client.Spec.Containers[0].TerminationMessagePolicy != apiv1.TerminationMessageReadFile {
+		t.Fatal("expected the client to be bound to its node and report through its termination message")
+	}
+
+	c.nodePort = true
+	if c.service("service-connectivity-check-1").Spec.Type != apiv1.ServiceTypeNodePort {
+		t.Fatal("expected a NodePort service when the node port is checked")
+	}
+
+	if service.Spec.IPFamilyPolicy != nil || len(service.Spec.IPFamilies) != 0 {
+		t.Fatal("expected the cluster's default IP family without IP families but got", service.Spec.IPFamilies)
+	}
+	c.ipFamilies = []apiv1.IPFamily{apiv1.IPv6Protocol}
+	service = c.service("service-connectivity-check-1")
+	if *service.Spec.IPFamilyPolicy != apiv1.IPFamilyPolicySingleStack || service.Spec.IPFamilies[0] != apiv1.IPv6Protocol {
+		t.Fatal("expected a single stack IPv6 service but got", *service.Spec.IPFamilyPolicy, service.Spec.IPFamilies)
+	}
+	c.ipFamilies = []apiv1.IPFamily{apiv1.IPv4Protocol, apiv1.IPv6Protocol}
+	if *c.service("service-connectivity-check-1").Spec.IPFamilyPolicy != apiv1.IPFamilyPolicyRequireDualStack {
+		t.Fatal("expected a dual-stack service with two IP families")
+	}
+}
@@ -0,0 +1,211 @@
+// Package main implements a check that creates a test service and ensures its ClusterIP, and optionally its node port,
+// can be reached from client pods on a sample of nodes.  The same binary runs as the backend of the service and as the
+// clients, depending on MODE.
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+
+	checkclient "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/nodeCheck"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/util"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
+)
+
+const (
+	// defaultImage is the image of the backend and client pods
+	defaultImage = "kuberhealthy/service-connectivity-check:v1.0.0"
+
+	// defaultNamespace is the namespace the pods and service are created in when the check's namespace can not be
+	// found
+	defaultNamespace = "kuberhealthy"
+
+	// defaultSampleSize is the number of nodes clients are started on
+	defaultSampleSize = 3
+
+	// defaultStartTimeout is how long the backend and client pods can take to start
+	defaultStartTimeout = time.Minute * 2
+
+	// defaultRequestTimeout is how long each client keeps trying to reach the service
+	defaultRequestTimeout = time.Minute
+)
+
+// kubeConfigFile is a variable containing file path of Kubernetes config files
+var kubeConfigFile = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+
+func init() {
+	// set debug mode for nodeCheck pkg
+	nodeCheck.EnableDebugOutput()
+}
+
+func main() {
+	// the backend and client pods run the same image as the check
+	switch os.Getenv("MODE") {
+	case "server":
+		log.Fatalln(runServer())
+	case "client":
+		os.Exit(clientMain())
+	}
+
+	checker, err := newCheckerFromEnv()
+	if err != nil {
+		log.Errorln("Error parsing the check configuration:", err)
+		reportToKuberhealthy([]string{err.Error()})
+		return
+	}
+
+	// leave time to clean up and report before the deadline of the run
+	ctx := context.Background()
+	deadline, err := checkclient.GetDeadline()
+	if err != nil {
+		log.Warnln("There was an issue getting the check deadline:", err.Error())
+	} else {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-time.Second*30))
+		defer cancel()
+	}
+
+	client, err := kubeClient.Create(kubeConfigFile)
+	if err != nil {
+		reportToKuberhealthy([]string{"Failed to create a kubernetes client: " + err.Error()})
+		return
+	}
+	checker.client = client
+
+	// the pods and service are owned by the checker pod, so that they are garbage collected if cleanup is interrupted
+	checker.ownerReferences, err = util.GetOwnerRef(client, checker.namespace)
+	if err != nil {
+		log.Warnln("Failed to get the owner reference of the checker pod:", err)
+	}
+
+	// hits kuberhealthy endpoint to see if node is ready
+	err = nodeCheck.WaitForKuberhealthy(ctx)
+	if err != nil {
+		log.Errorln("Error waiting for kuberhealthy endpoint to be contactable by checker pod with error:" + err.Error())
+	}
+
+	reportToKuberhealthy(checker.run(ctx))
+}
+
+// clientMain reaches the service at the addresses in the environment of a client pod and returns the exit code of the
+// client
+func clientMain() int {
+	timeout, err := time.ParseDuration(os.Getenv("REQUEST_TIMEOUT"))
+	if err != nil {
+		timeout = defaultRequestTimeout
+	}
+
+	// a dual-stack service is reached on the ClusterIP of each of its address families
+	var urls []string
+	for _, ip := range strings.Split(os.Getenv("CLUSTER_IPS"), ",") {
+		urls = append(urls, fmt.Sprintf("http://%s/", net.JoinHostPort(ip, strconv.Itoa(serverPort))))
+	}
+	if nodePort := os.Getenv("NODE_PORT"); len(nodePort) > 0 {
+		urls = append(urls, fmt.Sprintf("http://%s/", net.JoinHostPort(os.Getenv("HOST_IP"), nodePort)))
+	}
+
+	errs := runClient(urls, timeout, time.Second)
+	if len(errs) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// newCheckerFromEnv creates a checker from the environment variables of the checker pod
+func newCheckerFromEnv() (*checker, error) {
+	c := &checker{
+		namespace:      util.GetInstanceNamespace(defaultNamespace),
+		image:          defaultImage,
+		nodeSelector:   os.Getenv("NODE_SELECTOR"),
+		sampleSize:     defaultSampleSize,
+		startTimeout:   defaultStartTimeout,
+		requestTimeout: defaultRequestTimeout,
+		pollInterval:   time.Second * 2,
+	}
+	if image := os.Getenv("CHECK_IMAGE"); len(image) > 0 {
+		c.image = image
+	}
+	if namespace := os.Getenv("CHECK_NAMESPACE"); len(namespace) > 0 {
+		c.namespace = namespace
+	}
+
+	var err error
+	if v := os.Getenv("NODE_SAMPLE_SIZE"); len(v) > 0 {
+		c.sampleSize, err = strconv.Atoi(v)
+		if err != nil || c.sampleSize < 1 {
+			return nil, fmt.Errorf("NODE_SAMPLE_SIZE must be a positive number, got %q", v)
+		}
+	}
+	if v := os.Getenv("CHECK_NODE_PORT"); len(v) > 0 {
+		c.nodePort, err = strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CHECK_NODE_PORT: %w", err)
+		}
+	}
+	if v := os.Getenv("IP_FAMILIES"); len(v) > 0 {
+		c.ipFamilies, err = parseIPFamilies(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP_FAMILIES: %w", err)
+		}
+	}
+	if v := os.Getenv("START_TIMEOUT"); len(v) > 0 {
+		c.startTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid START_TIMEOUT: %w", err)
+		}
+	}
+	if v := os.Getenv("REQUEST_TIMEOUT"); len(v) > 0 {
+		c.requestTimeout, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REQUEST_TIMEOUT: %w", err)
+		}
+	}
+
+	log.Infoln("Reaching a test service from", c.sampleSize, "nodes in namespace", c.namespace, "with node port:", c.nodePort, "and IP families:", c.ipFamilies)
+	return c, nil
+}
+
+// parseIPFamilies parses a comma separated list of address families, IPv4 and IPv6, in the order the ClusterIPs of the
+// service are assigned in
+func parseIPFamilies(s string) ([]apiv1.IPFamily, error) {
+	var families []apiv1.IPFamily
+	for _, family := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(family)) {
+		case "ipv4":
+			families = append(families, apiv1.IPv4Protocol)
+		case "ipv6":
+			families = append(families, apiv1.IPv6Protocol)
+		default:
+			return nil, fmt.Errorf("unknown IP family %q, expected IPv4 or IPv6", family)
+		}
+	}
+	if len(families) > 2 || (len(families) == 2 && families[0] == families[1]) {
+		return nil, fmt.Errorf("expected IPv4, IPv6 or both, got %q", s)
+	}
+	return families, nil
+}
+
+// reportToKuberhealthy reports the errors of the run to Kuberhealthy, or success when there are none
+func reportToKuberhealthy(errorMessages []string) {
+	var err error
+	if len(errorMessages) == 0 {
+		err = checkclient.ReportSuccess()
+	} else {
+		log.Errorln("Reporting errors to Kuberhealthy:", errorMessages)
+		err = checkclient.ReportFailure(errorMessages)
+	}
+	if err != nil {
+		log.Fatalln("error reporting to kuberhealthy:", err.Error())
+	}
+	log.Infoln("Successfully reported to Kuberhealthy")
+}
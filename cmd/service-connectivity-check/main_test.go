@@ -0,0 +1,25 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+func TestParseIPFamilies(t *testing.T) {
+	families, err := parseIPFamilies("ipv6, IPv4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(families, []apiv1.IPFamily{apiv1.IPv6Protocol, apiv1.IPv4Protocol}) {
+		t.Fatal("expected IPv6 then IPv4 but got", families)
+	}
+
+	for _, s := range []string{"IPv5", "IPv4,IPv4", "IPv4,IPv6,IPv4", ""} {
+		_, err := parseIPFamilies(s)
+		if err == nil {
+			t.Fatal("expected IP families", s, "to be invalid")
+		}
+	}
+}
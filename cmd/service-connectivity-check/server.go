@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// serverPort is the port the backend pod serves on and the port of the service in front of it
+	serverPort = 8080
+
+	// serverResponse is the body the backend pod responds with, so that clients know they reached it and not
+	// something else listening on the address
+	serverResponse = "kuberhealthy-service-connectivity-check"
+
+	// terminationLogPath is where clients write the result of their requests, so that the checker can read it from
+	// the status of their pod
+	terminationLogPath = "/dev/termination-log"
+)
+
+// runServer serves the backend of the test service until it is stopped
+func runServer() error {
+	log.Infoln("Serving the test service backend on port", serverPort)
+	return http.ListenAndServe(fmt.Sprintf(":%d", serverPort), newServerHandler())
+}
+
+// newServerHandler returns the handler of the backend pod.  Every request is answered with the serverResponse.
+func newServerHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(serverResponse))
+		if err != nil {
+			log.Warnln("Failed to respond to", r.RemoteAddr+":", err)
+		}
+	})
+}
+
+// runClient requests each of the supplied urls until it reaches the backend or the timeout passes.  The errors are
+// written to the termination log and returned.
+func runClient(urls []string, timeout time.Duration, retryInterval time.Duration) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []string
+	for _, u := range urls {
+		err := requestUntilReached(ctx, u, retryInterval)
+		if err != nil {
+			log.Errorln(err)
+			errs = append(errs, err.Error())
+			continue
+		}
+		log.Infoln("Reached the test service at", u)
+	}
+
+	// the checker reads the errors from the termination message of the pod
+	message := strings.Join(errs, "\n")
+	err := os.WriteFile(terminationLogPath, []byte(message), 0644)
+	if err != nil {
+		log.Warnln("Failed to write the termination log:", err)
+	}
+	return errs
+}
+
+// requestUntilReached requests a url until it responds with the serverResponse or the context ends, and returns the
+// error of the last attempt
+func requestUntilReached(ctx context.Context, u string, retryInterval time.Duration) error {
+	client := &http.Client{Timeout: time.Second * 5}
+	var err error
+	for {
+		err = request(ctx, client, u)
+		if err == nil {
+			return nil
+		}
+		log.Debugln("Failed to reach", u+", retrying:", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Failed to reach %s: %w", u, err)
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// request requests a url once and ensures it was answered by the backend pod
+func request(ctx context.Context, client *http.Client, u string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK || string(body) != serverResponse {
+		return fmt.Errorf("unexpected response with status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestUntilReached(t *testing.T) {
+	server := httptest.NewServer(newServerHandler())
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := requestUntilReached(ctx, server.URL, time.Millisecond*10)
+	if err != nil {
+		t.Fatal("expected the backend to be reached but got", err)
+	}
+
+	// something other than the backend answering on the address does not count
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer other.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	err = requestUntilReached(ctx, other.URL, time.Millisecond*10)
+	if err == nil || !strings.Contains(err.Error(), "unexpected response") {
+		t.Fatal("expected a response from something other than the backend to fail but got", err)
+	}
+
+	// an address nothing listens on fails once the timeout passes
+	other.Close()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	err = requestUntilReached(ctx, other.URL, time.Millisecond*10)
+	if err == nil || !strings.Contains(err.Error(), "Failed to reach "+other.URL) {
+		t.Fatal("expected an unreachable address to fail but got", err)
+	}
+}
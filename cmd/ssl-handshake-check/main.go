@@ -6,6 +6,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/url"
 	"os"
 	"strconv"
@@ -130,7 +131,7 @@ func (shc *Checker) runHandshake(ctx context.Context, cancel context.CancelFunc,
 }
 
 func (shc *Checker) doChecks() error {
-	siteURL, err := url.Parse("https://" + domainName + ":" + portNum)
+	siteURL, err := url.Parse("https://" + net.JoinHostPort(domainName, portNum))
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+)
+
+// namespaceDeletionConditions are the conditions the namespace controller sets on a terminating namespace to explain
+// what is keeping it from being removed
+var namespaceDeletionConditions = map[apiv1.NamespaceConditionType]bool{
+	apiv1.NamespaceDeletionDiscoveryFailure: true,
+	apiv1.NamespaceDeletionGVParsingFailure: true,
+	apiv1.NamespaceDeletionContentFailure:   true,
+	apiv1.NamespaceContentRemaining:         true,
+	apiv1.NamespaceFinalizersRemaining:      true,
+}
+
+// checker finds namespaces, and objects of other resources, that have been terminating for longer than allowed
+type checker struct {
+	maxTerminating time.Duration                 // how long an object can be terminating before it is stuck
+	resources      []schema.GroupVersionResource // the resources whose objects are checked besides namespaces
+	client         kubernetes.Interface
+	metadataClient metadata.Interface
+}
+
+// stuckObject is an object that has been terminating for longer than allowed
+type stuckObject struct {
+	resource    string // the resource of the object, such as namespaces
+	namespace   string
+	name        string
+	terminating time.Duration // how long the object has been terminating
+	finalizers  []string      // the finalizers that keep the object from being removed
+	reasons     []string      // why the namespace controller can not finish removing a namespace
+}
+
+// key returns the resource, namespace and name of the object, which is its key in the details of the report
+func (o stuckObject) key() string {
+	if len(o.namespace) == 0 {
+		return o.resource + "/" + o.name
+	}
+	return o.resource + "/" + o.namespace + "/" + o.name
+}
+
+// String describes the object, how long it has been terminating and what is keeping it from being removed
+func (o stuckObject) String() string {
+	finalizers := "no finalizers"
+	if len(o.finalizers) > 0 {
+		finalizers = "finalizers " + strings.Join(o.finalizers, ", ")
+	}
+	message := fmt.Sprintf("%s has been terminating for %s with %s", o.key(), o.terminating.Round(time.Second), finalizers)
+	if len(o.reasons) > 0 {
+		message += ": " + strings.Join(o.reasons, "; ")
+	}
+	return message
+}
+
+// run finds the stuck objects and returns an error for each of them, and the finalizers of as many of them as fit in
+// the details of a report
+func (c *checker) run(ctx context.Context) ([]string, map[string]string) {
+	var errs []string
+	var stuck []stuckObject
+
+	namespaces, err := c.stuckNamespaces(ctx)
+	if err != nil {
+		errs = append(errs, "Failed to list namespaces: "+err.Error())
+	}
+	stuck = append(stuck, namespaces...)
+
+	for _, resource := range c.resources {
+		objects, err := c.stuckObjects(ctx, resource)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("Failed to list %s: %s", resource.String(), err))
+			continue
+		}
+		stuck = append(stuck, objects...)
+	}
+
+	log.Infoln("Found", len(stuck), "objects terminating for longer than", c.maxTerminating)
+	sort.Slice(stuck, func(i, j int) bool { return stuck[i].key() < stuck[j].key() })
+	for _, o := range stuck {
+		errs = append(errs, o.String())
+	}
+	return errs, details(stuck)
+}
+
+// stuckNamespaces returns the namespaces that have been terminating for longer than allowed, along with the
+// conditions that explain why
+func (c *checker) stuckNamespaces(ctx context.Context) ([]stuckObject, error) {
+	namespaces, err := c.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var stuck []stuckObject
+	for _, ns := range namespaces.Items {
+		terminating, ok := c.terminatingTooLong(ns.DeletionTimestamp)
+		if !ok {
+			continue
+		}
+		o := stuckObject{resource: "namespaces", name: ns.Name, terminating: terminating}
+		for _, finalizer := range ns.Spec.Finalizers {
+			o.finalizers = append(o.finalizers, string(finalizer))
+		}
+		o.finalizers = append(o.finalizers, ns.Finalizers...)
+		for _, condition := range ns.Status.Conditions {
+			if namespaceDeletionConditions[condition.Type] && condition.Status == apiv1.ConditionTrue {
+				o.reasons = append(o.reasons, fmt.Sprintf("%s: %s", condition.Type, condition.Message))
+			}
+		}
+		stuck = append(stuck, o)
+	}
+	return stuck, nil
+}
+
+// stuckObjects returns the objects of a resource in every namespace that have been terminating for longer than
+// allowed.  Only the metadata of the objects is listed.
+func (c *checker) stuckObjects(ctx context.Context, resource schema.GroupVersionResource) ([]stuckObject, error) {
+	objects, err := c.metadataClient.Resource(resource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var stuck []stuckObject
+	for _, object := range objects.Items {
+		terminating, ok := c.terminatingTooLong(object.DeletionTimestamp)
+		if !ok {
+			continue
+		}
+		stuck = append(stuck, stuckObject{
+			resource:    resource.GroupResource().String(),
+			namespace:   object.Namespace,
+			name:        object.Name,
+			terminating: terminating,
+			finalizers:  object.Finalizers,
+		})
+	}
+	return stuck, nil
+}
+
+// terminatingTooLong returns how long an object with the supplied deletion timestamp has been terminating, and
+// whether that is longer than allowed
+func (c *checker) terminatingTooLong(deletionTimestamp *metav1.Time) (time.Duration, bool) {
+	if deletionTimestamp == nil {
+		return 0, false
+	}
+	terminating := time.Since(deletionTimestamp.Time)
+	return terminating, terminating > c.maxTerminating
+}
+
+// details returns the finalizers of the stuck objects keyed by the objects.  Objects that do not fit in the size
+// limits of the details of a report are left out, but are still reported as errors.
+func details(stuck []stuckObject) map[string]string {
+	details := make(map[string]string)
+	var size int
+	for _, o := range stuck {
+		value := strings.Join(o.finalizers, ",")
+		if len(value) == 0 {
+			value = "none"
+		}
+		if len(details) == status.MaxDetails || size+len(o.key())+len(value) > status.MaxDetailsSize {
+			log.Warnln("Leaving the finalizers of", len(stuck)-len(details), "stuck objects out of the report details")
+			break
+		}
+		details[o.key()] = value
+		size += len(o.key()) + len(value)
+	}
+	return details
+}
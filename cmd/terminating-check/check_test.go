@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	metadatafake "k8s.io/client-go/metadata/fake"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+)
+
+var pvcResource = schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+
+// testNamespace returns a namespace that has been terminating for the supplied duration, or is active when it is zero
+func testNamespace(name string, terminating time.Duration) *apiv1.Namespace {
+	ns := &apiv1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if terminating > 0 {
+		deleted := metav1.NewTime(time.Now().Add(-terminating))
+		ns.DeletionTimestamp = &deleted
+		ns.Spec.Finalizers = []apiv1.FinalizerName{apiv1.FinalizerKubernetes}
+		ns.Status.Phase = apiv1.NamespaceTerminating
+	}
+	return ns
+}
+
+// testPVC returns the metadata of a persistent volume claim that has been terminating for the supplied duration
+func testPVC(namespace string, name string, terminating time.Duration) *metav1.PartialObjectMetadata {
+	deleted := metav1.NewTime(time.Now().Add(-terminating))
+	return &metav1.PartialObjectMetadata{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PersistentVolumeClaim"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         namespace,
+			DeletionTimestamp: &deleted,
+			Finalizers:        []string{"kubernetes.io/pvc-protection"},
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	stuck := testNamespace("team-a", time.Hour)
+	stuck.Status.Conditions = []apiv1.NamespaceCondition{
+		{
+			Type:    apiv1.NamespaceDeletionDiscoveryFailure,
+			Status:  apiv1.ConditionTrue,
+			Message: "Discovery failed for some groups, 1 failing: metrics.k8s.io/v1beta1: the server is currently unable to handle the request",
+		},
+		{Type: apiv1.NamespaceDeletionContentFailure, Status: apiv1.ConditionFalse, Message: "All content successfully deleted"},
+	}
+	client := fake.NewSimpleClientset(stuck, testNamespace("team-b", time.Minute), testNamespace("team-c", 0))
+	scheme := metadatafake.NewTestScheme()
+	metav1.AddMetaToScheme(scheme)
+	metadataClient := metadatafake.NewSimpleMetadataClient(scheme,
+		testPVC("team-b", "data", time.Hour*2),
+		testPVC("team-b", "cache", time.Minute),
+	)
+
+	c := &checker{maxTerminating: time.Minute * 15, resources: []schema.GroupVersionResource{pvcResource}, client: client, metadataClient: metadataClient}
+	errs, details := c.run(context.Background())
+	if len(errs) != 2 {
+		t.Fatal("expected the stuck namespace and persistent volume claim to be reported but got", errs)
+	}
+	if !strings.HasPrefix(errs[0], "namespaces/team-a has been terminating for 1h0m0s with finalizers kubernetes: NamespaceDeletionDiscoveryFailure: Discovery failed") {
+		t.Fatal("expected the stuck namespace with its finalizers and the reason it is stuck but got", errs[0])
+	}
+	if strings.Contains(errs[0], "NamespaceDeletionContentFailure") {
+		t.Fatal("expected conditions that are not true to be left out but got", errs[0])
+	}
+	if !strings.HasPrefix(errs[1], "persistentvolumeclaims/team-b/data has been terminating for 2h0m0s with finalizers kubernetes.io/pvc-protection") {
+		t.Fatal("expected the stuck persistent volume claim but got", errs[1])
+	}
+
+	if len(details) != 2 || details["namespaces/team-a"] != "kubernetes" || details["persistentvolumeclaims/team-b/data"] != "kubernetes.io/pvc-protection" {
+		t.Fatal("expected the finalizers of the stuck objects in the details but got", details)
+	}
+}
+
+func TestRunHealthy(t *testing.T) {
+	client := fake.NewSimpleClientset(testNamespace("team-a", 0), testNamespace("team-b", time.Minute))
+	c := &checker{maxTerminating: time.Minute * 15, client: client}
+	errs, details := c.run(context.Background())
+	if len(errs) != 0 || len(details) != 0 {
+		t.Fatal("expected no stuck objects but got", errs, details)
+	}
+}
+
+func TestDetailsLimits(t *testing.T) {
+	var stuck []stuckObject
+	for i := 0; i < status.MaxDetails+5; i++ {
+		stuck = append(stuck, stuckObject{resource: "namespaces", name: fmt.Sprintf("team-%02d", i)})
+	}
+	d := details(stuck)
+	if len(d) != status.MaxDetails || d["namespaces/team-00"] != "none" {
+		t.Fatal("expected the details to be limited to", status.MaxDetails, "but got", len(d))
+	}
+
+	stuck = []stuckObject{
+		{resource: "namespaces", name: "team-a", finalizers: []string{strings.Repeat("a", status.MaxDetailsSize-100)}},
+		{resource: "namespaces", name: "team-b", finalizers: []string{strings.Repeat("b", 200)}},
+	}
+	d = details(stuck)
+	if len(d) != 1 {
+		t.Fatal("expected the details to be limited in size but got", len(d), "details")
+	}
+	report := status.Report{Details: d}
+	if err := report.ValidateDetails(); err != nil {
+		t.Fatal("expected valid details but got", err)
+	}
+}
+
+func TestParseResources(t *testing.T) {
+	resources, err := parseResources(" v1/persistentvolumeclaims, apps/v1/deployments,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []schema.GroupVersionResource{pvcResource, {Group: "apps", Version: "v1", Resource: "deployments"}}
+	if len(resources) != len(expected) || resources[0] != expected[0] || resources[1] != expected[1] {
+		t.Fatal("expected", expected, "but got", resources)
+	}
+
+	for _, invalid := range []string{"deployments", "a/b/c/d", "apps//deployments"} {
+		_, err = parseResources(invalid)
+		if err == nil {
+			t.Fatal("expected an error for", invalid)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Package main implements a check that finds namespaces, and optionally objects of other resources, that have been
+// terminating for longer than allowed.  Stuck objects usually point to finalizers that are never removed, or to
+// aggregated APIs that are unavailable and keep the namespace controller from removing the contents of a namespace.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+
+	checkclient "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/nodeCheck"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
+)
+
+// defaultMaxTerminating is how long an object can be terminating before it is stuck
+const defaultMaxTerminating = time.Minute * 15
+
+// kubeConfigFile is a variable containing file path of Kubernetes config files
+var kubeConfigFile = filepath.Join(os.Getenv("HOME"), ".kube", "config")
+
+func init() {
+	// set debug mode for nodeCheck pkg
+	nodeCheck.EnableDebugOutput()
+}
+
+func main() {
+	checker, err := newCheckerFromEnv()
+	if err != nil {
+		log.Errorln("Error parsing the check configuration:", err)
+		reportToKuberhealthy([]string{err.Error()}, nil)
+		return
+	}
+
+	ctx := context.Background()
+	deadline, err := checkclient.GetDeadline()
+	if err != nil {
+		log.Warnln("There was an issue getting the check deadline:", err.Error())
+	} else {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline.Add(-time.Second*5))
+		defer cancel()
+	}
+
+	config, err := kubeClient.Config(kubeConfigFile)
+	if err != nil {
+		reportToKuberhealthy([]string{"Failed to create a kubernetes client configuration: " + err.Error()}, nil)
+		return
+	}
+	checker.client, err = kubernetes.NewForConfig(config)
+	if err != nil {
+		reportToKuberhealthy([]string{"Failed to create a kubernetes client: " + err.Error()}, nil)
+		return
+	}
+	checker.metadataClient, err = metadata.NewForConfig(config)
+	if err != nil {
+		reportToKuberhealthy([]string{"Failed to create a kubernetes metadata client: " + err.Error()}, nil)
+		return
+	}
+
+	// hits kuberhealthy endpoint to see if node is ready
+	err = nodeCheck.WaitForKuberhealthy(ctx)
+	if err != nil {
+		log.Errorln("Error waiting for kuberhealthy endpoint to be contactable by checker pod with error:" + err.Error())
+	}
+
+	reportToKuberhealthy(checker.run(ctx))
+}
+
+// newCheckerFromEnv creates a checker from the environment variables of the checker pod
+func newCheckerFromEnv() (*checker, error) {
+	c := &checker{maxTerminating: defaultMaxTerminating}
+
+	var err error
+	if v := os.Getenv("MAX_TERMINATING_DURATION"); len(v) > 0 {
+		c.maxTerminating, err = time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MAX_TERMINATING_DURATION: %w", err)
+		}
+	}
+	c.resources, err = parseResources(os.Getenv("RESOURCES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid RESOURCES: %w", err)
+	}
+
+	log.Infoln("Finding namespaces and", len(c.resources), "other resources terminating for longer than", c.maxTerminating)
+	return c, nil
+}
+
+// parseResources parses a comma separated list of resources in the form group/version/resource, or version/resource
+// for the resources of the core group, such as v1/persistentvolumeclaims,apps/v1/deployments
+func parseResources(s string) ([]schema.GroupVersionResource, error) {
+	var resources []schema.GroupVersionResource
+	for _, r := range strings.Split(s, ",") {
+		r = strings.TrimSpace(r)
+		if len(r) == 0 {
+			continue
+		}
+		parts := strings.Split(r, "/")
+		for _, part := range parts {
+			if len(part) == 0 {
+				return nil, fmt.Errorf("resource %q has a blank part", r)
+			}
+		}
+		switch len(parts) {
+		case 2:
+			resources = append(resources, schema.GroupVersionResource{Version: parts[0], Resource: parts[1]})
+		case 3:
+			resources = append(resources, schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]})
+		default:
+			return nil, fmt.Errorf("resource %q is not in the form group/version/resource or version/resource", r)
+		}
+	}
+	return resources, nil
+}
+
+// reportToKuberhealthy reports the errors of the run to Kuberhealthy, or success when there are none, along with the
+// finalizers of the stuck objects
+func reportToKuberhealthy(errorMessages []string, details map[string]string) {
+	var err error
+	if len(errorMessages) == 0 {
+		err = checkclient.ReportSuccessWithDetails(details)
+	} else {
+		log.Errorln("Reporting errors to Kuberhealthy:", errorMessages)
+		err = checkclient.ReportFailureWithDetails(errorMessages, details)
+	}
+	if err != nil {
+		log.Fatalln("error reporting to kuberhealthy:", err.Error())
+	}
+	log.Infoln("Successfully reported to Kuberhealthy")
+}
@@ -0,0 +1,178 @@
+// Package alertmanager pushes alerts to the v2 API of a Prometheus Alertmanager
+package alertmanager // import "github.com/kuberhealthy/kuberhealthy/v2/pkg/alertmanager"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// AlertsPath is the path of the Alertmanager API that alerts are posted to
+const AlertsPath = "/api/v2/alerts"
+
+// DefaultQueueSize is the number of alerts that can wait for delivery before new alerts are dropped
+const DefaultQueueSize = 100
+
+// DefaultMaxRetries is the number of times delivery of an alert is retried before it is dropped
+const DefaultMaxRetries = 3
+
+// DefaultRetryDelay is the delay before the first retry of an alert.  The delay doubles with each retry.
+const DefaultRetryDelay = time.Second
+
+// DefaultTimeout is the timeout of each delivery attempt
+const DefaultTimeout = time.Second * 10
+
+// Alert is an alert in the format of the Alertmanager v2 API.  Alertmanager identifies alerts by their labels, so
+// the labels of an alert should not change while it fires.  An alert with an EndsAt in the past is resolved.
+type Alert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// NotifierConfig configures the delivery of alerts to Alertmanager
+type NotifierConfig struct {
+	URL        string                                // the base URL of Alertmanager, such as http://alertmanager:9093
+	Headers    map[string]string                     // extra headers sent with every request, such as authorization
+	QueueSize  int                                   // the number of alerts that can wait for delivery
+	MaxRetries int                                   // the number of times a failed delivery is retried
+	RetryDelay time.Duration                         // the delay before the first retry, doubled with each retry
+	Timeout    time.Duration                         // the timeout of each delivery attempt
+	Proxy      func(*http.Request) (*url.URL, error) // the proxy deliveries are sent through. nil uses the proxy environment variables
+}
+
+// Notifier delivers alerts to Alertmanager in the background.  Alerts that can not be queued or delivered are
+// dropped and counted.
+type Notifier struct {
+	config  NotifierConfig
+	client  *http.Client
+	queue   chan Alert
+	dropped uint64
+}
+
+// NewNotifier creates a notifier that delivers alerts to the Alertmanager at the configured URL.  Unset settings use
+// their defaults.  Alerts are queued until the notifier is started.
+func NewNotifier(config NotifierConfig) *Notifier {
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = DefaultMaxRetries
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = DefaultRetryDelay
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	config.URL = strings.TrimSuffix(config.URL, "/")
+	return &Notifier{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout, Transport: proxyTransport(config.Proxy)},
+		queue:  make(chan Alert, config.QueueSize),
+	}
+}
+
+// Send queues an alert for delivery without blocking.  The alert is dropped if the queue is full.
+func (n *Notifier) Send(a Alert) {
+	select {
+	case n.queue <- a:
+	default:
+		atomic.AddUint64(&n.dropped, 1)
+		log.Warningln("alertmanager: dropping alert", a.Labels["alertname"], "because the delivery queue is full")
+	}
+}
+
+// Dropped returns the number of alerts that were dropped since the notifier was created
+func (n *Notifier) Dropped() uint64 {
+	return atomic.LoadUint64(&n.dropped)
+}
+
+// Start delivers queued alerts until the context is canceled
+func (n *Notifier) Start(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case a := <-n.queue:
+			err := n.deliverWithRetry(ctx, a)
+			if err != nil {
+				atomic.AddUint64(&n.dropped, 1)
+				log.Errorln("alertmanager: dropping alert", a.Labels["alertname"]+":", err)
+			}
+		}
+	}
+}
+
+// deliverWithRetry delivers an alert and retries failed deliveries with an exponential backoff
+func (n *Notifier) deliverWithRetry(ctx context.Context, a Alert) error {
+	delay := n.config.RetryDelay
+	var err error
+	for try := 0; try <= n.config.MaxRetries; try++ {
+		if try > 0 {
+			log.Debugln("alertmanager: retrying delivery of alert", a.Labels["alertname"], "in", delay.String()+":", err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay = delay + delay
+		}
+		err = n.deliver(ctx, a)
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to deliver alert after %d retries: %w", n.config.MaxRetries, err)
+}
+
+// deliver posts an alert to Alertmanager
+func (n *Notifier) deliver(ctx context.Context, a Alert) error {
+	b, err := json.Marshal([]Alert{a})
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.config.URL+AlertsPath, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range n.config.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("alertmanager responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// proxyTransport returns the transport of the HTTP client, which sends requests through the supplied proxy.  The
+// default transport, which uses the proxy environment variables, is returned when the proxy is nil.
+func proxyTransport(proxy func(*http.Request) (*url.URL, error)) http.RoundTripper {
+	if proxy == nil {
+		return http.DefaultTransport
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	return transport
+}
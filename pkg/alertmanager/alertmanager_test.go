@@ -0,0 +1,85 @@
+package alertmanager
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestNotifierDelivers ensures alerts are posted to the v2 alerts API as a JSON list with the configured headers
+func TestNotifierDelivers(t *testing.T) {
+	received := make(chan []Alert, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != AlertsPath {
+			t.Error("unexpected request:", r.Method, r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Error("expected configured header on request but got", r.Header.Get("Authorization"))
+		}
+		var alerts []Alert
+		err := json.NewDecoder(r.Body).Decode(&alerts)
+		if err != nil {
+			t.Error("failed to decode alerts:", err)
+		}
+		received <- alerts
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier := NewNotifier(NotifierConfig{URL: server.URL + "/", Headers: map[string]string{"Authorization": "Bearer token"}})
+	go notifier.Start(ctx)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	notifier.Send(Alert{
+		Labels:      map[string]string{"alertname": "KuberhealthyCheckFailed", "check": "dns"},
+		Annotations: map[string]string{"errors": "lookup failed"},
+		StartsAt:    now,
+		EndsAt:      now.Add(time.Hour),
+	})
+
+	select {
+	case alerts := <-received:
+		if len(alerts) != 1 || alerts[0].Labels["check"] != "dns" || alerts[0].Annotations["errors"] != "lookup failed" {
+			t.Fatal("unexpected alerts received:", alerts)
+		}
+		if !alerts[0].StartsAt.Equal(now) || !alerts[0].EndsAt.Equal(now.Add(time.Hour)) {
+			t.Fatal("expected the start and end of the alert but got", alerts[0].StartsAt, alerts[0].EndsAt)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for alert delivery")
+	}
+}
+
+// TestNotifierRetriesAndDrops ensures failed deliveries are retried a bounded number of times before the alert is
+// dropped and counted
+func TestNotifierRetriesAndDrops(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifier := NewNotifier(NotifierConfig{URL: server.URL, MaxRetries: 2, RetryDelay: time.Millisecond})
+	go notifier.Start(ctx)
+
+	notifier.Send(Alert{Labels: map[string]string{"alertname": "KuberhealthyCheckFailed"}})
+
+	deadline := time.Now().Add(time.Second * 5)
+	for notifier.Dropped() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for alert to be dropped")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	if atomic.LoadInt32(&attempts) != 3 {
+		t.Fatal("expected three delivery attempts but got", atomic.LoadInt32(&attempts))
+	}
+}
@@ -20,6 +20,7 @@
 package v1
 
 import (
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -41,9 +42,42 @@ func (in *CheckConfig) DeepCopyInto(out *CheckConfig) {
 			(*out)[key] = val
 		}
 	}
+	if in.RBAC != nil {
+		in, out := &in.RBAC, &out.RBAC
+		*out = new(CheckRBAC)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CheckRBAC) DeepCopyInto(out *CheckRBAC) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]rbacv1.PolicyRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheckRBAC.
+func (in *CheckRBAC) DeepCopy() *CheckRBAC {
+	if in == nil {
+		return nil
+	}
+	out := new(CheckRBAC)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CheckConfig.
 func (in *CheckConfig) DeepCopy() *CheckConfig {
 	if in == nil {
@@ -60,6 +94,7 @@ func (in *KuberhealthyCheck) DeepCopyInto(out *KuberhealthyCheck) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -73,6 +108,26 @@ func (in *KuberhealthyCheck) DeepCopy() *KuberhealthyCheck {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KuberhealthyCheckStatus) DeepCopyInto(out *KuberhealthyCheckStatus) {
+	*out = *in
+	if in.LastRun != nil {
+		in, out := &in.LastRun, &out.LastRun
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KuberhealthyCheckStatus.
+func (in *KuberhealthyCheckStatus) DeepCopy() *KuberhealthyCheckStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KuberhealthyCheckStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
 func (in *KuberhealthyCheck) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
@@ -2,6 +2,7 @@ package v1
 
 import (
 	apiv1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -11,9 +12,19 @@ import (
 // KuberhealthyCheck represents the data in the CRD for configuring an
 // external check for Kuberhealthy
 // +k8s:openapi-gen=true
+// +kubebuilder:printcolumn:name="Interval",type=string,JSONPath=`.spec.runInterval`,description="Run interval"
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`,description="Cron schedule"
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.podSpec.containers[0].image`,description="Checker image"
+// +kubebuilder:printcolumn:name="Paused",type=boolean,JSONPath=`.spec.paused`,description="Paused checks are not run"
+// +kubebuilder:printcolumn:name="OK",type=boolean,JSONPath=`.status.OK`,description="OK status of the last run"
+// +kubebuilder:printcolumn:name="Last Run",type=date,JSONPath=`.status.LastRun`,description="Last Run"
+// +kubebuilder:printcolumn:name="Errors",type=integer,JSONPath=`.status.ErrorCount`,description="Error count of the last run"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`,description="Age"
 // +kubebuilder:resource:path="khchecks"
 // +kubebuilder:resource:singular="khcheck"
 // +kubebuilder:resource:shortName="khc"
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 type KuberhealthyCheck struct {
 	metav1.TypeMeta `json:",inline" yaml:",inline"`
 	// +optional
@@ -22,6 +33,25 @@ type KuberhealthyCheck struct {
 	// Spec holds the desired state of the KuberhealthyCheck (from the client).
 	// +optional
 	Spec CheckConfig `json:"spec,omitempty" yaml:"spec,omitempty"`
+
+	// Status holds the result of the last run of the check, written by Kuberhealthy through the status subresource
+	// +optional
+	Status KuberhealthyCheckStatus `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+// KuberhealthyCheckStatus mirrors the result of the last run of a check from its khstate, so that kubectl get khchecks
+// shows whether checks are passing
+// +k8s:openapi-gen=true
+type KuberhealthyCheckStatus struct {
+	// +optional
+	OK bool `json:"OK" yaml:"OK"` // true when the last run of the check passed
+	// +optional
+	// +nullable
+	LastRun *metav1.Time `json:"LastRun,omitempty" yaml:"LastRun,omitempty"` // the time the check was last run
+	// +optional
+	ErrorCount int `json:"ErrorCount,omitempty" yaml:"ErrorCount,omitempty"` // the number of errors of the last run
+	// +optional
+	LastError string `json:"LastError,omitempty" yaml:"LastError,omitempty"` // the last error of the last run, truncated like the LastError of its khstate
 }
 
 // CheckConfig represents a configuration for a kuberhealthy external
@@ -30,15 +60,71 @@ type KuberhealthyCheck struct {
 // endpoint.
 // +k8s:openapi-gen=true
 type CheckConfig struct {
-	RunInterval string        `json:"runInterval" yaml:"runInterval"` // the interval at which the check runs
-	Timeout     string        `json:"timeout" yaml:"timeout"`         // the maximum time the pod is allowed to run before a failure is assumed
-	PodSpec     apiv1.PodSpec `json:"podSpec" yaml:"podSpec"`         // a spec for the external checker
+	// +optional
+	RunInterval string `json:"runInterval" yaml:"runInterval"` // the interval at which the check runs
+	// +optional
+	Schedule string `json:"schedule,omitempty" yaml:"schedule,omitempty"` // a cron expression for when the check runs, such as "0 9-17 * * MON-FRI". replaces runInterval when set
+	// +optional
+	ScheduleTimezone string        `json:"scheduleTimezone,omitempty" yaml:"scheduleTimezone,omitempty"` // the IANA timezone the schedule is evaluated in. defaults to UTC
+	Timeout          string        `json:"timeout" yaml:"timeout"`                                       // the maximum time the pod is allowed to run before a failure is assumed
+	PodSpec          apiv1.PodSpec `json:"podSpec" yaml:"podSpec"`                                       // a spec for the external checker
 	// +optional
 	ExtraAnnotations map[string]string `json:"extraAnnotations" yaml:"extraAnnotations"` // a map of extra annotations that will be applied to the pod
 	// +optional
 	ExtraLabels map[string]string `json:"extraLabels" yaml:"extraLabels"` // a map of extra labels that will be applied to the pod
+	// +optional
+	Paused bool `json:"paused,omitempty" yaml:"paused,omitempty"` // paused checks are not scheduled until this is set back to false
+	// +optional
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty" yaml:"concurrencyPolicy,omitempty"` // how a run is handled while the previous run is still in flight. defaults to Forbid
+	// +optional
+	FailureThreshold int `json:"failureThreshold,omitempty" yaml:"failureThreshold,omitempty"` // the number of consecutive failed runs before the check reports an error. defaults to 1
+	// +optional
+	RetryBackoff string `json:"retryBackoff,omitempty" yaml:"retryBackoff,omitempty"` // how long to wait before retrying a failed run that has not reached the failureThreshold, doubling with each failure
+	// +optional
+	RBAC *CheckRBAC `json:"rbac,omitempty" yaml:"rbac,omitempty"` // the permissions of the checker pods. Kuberhealthy creates a ServiceAccount, Role and RoleBinding for them when set
+	// +optional
+	DependsOn []string `json:"dependsOn,omitempty" yaml:"dependsOn,omitempty"` // the checks this check depends on, by name in the same namespace or by namespace/name
+	// +optional
+	DependencyPolicy DependencyPolicy `json:"dependencyPolicy,omitempty" yaml:"dependencyPolicy,omitempty"` // what happens to the check while a check it depends on fails. defaults to Suppress
+}
+
+// CheckRBAC declares the permissions the checker pods of a check need in the namespace of the check.  Kuberhealthy
+// creates a ServiceAccount, a Role with the rules and a RoleBinding between them, all named after the
+// serviceAccountName of the podSpec, or after the check when it is not set.  The checker pods run as the
+// ServiceAccount.
+// +k8s:openapi-gen=true
+type CheckRBAC struct {
+	// +optional
+	Rules []rbacv1.PolicyRule `json:"rules,omitempty" yaml:"rules,omitempty"` // the rules of the Role
 }
 
+// ConcurrencyPolicy describes how a check run is handled when it is due while the previous run is still in flight.
+// The policies are modeled after the concurrency policies of Kubernetes CronJobs.
+// +kubebuilder:validation:Enum=Forbid;Replace;Allow
+type ConcurrencyPolicy string
+
+const (
+	// ForbidConcurrent skips the run that is due and notes the skipped run in the khstate
+	ForbidConcurrent ConcurrencyPolicy = "Forbid"
+	// ReplaceConcurrent stops the run in flight, invalidates its UUID and starts the run that is due
+	ReplaceConcurrent ConcurrencyPolicy = "Replace"
+	// AllowConcurrent starts the run that is due next to the run in flight.  The last valid report wins.
+	AllowConcurrent ConcurrencyPolicy = "Allow"
+)
+
+// DependencyPolicy describes what happens to a check while a check it depends on fails
+// +kubebuilder:validation:Enum=Suppress;Skip
+type DependencyPolicy string
+
+const (
+	// SuppressOnDependencyFailure keeps running the check, but its failures are shown as suppressed by the failing
+	// dependency and do not affect the global OK state
+	SuppressOnDependencyFailure DependencyPolicy = "Suppress"
+	// SkipOnDependencyFailure skips runs of the check until its dependencies pass again.  Its last result is
+	// suppressed like with SuppressOnDependencyFailure.
+	SkipOnDependencyFailure DependencyPolicy = "Skip"
+)
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // KuberhealthyCheckList is a list of KuberhealthyCheck resources
@@ -0,0 +1,62 @@
+package v1
+
+import (
+	"encoding/json"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestPrinterColumnFields ensures that the fields referenced by the khcheck printer columns serialize into
+// the JSON paths that the CRD definition expects
+func TestPrinterColumnFields(t *testing.T) {
+	check := NewKuberhealthyCheck("test-check", "kuberhealthy", CheckConfig{
+		RunInterval: "5m",
+		Timeout:     "1m",
+		Paused:      true,
+		PodSpec: apiv1.PodSpec{
+			Containers: []apiv1.Container{{Name: "main", Image: "kuberhealthy/test-check:v1"}},
+		},
+	})
+	now := metav1.Now()
+	check.Status = KuberhealthyCheckStatus{OK: true, LastRun: &now}
+
+	b, err := json.Marshal(check)
+	if err != nil {
+		t.Fatal("failed to marshal khcheck:", err)
+	}
+
+	var out struct {
+		Spec struct {
+			RunInterval string `json:"runInterval"`
+			Paused      bool   `json:"paused"`
+			PodSpec     struct {
+				Containers []struct {
+					Image string `json:"image"`
+				} `json:"containers"`
+			} `json:"podSpec"`
+		} `json:"spec"`
+		Status map[string]interface{} `json:"status"`
+	}
+	err = json.Unmarshal(b, &out)
+	if err != nil {
+		t.Fatal("failed to unmarshal khcheck:", err)
+	}
+
+	if out.Spec.RunInterval != "5m" {
+		t.Fatal("expected .spec.runInterval to be 5m but got", out.Spec.RunInterval)
+	}
+	if !out.Spec.Paused {
+		t.Fatal("expected .spec.paused to be true")
+	}
+	if len(out.Spec.PodSpec.Containers) != 1 || out.Spec.PodSpec.Containers[0].Image != "kuberhealthy/test-check:v1" {
+		t.Fatal("expected .spec.podSpec.containers[0].image to be set but got", out.Spec.PodSpec.Containers)
+	}
+
+	for _, field := range []string{"OK", "LastRun"} {
+		if _, ok := out.Status[field]; !ok {
+			t.Fatal("expected khcheck status to contain printer column field", field)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package v2
+
+import (
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+)
+
+// V1APIVersion is the apiVersion of the v1 khchecks that are stored
+const V1APIVersion = "comcast.github.io/v1"
+
+// ConvertFrom sets the khcheck from a v1 khcheck.  The extra labels and annotations of the v1 khcheck become the
+// labels and annotations of the pod template.
+func (c *KuberhealthyCheck) ConvertFrom(src *khcheckv1.KuberhealthyCheck) {
+	src = src.DeepCopy()
+	c.TypeMeta = src.TypeMeta
+	c.APIVersion = SchemeGroupVersion.String()
+	c.ObjectMeta = src.ObjectMeta
+
+	c.Spec = KuberhealthyCheckSpec{
+		Schedule: CheckSchedule{
+			Interval: src.Spec.RunInterval,
+			Cron:     src.Spec.Schedule,
+			Timezone: src.Spec.ScheduleTimezone,
+		},
+		Timeout:           src.Spec.Timeout,
+		Paused:            src.Spec.Paused,
+		ConcurrencyPolicy: ConcurrencyPolicy(src.Spec.ConcurrencyPolicy),
+		Retry: RetryPolicy{
+			FailureThreshold: src.Spec.FailureThreshold,
+			Backoff:          src.Spec.RetryBackoff,
+		},
+	}
+	c.Spec.Template.Labels = src.Spec.ExtraLabels
+	c.Spec.Template.Annotations = src.Spec.ExtraAnnotations
+	c.Spec.Template.Spec = src.Spec.PodSpec
+	if len(src.Spec.DependsOn) > 0 || len(src.Spec.DependencyPolicy) > 0 {
+		c.Spec.Dependencies = &CheckDependencies{
+			Checks: src.Spec.DependsOn,
+			Policy: DependencyPolicy(src.Spec.DependencyPolicy),
+		}
+	}
+	if src.Spec.RBAC != nil {
+		c.Spec.RBAC = &CheckRBAC{Rules: src.Spec.RBAC.Rules}
+	}
+
+	c.Status = KuberhealthyCheckStatus{
+		OK:         src.Status.OK,
+		LastRun:    src.Status.LastRun,
+		ErrorCount: src.Status.ErrorCount,
+		LastError:  src.Status.LastError,
+	}
+}
+
+// ConvertTo sets a v1 khcheck from the khcheck.  v1 khchecks have no pod template metadata other than labels and
+// annotations, so the rest of it is dropped.
+func (c *KuberhealthyCheck) ConvertTo(dst *khcheckv1.KuberhealthyCheck) {
+	src := c.DeepCopy()
+	dst.TypeMeta = src.TypeMeta
+	dst.APIVersion = V1APIVersion
+	dst.ObjectMeta = src.ObjectMeta
+
+	dst.Spec = khcheckv1.CheckConfig{
+		RunInterval:       src.Spec.Schedule.Interval,
+		Schedule:          src.Spec.Schedule.Cron,
+		ScheduleTimezone:  src.Spec.Schedule.Timezone,
+		Timeout:           src.Spec.Timeout,
+		PodSpec:           src.Spec.Template.Spec,
+		ExtraAnnotations:  src.Spec.Template.Annotations,
+		ExtraLabels:       src.Spec.Template.Labels,
+		Paused:            src.Spec.Paused,
+		ConcurrencyPolicy: khcheckv1.ConcurrencyPolicy(src.Spec.ConcurrencyPolicy),
+		FailureThreshold:  src.Spec.Retry.FailureThreshold,
+		RetryBackoff:      src.Spec.Retry.Backoff,
+	}
+	if src.Spec.Dependencies != nil {
+		dst.Spec.DependsOn = src.Spec.Dependencies.Checks
+		dst.Spec.DependencyPolicy = khcheckv1.DependencyPolicy(src.Spec.Dependencies.Policy)
+	}
+	if src.Spec.RBAC != nil {
+		dst.Spec.RBAC = &khcheckv1.CheckRBAC{Rules: src.Spec.RBAC.Rules}
+	}
+
+	dst.Status = khcheckv1.KuberhealthyCheckStatus{
+		OK:         src.Status.OK,
+		LastRun:    src.Status.LastRun,
+		ErrorCount: src.Status.ErrorCount,
+		LastError:  src.Status.LastError,
+	}
+}
@@ -0,0 +1,86 @@
+package v2
+
+import (
+	"reflect"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+)
+
+// testV1Check returns a v1 khcheck with every spec and status field set
+func testV1Check() khcheckv1.KuberhealthyCheck {
+	now := metav1.Now()
+	kc := khcheckv1.NewKuberhealthyCheck("dns", "kuberhealthy", khcheckv1.CheckConfig{
+		RunInterval:       "2m",
+		Schedule:          "*/5 * * * *",
+		ScheduleTimezone:  "Europe/Amsterdam",
+		Timeout:           "1m",
+		PodSpec:           apiv1.PodSpec{Containers: []apiv1.Container{{Name: "main", Image: "kuberhealthy/dns-resolution-check:v1.5.0"}}},
+		ExtraAnnotations:  map[string]string{"team": "sre"},
+		ExtraLabels:       map[string]string{"app": "dns"},
+		Paused:            true,
+		ConcurrencyPolicy: khcheckv1.ReplaceConcurrent,
+		FailureThreshold:  3,
+		RetryBackoff:      "10s",
+		RBAC:              &khcheckv1.CheckRBAC{Rules: []rbacv1.PolicyRule{{Verbs: []string{"get"}, Resources: []string{"pods"}}}},
+		DependsOn:         []string{"kube-system/coredns"},
+		DependencyPolicy:  khcheckv1.SkipOnDependencyFailure,
+	})
+	kc.APIVersion = V1APIVersion
+	kc.Kind = "KuberhealthyCheck"
+	kc.Status = khcheckv1.KuberhealthyCheckStatus{OK: true, LastRun: &now, ErrorCount: 1, LastError: "timed out"}
+	return kc
+}
+
+// TestConvertCheck ensures a v1 khcheck converts to v2 and back without losing any settings
+func TestConvertCheck(t *testing.T) {
+	src := testV1Check()
+	for _, v := range []reflect.Value{reflect.ValueOf(src.Spec), reflect.ValueOf(src.Status)} {
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).IsZero() {
+				t.Fatal("expected the test khcheck to set", v.Type().Field(i).Name, "so that its conversion is covered")
+			}
+		}
+	}
+
+	var kc KuberhealthyCheck
+	kc.ConvertFrom(&src)
+	if kc.APIVersion != "comcast.github.io/v2" || kc.Name != "dns" || kc.Namespace != "kuberhealthy" {
+		t.Fatal("expected the metadata of the khcheck to be kept but got", kc.TypeMeta, kc.ObjectMeta)
+	}
+	if kc.Spec.Schedule.Cron != "*/5 * * * *" || kc.Spec.Template.Labels["app"] != "dns" || kc.Spec.Template.Spec.Containers[0].Name != "main" {
+		t.Fatal("expected the schedule and pod template to be converted but got", kc.Spec)
+	}
+	if kc.Spec.Dependencies == nil || kc.Spec.Dependencies.Policy != SkipOnDependencyFailure || kc.Spec.Retry.FailureThreshold != 3 {
+		t.Fatal("expected the dependencies and retry policy to be converted but got", kc.Spec)
+	}
+
+	var dst khcheckv1.KuberhealthyCheck
+	kc.ConvertTo(&dst)
+	if !reflect.DeepEqual(src, dst) {
+		t.Fatalf("expected the khcheck to convert back to\n%+v\nbut got\n%+v", src, dst)
+	}
+}
+
+// TestConvertCheckWithoutDependencies ensures a khcheck without dependencies has none in v2
+func TestConvertCheckWithoutDependencies(t *testing.T) {
+	src := testV1Check()
+	src.Spec.DependsOn = nil
+	src.Spec.DependencyPolicy = ""
+	src.Spec.RBAC = nil
+
+	var kc KuberhealthyCheck
+	kc.ConvertFrom(&src)
+	if kc.Spec.Dependencies != nil || kc.Spec.RBAC != nil {
+		t.Fatal("expected no dependencies or rbac but got", kc.Spec.Dependencies, kc.Spec.RBAC)
+	}
+	var dst khcheckv1.KuberhealthyCheck
+	kc.ConvertTo(&dst)
+	if !reflect.DeepEqual(src, dst) {
+		t.Fatalf("expected the khcheck to convert back to\n%+v\nbut got\n%+v", src, dst)
+	}
+}
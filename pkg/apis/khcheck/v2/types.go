@@ -0,0 +1,143 @@
+package v2
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// KuberhealthyCheck represents the data in the CRD for configuring an external check for Kuberhealthy.  Compared to
+// v1, its settings are grouped by concern and its checker pods are described by a pod template.
+// +k8s:openapi-gen=true
+// +kubebuilder:printcolumn:name="Interval",type=string,JSONPath=`.spec.schedule.interval`,description="Run interval"
+// +kubebuilder:printcolumn:name="Cron",type=string,JSONPath=`.spec.schedule.cron`,description="Cron schedule"
+// +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.template.spec.containers[0].image`,description="Checker image"
+// +kubebuilder:printcolumn:name="Paused",type=boolean,JSONPath=`.spec.paused`,description="Paused checks are not run"
+// +kubebuilder:printcolumn:name="OK",type=boolean,JSONPath=`.status.ok`,description="OK status of the last run"
+// +kubebuilder:printcolumn:name="Last Run",type=date,JSONPath=`.status.lastRun`,description="Last Run"
+// +kubebuilder:printcolumn:name="Errors",type=integer,JSONPath=`.status.errorCount`,description="Error count of the last run"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`,description="Age"
+// +kubebuilder:resource:path="khchecks"
+// +kubebuilder:resource:singular="khcheck"
+// +kubebuilder:resource:shortName="khc"
+// +kubebuilder:subresource:status
+// +kubebuilder:unservedversion
+type KuberhealthyCheck struct {
+	metav1.TypeMeta `json:",inline" yaml:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+
+	// Spec holds the desired state of the KuberhealthyCheck (from the client).
+	// +optional
+	Spec KuberhealthyCheckSpec `json:"spec,omitempty" yaml:"spec,omitempty"`
+
+	// Status holds the result of the last run of the check, written by Kuberhealthy through the status subresource
+	// +optional
+	Status KuberhealthyCheckStatus `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+// KuberhealthyCheckSpec configures when a check runs, how its runs are judged and the checker pods it runs
+// +k8s:openapi-gen=true
+type KuberhealthyCheckSpec struct {
+	// +optional
+	Schedule CheckSchedule `json:"schedule,omitempty" yaml:"schedule,omitempty"` // when the check runs
+	Timeout  string        `json:"timeout" yaml:"timeout"`                       // the maximum time the pod is allowed to run before a failure is assumed
+	// +optional
+	Paused bool `json:"paused,omitempty" yaml:"paused,omitempty"` // paused checks are not scheduled until this is set back to false
+	// +optional
+	ConcurrencyPolicy ConcurrencyPolicy `json:"concurrencyPolicy,omitempty" yaml:"concurrencyPolicy,omitempty"` // how a run is handled while the previous run is still in flight. defaults to Forbid
+	// +optional
+	Retry RetryPolicy `json:"retry,omitempty" yaml:"retry,omitempty"` // how failed runs are retried before the check reports an error
+	// +optional
+	Dependencies *CheckDependencies    `json:"dependencies,omitempty" yaml:"dependencies,omitempty"` // the checks this check depends on
+	Template     apiv1.PodTemplateSpec `json:"template" yaml:"template"`                             // the checker pods. the labels and annotations of its metadata are applied to them
+	// +optional
+	RBAC *CheckRBAC `json:"rbac,omitempty" yaml:"rbac,omitempty"` // the permissions of the checker pods. Kuberhealthy creates a ServiceAccount, Role and RoleBinding for them when set
+}
+
+// CheckSchedule describes when a check runs, either every interval or on a cron schedule
+// +k8s:openapi-gen=true
+type CheckSchedule struct {
+	// +optional
+	Interval string `json:"interval,omitempty" yaml:"interval,omitempty"` // the interval at which the check runs
+	// +optional
+	Cron string `json:"cron,omitempty" yaml:"cron,omitempty"` // a cron expression for when the check runs, such as "0 9-17 * * MON-FRI". replaces interval when set
+	// +optional
+	Timezone string `json:"timezone,omitempty" yaml:"timezone,omitempty"` // the IANA timezone the cron expression is evaluated in. defaults to UTC
+}
+
+// RetryPolicy describes how many failed runs a check tolerates before it reports an error
+// +k8s:openapi-gen=true
+type RetryPolicy struct {
+	// +optional
+	FailureThreshold int `json:"failureThreshold,omitempty" yaml:"failureThreshold,omitempty"` // the number of consecutive failed runs before the check reports an error. defaults to 1
+	// +optional
+	Backoff string `json:"backoff,omitempty" yaml:"backoff,omitempty"` // how long to wait before retrying a failed run that has not reached the failureThreshold, doubling with each failure
+}
+
+// CheckDependencies lists the checks a check depends on and what happens to it while they fail
+// +k8s:openapi-gen=true
+type CheckDependencies struct {
+	// +optional
+	Checks []string `json:"checks,omitempty" yaml:"checks,omitempty"` // the checks this check depends on, by name in the same namespace or by namespace/name
+	// +optional
+	Policy DependencyPolicy `json:"policy,omitempty" yaml:"policy,omitempty"` // what happens to the check while a check it depends on fails. defaults to Suppress
+}
+
+// CheckRBAC declares the permissions the checker pods of a check need in the namespace of the check
+// +k8s:openapi-gen=true
+type CheckRBAC struct {
+	// +optional
+	Rules []rbacv1.PolicyRule `json:"rules,omitempty" yaml:"rules,omitempty"` // the rules of the Role
+}
+
+// ConcurrencyPolicy describes how a check run is handled when it is due while the previous run is still in flight
+// +kubebuilder:validation:Enum=Forbid;Replace;Allow
+type ConcurrencyPolicy string
+
+const (
+	// ForbidConcurrent skips the run that is due and notes the skipped run in the khstate
+	ForbidConcurrent ConcurrencyPolicy = "Forbid"
+	// ReplaceConcurrent stops the run in flight, invalidates its UUID and starts the run that is due
+	ReplaceConcurrent ConcurrencyPolicy = "Replace"
+	// AllowConcurrent starts the run that is due next to the run in flight.  The last valid report wins.
+	AllowConcurrent ConcurrencyPolicy = "Allow"
+)
+
+// DependencyPolicy describes what happens to a check while a check it depends on fails
+// +kubebuilder:validation:Enum=Suppress;Skip
+type DependencyPolicy string
+
+const (
+	// SuppressOnDependencyFailure keeps running the check, but its failures are shown as suppressed
+	SuppressOnDependencyFailure DependencyPolicy = "Suppress"
+	// SkipOnDependencyFailure skips runs of the check until its dependencies pass again
+	SkipOnDependencyFailure DependencyPolicy = "Skip"
+)
+
+// KuberhealthyCheckStatus mirrors the result of the last run of a check from its khstate
+// +k8s:openapi-gen=true
+type KuberhealthyCheckStatus struct {
+	// +optional
+	OK bool `json:"ok" yaml:"ok"` // true when the last run of the check passed
+	// +optional
+	// +nullable
+	LastRun *metav1.Time `json:"lastRun,omitempty" yaml:"lastRun,omitempty"` // the time the check was last run
+	// +optional
+	ErrorCount int `json:"errorCount,omitempty" yaml:"errorCount,omitempty"` // the number of errors of the last run
+	// +optional
+	LastError string `json:"lastError,omitempty" yaml:"lastError,omitempty"` // the last error of the last run, truncated like the LastError of its khstate
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// KuberhealthyCheckList is a list of KuberhealthyCheck resources
+type KuberhealthyCheckList struct {
+	metav1.TypeMeta `json:",inline" yaml:",inline"`
+	metav1.ListMeta `json:"metadata" yaml:"metadata"`
+
+	Items []KuberhealthyCheck `json:"items" yaml:"items"`
+}
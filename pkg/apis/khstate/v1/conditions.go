@@ -0,0 +1,75 @@
+package v1
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The condition types of a khstate
+const (
+	// ConditionHealthy is True when the last run of the khWorkload passed
+	ConditionHealthy = "Healthy"
+	// ConditionPaused is True when the khcheck is paused and not run
+	ConditionPaused = "Paused"
+)
+
+// The reasons of khstate conditions
+const (
+	ReasonRunSucceeded = "RunSucceeded" // the last run passed
+	ReasonRunFailed    = "RunFailed"    // the last run failed
+	ReasonNotRun       = "NotRun"       // the khWorkload has not completed a run yet
+	ReasonCheckPaused  = "CheckPaused"  // the khcheck is paused
+	ReasonCheckActive  = "CheckActive"  // the khcheck is not paused
+)
+
+// ConditionMessageMaxLength is the longest condition message stored.  Longer errors are truncated.
+const ConditionMessageMaxLength = 1024
+
+// SetConditions sets the conditions of the khstate from its spec.  The last transition time of a condition only
+// changes when its status does, so existing conditions should be carried over before calling it.
+func (s *KuberhealthyState) SetConditions() {
+	healthy := metav1.Condition{
+		Type:    ConditionHealthy,
+		Status:  metav1.ConditionTrue,
+		Reason:  ReasonRunSucceeded,
+		Message: "The last run passed",
+	}
+	switch {
+	case s.Spec.LastRun == nil:
+		healthy.Status = metav1.ConditionUnknown
+		healthy.Reason = ReasonNotRun
+		healthy.Message = "The khWorkload has not completed a run yet"
+	case !s.Spec.OK:
+		healthy.Status = metav1.ConditionFalse
+		healthy.Reason = ReasonRunFailed
+		healthy.Message = conditionMessage(s.Spec.Errors)
+	}
+	meta.SetStatusCondition(&s.Status.Conditions, healthy)
+
+	paused := metav1.Condition{
+		Type:    ConditionPaused,
+		Status:  metav1.ConditionFalse,
+		Reason:  ReasonCheckActive,
+		Message: "The khcheck is run",
+	}
+	if s.Spec.Paused {
+		paused.Status = metav1.ConditionTrue
+		paused.Reason = ReasonCheckPaused
+		paused.Message = "The khcheck is paused and keeps the result of its last run"
+	}
+	meta.SetStatusCondition(&s.Status.Conditions, paused)
+}
+
+// conditionMessage joins the errors of a failed run into a condition message
+func conditionMessage(errors []string) string {
+	if len(errors) == 0 {
+		return "The last run failed"
+	}
+	message := []rune(strings.Join(errors, "; "))
+	if len(message) > ConditionMessageMaxLength {
+		message = append(message[:ConditionMessageMaxLength-3], []rune("...")...)
+	}
+	return string(message)
+}
@@ -0,0 +1,53 @@
+package v1
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestSetConditions ensures the conditions follow the spec and only transition when their status changes
+func TestSetConditions(t *testing.T) {
+	state := NewKuberhealthyState("dns-status", NewWorkloadDetails(KHCheck))
+	healthy := meta.FindStatusCondition(state.Status.Conditions, ConditionHealthy)
+	if healthy == nil || healthy.Status != metav1.ConditionUnknown || healthy.Reason != ReasonNotRun {
+		t.Fatal("expected a khstate without a run to be of unknown health but got", healthy)
+	}
+
+	lastRun := metav1.Now()
+	state.Spec.LastRun = &lastRun
+	state.Spec.Errors = []string{"lookup failed", "timed out"}
+	state.SetConditions()
+	healthy = meta.FindStatusCondition(state.Status.Conditions, ConditionHealthy)
+	if healthy.Status != metav1.ConditionFalse || healthy.Reason != ReasonRunFailed || healthy.Message != "lookup failed; timed out" {
+		t.Fatal("expected a failed run to be unhealthy with its errors but got", healthy)
+	}
+
+	// a run that fails again keeps the transition time
+	transition := metav1.NewTime(time.Now().Add(-time.Hour))
+	healthy.LastTransitionTime = transition
+	state.SetConditions()
+	if !meta.FindStatusCondition(state.Status.Conditions, ConditionHealthy).LastTransitionTime.Equal(&transition) {
+		t.Fatal("expected the transition time to be kept while the status does not change")
+	}
+
+	state.Spec.OK = true
+	state.Spec.Paused = true
+	state.SetConditions()
+	if !meta.IsStatusConditionTrue(state.Status.Conditions, ConditionHealthy) || !meta.IsStatusConditionTrue(state.Status.Conditions, ConditionPaused) {
+		t.Fatal("expected a passed run of a paused check to be healthy and paused but got", state.Status.Conditions)
+	}
+	if len(state.Status.Conditions) != 2 {
+		t.Fatal("expected one condition of each type but got", state.Status.Conditions)
+	}
+}
+
+func TestConditionMessageTruncated(t *testing.T) {
+	message := conditionMessage([]string{strings.Repeat("é", ConditionMessageMaxLength*2)})
+	if len([]rune(message)) != ConditionMessageMaxLength || !strings.HasSuffix(message, "...") {
+		t.Fatal("expected a long message to be truncated but got", len([]rune(message)), "characters")
+	}
+}
@@ -23,6 +23,7 @@ package v1
 import (
 	"log"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -32,6 +33,20 @@ func (in *KuberhealthyState) DeepCopyInto(out *KuberhealthyState) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KuberhealthyStateStatus) DeepCopyInto(out *KuberhealthyStateStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -94,7 +109,90 @@ func (in *WorkloadDetails) DeepCopyInto(out *WorkloadDetails) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	in.LastRun.DeepCopyInto(out.LastRun)
+	if in.LastRun != nil {
+		in, out := &in.LastRun, &out.LastRun
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSkippedRun != nil {
+		in, out := &in.LastSkippedRun, &out.LastSkippedRun
+		*out = (*in).DeepCopy()
+	}
+	if in.AllowedUUIDs != nil {
+		in, out := &in.AllowedUUIDs, &out.AllowedUUIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SuppressedByDependencies != nil {
+		in, out := &in.SuppressedByDependencies, &out.SuppressedByDependencies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]ContainerRunStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]TargetDetails, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Details != nil {
+		in, out := &in.Details, &out.Details
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.History != nil {
+		in, out := &in.History, &out.History
+		*out = make([]RunResult, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunResult) DeepCopyInto(out *RunResult) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ContainerRunStatus) DeepCopyInto(out *ContainerRunStatus) {
+	*out = *in
+	if in.ExitCode != nil {
+		in, out := &in.ExitCode, &out.ExitCode
+		*out = new(int32)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetDetails) DeepCopyInto(out *TargetDetails) {
+	*out = *in
+	if in.Errors != nil {
+		in, out := &in.Errors, &out.Errors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NotAfter != nil {
+		in, out := &in.NotAfter, &out.NotAfter
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -113,7 +211,9 @@ func (in *WorkloadDetails) DeepCopy() *WorkloadDetails {
 func NewKuberhealthyState(name string, spec WorkloadDetails) KuberhealthyState {
 	state := KuberhealthyState{}
 	state.SetName(name)
+	spec.SetErrorSummary()
 	state.Spec = spec
+	state.SetConditions()
 	return state
 }
 
@@ -128,6 +228,22 @@ func NewWorkloadDetails(workloadType KHWorkload) WorkloadDetails {
 	}
 }
 
+// SetErrorSummary fills ErrorCount and LastError from the current Errors so that the printer columns shown by
+// `kubectl get khstates` stay consistent with the full error list
+func (wd *WorkloadDetails) SetErrorSummary() {
+	wd.ErrorCount = len(wd.Errors)
+	wd.LastError = ""
+	if wd.ErrorCount == 0 {
+		return
+	}
+
+	lastError := []rune(wd.Errors[wd.ErrorCount-1])
+	if len(lastError) > LastErrorMaxLength {
+		lastError = append(lastError[:LastErrorMaxLength-3], []rune("...")...)
+	}
+	wd.LastError = string(lastError)
+}
+
 // GetKHWorkload returns the workload for the WorkloadDetails struct
 func (wd *WorkloadDetails) GetKHWorkload() KHWorkload {
 	// failsafe if the workload is empty
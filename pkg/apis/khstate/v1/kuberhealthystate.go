@@ -38,6 +38,7 @@ type KuberhealthyStatesGetter interface {
 type KuberhealthyStateInterface interface {
 	Create(*KuberhealthyState) (KuberhealthyState, error)
 	Update(*KuberhealthyState) (KuberhealthyState, error)
+	UpdateStatus(*KuberhealthyState) (KuberhealthyState, error)
 	Delete(name string, options *metav1.DeleteOptions) error
 	DeleteCollection(options *metav1.DeleteOptions, listOptions metav1.ListOptions) error
 	Get(name string, options metav1.GetOptions) (KuberhealthyState, error)
@@ -130,6 +131,20 @@ func (c *kuberhealthyStates) Update(kuberhealthyState *KuberhealthyState) (resul
 	return
 }
 
+// UpdateStatus was generated because the type contains a Status member.
+func (c *kuberhealthyStates) UpdateStatus(kuberhealthyState *KuberhealthyState) (result KuberhealthyState, err error) {
+	result = KuberhealthyState{}
+	err = c.client.Put().
+		Namespace(c.ns).
+		Resource("khstates").
+		Name(kuberhealthyState.Name).
+		SubResource("status").
+		Body(kuberhealthyState).
+		Do(context.TODO()).
+		Into(&result)
+	return
+}
+
 // Delete takes name of the kuberhealthyState and deletes it. Returns an error if one occurs.
 func (c *kuberhealthyStates) Delete(name string, options *metav1.DeleteOptions) error {
 	return c.client.Delete().
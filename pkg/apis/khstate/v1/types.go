@@ -12,10 +12,14 @@ import (
 // +k8s:openapi-gen=true
 // +kubebuilder:printcolumn:name="OK",type=string,JSONPath=`.spec.OK`,description="OK status"
 // +kubebuilder:printcolumn:name="Age LastRun",type=date,JSONPath=`.spec.LastRun`,description="Last Run"
+// +kubebuilder:printcolumn:name="Errors",type=integer,JSONPath=`.spec.ErrorCount`,description="Error count"
+// +kubebuilder:printcolumn:name="Last Error",type=string,JSONPath=`.spec.LastError`,description="Last error (truncated)"
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`,description="Age"
 // +kubebuilder:resource:path="khstates"
 // +kubebuilder:resource:singular="khstate"
 // +kubebuilder:resource:shortName="khs"
+// +kubebuilder:subresource:status
+// +kubebuilder:storageversion
 type KuberhealthyState struct {
 	metav1.TypeMeta `json:",inline" yaml:",inline"`
 	// +optional
@@ -24,6 +28,21 @@ type KuberhealthyState struct {
 	// Spec holds the desired state of the KuberhealthyState (from the client).
 	// +optional
 	Spec WorkloadDetails `json:"spec" yaml:"spec"`
+
+	// Status holds the conditions of the KuberhealthyState, derived from its spec and written through the status
+	// subresource
+	// +optional
+	Status KuberhealthyStateStatus `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+// KuberhealthyStateStatus holds standard Kubernetes conditions that describe the result of a khWorkload, so that
+// generic tooling such as kubectl wait can consume khstates
+// +k8s:openapi-gen=true
+type KuberhealthyStateStatus struct {
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"`
 }
 
 // WorkloadDetails contains details about a single kuberhealthy check or job's current status
@@ -39,10 +58,80 @@ type WorkloadDetails struct {
 	LastRun          *metav1.Time `json:"LastRun,omitempty" yaml:"LastRun,omitempty"` // the time the khWorkload was last run
 	AuthoritativePod string       `json:"AuthoritativePod" yaml:"AuthoritativePod"`   // the main kuberhealthy pod creating and updating the khstate
 	CurrentUUID      string       `json:"uuid" yaml:"uuid"`                           // the UUID that is authorized to report statuses into the kuberhealthy endpoint
+	// +optional
+	ErrorCount int `json:"ErrorCount" yaml:"ErrorCount"` // the number of errors in Errors, used for kubectl printer columns
+	// +optional
+	LastError string `json:"LastError,omitempty" yaml:"LastError,omitempty"` // the last error in Errors truncated to LastErrorMaxLength, used for kubectl printer columns
+	// +optional
+	Stale bool `json:"Stale,omitempty" yaml:"Stale,omitempty"` // true when the khWorkload has not completed a run since Kuberhealthy started and is in its startup grace period
+	// +optional
+	Suppressed bool `json:"Suppressed,omitempty" yaml:"Suppressed,omitempty"` // true when failures of the khWorkload are suppressed by an active maintenance window
+	// +optional
+	SuppressedByDependencies []string `json:"SuppressedByDependencies,omitempty" yaml:"SuppressedByDependencies,omitempty"` // the failing checks the khWorkload depends on, which suppress its failures
+	// +optional
+	Paused bool `json:"Paused,omitempty" yaml:"Paused,omitempty"` // true when the khcheck is paused, so its last result is kept but it is not run
+	// +optional
+	Pending bool `json:"Pending,omitempty" yaml:"Pending,omitempty"` // true while a run of the khcheck is queued because the most check runs allowed at once are in flight
+	// +optional
+	Severity string `json:"Severity,omitempty" yaml:"Severity,omitempty"` // the severity of the Errors, Critical or Warning.  Warning failures do not make the cluster unhealthy.  blank is Critical
+	// +optional
+	SkippedRuns int `json:"SkippedRuns,omitempty" yaml:"SkippedRuns,omitempty"` // the number of runs skipped because the previous run was still in flight and the concurrencyPolicy is Forbid
+	// +optional
+	// +nullable
+	LastSkippedRun *metav1.Time `json:"LastSkippedRun,omitempty" yaml:"LastSkippedRun,omitempty"` // the time a run was last skipped because the previous run was still in flight
+	// +optional
+	ConsecutiveFailures int `json:"ConsecutiveFailures,omitempty" yaml:"ConsecutiveFailures,omitempty"` // the number of failed runs since the last successful run, counted when the khWorkload has a failureThreshold
+	// +optional
+	LastFailedUUID string `json:"LastFailedUUID,omitempty" yaml:"LastFailedUUID,omitempty"` // the UUID of the last failed run that was counted, so repeated reports of a run are counted once
+	// +optional
+	AllowedUUIDs []string `json:"AllowedUUIDs,omitempty" yaml:"AllowedUUIDs,omitempty"` // the UUIDs of overlapping runs that may report in next to the CurrentUUID when the concurrencyPolicy is Allow
+	// +optional
+	LogExcerpt string `json:"LogExcerpt,omitempty" yaml:"LogExcerpt,omitempty"` // the end of the checker pod logs captured when the khWorkload last failed
+	// +optional
+	Containers []ContainerRunStatus `json:"Containers,omitempty" yaml:"Containers,omitempty"` // the final status and last observed resource usage of each checker pod container of the last run
+	// +optional
+	Targets []TargetDetails `json:"Targets,omitempty" yaml:"Targets,omitempty"` // the result for each target of a built-in check that checks several targets
+	// +optional
+	Details map[string]string `json:"Details,omitempty" yaml:"Details,omitempty"` // the extra output reported by the last run of the khWorkload
+	// +optional
+	History []RunResult `json:"History,omitempty" yaml:"History,omitempty"` // the results of the most recent runs of the khWorkload, oldest first
 	// +nullable
 	khWorkload *KHWorkload `json:"khWorkload,omitempty" yaml:"khWorkload,omitempty"`
 }
 
+// ContainerRunStatus is the final status of a checker pod container at the end of a run, along with its last
+// observed resource usage when the metrics API is available
+type ContainerRunStatus struct {
+	Name         string `json:"Name" yaml:"Name"`                               // the name of the container
+	OOMKilled    bool   `json:"OOMKilled,omitempty" yaml:"OOMKilled,omitempty"` // true when the container was killed for running out of memory
+	ExitCode     *int32 `json:"ExitCode,omitempty" yaml:"ExitCode,omitempty"`   // the exit code of the container if it terminated
+	Reason       string `json:"Reason,omitempty" yaml:"Reason,omitempty"`       // the reason the container terminated or is waiting
+	RestartCount int32  `json:"RestartCount" yaml:"RestartCount"`               // the number of times the container restarted
+	CPU          string `json:"CPU,omitempty" yaml:"CPU,omitempty"`             // the last observed CPU usage of the container, such as 25m
+	Memory       string `json:"Memory,omitempty" yaml:"Memory,omitempty"`       // the last observed memory usage of the container, such as 64Mi
+}
+
+// TargetDetails is the result of a single target of a built-in check that checks several targets
+type TargetDetails struct {
+	Target string   `json:"Target" yaml:"Target"`                     // the target that was checked, such as host:port or namespace/name
+	OK     bool     `json:"OK" yaml:"OK"`                             // true when the target passed the check
+	Errors []string `json:"Errors,omitempty" yaml:"Errors,omitempty"` // the errors of the target
+	// +optional
+	// +nullable
+	NotAfter *metav1.Time `json:"NotAfter,omitempty" yaml:"NotAfter,omitempty"` // the time the certificate of the target expires
+	// +optional
+	DaysRemaining int `json:"DaysRemaining,omitempty" yaml:"DaysRemaining,omitempty"` // the whole days until the certificate of the target expires
+}
+
+// RunResult is the result of a single run of a khWorkload, kept in the History of its khstate
+type RunResult struct {
+	Time        metav1.Time `json:"Time" yaml:"Time"`                                   // the time the result of the run was recorded
+	UUID        string      `json:"uuid,omitempty" yaml:"uuid,omitempty"`               // the UUID of the run
+	OK          bool        `json:"OK" yaml:"OK"`                                       // true when the run completed successfully
+	Errors      []string    `json:"Errors,omitempty" yaml:"Errors,omitempty"`           // the errors of the run
+	RunDuration string      `json:"RunDuration,omitempty" yaml:"RunDuration,omitempty"` // the time it took for the run to complete
+}
+
 // KHWorkload is used to describe the different types of kuberhealthy workloads: KhCheck or KHJob
 type KHWorkload string
 
@@ -54,6 +143,9 @@ const (
 	KHJob   KHWorkload = "KHJob"
 )
 
+// LastErrorMaxLength is the maximum length of the LastError summary shown in the khstate printer columns
+const LastErrorMaxLength = 60
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // KuberhealthyStateList is a list of KuberhealthyState resources
@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestPrinterColumnFields ensures that the fields referenced by the khstate printer columns serialize into
+// the JSON paths that the CRD definition expects
+func TestPrinterColumnFields(t *testing.T) {
+	details := NewWorkloadDetails(KHCheck)
+	details.OK = false
+	details.Errors = []string{"first error", strings.Repeat("x", 100)}
+	now := metav1.Now()
+	details.LastRun = &now
+	state := NewKuberhealthyState("test-check", details)
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal("failed to marshal khstate:", err)
+	}
+
+	var out map[string]map[string]interface{}
+	err = json.Unmarshal(b, &out)
+	if err != nil {
+		t.Fatal("failed to unmarshal khstate:", err)
+	}
+
+	spec := out["spec"]
+	for _, field := range []string{"OK", "LastRun", "ErrorCount", "LastError"} {
+		if _, ok := spec[field]; !ok {
+			t.Fatal("expected khstate spec to contain printer column field", field)
+		}
+	}
+
+	if spec["ErrorCount"].(float64) != 2 {
+		t.Fatal("expected ErrorCount of 2 but got", spec["ErrorCount"])
+	}
+
+	lastError := spec["LastError"].(string)
+	if len(lastError) != LastErrorMaxLength {
+		t.Fatal("expected LastError to be truncated to", LastErrorMaxLength, "characters but was", len(lastError))
+	}
+	if !strings.HasSuffix(lastError, "...") {
+		t.Fatal("expected truncated LastError to end with an ellipsis:", lastError)
+	}
+}
+
+// TestSetErrorSummary ensures the error summary is reset when errors clear
+func TestSetErrorSummary(t *testing.T) {
+	details := NewWorkloadDetails(KHCheck)
+	details.Errors = []string{"short error"}
+	details.SetErrorSummary()
+	if details.ErrorCount != 1 || details.LastError != "short error" {
+		t.Fatal("unexpected error summary:", details.ErrorCount, details.LastError)
+	}
+
+	details.Errors = []string{}
+	details.SetErrorSummary()
+	if details.ErrorCount != 0 || details.LastError != "" {
+		t.Fatal("expected error summary to be cleared but got:", details.ErrorCount, details.LastError)
+	}
+}
+
+// TestWorkloadDetailsDeepCopy ensures the last run time is copied instead of shared, and that khstates that have
+// not run yet can be copied
+func TestWorkloadDetailsDeepCopy(t *testing.T) {
+	details := NewWorkloadDetails(KHCheck)
+	if details.DeepCopy().LastRun != nil {
+		t.Fatal("expected a copy without a last run")
+	}
+
+	now := metav1.Now()
+	details.LastRun = &now
+	out := details.DeepCopy()
+	if out.LastRun == details.LastRun || !out.LastRun.Equal(details.LastRun) {
+		t.Fatal("expected the last run time to be copied but got", out.LastRun)
+	}
+}
@@ -0,0 +1,105 @@
+package v2
+
+import (
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// V1APIVersion is the apiVersion of the v1 khstates that are stored
+const V1APIVersion = "comcast.github.io/v1"
+
+// ConvertFrom sets the khstate from a v1 khstate.  The scheduling fields of the v1 spec stay in the spec, and the
+// results of runs move to the status.
+func (s *KuberhealthyState) ConvertFrom(src *khstatev1.KuberhealthyState) {
+	src = src.DeepCopy()
+	s.TypeMeta = src.TypeMeta
+	s.APIVersion = SchemeGroupVersion.String()
+	s.ObjectMeta = src.ObjectMeta
+
+	in := src.Spec
+	s.Spec = KuberhealthyStateSpec{
+		Namespace:        in.Namespace,
+		AuthoritativePod: in.AuthoritativePod,
+		CurrentUUID:      in.CurrentUUID,
+		AllowedUUIDs:     in.AllowedUUIDs,
+		Paused:           in.Paused,
+		Severity:         in.Severity,
+	}
+	s.Status = KuberhealthyStateStatus{
+		OK:                       in.OK,
+		Errors:                   in.Errors,
+		ErrorCount:               in.ErrorCount,
+		LastError:                in.LastError,
+		RunDuration:              in.RunDuration,
+		Node:                     in.Node,
+		LastRun:                  in.LastRun,
+		Stale:                    in.Stale,
+		Pending:                  in.Pending,
+		Suppressed:               in.Suppressed,
+		SuppressedByDependencies: in.SuppressedByDependencies,
+		SkippedRuns:              in.SkippedRuns,
+		LastSkippedRun:           in.LastSkippedRun,
+		ConsecutiveFailures:      in.ConsecutiveFailures,
+		LastFailedUUID:           in.LastFailedUUID,
+		LogExcerpt:               in.LogExcerpt,
+		Details:                  in.Details,
+		Conditions:               src.Status.Conditions,
+	}
+	for _, c := range in.Containers {
+		s.Status.Containers = append(s.Status.Containers, ContainerStatus(c))
+	}
+	for _, t := range in.Targets {
+		s.Status.Targets = append(s.Status.Targets, TargetResult(t))
+	}
+	for _, r := range in.History {
+		s.Status.History = append(s.Status.History, RunResult(r))
+	}
+}
+
+// ConvertTo sets a v1 khstate from the khstate
+func (s *KuberhealthyState) ConvertTo(dst *khstatev1.KuberhealthyState) {
+	src := s.DeepCopy()
+	dst.TypeMeta = src.TypeMeta
+	dst.APIVersion = V1APIVersion
+	dst.ObjectMeta = src.ObjectMeta
+
+	in := src.Status
+	dst.Spec = khstatev1.WorkloadDetails{
+		OK:                       in.OK,
+		Errors:                   in.Errors,
+		RunDuration:              in.RunDuration,
+		Namespace:                src.Spec.Namespace,
+		Node:                     in.Node,
+		LastRun:                  in.LastRun,
+		AuthoritativePod:         src.Spec.AuthoritativePod,
+		CurrentUUID:              src.Spec.CurrentUUID,
+		ErrorCount:               in.ErrorCount,
+		LastError:                in.LastError,
+		Stale:                    in.Stale,
+		Pending:                  in.Pending,
+		Suppressed:               in.Suppressed,
+		SuppressedByDependencies: in.SuppressedByDependencies,
+		Paused:                   src.Spec.Paused,
+		Severity:                 src.Spec.Severity,
+		SkippedRuns:              in.SkippedRuns,
+		LastSkippedRun:           in.LastSkippedRun,
+		ConsecutiveFailures:      in.ConsecutiveFailures,
+		LastFailedUUID:           in.LastFailedUUID,
+		AllowedUUIDs:             src.Spec.AllowedUUIDs,
+		LogExcerpt:               in.LogExcerpt,
+		Details:                  in.Details,
+	}
+	// v1 khstates always list their errors
+	if dst.Spec.Errors == nil {
+		dst.Spec.Errors = []string{}
+	}
+	for _, c := range in.Containers {
+		dst.Spec.Containers = append(dst.Spec.Containers, khstatev1.ContainerRunStatus(c))
+	}
+	for _, t := range in.Targets {
+		dst.Spec.Targets = append(dst.Spec.Targets, khstatev1.TargetDetails(t))
+	}
+	for _, r := range in.History {
+		dst.Spec.History = append(dst.Spec.History, khstatev1.RunResult(r))
+	}
+	dst.Status = khstatev1.KuberhealthyStateStatus{Conditions: in.Conditions}
+}
@@ -0,0 +1,88 @@
+package v2
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// testV1State returns a v1 khstate with every exported spec field set
+func testV1State() khstatev1.KuberhealthyState {
+	now := metav1.Now()
+	exitCode := int32(1)
+	state := khstatev1.KuberhealthyState{Spec: khstatev1.WorkloadDetails{
+		OK:                       false,
+		Errors:                   []string{"timed out"},
+		RunDuration:              "1m0s",
+		Namespace:                "kuberhealthy",
+		Node:                     "node-1",
+		LastRun:                  &now,
+		AuthoritativePod:         "kuberhealthy-abc",
+		CurrentUUID:              "uuid-2",
+		ErrorCount:               1,
+		LastError:                "timed out",
+		Stale:                    true,
+		Suppressed:               true,
+		SuppressedByDependencies: []string{"kube-system/coredns"},
+		Paused:                   true,
+		Pending:                  true,
+		Severity:                 "Warning",
+		SkippedRuns:              2,
+		LastSkippedRun:           &now,
+		ConsecutiveFailures:      3,
+		LastFailedUUID:           "uuid-1",
+		AllowedUUIDs:             []string{"uuid-0"},
+		LogExcerpt:               "dial tcp: i/o timeout",
+		Containers:               []khstatev1.ContainerRunStatus{{Name: "main", ExitCode: &exitCode, Reason: "Error", CPU: "25m"}},
+		Targets:                  []khstatev1.TargetDetails{{Target: "example.com:443", OK: true, NotAfter: &now, DaysRemaining: 30}},
+		Details:                  map[string]string{"latency": "12ms"},
+		History:                  []khstatev1.RunResult{{Time: now, UUID: "uuid-1", Errors: []string{"timed out"}, RunDuration: "1m0s"}},
+	}}
+	state.APIVersion = V1APIVersion
+	state.Kind = "KuberhealthyState"
+	state.Name = "dns"
+	state.Namespace = "kuberhealthy"
+	state.Status.Conditions = []metav1.Condition{{Type: khstatev1.ConditionHealthy, Status: metav1.ConditionFalse, Reason: khstatev1.ReasonRunFailed, LastTransitionTime: now}}
+	return state
+}
+
+// TestConvertState ensures a v1 khstate converts to v2 and back without losing any fields
+func TestConvertState(t *testing.T) {
+	src := testV1State()
+	v := reflect.ValueOf(src.Spec)
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if field.IsExported() && field.Name != "OK" && v.Field(i).IsZero() {
+			t.Fatal("expected the test khstate to set", field.Name, "so that its conversion is covered")
+		}
+	}
+
+	var s KuberhealthyState
+	s.ConvertFrom(&src)
+	if s.APIVersion != "comcast.github.io/v2" || s.Spec.CurrentUUID != "uuid-2" || s.Status.ErrorCount != 1 || s.Status.History[0].UUID != "uuid-1" {
+		t.Fatal("expected the khstate to be converted but got", s)
+	}
+	if len(s.Status.Conditions) != 1 {
+		t.Fatal("expected the conditions to be kept but got", s.Status.Conditions)
+	}
+
+	var dst khstatev1.KuberhealthyState
+	s.ConvertTo(&dst)
+	if !reflect.DeepEqual(src, dst) {
+		t.Fatalf("expected the khstate to convert back to\n%+v\nbut got\n%+v", src, dst)
+	}
+}
+
+// TestConvertStateWithoutErrors ensures v1 khstates converted from v2 always list their errors
+func TestConvertStateWithoutErrors(t *testing.T) {
+	var s KuberhealthyState
+	s.Status.OK = true
+	var dst khstatev1.KuberhealthyState
+	s.ConvertTo(&dst)
+	if dst.Spec.Errors == nil || dst.APIVersion != V1APIVersion {
+		t.Fatal("expected an empty list of errors but got", dst.Spec.Errors)
+	}
+}
@@ -0,0 +1,7 @@
+// +k8s:deepcopy-gen=package
+// +k8s:defaulter-gen=TypeMeta
+// +groupName=comcast.github.io
+
+// Package v2 is the v2 API of khstates.  khstates are stored as v1, and v2 khstates are converted to and from v1 by
+// the conversion webhook of Kuberhealthy.
+package v2
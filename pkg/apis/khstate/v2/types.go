@@ -0,0 +1,146 @@
+package v2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// KuberhealthyState represents the state of a khjob or khcheck for Kuberhealthy.  Compared to v1, the scheduling of
+// runs is kept in the spec and their results are kept in the status.
+// +k8s:openapi-gen=true
+// +kubebuilder:printcolumn:name="OK",type=boolean,JSONPath=`.status.ok`,description="OK status"
+// +kubebuilder:printcolumn:name="Age LastRun",type=date,JSONPath=`.status.lastRun`,description="Last Run"
+// +kubebuilder:printcolumn:name="Errors",type=integer,JSONPath=`.status.errorCount`,description="Error count"
+// +kubebuilder:printcolumn:name="Last Error",type=string,JSONPath=`.status.lastError`,description="Last error (truncated)"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`,description="Age"
+// +kubebuilder:resource:path="khstates"
+// +kubebuilder:resource:singular="khstate"
+// +kubebuilder:resource:shortName="khs"
+// +kubebuilder:subresource:status
+// +kubebuilder:unservedversion
+type KuberhealthyState struct {
+	metav1.TypeMeta `json:",inline" yaml:",inline"`
+	// +optional
+	metav1.ObjectMeta `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+
+	// Spec holds the runs of the khWorkload Kuberhealthy has scheduled
+	// +optional
+	Spec KuberhealthyStateSpec `json:"spec" yaml:"spec"`
+
+	// Status holds the results of the runs of the khWorkload
+	// +optional
+	Status KuberhealthyStateStatus `json:"status,omitempty" yaml:"status,omitempty"`
+}
+
+// KuberhealthyStateSpec holds the runs of a khWorkload that Kuberhealthy has scheduled and the settings of the
+// khWorkload its results are judged with
+// +k8s:openapi-gen=true
+type KuberhealthyStateSpec struct {
+	// +optional
+	Namespace string `json:"namespace,omitempty" yaml:"namespace,omitempty"` // the namespace the khWorkload was run in
+	// +optional
+	AuthoritativePod string `json:"authoritativePod,omitempty" yaml:"authoritativePod,omitempty"` // the main kuberhealthy pod creating and updating the khstate
+	// +optional
+	CurrentUUID string `json:"currentUUID,omitempty" yaml:"currentUUID,omitempty"` // the UUID that is authorized to report statuses into the kuberhealthy endpoint
+	// +optional
+	AllowedUUIDs []string `json:"allowedUUIDs,omitempty" yaml:"allowedUUIDs,omitempty"` // the UUIDs of overlapping runs that may report in next to the currentUUID
+	// +optional
+	Paused bool `json:"paused,omitempty" yaml:"paused,omitempty"` // true when the khcheck is paused, so its last result is kept but it is not run
+	// +optional
+	Severity string `json:"severity,omitempty" yaml:"severity,omitempty"` // the severity of the errors, Critical or Warning. blank is Critical
+}
+
+// KuberhealthyStateStatus holds the result of the last run of a khWorkload and the results of its recent runs
+// +k8s:openapi-gen=true
+type KuberhealthyStateStatus struct {
+	// +optional
+	OK bool `json:"ok" yaml:"ok"` // true when the last run of the khWorkload completed successfully
+	// +optional
+	Errors []string `json:"errors,omitempty" yaml:"errors,omitempty"` // the errors reported by the last run
+	// +optional
+	ErrorCount int `json:"errorCount,omitempty" yaml:"errorCount,omitempty"` // the number of errors, used for kubectl printer columns
+	// +optional
+	LastError string `json:"lastError,omitempty" yaml:"lastError,omitempty"` // the last error truncated for kubectl printer columns
+	// +optional
+	RunDuration string `json:"runDuration,omitempty" yaml:"runDuration,omitempty"` // the time it took for the last run to complete
+	// +optional
+	Node string `json:"node,omitempty" yaml:"node,omitempty"` // the node the last run ran on
+	// +optional
+	// +nullable
+	LastRun *metav1.Time `json:"lastRun,omitempty" yaml:"lastRun,omitempty"` // the time the khWorkload was last run
+	// +optional
+	Stale bool `json:"stale,omitempty" yaml:"stale,omitempty"` // true when the khWorkload has not completed a run since Kuberhealthy started
+	// +optional
+	Pending bool `json:"pending,omitempty" yaml:"pending,omitempty"` // true while a run of the khWorkload is queued because the most check runs allowed at once are in flight
+	// +optional
+	Suppressed bool `json:"suppressed,omitempty" yaml:"suppressed,omitempty"` // true when failures are suppressed by an active maintenance window
+	// +optional
+	SuppressedByDependencies []string `json:"suppressedByDependencies,omitempty" yaml:"suppressedByDependencies,omitempty"` // the failing checks the khWorkload depends on
+	// +optional
+	SkippedRuns int `json:"skippedRuns,omitempty" yaml:"skippedRuns,omitempty"` // the number of runs skipped because the previous run was still in flight
+	// +optional
+	// +nullable
+	LastSkippedRun *metav1.Time `json:"lastSkippedRun,omitempty" yaml:"lastSkippedRun,omitempty"` // the time a run was last skipped
+	// +optional
+	ConsecutiveFailures int `json:"consecutiveFailures,omitempty" yaml:"consecutiveFailures,omitempty"` // the number of failed runs since the last successful run
+	// +optional
+	LastFailedUUID string `json:"lastFailedUUID,omitempty" yaml:"lastFailedUUID,omitempty"` // the UUID of the last failed run that was counted
+	// +optional
+	LogExcerpt string `json:"logExcerpt,omitempty" yaml:"logExcerpt,omitempty"` // the end of the checker pod logs captured when the khWorkload last failed
+	// +optional
+	Containers []ContainerStatus `json:"containers,omitempty" yaml:"containers,omitempty"` // the final status and last observed resource usage of each checker pod container
+	// +optional
+	Targets []TargetResult `json:"targets,omitempty" yaml:"targets,omitempty"` // the result for each target of a built-in check that checks several targets
+	// +optional
+	Details map[string]string `json:"details,omitempty" yaml:"details,omitempty"` // the extra output reported by the last run
+	// +optional
+	History []RunResult `json:"history,omitempty" yaml:"history,omitempty"` // the results of the most recent runs, oldest first
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" yaml:"conditions,omitempty"` // standard conditions derived from the result of the last run
+}
+
+// ContainerStatus is the final status of a checker pod container at the end of a run
+type ContainerStatus struct {
+	Name         string `json:"name" yaml:"name"`                               // the name of the container
+	OOMKilled    bool   `json:"oomKilled,omitempty" yaml:"oomKilled,omitempty"` // true when the container was killed for running out of memory
+	ExitCode     *int32 `json:"exitCode,omitempty" yaml:"exitCode,omitempty"`   // the exit code of the container if it terminated
+	Reason       string `json:"reason,omitempty" yaml:"reason,omitempty"`       // the reason the container terminated or is waiting
+	RestartCount int32  `json:"restartCount" yaml:"restartCount"`               // the number of times the container restarted
+	CPU          string `json:"cpu,omitempty" yaml:"cpu,omitempty"`             // the last observed CPU usage of the container, such as 25m
+	Memory       string `json:"memory,omitempty" yaml:"memory,omitempty"`       // the last observed memory usage of the container, such as 64Mi
+}
+
+// TargetResult is the result of a single target of a built-in check that checks several targets
+type TargetResult struct {
+	Target string   `json:"target" yaml:"target"`                     // the target that was checked, such as host:port or namespace/name
+	OK     bool     `json:"ok" yaml:"ok"`                             // true when the target passed the check
+	Errors []string `json:"errors,omitempty" yaml:"errors,omitempty"` // the errors of the target
+	// +optional
+	// +nullable
+	NotAfter *metav1.Time `json:"notAfter,omitempty" yaml:"notAfter,omitempty"` // the time the certificate of the target expires
+	// +optional
+	DaysRemaining int `json:"daysRemaining,omitempty" yaml:"daysRemaining,omitempty"` // the whole days until the certificate of the target expires
+}
+
+// RunResult is the result of a single run of a khWorkload, kept in the history of its khstate
+type RunResult struct {
+	Time        metav1.Time `json:"time" yaml:"time"`                                   // the time the result of the run was recorded
+	UUID        string      `json:"uuid,omitempty" yaml:"uuid,omitempty"`               // the UUID of the run
+	OK          bool        `json:"ok" yaml:"ok"`                                       // true when the run completed successfully
+	Errors      []string    `json:"errors,omitempty" yaml:"errors,omitempty"`           // the errors of the run
+	RunDuration string      `json:"runDuration,omitempty" yaml:"runDuration,omitempty"` // the time it took for the run to complete
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// KuberhealthyStateList is a list of KuberhealthyState resources
+type KuberhealthyStateList struct {
+	metav1.TypeMeta `json:",inline" yaml:",inline"`
+	metav1.ListMeta `json:"metadata" yaml:"metadata"`
+
+	Items []KuberhealthyState `json:"items" yaml:"items"`
+}
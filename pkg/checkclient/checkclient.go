@@ -0,0 +1,100 @@
+// Package checkclient reports the result of an external check run to Kuberhealthy with a single call to Report.
+// Reports are sent over gRPC when a gRPC address is configured and over HTTP to the KH_REPORTING_URL otherwise, so
+// check authors can switch transports without changing their checks.  Run wraps a check function with the deadline of
+// the run and reports its result, so check images do not have to handle the report protocol themselves.
+package checkclient
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	grpcstatus "google.golang.org/grpc/status"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+	httpclient "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+)
+
+// GRPCAddressEnv is the environment variable that holds the host:port of the Kuberhealthy gRPC check report server.
+// Check authors set it in the podSpec of their khcheck to report over gRPC.
+const GRPCAddressEnv = "KH_REPORTING_GRPC_ADDRESS"
+
+// maxElapsedTime is how long reports are retried for
+const maxElapsedTime = time.Second * 30
+
+// Options configure how a report is sent.  The zero value reports over gRPC if GRPCAddressEnv is set and over HTTP
+// otherwise.
+type Options struct {
+	GRPCAddress string            // the host:port of the Kuberhealthy gRPC server. defaults to the GRPCAddressEnv environment variable
+	TLSConfig   *tls.Config       // the TLS configuration of the gRPC connection, including a client certificate for mTLS. nil connects without TLS
+	Details     map[string]string // extra output of the check run shown with the check on the status page. see status.Report.ValidateDetails for the limits
+	Severity    string            // the severity of failed reports. status.SeverityWarning failures do not make the cluster unhealthy. defaults to status.SeverityCritical
+}
+
+// Report reports the result of a check run to Kuberhealthy.  The check passed when no error messages are supplied.
+// Failed reports are retried for a short time before an error is returned.
+func Report(ctx context.Context, errorMessages []string, opts Options) error {
+	address := opts.GRPCAddress
+	if len(address) == 0 {
+		address = os.Getenv(GRPCAddressEnv)
+	}
+	if len(address) == 0 {
+		switch {
+		case len(errorMessages) == 0:
+			return httpclient.ReportSuccessWithDetails(opts.Details)
+		case opts.Severity == status.SeverityWarning:
+			return httpclient.ReportWarningWithDetails(errorMessages, opts.Details)
+		}
+		return httpclient.ReportFailureWithDetails(errorMessages, opts.Details)
+	}
+	report := status.NewReport(errorMessages)
+	report.Details = opts.Details
+	if !report.OK {
+		report.Severity = opts.Severity
+	}
+	return reportGRPC(ctx, address, opts.TLSConfig, report)
+}
+
+// reportGRPC sends a report to the Kuberhealthy gRPC server at address
+func reportGRPC(ctx context.Context, address string, tlsConfig *tls.Config, report status.Report) error {
+	runUUID := os.Getenv(external.KHRunUUID)
+	if len(runUUID) == 0 {
+		return fmt.Errorf("fetched %s environment variable but it was blank", external.KHRunUUID)
+	}
+
+	creds := insecure.NewCredentials()
+	if tlsConfig != nil {
+		creds = credentials.NewTLS(tlsConfig)
+	}
+	conn, err := grpc.DialContext(ctx, address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return fmt.Errorf("failed to connect to the kuberhealthy gRPC server at %s: %w", address, err)
+	}
+	defer conn.Close()
+
+	client := status.NewCheckReporterClient(conn)
+	req := &status.ReportCheckStatusRequest{RunUuid: runUUID, Ok: report.OK, Errors: report.Errors, Details: report.Details, Severity: report.Severity, ReportToken: os.Getenv(external.KHReportToken)}
+	exponentialBackOff := backoff.NewExponentialBackOff()
+	exponentialBackOff.MaxElapsedTime = maxElapsedTime
+	err = backoff.Retry(func() error {
+		_, err := client.ReportCheckStatus(ctx, req)
+		// reports that kuberhealthy refused are not retried
+		switch grpcstatus.Code(err) {
+		case codes.InvalidArgument, codes.PermissionDenied, codes.Unimplemented:
+			return backoff.Permanent(err)
+		}
+		return err
+	}, backoff.WithContext(exponentialBackOff, ctx))
+	if err != nil {
+		return fmt.Errorf("failed to report to the kuberhealthy gRPC server at %s: %w", address, err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package checkclient
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	httpclient "github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/checkclient"
+)
+
+// DefaultMargin is how long before the deadline of the run checks started with Run are canceled, leaving time to clean
+// up and report
+const DefaultMargin = time.Second * 30
+
+// CheckFunc runs a check and returns the error messages of the run, or none when the check passed, along with the
+// details shown with the check on the status page.  The context is canceled when the check runs out of time.
+type CheckFunc func(ctx context.Context) (errorMessages []string, details map[string]string)
+
+// Deadline returns the time Kuberhealthy expects a report of the current run by, which it passes to the checker pod
+// in the KH_CHECK_RUN_DEADLINE environment variable
+func Deadline() (time.Time, error) {
+	return httpclient.GetDeadline()
+}
+
+// WithDeadline returns a context that is canceled margin before the deadline of the run.  When the checker pod was
+// not given a deadline, the context is only canceled with its parent.
+func WithDeadline(parent context.Context, margin time.Duration) (context.Context, context.CancelFunc) {
+	deadline, err := Deadline()
+	if err != nil {
+		return context.WithCancel(parent)
+	}
+	return context.WithDeadline(parent, deadline.Add(-margin))
+}
+
+// ReportSuccess reports to Kuberhealthy that the check run passed
+func ReportSuccess(ctx context.Context, opts Options) error {
+	return Report(ctx, nil, opts)
+}
+
+// ReportFailure reports to Kuberhealthy that the check run failed with the supplied error messages
+func ReportFailure(ctx context.Context, errorMessages []string, opts Options) error {
+	if len(errorMessages) == 0 {
+		return fmt.Errorf("a failure must be reported with at least one error message")
+	}
+	return Report(ctx, errorMessages, opts)
+}
+
+// Run runs a check within the deadline of the run and reports its result to Kuberhealthy.  A check that does not
+// return DefaultMargin before the deadline, or that panics, is reported as failed, so that Kuberhealthy hears of it
+// before the run times out.  The details returned by the check replace the details of the options.
+func Run(check CheckFunc, opts Options) error {
+	ctx, cancel := WithDeadline(context.Background(), DefaultMargin)
+	defer cancel()
+
+	errorMessages, details := runCheck(ctx, check)
+	if details != nil {
+		opts.Details = details
+	}
+	if len(errorMessages) > 0 {
+		log.Println("Reporting errors to Kuberhealthy:", errorMessages)
+	}
+
+	// the context of the check may be over already, so the report gets its own
+	reportCtx, reportCancel := context.WithTimeout(context.Background(), maxElapsedTime)
+	defer reportCancel()
+	return Report(reportCtx, errorMessages, opts)
+}
+
+// runCheck runs a check until it returns or the context ends
+func runCheck(ctx context.Context, check CheckFunc) ([]string, map[string]string) {
+	type result struct {
+		errorMessages []string
+		details       map[string]string
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- result{errorMessages: []string{fmt.Sprintf("check panicked: %v", r)}}
+			}
+		}()
+		errorMessages, details := check(ctx)
+		done <- result{errorMessages: errorMessages, details: details}
+	}()
+
+	select {
+	case r := <-done:
+		return r.errorMessages, r.details
+	case <-ctx.Done():
+		return []string{fmt.Sprintf("check did not finish before its deadline: %s", ctx.Err())}, nil
+	}
+}
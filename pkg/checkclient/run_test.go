@@ -0,0 +1,153 @@
+package checkclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+)
+
+// testReportServer starts a server that records the reports sent to it and points the check client at it
+func testReportServer(t *testing.T) *[]status.Report {
+	var reports []status.Report
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("kh-run-uuid") != "test-uuid" {
+			t.Error("expected the run uuid header but got", r.Header.Get("kh-run-uuid"))
+		}
+		var report status.Report
+		err := json.NewDecoder(r.Body).Decode(&report)
+		if err != nil {
+			t.Error("failed to decode report:", err)
+		}
+		reports = append(reports, report)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv(external.KHReportingURL, server.URL)
+	t.Setenv(external.KHRunUUID, "test-uuid")
+	t.Setenv(GRPCAddressEnv, "")
+	return &reports
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		check   CheckFunc
+		ok      bool
+		errors  string
+		details map[string]string
+	}{
+		{
+			name:    "success",
+			check:   func(ctx context.Context) ([]string, map[string]string) { return nil, map[string]string{"zone": "a"} },
+			ok:      true,
+			details: map[string]string{"zone": "a"},
+		},
+		{
+			name:    "failure",
+			check:   func(ctx context.Context) ([]string, map[string]string) { return []string{"node not ready"}, nil },
+			errors:  "node not ready",
+			details: map[string]string{"default": "detail"},
+		},
+		{
+			name:    "panic",
+			check:   func(ctx context.Context) ([]string, map[string]string) { panic("nil map") },
+			errors:  "check panicked: nil map",
+			details: map[string]string{"default": "detail"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reports := testReportServer(t)
+			err := Run(test.check, Options{Details: map[string]string{"default": "detail"}})
+			if err != nil {
+				t.Fatal("failed to run check:", err)
+			}
+			if len(*reports) != 1 {
+				t.Fatal("expected a single report but got", *reports)
+			}
+			report := (*reports)[0]
+			if report.OK != test.ok || strings.Join(report.Errors, "; ") != test.errors {
+				t.Fatal("expected a report with ok", test.ok, "and errors", test.errors, "but got", report)
+			}
+			if len(report.Details) != len(test.details) {
+				t.Fatal("expected details", test.details, "but got", report.Details)
+			}
+			for k, v := range test.details {
+				if report.Details[k] != v {
+					t.Fatal("expected details", test.details, "but got", report.Details)
+				}
+			}
+		})
+	}
+}
+
+func TestRunCheckDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	errs, _ := runCheck(ctx, func(ctx context.Context) ([]string, map[string]string) {
+		time.Sleep(time.Second)
+		return nil, nil
+	})
+	if len(errs) != 1 || !strings.Contains(errs[0], "check did not finish before its deadline") {
+		t.Fatal("expected the check to run out of time but got", errs)
+	}
+}
+
+func TestWithDeadline(t *testing.T) {
+	deadline := time.Now().Add(time.Minute * 5).Truncate(time.Second)
+	t.Setenv(external.KHDeadline, strconv.FormatInt(deadline.Unix(), 10))
+	ctx, cancel := WithDeadline(context.Background(), time.Minute)
+	defer cancel()
+	got, ok := ctx.Deadline()
+	if !ok || !got.Equal(deadline.Add(-time.Minute)) {
+		t.Fatal("expected the context to end a minute before", deadline, "but got", got)
+	}
+
+	t.Setenv(external.KHDeadline, "")
+	ctx, cancel = WithDeadline(context.Background(), time.Minute)
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatal("expected no deadline without", external.KHDeadline)
+	}
+}
+
+func TestReportFailureRequiresErrors(t *testing.T) {
+	reports := testReportServer(t)
+	err := ReportFailure(context.Background(), nil, Options{})
+	if err == nil {
+		t.Fatal("expected a failure without error messages to be refused")
+	}
+	if len(*reports) != 0 {
+		t.Fatal("expected nothing to be reported but got", *reports)
+	}
+}
+
+func TestReportSeverity(t *testing.T) {
+	reports := testReportServer(t)
+	opts := Options{Severity: status.SeverityWarning}
+	err := ReportFailure(context.Background(), []string{"certificate expires in 10 days"}, opts)
+	if err != nil {
+		t.Fatal("failed to report failure:", err)
+	}
+	err = ReportSuccess(context.Background(), opts)
+	if err != nil {
+		t.Fatal("failed to report success:", err)
+	}
+	if len(*reports) != 2 {
+		t.Fatal("expected two reports but got", *reports)
+	}
+	if (*reports)[0].Severity != status.SeverityWarning {
+		t.Fatal("expected the failure to be a warning but got", (*reports)[0])
+	}
+	if (*reports)[1].Severity != "" {
+		t.Fatal("expected the success to have no severity but got", (*reports)[1])
+	}
+}
@@ -0,0 +1,141 @@
+package daemonset
+
+import (
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// nodeArchLabel is the well known node label holding the architecture of a node
+const nodeArchLabel = "kubernetes.io/arch"
+
+// dsArchLabel is the label used to tell apart the daemonsets deployed for each architecture
+const dsArchLabel = "kh-arch"
+
+// defaultDSArch is the dsArchLabel value of the daemonset that runs the default pause container image
+const defaultDSArch = "default"
+
+// podProblemReasons are the container waiting reasons that keep a daemonset pod from ever coming online
+var podProblemReasons = map[string]bool{
+	"ImagePullBackOff":     true,
+	"ErrImagePull":         true,
+	"InvalidImageName":     true,
+	"CrashLoopBackOff":     true,
+	"CreateContainerError": true,
+}
+
+// ParseImagesByArch parses a comma separated list of arch=image pairs, such as
+// arm64=registry/pause-arm64:3.2,amd64=registry/pause:3.2
+func ParseImagesByArch(s string) map[string]string {
+	imagesByArch := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
+		archImage := strings.SplitN(pair, "=", 2)
+		if len(archImage) != 2 || len(archImage[0]) == 0 || len(archImage[1]) == 0 {
+			log.Warnln("Unable to parse arch=image pair:", pair)
+			continue
+		}
+		imagesByArch[archImage[0]] = archImage[1]
+	}
+	return imagesByArch
+}
+
+// sortedArchs returns the architectures with a pause container image mapping in a stable order
+func (dsc *Checker) sortedArchs() []string {
+	var archs []string
+	for arch := range dsc.PauseContainerImageByArch {
+		archs = append(archs, arch)
+	}
+	sort.Strings(archs)
+	return archs
+}
+
+// archDaemonSetNames returns the names of all daemonsets deployed for a check run.  When pause container images
+// are mapped by architecture, a daemonset is deployed for each mapped architecture next to the default daemonset.
+func (dsc *Checker) archDaemonSetNames(dsName string) []string {
+	names := []string{dsName}
+	for _, arch := range dsc.sortedArchs() {
+		names = append(names, dsName+"-"+arch)
+	}
+	return names
+}
+
+// generateArchDaemonSetSpecs splits the daemonset spec into one daemonset per mapped architecture using the mapped
+// pause container image.  Each is restricted to nodes of its architecture with node affinity, while the supplied
+// daemonset keeps the default image and runs on all other nodes.  Without mapped architectures, only the supplied
+// daemonset is returned.
+func (dsc *Checker) generateArchDaemonSetSpecs(daemonSet *appsv1.DaemonSet) []*appsv1.DaemonSet {
+	archs := dsc.sortedArchs()
+	if len(archs) == 0 {
+		return []*appsv1.DaemonSet{daemonSet}
+	}
+
+	var daemonSets []*appsv1.DaemonSet
+	for _, arch := range archs {
+		archDaemonSet := daemonSet.DeepCopy()
+		archDaemonSet.Name = daemonSet.Name + "-" + arch
+		setDaemonSetArch(archDaemonSet, arch, apiv1.NodeSelectorOpIn, []string{arch})
+		archDaemonSet.Spec.Template.Spec.Containers[0].Image = dsc.PauseContainerImageByArch[arch]
+		daemonSets = append(daemonSets, archDaemonSet)
+	}
+
+	setDaemonSetArch(daemonSet, defaultDSArch, apiv1.NodeSelectorOpNotIn, archs)
+	return append([]*appsv1.DaemonSet{daemonSet}, daemonSets...)
+}
+
+// setDaemonSetArch labels a daemonset and its pods with its architecture and adds a node affinity for the supplied
+// architectures next to the node exclusions.  The label keeps the selectors of the daemonsets of a check run from
+// overlapping.
+func setDaemonSetArch(daemonSet *appsv1.DaemonSet, archLabel string, operator apiv1.NodeSelectorOperator, archs []string) {
+	daemonSet.Labels[dsArchLabel] = archLabel
+	daemonSet.Spec.Selector.MatchLabels[dsArchLabel] = archLabel
+	daemonSet.Spec.Template.Labels[dsArchLabel] = archLabel
+	addNodeAffinity(&daemonSet.Spec.Template.Spec, apiv1.NodeSelectorRequirement{
+		Key:      nodeArchLabel,
+		Operator: operator,
+		Values:   archs,
+	})
+}
+
+// describePodProblems describes daemonset pods with containers that are unable to start, such as pods that can not
+// pull their image, along with the node and its architecture.  Used for error messaging.
+func describePodProblems(pods []apiv1.Pod, nodes []apiv1.Node) []string {
+	nodeArchs := make(map[string]string)
+	for _, n := range nodes {
+		nodeArchs[n.Name] = n.Labels[nodeArchLabel]
+	}
+
+	var problems []string
+	for _, p := range pods {
+		for _, c := range p.Status.ContainerStatuses {
+			if c.State.Waiting == nil || !podProblemReasons[c.State.Waiting.Reason] {
+				continue
+			}
+			arch := nodeArchs[p.Spec.NodeName]
+			if len(arch) == 0 {
+				arch = "unknown"
+			}
+			problem := "pod " + p.Name + " on node " + p.Spec.NodeName + " (arch " + arch + ") is in " +
+				c.State.Waiting.Reason + " with image " + c.Image
+			if len(c.State.Waiting.Message) > 0 {
+				problem += ": " + c.State.Waiting.Message
+			}
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+// formatPodProblems formats the daemonset pod problems for error messages
+func formatPodProblems(problems []string) string {
+	if len(problems) == 0 {
+		return ""
+	}
+	return " Daemonset pod problems: " + strings.Join(problems, "; ")
+}
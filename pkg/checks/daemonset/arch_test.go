@@ -0,0 +1,137 @@
+package daemonset
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseImagesByArch(t *testing.T) {
+	imagesByArch := ParseImagesByArch("arm64=registry/pause-arm64:3.2, amd64=registry/pause:3.2,invalid,=missing-arch,")
+	if len(imagesByArch) != 2 {
+		t.Fatal("expected two parsed images but got:", imagesByArch)
+	}
+	if imagesByArch["arm64"] != "registry/pause-arm64:3.2" || imagesByArch["amd64"] != "registry/pause:3.2" {
+		t.Fatal("unexpected parsed images:", imagesByArch)
+	}
+}
+
+func TestGenerateArchDaemonSetSpecs(t *testing.T) {
+	daemonSet := &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "daemonset-test", Labels: map[string]string{"kh-app": "daemonset-test"}},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"kh-app": "daemonset-test"}},
+			Template: apiv1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"kh-app": "daemonset-test"}},
+				Spec: apiv1.PodSpec{
+					Containers: []apiv1.Container{{Name: "sleep", Image: DefaultPauseContainerImage}},
+				},
+			},
+		},
+	}
+
+	dsc := newTestChecker(t)
+	daemonSets := dsc.generateArchDaemonSetSpecs(daemonSet)
+	if len(daemonSets) != 1 || daemonSets[0].Spec.Template.Spec.Affinity != nil {
+		t.Fatal("expected only the default daemonset without node affinity when no images are mapped by arch")
+	}
+
+	dsc.PauseContainerImageByArch = map[string]string{"arm64": "registry/pause-arm64:3.2"}
+	daemonSets = dsc.generateArchDaemonSetSpecs(daemonSet)
+	if len(daemonSets) != 2 {
+		t.Fatal("expected a default and an arm64 daemonset but got", len(daemonSets))
+	}
+
+	defaultDS, armDS := daemonSets[0], daemonSets[1]
+	if defaultDS.Name != "daemonset-test" || armDS.Name != "daemonset-test-arm64" {
+		t.Fatal("unexpected daemonset names:", defaultDS.Name, armDS.Name)
+	}
+	if defaultDS.Spec.Template.Spec.Containers[0].Image != DefaultPauseContainerImage {
+		t.Fatal("expected the default daemonset to use the default image but got:", defaultDS.Spec.Template.Spec.Containers[0].Image)
+	}
+	if armDS.Spec.Template.Spec.Containers[0].Image != "registry/pause-arm64:3.2" {
+		t.Fatal("expected the arm64 daemonset to use the arm64 image but got:", armDS.Spec.Template.Spec.Containers[0].Image)
+	}
+
+	defaultRequirement := defaultDS.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0]
+	if defaultRequirement.Key != nodeArchLabel || defaultRequirement.Operator != apiv1.NodeSelectorOpNotIn || defaultRequirement.Values[0] != "arm64" {
+		t.Fatal("expected the default daemonset to avoid arm64 nodes but got:", defaultRequirement)
+	}
+	armRequirement := armDS.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0]
+	if armRequirement.Key != nodeArchLabel || armRequirement.Operator != apiv1.NodeSelectorOpIn || armRequirement.Values[0] != "arm64" {
+		t.Fatal("expected the arm64 daemonset to only run on arm64 nodes but got:", armRequirement)
+	}
+
+	// selectors must not overlap, but all pods are found by the kh-app label
+	if defaultDS.Spec.Selector.MatchLabels[dsArchLabel] == armDS.Spec.Selector.MatchLabels[dsArchLabel] {
+		t.Fatal("expected the daemonset selectors to differ by arch")
+	}
+	if armDS.Spec.Template.Labels["kh-app"] != "daemonset-test" {
+		t.Fatal("expected arch daemonset pods to keep the kh-app label")
+	}
+}
+
+func TestRemoveArchDaemonsets(t *testing.T) {
+	dsc := newTestChecker(t)
+	dsc.PauseContainerImageByArch = map[string]string{"arm64": "registry/pause-arm64:3.2"}
+	for _, name := range dsc.archDaemonSetNames(dsc.DaemonSetName) {
+		_, err := dsc.client.AppsV1().DaemonSets(dsc.Namespace).Create(context.Background(), testDaemonset(name), metav1.CreateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := dsc.remove(context.Background(), dsc.DaemonSetName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range dsc.archDaemonSetNames(dsc.DaemonSetName) {
+		exists, err := dsc.fetchDS(context.Background(), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists {
+			t.Fatal("expected daemonset", name, "to be removed")
+		}
+	}
+}
+
+func TestDescribePodProblems(t *testing.T) {
+	nodes := []apiv1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node-arm", Labels: map[string]string{nodeArchLabel: "arm64"}}},
+	}
+	pods := []apiv1.Pod{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ds-pod-arm"},
+			Spec:       apiv1.PodSpec{NodeName: "node-arm"},
+			Status: apiv1.PodStatus{ContainerStatuses: []apiv1.ContainerStatus{{
+				Image: DefaultPauseContainerImage,
+				State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{
+					Reason:  "ImagePullBackOff",
+					Message: "no matching manifest for linux/arm64",
+				}},
+			}}},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "ds-pod-starting"},
+			Spec:       apiv1.PodSpec{NodeName: "node-amd"},
+			Status: apiv1.PodStatus{ContainerStatuses: []apiv1.ContainerStatus{{
+				State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+			}}},
+		},
+	}
+
+	problems := describePodProblems(pods, nodes)
+	if len(problems) != 1 {
+		t.Fatal("expected one pod problem but got:", problems)
+	}
+	for _, expected := range []string{"ds-pod-arm", "node-arm", "arch arm64", "ImagePullBackOff", DefaultPauseContainerImage, "no matching manifest"} {
+		if !strings.Contains(problems[0], expected) {
+			t.Fatal("expected pod problem to contain", expected, "but got:", problems[0])
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package daemonset
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CleanUp triggers check clean up and waits for all rogue daemonsets to clear
+func (dsc *Checker) CleanUp(ctx context.Context) error {
+
+	// Clean up daemonsets and daemonset pods
+	// deleteDS not only issues a delete on the rogue daemonset but also on the rogue daemonset pods
+	log.Infoln("Cleaning up daemonsets and daemonset pods")
+
+	daemonSets, err := dsc.getAllDaemonsets(ctx)
+	if err != nil {
+		return err
+	}
+
+	// If any rogue daemonsets are found, proceed to remove them.
+	if len(daemonSets) > 0 {
+		for _, ds := range daemonSets {
+			log.Infoln("Removing rogue daemonset:", ds.Name)
+			err := dsc.remove(ctx, ds.Name)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	log.Infoln("Finished cleanup. No rogue daemonsets or daemonset pods exist")
+	return nil
+}
+
+// checkNameLabel is the label that holds the name of the khcheck that created a daemonset
+const checkNameLabel = "checkName"
+
+// evictStaleDaemonsets force deletes the daemonsets and daemonset pods left behind by previous runs of this khcheck,
+// such as runs of checker pods that crashed along with their kuberhealthy master, before the daemonset of this run is
+// deployed.  Daemonsets are only stale once their run is older than the timeout of the khcheck, so that runs still in
+// flight are left alone.
+func (dsc *Checker) evictStaleDaemonsets(ctx context.Context) error {
+
+	if len(dsc.CheckName) == 0 {
+		log.Infoln("Not evicting stale daemonsets because KH_CHECK_NAME is not set.")
+		return nil
+	}
+
+	daemonSets, err := dsc.listDaemonsetsBySelector(ctx, "source=kuberhealthy,khcheck=daemonset,"+checkNameLabel+"="+dsc.CheckName)
+	if err != nil {
+		return err
+	}
+
+	currentDaemonSets := make(map[string]bool)
+	for _, name := range dsc.archDaemonSetNames(dsc.DaemonSetName) {
+		currentDaemonSets[name] = true
+	}
+
+	// runs of this khcheck are given until their deadline, so no run is in flight longer than this one may take
+	checkTimeout := dsc.CheckTimeout
+
+	for _, ds := range daemonSets {
+		if currentDaemonSets[ds.Name] {
+			continue
+		}
+		runTime := daemonSetRunTime(ds)
+		if dsc.now.Sub(runTime) <= checkTimeout {
+			log.Infoln("Leaving daemonset", ds.Name, "in place because its run started", dsc.now.Sub(runTime).Round(time.Second), "ago, within the check timeout of", checkTimeout.Round(time.Second))
+			continue
+		}
+		log.Infoln("Force deleting stale daemonset", ds.Name, "created", ds.CreationTimestamp.String(), "by", ds.Labels["creatingInstance"])
+		err = dsc.forceDeleteDaemonset(ctx, ds.Name)
+		if err != nil {
+			return errors.New("failed to force delete stale daemonset " + ds.Name + ": " + err.Error())
+		}
+
+		// daemonsets deployed for an architecture share the kh-app label of their run with its default daemonset
+		khApp := ds.Labels["kh-app"]
+		if len(khApp) == 0 {
+			khApp = ds.Name
+		}
+		err = dsc.forceDeletePods(ctx, khApp)
+		if err != nil {
+			return errors.New("failed to force delete the pods of stale daemonset " + ds.Name + ": " + err.Error())
+		}
+	}
+	return nil
+}
+
+// daemonSetRunTime returns when the run that created a daemonset started, from its checkRunTime label or else its
+// creation time
+func daemonSetRunTime(ds appsv1.DaemonSet) time.Time {
+	runTime, err := strconv.ParseInt(ds.Labels["checkRunTime"], 10, 64)
+	if err != nil {
+		return ds.CreationTimestamp.Time
+	}
+	return time.Unix(runTime, 0)
+}
+
+// getAllDaemonsets fetches all daemonsets created by the daemonset khcheck
+func (dsc *Checker) getAllDaemonsets(ctx context.Context) ([]appsv1.DaemonSet, error) {
+	return dsc.listDaemonsetsBySelector(ctx, "source=kuberhealthy,khcheck=daemonset")
+}
+
+// listDaemonsetsBySelector fetches the daemonsets matching the label selector
+func (dsc *Checker) listDaemonsetsBySelector(ctx context.Context, selector string) ([]appsv1.DaemonSet, error) {
+
+	var allDS []appsv1.DaemonSet
+	var cont string
+	var err error
+
+	// fetch the ds objects created by kuberhealthy
+	for {
+		var dsList *appsv1.DaemonSetList
+		dsList, err = dsc.getDSClient().List(ctx, metav1.ListOptions{
+			LabelSelector: selector,
+		})
+		if err != nil {
+			errorMessage := "Error getting all daemonsets: " + err.Error()
+			log.Errorln(errorMessage)
+			return allDS, errors.New(errorMessage)
+		}
+		cont = dsList.Continue
+
+		// pick the items out and add them to our end results
+		for _, ds := range dsList.Items {
+			allDS = append(allDS, ds)
+		}
+
+		// while continue is set, keep fetching items
+		if len(cont) == 0 {
+			break
+		}
+	}
+
+	return allDS, nil
+}
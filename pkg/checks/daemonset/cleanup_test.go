@@ -0,0 +1,197 @@
+package daemonset
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newTestChecker creates a checker on a fake clientset populated with the supplied objects
+func newTestChecker(t *testing.T, objects ...runtime.Object) *Checker {
+	dsc := NewDaemonSetChecker(newFakeClient(objects...), "kuberhealthy", "daemonset", time.Second*10)
+	dsc.DaemonSetName = "daemonset-test-" + t.Name()
+	return dsc
+}
+
+// newFakeClient creates a fake clientset that also honors pod delete collection requests, which the fake
+// clientset otherwise accepts without removing anything
+func newFakeClient(objects ...runtime.Object) *fake.Clientset {
+	fakeClient := fake.NewSimpleClientset(objects...)
+	fakeClient.PrependReactor("delete-collection", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		deleteAction := action.(k8stesting.DeleteCollectionAction)
+		selector, err := labels.Parse(deleteAction.GetListRestrictions().Labels.String())
+		if err != nil {
+			return true, nil, err
+		}
+		obj, err := fakeClient.Tracker().List(apiv1.SchemeGroupVersion.WithResource("pods"), apiv1.SchemeGroupVersion.WithKind("Pod"), action.GetNamespace())
+		if err != nil {
+			return true, nil, err
+		}
+		for _, p := range obj.(*apiv1.PodList).Items {
+			if !selector.Matches(labels.Set(p.Labels)) {
+				continue
+			}
+			err = fakeClient.Tracker().Delete(apiv1.SchemeGroupVersion.WithResource("pods"), p.Namespace, p.Name)
+			if err != nil {
+				return true, nil, err
+			}
+		}
+		return true, nil, nil
+	})
+	return fakeClient
+}
+
+func testDaemonset(name string) *appsv1.DaemonSet {
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "kuberhealthy",
+			Labels: map[string]string{
+				"source":  "kuberhealthy",
+				"khcheck": "daemonset",
+			},
+		},
+	}
+}
+
+func testDaemonsetPod(name string, dsName string, hostIP string) *apiv1.Pod {
+	return &apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "kuberhealthy",
+			Labels: map[string]string{
+				"kh-app":  dsName,
+				"source":  "kuberhealthy",
+				"khcheck": "daemonset",
+			},
+		},
+		Status: apiv1.PodStatus{
+			Phase:  apiv1.PodRunning,
+			HostIP: hostIP,
+		},
+	}
+}
+
+func TestCleanUp(t *testing.T) {
+	dsc := newTestChecker(t,
+		testDaemonset("daemonset-rogue-1"),
+		testDaemonset("daemonset-rogue-2"),
+		testDaemonsetPod("daemonset-rogue-1-abc", "daemonset-rogue-1", "10.0.0.1"),
+	)
+
+	err := dsc.CleanUp(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error during cleanup:", err)
+	}
+
+	daemonSets, err := dsc.getAllDaemonsets(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error listing daemonsets:", err)
+	}
+	if len(daemonSets) != 0 {
+		t.Fatal("expected all rogue daemonsets to be removed but found:", len(daemonSets))
+	}
+
+	pods, err := dsc.client.CoreV1().Pods(dsc.Namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal("unexpected error listing pods:", err)
+	}
+	if len(pods.Items) != 0 {
+		t.Fatal("expected all rogue daemonset pods to be removed but found:", len(pods.Items))
+	}
+}
+
+func TestCleanUpCancelled(t *testing.T) {
+	dsc := newTestChecker(t, testDaemonset("daemonset-rogue-1"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := dsc.CleanUp(ctx)
+	if err == nil {
+		t.Fatal("expected an error when cleaning up with a cancelled context")
+	}
+}
+
+// testCheckDaemonset creates a daemonset of the khcheck started by a run at the run time
+func testCheckDaemonset(name string, khApp string, checkName string, runTime time.Time) *appsv1.DaemonSet {
+	ds := testDaemonset(name)
+	ds.Labels["kh-app"] = khApp
+	ds.Labels[checkNameLabel] = checkName
+	ds.Labels["checkRunTime"] = strconv.FormatInt(runTime.Unix(), 10)
+	return ds
+}
+
+func TestEvictStaleDaemonsets(t *testing.T) {
+	dsc := newTestChecker(t)
+	dsc.CheckName, dsc.CheckTimeout = "daemonset", time.Minute*10
+
+	staleRun := dsc.now.Add(-time.Hour)
+	dsc.client = newFakeClient(
+		testCheckDaemonset(dsc.DaemonSetName, dsc.DaemonSetName, "daemonset", dsc.now),
+		testCheckDaemonset("daemonset-stale", "daemonset-stale", "daemonset", staleRun),
+		testCheckDaemonset("daemonset-stale-arm64", "daemonset-stale", "daemonset", staleRun),
+		testCheckDaemonset("daemonset-in-flight", "daemonset-in-flight", "daemonset", dsc.now.Add(-time.Minute*5)),
+		testCheckDaemonset("daemonset-other", "daemonset-other", "daemonset-gpu", staleRun),
+		testDaemonsetPod(dsc.DaemonSetName+"-abc", dsc.DaemonSetName, "10.0.0.1"),
+		testDaemonsetPod("daemonset-stale-abc", "daemonset-stale", "10.0.0.1"),
+		testDaemonsetPod("daemonset-stale-def", "daemonset-stale", "10.0.0.2"),
+		testDaemonsetPod("daemonset-other-abc", "daemonset-other", "10.0.0.1"),
+	)
+
+	err := dsc.evictStaleDaemonsets(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error evicting stale daemonsets:", err)
+	}
+
+	daemonSets, err := dsc.getAllDaemonsets(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error listing daemonsets:", err)
+	}
+	remaining := make(map[string]bool)
+	for _, ds := range daemonSets {
+		remaining[ds.Name] = true
+	}
+	if len(remaining) != 3 || !remaining[dsc.DaemonSetName] || !remaining["daemonset-in-flight"] || !remaining["daemonset-other"] {
+		t.Fatal("expected only the stale daemonsets of this khcheck to be evicted but found:", remaining)
+	}
+
+	pods, err := dsc.client.CoreV1().Pods(dsc.Namespace).List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal("unexpected error listing pods:", err)
+	}
+	if len(pods.Items) != 2 {
+		t.Fatal("expected only the pods of the stale daemonsets of this khcheck to be removed but found:", pods.Items)
+	}
+	for _, p := range pods.Items {
+		if p.Labels["kh-app"] == "daemonset-stale" {
+			t.Fatal("expected the pods of the stale daemonset to be removed but found:", p.Name)
+		}
+	}
+}
+
+func TestEvictStaleDaemonsetsWithoutCheckName(t *testing.T) {
+	dsc := newTestChecker(t)
+	dsc.CheckName, dsc.CheckTimeout = "", time.Minute*10
+	dsc.client = newFakeClient(testCheckDaemonset("daemonset-stale", "daemonset-stale", "daemonset", dsc.now.Add(-time.Hour)))
+
+	err := dsc.evictStaleDaemonsets(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error evicting stale daemonsets:", err)
+	}
+	daemonSets, err := dsc.getAllDaemonsets(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error listing daemonsets:", err)
+	}
+	if len(daemonSets) != 1 {
+		t.Fatal("expected no daemonsets to be evicted without a check name but found:", len(daemonSets))
+	}
+}
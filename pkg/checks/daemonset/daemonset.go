@@ -0,0 +1,99 @@
+// Package daemonset implements a checker that deploys a daemonset to every node of the cluster and removes it again.
+// The check fails when a node does not run a daemonset pod in time or when the daemonset is not removed in time.
+package daemonset
+
+import (
+	"strconv"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultPauseContainerImage is the pause container image the daemonset pods run when no image is supplied
+const DefaultPauseContainerImage = "gcr.io/google-containers/pause:3.1"
+
+// defaultUser is the user the daemonset pods run as when the current user can not be determined
+const defaultUser = int64(1000)
+
+// Checker represents a daemonset checker for a single check run.
+type Checker struct {
+	// Namespace is the namespace the daemonset is deployed in
+	Namespace string
+	// DaemonSetName is the name of the daemonset deployed by this run.  It is made unique by the hostname of the
+	// checker pod and the time the run started.
+	DaemonSetName string
+	// Hostname is the hostname of the checker pod, which the daemonset is labeled with
+	Hostname string
+	// PauseContainerImage is the image of the daemonset pods, and PauseContainerImageByArch holds the images used on
+	// nodes of specific architectures
+	PauseContainerImage       string
+	PauseContainerImageByArch map[string]string
+	// NodeSelectors and NodeExclusions select the nodes the daemonset runs on
+	NodeSelectors  map[string]string
+	NodeExclusions []apiv1.NodeSelectorRequirement
+	// PriorityClassName is the priority class of the daemonset pods
+	PriorityClassName string
+	// Resources are the resource requests and limits of the daemonset pods
+	Resources apiv1.ResourceRequirements
+	// Tolerations are the tolerations of the daemonset pods.  When none are supplied, every taint found on the nodes of
+	// the cluster is tolerated, except the AllowedTaints.
+	Tolerations   []apiv1.Toleration
+	AllowedTaints map[string]apiv1.TaintEffect
+	// TolerateAllTaints is set when Tolerations tolerate every taint, so that tainted node pools are always checked
+	TolerateAllTaints bool
+	// DeployTimeout and RemoveTimeout are the time allowed for the daemonset pods to come online and for the daemonset
+	// to be removed
+	DeployTimeout time.Duration
+	RemoveTimeout time.Duration
+	// CheckName is the name of the khcheck, which the daemonset is labeled with so that only the stale daemonsets of
+	// this khcheck are evicted.  No daemonsets are evicted without it.
+	CheckName string
+	// CheckTimeout is the timeout of the khcheck.  Daemonsets of earlier runs are only stale once their run is older.
+	CheckTimeout time.Duration
+
+	client kubernetes.Interface
+	now    time.Time
+
+	// nodesMissingDSPod and podRemovalList reveal nodes with daemonset pods that fail to come up or be removed
+	nodesMissingDSPod []string
+	podRemovalList    *apiv1.PodList
+	// dsPodProblems describes daemonset pods that are unable to start, such as pods that can not pull their image
+	dsPodProblems []string
+	// nodesWithUntoleratedTaints describes the nodes that are not checked because the daemonset does not tolerate one
+	// of their taints, such as "gpu-1 (nvidia.com/gpu=present:NoSchedule)"
+	nodesWithUntoleratedTaints []string
+	// checkedNodes and excludedNodes are the nodes expected to run a daemonset pod and the nodes left out by
+	// NodeSelectors or NodeExclusions the last time the nodes of the cluster were listed
+	checkedNodes  []string
+	excludedNodes []string
+	// nodesListed is set once the nodes of the cluster were listed, so that the node counts are only reported when
+	// known
+	nodesListed bool
+}
+
+// NewDaemonSetChecker creates a daemonset checker that deploys a daemonset named after name into namespace and is
+// given timeout to deploy and remove it.  Any kubernetes.Interface can be supplied, which allows a fake clientset to
+// be used for testing.  The other settings of the checker start out with their defaults.
+func NewDaemonSetChecker(client kubernetes.Interface, namespace string, name string, timeout time.Duration) *Checker {
+	return NewDaemonSetCheckerWithClock(client, namespace, name, timeout, time.Now)
+}
+
+// NewDaemonSetCheckerWithClock creates a daemonset checker that takes the time its run started at from now
+func NewDaemonSetCheckerWithClock(client kubernetes.Interface, namespace string, name string, timeout time.Duration, now func() time.Time) *Checker {
+	start := now()
+	hostname := getHostname()
+	return &Checker{
+		Namespace:                 namespace,
+		DaemonSetName:             name + "-" + hostname + "-" + strconv.Itoa(int(start.Unix())),
+		Hostname:                  hostname,
+		PauseContainerImage:       DefaultPauseContainerImage,
+		PauseContainerImageByArch: make(map[string]string),
+		NodeSelectors:             make(map[string]string),
+		DeployTimeout:             timeout,
+		RemoveTimeout:             timeout,
+		CheckTimeout:              timeout,
+		client:                    client,
+		now:                       start,
+	}
+}
@@ -0,0 +1,187 @@
+package daemonset
+
+import (
+	"context"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	v13 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const maxElapsedTime = time.Minute
+
+// newBackoff returns an exponential backoff for retries that stops retrying once the context is done.  Every call
+// gets a backoff of its own because the state of a backoff can not be shared by concurrent calls.
+func newBackoff(ctx context.Context) backoff.BackOff {
+	exponentialBackoff := backoff.NewExponentialBackOff()
+	exponentialBackoff.MaxElapsedTime = maxElapsedTime
+	return backoff.WithContext(exponentialBackoff, ctx)
+}
+
+// getDSClient returns a daemonset client, useful for interacting with daemonsets
+func (dsc *Checker) getDSClient() v1.DaemonSetInterface {
+	log.Debug("Creating Daemonset client.")
+	return dsc.client.AppsV1().DaemonSets(dsc.Namespace)
+}
+
+// getPodClient returns a pod client, useful for interacting with pods
+func (dsc *Checker) getPodClient() corev1.PodInterface {
+	log.Debug("Creating Pod client.")
+	return dsc.client.CoreV1().Pods(dsc.Namespace)
+}
+
+// getNodeClient returns a node client, useful for interacting with nodes
+func (dsc *Checker) getNodeClient() corev1.NodeInterface {
+	log.Debug("Creating Node client.")
+	return dsc.client.CoreV1().Nodes()
+}
+
+func (dsc *Checker) createDaemonset(ctx context.Context, daemonsetSpec *appsv1.DaemonSet) error {
+
+	err := backoff.Retry(func() error {
+		var err error
+		_, err = dsc.getDSClient().Create(ctx, daemonsetSpec, metav1.CreateOptions{})
+		return err
+	}, newBackoff(ctx))
+	if err != nil {
+		log.Errorln("Failed to create daemonset. Error:", err)
+		return err
+	}
+
+	return err
+}
+
+func (dsc *Checker) listDaemonsets(ctx context.Context, more string) (*appsv1.DaemonSetList, error) {
+
+	var dsList *appsv1.DaemonSetList
+	err := backoff.Retry(func() error {
+		var err error
+		dsList, err = dsc.getDSClient().List(ctx, metav1.ListOptions{
+			Continue: more,
+		})
+		return err
+	}, newBackoff(ctx))
+	if err != nil {
+		log.Errorln("Failed to list daemonsets. Error:", err)
+		return dsList, err
+	}
+
+	return dsList, err
+}
+
+func (dsc *Checker) deleteDaemonset(ctx context.Context, dsName string) error {
+
+	err := backoff.Retry(func() error {
+		var err error
+		err = dsc.getDSClient().Delete(ctx, dsName, metav1.DeleteOptions{})
+		return err
+	}, newBackoff(ctx))
+	if err != nil {
+		log.Errorln("Failed to delete daemonset. Error:", err)
+		return err
+	}
+
+	return err
+}
+
+// forceDeleteDaemonset deletes a daemonset without a grace period, leaving its pods to be removed in the background.
+// Daemonsets that no longer exist are not an error.
+func (dsc *Checker) forceDeleteDaemonset(ctx context.Context, dsName string) error {
+
+	gracePeriod := int64(0)
+	propagationPolicy := metav1.DeletePropagationBackground
+	err := backoff.Retry(func() error {
+		var err error
+		err = dsc.getDSClient().Delete(ctx, dsName, metav1.DeleteOptions{
+			GracePeriodSeconds: &gracePeriod,
+			PropagationPolicy:  &propagationPolicy,
+		})
+		// a daemonset that is already gone needs no retries
+		if k8serrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}, newBackoff(ctx))
+	if err != nil {
+		log.Errorln("Failed to force delete daemonset. Error:", err)
+		return err
+	}
+
+	return err
+}
+
+func (dsc *Checker) listPods(ctx context.Context) (*v13.PodList, error) {
+
+	var podList *v13.PodList
+	err := backoff.Retry(func() error {
+		var err error
+		podList, err = dsc.getPodClient().List(ctx, metav1.ListOptions{
+			LabelSelector: "kh-app=" + dsc.DaemonSetName + ",source=kuberhealthy,khcheck=daemonset",
+		})
+		return err
+	}, newBackoff(ctx))
+	if err != nil {
+		log.Errorln("Failed to list daemonset pods. Error:", err)
+		return podList, err
+	}
+
+	return podList, err
+}
+
+func (dsc *Checker) deletePods(ctx context.Context, dsName string) error {
+
+	err := backoff.Retry(func() error {
+		var err error
+		err = dsc.getPodClient().DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{
+			LabelSelector: "kh-app=" + dsName + ",source=kuberhealthy,khcheck=daemonset",
+		})
+		return err
+	}, newBackoff(ctx))
+	if err != nil {
+		log.Errorln("Failed to delete daemonset pods. Error:", err)
+		return err
+	}
+
+	return err
+}
+
+// forceDeletePods deletes the daemonset pods with the supplied kh-app label without a grace period
+func (dsc *Checker) forceDeletePods(ctx context.Context, khApp string) error {
+
+	gracePeriod := int64(0)
+	err := backoff.Retry(func() error {
+		var err error
+		err = dsc.getPodClient().DeleteCollection(ctx, metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod}, metav1.ListOptions{
+			LabelSelector: "kh-app=" + khApp + ",source=kuberhealthy,khcheck=daemonset",
+		})
+		return err
+	}, newBackoff(ctx))
+	if err != nil {
+		log.Errorln("Failed to force delete daemonset pods. Error:", err)
+		return err
+	}
+
+	return err
+}
+
+func (dsc *Checker) listNodes(ctx context.Context) (*v13.NodeList, error) {
+
+	var nodeList *v13.NodeList
+	err := backoff.Retry(func() error {
+		var err error
+		nodeList, err = dsc.getNodeClient().List(ctx, metav1.ListOptions{})
+		return err
+	}, newBackoff(ctx))
+	if err != nil {
+		log.Errorln("Failed to list nodes. Error:", err)
+		return nodeList, err
+	}
+
+	return nodeList, err
+}
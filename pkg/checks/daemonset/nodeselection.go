@@ -0,0 +1,139 @@
+package daemonset
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// ParseNodeExclusions parses a comma separated list of key=value and key node labels, such as
+// kubernetes.io/os=windows,node.example.com/spot, into node affinity requirements that keep the daemonset off nodes
+// with any of the labels.  A key without a value excludes nodes with the label whatever its value.
+func ParseNodeExclusions(s string) []apiv1.NodeSelectorRequirement {
+	var exclusions []apiv1.NodeSelectorRequirement
+	index := make(map[string]int)
+	for _, label := range strings.Split(s, ",") {
+		label = strings.TrimSpace(label)
+		if len(label) == 0 {
+			continue
+		}
+		keyValue := strings.SplitN(label, "=", 2)
+		key := keyValue[0]
+		if len(key) == 0 || (len(keyValue) == 2 && len(keyValue[1]) == 0) {
+			log.Warnln("Unable to parse node label to exclude:", label)
+			continue
+		}
+
+		requirement := apiv1.NodeSelectorRequirement{Key: key, Operator: apiv1.NodeSelectorOpDoesNotExist}
+		if len(keyValue) == 2 {
+			requirement = apiv1.NodeSelectorRequirement{Key: key, Operator: apiv1.NodeSelectorOpNotIn, Values: []string{keyValue[1]}}
+		}
+
+		// values of the same key are excluded by one requirement, and a key without a value excludes every value
+		i, exists := index[key]
+		if !exists {
+			index[key] = len(exclusions)
+			exclusions = append(exclusions, requirement)
+			continue
+		}
+		if exclusions[i].Operator == apiv1.NodeSelectorOpDoesNotExist {
+			continue
+		}
+		if requirement.Operator == apiv1.NodeSelectorOpDoesNotExist {
+			exclusions[i] = requirement
+			continue
+		}
+		exclusions[i].Values = append(exclusions[i].Values, requirement.Values...)
+	}
+	return exclusions
+}
+
+// nodeIsExcluded determines if a node has one of the labels of the supplied exclusions
+func nodeIsExcluded(labels map[string]string, exclusions []apiv1.NodeSelectorRequirement) bool {
+	for _, e := range exclusions {
+		value, ok := labels[e.Key]
+		if !ok {
+			continue
+		}
+		if e.Operator == apiv1.NodeSelectorOpDoesNotExist {
+			return true
+		}
+		for _, v := range e.Values {
+			if v == value {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// addNodeAffinity adds the supplied requirements to every required node selector term of a pod spec, so that they
+// hold next to any node affinity already set, such as the architecture of the node
+func addNodeAffinity(podSpec *apiv1.PodSpec, requirements ...apiv1.NodeSelectorRequirement) {
+	if len(requirements) == 0 {
+		return
+	}
+	if podSpec.Affinity == nil {
+		podSpec.Affinity = &apiv1.Affinity{}
+	}
+	if podSpec.Affinity.NodeAffinity == nil {
+		podSpec.Affinity.NodeAffinity = &apiv1.NodeAffinity{}
+	}
+	nodeAffinity := podSpec.Affinity.NodeAffinity
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &apiv1.NodeSelector{}
+	}
+	terms := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) == 0 {
+		terms = []apiv1.NodeSelectorTerm{{}}
+	}
+	for i := range terms {
+		terms[i].MatchExpressions = append(terms[i].MatchExpressions, requirements...)
+	}
+	nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms = terms
+}
+
+// formatNodeSelectors formats node selectors as sorted, comma separated key=value pairs
+func formatNodeSelectors(nodeSelectors map[string]string) string {
+	var pairs []string
+	for key, value := range nodeSelectors {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// FormatNodeExclusions formats node exclusions the way EXCLUDE_NODE_LABELS is set
+func FormatNodeExclusions(exclusions []apiv1.NodeSelectorRequirement) string {
+	var labels []string
+	for _, e := range exclusions {
+		if e.Operator == apiv1.NodeSelectorOpDoesNotExist {
+			labels = append(labels, e.Key)
+			continue
+		}
+		for _, v := range e.Values {
+			labels = append(labels, e.Key+"="+v)
+		}
+	}
+	return strings.Join(labels, ",")
+}
+
+// NodeSetDetails describes the nodes the check ran against for the details of the check on the status page
+func (dsc *Checker) NodeSetDetails() map[string]string {
+	details := make(map[string]string)
+	if len(dsc.NodeSelectors) > 0 {
+		details["nodeSelector"] = formatNodeSelectors(dsc.NodeSelectors)
+	}
+	if len(dsc.NodeExclusions) > 0 {
+		details["excludeNodeLabels"] = FormatNodeExclusions(dsc.NodeExclusions)
+	}
+	if dsc.nodesListed {
+		details["checkedNodes"] = strconv.Itoa(len(dsc.checkedNodes))
+		details["excludedNodes"] = strconv.Itoa(len(dsc.excludedNodes))
+		details["untoleratedNodes"] = strconv.Itoa(len(dsc.nodesWithUntoleratedTaints))
+	}
+	return details
+}
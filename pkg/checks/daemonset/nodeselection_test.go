@@ -0,0 +1,91 @@
+package daemonset
+
+import (
+	"context"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+func TestParseNodeExclusions(t *testing.T) {
+	exclusions := ParseNodeExclusions("kubernetes.io/os=windows, pool=spot,pool=preemptible,dedicated,dedicated=infra,=missing-key,empty=,")
+	if len(exclusions) != 3 {
+		t.Fatal("expected three parsed exclusions but got:", exclusions)
+	}
+	if exclusions[0].Key != "kubernetes.io/os" || exclusions[0].Operator != apiv1.NodeSelectorOpNotIn || exclusions[0].Values[0] != "windows" {
+		t.Fatal("expected windows nodes to be excluded but got:", exclusions[0])
+	}
+	if exclusions[1].Key != "pool" || len(exclusions[1].Values) != 2 {
+		t.Fatal("expected both pool values to be excluded by one requirement but got:", exclusions[1])
+	}
+	if exclusions[2].Key != "dedicated" || exclusions[2].Operator != apiv1.NodeSelectorOpDoesNotExist {
+		t.Fatal("expected every dedicated node to be excluded but got:", exclusions[2])
+	}
+	if FormatNodeExclusions(exclusions) != "kubernetes.io/os=windows,pool=spot,pool=preemptible,dedicated" {
+		t.Fatal("unexpected formatted exclusions:", FormatNodeExclusions(exclusions))
+	}
+
+	if !nodeIsExcluded(map[string]string{"pool": "preemptible"}, exclusions) {
+		t.Fatal("expected a preemptible node to be excluded")
+	}
+	if !nodeIsExcluded(map[string]string{"dedicated": "gpu"}, exclusions) {
+		t.Fatal("expected a node with the dedicated label to be excluded whatever its value")
+	}
+	if nodeIsExcluded(map[string]string{"kubernetes.io/os": "linux", "pool": "on-demand"}, exclusions) {
+		t.Fatal("expected a linux on-demand node to not be excluded")
+	}
+}
+
+func TestAddNodeAffinity(t *testing.T) {
+	podSpec := apiv1.PodSpec{}
+	addNodeAffinity(&podSpec)
+	if podSpec.Affinity != nil {
+		t.Fatal("expected no node affinity without requirements")
+	}
+
+	exclusion := apiv1.NodeSelectorRequirement{Key: "pool", Operator: apiv1.NodeSelectorOpNotIn, Values: []string{"spot"}}
+	addNodeAffinity(&podSpec, exclusion)
+	addNodeAffinity(&podSpec, apiv1.NodeSelectorRequirement{Key: nodeArchLabel, Operator: apiv1.NodeSelectorOpIn, Values: []string{"arm64"}})
+	terms := podSpec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 1 || len(terms[0].MatchExpressions) != 2 {
+		t.Fatal("expected the arch requirement to be added to the exclusion in one term but got:", terms)
+	}
+	if terms[0].MatchExpressions[0].Key != "pool" || terms[0].MatchExpressions[1].Key != nodeArchLabel {
+		t.Fatal("unexpected node affinity requirements:", terms[0].MatchExpressions)
+	}
+}
+
+func TestGetNodesMissingDSPodExcludedNodes(t *testing.T) {
+	dsc := newTestChecker(t)
+	dsc.NodeSelectors = map[string]string{"kubernetes.io/os": "linux"}
+	dsc.NodeExclusions = ParseNodeExclusions("pool=spot")
+
+	linux := testNode("linux-1", "10.0.0.1")
+	linux.Labels = map[string]string{"kubernetes.io/os": "linux", "pool": "on-demand"}
+	missing := testNode("linux-2", "10.0.0.2")
+	missing.Labels = map[string]string{"kubernetes.io/os": "linux"}
+	spot := testNode("spot-1", "10.0.0.3")
+	spot.Labels = map[string]string{"kubernetes.io/os": "linux", "pool": "spot"}
+	windows := testNode("windows-1", "10.0.0.4")
+	windows.Labels = map[string]string{"kubernetes.io/os": "windows"}
+	dsc.client = newFakeClient(linux, missing, spot, windows, testDaemonsetPod(dsc.DaemonSetName+"-abc", dsc.DaemonSetName, "10.0.0.1"))
+
+	nodesMissingPod, err := dsc.getNodesMissingDSPod(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if len(nodesMissingPod) != 1 || nodesMissingPod[0] != "linux-2" {
+		t.Fatal("expected only linux-2 to be missing a daemonset pod but got:", nodesMissingPod)
+	}
+	if len(dsc.checkedNodes) != 2 || len(dsc.excludedNodes) != 2 {
+		t.Fatal("expected two checked and two excluded nodes but got:", dsc.checkedNodes, dsc.excludedNodes)
+	}
+
+	details := dsc.NodeSetDetails()
+	if details["nodeSelector"] != "kubernetes.io/os=linux" || details["excludeNodeLabels"] != "pool=spot" {
+		t.Fatal("expected the node selection in the details but got:", details)
+	}
+	if details["checkedNodes"] != "2" || details["excludedNodes"] != "2" || details["untoleratedNodes"] != "0" {
+		t.Fatal("expected the node counts in the details but got:", details)
+	}
+}
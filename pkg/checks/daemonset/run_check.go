@@ -1,32 +1,33 @@
-package main
+package daemonset
 
 import (
 	"context"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
 	apiv1 "k8s.io/api/core/v1"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/util"
 )
 
-// Globals for revealing daemonsets that fail to be removed or
-// nodes with daemonset pods that fail to come up or be removed
-var nodesMissingDSPod []string
-var podRemovalList *apiv1.PodList
+// Run runs pre-check cleanup and then the full daemonset check
+func (dsc *Checker) Run(ctx context.Context) error {
 
-// runCheck runs pre-check cleanup and then the full daemonset check
-func runCheck(ctx context.Context) error {
+	// Remove daemonsets left behind by previous check runs so that they do not linger in the check namespace
+	log.Infoln("Evicting stale daemonsets")
+	err := dsc.evictStaleDaemonsets(ctx)
+	if err != nil {
+		log.Errorln("Error evicting stale daemonsets:", err)
+	}
 
 	log.Infoln("Running daemonset check")
-	err := runDaemonsetCheck(ctx)
+	err = dsc.runDaemonsetCheck(ctx)
 	if err != nil {
 		return err
 	}
@@ -34,18 +35,18 @@ func runCheck(ctx context.Context) error {
 }
 
 // runDaemonsetCheck runs the full daemonset check. Deploy daemonset, remove daemonset, and post-check cleanup.
-func runDaemonsetCheck(ctx context.Context) error {
+func (dsc *Checker) runDaemonsetCheck(ctx context.Context) error {
 
 	// Deploy Daemonset
 	log.Infoln("Running daemonset deploy...")
-	err := deploy(ctx)
+	err := dsc.deploy(ctx)
 	if err != nil {
 		return err
 	}
 
 	// remove the daemonset and block until completed
 	log.Infoln("Running daemonset removal...")
-	err = remove(ctx, daemonSetName)
+	err = dsc.remove(ctx, dsc.DaemonSetName)
 	if err != nil {
 		return err
 	}
@@ -54,63 +55,94 @@ func runDaemonsetCheck(ctx context.Context) error {
 }
 
 // deploy runs doDeploy and checks for any errors during the deployment.
-func deploy(ctx context.Context) error {
+func (dsc *Checker) deploy(ctx context.Context) error {
 	log.Infoln("Deploying daemonset.")
 
 	// do the deployment and try to clean up if it fails
-	err := doDeploy(ctx)
+	err := dsc.doDeploy(ctx)
 	if err != nil {
 		return fmt.Errorf("error deploying daemonset: %s", err)
 	}
 
+	// stop the worker and wait for it to exit before returning, so that it does not outlive the deploy
+	ctx, cancel := context.WithCancel(ctx)
+	var workers sync.WaitGroup
+	defer workers.Wait()
+	defer cancel()
+
 	// wait for pods to come online
 	doneChan := make(chan error, 1)
+	workers.Add(1)
 	go func() {
+		defer workers.Done()
 		log.Debugln("Worker: waitForPodsToComeOnline started")
-		doneChan <- waitForPodsToComeOnline(ctx)
+		doneChan <- dsc.waitForPodsToComeOnline(ctx)
 	}()
 
 	// set daemonset deploy deadline
-	deadlineChan := time.After(checkDeadline.Sub(now))
+	deadlineChan := time.After(dsc.DeployTimeout)
 	// watch for either the pods to come online, the check timeout to pass, or the context to be revoked
 	select {
 	case err = <-doneChan:
 		if err != nil {
 			return fmt.Errorf("error waiting for pods to come online: %s", err)
 		}
-		log.Infoln("Successfully deployed daemonset.")
+		log.Infoln("Successfully deployed daemonset to", len(dsc.checkedNodes), "node(s).")
+		if len(dsc.excludedNodes) > 0 {
+			log.Infoln("Node(s) excluded by NODE_SELECTOR or EXCLUDE_NODE_LABELS:", formatNodes(dsc.excludedNodes))
+		}
+		if len(dsc.nodesWithUntoleratedTaints) > 0 {
+			log.Warnln("Node(s) not checked because the daemonset does not tolerate their taints:", formatNodes(dsc.nodesWithUntoleratedTaints))
+		}
 	case <-deadlineChan:
-		log.Debugln("nodes missing DS pods:", nodesMissingDSPod)
-		return errors.New("Reached check pod timeout: " + checkDeadline.Sub(now).String() + " waiting for all pods to come online. " +
-			"Node(s) missing daemonset pod: " + formatNodes(nodesMissingDSPod))
+		// the worker has to exit before the nodes it found missing a pod are read
+		cancel()
+		workers.Wait()
+		log.Debugln("nodes missing DS pods:", dsc.nodesMissingDSPod)
+		return errors.New("Reached daemonset deploy timeout: " + dsc.DeployTimeout.String() + " waiting for all pods to come online. " +
+			"Node(s) missing daemonset pod: " + formatNodes(dsc.nodesMissingDSPod) + "." + formatPodProblems(dsc.dsPodProblems) +
+			formatUntoleratedNodes(dsc.nodesWithUntoleratedTaints))
 	case <-ctx.Done():
 		return errors.New("failed to complete check due to an interrupt signal. canceling deploying daemonset and shutting down from interrupt")
 	}
 	return nil
 }
 
-// doDeploy creates a daemonset
-func doDeploy(ctx context.Context) error {
+// doDeploy creates a daemonset, or a daemonset per architecture if pause container images are mapped by architecture
+func (dsc *Checker) doDeploy(ctx context.Context) error {
 	//Generate the spec for the DS that we are about to deploy
-	daemonSetSpec := generateDaemonSetSpec(ctx)
+	daemonSetSpec := dsc.generateDaemonSetSpec(ctx)
 
-	//Generate DS client and create the set with the template we just generated
-	err := createDaemonset(ctx, daemonSetSpec)
-	return err
+	//Generate DS client and create the sets with the template we just generated
+	for _, ds := range dsc.generateArchDaemonSetSpecs(daemonSetSpec) {
+		err := dsc.createDaemonset(ctx, ds)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // remove removes the created daemonset for this check from the cluster. Waits for daemonset and daemonset pods to clear
-func remove(ctx context.Context, dsName string) error {
+func (dsc *Checker) remove(ctx context.Context, dsName string) error {
 	log.Infoln("Removing daemonset.")
 
+	// stop the workers and wait for them to exit before returning, so that none outlive the removal
+	ctx, cancel := context.WithCancel(ctx)
+	var workers sync.WaitGroup
+	defer workers.Wait()
+	defer cancel()
+
 	doneChan := make(chan error, 1)
 	// start the DS delete in the background
+	workers.Add(1)
 	go func() {
-		doneChan <- deleteDS(ctx, dsName)
+		defer workers.Done()
+		doneChan <- dsc.deleteDS(ctx, dsName)
 	}()
 
 	// set daemonset remove deadline
-	deadlineChan := time.After(checkDeadline.Sub(now))
+	deadlineChan := time.After(dsc.RemoveTimeout)
 	// wait for the DS delete call to finish, the timeout to happen, or the context to cancel
 	select {
 	case err := <-doneChan:
@@ -119,16 +151,18 @@ func remove(ctx context.Context, dsName string) error {
 		}
 		log.Infoln("Successfully requested daemonset removal.")
 	case <-deadlineChan:
-		return errors.New("Reached check pod timeout: " + checkDeadline.Sub(now).String() + " waiting for daemonset removal command to complete.")
+		return errors.New("Reached daemonset removal timeout: " + dsc.RemoveTimeout.String() + " waiting for daemonset removal command to complete.")
 	case <-ctx.Done():
 		// If there is a cancellation interrupt signal.
 		return errors.New("failed to complete check due to shutdown signal. canceling daemonset removal and shutting down from interrupt")
 	}
 
 	// Wait for daemonset to be removed
+	workers.Add(1)
 	go func() {
+		defer workers.Done()
 		log.Debugln("Worker: waitForDSRemoval started")
-		doneChan <- waitForDSRemoval(ctx)
+		doneChan <- dsc.waitForDSRemoval(ctx)
 	}()
 
 	// wait for either the DS to be removed, the timeout to occur, or a context cancellation
@@ -139,16 +173,18 @@ func remove(ctx context.Context, dsName string) error {
 		}
 		log.Infoln("Successfully removed daemonset.")
 	case <-deadlineChan:
-		return errors.New("Reached check pod timeout: " + checkDeadline.Sub(now).String() + " waiting for daemonset removal.")
+		return errors.New("Reached daemonset removal timeout: " + dsc.RemoveTimeout.String() + " waiting for daemonset removal.")
 	case <-ctx.Done():
 		// If there is a cancellation interrupt signal.
 		return errors.New("failed to complete check due to an interrupt signal. canceling removing daemonset and shutting down from interrupt")
 	}
 
 	// Wait for all daemonsets pods to be removed
+	workers.Add(1)
 	go func() {
+		defer workers.Done()
 		log.Debugln("Worker: waitForPodRemoval started")
-		doneChan <- waitForPodRemoval(ctx)
+		doneChan <- dsc.waitForPodRemoval(ctx)
 	}()
 
 	// wait for all pods to be removed, a timeout, or the context to revoke
@@ -159,8 +195,11 @@ func remove(ctx context.Context, dsName string) error {
 		}
 		log.Infoln("Successfully removed daemonset pods.")
 	case <-deadlineChan:
-		unClearedDSPodsNodes := getDSPodsNodeList(podRemovalList)
-		return errors.New("reached check pod timeout: " + checkDeadline.Sub(now).String() + " waiting for daemonset pods removal. " + "Node(s) failing to remove daemonset pod: " + unClearedDSPodsNodes)
+		// the worker has to exit before the pods it last listed are read
+		cancel()
+		workers.Wait()
+		unClearedDSPodsNodes := getDSPodsNodeList(dsc.podRemovalList)
+		return errors.New("reached daemonset removal timeout: " + dsc.RemoveTimeout.String() + " waiting for daemonset pods removal. " + "Node(s) failing to remove daemonset pod: " + unClearedDSPodsNodes)
 	case <-ctx.Done():
 		return errors.New("failed to complete check due to an interrupt signal. canceling removing daemonset pods and shutting down from interrupt")
 	}
@@ -169,7 +208,7 @@ func remove(ctx context.Context, dsName string) error {
 }
 
 // waitForPodsToComeOnline blocks until all pods of the daemonset are deployed and online
-func waitForPodsToComeOnline(ctx context.Context) error {
+func (dsc *Checker) waitForPodsToComeOnline(ctx context.Context) error {
 
 	log.Debugln("Waiting for all ds pods to come online")
 
@@ -177,12 +216,12 @@ func waitForPodsToComeOnline(ctx context.Context) error {
 	var counter int
 
 	// init a timeout for this whole deletion of daemonsets
-	log.Infoln("Timeout set:", checkDeadline.Sub(now).String(), "for all daemonset pods to come online")
+	log.Infoln("Timeout set:", dsc.DeployTimeout.String(), "for all daemonset pods to come online")
 
 	for {
 		select {
 		case <-ctx.Done():
-			return errors.New("DaemonsetChecker: Node(s) which were unable to schedule before context was cancelled: " + formatNodes(nodesMissingDSPod))
+			return errors.New("DaemonsetChecker: Node(s) which were unable to schedule before context was cancelled: " + formatNodes(dsc.nodesMissingDSPod) + "." + formatPodProblems(dsc.dsPodProblems))
 		default:
 		}
 
@@ -193,7 +232,7 @@ func waitForPodsToComeOnline(ctx context.Context) error {
 
 		// find nodes missing pods from this daemonset
 		var err error
-		nodesMissingDSPod, err = getNodesMissingDSPod(ctx)
+		dsc.nodesMissingDSPod, err = dsc.getNodesMissingDSPod(ctx)
 		if err != nil {
 			log.Warningln("DaemonsetChecker: Error determining which node was unschedulable. Retrying.", err)
 			continue
@@ -206,11 +245,11 @@ func waitForPodsToComeOnline(ctx context.Context) error {
 
 		// The DS must not have any nodes missing pods for five iterations in a row
 		readySeconds := 5
-		if len(nodesMissingDSPod) <= 0 {
+		if len(dsc.nodesMissingDSPod) <= 0 {
 			counter++
 			log.Infoln("DaemonsetChecker: All daemonset pods have been ready for", counter, "/", readySeconds, "seconds.")
 			if counter >= readySeconds {
-				log.Infoln("DaemonsetChecker: Daemonset " + daemonSetName + " done deploying pods.")
+				log.Infoln("DaemonsetChecker: Daemonset " + dsc.DaemonSetName + " done deploying pods.")
 				return nil
 			}
 			continue
@@ -218,44 +257,47 @@ func waitForPodsToComeOnline(ctx context.Context) error {
 		// else if we've started counting up but there is a DS pod that went unready
 		// reset the counter
 		if counter > 0 {
-			log.Infoln("DaemonsetChecker: Daemonset "+daemonSetName+" was ready for", counter, "out of,", readySeconds, "seconds but has left the ready state. Restarting", readySeconds, "second timer.")
+			log.Infoln("DaemonsetChecker: Daemonset "+dsc.DaemonSetName+" was ready for", counter, "out of,", readySeconds, "seconds but has left the ready state. Restarting", readySeconds, "second timer.")
 			counter = 0
 		}
 		// If the counter isnt iterating up or being reset, we are still waiting for pods to come online
-		log.Infoln("DaemonsetChecker: Daemonset check waiting for", len(nodesMissingDSPod), "pod(s) to come up on nodes", nodesMissingDSPod)
+		log.Infoln("DaemonsetChecker: Daemonset check waiting for", len(dsc.nodesMissingDSPod), "pod(s) to come up on nodes", dsc.nodesMissingDSPod)
 	}
 }
 
 // waitForDSRemoval waits for the daemonset to be removed before returning
-func waitForDSRemoval(ctx context.Context) error {
+func (dsc *Checker) waitForDSRemoval(ctx context.Context) error {
 
 	log.Debugln("Waiting for ds removal")
 
 	// repeatedly fetch the DS until it goes away
-	for {
-		select {
-		case <-ctx.Done():
-			return errors.New("Waiting for daemonset: " + daemonSetName + " removal aborted by context cancellation.")
-		default:
-		}
-		// check for our context to expire to break the loop
-		ctxErr := ctx.Err()
-		if ctxErr != nil {
-			return ctxErr
-		}
-		time.Sleep(time.Second / 2)
-		exists, err := fetchDS(ctx, daemonSetName)
-		if err != nil {
-			return err
-		}
-		if !exists {
-			return nil
+	for _, dsName := range dsc.archDaemonSetNames(dsc.DaemonSetName) {
+		for {
+			select {
+			case <-ctx.Done():
+				return errors.New("Waiting for daemonset: " + dsName + " removal aborted by context cancellation.")
+			default:
+			}
+			// check for our context to expire to break the loop
+			ctxErr := ctx.Err()
+			if ctxErr != nil {
+				return ctxErr
+			}
+			time.Sleep(time.Second / 2)
+			exists, err := dsc.fetchDS(ctx, dsName)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				break
+			}
 		}
 	}
+	return nil
 }
 
 // waitForPodRemoval waits for the daemonset to finish removing all daemonset pods
-func waitForPodRemoval(ctx context.Context) error {
+func (dsc *Checker) waitForPodRemoval(ctx context.Context) error {
 
 	log.Debugln("Waiting for ds pods removal")
 
@@ -268,23 +310,23 @@ func waitForPodRemoval(ctx context.Context) error {
 	for {
 
 		var err error
-		podRemovalList, err = listPods(ctx)
+		dsc.podRemovalList, err = dsc.listPods(ctx)
 		if err != nil {
-			errorMessage := "Failed to list daemonset: " + daemonSetName + " pods: " + err.Error()
+			errorMessage := "Failed to list daemonset: " + dsc.DaemonSetName + " pods: " + err.Error()
 			log.Errorln(errorMessage)
 			return errors.New(errorMessage)
 		}
 
-		log.Infoln("DaemonsetChecker using LabelSelector: kh-app=" + daemonSetName + ",source=kuberhealthy,khcheck=daemonset to remove ds pods")
+		log.Infoln("DaemonsetChecker using LabelSelector: kh-app=" + dsc.DaemonSetName + ",source=kuberhealthy,khcheck=daemonset to remove ds pods")
 
 		// If the delete ticker has ticked, then issue a repeat request for pods to be deleted.
 		// See kuberhealthy issue #74
 		select {
 		case <-deleteTicker.C:
 			log.Infoln("DaemonsetChecker re-issuing a pod delete command for daemonset checkers.")
-			err := deletePods(ctx, daemonSetName)
+			err := dsc.deletePods(ctx, dsc.DaemonSetName)
 			if err != nil {
-				errorMessage := "Failed to delete daemonset " + daemonSetName + " pods: " + err.Error()
+				errorMessage := "Failed to delete daemonset " + dsc.DaemonSetName + " pods: " + err.Error()
 				log.Errorln(errorMessage)
 				return errors.New(errorMessage)
 			}
@@ -294,12 +336,12 @@ func waitForPodRemoval(ctx context.Context) error {
 		}
 
 		// Check all pods for any kuberhealthy test daemonset pods that still exist
-		log.Infoln("DaemonsetChecker waiting for", len(podRemovalList.Items), "pods to delete")
-		for _, p := range podRemovalList.Items {
+		log.Infoln("DaemonsetChecker waiting for", len(dsc.podRemovalList.Items), "pods to delete")
+		for _, p := range dsc.podRemovalList.Items {
 			log.Infoln("DaemonsetChecker is still removing:", p.Namespace, p.Name, "on node", p.Spec.NodeName)
 		}
 
-		if len(podRemovalList.Items) == 0 {
+		if len(dsc.podRemovalList.Items) == 0 {
 			log.Infoln("DaemonsetChecker has finished removing all daemonset pods")
 			return nil
 		}
@@ -308,9 +350,9 @@ func waitForPodRemoval(ctx context.Context) error {
 }
 
 // generateDaemonSetSpec generates a daemonset spec to deploy into the cluster
-func generateDaemonSetSpec(ctx context.Context) *appsv1.DaemonSet {
+func (dsc *Checker) generateDaemonSetSpec(ctx context.Context) *appsv1.DaemonSet {
 
-	checkRunTime := strconv.Itoa(int(now.Unix()))
+	checkRunTime := strconv.Itoa(int(dsc.now.Unix()))
 	terminationGracePeriod := int64(1)
 
 	// Set the runAsUser
@@ -323,36 +365,36 @@ func generateDaemonSetSpec(ctx context.Context) *appsv1.DaemonSet {
 	runAsUser = currentUser
 
 	// if a list of tolerations wasnt passed in, default to tolerating all taints
-	if len(tolerations) == 0 {
+	if len(dsc.Tolerations) == 0 {
 		// find all the taints in the cluster and create a toleration for each
-		tolerations, err = findAllUniqueTolerations(ctx, client)
+		dsc.Tolerations, err = dsc.findAllUniqueTolerations(ctx)
 		if err != nil {
 			log.Warningln("Unable to generate list of pod scheduling tolerations", err)
 		}
 	}
 
 	// Add daemonset check pod ownerReference
-	ownerRef, err := util.GetOwnerRef(client, checkNamespace)
+	ownerRef, err := util.GetOwnerRef(dsc.client, dsc.Namespace)
 	if err != nil {
 		log.Errorln("Error getting ownerReference:", err)
 	}
 
 	// Check for given node selector values.
 	// Set the map to the default of nil (<none>) if there are no selectors given.
-	if len(dsNodeSelectors) == 0 {
-		dsNodeSelectors = nil
+	if len(dsc.NodeSelectors) == 0 {
+		dsc.NodeSelectors = nil
 	}
 
 	// create the DS object
 	log.Infoln("Generating daemonset kubernetes spec.")
 	daemonSet := &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: daemonSetName,
+			Name: dsc.DaemonSetName,
 			Labels: map[string]string{
-				"kh-app":           daemonSetName,
+				"kh-app":           dsc.DaemonSetName,
 				"source":           "kuberhealthy",
 				"khcheck":          "daemonset",
-				"creatingInstance": hostName,
+				"creatingInstance": dsc.Hostname,
 				"checkRunTime":     checkRunTime,
 			},
 			OwnerReferences: ownerRef,
@@ -361,23 +403,23 @@ func generateDaemonSetSpec(ctx context.Context) *appsv1.DaemonSet {
 			MinReadySeconds: 2,
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
-					"kh-app":           daemonSetName,
+					"kh-app":           dsc.DaemonSetName,
 					"source":           "kuberhealthy",
 					"khcheck":          "daemonset",
-					"creatingInstance": hostName,
+					"creatingInstance": dsc.Hostname,
 					"checkRunTime":     checkRunTime,
 				},
 			},
 			Template: apiv1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: map[string]string{
-						"kh-app":           daemonSetName,
+						"kh-app":           dsc.DaemonSetName,
 						"source":           "kuberhealthy",
 						"khcheck":          "daemonset",
-						"creatingInstance": hostName,
+						"creatingInstance": dsc.Hostname,
 						"checkRunTime":     checkRunTime,
 					},
-					Name: daemonSetName,
+					Name: dsc.DaemonSetName,
 					Annotations: map[string]string{
 						"cluster-autoscaler.kubernetes.io/safe-to-evict": "true",
 					},
@@ -386,44 +428,46 @@ func generateDaemonSetSpec(ctx context.Context) *appsv1.DaemonSet {
 				Spec: apiv1.PodSpec{
 					TerminationGracePeriodSeconds: &terminationGracePeriod,
 					Tolerations:                   []apiv1.Toleration{},
-					PriorityClassName:             podPriorityClassName,
+					PriorityClassName:             dsc.PriorityClassName,
 					Containers: []apiv1.Container{
 						{
 							Name:  "sleep",
-							Image: dsPauseContainerImage,
+							Image: dsc.PauseContainerImage,
 							SecurityContext: &apiv1.SecurityContext{
 								RunAsUser: &runAsUser,
 							},
-							Resources: apiv1.ResourceRequirements{
-								Requests: apiv1.ResourceList{
-									apiv1.ResourceCPU:    resource.MustParse("0"),
-									apiv1.ResourceMemory: resource.MustParse("0"),
-								},
-							},
+							Resources: dsc.Resources,
 						},
 					},
-					NodeSelector: dsNodeSelectors,
+					NodeSelector: dsc.NodeSelectors,
 				},
 			},
 		},
 	}
 
 	// Add our generated list of tolerations or any the user input via flag
-	daemonSet.Spec.Template.Spec.Tolerations = append(daemonSet.Spec.Template.Spec.Tolerations, tolerations...)
+	daemonSet.Spec.Template.Spec.Tolerations = append(daemonSet.Spec.Template.Spec.Tolerations, dsc.Tolerations...)
 	log.Infoln("Deploying daemonset with tolerations: ", daemonSet.Spec.Template.Spec.Tolerations)
 
+	// Keep the daemonset off the node pools excluded by label
+	addNodeAffinity(&daemonSet.Spec.Template.Spec, dsc.NodeExclusions...)
+
+	// Label the daemonset with its khcheck so that other khchecks never evict it
+	if len(dsc.CheckName) != 0 {
+		daemonSet.Labels[checkNameLabel] = dsc.CheckName
+	}
+
 	return daemonSet
 }
 
-// findAllUniqueTolerations returns a list of all taints present on any node group in the cluster
-// this is exportable because of a chicken/egg.  We need to determine the taints before
-// we construct the testDS in New() and pass them into New()
-func findAllUniqueTolerations(ctx context.Context, client *kubernetes.Clientset) ([]apiv1.Toleration, error) {
+// findAllUniqueTolerations returns a list of all taints present on any node group in the cluster, except the
+// AllowedTaints
+func (dsc *Checker) findAllUniqueTolerations(ctx context.Context) ([]apiv1.Toleration, error) {
 
 	var uniqueTolerations []apiv1.Toleration
 
 	// get a list of all the nodes in the cluster
-	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	nodes, err := dsc.client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return uniqueTolerations, err
 	}
@@ -436,16 +480,17 @@ func findAllUniqueTolerations(ctx context.Context, client *kubernetes.Clientset)
 
 			// Don't tolerate any taints listed in ALLOWED_TAINTS
 			// Ignoring cordoned nodes example: node.kubernetes.io/unschedulable:NoSchedule
-			if val, exists := allowedTaints[t.Key]; exists {
+			if val, exists := dsc.AllowedTaints[t.Key]; exists {
 				if val == t.Effect {
 					// Skip tolerating allowed taints
 					continue
 				}
 			}
 
-			// only add unique entries to the slice
-			if _, value := keys[t.Value]; !value {
-				keys[t.Value] = true
+			// only add unique entries to the slice. taints with the same value but another key or effect need
+			// their own toleration
+			if _, value := keys[t.ToString()]; !value {
+				keys[t.ToString()] = true
 				// Add the taints to the list as tolerations
 				// daemonset.spec.template.spec.tolerations
 				uniqueTolerations = append(uniqueTolerations, apiv1.Toleration{Key: t.Key, Value: t.Value, Effect: t.Effect})
@@ -457,13 +502,13 @@ func findAllUniqueTolerations(ctx context.Context, client *kubernetes.Clientset)
 }
 
 // getNodesMissingDSPod gets a list of nodes that do not have a DS pod running on them
-func getNodesMissingDSPod(ctx context.Context) ([]string, error) {
+func (dsc *Checker) getNodesMissingDSPod(ctx context.Context) ([]string, error) {
 
 	// nodesMissingDSPods holds the final list of nodes missing pods
 	var nodesMissingDSPods []string
 
 	// get a list of all the nodes in the cluster
-	nodes, err := listNodes(ctx)
+	nodes, err := dsc.listNodes(ctx)
 	if err != nil {
 		errorMessage := "Failed to list nodes: " + err.Error()
 		log.Errorln(errorMessage)
@@ -471,22 +516,42 @@ func getNodesMissingDSPod(ctx context.Context) ([]string, error) {
 	}
 
 	// get a list of DS pods
-	pods, err := listPods(ctx)
+	pods, err := dsc.listPods(ctx)
 	if err != nil {
-		errorMessage := "Failed to list daemonset: " + daemonSetName + " pods: " + err.Error()
+		errorMessage := "Failed to list daemonset: " + dsc.DaemonSetName + " pods: " + err.Error()
 		log.Errorln(errorMessage)
 		return nodesMissingDSPods, errors.New(errorMessage)
 	}
 
 	// populate a node status map. default status is "false", meaning there is
 	// not a pod deployed to that node.  We are only adding nodes that tolerate
-	// our list of dsc.Tolerations
+	// our list of Tolerations.  Nodes skipped for taints that are not allowed
+	// are described, so that they are not left out silently.
 	nodeStatuses := make(map[string]bool)
+	var untoleratedNodes []string
+	var checked []string
+	var excluded []string
 	for _, n := range nodes.Items {
-		if taintsAreTolerated(n.Spec.Taints, tolerations) && nodeLabelsMatch(n.Labels, dsNodeSelectors) {
-			nodeStatuses[n.Name] = false
+		if !nodeLabelsMatch(n.Labels, dsc.NodeSelectors) || nodeIsExcluded(n.Labels, dsc.NodeExclusions) {
+			excluded = append(excluded, n.Name)
+			continue
 		}
+		// nodes with ALLOWED_TAINTS, such as cordoned nodes, stay exempt when every taint is tolerated
+		if dsc.TolerateAllTaints && len(dsc.withoutAllowedTaints(n.Spec.Taints)) < len(n.Spec.Taints) {
+			continue
+		}
+		untolerated := untoleratedTaints(n.Spec.Taints, dsc.Tolerations)
+		if len(untolerated) > 0 {
+			if notAllowed := dsc.withoutAllowedTaints(untolerated); len(notAllowed) > 0 {
+				untoleratedNodes = append(untoleratedNodes, describeUntoleratedNode(n.Name, notAllowed))
+			}
+			continue
+		}
+		nodeStatuses[n.Name] = false
+		checked = append(checked, n.Name)
 	}
+	dsc.nodesWithUntoleratedTaints = untoleratedNodes
+	dsc.checkedNodes, dsc.excludedNodes, dsc.nodesListed = checked, excluded, true
 
 	// Look over all daemonset pods.  Mark any hosts that host one of the pods
 	// as "true" in the nodeStatuses map, indicating that a daemonset pod is
@@ -503,7 +568,7 @@ func getNodesMissingDSPod(ctx context.Context) ([]string, error) {
 				if nodeip.Type != "InternalIP" || nodeip.Address != pod.Status.HostIP {
 					continue
 				}
-				if taintsAreTolerated(node.Spec.Taints, tolerations) {
+				if taintsAreTolerated(node.Spec.Taints, dsc.Tolerations) {
 					nodeStatuses[node.Name] = true
 					break
 				}
@@ -511,6 +576,9 @@ func getNodesMissingDSPod(ctx context.Context) ([]string, error) {
 		}
 	}
 
+	// describe pods that are unable to be scheduled or to start so that the error output can identify them
+	dsc.dsPodProblems = append(describeSchedulingProblems(pods.Items), describePodProblems(pods.Items, nodes.Items)...)
+
 	// pick out all the nodes without daemonset pods on them and
 	// add them to the final results
 	for nodeName, hasDS := range nodeStatuses {
@@ -525,21 +593,7 @@ func getNodesMissingDSPod(ctx context.Context) ([]string, error) {
 // taintsAreTolerated iterates through all taints and tolerations passed in
 // and checks that all taints are tolerated by the supplied tolerations
 func taintsAreTolerated(taints []apiv1.Taint, tolerations []apiv1.Toleration) bool {
-	for _, taint := range taints {
-		var taintIsTolerated bool
-		for _, toleration := range tolerations {
-			if taint.Key == toleration.Key && taint.Value == toleration.Value {
-				taintIsTolerated = true
-				break
-			}
-		}
-		// if none of the tolerations match the taint, it is not tolerated
-		if !taintIsTolerated {
-			return false
-		}
-	}
-	// if all taints have a matching toleration, return true
-	return true
+	return len(untoleratedTaints(taints, tolerations)) == 0
 }
 
 // nodeLabelsMatch iterates through labels on a node and checks for matches
@@ -564,32 +618,34 @@ func nodeLabelsMatch(labels, nodeSelectors map[string]string) bool {
 	return labelsMatch
 }
 
-// deleteDS deletes specified daemonset from its checkNamespace.
+// deleteDS deletes specified daemonset from its Namespace.
 // Delete daemonset first, then proceed to delete all daemonset pods.
-func deleteDS(ctx context.Context, dsName string) error {
+func (dsc *Checker) deleteDS(ctx context.Context, dsName string) error {
 
 	log.Infoln("DaemonsetChecker deleting daemonset:", dsName)
 
 	// Confirm the count of ds pods we are removing before issuing a delete
-	pods, err := listPods(ctx)
+	pods, err := dsc.listPods(ctx)
 	if err != nil {
-		errorMessage := "Failed to list daemonset: " + daemonSetName + " pods: " + err.Error()
+		errorMessage := "Failed to list daemonset: " + dsc.DaemonSetName + " pods: " + err.Error()
 		log.Errorln(errorMessage)
 		return errors.New(errorMessage)
 	}
 	log.Infoln("There are", len(pods.Items), "daemonset pods to remove")
 
-	// Delete daemonset
-	err = deleteDaemonset(ctx, dsName)
-	if err != nil {
-		errorMessage := "Failed to delete daemonset: " + dsName + err.Error()
-		log.Errorln(errorMessage)
-		return errors.New(errorMessage)
+	// Delete daemonset along with the daemonsets deployed for specific architectures
+	for _, name := range dsc.archDaemonSetNames(dsName) {
+		err = dsc.deleteDaemonset(ctx, name)
+		if err != nil {
+			errorMessage := "Failed to delete daemonset: " + name + err.Error()
+			log.Errorln(errorMessage)
+			return errors.New(errorMessage)
+		}
 	}
 
 	// Issue a delete to every pod. removing the DS alone does not ensure all pods are removed
 	log.Infoln("DaemonsetChecker removing daemonset. Proceeding to remove daemonset pods")
-	err = deletePods(ctx, dsName)
+	err = dsc.deletePods(ctx, dsName)
 	if err != nil {
 		errorMessage := "Failed to delete daemonset " + dsName + " pods: " + err.Error()
 		log.Errorln(errorMessage)
@@ -602,13 +658,13 @@ func deleteDS(ctx context.Context, dsName string) error {
 // fetchDS fetches the ds for the checker from the api server
 // and returns a bool indicating if it exists or not
 
-func fetchDS(ctx context.Context, dsName string) (bool, error) {
+func (dsc *Checker) fetchDS(ctx context.Context, dsName string) (bool, error) {
 	var firstQuery bool = true
 	var more string
 	// pagination
 	for firstQuery || len(more) > 0 {
 		firstQuery = false
-		dsList, err := listDaemonsets(ctx, more)
+		dsList, err := dsc.listDaemonsets(ctx, more)
 		if err != nil {
 			log.Errorln(err.Error())
 			return false, err
@@ -0,0 +1,142 @@
+package daemonset
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeLabelsMatch(test *testing.T) {
+	labels := map[string]string{
+		"blah":                   "blerp",
+		"kubernetes.io/hostname": "ip-10-112-79-36.us-west-2.compute.internal",
+		"kubernetes.io/role":     "node",
+	}
+	nodeSelectors := map[string]string{
+		"blah":               "blerp",
+		"kubernetes.io/role": "node",
+	}
+	//nodeSelectors := map[string]string{}
+	expectedResults := []bool{
+		true,
+		false,
+	}
+	test.Log("testing nodelabelsmatch")
+	r := nodeLabelsMatch(labels, nodeSelectors)
+	if r == false {
+		test.Errorf("Expected %v but got: %v", expectedResults[0], r)
+	}
+}
+
+func testNode(name string, internalIP string, taints ...apiv1.Taint) *apiv1.Node {
+	return &apiv1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: apiv1.NodeSpec{
+			Taints: taints,
+		},
+		Status: apiv1.NodeStatus{
+			Addresses: []apiv1.NodeAddress{
+				{Type: apiv1.NodeInternalIP, Address: internalIP},
+			},
+		},
+	}
+}
+
+func TestGetNodesMissingDSPod(t *testing.T) {
+	dsc := newTestChecker(t)
+	dsc.client = newFakeClient(
+		testNode("node-1", "10.0.0.1"),
+		testNode("node-2", "10.0.0.2"),
+		testNode("tainted-node", "10.0.0.3", apiv1.Taint{Key: "dedicated", Value: "gpu", Effect: apiv1.TaintEffectNoSchedule}),
+		testDaemonsetPod(dsc.DaemonSetName+"-abc", dsc.DaemonSetName, "10.0.0.1"),
+	)
+
+	missing, err := dsc.getNodesMissingDSPod(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if len(missing) != 1 || missing[0] != "node-2" {
+		t.Fatal("expected only node-2 to be missing a daemonset pod but got:", missing)
+	}
+}
+
+func TestRemove(t *testing.T) {
+	dsc := newTestChecker(t)
+	dsc.client = newFakeClient(
+		testDaemonset(dsc.DaemonSetName),
+		testDaemonsetPod(dsc.DaemonSetName+"-abc", dsc.DaemonSetName, "10.0.0.1"),
+	)
+
+	err := dsc.remove(context.Background(), dsc.DaemonSetName)
+	if err != nil {
+		t.Fatal("unexpected error removing daemonset:", err)
+	}
+	exists, err := dsc.fetchDS(context.Background(), dsc.DaemonSetName)
+	if err != nil {
+		t.Fatal("unexpected error fetching daemonset:", err)
+	}
+	if exists {
+		t.Fatal("expected daemonset to be removed")
+	}
+}
+
+func TestRemoveTimeout(t *testing.T) {
+	dsc := newTestChecker(t)
+	dsc.RemoveTimeout = 0
+
+	err := dsc.remove(context.Background(), dsc.DaemonSetName)
+	if err == nil {
+		t.Fatal("expected a timeout error when the removal timeout has passed")
+	}
+}
+
+func TestFindAllUniqueTolerations(t *testing.T) {
+	dsc := newTestChecker(t,
+		testNode("node-1", "10.0.0.1", apiv1.Taint{Key: "dedicated", Value: "gpu", Effect: apiv1.TaintEffectNoSchedule}),
+		testNode("node-2", "10.0.0.2", apiv1.Taint{Key: "dedicated", Value: "gpu", Effect: apiv1.TaintEffectNoSchedule}),
+		testNode("node-3", "10.0.0.3", apiv1.Taint{Key: "node.kubernetes.io/unschedulable", Effect: apiv1.TaintEffectNoSchedule}),
+	)
+	dsc.AllowedTaints = map[string]apiv1.TaintEffect{"node.kubernetes.io/unschedulable": apiv1.TaintEffectNoSchedule}
+
+	uniqueTolerations, err := dsc.findAllUniqueTolerations(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if len(uniqueTolerations) != 1 || uniqueTolerations[0].Key != "dedicated" {
+		t.Fatal("expected a single toleration for the dedicated taint but got:", uniqueTolerations)
+	}
+}
+
+func TestFindAllUniqueTolerationsSameValue(t *testing.T) {
+	dsc := newTestChecker(t,
+		testNode("node-1", "10.0.0.1", apiv1.Taint{Key: "nvidia.com/gpu", Effect: apiv1.TaintEffectNoSchedule}),
+		testNode("node-2", "10.0.0.2", apiv1.Taint{Key: "node-role.kubernetes.io/infra", Effect: apiv1.TaintEffectNoSchedule}),
+	)
+
+	uniqueTolerations, err := dsc.findAllUniqueTolerations(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if len(uniqueTolerations) != 2 {
+		t.Fatal("expected a toleration for each taint without a value but got:", uniqueTolerations)
+	}
+}
+
+func TestGenerateDaemonSetSpecRunTime(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	dsc := NewDaemonSetCheckerWithClock(newFakeClient(), "kuberhealthy", "daemonset", time.Minute, func() time.Time { return start })
+	if !strings.HasSuffix(dsc.DaemonSetName, "-1700000000") {
+		t.Fatal("expected the daemonset to be named after the time the run started but got:", dsc.DaemonSetName)
+	}
+
+	daemonSet := dsc.generateDaemonSetSpec(context.Background())
+	if daemonSet.Labels["checkRunTime"] != "1700000000" || daemonSet.Spec.Template.Labels["checkRunTime"] != "1700000000" {
+		t.Fatal("expected the daemonset to be labeled with the time the run started but got:", daemonSet.Labels)
+	}
+}
@@ -0,0 +1,100 @@
+package daemonset
+
+import (
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// TolerateAllTolerations tolerates every taint, so that every node runs a daemonset pod when TOLERATE_ALL_TAINTS is set
+var TolerateAllTolerations = []apiv1.Toleration{{Operator: apiv1.TolerationOpExists}}
+
+// untoleratedTaints returns the taints that keep pods with the supplied tolerations off a node.  PreferNoSchedule
+// taints are not returned, since the scheduler still places daemonset pods on nodes with them.
+func untoleratedTaints(taints []apiv1.Taint, tolerations []apiv1.Toleration) []apiv1.Taint {
+	var untolerated []apiv1.Taint
+	for i := range taints {
+		if taints[i].Effect == apiv1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		var tolerated bool
+		for j := range tolerations {
+			if tolerations[j].ToleratesTaint(&taints[i]) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			untolerated = append(untolerated, taints[i])
+		}
+	}
+	return untolerated
+}
+
+// withoutAllowedTaints returns the taints that are not one of the ALLOWED_TAINTS, which exempt nodes from running a
+// daemonset pod
+func (dsc *Checker) withoutAllowedTaints(taints []apiv1.Taint) []apiv1.Taint {
+	var notAllowed []apiv1.Taint
+	for _, t := range taints {
+		if effect, exists := dsc.AllowedTaints[t.Key]; exists && effect == t.Effect {
+			continue
+		}
+		notAllowed = append(notAllowed, t)
+	}
+	return notAllowed
+}
+
+// describeUntoleratedNode describes a node that is not checked because of its untolerated taints
+func describeUntoleratedNode(node string, taints []apiv1.Taint) string {
+	var described []string
+	for _, t := range taints {
+		described = append(described, t.ToString())
+	}
+	return node + " (" + strings.Join(described, ", ") + ")"
+}
+
+// formatUntoleratedNodes formats the nodes with untolerated taints for error messages
+func formatUntoleratedNodes(nodes []string) string {
+	if len(nodes) == 0 {
+		return ""
+	}
+	return " Node(s) not checked because the daemonset does not tolerate their taints: " + formatNodes(nodes) + "."
+}
+
+// describeSchedulingProblems describes the daemonset pods the scheduler can not place on their node, such as pods
+// that do not fit on a full node, so that the error output names the node and the reason
+func describeSchedulingProblems(pods []apiv1.Pod) []string {
+	var problems []string
+	for _, p := range pods {
+		for _, c := range p.Status.Conditions {
+			if c.Type != apiv1.PodScheduled || c.Status != apiv1.ConditionFalse || c.Reason != apiv1.PodReasonUnschedulable {
+				continue
+			}
+			problem := "pod " + p.Name + " can not be scheduled on node " + podTargetNode(p)
+			if len(c.Message) > 0 {
+				problem += ": " + c.Message
+			}
+			problems = append(problems, problem)
+		}
+	}
+	return problems
+}
+
+// podTargetNode returns the node a daemonset pod runs on, or the node it is meant for when it is not scheduled yet.
+// The daemonset controller binds pods to their node with a required node affinity on the metadata.name field.
+func podTargetNode(pod apiv1.Pod) string {
+	if len(pod.Spec.NodeName) > 0 {
+		return pod.Spec.NodeName
+	}
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.NodeAffinity == nil || pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return "unknown"
+	}
+	for _, term := range pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		for _, field := range term.MatchFields {
+			if field.Key == "metadata.name" && field.Operator == apiv1.NodeSelectorOpIn && len(field.Values) == 1 {
+				return field.Values[0]
+			}
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,106 @@
+package daemonset
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUntoleratedTaints(t *testing.T) {
+	taints := []apiv1.Taint{
+		{Key: "nvidia.com/gpu", Value: "present", Effect: apiv1.TaintEffectNoSchedule},
+		{Key: "dedicated", Value: "infra", Effect: apiv1.TaintEffectNoExecute},
+		{Key: "spot", Value: "true", Effect: apiv1.TaintEffectPreferNoSchedule},
+	}
+
+	// a key only toleration tolerates the taint whatever its value, and PreferNoSchedule taints never keep pods off
+	tolerations := []apiv1.Toleration{{Key: "nvidia.com/gpu", Operator: apiv1.TolerationOpExists}}
+	untolerated := untoleratedTaints(taints, tolerations)
+	if len(untolerated) != 1 || untolerated[0].Key != "dedicated" {
+		t.Fatal("expected only the dedicated taint to be untolerated but got", untolerated)
+	}
+
+	// a toleration of another effect does not tolerate the taint
+	tolerations = append(tolerations, apiv1.Toleration{Key: "dedicated", Value: "infra", Effect: apiv1.TaintEffectNoSchedule})
+	if len(untoleratedTaints(taints, tolerations)) != 1 {
+		t.Fatal("expected a NoSchedule toleration to not tolerate a NoExecute taint")
+	}
+
+	if len(untoleratedTaints(taints, TolerateAllTolerations)) != 0 {
+		t.Fatal("expected every taint to be tolerated when all taints are tolerated")
+	}
+}
+
+func TestGetNodesMissingDSPodUntoleratedTaints(t *testing.T) {
+	dsc := newTestChecker(t)
+	dsc.AllowedTaints = map[string]apiv1.TaintEffect{"node.kubernetes.io/unschedulable": apiv1.TaintEffectNoSchedule}
+	dsc.Tolerations = []apiv1.Toleration{{Key: "dedicated", Operator: apiv1.TolerationOpExists}}
+
+	gpuTaint := apiv1.Taint{Key: "nvidia.com/gpu", Value: "present", Effect: apiv1.TaintEffectNoSchedule}
+	dsc.client = newFakeClient(
+		testNode("node-1", "10.0.0.1"),
+		testNode("infra-1", "10.0.0.2", apiv1.Taint{Key: "dedicated", Value: "infra", Effect: apiv1.TaintEffectNoSchedule}),
+		testNode("gpu-1", "10.0.0.3", gpuTaint),
+		testNode("cordoned-1", "10.0.0.4", apiv1.Taint{Key: "node.kubernetes.io/unschedulable", Effect: apiv1.TaintEffectNoSchedule}),
+		testDaemonsetPod(dsc.DaemonSetName+"-abc", dsc.DaemonSetName, "10.0.0.1"),
+	)
+
+	// the gpu node is not checked and is reported, while the cordoned node is allowed to be skipped
+	missing, err := dsc.getNodesMissingDSPod(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if len(missing) != 1 || missing[0] != "infra-1" {
+		t.Fatal("expected only infra-1 to be missing a daemonset pod but got:", missing)
+	}
+	if len(dsc.nodesWithUntoleratedTaints) != 1 || dsc.nodesWithUntoleratedTaints[0] != "gpu-1 (nvidia.com/gpu=present:NoSchedule)" {
+		t.Fatal("expected gpu-1 to be reported for its untolerated taint but got:", dsc.nodesWithUntoleratedTaints)
+	}
+	if !strings.Contains(formatUntoleratedNodes(dsc.nodesWithUntoleratedTaints), "does not tolerate their taints: gpu-1") {
+		t.Fatal("expected the untolerated nodes in the error output but got:", formatUntoleratedNodes(dsc.nodesWithUntoleratedTaints))
+	}
+
+	// with every taint tolerated, the gpu node is checked too
+	dsc.TolerateAllTaints = true
+	dsc.Tolerations = TolerateAllTolerations
+	missing, err = dsc.getNodesMissingDSPod(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	if len(missing) != 2 || len(dsc.nodesWithUntoleratedTaints) != 0 {
+		t.Fatal("expected infra-1 and gpu-1 to be missing a daemonset pod but got:", missing, dsc.nodesWithUntoleratedTaints)
+	}
+}
+
+func TestDescribeSchedulingProblems(t *testing.T) {
+	pending := apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "daemonset-abc"},
+		Spec: apiv1.PodSpec{
+			Affinity: &apiv1.Affinity{NodeAffinity: &apiv1.NodeAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: &apiv1.NodeSelector{
+					NodeSelectorTerms: []apiv1.NodeSelectorTerm{{
+						MatchFields: []apiv1.NodeSelectorRequirement{{Key: "metadata.name", Operator: apiv1.NodeSelectorOpIn, Values: []string{"gpu-1"}}},
+					}},
+				},
+			}},
+		},
+		Status: apiv1.PodStatus{
+			Phase: apiv1.PodPending,
+			Conditions: []apiv1.PodCondition{{
+				Type:    apiv1.PodScheduled,
+				Status:  apiv1.ConditionFalse,
+				Reason:  apiv1.PodReasonUnschedulable,
+				Message: "0/1 nodes are available: 1 Insufficient cpu.",
+			}},
+		},
+	}
+	running := apiv1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "daemonset-def"}, Spec: apiv1.PodSpec{NodeName: "node-1"}}
+
+	problems := describeSchedulingProblems([]apiv1.Pod{pending, running})
+	if len(problems) != 1 || problems[0] != "pod daemonset-abc can not be scheduled on node gpu-1: 0/1 nodes are available: 1 Insufficient cpu." {
+		t.Fatal("expected the unschedulable pod to be described with its node but got:", problems)
+	}
+}
@@ -0,0 +1,68 @@
+package dnsresolution
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func newTestChecker(client *fake.Clientset, hostname string, labelSelector string) *Checker {
+	dc := NewDNSResolutionChecker(client, hostname, time.Second*10)
+	dc.Namespace = "kube-system"
+	dc.LabelSelector = labelSelector
+	return dc
+}
+
+func TestCheckPass(t *testing.T) {
+	dc := newTestChecker(fake.NewSimpleClientset(), "localhost", "")
+	err := dc.check()
+	if err != nil {
+		t.Fatal("expected localhost to resolve but got:", err)
+	}
+}
+
+func TestCheckEndpointsNoEndpoints(t *testing.T) {
+	dc := newTestChecker(fake.NewSimpleClientset(), "localhost", "k8s-app=kube-dns")
+	err := dc.check()
+	if err == nil || !strings.Contains(err.Error(), "No endpoints found") {
+		t.Fatal("expected an error for missing dns endpoints but got:", err)
+	}
+}
+
+func TestCheckEndpointsNoAddresses(t *testing.T) {
+	endpoints := &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kube-dns",
+			Namespace: "kube-system",
+			Labels:    map[string]string{"k8s-app": "kube-dns"},
+		},
+	}
+	dc := newTestChecker(fake.NewSimpleClientset(endpoints), "localhost", "k8s-app=kube-dns")
+	err := dc.check()
+	if err == nil || !strings.Contains(err.Error(), "No Ip's found") {
+		t.Fatal("expected an error for dns endpoints without addresses but got:", err)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	blockChan := make(chan struct{})
+	defer close(blockChan)
+	client.PrependReactor("list", "endpoints", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		<-blockChan
+		return true, &v1.EndpointsList{}, nil
+	})
+
+	dc := newTestChecker(client, "localhost", "k8s-app=kube-dns")
+	dc.Timeout = time.Millisecond * 50
+	err := dc.check()
+	if err == nil || !strings.Contains(err.Error(), "Timeout was reached") {
+		t.Fatal("expected a timeout error but got:", err)
+	}
+}
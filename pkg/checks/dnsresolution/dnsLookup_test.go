@@ -1,4 +1,4 @@
-package main
+package dnsresolution
 
 import (
 	"context"
@@ -6,22 +6,19 @@ import (
 	"net"
 	"strings"
 	"testing"
-	"time"
 )
 
 func TestDnsLookup(t *testing.T) {
+	// a resolver that can not reach any DNS server still resolves localhost from the hosts file
 	r := &net.Resolver{
 		PreferGo: true,
 		Dial: func(ctx context.Context, network, address2 string) (net.Conn, error) {
-			d := net.Dialer{
-				Timeout: time.Millisecond * time.Duration(10000),
-			}
-			return d.DialContext(ctx, "udp", "8.8.8.8:53")
+			return nil, errors.New("dns server unreachable")
 		},
 	}
 	testCase := make(map[string]error)
 	testCase["bad.host.com"] = errors.New("DNS Status check determined that bad.host.com is DOWN")
-	testCase["google.com"] = nil
+	testCase["localhost"] = nil
 
 	for arg, expectedValue := range testCase {
 		host := arg
@@ -29,13 +26,13 @@ func TestDnsLookup(t *testing.T) {
 		err := dnsLookup(r, host)
 		switch err {
 		case nil:
-			if host != "google.com" {
+			if expectedValue != nil {
 				t.Fatalf("doChecks failed to validate hostname correctly. Hostname: %s, Expected Check Result: %v", arg, expectedValue)
 			}
 			t.Logf("doChecks correctly validated hostname. ")
 		default:
-			if !strings.Contains(err.Error(), expectedValue.Error()) {
-				t.Fatalf("doChecks failed to validate hostname correctly. Hostname: %s, Expected Check Result: %v", arg, expectedValue)
+			if expectedValue == nil || !strings.Contains(err.Error(), expectedValue.Error()) {
+				t.Fatalf("doChecks failed to validate hostname correctly. Hostname: %s, Expected Check Result: %v, got: %v", arg, expectedValue, err)
 			}
 			t.Logf("doChecks correctly validated hostname. ")
 		}
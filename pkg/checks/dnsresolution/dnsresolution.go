@@ -0,0 +1,213 @@
+// Package dnsresolution implements a DNS checker for Kuberhealthy.  It verifies that local DNS and external DNS are
+// functioning correctly.
+package dnsresolution
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultMaxTimeInFailure is how long the check may fail before it is considered down
+const DefaultMaxTimeInFailure = 60 * time.Second
+
+// DefaultTimeout is how long the check may run when no timeout is supplied
+const DefaultTimeout = 5 * time.Minute
+
+// Checker validates that DNS is functioning correctly
+type Checker struct {
+	client           kubernetes.Interface
+	MaxTimeInFailure time.Duration
+	Hostname         string
+	Namespace        string
+	LabelSelector    string
+	Timeout          time.Duration
+	// Resolver is used for lookups along the resolution path. It uses the pod's own DNS configuration.
+	Resolver          *net.Resolver
+	ResolutionLookups []ResolutionLookup
+	// Endpoints are looked up instead of the Hostname when set, and their results are reported as khstate details
+	Endpoints []DNSEndpoint
+	// MaxLookupLatency is how long a lookup can take before it fails the check.  Zero disables the maximum.
+	MaxLookupLatency time.Duration
+}
+
+// NewDNSResolutionChecker returns a new DNS checker that looks up hostname within timeout.  Any kubernetes.Interface
+// can be supplied, which allows a fake clientset to be used for testing.  Lookups use the pod's own DNS configuration,
+// and the other settings of the checker start out blank.
+func NewDNSResolutionChecker(client kubernetes.Interface, hostname string, timeout time.Duration) *Checker {
+	return &Checker{
+		client:           client,
+		Hostname:         hostname,
+		MaxTimeInFailure: DefaultMaxTimeInFailure,
+		Timeout:          timeout,
+		Resolver:         newSystemResolver(),
+	}
+}
+
+// Check runs the DNS checks and returns the errors found, along with the results of the endpoints looked up as
+// details to report them with
+func (dc *Checker) Check() ([]string, map[string]string) {
+	var errs []string
+	var details map[string]string
+	if len(dc.Endpoints) > 0 {
+		// each endpoint and record type is reported on its own, so internal failures can be told apart from
+		// external ones
+		errs, details = dc.checkDNSEndpoints()
+	} else {
+		err := dc.check()
+		if err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	// lookups along the resolution path are reported separately, so a broken search path can be told apart from
+	// broken upstream forwarding
+	errs = append(errs, dc.checkResolutionPath()...)
+	return errs, details
+}
+
+// check runs the DNS checks in a goroutine and returns the first error encountered or an error if the checks
+// do not complete before the checker's timeout
+func (dc *Checker) check() error {
+	log.Infoln("Running DNS status checker")
+	doneChan := make(chan error, 1)
+
+	// run the check in a goroutine and notify the doneChan when completed
+	go func(doneChan chan error) {
+		err := dc.doChecks()
+		doneChan <- err
+	}(doneChan)
+
+	// wait for either a timeout or job completion
+	select {
+	case <-time.After(dc.Timeout):
+		// The check has timed out after its specified timeout period
+		return errors.New("Failed to complete DNS Status check in time! Timeout was reached.")
+	case err := <-doneChan:
+		return err
+	}
+}
+
+// create a Resolver object to use for DNS queries
+func createResolver(ip string) (*net.Resolver, error) {
+	r := &net.Resolver{}
+	// if we're supplied a null string, return an error
+	if len(ip) < 1 {
+		return r, errors.New("Need a valid ip to create Resolver")
+	}
+	// attempt to create the resolver based on the string
+	r = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address2 string) (net.Conn, error) {
+			d := net.Dialer{
+				Timeout: time.Millisecond * time.Duration(10000),
+			}
+			return d.DialContext(ctx, "udp", net.JoinHostPort(ip, "53"))
+		},
+	}
+	return r, nil
+}
+
+func getIpsFromEndpoint(endpoints *v1.EndpointsList) ([]string, error) {
+	var ipList []string
+	if len(endpoints.Items) == 0 {
+		return ipList, errors.New("No endpoints found")
+	}
+	// loop through endpoint list, subsets, and finally addresses to get backend DNS ip's to query
+	for ep := 0; ep < len(endpoints.Items); ep++ {
+		for sub := 0; sub < len(endpoints.Items[ep].Subsets); sub++ {
+			for address := 0; address < len(endpoints.Items[ep].Subsets[sub].Addresses); address++ {
+				// create resolver based on backends found in the dns endpoint
+				ipList = append(ipList, endpoints.Items[ep].Subsets[sub].Addresses[address].IP)
+			}
+		}
+	}
+	if len(ipList) != 0 {
+		return ipList, nil
+	}
+	return ipList, errors.New("No Ip's found in endpoints list")
+}
+
+func dnsLookup(r *net.Resolver, host string) error {
+	_, err := r.LookupHost(context.Background(), host)
+	if err != nil {
+		errorMessage := "DNS Status check determined that " + host + " is DOWN: " + err.Error()
+		return errors.New(errorMessage)
+	}
+	return nil
+}
+
+func (dc *Checker) checkEndpoints() error {
+	endpoints, err := dc.client.CoreV1().Endpoints(dc.Namespace).List(context.Background(), metav1.ListOptions{LabelSelector: dc.LabelSelector})
+	if err != nil {
+		message := "DNS status check unable to get dns endpoints from cluster: " + err.Error()
+		log.Errorln(message)
+		return errors.New(message)
+	}
+
+	//get ips from endpoint list to check
+	ips, err := getIpsFromEndpoint(endpoints)
+	if err != nil {
+		return err
+	}
+
+	//given that we got valid ips from endpoint list, parse them
+	if len(ips) > 0 {
+		for ip := 0; ip < len(ips); ip++ {
+			//create a resolver for each ip and return any error
+			r, err := createResolver(ips[ip])
+			if err != nil {
+				return err
+			}
+			//run a lookup for each ip if we successfully created a resolver, return error
+			start := time.Now()
+			err = dnsLookup(r, dc.Hostname)
+			if err != nil {
+				return err
+			}
+			if latency := time.Since(start); dc.slowLookup(latency) {
+				return dc.slowLookupErr("lookup of "+dc.Hostname+" from DNS endpoint "+ips[ip], latency)
+			}
+		}
+		log.Infoln("DNS Status check from service endpoint determined that", dc.Hostname, "was OK.")
+		return nil
+	}
+	return errors.New("No ips found in endpoint with label: " + dc.LabelSelector)
+}
+
+// doChecks does validations on the DNS call to the endpoint
+func (dc *Checker) doChecks() error {
+
+	log.Infoln("DNS Status check testing hostname:", dc.Hostname)
+
+	// if there's a label selector, do checks against endpoints
+	if len(dc.LabelSelector) > 0 {
+		err := dc.checkEndpoints()
+		if err != nil {
+			return err
+		}
+		return nil
+	}
+
+	// otherwise do lookup against service endpoint
+	start := time.Now()
+	_, err := net.LookupHost(dc.Hostname)
+	if err != nil {
+		errorMessage := "DNS Status check determined that " + dc.Hostname + " is DOWN: " + err.Error()
+		log.Errorln(errorMessage)
+		return errors.New(errorMessage)
+	}
+	if latency := time.Since(start); dc.slowLookup(latency) {
+		err = dc.slowLookupErr("lookup of "+dc.Hostname, latency)
+		log.Errorln(err)
+		return err
+	}
+	log.Infoln("DNS Status check from service endpoint determined that", dc.Hostname, "was OK.")
+	return nil
+}
@@ -0,0 +1,207 @@
+package dnsresolution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+)
+
+// endpointScope tells whether a DNS endpoint is a cluster service or a public name
+type endpointScope string
+
+const (
+	// internalEndpoint is a cluster service that cluster DNS answers for itself
+	internalEndpoint endpointScope = "internal"
+	// externalEndpoint is a public name that cluster DNS forwards to the upstream resolvers
+	externalEndpoint endpointScope = "external"
+)
+
+// defaultRecordType is the record type an endpoint is expected to have when none are listed
+const defaultRecordType = "A"
+
+// supportedRecordTypes are the record types an endpoint can be expected to have.  IP is not a real record type, it is
+// met by either an A or an AAAA record, so that an endpoint can be checked the same way on IPv4, IPv6 and dual-stack
+// clusters.
+var supportedRecordTypes = map[string]bool{
+	"A":     true,
+	"AAAA":  true,
+	"IP":    true,
+	"CNAME": true,
+	"MX":    true,
+	"NS":    true,
+	"TXT":   true,
+}
+
+// DNSEndpoint is a host that is expected to resolve to records of each of its record types
+type DNSEndpoint struct {
+	scope       endpointScope
+	host        string
+	recordTypes []string
+}
+
+// String describes the endpoint for log output, such as "internal endpoint kubernetes.default with record types [A]"
+func (e DNSEndpoint) String() string {
+	return fmt.Sprintf("%s endpoint %s with record types %v", e.scope, e.host, e.recordTypes)
+}
+
+// detailKey returns the key of the khstate detail that holds the result of looking up a record type of the endpoint
+func (e DNSEndpoint) detailKey(recordType string) string {
+	return string(e.scope) + ":" + e.host + ":" + recordType
+}
+
+// err returns the error of a failed lookup, worded so that failures of cluster DNS can be told apart from failures of
+// the upstream resolvers on the status page
+func (e DNSEndpoint) err(recordType string, lookupErr error) error {
+	if e.scope == internalEndpoint {
+		return fmt.Errorf("DNS Status check internal lookup of %s record of %s failed. Cluster DNS may be down: %w", recordType, e.host, lookupErr)
+	}
+	return fmt.Errorf("DNS Status check external lookup of %s record of %s failed. Upstream DNS forwarding may be broken: %w", recordType, e.host, lookupErr)
+}
+
+// slowErr returns the error of a lookup that took longer than the maximum lookup latency
+func (e DNSEndpoint) slowErr(dc *Checker, recordType string, latency time.Duration) error {
+	lookup := fmt.Sprintf("%s lookup of %s record of %s", e.scope, recordType, e.host)
+	if e.scope == internalEndpoint {
+		return fmt.Errorf("%w. Cluster DNS may be overloaded", dc.slowLookupErr(lookup, latency))
+	}
+	return fmt.Errorf("%w. Upstream DNS may be slow", dc.slowLookupErr(lookup, latency))
+}
+
+// ParseDNSEndpoints parses the DNS_ENDPOINTS environment variable.  Endpoints are comma separated and given as
+// scope:host or scope:host:TYPE+TYPE, where scope is internal or external, such as
+// internal:kubernetes.default:AAAA,external:google.com:A+AAAA.  Endpoints without record types are expected to have
+// an A record.
+func ParseDNSEndpoints(s string) ([]DNSEndpoint, error) {
+	var endpoints []DNSEndpoint
+	var lookups int
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf("invalid DNS endpoint %q, expected scope:host or scope:host:TYPE+TYPE", entry)
+		}
+		e := DNSEndpoint{scope: endpointScope(strings.ToLower(parts[0])), host: parts[1]}
+		if e.scope != internalEndpoint && e.scope != externalEndpoint {
+			return nil, fmt.Errorf("invalid scope %q of DNS endpoint %q, expected internal or external", parts[0], entry)
+		}
+
+		e.recordTypes = []string{defaultRecordType}
+		if len(parts) == 3 {
+			e.recordTypes = nil
+			for _, recordType := range strings.Split(parts[2], "+") {
+				recordType = strings.ToUpper(strings.TrimSpace(recordType))
+				if !supportedRecordTypes[recordType] {
+					return nil, fmt.Errorf("unsupported record type %q of DNS endpoint %q", recordType, entry)
+				}
+				e.recordTypes = append(e.recordTypes, recordType)
+			}
+		}
+		lookups += len(e.recordTypes)
+		endpoints = append(endpoints, e)
+	}
+
+	// the result of each lookup is reported as a detail of the khstate
+	if lookups > status.MaxDetails {
+		return nil, fmt.Errorf("DNS_ENDPOINTS has %d record lookups but at most %d are allowed", lookups, status.MaxDetails)
+	}
+	return endpoints, nil
+}
+
+// lookupRecord looks up the records of a type for a host and fails when there are none
+func lookupRecord(ctx context.Context, r *net.Resolver, host string, recordType string) error {
+	var found int
+	switch recordType {
+	case "A", "AAAA", "IP":
+		network := "ip"
+		switch recordType {
+		case "A":
+			network = "ip4"
+		case "AAAA":
+			network = "ip6"
+		}
+		ips, err := r.LookupIP(ctx, network, host)
+		if err != nil {
+			return err
+		}
+		found = len(ips)
+	case "CNAME":
+		cname, err := r.LookupCNAME(ctx, host)
+		if err != nil {
+			return err
+		}
+		// the canonical name of a host without a CNAME record is the host itself
+		if strings.TrimSuffix(cname, ".") != strings.TrimSuffix(host, ".") {
+			found = 1
+		}
+	case "MX":
+		records, err := r.LookupMX(ctx, host)
+		if err != nil {
+			return err
+		}
+		found = len(records)
+	case "NS":
+		records, err := r.LookupNS(ctx, host)
+		if err != nil {
+			return err
+		}
+		found = len(records)
+	case "TXT":
+		records, err := r.LookupTXT(ctx, host)
+		if err != nil {
+			return err
+		}
+		found = len(records)
+	default:
+		return fmt.Errorf("unsupported record type %s", recordType)
+	}
+	if found == 0 {
+		return errors.New("no " + recordType + " records found")
+	}
+	return nil
+}
+
+// checkDNSEndpoints looks up each record type of each endpoint with the pod's own DNS configuration.  An error is
+// returned for each failed lookup and for each lookup slower than the maximum lookup latency.  The result and latency
+// of every lookup is returned as details for the khstate, keyed by scope:host:TYPE, such as OK 12ms, SLOW 1.2s or
+// FAILED.
+func (dc *Checker) checkDNSEndpoints() ([]string, map[string]string) {
+	var errs []string
+	details := make(map[string]string)
+	for _, e := range dc.Endpoints {
+		for _, recordType := range e.recordTypes {
+			ctx, cancel := context.WithTimeout(context.Background(), dc.Timeout)
+			start := time.Now()
+			err := lookupRecord(ctx, dc.Resolver, e.host, recordType)
+			latency := time.Since(start)
+			cancel()
+			if err != nil {
+				err = e.err(recordType, err)
+				log.Errorln(err)
+				errs = append(errs, err.Error())
+				details[e.detailKey(recordType)] = "FAILED"
+				continue
+			}
+			if dc.slowLookup(latency) {
+				err = e.slowErr(dc, recordType, latency)
+				log.Errorln(err)
+				errs = append(errs, err.Error())
+				details[e.detailKey(recordType)] = "SLOW " + latency.Round(time.Millisecond).String()
+				continue
+			}
+			log.Infoln("DNS Status check", e.scope, "lookup of", recordType, "record of", e.host, "was OK in", latency)
+			details[e.detailKey(recordType)] = "OK " + latency.Round(time.Millisecond).String()
+		}
+	}
+	return errs, details
+}
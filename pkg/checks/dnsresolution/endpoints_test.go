@@ -0,0 +1,139 @@
+package dnsresolution
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseDNSEndpoints(t *testing.T) {
+	endpoints, err := ParseDNSEndpoints("internal:kubernetes.default, external:google.com:A+aaaa,EXTERNAL:example.com:MX,internal:kube-dns:ip")
+	if err != nil {
+		t.Fatal("failed to parse endpoints:", err)
+	}
+	expected := []DNSEndpoint{
+		{scope: internalEndpoint, host: "kubernetes.default", recordTypes: []string{"A"}},
+		{scope: externalEndpoint, host: "google.com", recordTypes: []string{"A", "AAAA"}},
+		{scope: externalEndpoint, host: "example.com", recordTypes: []string{"MX"}},
+		{scope: internalEndpoint, host: "kube-dns", recordTypes: []string{"IP"}},
+	}
+	if !reflect.DeepEqual(endpoints, expected) {
+		t.Fatal("expected endpoints", expected, "but got", endpoints)
+	}
+
+	endpoints, err = ParseDNSEndpoints("")
+	if err != nil || len(endpoints) != 0 {
+		t.Fatal("expected no endpoints when unset but got", endpoints, err)
+	}
+
+	invalid := map[string]string{
+		"google.com":                 "expected scope:host",
+		"internal:":                  "expected scope:host",
+		"public:google.com":          "expected internal or external",
+		"external:google.com:SOA":    "unsupported record type",
+		"external:google.com:A:AAAA": "expected scope:host",
+		strings.Repeat("external:google.com:A+AAAA,", 11): "at most 20 are allowed",
+	}
+	for s, expectedErr := range invalid {
+		_, err := ParseDNSEndpoints(s)
+		if err == nil || !strings.Contains(err.Error(), expectedErr) {
+			t.Fatalf("expected parsing %q to fail with %q but got %v", s, expectedErr, err)
+		}
+	}
+}
+
+func TestCheckDNSEndpoints(t *testing.T) {
+	dc := newTestChecker(fake.NewSimpleClientset(), "", "")
+	dc.Timeout = time.Second * 5
+
+	// a resolver that can not reach any DNS server still resolves localhost from the hosts file
+	dc.Resolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return nil, errors.New("dns server unreachable")
+		},
+	}
+	dc.Endpoints = []DNSEndpoint{
+		{scope: internalEndpoint, host: "localhost", recordTypes: []string{"A", "IP"}},
+		{scope: internalEndpoint, host: "kubernetes.default", recordTypes: []string{"A"}},
+		{scope: externalEndpoint, host: "google.com", recordTypes: []string{"MX"}},
+	}
+
+	errs, details := dc.checkDNSEndpoints()
+	if len(errs) != 2 {
+		t.Fatal("expected an error for each lookup that needs a DNS server but got:", errs)
+	}
+	if !strings.Contains(errs[0], "internal lookup of A record of kubernetes.default") || !strings.Contains(errs[0], "Cluster DNS") {
+		t.Fatal("expected the internal lookup to fail as a cluster DNS failure but got:", errs[0])
+	}
+	if !strings.Contains(errs[1], "external lookup of MX record of google.com") || !strings.Contains(errs[1], "Upstream DNS") {
+		t.Fatal("expected the external lookup to fail as an upstream DNS failure but got:", errs[1])
+	}
+
+	if !strings.HasPrefix(details["internal:localhost:A"], "OK ") {
+		t.Fatal("expected the localhost lookup to be OK with its latency but got:", details["internal:localhost:A"])
+	}
+	if !strings.HasPrefix(details["internal:localhost:IP"], "OK ") {
+		t.Fatal("expected the localhost lookup of either address family to be OK but got:", details["internal:localhost:IP"])
+	}
+	delete(details, "internal:localhost:A")
+	delete(details, "internal:localhost:IP")
+	expected := map[string]string{
+		"internal:kubernetes.default:A": "FAILED",
+		"external:google.com:MX":        "FAILED",
+	}
+	if !reflect.DeepEqual(details, expected) {
+		t.Fatal("expected details", expected, "but got", details)
+	}
+}
+
+func TestCheckDNSEndpointsLatency(t *testing.T) {
+	dc := newTestChecker(fake.NewSimpleClientset(), "", "")
+	dc.Timeout = time.Second * 5
+	dc.Resolver = &net.Resolver{PreferGo: true}
+	dc.Endpoints = []DNSEndpoint{{scope: internalEndpoint, host: "localhost", recordTypes: []string{"A"}}}
+
+	// every lookup is slower than a nanosecond
+	dc.MaxLookupLatency = time.Nanosecond
+	errs, details := dc.checkDNSEndpoints()
+	if len(errs) != 1 || !strings.Contains(errs[0], "internal lookup of A record of localhost took") || !strings.Contains(errs[0], "Cluster DNS may be overloaded") {
+		t.Fatal("expected the lookup to be too slow but got:", errs)
+	}
+	if !strings.HasPrefix(details["internal:localhost:A"], "SLOW ") {
+		t.Fatal("expected the lookup to be reported as slow but got:", details)
+	}
+
+	dc.MaxLookupLatency = time.Minute
+	errs, details = dc.checkDNSEndpoints()
+	if len(errs) != 0 || !strings.HasPrefix(details["internal:localhost:A"], "OK ") {
+		t.Fatal("expected the lookup to be OK within a minute but got:", errs, details)
+	}
+}
+
+func TestMaxLookupLatencyFromEnv(t *testing.T) {
+	t.Setenv("MAX_LOOKUP_LATENCY", "")
+	latency, err := MaxLookupLatencyFromEnv()
+	if err != nil || latency != 0 {
+		t.Fatal("expected no maximum when unset but got", latency, err)
+	}
+
+	t.Setenv("MAX_LOOKUP_LATENCY", "250ms")
+	latency, err = MaxLookupLatencyFromEnv()
+	if err != nil || latency != time.Millisecond*250 {
+		t.Fatal("expected a maximum of 250ms but got", latency, err)
+	}
+
+	for _, v := range []string{"fast", "-1s"} {
+		t.Setenv("MAX_LOOKUP_LATENCY", v)
+		_, err = MaxLookupLatencyFromEnv()
+		if err == nil {
+			t.Fatalf("expected parsing %q to fail", v)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package dnsresolution
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetIpsFromEndpoint(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kube-dns",
+			Namespace: "kube-system",
+			Labels:    map[string]string{"k8s-app": "kube-dns"},
+		},
+		Subsets: []v1.EndpointSubset{
+			{Addresses: []v1.EndpointAddress{{IP: "10.0.0.10"}, {IP: "10.0.0.11"}}},
+		},
+	})
+	endpoints, err := client.CoreV1().Endpoints("kube-system").List(context.Background(), metav1.ListOptions{LabelSelector: "k8s-app=kube-dns"})
+	if err != nil {
+		t.Fatalf("Unable to get endpoint list %+v\n", err)
+	}
+
+	ips, err := getIpsFromEndpoint(endpoints)
+	if err != nil {
+		t.Fatalf(err.Error())
+	}
+	if !reflect.DeepEqual(ips, []string{"10.0.0.10", "10.0.0.11"}) {
+		t.Fatalf("Expected the ips of the endpoint addresses but got %v", ips)
+	}
+
+}
@@ -0,0 +1,35 @@
+package dnsresolution
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// MaxLookupLatencyFromEnv returns the maximum lookup latency set in the MAX_LOOKUP_LATENCY environment variable.
+// Lookups are not timed against a maximum when it is unset.
+func MaxLookupLatencyFromEnv() (time.Duration, error) {
+	v := os.Getenv("MAX_LOOKUP_LATENCY")
+	if len(v) == 0 {
+		return 0, nil
+	}
+	latency, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, err
+	}
+	if latency < 0 {
+		return 0, fmt.Errorf("%s is negative", v)
+	}
+	return latency, nil
+}
+
+// slowLookup determines if a lookup that succeeded took longer than the maximum lookup latency.  Slow DNS is as
+// damaging to most workloads as failing DNS, so slow lookups fail the check too.
+func (dc *Checker) slowLookup(latency time.Duration) bool {
+	return dc.MaxLookupLatency > 0 && latency > dc.MaxLookupLatency
+}
+
+// slowLookupErr returns the error of a lookup of a host that took longer than the maximum lookup latency
+func (dc *Checker) slowLookupErr(lookup string, latency time.Duration) error {
+	return fmt.Errorf("DNS Status check %s took %s, which is longer than the maximum of %s", lookup, latency.Round(time.Millisecond), dc.MaxLookupLatency)
+}
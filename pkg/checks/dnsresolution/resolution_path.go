@@ -0,0 +1,127 @@
+package dnsresolution
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultShortName = "kubernetes.default"
+const defaultFQDN = "kubernetes.default.svc.cluster.local."
+
+// lookupClass is the class of a lookup along the resolution path a workload uses
+type lookupClass int
+
+const (
+	// searchPathLookup is a lookup of a short name that only resolves through the pod's DNS search domains
+	searchPathLookup lookupClass = iota
+	// clusterFQDNLookup is a lookup of a fully qualified cluster name that does not use the search domains
+	clusterFQDNLookup
+	// externalLookup is a lookup of a name outside of the cluster that cluster DNS forwards upstream
+	externalLookup
+)
+
+// ResolutionLookup is a lookup of a host along the resolution path a workload uses
+type ResolutionLookup struct {
+	class lookupClass
+	host  string
+}
+
+// String returns the host that is looked up
+func (l ResolutionLookup) String() string {
+	return l.host
+}
+
+// err returns the error of a failed lookup, worded so that the class of the failed lookup can be told apart on the
+// status page
+func (l ResolutionLookup) err(lookupErr error) error {
+	switch l.class {
+	case searchPathLookup:
+		return fmt.Errorf("DNS Status check search path lookup of %s failed. The pod DNS search domains or ndots may be broken: %w", l.host, lookupErr)
+	case clusterFQDNLookup:
+		return fmt.Errorf("DNS Status check cluster lookup of FQDN %s failed. Cluster DNS may be down: %w", l.host, lookupErr)
+	}
+	return fmt.Errorf("DNS Status check external lookup of %s failed. Upstream DNS forwarding may be broken: %w", l.host, lookupErr)
+}
+
+// ResolutionLookupsFromEnv returns the lookups along the resolution path configured with the DNS_CHECK_SHORT_NAME,
+// DNS_CHECK_FQDN and DNS_CHECK_EXTERNAL_NAMES environment variables.  The short name and FQDN default to the
+// kubernetes API service and are skipped when set blank.  External names are comma separated and are only looked up
+// when set, since not every cluster permits egress.
+func ResolutionLookupsFromEnv() []ResolutionLookup {
+	var lookups []ResolutionLookup
+
+	shortName, ok := os.LookupEnv("DNS_CHECK_SHORT_NAME")
+	if !ok {
+		shortName = defaultShortName
+	}
+	if len(shortName) > 0 {
+		lookups = append(lookups, ResolutionLookup{class: searchPathLookup, host: shortName})
+	}
+
+	fqdn, ok := os.LookupEnv("DNS_CHECK_FQDN")
+	if !ok {
+		fqdn = defaultFQDN
+	}
+	if len(fqdn) > 0 {
+		// the trailing dot keeps the resolver from trying the search domains
+		if !strings.HasSuffix(fqdn, ".") {
+			fqdn = fqdn + "."
+		}
+		lookups = append(lookups, ResolutionLookup{class: clusterFQDNLookup, host: fqdn})
+	}
+
+	for _, name := range strings.Split(os.Getenv("DNS_CHECK_EXTERNAL_NAMES"), ",") {
+		name = strings.TrimSpace(name)
+		if len(name) > 0 {
+			lookups = append(lookups, ResolutionLookup{class: externalLookup, host: name})
+		}
+	}
+
+	return lookups
+}
+
+// checkResolutionPath looks up each host along the resolution path with the pod's own DNS configuration, the way a
+// workload would, and returns an error for each lookup that failed or was slower than the maximum lookup latency
+func (dc *Checker) checkResolutionPath() []string {
+	var errs []string
+	for _, lookup := range dc.ResolutionLookups {
+		ctx, cancel := context.WithTimeout(context.Background(), dc.Timeout)
+		start := time.Now()
+		_, err := dc.Resolver.LookupHost(ctx, lookup.host)
+		latency := time.Since(start)
+		cancel()
+		if err != nil {
+			err = lookup.err(err)
+			log.Errorln(err)
+			errs = append(errs, err.Error())
+			continue
+		}
+		if dc.slowLookup(latency) {
+			err = dc.slowLookupErr("resolution path lookup of "+lookup.host, latency)
+			log.Errorln(err)
+			errs = append(errs, err.Error())
+			continue
+		}
+		log.Infoln("DNS Status check resolution path lookup of", lookup.host, "was OK in", latency)
+	}
+	return errs
+}
+
+// newSystemResolver returns a resolver that uses the pod's resolv.conf, including its search domains and ndots
+func newSystemResolver() *net.Resolver {
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			d := net.Dialer{
+				Timeout: time.Millisecond * time.Duration(10000),
+			}
+			return d.DialContext(ctx, network, address)
+		},
+	}
+}
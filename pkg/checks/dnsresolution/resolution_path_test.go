@@ -0,0 +1,72 @@
+package dnsresolution
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestResolutionLookupsFromEnv(t *testing.T) {
+	t.Setenv("DNS_CHECK_FQDN", "kubernetes.default.svc.example.org")
+	t.Setenv("DNS_CHECK_EXTERNAL_NAMES", "google.com, example.com")
+
+	lookups := ResolutionLookupsFromEnv()
+	expected := []ResolutionLookup{
+		{class: searchPathLookup, host: defaultShortName},
+		{class: clusterFQDNLookup, host: "kubernetes.default.svc.example.org."},
+		{class: externalLookup, host: "google.com"},
+		{class: externalLookup, host: "example.com"},
+	}
+	if len(lookups) != len(expected) {
+		t.Fatal("expected lookups", expected, "but got", lookups)
+	}
+	for i := range expected {
+		if lookups[i] != expected[i] {
+			t.Fatal("expected lookups", expected, "but got", lookups)
+		}
+	}
+
+	// blank names skip their lookup and external names are disabled by default
+	t.Setenv("DNS_CHECK_SHORT_NAME", "")
+	t.Setenv("DNS_CHECK_FQDN", "")
+	t.Setenv("DNS_CHECK_EXTERNAL_NAMES", "")
+	lookups = ResolutionLookupsFromEnv()
+	if len(lookups) != 0 {
+		t.Fatal("expected no lookups but got", lookups)
+	}
+}
+
+func TestCheckResolutionPath(t *testing.T) {
+	dc := newTestChecker(fake.NewSimpleClientset(), "localhost", "")
+	dc.Timeout = time.Second * 5
+
+	// a resolver that can not reach any DNS server still resolves localhost from the hosts file
+	dc.Resolver = &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return nil, errors.New("dns server unreachable")
+		},
+	}
+	dc.ResolutionLookups = []ResolutionLookup{
+		{class: searchPathLookup, host: "localhost"},
+		{class: searchPathLookup, host: "kubernetes.default"},
+		{class: clusterFQDNLookup, host: "kubernetes.default.svc.cluster.local."},
+		{class: externalLookup, host: "google.com"},
+	}
+
+	errs := dc.checkResolutionPath()
+	if len(errs) != 3 {
+		t.Fatal("expected an error for each lookup that needs a DNS server but got:", errs)
+	}
+	expected := []string{"search path lookup of kubernetes.default", "cluster lookup of FQDN", "Upstream DNS forwarding"}
+	for i, e := range expected {
+		if !strings.Contains(errs[i], e) {
+			t.Fatalf("expected error %q to contain %q", errs[i], e)
+		}
+	}
+}
@@ -6,6 +6,8 @@ package checkclient
 
 import (
 	"bytes"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -56,6 +58,37 @@ func ReportFailure(errorMessages []string) error {
 	return sendReport(newReport)
 }
 
+// ReportSuccessWithDetails reports a successful check run along with details that are shown with the check on the
+// Kuberhealthy status page, such as the number of records verified.
+func ReportSuccessWithDetails(details map[string]string) error {
+	writeLog("DEBUG: Reporting SUCCESS with", len(details), "details")
+
+	newReport := status.NewReport([]string{})
+	newReport.Details = details
+	return sendReport(newReport)
+}
+
+// ReportFailureWithDetails reports that the external checker has found problems along with details that are shown
+// with the check on the Kuberhealthy status page, such as the availability zone that failed.
+func ReportFailureWithDetails(errorMessages []string, details map[string]string) error {
+	writeLog("DEBUG: Reporting FAILURE with", len(details), "details")
+
+	newReport := status.NewReport(errorMessages)
+	newReport.Details = details
+	return sendReport(newReport)
+}
+
+// ReportWarningWithDetails reports that the external checker has found problems that are only a warning.  Warnings
+// are shown with the check on the status page, exported as metrics and notified, but do not make the cluster
+// unhealthy.
+func ReportWarningWithDetails(errorMessages []string, details map[string]string) error {
+	writeLog("DEBUG: Reporting WARNING with", len(details), "details")
+
+	newReport := status.NewWarningReport(errorMessages)
+	newReport.Details = details
+	return sendReport(newReport)
+}
+
 // writeLog writes a log entry if debugging is enabled
 func writeLog(i ...interface{}) {
 	if Debug {
@@ -91,18 +124,25 @@ func sendReport(s status.Report) error {
 	}
 	writeLog("INFO: Using kuberhealthy run UUID: ", uuid)
 
-	// create the Kuberhealthy post request with the kh-run-uuid header
+	// create the Kuberhealthy post request with the kh-run-uuid header, and the kh-report-token header when
+	// Kuberhealthy gave this run a report token
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(b))
 	if err != nil {
 		return fmt.Errorf("error creating http request: %w", err)
 	}
 	req.Header.Set("kh-run-uuid", uuid)
+	if token := os.Getenv(external.KHReportToken); len(token) > 0 {
+		req.Header.Set("kh-report-token", token)
+	}
 	req.Header.Set("Content-Type", "application/json")
 
 	exponentialBackOff := backoff.NewExponentialBackOff()
 	exponentialBackOff.MaxElapsedTime = maxElapsedTime
 
-	client := &http.Client{}
+	client, err := newReportingClient()
+	if err != nil {
+		return err
+	}
 	// send to the server
 	var resp *http.Response
 	err = backoff.Retry(func() error {
@@ -145,6 +185,25 @@ func getKuberhealthyURL() (string, error) {
 	return reportingURL, nil
 }
 
+// newReportingClient creates the http client reports are sent with.  When Kuberhealthy is served over TLS with a
+// certificate that is not signed by a system root, the CA bundle it is signed by is taken from the environment
+// variables.
+func newReportingClient() (*http.Client, error) {
+	ca := os.Getenv(external.KHReportingCA)
+	if len(ca) == 0 {
+		return &http.Client{}, nil
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(ca)) {
+		writeLog("ERROR: kuberhealthy reporting CA from environment variable", external.KHReportingCA, "did not contain a PEM certificate")
+		return nil, fmt.Errorf("%s environment variable does not contain a PEM certificate", external.KHReportingCA)
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return &http.Client{Transport: transport}, nil
+}
+
 // getKuberhealthyRunUUID fetches the kuberheathy checker pod run UUID to send to our external checker
 // status to report to from the environment variable
 func getKuberhealthyRunUUID() (string, error) {
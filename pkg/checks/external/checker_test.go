@@ -3,11 +3,12 @@ package external
 import (
 	"context"
 	"errors"
-	"log"
+	"os"
 	"testing"
 
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
 
 	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
 	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
@@ -16,41 +17,40 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 )
 
-var client *kubernetes.Clientset
-
 const defaultNamespace = "kuberhealthy"
 
-var khStateClient *khstatev1.KHStateV1Client
-var khCheckClient *khcheckv1.KHCheckV1Client
-
-func init() {
+// clusterClients creates clients of the cluster in the kubeconfig file for the tests that run checks end to end.
+// Those tests are skipped when there is no cluster to run them on.
+func clusterClients(t *testing.T) (*kubernetes.Clientset, *khcheckv1.KHCheckV1Client, *khstatev1.KHStateV1Client) {
+	t.Helper()
+	_, err := os.Stat(kubeConfigFile)
+	if err != nil {
+		t.Skip("Skipping a test that needs a cluster:", err)
+	}
 
 	// create a kubernetes clientset for our tests to use
-	var err error
-	client, err = kubeClient.Create(kubeConfigFile)
+	client, err := kubeClient.Create(kubeConfigFile)
 	if err != nil {
-		log.Fatal("Unable to create kubernetes client", err)
+		t.Fatal("Unable to create kubernetes client", err)
 	}
 
 	// make a new crd check client
 	checkClient, err := khcheckv1.Client(kubeConfigFile)
 	if err != nil {
-		log.Fatalln("err")
+		t.Fatal("Unable to create khcheck client", err)
 	}
-	khCheckClient = checkClient
 
 	// make a new crd state client
 	stateClient, err := khstatev1.Client(kubeConfigFile)
 	if err != nil {
-		log.Fatalln("err")
+		t.Fatal("Unable to create khstate client", err)
 	}
-	khStateClient = stateClient
-
+	return client, checkClient, stateClient
 }
 
 // newTestChecker creates a new test checker struct with a basic set of defaults
 // that work out of the box
-func newTestChecker(client *kubernetes.Clientset) (*Checker, error) {
+func newTestChecker(client kubernetes.Interface) (*Checker, error) {
 	podCheckFile := "test/basicCheckerPod.yaml"
 	p, err := loadTestPodSpecFile(podCheckFile)
 	if err != nil {
@@ -60,18 +60,27 @@ func newTestChecker(client *kubernetes.Clientset) (*Checker, error) {
 	return chk, err
 }
 
+// newClusterTestChecker creates a new test checker that runs on the cluster in the kubeconfig file
+func newClusterTestChecker(t *testing.T) *Checker {
+	t.Helper()
+	client, checkClient, stateClient := clusterClients(t)
+	checker, err := newTestChecker(client)
+	if err != nil {
+		t.Fatal("Failed to create new external check:", err)
+	}
+	checker.KHCheckClient = checkClient
+	checker.KHStateClient = stateClient
+	return checker
+}
+
 // TestExternalChecker tests the external checker end to end
 func TestExternalChecker(t *testing.T) {
 
 	// make a new default checker of this check
-	checker, err := newTestChecker(client)
-	if err != nil {
-		t.Fatal("Failed to create client:", err)
-	}
-	checker.KubeClient = client
+	checker := newClusterTestChecker(t)
 
 	// run the checker with the kube client
-	err = checker.RunOnce(context.Background())
+	err := checker.RunOnce(context.Background())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -79,9 +88,9 @@ func TestExternalChecker(t *testing.T) {
 
 // newTestCheckFromSpec creates a new test checker but using the supplied
 // spec file for a khcheck
-func newTestCheckFromSpec(client *kubernetes.Clientset, checkSpec *khcheckv1.KuberhealthyCheck, reportingURL string) *Checker {
+func newTestCheckFromSpec(client kubernetes.Interface, checkSpec *khcheckv1.KuberhealthyCheck, reportingURL string) *Checker {
 	// create a new checker and insert this pod spec
-	checker := New(client, checkSpec, khCheckClient, khStateClient, reportingURL) // external checker does not ever return an error so we drop it
+	checker := New(client, checkSpec, nil, nil, reportingURL) // external checker does not ever return an error so we drop it
 	checker.Debug = true
 	return checker
 }
@@ -92,17 +101,13 @@ func TestExternalCheckerSanitation(t *testing.T) {
 	t.Parallel()
 
 	// make a new default checker of this check
-	checker, err := newTestChecker(client)
-	if err != nil {
-		t.Fatal("Failed to create client:", err)
-	}
-	checker.KubeClient = client
+	checker := newClusterTestChecker(t)
 
 	// sabotage the pod name
 	checker.CheckName = ""
 
 	// run the checker with the kube client
-	err = checker.RunOnce(context.Background())
+	err := checker.RunOnce(context.Background())
 	if err == nil {
 		t.Fatal("Expected pod name blank validation check failure but did not hit it")
 	}
@@ -161,17 +166,8 @@ func deleteKHCheckSpec(checkName string, checkNamespace string) error {
 // removing other properties of the check
 func TestWriteWhitelistedUUID(t *testing.T) {
 
-	// create a client for kubernetes
-	client, err := kubeClient.Create(kubeConfigFile)
-	if err != nil {
-		t.Fatal("Failed to create kube client:", err)
-	}
-
 	// make an external checker for kubernetes
-	checker, err := newTestChecker(client)
-	if err != nil {
-		t.Fatal("Failed to create new external check:", err)
-	}
+	checker := newClusterTestChecker(t)
 
 	// generate a fresh UUID for this test
 	testUUID := checker.currentCheckUUID
@@ -233,17 +229,10 @@ func TestGetWhitelistedUUIDForExternalCheck(t *testing.T) {
 	var testUUID = "test-UUID-1234"
 
 	// make an external check and cause it to write a whitelist
-	c, err := newTestChecker(client)
-	if err != nil {
-		t.Fatal("Failed to create new external check:", err)
-	}
-	c.KubeClient, err = kubeClient.Create(kubeConfigFile)
-	if err != nil {
-		t.Fatal("Failed to create kube client:", err)
-	}
+	c := newClusterTestChecker(t)
 
 	// delete the UUID (blank it out)
-	err = c.setUUID("")
+	err := c.setUUID("")
 	if err != nil {
 		t.Fatal("Failed to blank the UUID on test check:", err)
 	}
@@ -276,7 +265,7 @@ func TestGetWhitelistedUUIDForExternalCheck(t *testing.T) {
 }
 
 func TestSanityCheck(t *testing.T) {
-	c, err := newTestChecker(client)
+	c, err := newTestChecker(fake.NewSimpleClientset())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,110 @@
+package external
+
+import (
+	"context"
+	"time"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+)
+
+// maxAllowedUUIDs is the most UUIDs of overlapping runs that are allowed to report in at once.  This keeps runs that
+// never released their UUID, such as runs interrupted by a Kuberhealthy restart, from piling up on the khstate.
+const maxAllowedUUIDs = 10
+
+// allowsConcurrentRuns indicates that runs of this check may overlap
+func (ext *Checker) allowsConcurrentRuns() bool {
+	return ext.ConcurrencyPolicy == khcheckv1.AllowConcurrent
+}
+
+// allowedUUIDs returns the UUIDs of overlapping runs that may report in once the supplied uuid is whitelisted
+func (ext *Checker) allowedUUIDs(existing []string, uuid string) []string {
+	if !ext.allowsConcurrentRuns() {
+		return nil
+	}
+	allowed := append(removeUUID(existing, uuid), uuid)
+	if len(allowed) > maxAllowedUUIDs {
+		allowed = allowed[len(allowed)-maxAllowedUUIDs:]
+	}
+	return allowed
+}
+
+// removeUUID returns the UUIDs without the supplied one
+func removeUUID(uuids []string, uuid string) []string {
+	var out []string
+	for _, u := range uuids {
+		if u != uuid {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// releaseUUID removes the UUID of a finished run from the UUIDs of overlapping runs that may report in.  Runs that
+// are not allowed to overlap never add their UUID, so there is nothing to release.
+func (ext *Checker) releaseUUID(uuid string) {
+	if !ext.allowsConcurrentRuns() {
+		return
+	}
+
+	// another run or report may update the khstate at the same time, so we retry on conflicts
+	for tries := 0; tries < 5; tries++ {
+		checkState, err := ext.getKHState()
+		if err != nil {
+			ext.log("failed to fetch khstate to release uuid", uuid+":", err)
+			return
+		}
+		checkState.Spec.AllowedUUIDs = removeUUID(checkState.Spec.AllowedUUIDs, uuid)
+		_, err = ext.KHStateClient.KuberhealthyStates(ext.CheckNamespace()).Update(&checkState)
+		if err == nil {
+			return
+		}
+		ext.log("failed to release uuid", uuid, "from khstate:", err)
+		time.Sleep(time.Second)
+	}
+}
+
+// Clone creates a new checker with the configuration of this checker.  The clone shares no run state with this
+// checker, so it can run next to it when the concurrencyPolicy allows overlapping runs.
+func (ext *Checker) Clone() *Checker {
+	return &Checker{
+		CheckName:                ext.CheckName,
+		Namespace:                ext.Namespace,
+		RunInterval:              ext.RunInterval,
+		Schedule:                 ext.Schedule,
+		ScheduleTimezone:         ext.ScheduleTimezone,
+		RunTimeout:               ext.RunTimeout,
+		KubeClient:               ext.KubeClient,
+		KHJobClient:              ext.KHJobClient,
+		KHCheckClient:            ext.KHCheckClient,
+		KHStateClient:            ext.KHStateClient,
+		PodSpec:                  *ext.OriginalPodSpec.DeepCopy(),
+		OriginalPodSpec:          *ext.OriginalPodSpec.DeepCopy(),
+		KuberhealthyReportingURL: ext.KuberhealthyReportingURL,
+		KuberhealthyReportingCA:  ext.KuberhealthyReportingCA,
+		ExtraAnnotations:         ext.ExtraAnnotations,
+		ExtraLabels:              ext.ExtraLabels,
+		DefaultResources:         *ext.DefaultResources.DeepCopy(),
+		RBAC:                     ext.RBAC.DeepCopy(),
+		ImagePullSecrets:         ext.ImagePullSecrets,
+		Debug:                    ext.Debug,
+		hostname:                 ext.hostname,
+		KHWorkload:               ext.KHWorkload,
+		MaxFailureLogBytes:       ext.MaxFailureLogBytes,
+		RunnerPod:                ext.RunnerPod,
+		ConcurrencyPolicy:        ext.ConcurrencyPolicy,
+		FailureThreshold:         ext.FailureThreshold,
+		RetryBackoff:             ext.RetryBackoff,
+	}
+}
+
+// Invalidate invalidates the run in flight by whitelisting a new UUID, so that a late report from its checker pod
+// is rejected, and then evicts its checker pod.  This is used to replace a run that is still in flight.
+func (ext *Checker) Invalidate(ctx context.Context) error {
+	ext.log("invalidating run in flight")
+	err := ext.setNewCheckUUID()
+	if err != nil {
+		return err
+	}
+	ext.cleanup(ctx)
+	return nil
+}
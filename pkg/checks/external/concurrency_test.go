@@ -0,0 +1,57 @@
+package external
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+
+	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
+)
+
+// TestAllowedUUIDs ensures only checks that allow overlapping runs whitelist extra UUIDs and that the number of
+// whitelisted UUIDs is bounded
+func TestAllowedUUIDs(t *testing.T) {
+	ext := &Checker{ConcurrencyPolicy: khcheckv1.ForbidConcurrent}
+	if ext.allowedUUIDs([]string{"a"}, "b") != nil {
+		t.Fatal("expected no allowed uuids for checks that forbid overlapping runs")
+	}
+
+	ext.ConcurrencyPolicy = khcheckv1.AllowConcurrent
+	allowed := ext.allowedUUIDs([]string{"a", "b"}, "b")
+	if len(allowed) != 2 || allowed[0] != "a" || allowed[1] != "b" {
+		t.Fatal("unexpected allowed uuids:", allowed)
+	}
+
+	for i := 0; i < maxAllowedUUIDs*2; i++ {
+		allowed = ext.allowedUUIDs(allowed, string(rune('c'+i)))
+	}
+	if len(allowed) != maxAllowedUUIDs {
+		t.Fatal("expected allowed uuids to be bounded to", maxAllowedUUIDs, "but got", len(allowed))
+	}
+
+	allowed = removeUUID(allowed, allowed[0])
+	if len(allowed) != maxAllowedUUIDs-1 {
+		t.Fatal("expected uuid to be removed but got", allowed)
+	}
+}
+
+// TestClone ensures a cloned checker does not share its pod spec with the original
+func TestClone(t *testing.T) {
+	ext := &Checker{
+		CheckName:         "dns",
+		Namespace:         "kuberhealthy",
+		ConcurrencyPolicy: khcheckv1.AllowConcurrent,
+		OriginalPodSpec: apiv1.PodSpec{
+			Containers: []apiv1.Container{{Name: "main", Image: "kuberhealthy/dns-status-check:v1"}},
+		},
+	}
+	clone := ext.Clone()
+	if clone.CheckName != ext.CheckName || clone.Namespace != ext.Namespace || clone.ConcurrencyPolicy != ext.ConcurrencyPolicy {
+		t.Fatal("expected clone to have the configuration of the checker:", clone)
+	}
+
+	clone.OriginalPodSpec.Containers[0].Env = append(clone.OriginalPodSpec.Containers[0].Env, apiv1.EnvVar{Name: "KH_RUN_UUID"})
+	if len(ext.OriginalPodSpec.Containers[0].Env) != 0 {
+		t.Fatal("expected clone to not share its pod spec with the checker")
+	}
+}
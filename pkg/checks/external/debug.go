@@ -0,0 +1,48 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+)
+
+// DebugPod prepares the checker pod of a single run of the check for the local debug runner.  The pod gets the same
+// spec, environment, labels and RBAC as the checker pods of scheduled runs, with the supplied run UUID and deadline.
+// Unlike scheduled runs, the check does not need to exist in the cluster, so the pod is not owned by it, and no
+// khstate is read or written.
+func (ext *Checker) DebugPod(ctx context.Context, runUUID string, deadline time.Time) (*apiv1.Pod, error) {
+	ext.currentCheckUUID = runUUID
+	ext.regeneratePodName()
+
+	err := ext.configureUserPodSpec(deadline)
+	if err != nil {
+		return nil, errors.New("failed to configure pod spec for Kubernetes from user specified pod spec: " + err.Error())
+	}
+	err = ext.validatePodSpec()
+	if err != nil {
+		return nil, err
+	}
+	err = ext.ensureRBAC(ctx)
+	if err != nil {
+		return nil, errors.New("failed to create the RBAC of the checker pod: " + err.Error())
+	}
+
+	p := &apiv1.Pod{}
+	p.Namespace = ext.Namespace
+	p.Name = ext.podName()
+	p.Spec = ext.PodSpec
+	ext.addKuberhealthyLabels(p)
+	return p, nil
+}
+
+// PodStartError returns an error when a checker pod can never start, because an image can not be pulled or a
+// container can not be configured.  nil is returned for pods that are still starting.
+func PodStartError(p *apiv1.Pod) error {
+	err := imagePullError(p)
+	if err != nil {
+		return err
+	}
+	return containerConfigError(p)
+}
@@ -0,0 +1,109 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultMaxFailureLogBytes is the default amount of checker pod logs kept when a check run fails
+const DefaultMaxFailureLogBytes = 4096
+
+// failureLogTailLines is the number of log lines requested from each container when capturing the logs of a failed
+// checker pod.  Only the last bytes of these lines are kept.
+const failureLogTailLines int64 = 200
+
+// FailureLogs returns the excerpt of the checker pod logs that was captured when the last run failed.  A blank
+// string is returned if the last run did not fail or its logs could not be captured.
+func (ext *Checker) FailureLogs() string {
+	return ext.failureLogs
+}
+
+// CaptureFailureLogs captures an excerpt of the logs of the current checker pod and returns it.  This is used when a
+// checker pod reports a failure.  Errors fetching logs are logged and never returned, so they can not mask the
+// failure of the check itself.
+func (ext *Checker) CaptureFailureLogs(ctx context.Context) string {
+	if ext.MaxFailureLogBytes <= 0 || ext.KubeClient == nil {
+		return ""
+	}
+
+	logs, err := ext.fetchPodLogs(ctx)
+	if err != nil {
+		ext.log("unable to capture logs of checker pod", ext.podName()+":", err)
+	}
+	ext.failureLogs = logs
+	return logs
+}
+
+// fetchPodLogs fetches the last MaxFailureLogBytes of logs from all containers of the current checker pod
+func (ext *Checker) fetchPodLogs(ctx context.Context) (string, error) {
+	podClient := ext.KubeClient.CoreV1().Pods(ext.Namespace)
+	pod, err := podClient.Get(ctx, ext.podName(), metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch checker pod: %w", err)
+	}
+
+	var logs []string
+	var logErr error
+	for _, container := range pod.Spec.Containers {
+		tailLines := failureLogTailLines
+		req := podClient.GetLogs(pod.Name, &apiv1.PodLogOptions{
+			Container: container.Name,
+			TailLines: &tailLines,
+		})
+		stream, err := req.Stream(ctx)
+		if err != nil {
+			logErr = fmt.Errorf("failed to fetch logs of container %s: %w", container.Name, err)
+			continue
+		}
+		containerLogs, err := tailBytes(stream, ext.MaxFailureLogBytes)
+		stream.Close()
+		if err != nil {
+			logErr = fmt.Errorf("failed to read logs of container %s: %w", container.Name, err)
+			continue
+		}
+		if len(pod.Spec.Containers) > 1 {
+			containerLogs = "[" + container.Name + "] " + containerLogs
+		}
+		logs = append(logs, containerLogs)
+	}
+
+	return truncateLogs(strings.Join(logs, "\n"), ext.MaxFailureLogBytes), logErr
+}
+
+// tailBytes reads the reader to its end and returns the last n bytes that were read
+func tailBytes(r io.Reader, n int) (string, error) {
+	var tail []byte
+	buf := make([]byte, 4096)
+	for {
+		read, err := r.Read(buf)
+		tail = append(tail, buf[:read]...)
+		// keep a few extra bytes so that the final cut can be made on a character boundary
+		if len(tail) > 2*n+utf8.UTFMax {
+			tail = tail[len(tail)-n-utf8.UTFMax:]
+		}
+		if err == io.EOF {
+			return truncateLogs(string(tail), n), nil
+		}
+		if err != nil {
+			return truncateLogs(string(tail), n), err
+		}
+	}
+}
+
+// truncateLogs keeps the last n bytes of the logs without cutting a multi-byte character in half
+func truncateLogs(logs string, n int) string {
+	if len(logs) <= n {
+		return logs
+	}
+	cut := len(logs) - n
+	for cut < len(logs) && !utf8.RuneStart(logs[cut]) {
+		cut++
+	}
+	return logs[cut:]
+}
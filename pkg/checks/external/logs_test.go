@@ -0,0 +1,41 @@
+package external
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestTailBytes ensures only the end of long logs is kept
+func TestTailBytes(t *testing.T) {
+	logs := strings.Repeat("a", 10000) + "the end"
+	tail, err := tailBytes(strings.NewReader(logs), 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tail != "the end" {
+		t.Fatal("expected the end of the logs to be kept but got:", tail)
+	}
+
+	tail, err = tailBytes(strings.NewReader("short"), DefaultMaxFailureLogBytes)
+	if err != nil || tail != "short" {
+		t.Fatal("expected short logs to be kept whole:", tail, err)
+	}
+}
+
+// TestTruncateLogs ensures logs are not cut in the middle of a multi-byte character
+func TestTruncateLogs(t *testing.T) {
+	logs := strings.Repeat("ü", 100)
+	truncated := truncateLogs(logs, 51)
+	if !utf8.ValidString(truncated) {
+		t.Fatal("expected truncated logs to be valid UTF-8:", truncated)
+	}
+	if len(truncated) != 50 {
+		t.Fatal("expected truncated logs to be 50 bytes long but got:", len(truncated))
+	}
+
+	tail, err := tailBytes(strings.NewReader(strings.Repeat("ü", 10000)), 51)
+	if err != nil || !utf8.ValidString(tail) || len(tail) != 50 {
+		t.Fatal("expected the tail of the logs to be valid UTF-8:", len(tail), err)
+	}
+}
@@ -33,6 +33,10 @@ import (
 // KHReportingURL is the environment variable used to tell external checks where to send their status updates
 const KHReportingURL = "KH_REPORTING_URL"
 
+// KHReportingCA is the environment variable used to give external checks the PEM encoded CA bundle that the
+// certificate of the reporting URL is verified with when Kuberhealthy is served over TLS
+const KHReportingCA = "KH_REPORTING_CA"
+
 // KHRunUUID is the environment variable used to tell external checks their check's UUID so that they
 // can be de-duplicated on the server side.
 const KHRunUUID = "KH_RUN_UUID"
@@ -40,6 +44,9 @@ const KHRunUUID = "KH_RUN_UUID"
 // KHDeadline is the environment variable name for when checks must finish their runs by in unixtime
 const KHDeadline = "KH_CHECK_RUN_DEADLINE"
 
+// KHCheckName is the environment variable used to tell external checks the name of their khcheck
+const KHCheckName = "KH_CHECK_NAME"
+
 // KHCheckNameAnnotationKey is the annotation which holds the check's name for later validation when the pod calls in
 const KHCheckNameAnnotationKey = "comcast.github.io/check-name"
 
@@ -47,6 +54,14 @@ const KHCheckNameAnnotationKey = "comcast.github.io/check-name"
 // checks in.
 const KHPodNamespace = "KH_POD_NAMESPACE"
 
+// LogFieldCheck, LogFieldNamespace and LogFieldRunUUID are the log fields that hold the name, namespace and run UUID
+// of the check a log line is about
+const (
+	LogFieldCheck     = "check"
+	LogFieldNamespace = "namespace"
+	LogFieldRunUUID   = "runUUID"
+)
+
 // DefaultKuberhealthyReportingURL is the default location that external checks
 // are expected to report into.
 const DefaultKuberhealthyReportingURL = "http://kuberhealthy.kuberhealthy.svc.cluster.local/externalCheckStatus"
@@ -54,9 +69,16 @@ const DefaultKuberhealthyReportingURL = "http://kuberhealthy.kuberhealthy.svc.cl
 // kuberhealthyRunIDLabel is the pod label for the kuberhealthy run id value
 const kuberhealthyRunIDLabel = "kuberhealthy-run-id"
 
+// kuberhealthyRunUUIDLabel is the pod label for the kuberhealthy run uuid value.  It is applied alongside
+// kuberhealthyRunIDLabel so that checker pods carry a consistent set of labels for autoscaler and PDB selectors.
+const kuberhealthyRunUUIDLabel = "kuberhealthy-run-uuid"
+
 // kuberhealthyCheckNameLabel is the label used to flag this pod as being managed by this checker
 const kuberhealthyCheckNameLabel = "kuberhealthy-check-name"
 
+// khWorkloadAPIVersion is the API version used when setting owner references of checker pods to their khcheck or khjob
+const khWorkloadAPIVersion = "comcast.github.io/v1"
+
 // defaultTimeout is the default time a pod is allowed to run when this checker is created
 const defaultTimeout = time.Minute * 15
 
@@ -72,14 +94,22 @@ var ErrPodRemovedUnexpectedly = errors.New("pod deleted unexpectedly")
 // ErrPodDeletedBeforeRunning is a constant for the error when a pod is deleted before the check pod running
 var ErrPodDeletedBeforeRunning = errors.New("the khcheck check pod is deleted, waiting for start failed")
 
+// ErrPodEvicted is a constant for the error when a checker pod is evicted during a check run, such as during a node drain
+var ErrPodEvicted = errors.New("pod evicted during check run")
+
+// ErrRunTimedOut is a constant for the error when a check run does not complete within the timeout of its khcheck
+var ErrRunTimedOut = errors.New("check run timed out")
+
 // DefaultName is used when no check name is supplied
 var DefaultName = "external-check"
 
 // kubeConfigFile is the default location to check for a kubernetes configuration file
 var kubeConfigFile = filepath.Join(os.Getenv("HOME"), ".kube", "config")
 
-// Namespace of Kuberhealthy pod. Used to help set ownerReference for created checker pods.
+// Namespace of Kuberhealthy pod. Used to help set ownerReference for created checker pods.  It is looked up once, when
+// the first checker pod is created, so that importing this package has no side effects.
 var kuberhealthyNamespace = "kuberhealthy"
+var kuberhealthyNamespaceOnce sync.Once
 
 // Checker implements a KuberhealthyCheck for external
 // check execution and lifecycle management.
@@ -87,8 +117,10 @@ type Checker struct {
 	CheckName                string // the name of this checker
 	Namespace                string
 	RunInterval              time.Duration // how often this check runs a loop
+	Schedule                 string        // a cron expression for when this check runs. replaces RunInterval when set
+	ScheduleTimezone         string        // the IANA timezone the schedule is evaluated in. defaults to UTC
 	RunTimeout               time.Duration // time check must run completely within
-	KubeClient               *kubernetes.Clientset
+	KubeClient               kubernetes.Interface
 	KHJobClient              *khjobv1.KHJobV1Client
 	KHCheckClient            *khcheckv1.KHCheckV1Client
 	KHStateClient            *khstatev1.KHStateV1Client
@@ -96,10 +128,12 @@ type Checker struct {
 	OriginalPodSpec          apiv1.PodSpec // the user-provided spec of the pod
 	RunID                    string        // the uuid of the current run
 	KuberhealthyReportingURL string        // the URL that the check should want to report results back to
+	KuberhealthyReportingCA  string        // the PEM encoded CA bundle checker pods verify the reporting URL with. blank uses the system roots
 	ExtraAnnotations         map[string]string
 	ExtraLabels              map[string]string
 	Node                     string             // the node the checker pod runs on
 	currentCheckUUID         string             // the UUID of the current external checker running
+	currentReportToken       string             // the secret token the checker pod of the current run reports with
 	Debug                    bool               // indicates we should run in debug mode - run once and stop
 	shutdownCTXFunc          context.CancelFunc // used to cancel things in-flight when shutting down gracefully
 	shutdownCTX              context.Context    // a context used for shutting down the check gracefully
@@ -107,22 +141,37 @@ type Checker struct {
 	hostname                 string             // hostname cache
 	checkPodName             string             // the current unique checker pod name
 	KHWorkload               khstatev1.KHWorkload
+	MaxFailureLogBytes       int                            // the amount of checker pod logs kept when a run fails. zero disables log capture
+	DefaultResources         apiv1.ResourceRequirements     // the resource requests and limits of checker pod containers that set none
+	RBAC                     *khcheckv1.CheckRBAC           // the permissions of the checker pods, created for them before each run when set
+	ImagePullSecrets         []apiv1.LocalObjectReference   // the pull secrets added to every checker pod, next to those of the pod spec
+	failureLogs              string                         // the checker pod logs captured when the last run failed
+	RunnerPod                string                         // the Kuberhealthy pod running this check. when set, it is recorded as the khstate's authoritative pod when a run starts
+	ConcurrencyPolicy        khcheckv1.ConcurrencyPolicy    // how a run is handled while the previous run is still in flight
+	FailureThreshold         int                            // the number of consecutive failed runs before the check reports an error
+	RetryBackoff             time.Duration                  // how long to wait before retrying a failed run that has not reached the FailureThreshold. zero waits for the next run
+	runContainers            []khstatev1.ContainerRunStatus // the final status and last observed resource usage of the checker pod containers of the last run
+	runContainersMu          sync.Mutex
 }
 
-func init() {
-	// Get namespace of Kuberhealthy pod. Used to help set ownerReference for created checker pods to proper
-	// Kuberhealthy instance.
-	kuberhealthyNamespace = util.GetInstanceNamespace(kuberhealthyNamespace)
-	log.Infoln("Kuberhealthy is located in the", kuberhealthyNamespace, "namespace.")
+// instanceNamespace returns the namespace of the Kuberhealthy pod
+func instanceNamespace() string {
+	kuberhealthyNamespaceOnce.Do(func() {
+		// Get namespace of Kuberhealthy pod. Used to help set ownerReference for created checker pods to proper
+		// Kuberhealthy instance.
+		kuberhealthyNamespace = util.GetInstanceNamespace(kuberhealthyNamespace)
+		log.Infoln("Kuberhealthy is located in the", kuberhealthyNamespace, "namespace.")
+	})
+	return kuberhealthyNamespace
 }
 
 // New creates a new external checker
-func New(client *kubernetes.Clientset, checkConfig *khcheckv1.KuberhealthyCheck, khCheckClient *khcheckv1.KHCheckV1Client, khStateClient *khstatev1.KHStateV1Client, reportingURL string) *Checker {
+func New(client kubernetes.Interface, checkConfig *khcheckv1.KuberhealthyCheck, khCheckClient *khcheckv1.KHCheckV1Client, khStateClient *khstatev1.KHStateV1Client, reportingURL string) *Checker {
 
 	return NewCheck(client, checkConfig, khCheckClient, khStateClient, reportingURL)
 }
 
-func NewCheck(client *kubernetes.Clientset, checkConfig *khcheckv1.KuberhealthyCheck, khCheckClient *khcheckv1.KHCheckV1Client, khStateClient *khstatev1.KHStateV1Client, reportingURL string) *Checker {
+func NewCheck(client kubernetes.Interface, checkConfig *khcheckv1.KuberhealthyCheck, khCheckClient *khcheckv1.KHCheckV1Client, khStateClient *khstatev1.KHStateV1Client, reportingURL string) *Checker {
 
 	if len(checkConfig.Namespace) == 0 {
 		checkConfig.Namespace = "kuberhealthy"
@@ -137,16 +186,18 @@ func NewCheck(client *kubernetes.Clientset, checkConfig *khcheckv1.KuberhealthyC
 		CheckName:                checkConfig.Name,
 		KuberhealthyReportingURL: reportingURL,
 		RunTimeout:               defaultTimeout,
+		MaxFailureLogBytes:       DefaultMaxFailureLogBytes,
 		ExtraAnnotations:         make(map[string]string),
 		ExtraLabels:              make(map[string]string),
 		OriginalPodSpec:          checkConfig.Spec.PodSpec,
 		PodSpec:                  checkConfig.Spec.PodSpec,
+		RBAC:                     checkConfig.Spec.RBAC.DeepCopy(),
 		KubeClient:               client,
 		KHWorkload:               khstatev1.KHCheck,
 	}
 }
 
-func NewJob(client *kubernetes.Clientset, jobConfig *khjobv1.KuberhealthyJob, khJobClient *khjobv1.KHJobV1Client, khStateClient *khstatev1.KHStateV1Client, reportingURL string) *Checker {
+func NewJob(client kubernetes.Interface, jobConfig *khjobv1.KuberhealthyJob, khJobClient *khjobv1.KHJobV1Client, khStateClient *khstatev1.KHStateV1Client, reportingURL string) *Checker {
 
 	if len(jobConfig.Namespace) == 0 {
 		jobConfig.Namespace = "kuberhealthy"
@@ -166,6 +217,7 @@ func NewJob(client *kubernetes.Clientset, jobConfig *khjobv1.KuberhealthyJob, kh
 		PodSpec:                  jobConfig.Spec.PodSpec,
 		KubeClient:               client,
 		KHWorkload:               khstatev1.KHJob,
+		MaxFailureLogBytes:       DefaultMaxFailureLogBytes,
 	}
 }
 
@@ -234,6 +286,11 @@ func (ext *Checker) Interval() time.Duration {
 	return ext.RunInterval
 }
 
+// CurrentUUID returns the UUID of the run in flight, or of the last run when no run is in flight
+func (ext *Checker) CurrentUUID() string {
+	return ext.currentCheckUUID
+}
+
 // Timeout returns the maximum run time for this check before it times out
 func (ext *Checker) Timeout() time.Duration {
 	return ext.RunTimeout
@@ -241,7 +298,7 @@ func (ext *Checker) Timeout() time.Duration {
 
 // Run executes the checker.  This is ran on each "tick" of
 // the RunInterval and is executed by the Kuberhealthy checker
-func (ext *Checker) Run(ctx context.Context, client *kubernetes.Clientset) error {
+func (ext *Checker) Run(ctx context.Context, client kubernetes.Interface) error {
 
 	// store the client in the checker
 	ext.KubeClient = client
@@ -251,11 +308,24 @@ func (ext *Checker) Run(ctx context.Context, client *kubernetes.Clientset) error
 	if err != nil {
 		return err
 	}
+	defer ext.releaseUUID(ext.currentCheckUUID)
 
 	// run a check iteration
 	ext.log("Running external check iteration")
 	err = ext.RunOnce(ctx)
 
+	// if the checker pod was evicted (such as during a node drain), we retry the run once with a new UUID
+	// before reporting a failure
+	if err != nil && errors.Is(err, ErrPodEvicted) {
+		ext.log("checker pod was evicted. retrying this check run once")
+		err = ext.setNewCheckUUID()
+		if err != nil {
+			return err
+		}
+		defer ext.releaseUUID(ext.currentCheckUUID)
+		err = ext.RunOnce(ctx)
+	}
+
 	// if the pod was removed, we skip this run gracefully
 	if err != nil && err.Error() == ErrPodRemovedExpectedly.Error() {
 		ext.log("pod was removed during check expectedly. skipping this run")
@@ -290,8 +360,12 @@ func (ext *Checker) cleanup(ctx context.Context) {
 	ext.log("Evicting up any running pods with name", ext.podName())
 	podClient := ext.KubeClient.CoreV1().Pods(ext.Namespace)
 
-	// find all pods that are running still so we can evict them (not delete - for records)
+	// find all pods that are running still so we can evict them (not delete - for records).  when runs may
+	// overlap, only the pods of this run are evicted so that the other runs are left alone.
 	checkLabelSelector := kuberhealthyCheckNameLabel + " = " + ext.CheckName
+	if ext.allowsConcurrentRuns() {
+		checkLabelSelector = kuberhealthyRunIDLabel + " = " + ext.currentCheckUUID
+	}
 	ext.log("eviction: looking for pods with the label", checkLabelSelector)
 	podList, err := podClient.List(ctx, metav1.ListOptions{
 		LabelSelector: checkLabelSelector,
@@ -370,6 +444,7 @@ func (ext *Checker) setUUID(uuid string) error {
 		details.AuthoritativePod = ext.hostname
 		details.OK = true
 		details.CurrentUUID = uuid
+		details.AllowedUUIDs = ext.allowedUUIDs(nil, uuid)
 		details.RunDuration = time.Duration(0).String()
 		newState := khstatev1.NewKuberhealthyState(ext.CheckName, details)
 		newState.Namespace = ext.Namespace
@@ -386,6 +461,10 @@ func (ext *Checker) setUUID(uuid string) error {
 
 	// assign the new uuid to the fetched checkState
 	checkState.Spec.CurrentUUID = uuid
+	checkState.Spec.AllowedUUIDs = ext.allowedUUIDs(checkState.Spec.AllowedUUIDs, uuid)
+	if len(ext.RunnerPod) > 0 {
+		checkState.Spec.AuthoritativePod = ext.RunnerPod
+	}
 	ext.log("Updating khstate to CurrentUUID:", checkState.Spec.CurrentUUID)
 	_, err = ext.KHStateClient.KuberhealthyStates(ext.CheckNamespace()).Update(&checkState)
 	if err != nil {
@@ -425,6 +504,10 @@ func (ext *Checker) setUUID(uuid string) error {
 			log.Errorln("failed to fetch khstate for check", checkState.Namespace, checkState.Name, "with error:", err)
 		}
 		checkState.Spec.CurrentUUID = uuid
+		checkState.Spec.AllowedUUIDs = ext.allowedUUIDs(checkState.Spec.AllowedUUIDs, uuid)
+		if len(ext.RunnerPod) > 0 {
+			checkState.Spec.AuthoritativePod = ext.RunnerPod
+		}
 		_, err = ext.KHStateClient.KuberhealthyStates(ext.CheckNamespace()).Update(&checkState)
 		if err != nil {
 			log.Errorln("failed to update khstate CurrentUUID for check", checkState.Namespace, checkState.Name, "with error:", err)
@@ -461,7 +544,12 @@ func (ext *Checker) watchForCheckerPodDelete(ctx context.Context) chan error {
 		select {
 		case <-ctx.Done(): // graceful shutdown signal
 			ext.log("pod shutdown monitor stopping gracefully")
-		case <-ext.waitForDeletedEvent(watcher): // we saw the watched pod remove
+		case err := <-ext.waitForDeletedEvent(watcher): // we saw the watched pod remove
+			if err != nil && errors.Is(err, ErrPodEvicted) {
+				ext.log("pod shutdown monitor witnessed the checker pod being evicted")
+				waitForDeleteChan <- ErrPodEvicted
+				break
+			}
 			ext.log("pod shutdown monitor witnessed the checker pod being removed")
 			waitForDeleteChan <- fmt.Errorf("pod shutdown monitor witnessed the checker pod being removed")
 		}
@@ -509,6 +597,11 @@ func (ext *Checker) waitForDeletedEvent(w watch.Interface) chan error {
 				}
 				ext.log("checker pod shutdown monitor saw a modified event. the pod changed to ", p.Status.Phase)
 			case watch.Deleted: // we saw a deleted event, so notify upstream, but only once
+				p, ok := e.Object.(*apiv1.Pod)
+				if ok && podWasEvicted(p) {
+					outChan <- ErrPodEvicted
+					return
+				}
 				outChan <- nil
 				return
 			case watch.Error:
@@ -541,13 +634,33 @@ func (ext *Checker) newError(s string) error {
 
 // RunOnce runs one check loop.  This creates a checker pod and ensures it starts,
 // then ensures it changes to Running properly
-func (ext *Checker) RunOnce(ctx context.Context) error {
+func (ext *Checker) RunOnce(ctx context.Context) (err error) {
 
 	// create a context for this run
 	ext.shutdownCTX, ext.shutdownCTXFunc = context.WithCancel(ctx)
 	defer ext.shutdownCTXFunc()
 	defer ext.cleanup(ctx)
 
+	// capture the checker pod logs before the pod is cleaned up if this run fails
+	ext.failureLogs = ""
+	defer func() {
+		if err != nil && !errors.Is(err, ErrPodRemovedExpectedly) {
+			ext.CaptureFailureLogs(ctx)
+		}
+	}()
+
+	// record the final container statuses of the checker pod before it is cleaned up.  a run that timed out
+	// because its checker pod was OOMKilled reports the OOMKill instead of the timeout.
+	ext.runContainersMu.Lock()
+	ext.runContainers = nil
+	ext.runContainersMu.Unlock()
+	defer func() {
+		containers := ext.sampleRunContainers(ctx)
+		if name, oomKilled := oomKilledContainer(containers); oomKilled && err != nil && !errors.Is(err, ErrPodRemovedExpectedly) {
+			err = ext.oomKilledError(name)
+		}
+	}()
+
 	// regenerate the checker pod name with a new timestamp
 	ext.regeneratePodName()
 
@@ -584,13 +697,19 @@ func (ext *Checker) RunOnce(ctx context.Context) error {
 		return err
 	}
 
+	// create the service account and permissions declared for the checker pods
+	err = ext.ensureRBAC(ctx)
+	if err != nil {
+		return ext.newError("failed to create the RBAC of the checker pod: " + err.Error())
+	}
+
 	// waiting until all checker pods are gone...
 	ext.log("Waiting for all existing pods to clean up")
 	select {
 	case <-timeoutChan:
 		ext.log("timed out waiting for all existing pods to clean up")
 		errorMessage := "failed to see pod cleanup within timeout"
-		return ext.newError(errorMessage)
+		return ext.timedOut(ctx, errorMessage)
 	case err = <-ext.waitForAllPodsToClear(ctx):
 		if err != nil {
 			errorMessage := "error waiting for pod to clean up: " + err.Error()
@@ -611,6 +730,15 @@ func (ext *Checker) RunOnce(ctx context.Context) error {
 	podDeletedChan := ext.watchForCheckerPodDelete(podShutdownWatchCtx)
 	defer podShutdownWatchCtxCancel()
 
+	// store the report token of this run where only its checker pod can read it
+	if len(ext.currentReportToken) > 0 {
+		err = ext.createReportTokenSecret(ctx)
+		if err != nil {
+			return ext.newError("failed to create the report token secret of the checker pod: " + err.Error())
+		}
+		defer ext.deleteReportTokenSecret(ctx)
+	}
+
 	// Spawn kubernetes pod to run our external check
 	ext.log("creating pod for external check:", ext.CheckName)
 	ext.log("checker pod annotations and labels:", ext.ExtraAnnotations, ext.ExtraLabels)
@@ -625,8 +753,12 @@ func (ext *Checker) RunOnce(ctx context.Context) error {
 	select {
 	case <-timeoutChan: // were out of time
 		ext.log("timed out waiting for pod to startup")
-		return ext.newError("failed to see pod running within timeout")
+		return ext.timedOut(ctx, "failed to see pod running within timeout")
 	case err := <-podDeletedChan: // pod removed unexpectedly
+		if err != nil && errors.Is(err, ErrPodEvicted) {
+			ext.log("pod was evicted while waiting for pod to start running")
+			return ErrPodEvicted
+		}
 		if err != nil {
 			ext.log("error from pod shutdown watcher when watching for checker pod to start:", err.Error())
 			ext.log("pod removed unexpectedly while waiting for pod to start running")
@@ -635,6 +767,10 @@ func (ext *Checker) RunOnce(ctx context.Context) error {
 		ext.log("pod removed expectedly. pod shutdown monitor shutting down")
 		return ErrPodRemovedExpectedly
 	case err = <-ext.waitForPodStart(ctx): // pod started
+		if err != nil && errors.Is(err, ErrPodEvicted) {
+			ext.log("pod was evicted while waiting for pod to start running")
+			return ErrPodEvicted
+		}
 		if err != nil {
 			ext.cleanup(ctx)
 			errorMessage := "error when waiting for pod to start: " + err.Error()
@@ -648,6 +784,11 @@ func (ext *Checker) RunOnce(ctx context.Context) error {
 		return nil
 	}
 
+	// sample the container statuses and resource usage of the checker pod until this run ends
+	podResourcesCtx, podResourcesCtxCancel := context.WithCancel(ctx)
+	defer podResourcesCtxCancel()
+	podOOMKilledChan := ext.monitorPodResources(podResourcesCtx)
+
 	// validate that the pod was able to update its khstate
 	ext.log("Waiting for pod status to be reported from pod", ext.podName(), "in namespace", ext.Namespace)
 	select {
@@ -655,8 +796,12 @@ func (ext *Checker) RunOnce(ctx context.Context) error {
 		ext.log("timed out waiting for pod status to be reported")
 		errorMessage := "timed out waiting for checker pod to report in"
 		ext.log(errorMessage)
-		return ext.newError(errorMessage)
+		return ext.timedOut(ctx, errorMessage)
 	case err := <-podDeletedChan: // pod was removed
+		if err != nil && errors.Is(err, ErrPodEvicted) {
+			ext.log("pod was evicted while waiting for pod to report results")
+			return ErrPodEvicted
+		}
 		if err != nil {
 			ext.log("error from pod shutdown watcher when watching for checker pod to report results:", err.Error())
 			ext.log("pod removed unexpectedly while waiting for pod to report results")
@@ -671,6 +816,9 @@ func (ext *Checker) RunOnce(ctx context.Context) error {
 			return ext.newError(errorMessage)
 		}
 		ext.log("External check pod has reported status for this check iteration:", ext.podName())
+	case err = <-podOOMKilledChan: // a container ran out of memory
+		ext.log(err.Error())
+		return err
 	case <-ext.shutdownCTX.Done(): // shutdown signal
 		ext.log("shutting down check. aborting wait for pod status to update")
 		return nil
@@ -684,7 +832,7 @@ func (ext *Checker) RunOnce(ctx context.Context) error {
 	case <-timeoutChan: // out of time
 		errorMessage := "timed out waiting for pod to exit"
 		ext.log(errorMessage)
-		return ext.newError(errorMessage)
+		return ext.timedOut(ctx, errorMessage)
 	case err = <-ext.waitForPodExit(ctx): // pod stopped running
 		ext.log("External check pod is done running:", ext.podName())
 		if err != nil {
@@ -692,6 +840,9 @@ func (ext *Checker) RunOnce(ctx context.Context) error {
 			ext.log(errorMessage, err)
 			return ext.newError(errorMessage)
 		}
+	case err = <-podOOMKilledChan: // a container ran out of memory
+		ext.log(err.Error())
+		return err
 	case <-ext.shutdownCTX.Done(): // shutdown signal
 		ext.log("shutting down check. aborting wait for pod to be done running")
 		return nil
@@ -701,9 +852,31 @@ func (ext *Checker) RunOnce(ctx context.Context) error {
 	return nil
 }
 
-// log writes a normal InfoLn message output prefixed with this checker's name on it
+// log writes a normal InfoLn message with the check, namespace and runUUID fields of this checker's current run
 func (ext *Checker) log(s ...interface{}) {
-	log.Infoln(ext.currentCheckUUID+" "+ext.Namespace+"/"+ext.CheckName+":", s)
+	ext.Logger().Infoln(s...)
+}
+
+// Logger returns a logger that sets the check, namespace and runUUID fields of this checker's current run on each
+// line it logs
+func (ext *Checker) Logger() *log.Entry {
+	return CheckLogger(ext.CheckName, ext.Namespace, ext.currentCheckUUID)
+}
+
+// CheckLogger returns a logger that sets the check, namespace and runUUID fields on each line it logs, so that the
+// log lines of a check run can be found together.  Blank fields are left out.
+func CheckLogger(checkName string, namespace string, runUUID string) *log.Entry {
+	fields := log.Fields{}
+	if len(checkName) > 0 {
+		fields[LogFieldCheck] = checkName
+	}
+	if len(namespace) > 0 {
+		fields[LogFieldNamespace] = namespace
+	}
+	if len(runUUID) > 0 {
+		fields[LogFieldRunUUID] = runUUID
+	}
+	return log.WithFields(fields)
 }
 
 // sanityCheck runs a basic sanity check on the checker before running
@@ -712,6 +885,10 @@ func (ext *Checker) sanityCheck() error {
 		return errors.New("check namespace can not be empty")
 	}
 
+	if ext.CheckName == "" {
+		return errors.New("check name can not be empty")
+	}
+
 	if ext.KubeClient == nil {
 		return errors.New("kubeClient can not be nil")
 	}
@@ -796,8 +973,19 @@ func (ext *Checker) waitForPodStatusUpdate(lastUpdateTime metav1.Time) chan erro
 	return outChan
 }
 
-// podHasReportedInAfterTime indicates if a pod has reported a state since the supplied timestamp
+// podHasReportedInAfterTime indicates if a pod has reported a state since the supplied timestamp.  When runs may
+// overlap, the report must also be from the pod of this run.
 func (ext *Checker) podHasReportedInAfterTime(t metav1.Time) (bool, error) {
+	if ext.allowsConcurrentRuns() {
+		state, err := ext.getKHState()
+		if err != nil {
+			return false, err
+		}
+		if state.Spec.CurrentUUID != ext.currentCheckUUID {
+			return false, nil
+		}
+	}
+
 	// fetch the lastUpdateTime from the khstate as of right now
 	currentUpdateTime, err := ext.getCheckLastUpdateTime()
 	if err != nil {
@@ -831,7 +1019,7 @@ func (ext *Checker) waitForAllPodsToClear(ctx context.Context) chan error {
 
 		// watch events and return when the pod is in state running
 		for {
-			log.Debugln("Waiting for checker pod", ext.podName(), "to clear...")
+			ext.Logger().Debugln("Waiting for checker pod", ext.podName(), "to clear...")
 
 			// wait between requests
 			time.Sleep(time.Second * 5)
@@ -982,6 +1170,13 @@ func (ext *Checker) waitForPodStart(ctx context.Context) chan error {
 				ext.log("got an event while waiting for pod to start running")
 
 				if e.Type == watch.Deleted {
+					p, ok := e.Object.(*apiv1.Pod)
+					if ok && podWasEvicted(p) {
+						ext.log("the khcheck check pod was evicted before it started running")
+						outChan <- ErrPodEvicted
+						watcher.Stop()
+						return
+					}
 					ext.log("the khcheck check pod is deleted, waiting for start failed!")
 					outChan <- ErrPodDeletedBeforeRunning
 					watcher.Stop()
@@ -996,17 +1191,28 @@ func (ext *Checker) waitForPodStart(ctx context.Context) chan error {
 				}
 
 				// catch when the pod has an error image pull and return it as an error #201
-				for _, containerStat := range p.Status.ContainerStatuses {
-					if containerStat.State.Waiting == nil {
-						continue
-					}
-					if containerStat.State.Waiting.Reason == "ErrImagePull" {
-						ext.log("pod had an error image pull")
-						outChan <- errors.New(containerStat.State.Waiting.Reason)
-						watcher.Stop()
-						return
-					}
+				if err := imagePullError(p); err != nil {
+					ext.log("pod had an error image pull:", err)
+					outChan <- err
+					watcher.Stop()
+					return
 				}
+				// catch when a container can not be configured, such as when a secret or configmap it takes its
+				// environment from does not exist.  The kubelet keeps retrying, so the pod would never start.
+				if err := containerConfigError(p); err != nil {
+					ext.log("pod had a container configuration error:", err)
+					outChan <- err
+					watcher.Stop()
+					return
+				}
+				// catch when the pod has been evicted by the kubelet before it could run
+				if podWasEvicted(p) {
+					ext.log("pod was evicted before it started running")
+					outChan <- ErrPodEvicted
+					watcher.Stop()
+					return
+				}
+
 				// read the status of this pod (its ours)
 				ext.log("pod state is now:", string(p.Status.Phase))
 				if p.Status.Phase == apiv1.PodRunning || p.Status.Phase == apiv1.PodFailed || p.Status.Phase == apiv1.PodSucceeded {
@@ -1072,10 +1278,10 @@ func (ext *Checker) createPod(ctx context.Context) (*apiv1.Pod, error) {
 
 	// only set ownerReference for pods in the kuberhealthy namespace
 	// as cross-namespace owner references are disabled by design
-	if p.Namespace == kuberhealthyNamespace {
+	if p.Namespace == instanceNamespace() {
 
 		// Get ownerReference for the kuberhealthy pod
-		ownerRef, err := util.GetOwnerRef(ext.KubeClient, kuberhealthyNamespace)
+		ownerRef, err := util.GetOwnerRef(ext.KubeClient, instanceNamespace())
 		if err != nil {
 			return nil, errors.New("Failed to getOwnerReference for pod: " + p.Name + ", err: " + err.Error())
 		}
@@ -1084,17 +1290,68 @@ func (ext *Checker) createPod(ctx context.Context) (*apiv1.Pod, error) {
 		p.OwnerReferences = ownerRef
 	}
 
+	// set an ownerReference to the khcheck or khjob that this pod runs for.  Checker pods always run in the
+	// namespace of their khcheck, so this lets garbage collection clean them up even if Kuberhealthy dies.
+	workloadOwnerRef, err := ext.getWorkloadOwnerRef()
+	if err != nil {
+		ext.log("unable to set ownerReference to the", ext.KHWorkload, "for pod", p.Name+":", err)
+	} else {
+		p.OwnerReferences = append(p.OwnerReferences, workloadOwnerRef)
+	}
+
 	return ext.KubeClient.CoreV1().Pods(ext.Namespace).Create(ctx, p, metav1.CreateOptions{})
 }
 
+// getWorkloadOwnerRef builds an ownerReference pointing at the khcheck or khjob resource this checker runs for
+func (ext *Checker) getWorkloadOwnerRef() (metav1.OwnerReference, error) {
+	var ownerRef metav1.OwnerReference
+
+	switch ext.KHWorkload {
+	case khstatev1.KHJob:
+		if ext.KHJobClient == nil {
+			return ownerRef, errors.New("khjob client is not configured")
+		}
+		khJob, err := ext.KHJobClient.KuberhealthyJobs(ext.Namespace).Get(ext.CheckName, metav1.GetOptions{})
+		if err != nil {
+			return ownerRef, err
+		}
+		ownerRef = metav1.OwnerReference{
+			APIVersion: khWorkloadAPIVersion,
+			Kind:       "KuberhealthyJob",
+			Name:       khJob.GetName(),
+			UID:        khJob.GetUID(),
+		}
+	default:
+		if ext.KHCheckClient == nil {
+			return ownerRef, errors.New("khcheck client is not configured")
+		}
+		khCheck, err := ext.getCheck()
+		if err != nil {
+			return ownerRef, err
+		}
+		ownerRef = metav1.OwnerReference{
+			APIVersion: khWorkloadAPIVersion,
+			Kind:       "KuberhealthyCheck",
+			Name:       khCheck.GetName(),
+			UID:        khCheck.GetUID(),
+		}
+	}
+
+	if len(ownerRef.UID) == 0 {
+		return ownerRef, errors.New("fetched " + string(ext.KHWorkload) + " " + ext.CheckName + " had no UID")
+	}
+	return ownerRef, nil
+}
+
 // configureUserPodSpec configures a user-specified pod spec with
 // the unique and required fields for compatibility with an external
 // kuberhealthy check.  Required environment variables and settings
 // overwrite user-specified values.
 func (ext *Checker) configureUserPodSpec(deadline time.Time) error {
 
-	// start with a fresh spec each time we regenerate the spec
-	ext.PodSpec = ext.OriginalPodSpec
+	// start with a fresh spec each time we regenerate the spec.  The spec is copied deeply so that the settings
+	// enforced below never leak into the spec of the khcheck.
+	ext.PodSpec = *ext.OriginalPodSpec.DeepCopy()
 
 	// specify environment variables that need applied.  We apply environment
 	// variables that set the report-in URL of kuberhealthy along with
@@ -1112,6 +1369,10 @@ func (ext *Checker) configureUserPodSpec(deadline time.Time) error {
 			Name:  KHDeadline,
 			Value: strconv.FormatInt(deadline.Unix(), 10),
 		},
+		{
+			Name:  KHCheckName,
+			Value: ext.CheckName,
+		},
 		{
 			Name: KHPodNamespace,
 			ValueFrom: &apiv1.EnvVarSource{
@@ -1122,15 +1383,60 @@ func (ext *Checker) configureUserPodSpec(deadline time.Time) error {
 		},
 	}
 
+	if len(ext.currentReportToken) > 0 {
+		overwriteEnvVars = append(overwriteEnvVars, apiv1.EnvVar{
+			Name: KHReportToken,
+			ValueFrom: &apiv1.EnvVarSource{
+				SecretKeyRef: &apiv1.SecretKeySelector{
+					LocalObjectReference: apiv1.LocalObjectReference{Name: ext.reportTokenSecretName()},
+					Key:                  ReportTokenSecretKey,
+				},
+			},
+		})
+	}
+	if len(ext.KuberhealthyReportingCA) > 0 {
+		overwriteEnvVars = append(overwriteEnvVars, apiv1.EnvVar{
+			Name:  KHReportingCA,
+			Value: ext.KuberhealthyReportingCA,
+		})
+	}
+
 	// apply overwrite env vars on every container in the pod
 	for i := range ext.PodSpec.Containers {
-		ext.PodSpec.Containers[i].Env = resetInjectedContainerEnvVars(ext.PodSpec.Containers[i].Env, []string{KHReportingURL, KHRunUUID, KHPodNamespace, KHDeadline})
+		ext.PodSpec.Containers[i].Env = resetInjectedContainerEnvVars(ext.PodSpec.Containers[i].Env, []string{KHReportingURL, KHReportingCA, KHRunUUID, KHReportToken, KHPodNamespace, KHDeadline, KHCheckName})
 		ext.PodSpec.Containers[i].Env = append(ext.PodSpec.Containers[i].Env, overwriteEnvVars...)
 	}
 
+	// containers that do not set their own resources get the defaults, so that checker pods are accepted in
+	// namespaces with LimitRanges or quotas that require them
+	for i := range ext.PodSpec.InitContainers {
+		ApplyDefaultResources(&ext.PodSpec.InitContainers[i], ext.DefaultResources)
+	}
+	for i := range ext.PodSpec.Containers {
+		ApplyDefaultResources(&ext.PodSpec.Containers[i], ext.DefaultResources)
+	}
+
 	// enforce restart policy of never
 	ext.PodSpec.RestartPolicy = apiv1.RestartPolicyNever
 
+	// add the default pull secrets that the pod spec does not list itself
+	for _, secret := range ext.ImagePullSecrets {
+		if !hasImagePullSecret(ext.PodSpec.ImagePullSecrets, secret.Name) {
+			ext.PodSpec.ImagePullSecrets = append(ext.PodSpec.ImagePullSecrets, secret)
+		}
+	}
+
+	// checker pods run as the service account created for them when the check declares its rbac
+	if ext.RBAC != nil {
+		ext.PodSpec.ServiceAccountName = ext.rbacName()
+	}
+
+	// checker pods on the host network use the DNS of their node by default, which can not resolve the reporting
+	// URL of kuberhealthy's service
+	if ext.PodSpec.HostNetwork && len(ext.PodSpec.DNSPolicy) == 0 {
+		ext.PodSpec.DNSPolicy = apiv1.DNSClusterFirstWithHostNet
+	}
+
 	// enforce namespace as namespace of this checker
 	ext.Namespace = ext.CheckNamespace()
 
@@ -1155,6 +1461,7 @@ func (ext *Checker) addKuberhealthyLabels(pod *apiv1.Pod) {
 
 	// stack the kuberhealthy run id on top of the existing labels
 	pod.ObjectMeta.Labels[kuberhealthyRunIDLabel] = ext.currentCheckUUID
+	pod.ObjectMeta.Labels[kuberhealthyRunUUIDLabel] = ext.currentCheckUUID
 	pod.ObjectMeta.Labels[kuberhealthyCheckNameLabel] = ext.CheckName
 	pod.ObjectMeta.Labels["app"] = "kuberhealthy-check" // enforce a the label with an app name
 
@@ -1177,7 +1484,14 @@ func (ext *Checker) addKuberhealthyLabels(pod *apiv1.Pod) {
 func (ext *Checker) setNewCheckUUID() error {
 	uniqueID := uuid.New()
 	ext.currentCheckUUID = uniqueID.String()
-	log.Debugln("Generated new UUID for external check:", ext.currentCheckUUID)
+	ext.Logger().Debugln("Generated new UUID for external check")
+
+	// each run gets a token of its own that only its checker pod knows
+	token, err := newReportToken()
+	if err != nil {
+		return err
+	}
+	ext.currentReportToken = token
 
 	// set whitelist in check configuration CRD so only this
 	// currently running pod can report-in with a status update
@@ -1233,7 +1547,7 @@ func (ext *Checker) Shutdown() error {
 	ctx, ctxCancel := context.WithTimeout(context.Background(), ext.Timeout())
 	defer ctxCancel()
 
-	log.Debugln("Waiting for pod", ext.podName(), "to shutdown")
+	ext.Logger().Debugln("Waiting for pod", ext.podName(), "to shutdown")
 
 	select {
 	case err := <-ext.waitForShutdown(ctx):
@@ -1254,6 +1568,18 @@ func (ext *Checker) Shutdown() error {
 	return nil
 }
 
+// Abort cleans up after a run that was stopped before it completed, such as when Kuberhealthy shuts down.  The checker
+// pods of the run are evicted and, unless runs may overlap, a new UUID is whitelisted so that a late report from the
+// checker pod is rejected.  Runs that may overlap released their UUID when the run ended.
+func (ext *Checker) Abort(ctx context.Context) error {
+	ext.log("aborting run in flight")
+	ext.cleanup(ctx)
+	if ext.allowsConcurrentRuns() {
+		return nil
+	}
+	return ext.setNewCheckUUID()
+}
+
 // resetInjectedContainerEnvVars resets injected environment variables
 func resetInjectedContainerEnvVars(podVars []apiv1.EnvVar, injectedVars []string) []apiv1.EnvVar {
 	sanitizedVars := make([]apiv1.EnvVar, 0)
@@ -1276,3 +1602,70 @@ func containsEnvVarName(envVar string, injectedVars []string) bool {
 	}
 	return false
 }
+
+// hasImagePullSecret determines if a list of pull secrets holds the secret with the supplied name
+func hasImagePullSecret(secrets []apiv1.LocalObjectReference, name string) bool {
+	for _, secret := range secrets {
+		if secret.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// imagePullReasons are the reasons a container waits with when its image can not be pulled
+var imagePullReasons = map[string]bool{
+	"ErrImagePull":     true,
+	"ImagePullBackOff": true,
+	"InvalidImageName": true,
+}
+
+// imagePullError returns an error when a container of a pod can not pull its image.  Pods without imagePullSecrets
+// are pointed at them, since private images are the most common cause.
+func imagePullError(p *apiv1.Pod) error {
+	statuses := append(append([]apiv1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Waiting == nil || !imagePullReasons[status.State.Waiting.Reason] {
+			continue
+		}
+		message := "container " + status.Name + " failed to pull image " + status.Image + ": " + status.State.Waiting.Reason
+		if len(status.State.Waiting.Message) > 0 {
+			message += ": " + status.State.Waiting.Message
+		}
+		if len(p.Spec.ImagePullSecrets) == 0 {
+			message += ". Set imagePullSecrets in the podSpec if the image is private"
+		}
+		return errors.New(message)
+	}
+	return nil
+}
+
+// containerConfigError returns an error when a container of a pod can not be created because its configuration is
+// invalid, such as when it takes its environment from a secret, configmap or key that does not exist
+func containerConfigError(p *apiv1.Pod) error {
+	statuses := append(append([]apiv1.ContainerStatus{}, p.Status.InitContainerStatuses...), p.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Waiting == nil || status.State.Waiting.Reason != "CreateContainerConfigError" {
+			continue
+		}
+		return errors.New("container " + status.Name + " can not be configured: " + status.State.Waiting.Message)
+	}
+	return nil
+}
+
+// podWasEvicted determines if a pod was evicted, either by the kubelet under node pressure or through
+// the eviction API (such as during a node drain)
+func podWasEvicted(p *apiv1.Pod) bool {
+	if p == nil {
+		return false
+	}
+	if p.Status.Phase == apiv1.PodFailed && p.Status.Reason == "Evicted" {
+		return true
+	}
+	for _, condition := range p.Status.Conditions {
+		if condition.Type == apiv1.DisruptionTarget && condition.Status == apiv1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,7 +17,6 @@ import (
 	apiv1 "k8s.io/api/core/v1"
 
 	khcheckv1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khcheck/v1"
-	"github.com/kuberhealthy/kuberhealthy/v2/pkg/kubeClient"
 )
 
 func init() {
@@ -79,18 +79,8 @@ func TestLoadPodSpecTestFile(t *testing.T) {
 
 // TestShutdown tests shutting down a check while its running
 func TestShutdown(t *testing.T) {
-	// create a kubernetes clientset
-	client, err := kubeClient.Create(kubeConfigFile)
-	if err != nil {
-		t.Log("Unable to create kubernetes client", err)
-	}
-
 	// make a new default checker of this check
-	checker, err := newTestChecker(client)
-	if err != nil {
-		t.Log("Failed to create client:", err)
-	}
-	checker.KubeClient = client
+	checker := newClusterTestChecker(t)
 
 	// run the checker with the kube client
 	t.Log("Starting check...")
@@ -120,8 +110,193 @@ func TestShutdown(t *testing.T) {
 	case e := <-c:
 		// see if the check shut down without error
 		if e != nil {
-			t.Fatal("Error shutting down in-flight check:", err)
+			t.Fatal("Error shutting down in-flight check:", e)
 		}
 		t.Log("Check shutdown properly and without error")
 	}
 }
+
+// TestConfigureUserPodSpecScheduling ensures the scheduling fields of a khcheck's pod spec reach the checker pod and
+// that the enforced settings do not change the khcheck's pod spec
+func TestConfigureUserPodSpecScheduling(t *testing.T) {
+	ext := &Checker{
+		Namespace: "kuberhealthy",
+		OriginalPodSpec: apiv1.PodSpec{
+			HostNetwork:  true,
+			NodeSelector: map[string]string{"pool": "infra"},
+			Tolerations:  []apiv1.Toleration{{Key: "dedicated", Value: "infra", Effect: apiv1.TaintEffectNoSchedule}},
+			Volumes:      []apiv1.Volume{{Name: "config", VolumeSource: apiv1.VolumeSource{EmptyDir: &apiv1.EmptyDirVolumeSource{}}}},
+			Containers:   []apiv1.Container{{Name: "main", Image: "kuberhealthy/test-check"}},
+		},
+	}
+	err := ext.configureUserPodSpec(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ext.PodSpec.NodeSelector["pool"] != "infra" || len(ext.PodSpec.Tolerations) != 1 || len(ext.PodSpec.Volumes) != 1 || !ext.PodSpec.HostNetwork {
+		t.Fatal("expected the scheduling fields and volumes of the khcheck to be kept but got", ext.PodSpec)
+	}
+	if ext.PodSpec.DNSPolicy != apiv1.DNSClusterFirstWithHostNet {
+		t.Fatal("expected checker pods on the host network to use cluster DNS but got", ext.PodSpec.DNSPolicy)
+	}
+	if len(ext.OriginalPodSpec.Containers[0].Env) != 0 || len(ext.OriginalPodSpec.DNSPolicy) != 0 {
+		t.Fatal("expected the pod spec of the khcheck to be unchanged but got", ext.OriginalPodSpec)
+	}
+}
+
+// TestConfigureUserPodSpecEnv ensures the environment of the khcheck reaches the checker pod along with the
+// variables Kuberhealthy injects, and that injected variables replace those of the khcheck
+func TestConfigureUserPodSpecEnv(t *testing.T) {
+	ext := &Checker{
+		Namespace:                "kuberhealthy",
+		KuberhealthyReportingURL: "http://kuberhealthy.kuberhealthy.svc.cluster.local/externalCheckStatus",
+		OriginalPodSpec: apiv1.PodSpec{Containers: []apiv1.Container{{
+			Name:  "main",
+			Image: "kuberhealthy/test-check",
+			Env: []apiv1.EnvVar{
+				{Name: "TARGET", Value: "https://example.com"},
+				{Name: "TOKEN", ValueFrom: &apiv1.EnvVarSource{SecretKeyRef: &apiv1.SecretKeySelector{LocalObjectReference: apiv1.LocalObjectReference{Name: "credentials"}, Key: "token"}}},
+				{Name: KHReportingURL, Value: "http://elsewhere"},
+			},
+			EnvFrom: []apiv1.EnvFromSource{{ConfigMapRef: &apiv1.ConfigMapEnvSource{LocalObjectReference: apiv1.LocalObjectReference{Name: "settings"}}}},
+		}}},
+	}
+	err := ext.configureUserPodSpec(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := ext.PodSpec.Containers[0]
+	if len(c.EnvFrom) != 1 || c.EnvFrom[0].ConfigMapRef.Name != "settings" {
+		t.Fatal("expected the envFrom of the khcheck to be kept but got", c.EnvFrom)
+	}
+	env := make(map[string]apiv1.EnvVar)
+	for _, e := range c.Env {
+		if _, ok := env[e.Name]; ok {
+			t.Fatal("expected", e.Name, "to be set once")
+		}
+		env[e.Name] = e
+	}
+	if env["TARGET"].Value != "https://example.com" || env["TOKEN"].ValueFrom == nil || env["TOKEN"].ValueFrom.SecretKeyRef.Name != "credentials" {
+		t.Fatal("expected the env of the khcheck to be kept but got", c.Env)
+	}
+	if env[KHReportingURL].Value != ext.KuberhealthyReportingURL {
+		t.Fatal("expected the reporting URL of Kuberhealthy to replace the one of the khcheck but got", env[KHReportingURL].Value)
+	}
+	for _, name := range []string{KHRunUUID, KHDeadline, KHPodNamespace, KHCheckName} {
+		if _, ok := env[name]; !ok {
+			t.Fatal("expected", name, "to be injected")
+		}
+	}
+}
+
+// TestContainerConfigError ensures containers that can not be configured are reported with the message of the kubelet
+func TestContainerConfigError(t *testing.T) {
+	p := &apiv1.Pod{Status: apiv1.PodStatus{ContainerStatuses: []apiv1.ContainerStatus{
+		{Name: "main", State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "ContainerCreating"}}},
+	}}}
+	if err := containerConfigError(p); err != nil {
+		t.Fatal("expected no error for a container that is being created but got", err)
+	}
+
+	p.Status.InitContainerStatuses = []apiv1.ContainerStatus{
+		{Name: "init", State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "CreateContainerConfigError", Message: `secret "credentials" not found`}}},
+	}
+	err := containerConfigError(p)
+	if err == nil || err.Error() != `container init can not be configured: secret "credentials" not found` {
+		t.Fatal("expected the missing secret to be reported but got", err)
+	}
+}
+
+// TestImagePullError ensures containers that can not pull their image are reported with the message of the kubelet,
+// and that pods without pull secrets are pointed at them
+func TestImagePullError(t *testing.T) {
+	p := &apiv1.Pod{Status: apiv1.PodStatus{ContainerStatuses: []apiv1.ContainerStatus{{
+		Name:  "main",
+		Image: "registry.example.com/team/check:v1",
+		State: apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "pull access denied"}},
+	}}}}
+	err := imagePullError(p)
+	if err == nil || err.Error() != "container main failed to pull image registry.example.com/team/check:v1: ImagePullBackOff: pull access denied. Set imagePullSecrets in the podSpec if the image is private" {
+		t.Fatal("expected the failed pull to be reported with a hint about pull secrets but got", err)
+	}
+
+	p.Spec.ImagePullSecrets = []apiv1.LocalObjectReference{{Name: "registry"}}
+	err = imagePullError(p)
+	if err == nil || strings.Contains(err.Error(), "Set imagePullSecrets") {
+		t.Fatal("expected no hint about pull secrets for a pod with pull secrets but got", err)
+	}
+
+	p.Status.ContainerStatuses[0].State.Waiting.Reason = "ContainerCreating"
+	if err := imagePullError(p); err != nil {
+		t.Fatal("expected no error for a container that is being created but got", err)
+	}
+}
+
+// TestConfigureUserPodSpecImagePullSecrets ensures the default pull secrets are added to those of the pod spec once
+func TestConfigureUserPodSpecImagePullSecrets(t *testing.T) {
+	ext := &Checker{
+		Namespace:        "kuberhealthy",
+		ImagePullSecrets: []apiv1.LocalObjectReference{{Name: "registry"}, {Name: "mirror"}},
+		OriginalPodSpec: apiv1.PodSpec{
+			ImagePullSecrets: []apiv1.LocalObjectReference{{Name: "team-registry"}, {Name: "mirror"}},
+			Containers:       []apiv1.Container{{Name: "main", Image: "registry.example.com/team/check:v1"}},
+		},
+	}
+	err := ext.configureUserPodSpec(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, secret := range ext.PodSpec.ImagePullSecrets {
+		names = append(names, secret.Name)
+	}
+	if strings.Join(names, ",") != "team-registry,mirror,registry" {
+		t.Fatal("expected the pull secrets of the pod spec followed by the missing defaults but got", names)
+	}
+	if len(ext.OriginalPodSpec.ImagePullSecrets) != 2 {
+		t.Fatal("expected the pod spec of the khcheck to be unchanged but got", ext.OriginalPodSpec.ImagePullSecrets)
+	}
+}
+
+// TestDebugPod ensures the checker pod of the debug runner carries the run UUID, the reporting URL and the labels of
+// a scheduled run
+func TestDebugPod(t *testing.T) {
+	ext := &Checker{
+		CheckName:                "dns-status",
+		Namespace:                "team-a",
+		KuberhealthyReportingURL: "http://192.168.1.10:8090/externalCheckStatus",
+		ExtraLabels:              map[string]string{"team": "a"},
+		OriginalPodSpec:          apiv1.PodSpec{Containers: []apiv1.Container{{Name: "main", Image: "kuberhealthy/dns-resolution-check"}}},
+	}
+	p, err := ext.DebugPod(context.Background(), "2ad3f4c1-run", time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Namespace != "team-a" || !strings.HasPrefix(p.Name, "dns-status-") {
+		t.Fatal("expected a checker pod of the check in its namespace but got", p.Namespace+"/"+p.Name)
+	}
+	if p.Labels[kuberhealthyRunUUIDLabel] != "2ad3f4c1-run" || p.Labels[kuberhealthyCheckNameLabel] != "dns-status" || p.Labels["team"] != "a" {
+		t.Fatal("expected the labels of a scheduled run but got", p.Labels)
+	}
+	if len(p.OwnerReferences) > 0 {
+		t.Fatal("expected the checker pod not to be owned but got", p.OwnerReferences)
+	}
+	env := make(map[string]string)
+	for _, e := range p.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	if env[KHRunUUID] != "2ad3f4c1-run" || env[KHReportingURL] != ext.KuberhealthyReportingURL {
+		t.Fatal("expected the run UUID and reporting URL to be injected but got", p.Spec.Containers[0].Env)
+	}
+	if p.Spec.RestartPolicy != apiv1.RestartPolicyNever {
+		t.Fatal("expected the checker pod never to restart but got", p.Spec.RestartPolicy)
+	}
+
+	ext.OriginalPodSpec = apiv1.PodSpec{}
+	_, err = ext.DebugPod(context.Background(), "2ad3f4c1-run", time.Now().Add(time.Minute))
+	if err == nil {
+		t.Fatal("expected a pod spec without containers to be rejected")
+	}
+}
@@ -46,7 +46,7 @@ func WaitForKuberhealthy(ctx context.Context) error {
 
 // WaitForNodeAge checks the node's age to see if its less than the minimum node age. If so, sleeps until the node
 // reaches the minimum node age.
-func WaitForNodeAge(ctx context.Context, client *kubernetes.Clientset, nodeName string, minNodeAge time.Duration) error {
+func WaitForNodeAge(ctx context.Context, client kubernetes.Interface, nodeName string, minNodeAge time.Duration) error {
 
 	log.Debugln("Pod is on node:", nodeName)
 
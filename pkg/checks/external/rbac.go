@@ -0,0 +1,96 @@
+package external
+
+import (
+	"context"
+	"errors"
+
+	apiv1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// rbacName returns the name of the ServiceAccount, Role and RoleBinding created for the checker pods of the check.
+// The serviceAccountName of the pod spec is used when it is set.
+func (ext *Checker) rbacName() string {
+	if len(ext.OriginalPodSpec.ServiceAccountName) > 0 {
+		return ext.OriginalPodSpec.ServiceAccountName
+	}
+	return ext.CheckName
+}
+
+// ensureRBAC creates or updates the ServiceAccount, Role and RoleBinding declared in the rbac of the check, so that
+// its checker pods run with only the permissions the check declares.  Nothing is created when the check declares no
+// rbac.  The objects are owned by the khcheck, so that they are garbage collected with it.
+func (ext *Checker) ensureRBAC(ctx context.Context) error {
+	if ext.RBAC == nil {
+		return nil
+	}
+
+	var ownerReferences []metav1.OwnerReference
+	ownerRef, err := ext.getWorkloadOwnerRef()
+	if err != nil {
+		ext.log("unable to set ownerReference to the", ext.KHWorkload, "for the RBAC of the checker pods:", err)
+	} else {
+		ownerReferences = append(ownerReferences, ownerRef)
+	}
+
+	labels := map[string]string{
+		kuberhealthyCheckNameLabel: ext.CheckName,
+		"app":                      "kuberhealthy-check",
+	}
+	return ensureCheckRBAC(ctx, ext.KubeClient, ext.Namespace, ext.rbacName(), ext.RBAC.Rules, labels, ownerReferences)
+}
+
+// ensureCheckRBAC creates a ServiceAccount, a Role with the supplied rules and a RoleBinding between them, all with
+// the supplied name.  A ServiceAccount that already exists is used as it is.  The rules of an existing Role and the
+// subjects of an existing RoleBinding are updated.
+func ensureCheckRBAC(ctx context.Context, client kubernetes.Interface, namespace string, name string, rules []rbacv1.PolicyRule, labels map[string]string, ownerReferences []metav1.OwnerReference) error {
+	meta := metav1.ObjectMeta{
+		Name:            name,
+		Namespace:       namespace,
+		Labels:          labels,
+		OwnerReferences: ownerReferences,
+	}
+
+	_, err := client.CoreV1().ServiceAccounts(namespace).Create(ctx, &apiv1.ServiceAccount{ObjectMeta: meta}, metav1.CreateOptions{})
+	if err != nil && !k8sErrors.IsAlreadyExists(err) {
+		return errors.New("failed to create service account " + namespace + "/" + name + ": " + err.Error())
+	}
+
+	role := &rbacv1.Role{ObjectMeta: meta, Rules: rules}
+	_, err = client.RbacV1().Roles(namespace).Create(ctx, role, metav1.CreateOptions{})
+	if k8sErrors.IsAlreadyExists(err) {
+		var existing *rbacv1.Role
+		existing, err = client.RbacV1().Roles(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			existing.Rules = rules
+			_, err = client.RbacV1().Roles(namespace).Update(ctx, existing, metav1.UpdateOptions{})
+		}
+	}
+	if err != nil {
+		return errors.New("failed to create role " + namespace + "/" + name + ": " + err.Error())
+	}
+
+	roleRef := rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: name}
+	subjects := []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: name, Namespace: namespace}}
+	roleBinding := &rbacv1.RoleBinding{ObjectMeta: meta, RoleRef: roleRef, Subjects: subjects}
+	_, err = client.RbacV1().RoleBindings(namespace).Create(ctx, roleBinding, metav1.CreateOptions{})
+	if k8sErrors.IsAlreadyExists(err) {
+		var existing *rbacv1.RoleBinding
+		existing, err = client.RbacV1().RoleBindings(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			// the role of a role binding can not be changed
+			if existing.RoleRef != roleRef {
+				return errors.New("role binding " + namespace + "/" + name + " already exists and binds " + existing.RoleRef.Kind + " " + existing.RoleRef.Name)
+			}
+			existing.Subjects = subjects
+			_, err = client.RbacV1().RoleBindings(namespace).Update(ctx, existing, metav1.UpdateOptions{})
+		}
+	}
+	if err != nil {
+		return errors.New("failed to create role binding " + namespace + "/" + name + ": " + err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package external
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestEnsureCheckRBAC ensures the ServiceAccount, Role and RoleBinding of a check are created, that the rules of the
+// Role follow the check and that a RoleBinding of another role is not taken over
+func TestEnsureCheckRBAC(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	labels := map[string]string{kuberhealthyCheckNameLabel: "pod-lister"}
+	rules := []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"list"}}}
+
+	err := ensureCheckRBAC(ctx, client, "kuberhealthy", "pod-lister", rules, labels, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = client.CoreV1().ServiceAccounts("kuberhealthy").Get(ctx, "pod-lister", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal("expected the service account to be created:", err)
+	}
+	roleBinding, err := client.RbacV1().RoleBindings("kuberhealthy").Get(ctx, "pod-lister", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal("expected the role binding to be created:", err)
+	}
+	if roleBinding.RoleRef.Name != "pod-lister" || len(roleBinding.Subjects) != 1 || roleBinding.Subjects[0].Name != "pod-lister" {
+		t.Fatal("expected the role binding to bind the role to the service account but got", roleBinding.RoleRef, roleBinding.Subjects)
+	}
+
+	// the rules of the role follow the check
+	rules[0].Verbs = []string{"get", "list"}
+	err = ensureCheckRBAC(ctx, client, "kuberhealthy", "pod-lister", rules, labels, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	role, err := client.RbacV1().Roles("kuberhealthy").Get(ctx, "pod-lister", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(role.Rules) != 1 || len(role.Rules[0].Verbs) != 2 {
+		t.Fatal("expected the rules of the role to be updated but got", role.Rules)
+	}
+
+	// a role binding of another role is left alone
+	_, err = client.RbacV1().RoleBindings("kuberhealthy").Create(ctx, &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin", Namespace: "kuberhealthy"},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: "admin"},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ensureCheckRBAC(ctx, client, "kuberhealthy", "admin", rules, labels, nil)
+	if err == nil || !strings.Contains(err.Error(), "already exists and binds ClusterRole admin") {
+		t.Fatal("expected an error for a role binding of another role but got", err)
+	}
+}
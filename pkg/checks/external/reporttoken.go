@@ -0,0 +1,75 @@
+package external
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KHReportToken is the environment variable used to give external checks a secret token of their run.  Checker
+// pods present it with their reports so that a report can not be sent for a run by anyone who only knows its UUID.
+const KHReportToken = "KH_REPORT_TOKEN"
+
+// ReportTokenSecretKey is the key of the report token in the secret of a run
+const ReportTokenSecretKey = "token"
+
+// reportTokenBytes is the number of random bytes in a report token
+const reportTokenBytes = 32
+
+// newReportToken creates a random report token for a single run
+func newReportToken() (string, error) {
+	b := make([]byte, reportTokenBytes)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("failed to create a report token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// reportTokenSecretName is the name of the secret that holds the report token of the current run.  The token is
+// kept out of the pod spec so that anyone who can read pods can not read it.
+func (ext *Checker) reportTokenSecretName() string {
+	return ext.podName() + "-report-token"
+}
+
+// createReportTokenSecret creates the secret that the checker pod of the current run reads its report token from.
+// The secret is labeled with the UUID of the run so that Kuberhealthy only accepts it for the run it was made for.
+func (ext *Checker) createReportTokenSecret(ctx context.Context) error {
+	s := &apiv1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ext.reportTokenSecretName(),
+			Namespace: ext.Namespace,
+			Labels: map[string]string{
+				kuberhealthyRunIDLabel:     ext.currentCheckUUID,
+				kuberhealthyCheckNameLabel: ext.CheckName,
+			},
+		},
+		Type:       apiv1.SecretTypeOpaque,
+		StringData: map[string]string{ReportTokenSecretKey: ext.currentReportToken},
+	}
+
+	// the secret is collected with the khcheck or khjob if Kuberhealthy dies before it deletes it
+	workloadOwnerRef, err := ext.getWorkloadOwnerRef()
+	if err != nil {
+		ext.log("unable to set ownerReference to the", ext.KHWorkload, "for secret", s.Name+":", err)
+	} else {
+		s.OwnerReferences = []metav1.OwnerReference{workloadOwnerRef}
+	}
+
+	_, err = ext.KubeClient.CoreV1().Secrets(ext.Namespace).Create(ctx, s, metav1.CreateOptions{})
+	return err
+}
+
+// deleteReportTokenSecret deletes the report token secret of the current run once the run is over
+func (ext *Checker) deleteReportTokenSecret(ctx context.Context) {
+	name := ext.reportTokenSecretName()
+	err := ext.KubeClient.CoreV1().Secrets(ext.Namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		ext.log("failed to delete report token secret", name+":", err)
+	}
+}
@@ -0,0 +1,89 @@
+package external
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestConfigureUserPodSpecReportToken ensures the report token of the run is injected into every container and
+// replaces a token set in the khcheck
+func TestConfigureUserPodSpecReportToken(t *testing.T) {
+	token, err := newReportToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := newReportToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) == 0 || token == other {
+		t.Fatal("expected report tokens to be random")
+	}
+
+	ext := &Checker{
+		Namespace:          "kuberhealthy",
+		currentCheckUUID:   "run-uuid",
+		currentReportToken: token,
+		OriginalPodSpec: apiv1.PodSpec{Containers: []apiv1.Container{
+			{Name: "main", Env: []apiv1.EnvVar{{Name: KHReportToken, Value: "guessed"}}},
+			{Name: "sidecar"},
+		}},
+	}
+	err = ext.configureUserPodSpec(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range ext.PodSpec.Containers {
+		var refs []*apiv1.SecretKeySelector
+		for _, e := range c.Env {
+			if e.Name != KHReportToken {
+				continue
+			}
+			if len(e.Value) > 0 {
+				t.Fatalf("expected the container %s to not have the report token in its pod spec but got %s", c.Name, e.Value)
+			}
+			if e.ValueFrom != nil {
+				refs = append(refs, e.ValueFrom.SecretKeyRef)
+			}
+		}
+		if len(refs) != 1 || refs[0] == nil || refs[0].Name != ext.reportTokenSecretName() || refs[0].Key != ReportTokenSecretKey {
+			t.Fatalf("expected the container %s to read the report token from the secret of the run but got %v", c.Name, refs)
+		}
+	}
+}
+
+// TestReportTokenSecret ensures the report token secret of a run is labeled with the run and deleted afterwards
+func TestReportTokenSecret(t *testing.T) {
+	ext := &Checker{
+		CheckName:          "dns-status",
+		Namespace:          "kuberhealthy",
+		currentCheckUUID:   "run-uuid",
+		currentReportToken: "token",
+		KubeClient:         fake.NewSimpleClientset(),
+	}
+	ext.regeneratePodName()
+
+	err := ext.createReportTokenSecret(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := ext.KubeClient.CoreV1().Secrets("kuberhealthy").Get(context.Background(), ext.reportTokenSecretName(), metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Labels[kuberhealthyRunIDLabel] != "run-uuid" || s.StringData[ReportTokenSecretKey] != "token" {
+		t.Fatalf("expected the secret to hold the report token of the run but got labels %v and data %v", s.Labels, s.StringData)
+	}
+
+	ext.deleteReportTokenSecret(context.Background())
+	_, err = ext.KubeClient.CoreV1().Secrets("kuberhealthy").Get(context.Background(), ext.reportTokenSecretName(), metav1.GetOptions{})
+	if !k8sErrors.IsNotFound(err) {
+		t.Fatal("expected the report token secret to be deleted but got:", err)
+	}
+}
@@ -0,0 +1,214 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// ErrPodOOMKilled is the error returned when a container of the checker pod is killed for running out of memory
+const ErrPodOOMKilled = "checker pod OOMKilled"
+
+// resourceSampleInterval is how often the checker pod container statuses and resource usage are sampled during a run
+const resourceSampleInterval = time.Second * 5
+
+// podMetrics is the part of a metrics.k8s.io PodMetrics resource that holds the resource usage of each container
+type podMetrics struct {
+	Containers []struct {
+		Name  string             `json:"name"`
+		Usage apiv1.ResourceList `json:"usage"`
+	} `json:"containers"`
+}
+
+// RunContainers returns the final status and last observed resource usage of each checker pod container of the last
+// run.  Nil is returned if the checker pod of the last run could not be inspected.
+func (ext *Checker) RunContainers() []khstatev1.ContainerRunStatus {
+	ext.runContainersMu.Lock()
+	defer ext.runContainersMu.Unlock()
+	return ext.runContainers
+}
+
+// monitorPodResources samples the container statuses and resource usage of the checker pod until the context is
+// canceled.  The returned channel receives an error if a container of the checker pod is OOMKilled.
+func (ext *Checker) monitorPodResources(ctx context.Context) chan error {
+	outChan := make(chan error, 1)
+
+	ext.wg.Add(1)
+	go func() {
+		defer ext.wg.Done()
+		ticker := time.NewTicker(resourceSampleInterval)
+		defer ticker.Stop()
+		for {
+			containers := ext.sampleRunContainers(ctx)
+			if name, oomKilled := oomKilledContainer(containers); oomKilled {
+				outChan <- ext.oomKilledError(name)
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return outChan
+}
+
+// sampleRunContainers records the current container statuses of the checker pod, along with their resource usage if
+// the metrics API is available.  The last observed resource usage is kept when no new sample is available, such as
+// after the containers exit.  Errors are logged at debug level, since the metrics API is optional and the pod may
+// already be gone.
+func (ext *Checker) sampleRunContainers(ctx context.Context) []khstatev1.ContainerRunStatus {
+	if ext.KubeClient == nil {
+		return nil
+	}
+	pod, err := ext.KubeClient.CoreV1().Pods(ext.Namespace).Get(ctx, ext.podName(), metav1.GetOptions{})
+	if err != nil {
+		log.Debugln(ext.Namespace+"/"+ext.CheckName+": unable to fetch checker pod", ext.podName(), "to inspect its containers:", err)
+		return ext.RunContainers()
+	}
+
+	usage := ext.fetchPodMetrics(ctx, pod.Name)
+
+	ext.runContainersMu.Lock()
+	defer ext.runContainersMu.Unlock()
+	ext.runContainers = containerRunStatuses(pod.Status.ContainerStatuses, ext.runContainers, usage)
+	return ext.runContainers
+}
+
+// fetchPodMetrics returns the current resource usage of each container of a checker pod from the metrics.k8s.io API.
+// Nil is returned when the metrics API is not available or has no sample of the pod yet.
+func (ext *Checker) fetchPodMetrics(ctx context.Context, podName string) map[string]apiv1.ResourceList {
+	b, err := ext.KubeClient.CoreV1().RESTClient().Get().
+		AbsPath("/apis/metrics.k8s.io/v1beta1/namespaces", ext.Namespace, "pods", podName).
+		DoRaw(ctx)
+	if err != nil {
+		log.Debugln(ext.Namespace+"/"+ext.CheckName+": no metrics available for checker pod", podName+":", err)
+		return nil
+	}
+
+	var metrics podMetrics
+	err = json.Unmarshal(b, &metrics)
+	if err != nil {
+		log.Debugln(ext.Namespace+"/"+ext.CheckName+": unable to parse metrics of checker pod", podName+":", err)
+		return nil
+	}
+	usage := make(map[string]apiv1.ResourceList)
+	for _, c := range metrics.Containers {
+		usage[c.Name] = c.Usage
+	}
+	return usage
+}
+
+// containerRunStatuses builds the run status of each container from its pod container status.  The resource usage
+// of each container is taken from usage, or from the previous statuses when there is no new sample of it.
+func containerRunStatuses(statuses []apiv1.ContainerStatus, previous []khstatev1.ContainerRunStatus, usage map[string]apiv1.ResourceList) []khstatev1.ContainerRunStatus {
+	previousByName := make(map[string]khstatev1.ContainerRunStatus)
+	for _, p := range previous {
+		previousByName[p.Name] = p
+	}
+
+	containers := make([]khstatev1.ContainerRunStatus, 0, len(statuses))
+	for _, s := range statuses {
+		c := khstatev1.ContainerRunStatus{
+			Name:         s.Name,
+			RestartCount: s.RestartCount,
+			CPU:          previousByName[s.Name].CPU,
+			Memory:       previousByName[s.Name].Memory,
+		}
+
+		// a container that was restarted may hold its most interesting termination in its last state
+		terminated := s.State.Terminated
+		if terminated == nil {
+			terminated = s.LastTerminationState.Terminated
+		}
+		switch {
+		case terminated != nil:
+			exitCode := terminated.ExitCode
+			c.ExitCode = &exitCode
+			c.Reason = terminated.Reason
+			c.OOMKilled = terminated.Reason == "OOMKilled"
+		case s.State.Waiting != nil:
+			c.Reason = s.State.Waiting.Reason
+		}
+
+		if u, ok := usage[s.Name]; ok {
+			if cpu, ok := u[apiv1.ResourceCPU]; ok {
+				c.CPU = formatCPU(cpu)
+			}
+			if memory, ok := u[apiv1.ResourceMemory]; ok {
+				c.Memory = formatMemory(memory)
+			}
+		}
+		containers = append(containers, c)
+	}
+	return containers
+}
+
+// formatCPU formats a CPU quantity in millicores so that samples are easy to compare with the requests and limits of
+// the khcheck
+func formatCPU(q resource.Quantity) string {
+	return strconv.FormatInt(q.MilliValue(), 10) + "m"
+}
+
+// formatMemory formats a memory quantity in mebibytes, rounded up, so that samples are easy to compare with the
+// requests and limits of the khcheck
+func formatMemory(q resource.Quantity) string {
+	const mebibyte = 1 << 20
+	return strconv.FormatInt((q.Value()+mebibyte-1)/mebibyte, 10) + "Mi"
+}
+
+// oomKilledContainer returns the name of the first container that was OOMKilled
+func oomKilledContainer(containers []khstatev1.ContainerRunStatus) (string, bool) {
+	for _, c := range containers {
+		if c.OOMKilled {
+			return c.Name, true
+		}
+	}
+	return "", false
+}
+
+// oomKilledError returns the error of a run whose checker pod container was OOMKilled
+func (ext *Checker) oomKilledError(container string) error {
+	return ext.newError(ErrPodOOMKilled + ": container " + container + " ran out of memory. Raise its memory limit in the podSpec of the khcheck.")
+}
+
+// ApplyDefaultResources sets the default resource requests and limits on a container for each resource it sets
+// neither itself.  A default request is not set when the container limits the resource, since Kubernetes requests
+// the limit then, and a default limit is not set when the container requests more than it.
+func ApplyDefaultResources(c *apiv1.Container, defaults apiv1.ResourceRequirements) {
+	for name, quantity := range defaults.Requests {
+		if _, ok := c.Resources.Requests[name]; ok {
+			continue
+		}
+		if _, ok := c.Resources.Limits[name]; ok {
+			continue
+		}
+		if c.Resources.Requests == nil {
+			c.Resources.Requests = make(apiv1.ResourceList)
+		}
+		c.Resources.Requests[name] = quantity.DeepCopy()
+	}
+	for name, quantity := range defaults.Limits {
+		if _, ok := c.Resources.Limits[name]; ok {
+			continue
+		}
+		if request, ok := c.Resources.Requests[name]; ok && request.Cmp(quantity) > 0 {
+			continue
+		}
+		if c.Resources.Limits == nil {
+			c.Resources.Limits = make(apiv1.ResourceList)
+		}
+		c.Resources.Limits[name] = quantity.DeepCopy()
+	}
+}
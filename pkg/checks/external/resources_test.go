@@ -0,0 +1,107 @@
+package external
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// TestContainerRunStatuses ensures OOMKills, exit codes, restart counts and resource usage are recorded for each
+// container, and that the last observed resource usage is kept when there is no new sample
+func TestContainerRunStatuses(t *testing.T) {
+	statuses := []apiv1.ContainerStatus{
+		{
+			Name:         "main",
+			RestartCount: 1,
+			State:        apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}},
+		},
+		{
+			Name:  "sidecar",
+			State: apiv1.ContainerState{Running: &apiv1.ContainerStateRunning{}},
+		},
+	}
+	previous := []khstatev1.ContainerRunStatus{{Name: "main", CPU: "250m", Memory: "128Mi"}}
+	usage := map[string]apiv1.ResourceList{
+		"sidecar": {
+			apiv1.ResourceCPU:    resource.MustParse("1500000n"),
+			apiv1.ResourceMemory: resource.MustParse("10300Ki"),
+		},
+	}
+
+	containers := containerRunStatuses(statuses, previous, usage)
+	if len(containers) != 2 {
+		t.Fatal("expected 2 containers but got", containers)
+	}
+	main := containers[0]
+	if !main.OOMKilled || main.ExitCode == nil || *main.ExitCode != 137 || main.RestartCount != 1 || main.CPU != "250m" || main.Memory != "128Mi" {
+		t.Fatal("expected the OOMKilled main container with its last observed usage but got", main)
+	}
+	sidecar := containers[1]
+	if sidecar.OOMKilled || sidecar.ExitCode != nil || sidecar.CPU != "2m" || sidecar.Memory != "11Mi" {
+		t.Fatal("expected the running sidecar with its sampled usage but got", sidecar)
+	}
+
+	name, oomKilled := oomKilledContainer(containers)
+	if !oomKilled || name != "main" {
+		t.Fatal("expected the main container to be OOMKilled but got", name, oomKilled)
+	}
+}
+
+// TestContainerRunStatusesLastTermination ensures a restarted container reports the termination of its last run
+func TestContainerRunStatusesLastTermination(t *testing.T) {
+	statuses := []apiv1.ContainerStatus{{
+		Name:                 "main",
+		RestartCount:         3,
+		State:                apiv1.ContainerState{Waiting: &apiv1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+		LastTerminationState: apiv1.ContainerState{Terminated: &apiv1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}},
+	}}
+
+	containers := containerRunStatuses(statuses, nil, nil)
+	if containers[0].Reason != "Error" || *containers[0].ExitCode != 1 || containers[0].RestartCount != 3 || containers[0].OOMKilled {
+		t.Fatal("expected the last termination of the restarted container but got", containers[0])
+	}
+	if _, oomKilled := oomKilledContainer(containers); oomKilled {
+		t.Fatal("expected no OOMKilled container")
+	}
+}
+
+// TestApplyDefaultResources ensures the default resources are only set for resources a container sets neither a
+// request nor a limit for, and that they never produce a request higher than a limit
+func TestApplyDefaultResources(t *testing.T) {
+	defaults := apiv1.ResourceRequirements{
+		Requests: apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("10m"), apiv1.ResourceMemory: resource.MustParse("32Mi")},
+		Limits:   apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("100m"), apiv1.ResourceMemory: resource.MustParse("64Mi")},
+	}
+
+	c := apiv1.Container{Name: "main"}
+	ApplyDefaultResources(&c, defaults)
+	if c.Resources.Requests.Cpu().String() != "10m" || c.Resources.Limits.Memory().String() != "64Mi" {
+		t.Fatal("expected a container without resources to get the defaults but got", c.Resources)
+	}
+
+	c = apiv1.Container{Name: "main", Resources: apiv1.ResourceRequirements{
+		Requests: apiv1.ResourceList{apiv1.ResourceMemory: resource.MustParse("128Mi")},
+		Limits:   apiv1.ResourceList{apiv1.ResourceCPU: resource.MustParse("1")},
+	}}
+	ApplyDefaultResources(&c, defaults)
+	if _, ok := c.Resources.Requests[apiv1.ResourceCPU]; ok {
+		t.Fatal("expected no default CPU request for a container with a CPU limit but got", c.Resources.Requests.Cpu())
+	}
+	if _, ok := c.Resources.Limits[apiv1.ResourceMemory]; ok {
+		t.Fatal("expected no default memory limit below the memory request of the container but got", c.Resources.Limits.Memory())
+	}
+	if c.Resources.Requests.Memory().String() != "128Mi" || c.Resources.Limits.Cpu().String() != "1" {
+		t.Fatal("expected the resources of the container to be kept but got", c.Resources)
+	}
+
+	// the defaults are copied, not shared
+	c = apiv1.Container{Name: "main"}
+	ApplyDefaultResources(&c, defaults)
+	c.Resources.Requests[apiv1.ResourceCPU] = resource.MustParse("1")
+	if defaults.Requests.Cpu().String() != "10m" {
+		t.Fatal("expected the defaults to be unchanged but got", defaults.Requests)
+	}
+}
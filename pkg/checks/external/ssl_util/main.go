@@ -138,7 +138,7 @@ func SSLHandshakeWithCertPool(url *url.URL, certPool *x509.CertPool) error {
 	}
 
 	// dial to the TCP endpoint
-	conn, err := tls.DialWithDialer(d, "tcp", url.Hostname()+":"+url.Port(), &tls.Config{
+	conn, err := tls.DialWithDialer(d, "tcp", net.JoinHostPort(url.Hostname(), url.Port()), &tls.Config{
 		InsecureSkipVerify: false,
 		MinVersion:         tls.VersionTLS12,
 		RootCAs:            certPool,
@@ -180,7 +180,7 @@ func CertExpiry(host, port, days string, overrideTLS bool) (bool, bool, error) {
 	}
 
 	// InsecureSkipVerify should be false unless checking a self-signed certificate
-	conn, err := tls.DialWithDialer(d, "tcp", host+":"+port, &tls.Config{
+	conn, err := tls.DialWithDialer(d, "tcp", net.JoinHostPort(host, port), &tls.Config{
 		InsecureSkipVerify: overrideTLS,
 		MinVersion:         tls.VersionTLS12,
 	})
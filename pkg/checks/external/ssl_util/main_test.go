@@ -6,6 +6,9 @@ import (
 )
 
 func TestCertPull(t *testing.T) {
+	if !KubernetesCAPresent() {
+		t.Skip("Skipping a test that needs the certificate authority of a cluster:", kubernetesCAFileLocation)
+	}
 
 	certPool, err := CreatePool()
 	if err != nil {
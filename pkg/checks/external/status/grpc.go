@@ -0,0 +1,8 @@
+package status
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative report.proto
+
+// Report returns the status report carried by a ReportCheckStatus request
+func (r *ReportCheckStatusRequest) Report() Report {
+	return Report{Errors: r.GetErrors(), OK: r.GetOk(), Details: r.GetDetails(), Severity: r.GetSeverity()}
+}
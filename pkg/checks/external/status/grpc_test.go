@@ -0,0 +1,21 @@
+package status
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestReportCheckStatusRequestReport ensures the report carried by a ReportCheckStatus request keeps every field
+func TestReportCheckStatusRequestReport(t *testing.T) {
+	req := &ReportCheckStatusRequest{
+		RunUuid:     "run-uuid",
+		Errors:      []string{"certificate expires in 10 days"},
+		Details:     map[string]string{"zone": "us-east-1a"},
+		ReportToken: "report-token",
+		Severity:    SeverityWarning,
+	}
+	expected := Report{Errors: req.Errors, OK: false, Details: req.Details, Severity: SeverityWarning}
+	if report := req.Report(); !reflect.DeepEqual(report, expected) {
+		t.Fatal("expected", expected, "but got", report)
+	}
+}
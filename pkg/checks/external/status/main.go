@@ -3,10 +3,68 @@
 // status reporting endpoint.
 package status
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxDetails is the most details a report can carry
+const MaxDetails = 20
+
+// MaxDetailsSize is the most bytes the keys and values of the details of a report can add up to
+const MaxDetailsSize = 2048
+
+// ReservedDetailPrefixes are the detail key prefixes used internally by Kuberhealthy.  They are matched without
+// regard to case.
+var ReservedDetailPrefixes = []string{"kh_", "kuberhealthy"}
+
+// SeverityCritical is the severity of failures that make the cluster unhealthy.  Failures without a severity are
+// critical.
+const SeverityCritical = "Critical"
+
+// SeverityWarning is the severity of failures that are shown, exported and notified, but do not make the cluster
+// unhealthy
+const SeverityWarning = "Warning"
+
 // Report is the format expected by the /externalCheckStatus endpoint
 type Report struct {
-	Errors []string
-	OK     bool
+	Errors   []string
+	OK       bool
+	Details  map[string]string `json:",omitempty"` // extra output of the check run, such as the number of records verified
+	Severity string            `json:",omitempty"` // the severity of the errors, which is Critical when blank
+}
+
+// ValidateSeverity ensures the severity of the report is blank, Critical or Warning
+func (r Report) ValidateSeverity() error {
+	switch r.Severity {
+	case "", SeverityCritical, SeverityWarning:
+		return nil
+	}
+	return fmt.Errorf("severity %s was reported but only %s and %s are allowed", r.Severity, SeverityCritical, SeverityWarning)
+}
+
+// ValidateDetails ensures the details of the report are within the size limits and do not use reserved keys
+func (r Report) ValidateDetails() error {
+	if len(r.Details) > MaxDetails {
+		return fmt.Errorf("%d details were reported but at most %d are allowed", len(r.Details), MaxDetails)
+	}
+	var size int
+	for key, value := range r.Details {
+		if len(key) == 0 {
+			return errors.New("a detail with a blank key was reported")
+		}
+		for _, prefix := range ReservedDetailPrefixes {
+			if strings.HasPrefix(strings.ToLower(key), prefix) {
+				return fmt.Errorf("detail key %s uses the reserved prefix %s", key, prefix)
+			}
+		}
+		size += len(key) + len(value)
+	}
+	if size > MaxDetailsSize {
+		return fmt.Errorf("details of %d bytes were reported but at most %d bytes are allowed", size, MaxDetailsSize)
+	}
+	return nil
 }
 
 // NewReport creates a new error report to be sent to the server.  If
@@ -24,3 +82,12 @@ func NewReport(errorMessages []string) Report {
 		OK:     ok,
 	}
 }
+
+// NewWarningReport creates a new report of errors that are only a warning
+func NewWarningReport(errorMessages []string) Report {
+	r := NewReport(errorMessages)
+	if !r.OK {
+		r.Severity = SeverityWarning
+	}
+	return r
+}
@@ -0,0 +1,51 @@
+package status
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestValidateDetails ensures details beyond the size limits or with reserved keys are refused
+func TestValidateDetails(t *testing.T) {
+	tooMany := map[string]string{}
+	for i := 0; i <= MaxDetails; i++ {
+		tooMany["key"+strconv.Itoa(i)] = "value"
+	}
+
+	tests := map[string]struct {
+		details map[string]string
+		valid   bool
+	}{
+		"none":          {details: nil, valid: true},
+		"valid":         {details: map[string]string{"lastVerifiedBackup": "2024-05-01T02:00Z"}, valid: true},
+		"too many":      {details: tooMany, valid: false},
+		"too large":     {details: map[string]string{"output": strings.Repeat("a", MaxDetailsSize)}, valid: false},
+		"blank key":     {details: map[string]string{"": "value"}, valid: false},
+		"reserved kh":   {details: map[string]string{"KH_RUN_UUID": "value"}, valid: false},
+		"reserved name": {details: map[string]string{"kuberhealthy.pod": "value"}, valid: false},
+	}
+	for name, test := range tests {
+		err := Report{OK: true, Details: test.details}.ValidateDetails()
+		if (err == nil) != test.valid {
+			t.Error(name, "expected valid to be", test.valid, "but got error:", err)
+		}
+	}
+}
+
+// TestValidateSeverity ensures only the known severities are accepted
+func TestValidateSeverity(t *testing.T) {
+	tests := map[string]bool{
+		"":               true,
+		SeverityCritical: true,
+		SeverityWarning:  true,
+		"warning":        false,
+		"Info":           false,
+	}
+	for severity, valid := range tests {
+		err := Report{Errors: []string{"error"}, Severity: severity}.ValidateSeverity()
+		if (err == nil) != valid {
+			t.Error(severity, "expected valid to be", valid, "but got error:", err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// The gRPC service external checks can report their status to instead of the /externalCheckStatus endpoint.  It is
+// served when Kuberhealthy is started with --grpcListenAddress.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: report.proto
+
+package status
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	CheckReporter_ReportCheckStatus_FullMethodName = "/kuberhealthy.v1.CheckReporter/ReportCheckStatus"
+)
+
+// CheckReporterClient is the client API for CheckReporter service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CheckReporterClient interface {
+	// ReportCheckStatus reports the result of a check run.  The report is validated and stored the same way as
+	// reports to the /externalCheckStatus endpoint.
+	ReportCheckStatus(ctx context.Context, in *ReportCheckStatusRequest, opts ...grpc.CallOption) (*ReportCheckStatusResponse, error)
+}
+
+type checkReporterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCheckReporterClient(cc grpc.ClientConnInterface) CheckReporterClient {
+	return &checkReporterClient{cc}
+}
+
+func (c *checkReporterClient) ReportCheckStatus(ctx context.Context, in *ReportCheckStatusRequest, opts ...grpc.CallOption) (*ReportCheckStatusResponse, error) {
+	out := new(ReportCheckStatusResponse)
+	err := c.cc.Invoke(ctx, CheckReporter_ReportCheckStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CheckReporterServer is the server API for CheckReporter service.
+// All implementations must embed UnimplementedCheckReporterServer
+// for forward compatibility
+type CheckReporterServer interface {
+	// ReportCheckStatus reports the result of a check run.  The report is validated and stored the same way as
+	// reports to the /externalCheckStatus endpoint.
+	ReportCheckStatus(context.Context, *ReportCheckStatusRequest) (*ReportCheckStatusResponse, error)
+	mustEmbedUnimplementedCheckReporterServer()
+}
+
+// UnimplementedCheckReporterServer must be embedded to have forward compatible implementations.
+type UnimplementedCheckReporterServer struct {
+}
+
+func (UnimplementedCheckReporterServer) ReportCheckStatus(context.Context, *ReportCheckStatusRequest) (*ReportCheckStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportCheckStatus not implemented")
+}
+func (UnimplementedCheckReporterServer) mustEmbedUnimplementedCheckReporterServer() {}
+
+// UnsafeCheckReporterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CheckReporterServer will
+// result in compilation errors.
+type UnsafeCheckReporterServer interface {
+	mustEmbedUnimplementedCheckReporterServer()
+}
+
+func RegisterCheckReporterServer(s grpc.ServiceRegistrar, srv CheckReporterServer) {
+	s.RegisterService(&CheckReporter_ServiceDesc, srv)
+}
+
+func _CheckReporter_ReportCheckStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportCheckStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CheckReporterServer).ReportCheckStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CheckReporter_ReportCheckStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CheckReporterServer).ReportCheckStatus(ctx, req.(*ReportCheckStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CheckReporter_ServiceDesc is the grpc.ServiceDesc for CheckReporter service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CheckReporter_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kuberhealthy.v1.CheckReporter",
+	HandlerType: (*CheckReporterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ReportCheckStatus",
+			Handler:    _CheckReporter_ReportCheckStatus_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "report.proto",
+}
@@ -0,0 +1,97 @@
+package external
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/util"
+)
+
+// timedOutPodGracePeriod is how many seconds the checker pods of a run that timed out get to shut down before they
+// are killed.  Pods of a run that timed out may be hung, so they are not given the usual eviction grace period.
+const timedOutPodGracePeriod = 5
+
+// newTimeoutError returns an error for a run that did not complete within the timeout of its khcheck
+func (ext *Checker) newTimeoutError(s string) error {
+	return fmt.Errorf("%w after %s: %w", ErrRunTimedOut, ext.RunTimeout, ext.newError(s))
+}
+
+// timedOut ends a run that did not complete within its timeout.  The checker pods of the run are killed and the
+// UUID of the run is invalidated so that a hung checker pod can not report in after the run failed.  The returned
+// error is reported as the state of the check.
+func (ext *Checker) timedOut(ctx context.Context, s string) error {
+	ext.log("Run timed out after", ext.RunTimeout.String()+". Killing its checker pods and invalidating its UUID")
+	ext.killRunPods(ctx)
+	ext.invalidateUUID(ext.currentCheckUUID)
+	return ext.newTimeoutError(s)
+}
+
+// killRunPods kills the checker pods of the current run that are still running.  Pods of other checks and runs are
+// left alone.
+func (ext *Checker) killRunPods(ctx context.Context) {
+	if ext.KubeClient == nil {
+		return
+	}
+	podList, err := ext.KubeClient.CoreV1().Pods(ext.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: kuberhealthyRunIDLabel + "=" + ext.currentCheckUUID,
+	})
+	if err != nil {
+		ext.log("error when searching for checker pods of the run that timed out:", err)
+		return
+	}
+	for _, p := range podList.Items {
+		if p.DeletionTimestamp != nil || p.Status.Phase == apiv1.PodSucceeded || p.Status.Phase == apiv1.PodFailed {
+			continue
+		}
+		ext.log("killing checker pod", p.Name, "of the run that timed out")
+		err := util.PodKill(ext.KubeClient, p.Name, p.Namespace, timedOutPodGracePeriod)
+		if err != nil {
+			ext.log("error killing checker pod", p.Name+":", err)
+		}
+	}
+}
+
+// invalidateUUID removes the UUID of a run from its khstate, so that reports from the checker pods of the run are
+// rejected.  The next run sets a new UUID.
+func (ext *Checker) invalidateUUID(uuid string) {
+	if ext.KHStateClient == nil {
+		return
+	}
+
+	// the checker pod or another run may update the khstate at the same time, so we retry on conflicts
+	for tries := 0; tries < 5; tries++ {
+		checkState, err := ext.getKHState()
+		if err != nil {
+			ext.log("failed to fetch khstate to invalidate uuid", uuid+":", err)
+			return
+		}
+		if checkState.Spec.CurrentUUID != uuid && !containsUUID(checkState.Spec.AllowedUUIDs, uuid) {
+			return
+		}
+		if checkState.Spec.CurrentUUID == uuid {
+			checkState.Spec.CurrentUUID = ""
+		}
+		checkState.Spec.AllowedUUIDs = removeUUID(checkState.Spec.AllowedUUIDs, uuid)
+		_, err = ext.KHStateClient.KuberhealthyStates(ext.CheckNamespace()).Update(&checkState)
+		if err == nil {
+			ext.log("Invalidated uuid", uuid, "of the run that timed out")
+			return
+		}
+		ext.log("failed to invalidate uuid", uuid, "in khstate:", err)
+		time.Sleep(time.Second)
+	}
+}
+
+// containsUUID determines if the UUIDs contain the supplied one
+func containsUUID(uuids []string, uuid string) bool {
+	for _, u := range uuids {
+		if u == uuid {
+			return true
+		}
+	}
+	return false
+}
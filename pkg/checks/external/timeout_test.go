@@ -0,0 +1,29 @@
+package external
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewTimeoutError ensures run timeouts can be told apart from other run errors and name the timeout that passed
+func TestNewTimeoutError(t *testing.T) {
+	ext := &Checker{CheckName: "dns-status", Namespace: "kuberhealthy", RunTimeout: time.Minute * 5}
+	err := ext.newTimeoutError("timed out waiting for checker pod to report in")
+	if !errors.Is(err, ErrRunTimedOut) {
+		t.Fatal("expected a run timeout error but got", err)
+	}
+	if !strings.Contains(err.Error(), "after 5m0s") || !strings.HasSuffix(err.Error(), "kuberhealthy/dns-status: timed out waiting for checker pod to report in") {
+		t.Fatal("unexpected run timeout error:", err)
+	}
+	if errors.Is(ext.newError("failed to create pod"), ErrRunTimedOut) {
+		t.Fatal("expected other run errors to not be run timeouts")
+	}
+}
+
+func TestContainsUUID(t *testing.T) {
+	if !containsUUID([]string{"a", "b"}, "b") || containsUUID([]string{"a"}, "b") || containsUUID(nil, "") {
+		t.Fatal("unexpected result from containsUUID")
+	}
+}
@@ -21,7 +21,7 @@ const (
 )
 
 // GetOwnerRef fetches the UID from the pod and returns OwnerReference
-func GetOwnerRef(client *kubernetes.Clientset, namespace string) ([]metav1.OwnerReference, error) {
+func GetOwnerRef(client kubernetes.Interface, namespace string) ([]metav1.OwnerReference, error) {
 	// TODO: refactor function to receive context on exported function in next breaking change.
 	ctx := context.TODO()
 	podName, err := os.Hostname()
@@ -43,7 +43,7 @@ func GetOwnerRef(client *kubernetes.Clientset, namespace string) ([]metav1.Owner
 }
 
 // getKuberhealthyPod fetches the podSpec
-func getKuberhealthyPod(ctx context.Context, client *kubernetes.Clientset, namespace, podName string) (*apiv1.Pod, error) {
+func getKuberhealthyPod(ctx context.Context, client kubernetes.Interface, namespace, podName string) (*apiv1.Pod, error) {
 	podClient := client.CoreV1().Pods(namespace)
 	kHealthyPod, err := podClient.Get(ctx, podName, metav1.GetOptions{})
 	if err != nil {
@@ -94,7 +94,7 @@ func GetInstanceNamespace(defaultNamespace string) string {
 }
 
 // PodNameExists determines if a pod with the specified name exists in the specified namespace.
-func PodNameExists(client *kubernetes.Clientset, podName string, namespace string) (bool, error) {
+func PodNameExists(client kubernetes.Interface, podName string, namespace string) (bool, error) {
 	// TODO: refactor function to receive context on exported function in next breaking change.
 	ctx := context.TODO()
 
@@ -134,7 +134,7 @@ func PodNameExists(client *kubernetes.Clientset, podName string, namespace strin
 }
 
 // PodKill waits a number of seconds determined by the user, then deletes the chosen pod in the namespace specified
-func PodKill(client *kubernetes.Clientset, podName string, namespace string, gracePeriod int64) error {
+func PodKill(client kubernetes.Interface, podName string, namespace string, gracePeriod int64) error {
 	// TODO: refactor function to receive context on exported function in next breaking change.
 	ctx := context.TODO()
 
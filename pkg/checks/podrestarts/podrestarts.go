@@ -0,0 +1,217 @@
+// Package podrestarts implements a checker for pods that are restarting too much.  Pods fail the check when they have
+// more `BackOff` events within a restart window than are allowed.
+package podrestarts
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultMaxFailuresAllowed is the number of `BackOff` events a pod may have before it fails the check
+const DefaultMaxFailuresAllowed = 10
+
+// DefaultTimeout is how long the check may run when no timeout is supplied
+const DefaultTimeout = 10 * time.Minute
+
+// DefaultRestartSamplesConfigMap is the name of the config map the `BackOff` event counts seen by each run are kept in
+const DefaultRestartSamplesConfigMap = "pod-restarts-check"
+
+// Checker represents a long running pod restart checker.
+type Checker struct {
+	Namespace           string
+	MaxFailuresAllowed  int32
+	RestartWindow       time.Duration
+	NamespaceThresholds map[string]RestartThreshold
+	ExcludedNamespaces  map[string]bool
+	ExcludedPodSelector labels.Selector
+	BadPods             map[string]string
+	Timeout             time.Duration
+	// RestartSamplesNamespace and RestartSamplesConfigMap locate the `BackOff` event counts seen by earlier runs,
+	// which restarts within a restart window are counted from
+	RestartSamplesNamespace string
+	RestartSamplesConfigMap string
+	client                  kubernetes.Interface
+	now                     func() time.Time
+}
+
+// NewPodRestartsChecker creates a pod restarts checker for the pods of a namespace, or of all namespaces when namespace
+// is blank, that must complete within timeout.  Any kubernetes.Interface can be supplied, which allows a fake clientset
+// to be used for testing.  The other settings of the checker start out with their defaults.
+func NewPodRestartsChecker(client kubernetes.Interface, namespace string, timeout time.Duration) *Checker {
+	return NewPodRestartsCheckerWithClock(client, namespace, timeout, time.Now)
+}
+
+// NewPodRestartsCheckerWithClock creates a pod restarts checker that takes the time restart windows end at from now
+func NewPodRestartsCheckerWithClock(client kubernetes.Interface, namespace string, timeout time.Duration, now func() time.Time) *Checker {
+	return &Checker{
+		Namespace:               namespace,
+		MaxFailuresAllowed:      DefaultMaxFailuresAllowed,
+		ExcludedPodSelector:     labels.Nothing(),
+		BadPods:                 make(map[string]string),
+		Timeout:                 timeout,
+		RestartSamplesConfigMap: DefaultRestartSamplesConfigMap,
+		client:                  client,
+		now:                     now,
+	}
+}
+
+// threshold returns the restart threshold of pods in a namespace
+func (prc *Checker) threshold(namespace string) RestartThreshold {
+	if threshold, ok := prc.NamespaceThresholds[namespace]; ok {
+		return threshold
+	}
+	return RestartThreshold{MaxFailuresAllowed: prc.MaxFailuresAllowed, Window: prc.RestartWindow}
+}
+
+// Check runs the checks in a goroutine and returns all error messages found.  If the checks do not complete before
+// the checker's timeout, a timeout error message is returned.
+func (prc *Checker) Check(ctx context.Context) []string {
+	log.Infoln("Running Pod Restarts checker")
+	doneChan := make(chan error, 1)
+
+	// run the check in a goroutine and notify the doneChan when completed
+	go func(doneChan chan error) {
+		err := prc.doChecks(ctx)
+		doneChan <- err
+	}(doneChan)
+
+	// wait for either a timeout or job completion
+	select {
+	case <-time.After(prc.Timeout):
+		// The check has timed out after its specified timeout period
+		return []string{"Failed to complete Pod Restart check in time! Timeout was reached."}
+	case err := <-doneChan:
+		var errorMessages []string
+		if err != nil {
+			log.Error(err)
+			errorMessages = append(errorMessages, err.Error())
+		}
+		for _, msg := range prc.BadPods {
+			errorMessages = append(errorMessages, msg)
+		}
+		return errorMessages
+	}
+}
+
+// restartWindows reports whether the restarts of pods in any namespace are counted within a restart window
+func (prc *Checker) restartWindows() bool {
+	if prc.RestartWindow > 0 {
+		return true
+	}
+	for _, threshold := range prc.NamespaceThresholds {
+		if threshold.Window > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// doChecks grabs all events in a given namespace, then checks for pods with event type "Warning" with reason "BackOff",
+// and more of these events within the restart window of their namespace than its MaxFailuresAllowed.  Pods in excluded
+// namespaces are skipped. If any of these pods are found, an error message is appended to Checker struct
+// errorMessages.  The event counts seen are kept for the next run to count the restarts within restart windows from.
+func (prc *Checker) doChecks(ctx context.Context) error {
+
+	log.Infoln("Checking for pod BackOff events for all pods in the namespace:", prc.Namespace)
+
+	podWarningEvents, err := prc.client.CoreV1().Events(prc.Namespace).List(ctx, metav1.ListOptions{FieldSelector: "type=Warning"})
+	if err != nil {
+		return err
+	}
+
+	// the event counts seen by earlier runs are only needed to count restarts within restart windows
+	var samples *restartSamples
+	if prc.restartWindows() && len(prc.RestartSamplesNamespace) != 0 {
+		samples, err = prc.loadRestartSamples(ctx)
+		if err != nil {
+			log.Warningln("Unable to get the `BackOff` event counts seen by earlier runs, restarts within restart windows are undercounted:", err)
+		}
+	}
+	observedSamples := make(map[string][]restartSample)
+	now := prc.now()
+
+	if len(podWarningEvents.Items) != 0 {
+		log.Infoln("Found `Warning` events in the namespace:", prc.Namespace)
+
+		for _, event := range podWarningEvents.Items {
+
+			if event.InvolvedObject.Kind != "Pod" || event.Reason != "BackOff" || prc.ExcludedNamespaces[event.InvolvedObject.Namespace] {
+				continue
+			}
+
+			// Checks for pods with more BackOff events within the restart window than the MaxFailuresAllowed
+			threshold := prc.threshold(event.InvolvedObject.Namespace)
+			eventKey := event.Namespace + "/" + event.Name
+			var eventSamples []restartSample
+			if samples != nil {
+				eventSamples = samples.samples[eventKey]
+			}
+			if threshold.Window > 0 {
+				observedSamples[eventKey] = addRestartSample(eventSamples, restartSample{Time: now, Count: event.Count}, threshold.Window)
+			}
+			restarts := restartsWithinWindow(event, eventSamples, now, threshold.Window)
+			if restarts > threshold.MaxFailuresAllowed {
+				errorMessage := "Found: " + strconv.FormatInt(int64(restarts), 10) + " `BackOff` events for pod: " + event.InvolvedObject.Name + " in namespace: " + event.Namespace
+				if threshold.Window > 0 {
+					errorMessage += " within the last " + threshold.Window.String()
+				}
+
+				log.Infoln(errorMessage)
+
+				// We could be checking for pods in all namespaces so prefix the namespace
+				prc.BadPods[event.InvolvedObject.Namespace+"/"+event.InvolvedObject.Name] = errorMessage
+			}
+		}
+	}
+
+	// events that no longer exist are dropped from the counts kept
+	if samples != nil {
+		err = prc.saveRestartSamples(ctx, samples, observedSamples)
+		if err != nil {
+			log.Warningln("Unable to keep the `BackOff` event counts seen for the next run:", err)
+		}
+	}
+
+	for pod := range prc.BadPods {
+		err := prc.verifyBadPodRestartExists(ctx, pod)
+		if err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// verifyBadPodRestartExists removes the bad pod found from the events list if the pod no longer exists or is selected
+// by the ExcludedPodSelector
+func (prc *Checker) verifyBadPodRestartExists(ctx context.Context, pod string) error {
+
+	// Pod is in the form namespace/pod_name
+	parts := strings.Split(pod, "/")
+	namespace := parts[0]
+	podName := parts[1]
+
+	p, err := prc.client.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err == nil && prc.ExcludedPodSelector != nil && prc.ExcludedPodSelector.Matches(labels.Set(p.Labels)) {
+		log.Infoln("Bad Pod:", podName, "is selected by the excluded pod selector. Removing from bad pods map")
+		delete(prc.BadPods, pod)
+		return nil
+	}
+	if err != nil {
+		if k8sErrors.IsNotFound(err) || strings.Contains(err.Error(), "not found") {
+			log.Infoln("Bad Pod:", podName, "no longer exists. Removing from bad pods map")
+			delete(prc.BadPods, pod)
+		} else {
+			log.Infoln("Error getting bad pod:", podName, err)
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package podrestarts
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func pod(podName string, containerName string, restartCount int32) *v1.Pod {
+
+	var pod = v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test-namespace",
+			Name:      podName,
+		},
+		Spec: v1.PodSpec{},
+		Status: v1.PodStatus{
+			Reason: "Ready",
+			ContainerStatuses: []v1.ContainerStatus{
+				{Name: containerName,
+					Ready:        true,
+					RestartCount: restartCount},
+			},
+		},
+	}
+
+	return &pod
+}
+
+func makeRestartObservationsMap(podName string, containerName string, restartCount int32) map[string]map[string]int32 {
+	firstMap := make(map[string]int32)
+	firstMap[containerName] = restartCount
+
+	restartObservationsMap := make(map[string]map[string]int32)
+	restartObservationsMap[podName] = firstMap
+
+	return restartObservationsMap
+}
+
+func backOffEvent(podName string, count int32) *v1.Event {
+	return &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "test-namespace",
+			Name:      podName + "-backoff",
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind:      "Pod",
+			Namespace: "test-namespace",
+			Name:      podName,
+		},
+		Reason: "BackOff",
+		Type:   v1.EventTypeWarning,
+		Count:  count,
+	}
+}
+
+func newTestChecker(objects ...runtime.Object) *Checker {
+	prc := NewPodRestartsChecker(fake.NewSimpleClientset(objects...), "test-namespace", time.Second*10)
+	prc.MaxFailuresAllowed = 5
+	return prc
+}
+
+func TestCheckPass(t *testing.T) {
+	prc := newTestChecker(pod("healthy-pod", "app", 0), backOffEvent("healthy-pod", 2))
+	errorMessages := prc.Check(context.Background())
+	if len(errorMessages) != 0 {
+		t.Fatal("expected no errors but got:", errorMessages)
+	}
+}
+
+func TestCheckFail(t *testing.T) {
+	prc := newTestChecker(pod("restarting-pod", "app", 20), backOffEvent("restarting-pod", 20))
+	errorMessages := prc.Check(context.Background())
+	if len(errorMessages) != 1 {
+		t.Fatal("expected one error but got:", errorMessages)
+	}
+	expected := "Found: 20 `BackOff` events for pod: restarting-pod in namespace: test-namespace"
+	if errorMessages[0] != expected {
+		t.Fatal("expected error", expected, "but got:", errorMessages[0])
+	}
+}
+
+func TestCheckRemovesDeletedPods(t *testing.T) {
+	// the event remains but the pod it refers to no longer exists
+	prc := newTestChecker(backOffEvent("deleted-pod", 20))
+	errorMessages := prc.Check(context.Background())
+	if len(errorMessages) != 0 {
+		t.Fatal("expected no errors for a pod that no longer exists but got:", errorMessages)
+	}
+	if len(prc.BadPods) != 0 {
+		t.Fatal("expected deleted pod to be removed from bad pods but got:", prc.BadPods)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	blockChan := make(chan struct{})
+	defer close(blockChan)
+	client.PrependReactor("list", "events", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		<-blockChan
+		return true, &v1.EventList{}, nil
+	})
+
+	prc := NewPodRestartsChecker(client, "", time.Millisecond*50)
+	errorMessages := prc.Check(context.Background())
+	if len(errorMessages) != 1 || !strings.Contains(errorMessages[0], "Timeout was reached") {
+		t.Fatal("expected a timeout error but got:", errorMessages)
+	}
+}
+
+func TestCheckRestartWindow(t *testing.T) {
+	now := time.Now()
+	recent := backOffEvent("recent-pod", 20)
+	recent.FirstTimestamp = metav1.NewTime(now.Add(-time.Minute * 30))
+	recent.LastTimestamp = metav1.NewTime(now.Add(-time.Minute * 10))
+	stale := backOffEvent("stale-pod", 20)
+	stale.FirstTimestamp = metav1.NewTime(now.Add(-time.Hour * 3))
+	stale.LastTimestamp = metav1.NewTime(now.Add(-time.Hour * 2))
+
+	// pods that stopped restarting before the window no longer fail the check
+	prc := newTestChecker(pod("recent-pod", "app", 20), pod("stale-pod", "app", 20), recent, stale)
+	prc.RestartWindow = time.Hour
+	prc.now = func() time.Time { return now }
+	errorMessages := prc.Check(context.Background())
+	if len(errorMessages) != 1 {
+		t.Fatal("expected one error but got:", errorMessages)
+	}
+	expected := "Found: 20 `BackOff` events for pod: recent-pod in namespace: test-namespace within the last 1h0m0s"
+	if errorMessages[0] != expected {
+		t.Fatal("expected error", expected, "but got:", errorMessages[0])
+	}
+}
+
+func TestCheckRestartWindowOldCount(t *testing.T) {
+	// the pod restarted many times days ago and only once within the window
+	now := time.Now().Truncate(time.Second)
+	event := backOffEvent("recovered-pod", 20)
+	event.FirstTimestamp = metav1.NewTime(now.Add(-time.Hour * 72))
+	event.LastTimestamp = metav1.NewTime(now.Add(-time.Minute * 5))
+	samples := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kuberhealthy", Name: DefaultRestartSamplesConfigMap},
+		Data:       map[string]string{restartSamplesKey: `{"test-namespace/recovered-pod-backoff":[{"time":"` + now.Add(-time.Hour*2).Format(time.RFC3339) + `","count":19}]}`},
+	}
+
+	prc := newTestChecker(pod("recovered-pod", "app", 20), event, samples)
+	prc.RestartWindow = time.Hour
+	prc.RestartSamplesNamespace = "kuberhealthy"
+	prc.now = func() time.Time { return now }
+	errorMessages := prc.Check(context.Background())
+	if len(errorMessages) != 0 {
+		t.Fatal("expected no errors for a pod with a single restart within the window but got:", errorMessages)
+	}
+}
+
+func TestCheckRestartWindowSamples(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	event := backOffEvent("restarting-pod", 30)
+	event.FirstTimestamp = metav1.NewTime(now.Add(-time.Hour * 72))
+	event.LastTimestamp = metav1.NewTime(now.Add(-time.Minute * 5))
+	samples := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "kuberhealthy", Name: "pod-restarts-restart-samples"},
+		Data: map[string]string{restartSamplesKey: `{"test-namespace/restarting-pod-backoff":[` +
+			`{"time":"` + now.Add(-time.Hour*3).Format(time.RFC3339) + `","count":10},` +
+			`{"time":"` + now.Add(-time.Minute*90).Format(time.RFC3339) + `","count":18},` +
+			`{"time":"` + now.Add(-time.Minute*30).Format(time.RFC3339) + `","count":25}],` +
+			`"test-namespace/deleted-pod-backoff":[{"time":"` + now.Add(-time.Minute*30).Format(time.RFC3339) + `","count":3}]}`},
+	}
+
+	// only the restarts since the newest count seen before the window are counted
+	prc := newTestChecker(pod("restarting-pod", "app", 30), event, samples)
+	prc.RestartWindow = time.Hour
+	prc.RestartSamplesNamespace = "kuberhealthy"
+	prc.RestartSamplesConfigMap = "pod-restarts-restart-samples"
+	prc.now = func() time.Time { return now }
+	errorMessages := prc.Check(context.Background())
+	if len(errorMessages) != 1 {
+		t.Fatal("expected one error but got:", errorMessages)
+	}
+	expected := "Found: 12 `BackOff` events for pod: restarting-pod in namespace: test-namespace within the last 1h0m0s"
+	if errorMessages[0] != expected {
+		t.Fatal("expected error", expected, "but got:", errorMessages[0])
+	}
+
+	// the counts seen are kept for the next run, without the ones no longer needed
+	configMap, err := prc.client.CoreV1().ConfigMaps("kuberhealthy").Get(context.Background(), "pod-restarts-restart-samples", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kept map[string][]restartSample
+	err = json.Unmarshal([]byte(configMap.Data[restartSamplesKey]), &kept)
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := kept["test-namespace/restarting-pod-backoff"]
+	if len(kept) != 1 || len(counts) != 3 || counts[0].Count != 18 || counts[2].Count != 30 || !counts[2].Time.Equal(now) {
+		t.Fatal("unexpected restart samples kept:", kept)
+	}
+}
+
+func TestCheckRestartWindowCreatesSamples(t *testing.T) {
+	now := time.Now()
+	event := backOffEvent("restarting-pod", 30)
+	event.FirstTimestamp = metav1.NewTime(now.Add(-time.Hour * 72))
+	event.LastTimestamp = metav1.NewTime(now.Add(-time.Minute * 5))
+
+	prc := newTestChecker(pod("restarting-pod", "app", 30), event)
+	prc.RestartWindow = time.Hour
+	prc.RestartSamplesNamespace = "kuberhealthy"
+	prc.now = func() time.Time { return now }
+	errorMessages := prc.Check(context.Background())
+
+	// without earlier counts there is no baseline, so every BackOff event of the pod counts
+	expected := "Found: 30 `BackOff` events for pod: restarting-pod in namespace: test-namespace within the last 1h0m0s"
+	if len(errorMessages) != 1 || errorMessages[0] != expected {
+		t.Fatal("expected error", expected, "without earlier counts but got:", errorMessages)
+	}
+	configMap, err := prc.client.CoreV1().ConfigMaps("kuberhealthy").Get(context.Background(), DefaultRestartSamplesConfigMap, metav1.GetOptions{})
+	if err != nil {
+		t.Fatal("expected the counts seen to be kept but got:", err)
+	}
+	if !strings.Contains(configMap.Data[restartSamplesKey], `"count":30`) {
+		t.Fatal("unexpected restart samples kept:", configMap.Data[restartSamplesKey])
+	}
+}
+
+func TestCheckNamespaceThresholds(t *testing.T) {
+	prc := newTestChecker(pod("batch-pod", "app", 20), backOffEvent("batch-pod", 20))
+	prc.NamespaceThresholds = map[string]RestartThreshold{"test-namespace": {MaxFailuresAllowed: 50}}
+	errorMessages := prc.Check(context.Background())
+	if len(errorMessages) != 0 {
+		t.Fatal("expected no errors below the namespace threshold but got:", errorMessages)
+	}
+
+	prc = newTestChecker(pod("batch-pod", "app", 20), backOffEvent("batch-pod", 20))
+	prc.NamespaceThresholds = map[string]RestartThreshold{"other-namespace": {MaxFailuresAllowed: 50}}
+	errorMessages = prc.Check(context.Background())
+	if len(errorMessages) != 1 {
+		t.Fatal("expected the default threshold in namespaces without their own but got:", errorMessages)
+	}
+}
+
+func TestCheckExclusions(t *testing.T) {
+	prc := newTestChecker(pod("batch-pod", "app", 20), backOffEvent("batch-pod", 20))
+	prc.ExcludedNamespaces = map[string]bool{"test-namespace": true}
+	errorMessages := prc.Check(context.Background())
+	if len(errorMessages) != 0 {
+		t.Fatal("expected no errors for pods in excluded namespaces but got:", errorMessages)
+	}
+
+	batchPod := pod("batch-pod", "app", 20)
+	batchPod.Labels = map[string]string{"workload": "batch"}
+	prc = newTestChecker(batchPod, pod("restarting-pod", "app", 20), backOffEvent("batch-pod", 20), backOffEvent("restarting-pod", 20))
+	prc.ExcludedPodSelector, _ = labels.Parse("workload=batch")
+	errorMessages = prc.Check(context.Background())
+	if len(errorMessages) != 1 || !strings.Contains(errorMessages[0], "restarting-pod") {
+		t.Fatal("expected only the pod not selected by the excluded pod selector to fail but got:", errorMessages)
+	}
+}
@@ -0,0 +1,127 @@
+package podrestarts
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// restartSamplesKey is the config map key the `BackOff` event counts seen by earlier runs of the check are kept under
+const restartSamplesKey = "restartSamples"
+
+// restartSample is the count of a `BackOff` event seen by a run of the check
+type restartSample struct {
+	Time  time.Time `json:"time"`
+	Count int32     `json:"count"`
+}
+
+// restartSamples are the counts of `BackOff` events seen by runs of the check, oldest first, by event namespace/name.
+// They are kept in a config map between runs so that only the restarts within a restart window are counted.
+type restartSamples struct {
+	configMap *v1.ConfigMap
+	samples   map[string][]restartSample
+}
+
+// loadRestartSamples gets the `BackOff` event counts seen by earlier runs of the check
+func (prc *Checker) loadRestartSamples(ctx context.Context) (*restartSamples, error) {
+	rs := &restartSamples{samples: make(map[string][]restartSample)}
+	configMap, err := prc.client.CoreV1().ConfigMaps(prc.RestartSamplesNamespace).Get(ctx, prc.RestartSamplesConfigMap, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return rs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rs.configMap = configMap
+	if len(configMap.Data[restartSamplesKey]) != 0 {
+		err = json.Unmarshal([]byte(configMap.Data[restartSamplesKey]), &rs.samples)
+		if err != nil {
+			log.Warningln("Ignoring the unparsable restart samples in config map", prc.RestartSamplesConfigMap+":", err)
+			rs.samples = make(map[string][]restartSample)
+		}
+	}
+	return rs, nil
+}
+
+// saveRestartSamples replaces the `BackOff` event counts kept for the next runs of the check
+func (prc *Checker) saveRestartSamples(ctx context.Context, rs *restartSamples, samples map[string][]restartSample) error {
+	data, err := json.Marshal(samples)
+	if err != nil {
+		return err
+	}
+
+	if rs.configMap == nil {
+		_, err = prc.client.CoreV1().ConfigMaps(prc.RestartSamplesNamespace).Create(ctx, &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      prc.RestartSamplesConfigMap,
+				Namespace: prc.RestartSamplesNamespace,
+			},
+			Data: map[string]string{restartSamplesKey: string(data)},
+		}, metav1.CreateOptions{})
+		return err
+	}
+
+	configMap := rs.configMap.DeepCopy()
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[restartSamplesKey] = string(data)
+	_, err = prc.client.CoreV1().ConfigMaps(prc.RestartSamplesNamespace).Update(ctx, configMap, metav1.UpdateOptions{})
+	return err
+}
+
+// addRestartSample adds the count of an event seen now to its samples, and drops the samples no longer needed to count
+// the restarts within the window.  The newest sample from before the window is kept as the baseline of the count.
+func addRestartSample(samples []restartSample, sample restartSample, window time.Duration) []restartSample {
+	if len(samples) == 0 || samples[len(samples)-1].Count != sample.Count {
+		samples = append(samples, sample)
+	}
+	start := sample.Time.Add(-window)
+	for len(samples) > 1 && !samples[1].Time.After(start) {
+		samples = samples[1:]
+	}
+	return samples
+}
+
+// restartsWithinWindow counts the `BackOff` events of a pod within the window before now.  Events first seen within the
+// window count in full.  Otherwise, the count is the increase since the newest sample from before the window.  Without
+// such a sample, the increase since the oldest sample is a lower bound of the count.  An event without any samples,
+// such as on the first run of the check, has no baseline and counts in full, so that restarting pods are not missed.
+// Every `BackOff` event counts when the window is zero.
+func restartsWithinWindow(event v1.Event, samples []restartSample, now time.Time, window time.Duration) int32 {
+	if window == 0 {
+		return event.Count
+	}
+	start := now.Add(-window)
+	if eventLastSeen(event).Before(start) {
+		return 0
+	}
+	firstSeen := eventFirstSeen(event)
+	if !firstSeen.IsZero() && !firstSeen.Before(start) {
+		return event.Count
+	}
+	if len(samples) == 0 {
+		return event.Count
+	}
+
+	baseline := samples[0]
+	for _, sample := range samples {
+		if sample.Time.After(start) {
+			break
+		}
+		baseline = sample
+	}
+	// the event was replaced since the baseline was seen
+	if event.Count < baseline.Count {
+		return event.Count
+	}
+	if event.Count == baseline.Count {
+		return 1
+	}
+	return event.Count - baseline.Count
+}
@@ -0,0 +1,40 @@
+package podrestarts
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRestartsWithinWindow(t *testing.T) {
+	now := time.Now()
+	event := *backOffEvent("restarting-pod", 30)
+	event.FirstTimestamp = metav1.NewTime(now.Add(-time.Hour * 72))
+	event.LastTimestamp = metav1.NewTime(now.Add(-time.Minute * 5))
+
+	tests := []struct {
+		name     string
+		samples  []restartSample
+		window   time.Duration
+		expected int32
+	}{
+		{name: "no window", window: 0, expected: 30},
+		{name: "no samples", window: time.Hour, expected: 30},
+		{name: "baseline before the window", samples: []restartSample{{Time: now.Add(-time.Hour * 2), Count: 20}, {Time: now.Add(-time.Minute * 10), Count: 28}}, window: time.Hour, expected: 10},
+		{name: "only samples within the window", samples: []restartSample{{Time: now.Add(-time.Minute * 30), Count: 26}}, window: time.Hour, expected: 4},
+		{name: "unchanged since the baseline", samples: []restartSample{{Time: now.Add(-time.Minute * 30), Count: 30}}, window: time.Hour, expected: 1},
+		{name: "replaced event", samples: []restartSample{{Time: now.Add(-time.Hour * 2), Count: 40}}, window: time.Hour, expected: 30},
+	}
+	for _, test := range tests {
+		restarts := restartsWithinWindow(event, test.samples, now, test.window)
+		if restarts != test.expected {
+			t.Fatal(test.name+": expected", test.expected, "restarts but got:", restarts)
+		}
+	}
+
+	event.LastTimestamp = metav1.NewTime(now.Add(-time.Hour * 2))
+	if restarts := restartsWithinWindow(event, nil, now, time.Hour); restarts != 0 {
+		t.Fatal("expected no restarts for an event last seen before the window but got:", restarts)
+	}
+}
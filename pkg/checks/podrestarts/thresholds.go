@@ -0,0 +1,102 @@
+package podrestarts
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// RestartThreshold is how many `BackOff` events a pod may have within a window before it fails the check.  A window
+// of zero counts every `BackOff` event still kept by the API server.
+type RestartThreshold struct {
+	MaxFailuresAllowed int32
+	Window             time.Duration
+}
+
+// String describes the threshold for log output, such as "10 within 1h0m0s"
+func (t RestartThreshold) String() string {
+	if t.Window == 0 {
+		return strconv.Itoa(int(t.MaxFailuresAllowed))
+	}
+	return strconv.Itoa(int(t.MaxFailuresAllowed)) + " within " + t.Window.String()
+}
+
+// ParseNamespaceThresholds parses a comma separated list of namespace=restarts or namespace=restarts/window entries,
+// such as kube-system=3/30m,batch=50, into the restart threshold of each namespace.  Entries without a window use the
+// window of the supplied default threshold.
+func ParseNamespaceThresholds(s string, defaultThreshold RestartThreshold) (map[string]RestartThreshold, error) {
+	thresholds := make(map[string]RestartThreshold)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+		namespaceThreshold := strings.SplitN(entry, "=", 2)
+		if len(namespaceThreshold) != 2 || len(namespaceThreshold[0]) == 0 {
+			return nil, errors.New("unable to parse namespace threshold " + entry + ": expected namespace=restarts or namespace=restarts/window")
+		}
+
+		threshold := RestartThreshold{Window: defaultThreshold.Window}
+		restartsWindow := strings.SplitN(namespaceThreshold[1], "/", 2)
+		restarts, err := strconv.ParseInt(restartsWindow[0], 10, 32)
+		if err != nil || restarts < 0 {
+			return nil, errors.New("unable to parse the restarts of namespace threshold " + entry + ": expected a count that is zero or more")
+		}
+		threshold.MaxFailuresAllowed = int32(restarts)
+		if len(restartsWindow) == 2 {
+			threshold.Window, err = ParseWindow(restartsWindow[1])
+			if err != nil {
+				return nil, errors.New("unable to parse the window of namespace threshold " + entry + ": " + err.Error())
+			}
+		}
+		thresholds[namespaceThreshold[0]] = threshold
+	}
+	return thresholds, nil
+}
+
+// ParseWindow parses the window restarts are counted within, such as 1h
+func ParseWindow(s string) (time.Duration, error) {
+	window, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if window < 0 {
+		return 0, errors.New("window " + s + " is negative")
+	}
+	return window, nil
+}
+
+// ParseNamespaces parses a comma separated list of namespaces
+func ParseNamespaces(s string) map[string]bool {
+	namespaces := make(map[string]bool)
+	for _, namespace := range strings.Split(s, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if len(namespace) != 0 {
+			namespaces[namespace] = true
+		}
+	}
+	return namespaces
+}
+
+// eventLastSeen returns when an event last occurred.  Events reported through the events.k8s.io API only set their
+// event time or the last observed time of their series.
+func eventLastSeen(event v1.Event) time.Time {
+	if event.Series != nil && !event.Series.LastObservedTime.IsZero() {
+		return event.Series.LastObservedTime.Time
+	}
+	if !event.LastTimestamp.IsZero() {
+		return event.LastTimestamp.Time
+	}
+	return event.EventTime.Time
+}
+
+// eventFirstSeen returns when an event first occurred, or the zero time if it is not known
+func eventFirstSeen(event v1.Event) time.Time {
+	if !event.FirstTimestamp.IsZero() {
+		return event.FirstTimestamp.Time
+	}
+	return event.EventTime.Time
+}
@@ -0,0 +1,50 @@
+package podrestarts
+
+import (
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseNamespaceThresholds(t *testing.T) {
+	defaultThreshold := RestartThreshold{MaxFailuresAllowed: 10, Window: time.Hour}
+	thresholds, err := ParseNamespaceThresholds("kube-system=3/30m, batch=50,nightly=0/0s,", defaultThreshold)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(thresholds) != 3 {
+		t.Fatal("expected three namespace thresholds but got:", thresholds)
+	}
+	if thresholds["kube-system"] != (RestartThreshold{MaxFailuresAllowed: 3, Window: time.Minute * 30}) {
+		t.Fatal("unexpected kube-system threshold:", thresholds["kube-system"])
+	}
+	if thresholds["batch"] != (RestartThreshold{MaxFailuresAllowed: 50, Window: time.Hour}) {
+		t.Fatal("expected the batch threshold to keep the default window but got:", thresholds["batch"])
+	}
+	if thresholds["nightly"] != (RestartThreshold{}) {
+		t.Fatal("unexpected nightly threshold:", thresholds["nightly"])
+	}
+
+	for _, invalid := range []string{"kube-system", "=3", "kube-system=many", "kube-system=-1", "kube-system=3/soon", "kube-system=3/-1h"} {
+		_, err = ParseNamespaceThresholds(invalid, defaultThreshold)
+		if err == nil {
+			t.Fatal("expected an error parsing namespace threshold:", invalid)
+		}
+	}
+}
+
+func TestEventLastSeen(t *testing.T) {
+	lastTimestamp := time.Now().Add(-time.Hour).Truncate(time.Second)
+	lastObserved := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	event := v1.Event{LastTimestamp: metav1.NewTime(lastTimestamp)}
+	if !eventLastSeen(event).Equal(lastTimestamp) {
+		t.Fatal("expected the last timestamp of the event but got:", eventLastSeen(event))
+	}
+	event.Series = &v1.EventSeries{LastObservedTime: metav1.NewMicroTime(lastObserved)}
+	if !eventLastSeen(event).Equal(lastObserved) {
+		t.Fatal("expected the last observed time of the event series but got:", eventLastSeen(event))
+	}
+}
@@ -0,0 +1,82 @@
+// Package podstatus implements a pod health checker for Kuberhealthy.  Pods are checked to ensure they are in a
+// healthy lifecycle phase.
+package podstatus
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Checker finds the pods of a namespace that are in an unhealthy lifecycle phase
+type Checker struct {
+	client       kubernetes.Interface
+	namespace    string
+	skipDuration time.Duration
+	now          func() time.Time
+}
+
+// NewPodStatusChecker creates a pod status checker for the pods of a namespace, or of all namespaces when namespace is
+// blank.  Any kubernetes.Interface can be supplied, which allows a fake clientset to be used for testing.  Pods
+// younger than skipDuration are not checked.
+func NewPodStatusChecker(client kubernetes.Interface, namespace string, skipDuration time.Duration) *Checker {
+	return NewPodStatusCheckerWithClock(client, namespace, skipDuration, time.Now)
+}
+
+// NewPodStatusCheckerWithClock creates a pod status checker that takes the time pod ages are measured from from now
+func NewPodStatusCheckerWithClock(client kubernetes.Interface, namespace string, skipDuration time.Duration, now func() time.Time) *Checker {
+	return &Checker{
+		client:       client,
+		namespace:    namespace,
+		skipDuration: skipDuration,
+		now:          now,
+	}
+}
+
+// FindPodsNotRunning finds pods that are older than the skip duration and are in an unhealthy lifecycle phase
+func (c *Checker) FindPodsNotRunning(ctx context.Context) ([]string, error) {
+
+	var failures []string
+
+	pods, err := c.client.CoreV1().Pods(c.namespace).List(ctx, metav1.ListOptions{LabelSelector: "app!=kuberhealthy-check,source!=kuberhealthy"})
+	if err != nil {
+		return failures, err
+	}
+
+	checkTime := c.now()
+	skipBarrier := checkTime.Add(-c.skipDuration)
+
+	// start iteration over pods
+	for _, pod := range pods.Items {
+		// check if the pod age is over the skip duration
+		if pod.CreationTimestamp.Time.After(skipBarrier) {
+			log.Println("skipping checks on pod because it is too young:", pod.Name)
+			continue
+		}
+
+		// pods that are in phase Running/Succeeded are healthy
+		// pods that are in phase Pending/Failed/Unknown are unhealthy and added to our list of failed pods
+		// log if there is no match to the 5 possible pod status phases
+		switch {
+		case pod.Status.Phase == v1.PodRunning:
+			continue
+		case pod.Status.Phase == v1.PodSucceeded:
+			continue
+		case pod.Status.Phase == v1.PodPending:
+			failures = append(failures, "pod: "+pod.Name+" in namespace: "+pod.Namespace+" is in pod status phase "+string(pod.Status.Phase)+" ")
+		case pod.Status.Phase == v1.PodFailed:
+			failures = append(failures, "pod: "+pod.Name+" in namespace: "+pod.Namespace+" is in pod status phase "+string(pod.Status.Phase)+" ")
+		case pod.Status.Phase == v1.PodUnknown:
+			failures = append(failures, "pod: "+pod.Name+" in namespace: "+pod.Namespace+" is in pod status phase "+string(pod.Status.Phase)+" ")
+		default:
+			log.Info("pod: " + pod.Name + " in namespace: " + pod.Namespace + " is not in one of the five possible pod status phases " + string(pod.Status.Phase) + " ")
+		}
+	}
+
+	return failures, nil
+
+}
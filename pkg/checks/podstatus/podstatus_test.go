@@ -0,0 +1,131 @@
+package podstatus
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestFindPodsNotRunning(t *testing.T) {
+	objects := getTestPods()
+
+	type fields struct {
+		objects   []runtime.Object
+		namespace string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		want    []string
+		wantErr bool
+	}{
+		{name: "namespace_without_pods", fields: fields{
+			namespace: "empty",
+		}, want: nil, wantErr: false},
+		{name: "single_namespace", fields: fields{
+			namespace: "foo",
+		}, want: []string{"pod: foo-pod in namespace: foo is in pod status phase Pending "}, wantErr: false},
+		{name: "multi_namespace", fields: fields{
+			namespace: "",
+		}, want: []string{"pod: bar-pod in namespace: bar is in pod status phase Pending ", "pod: foo-pod in namespace: foo is in pod status phase Pending "}, wantErr: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			client := fake.NewSimpleClientset(objects...)
+			checker := NewPodStatusChecker(client, tt.fields.namespace, time.Minute*10)
+			got, err := checker.FindPodsNotRunning(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("findErrors() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findErrors() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+}
+
+func TestFindPodsNotRunningSkipsYoungPods(t *testing.T) {
+	checkTime := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	young := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "young-pod",
+			Namespace:         "foo",
+			CreationTimestamp: metav1.NewTime(checkTime.Add(-time.Minute)),
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodFailed,
+		},
+	}
+	old := young.DeepCopy()
+	old.Name = "old-pod"
+	old.CreationTimestamp = metav1.NewTime(checkTime.Add(-time.Hour))
+
+	checker := NewPodStatusCheckerWithClock(fake.NewSimpleClientset(young, old), "foo", time.Minute*10, func() time.Time { return checkTime })
+
+	got, err := checker.FindPodsNotRunning(context.Background())
+	if err != nil {
+		t.Fatal("unexpected error:", err)
+	}
+	want := []string{"pod: old-pod in namespace: foo is in pod status phase Failed "}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindPodsNotRunning() got = %v, want %v", got, want)
+	}
+}
+
+func TestFindPodsNotRunningListError(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("api server unavailable")
+	})
+
+	checker := NewPodStatusChecker(client, "", time.Minute*10)
+	_, err := checker.FindPodsNotRunning(context.Background())
+	if err == nil {
+		t.Fatal("expected an error when listing pods fails")
+	}
+}
+
+func getTestPods() []runtime.Object {
+
+	return []runtime.Object{
+		&v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "foo",
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo-pod",
+				Namespace: "foo",
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodPending,
+			},
+		},
+		&v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "bar",
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "bar-pod",
+				Namespace: "bar",
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodPending,
+			},
+		},
+	}
+}
@@ -0,0 +1,195 @@
+// Package cloudevents delivers CloudEvents (spec v1.0, structured JSON encoding) to an HTTP event sink
+package cloudevents // import "github.com/kuberhealthy/kuberhealthy/v2/pkg/cloudevents"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SpecVersion is the version of the CloudEvents specification events are sent with
+const SpecVersion = "1.0"
+
+// ContentType is the content type of events sent in the structured JSON encoding
+const ContentType = "application/cloudevents+json"
+
+// DefaultQueueSize is the number of events that can wait for delivery before new events are dropped
+const DefaultQueueSize = 100
+
+// DefaultMaxRetries is the number of times delivery of an event is retried before it is dropped
+const DefaultMaxRetries = 3
+
+// DefaultRetryDelay is the delay before the first retry of an event.  The delay doubles with each retry.
+const DefaultRetryDelay = time.Second
+
+// DefaultTimeout is the timeout of each delivery attempt
+const DefaultTimeout = time.Second * 10
+
+// Event is a CloudEvent in the structured JSON encoding
+type Event struct {
+	SpecVersion     string      `json:"specversion"`
+	ID              string      `json:"id"`
+	Source          string      `json:"source"`
+	Type            string      `json:"type"`
+	Subject         string      `json:"subject,omitempty"`
+	Time            time.Time   `json:"time"`
+	DataContentType string      `json:"datacontenttype"`
+	Data            interface{} `json:"data"`
+}
+
+// NewEvent creates an event of the supplied type and source with JSON data
+func NewEvent(id string, source string, eventType string, subject string, data interface{}) Event {
+	return Event{
+		SpecVersion:     SpecVersion,
+		ID:              id,
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC(),
+		DataContentType: "application/json",
+		Data:            data,
+	}
+}
+
+// SinkConfig configures the delivery of events to a sink
+type SinkConfig struct {
+	URL        string                                // the URL events are posted to
+	Headers    map[string]string                     // extra headers sent with every event, such as authorization
+	QueueSize  int                                   // the number of events that can wait for delivery
+	MaxRetries int                                   // the number of times a failed delivery is retried
+	RetryDelay time.Duration                         // the delay before the first retry, doubled with each retry
+	Timeout    time.Duration                         // the timeout of each delivery attempt
+	Proxy      func(*http.Request) (*url.URL, error) // the proxy deliveries are sent through. nil uses the proxy environment variables
+}
+
+// Sink delivers events to an HTTP endpoint in the background.  Events that can not be queued or delivered are
+// dropped and counted.
+type Sink struct {
+	config  SinkConfig
+	client  *http.Client
+	queue   chan Event
+	dropped uint64
+}
+
+// NewSink creates a sink that delivers events to the configured URL.  Unset settings use their defaults.  Events are
+// queued until the sink is started.
+func NewSink(config SinkConfig) *Sink {
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = DefaultMaxRetries
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = DefaultRetryDelay
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	return &Sink{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout, Transport: proxyTransport(config.Proxy)},
+		queue:  make(chan Event, config.QueueSize),
+	}
+}
+
+// Send queues an event for delivery without blocking.  The event is dropped if the queue is full.
+func (s *Sink) Send(e Event) {
+	select {
+	case s.queue <- e:
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+		log.Warningln("cloudevents: dropping event", e.Type, "for", e.Subject, "because the delivery queue is full")
+	}
+}
+
+// Dropped returns the number of events that were dropped since the sink was created
+func (s *Sink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Start delivers queued events until the context is canceled
+func (s *Sink) Start(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-s.queue:
+			err := s.deliverWithRetry(ctx, e)
+			if err != nil {
+				atomic.AddUint64(&s.dropped, 1)
+				log.Errorln("cloudevents: dropping event", e.Type, "for", e.Subject+":", err)
+			}
+		}
+	}
+}
+
+// deliverWithRetry delivers an event and retries failed deliveries with an exponential backoff
+func (s *Sink) deliverWithRetry(ctx context.Context, e Event) error {
+	delay := s.config.RetryDelay
+	var err error
+	for try := 0; try <= s.config.MaxRetries; try++ {
+		if try > 0 {
+			log.Debugln("cloudevents: retrying delivery of event", e.ID, "in", delay.String()+":", err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay = delay + delay
+		}
+		err = s.deliver(ctx, e)
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to deliver event after %d retries: %w", s.config.MaxRetries, err)
+}
+
+// deliver posts an event to the sink
+func (s *Sink) deliver(ctx context.Context, e Event) error {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", ContentType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("sink responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// proxyTransport returns the transport of the HTTP client, which sends requests through the supplied proxy.  The
+// default transport, which uses the proxy environment variables, is returned when the proxy is nil.
+func proxyTransport(proxy func(*http.Request) (*url.URL, error)) http.RoundTripper {
+	if proxy == nil {
+		return http.DefaultTransport
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	return transport
+}
@@ -0,0 +1,86 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestSinkDelivers ensures events are posted in the structured JSON encoding with the configured headers
+func TestSinkDelivers(t *testing.T) {
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != ContentType {
+			t.Error("unexpected content type:", r.Header.Get("Content-Type"))
+		}
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Error("expected configured header on request but got", r.Header.Get("Authorization"))
+		}
+		var e Event
+		err := json.NewDecoder(r.Body).Decode(&e)
+		if err != nil {
+			t.Error("failed to decode event:", err)
+		}
+		received <- e
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sink := NewSink(SinkConfig{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer token"}})
+	go sink.Start(ctx)
+
+	sink.Send(NewEvent("1234", "test-cluster", "io.kuberhealthy.check.failed", "kuberhealthy/dns", map[string]bool{"OK": false}))
+
+	select {
+	case e := <-received:
+		if e.SpecVersion != SpecVersion || e.ID != "1234" || e.Source != "test-cluster" || e.Type != "io.kuberhealthy.check.failed" || e.Subject != "kuberhealthy/dns" {
+			t.Fatal("unexpected event received:", e)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for event delivery")
+	}
+}
+
+// TestSinkRetriesAndDrops ensures failed deliveries are retried a bounded number of times before the event is
+// dropped and counted
+func TestSinkRetriesAndDrops(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sink := NewSink(SinkConfig{URL: server.URL, MaxRetries: 2, RetryDelay: time.Millisecond})
+	go sink.Start(ctx)
+
+	sink.Send(NewEvent("1234", "test-cluster", "io.kuberhealthy.check.failed", "kuberhealthy/dns", nil))
+
+	deadline := time.Now().Add(time.Second * 5)
+	for sink.Dropped() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for event to be dropped")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	if atomic.LoadInt32(&attempts) != 3 {
+		t.Fatal("expected three delivery attempts but got", atomic.LoadInt32(&attempts))
+	}
+}
+
+// TestSinkDropsWhenQueueFull ensures events are dropped instead of blocking when the queue is full
+func TestSinkDropsWhenQueueFull(t *testing.T) {
+	sink := NewSink(SinkConfig{URL: "http://127.0.0.1:0", QueueSize: 1})
+	sink.Send(NewEvent("1", "test-cluster", "io.kuberhealthy.check.run", "kuberhealthy/dns", nil))
+	sink.Send(NewEvent("2", "test-cluster", "io.kuberhealthy.check.run", "kuberhealthy/dns", nil))
+	if sink.Dropped() != 1 {
+		t.Fatal("expected one dropped event but got", sink.Dropped())
+	}
+}
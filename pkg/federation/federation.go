@@ -0,0 +1,204 @@
+// Package federation polls the status pages of Kuberhealthy instances in other clusters and aggregates them
+package federation // import "github.com/kuberhealthy/kuberhealthy/v2/pkg/federation"
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// maxStatusBytes is the largest status page read from a cluster
+const maxStatusBytes = 64 << 20
+
+// Cluster configures how the status page of the Kuberhealthy instance of a cluster is fetched
+type Cluster struct {
+	Name               string            `yaml:"name"`                         // the name the cluster is shown under
+	URL                string            `yaml:"url"`                          // the URL of the status page of its Kuberhealthy
+	Headers            map[string]string `yaml:"headers,omitempty"`            // headers sent with every request, such as authorization
+	BearerTokenFile    string            `yaml:"bearerTokenFile,omitempty"`    // a file holding a bearer token sent with every request. read on every poll, so rotated tokens are picked up
+	CAFile             string            `yaml:"caFile,omitempty"`             // a CA bundle the TLS certificate of the URL is verified with instead of the system roots
+	InsecureSkipVerify bool              `yaml:"insecureSkipVerify,omitempty"` // skip verifying the TLS certificate of the URL
+}
+
+// Validate ensures the cluster has a name and an http or https URL
+func (c Cluster) Validate() error {
+	if len(c.Name) == 0 {
+		return errors.New("a name is required")
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+		return fmt.Errorf("cluster %s: url %q must be an http or https URL", c.Name, c.URL)
+	}
+	return nil
+}
+
+// ClusterStatus is the last polled status of a cluster
+type ClusterStatus struct {
+	URL         string
+	Reachable   bool          // true when the last poll fetched the status page
+	OK          bool          // true when the cluster is reachable and its status page is OK
+	Error       string        `json:",omitempty"` // why the last poll failed
+	LastPoll    time.Time     // when the cluster was last polled
+	LastSuccess *time.Time    `json:",omitempty"` // when the status page was last fetched
+	Status      *health.State `json:",omitempty"` // the last fetched status page. kept while the cluster is unreachable
+}
+
+// Status is the aggregated status of all clusters
+type Status struct {
+	OK       bool                     // true when every cluster is reachable and OK
+	Errors   []string                 // an error for each cluster that is unreachable or not OK
+	Clusters map[string]ClusterStatus // the status of each cluster by name
+}
+
+// Poller polls the status pages of clusters and keeps their last status
+type Poller struct {
+	Proxy    func(*http.Request) (*url.URL, error) // the proxy status pages are fetched through. nil uses the proxy environment variables
+	mu       sync.RWMutex
+	statuses map[string]ClusterStatus
+}
+
+// NewPoller creates a Poller without any polled clusters
+func NewPoller() *Poller {
+	return &Poller{statuses: make(map[string]ClusterStatus)}
+}
+
+// Poll fetches the status page of every cluster concurrently and records the results.  The results of clusters that
+// are no longer listed are dropped.  Each request is bound by the context.
+func (p *Poller) Poll(ctx context.Context, clusters []Cluster) {
+	results := make([]ClusterStatus, len(clusters))
+	var wg sync.WaitGroup
+	for i, c := range clusters {
+		wg.Add(1)
+		go func(i int, c Cluster) {
+			defer wg.Done()
+			now := time.Now()
+			results[i] = ClusterStatus{URL: c.URL, LastPoll: now}
+			state, err := fetch(ctx, c, p.Proxy)
+			if err != nil {
+				results[i].Error = err.Error()
+				return
+			}
+			results[i].Reachable = true
+			results[i].OK = state.OK
+			results[i].LastSuccess = &now
+			results[i].Status = &state
+		}(i, c)
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	statuses := make(map[string]ClusterStatus, len(clusters))
+	for i, c := range clusters {
+		result := results[i]
+		previous, polled := p.statuses[c.Name]
+		if !result.Reachable && polled && previous.URL == c.URL {
+			result.LastSuccess = previous.LastSuccess
+			result.Status = previous.Status
+		}
+		statuses[c.Name] = result
+	}
+	p.statuses = statuses
+}
+
+// Status returns the aggregated status of the polled clusters
+func (p *Poller) Status() Status {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	status := Status{OK: true, Errors: []string{}, Clusters: make(map[string]ClusterStatus, len(p.statuses))}
+	names := make([]string, 0, len(p.statuses))
+	for name, cs := range p.statuses {
+		status.Clusters[name] = cs
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cs := p.statuses[name]
+		switch {
+		case !cs.Reachable:
+			status.OK = false
+			status.Errors = append(status.Errors, name+": "+cs.Error)
+		case !cs.OK:
+			status.OK = false
+			status.Errors = append(status.Errors, name+": "+strings.Join(cs.Status.Errors, "; "))
+		}
+	}
+	return status
+}
+
+// fetch requests the status page of a cluster through the supplied proxy
+func fetch(ctx context.Context, c Cluster, proxy func(*http.Request) (*url.URL, error)) (health.State, error) {
+	var state health.State
+	client, err := newHTTPClient(c, proxy)
+	if err != nil {
+		return state, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+	if err != nil {
+		return state, fmt.Errorf("failed to create status request: %w", err)
+	}
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+	if len(c.BearerTokenFile) > 0 {
+		token, err := os.ReadFile(c.BearerTokenFile)
+		if err != nil {
+			return state, fmt.Errorf("failed to read bearer token file: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return state, fmt.Errorf("failed to fetch status from %s: %w", c.URL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxStatusBytes))
+	if err != nil {
+		return state, fmt.Errorf("failed to read status from %s: %w", c.URL, err)
+	}
+
+	// a failing Kuberhealthy serves its status page with a 500 status code
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusInternalServerError {
+		return state, fmt.Errorf("%s responded with status %s", c.URL, resp.Status)
+	}
+	err = json.Unmarshal(body, &state)
+	if err != nil {
+		return state, fmt.Errorf("failed to parse status from %s: %w", c.URL, err)
+	}
+	return state, nil
+}
+
+// newHTTPClient creates the client the status page of a cluster is fetched with through the supplied proxy
+func newHTTPClient(c Cluster, proxy func(*http.Request) (*url.URL, error)) (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.InsecureSkipVerify}
+	if len(c.CAFile) > 0 {
+		pem, err := os.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA file %s does not contain a PEM certificate", c.CAFile)
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if proxy != nil {
+		transport.Proxy = proxy
+	}
+	return &http.Client{Transport: transport}, nil
+}
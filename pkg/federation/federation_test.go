@@ -0,0 +1,98 @@
+package federation
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
+)
+
+// TestClusterValidate ensures clusters need a name and an http or https URL
+func TestClusterValidate(t *testing.T) {
+	valid := Cluster{Name: "east", URL: "https://kuberhealthy.east.example.com/"}
+	if err := valid.Validate(); err != nil {
+		t.Fatal("expected a valid cluster but got", err)
+	}
+	for _, c := range []Cluster{
+		{URL: "https://kuberhealthy.east.example.com/"},
+		{Name: "east"},
+		{Name: "east", URL: "ftp://kuberhealthy.east.example.com/"},
+		{Name: "east", URL: "https://"},
+	} {
+		if err := c.Validate(); err == nil {
+			t.Fatal("expected an invalid cluster:", c)
+		}
+	}
+}
+
+// TestPoll ensures status pages are fetched with the bearer token, that failing clusters are reported and that an
+// unreachable cluster keeps its last status
+func TestPoll(t *testing.T) {
+	state := health.NewState()
+	state.CheckDetails["kuberhealthy/dns"] = khstatev1.WorkloadDetails{OK: true}
+	failing := health.NewState()
+	failing.OK = false
+	failing.Errors = []string{"dns check failed"}
+
+	var down int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&down) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/failing" {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(failing)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(state)
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	err := os.WriteFile(tokenFile, []byte("secret\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clusters := []Cluster{
+		{Name: "east", URL: server.URL + "/", BearerTokenFile: tokenFile},
+		{Name: "west", URL: server.URL + "/failing"},
+	}
+
+	p := NewPoller()
+	p.Poll(context.Background(), clusters)
+	status := p.Status()
+	if status.OK || len(status.Errors) != 1 || status.Errors[0] != "west: dns check failed" {
+		t.Fatal("expected only the failing cluster to be reported but got", status)
+	}
+	east := status.Clusters["east"]
+	if !east.Reachable || !east.OK || east.Status == nil || !east.Status.CheckDetails["kuberhealthy/dns"].OK {
+		t.Fatal("expected the status of the east cluster but got", east)
+	}
+
+	atomic.StoreInt32(&down, 1)
+	p.Poll(context.Background(), clusters[:1])
+	status = p.Status()
+	east = status.Clusters["east"]
+	if _, ok := status.Clusters["west"]; ok {
+		t.Fatal("expected the cluster that is no longer listed to be dropped")
+	}
+	if east.Reachable || east.OK || !strings.Contains(east.Error, "503") || east.Status == nil || east.LastSuccess == nil {
+		t.Fatal("expected the unreachable cluster to keep its last status but got", east)
+	}
+	if status.OK || len(status.Errors) != 1 || !strings.HasPrefix(status.Errors[0], "east: ") {
+		t.Fatal("expected the unreachable cluster to be reported but got", status.Errors)
+	}
+}
@@ -18,6 +18,24 @@ type State struct {
 	JobDetails    map[string]khstatev1.WorkloadDetails // map of job names to last run timestamp
 	CurrentMaster string
 	Metadata      map[string]string
+	// ActiveMaintenanceWindows lists the names of maintenance windows that are currently active
+	ActiveMaintenanceWindows []string `json:",omitempty"`
+	// SuppressedChecks lists the checks whose failures are currently suppressed by a maintenance window
+	SuppressedChecks []string `json:",omitempty"`
+	// StaleChecks lists the checks that have not completed a run since startup and are still in their grace period
+	StaleChecks []string `json:",omitempty"`
+	// PausedChecks lists the checks that are paused and not run
+	PausedChecks []string `json:",omitempty"`
+	// PendingChecks lists the checks whose next run is queued until fewer check runs are in flight
+	PendingChecks []string `json:",omitempty"`
+	// DependencySuppressedChecks lists the checks whose failures are suppressed because a check they depend on fails
+	DependencySuppressedChecks []string `json:",omitempty"`
+	// Warnings lists the errors of checks and jobs that failed with the Warning severity, which do not affect OK
+	Warnings []string `json:",omitempty"`
+	// WarningChecks lists the checks that failed with the Warning severity
+	WarningChecks []string `json:",omitempty"`
+	// Continue is the token of the next page of check and job details when the status page was requested with a limit
+	Continue string `json:",omitempty"`
 }
 
 // AddError adds new errors to State
@@ -32,6 +50,17 @@ func (h *State) AddError(s ...string) {
 	}
 }
 
+// AddWarning adds new warnings to State
+func (h *State) AddWarning(s ...string) {
+	for _, str := range s {
+		if len(str) == 0 {
+			log.Warningln("AddWarning was called but the warning was blank so it was skipped.")
+			continue
+		}
+		h.Warnings = append(h.Warnings, str)
+	}
+}
+
 // WriteHTTPStatusResponse writes a response to an http response writer
 func (h *State) WriteHTTPStatusResponse(w http.ResponseWriter) error {
 
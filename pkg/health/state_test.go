@@ -20,3 +20,11 @@ func TestAddError(t *testing.T) {
 	assert.Contains(t, s.Errors, "my error message")
 	assert.Contains(t, s.Errors, "my another error message")
 }
+
+func TestAddWarning(t *testing.T) {
+	s := health.NewState()
+	s.AddWarning("certificate expires in 10 days", "")
+
+	assert.Equal(t, []string{"certificate expires in 10 days"}, s.Warnings)
+	assert.True(t, s.OK)
+}
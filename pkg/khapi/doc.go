@@ -0,0 +1,5 @@
+// Package khapi holds the messages and the gRPC client and server of the Kuberhealthy gRPC service defined in
+// khapi.proto.  The code is generated with protoc-gen-go and protoc-gen-go-grpc.
+package khapi // import "github.com/kuberhealthy/kuberhealthy/v2/pkg/khapi"
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative khapi.proto
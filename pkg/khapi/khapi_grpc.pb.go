@@ -0,0 +1,301 @@
+// The gRPC service programs can read the status of Kuberhealthy from and control its checks with.  It mirrors the
+// JSON status page and the /run endpoint, and is served next to the CheckReporter service of report.proto when
+// Kuberhealthy is started with --grpcListenAddress and --grpcStatusAPI.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: khapi.proto
+
+package khapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Kuberhealthy_GetStatus_FullMethodName   = "/kuberhealthy.v1.Kuberhealthy/GetStatus"
+	Kuberhealthy_WatchStatus_FullMethodName = "/kuberhealthy.v1.Kuberhealthy/WatchStatus"
+	Kuberhealthy_GetCheck_FullMethodName    = "/kuberhealthy.v1.Kuberhealthy/GetCheck"
+	Kuberhealthy_RunCheck_FullMethodName    = "/kuberhealthy.v1.Kuberhealthy/RunCheck"
+	Kuberhealthy_PauseCheck_FullMethodName  = "/kuberhealthy.v1.Kuberhealthy/PauseCheck"
+)
+
+// KuberhealthyClient is the client API for Kuberhealthy service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type KuberhealthyClient interface {
+	// GetStatus returns the status page, limited to the requested namespaces and checks like the namespace and check
+	// query parameters of the status page.
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error)
+	// WatchStatus streams the status page.  The current status is sent right away, and again each time it changes.
+	WatchStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (Kuberhealthy_WatchStatusClient, error)
+	// GetCheck returns the details of a check or job, including the logs captured when it last failed.
+	GetCheck(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*Workload, error)
+	// RunCheck starts a run of a check right away, outside of its runInterval or schedule.  Requires mTLS.
+	RunCheck(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*RunCheckResponse, error)
+	// PauseCheck pauses or resumes a khcheck with its comcast.github.io/paused annotation.  Requires mTLS.
+	PauseCheck(ctx context.Context, in *PauseCheckRequest, opts ...grpc.CallOption) (*PauseCheckResponse, error)
+}
+
+type kuberhealthyClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKuberhealthyClient(cc grpc.ClientConnInterface) KuberhealthyClient {
+	return &kuberhealthyClient{cc}
+}
+
+func (c *kuberhealthyClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*Status, error) {
+	out := new(Status)
+	err := c.cc.Invoke(ctx, Kuberhealthy_GetStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kuberhealthyClient) WatchStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (Kuberhealthy_WatchStatusClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Kuberhealthy_ServiceDesc.Streams[0], Kuberhealthy_WatchStatus_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &kuberhealthyWatchStatusClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Kuberhealthy_WatchStatusClient interface {
+	Recv() (*Status, error)
+	grpc.ClientStream
+}
+
+type kuberhealthyWatchStatusClient struct {
+	grpc.ClientStream
+}
+
+func (x *kuberhealthyWatchStatusClient) Recv() (*Status, error) {
+	m := new(Status)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *kuberhealthyClient) GetCheck(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*Workload, error) {
+	out := new(Workload)
+	err := c.cc.Invoke(ctx, Kuberhealthy_GetCheck_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kuberhealthyClient) RunCheck(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*RunCheckResponse, error) {
+	out := new(RunCheckResponse)
+	err := c.cc.Invoke(ctx, Kuberhealthy_RunCheck_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kuberhealthyClient) PauseCheck(ctx context.Context, in *PauseCheckRequest, opts ...grpc.CallOption) (*PauseCheckResponse, error) {
+	out := new(PauseCheckResponse)
+	err := c.cc.Invoke(ctx, Kuberhealthy_PauseCheck_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// KuberhealthyServer is the server API for Kuberhealthy service.
+// All implementations must embed UnimplementedKuberhealthyServer
+// for forward compatibility
+type KuberhealthyServer interface {
+	// GetStatus returns the status page, limited to the requested namespaces and checks like the namespace and check
+	// query parameters of the status page.
+	GetStatus(context.Context, *GetStatusRequest) (*Status, error)
+	// WatchStatus streams the status page.  The current status is sent right away, and again each time it changes.
+	WatchStatus(*GetStatusRequest, Kuberhealthy_WatchStatusServer) error
+	// GetCheck returns the details of a check or job, including the logs captured when it last failed.
+	GetCheck(context.Context, *CheckRequest) (*Workload, error)
+	// RunCheck starts a run of a check right away, outside of its runInterval or schedule.  Requires mTLS.
+	RunCheck(context.Context, *CheckRequest) (*RunCheckResponse, error)
+	// PauseCheck pauses or resumes a khcheck with its comcast.github.io/paused annotation.  Requires mTLS.
+	PauseCheck(context.Context, *PauseCheckRequest) (*PauseCheckResponse, error)
+	mustEmbedUnimplementedKuberhealthyServer()
+}
+
+// UnimplementedKuberhealthyServer must be embedded to have forward compatible implementations.
+type UnimplementedKuberhealthyServer struct {
+}
+
+func (UnimplementedKuberhealthyServer) GetStatus(context.Context, *GetStatusRequest) (*Status, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedKuberhealthyServer) WatchStatus(*GetStatusRequest, Kuberhealthy_WatchStatusServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchStatus not implemented")
+}
+func (UnimplementedKuberhealthyServer) GetCheck(context.Context, *CheckRequest) (*Workload, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCheck not implemented")
+}
+func (UnimplementedKuberhealthyServer) RunCheck(context.Context, *CheckRequest) (*RunCheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RunCheck not implemented")
+}
+func (UnimplementedKuberhealthyServer) PauseCheck(context.Context, *PauseCheckRequest) (*PauseCheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseCheck not implemented")
+}
+func (UnimplementedKuberhealthyServer) mustEmbedUnimplementedKuberhealthyServer() {}
+
+// UnsafeKuberhealthyServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to KuberhealthyServer will
+// result in compilation errors.
+type UnsafeKuberhealthyServer interface {
+	mustEmbedUnimplementedKuberhealthyServer()
+}
+
+func RegisterKuberhealthyServer(s grpc.ServiceRegistrar, srv KuberhealthyServer) {
+	s.RegisterService(&Kuberhealthy_ServiceDesc, srv)
+}
+
+func _Kuberhealthy_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KuberhealthyServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Kuberhealthy_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KuberhealthyServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kuberhealthy_WatchStatus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetStatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KuberhealthyServer).WatchStatus(m, &kuberhealthyWatchStatusServer{stream})
+}
+
+type Kuberhealthy_WatchStatusServer interface {
+	Send(*Status) error
+	grpc.ServerStream
+}
+
+type kuberhealthyWatchStatusServer struct {
+	grpc.ServerStream
+}
+
+func (x *kuberhealthyWatchStatusServer) Send(m *Status) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Kuberhealthy_GetCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KuberhealthyServer).GetCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Kuberhealthy_GetCheck_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KuberhealthyServer).GetCheck(ctx, req.(*CheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kuberhealthy_RunCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KuberhealthyServer).RunCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Kuberhealthy_RunCheck_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KuberhealthyServer).RunCheck(ctx, req.(*CheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Kuberhealthy_PauseCheck_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseCheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KuberhealthyServer).PauseCheck(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Kuberhealthy_PauseCheck_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KuberhealthyServer).PauseCheck(ctx, req.(*PauseCheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Kuberhealthy_ServiceDesc is the grpc.ServiceDesc for Kuberhealthy service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Kuberhealthy_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kuberhealthy.v1.Kuberhealthy",
+	HandlerType: (*KuberhealthyServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStatus",
+			Handler:    _Kuberhealthy_GetStatus_Handler,
+		},
+		{
+			MethodName: "GetCheck",
+			Handler:    _Kuberhealthy_GetCheck_Handler,
+		},
+		{
+			MethodName: "RunCheck",
+			Handler:    _Kuberhealthy_RunCheck_Handler,
+		},
+		{
+			MethodName: "PauseCheck",
+			Handler:    _Kuberhealthy_PauseCheck_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchStatus",
+			Handler:       _Kuberhealthy_WatchStatus_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "khapi.proto",
+}
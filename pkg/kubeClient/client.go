@@ -10,6 +10,16 @@ import (
 // Create returns a kubernetes api clientset that enables communication with
 // the kubernetes API via the internal service.
 func Create(kubeConfigFile string) (*kubernetes.Clientset, error) {
+	kubeconfig, err := Config(kubeConfigFile)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(kubeconfig)
+}
+
+// Config returns the configuration of the kubernetes API via the internal service, or from the kube config file
+// when not running in a cluster.
+func Config(kubeConfigFile string) (*rest.Config, error) {
 	kubeconfig, err := rest.InClusterConfig()
 	if err != nil {
 		// If not in cluster, use kube config file
@@ -18,5 +28,5 @@ func Create(kubeConfigFile string) (*kubernetes.Clientset, error) {
 			return nil, err
 		}
 	}
-	return kubernetes.NewForConfig(kubeconfig)
+	return kubeconfig, nil
 }
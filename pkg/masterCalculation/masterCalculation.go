@@ -1,5 +1,6 @@
-// Package masterCalculation determines the master pod in multi pod
-// kuberhealthy deployments
+// Package masterCalculation lists the running pods of multi pod kuberhealthy
+// deployments and assigns checks to them when checks are sharded.  The master
+// pod is elected with a coordination.k8s.io Lease by kuberhealthy itself.
 package masterCalculation // import "github.com/kuberhealthy/kuberhealthy/v2/pkg/masterCalculation"
 
 import (
@@ -22,6 +23,9 @@ var namespace = os.Getenv("POD_NAMESPACE")
 var enableForceMaster bool // indicates we should always report as master for debugging
 
 // DebugAlwaysMasterOn makes all master queries return true without logic
+//
+// Deprecated: kuberhealthy elects its master with a coordination.k8s.io Lease and forces itself to be master with
+// the forceMaster flag.  This only affects IAmMaster.
 func DebugAlwaysMasterOn() {
 	enableForceMaster = true
 }
@@ -42,19 +46,42 @@ func getEnvVar(v string) (string, error) {
 	return envVar, err
 }
 
-// CalculateMaster determines which kuberhealthy pod should assume the master role
+// CalculateMaster determines which kuberhealthy pod should assume the master role by picking the first running
+// kuberhealthy pod in alphabetical order.
+//
+// Deprecated: kuberhealthy elects its master with a coordination.k8s.io Lease, so the pod returned here is not
+// necessarily the master.  Use ListKuberhealthyPods to list the running kuberhealthy pods.
 func CalculateMaster(client *kubernetes.Clientset) (string, error) {
+	log.Debugln("Calculating current master...")
+
+	podlist, err := ListKuberhealthyPods(client)
+	if err != nil {
+		return "", err
+	}
+
+	// choose master by grabbing the first in alphabetical order based on
+	// the pod name
+	master := podlist[0]
+
+	log.Debugln("Calculated master as", master)
+	return master, err
+}
+
+// ListKuberhealthyPods returns the names of all running kuberhealthy pods in alphabetical order
+func ListKuberhealthyPods(client kubernetes.Interface) ([]string, error) {
 	// TODO: refactor function to receive context on exported function in next breaking change.
-	ctx := context.TODO()
+	return listKuberhealthyPods(context.TODO(), client)
+}
 
-	log.Debugln("Calculating current master...")
+// listKuberhealthyPods lists the names of all running kuberhealthy pods in alphabetical order
+func listKuberhealthyPods(ctx context.Context, client kubernetes.Interface) ([]string, error) {
 
 	// get a list of all kuberhealthy pods
 	pods, err := client.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
 		LabelSelector: "app=kuberhealthy", FieldSelector: "status.phase=Running",
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// create a slice of all kuberhealthy pod names for use in sort
@@ -64,19 +91,18 @@ func CalculateMaster(client *kubernetes.Clientset) (string, error) {
 	}
 
 	if len(podlist) < 1 {
-		return "", errors.New("Failed to retrieve list of Kuberhealthy pods")
+		return nil, errors.New("Failed to retrieve list of Kuberhealthy pods")
 	}
 
-	// choose master by grabbing the first in alphabetical order based on
-	// the pod name
 	sort.Strings(podlist)
-	master := podlist[0]
-
-	log.Debugln("Calculated master as", master)
-	return master, err
+	return podlist, nil
 }
 
-// IAmMaster determines if the executing pod is the cluster master or not
+// IAmMaster determines if the executing pod is the first running kuberhealthy pod in alphabetical order, as named by
+// the POD_NAME environment variable.
+//
+// Deprecated: kuberhealthy elects its master with a coordination.k8s.io Lease, so the result of IAmMaster does not
+// tell whether the executing pod holds the lease.
 func IAmMaster(client *kubernetes.Clientset) (bool, error) {
 
 	// if we are in debug enable master always, then just return true
@@ -17,9 +17,9 @@ func TestRun(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	master, err := CalculateMaster(client)
+	pods, err := ListKuberhealthyPods(client)
 	if err != nil {
 		t.Fatal(err)
 	}
-	t.Log(master)
+	t.Log(pods)
 }
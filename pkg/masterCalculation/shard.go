@@ -0,0 +1,28 @@
+package masterCalculation
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// ShardOwner determines which of the supplied kuberhealthy pods is assigned the item with the supplied key, such as a
+// check's namespace/name.  Items are assigned with rendezvous hashing, so when a pod joins or leaves only the items
+// assigned to that pod move.  A blank string is returned if there are no pods.
+func ShardOwner(key string, pods []string) string {
+	var owner string
+	var ownerScore uint64
+	for _, pod := range pods {
+		score := shardScore(key, pod)
+		if len(owner) == 0 || score > ownerScore || (score == ownerScore && pod < owner) {
+			owner = pod
+			ownerScore = score
+		}
+	}
+	return owner
+}
+
+// shardScore calculates the rendezvous hashing score of a key for a pod
+func shardScore(key string, pod string) uint64 {
+	sum := sha256.Sum256([]byte(pod + "\x00" + key))
+	return binary.BigEndian.Uint64(sum[:8])
+}
@@ -0,0 +1,41 @@
+package masterCalculation
+
+import (
+	"strconv"
+	"testing"
+)
+
+// TestShardOwner ensures items are spread over pods and only the items of a removed pod move
+func TestShardOwner(t *testing.T) {
+	pods := []string{"kuberhealthy-a", "kuberhealthy-b", "kuberhealthy-c"}
+
+	if len(ShardOwner("kuberhealthy/daemonset", nil)) != 0 {
+		t.Fatal("expected no owner without pods")
+	}
+
+	owners := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		key := "kuberhealthy/check-" + strconv.Itoa(i)
+		owner := ShardOwner(key, pods)
+		if owner != ShardOwner(key, []string{"kuberhealthy-c", "kuberhealthy-a", "kuberhealthy-b"}) {
+			t.Fatal("expected the owner to not depend on the order of pods")
+		}
+		owners[key] = owner
+		counts[owner]++
+	}
+	for _, pod := range pods {
+		if counts[pod] < 50 {
+			t.Fatal("expected items to be spread over all pods but got:", counts)
+		}
+	}
+
+	// removing a pod only moves the items it owned
+	remaining := []string{"kuberhealthy-a", "kuberhealthy-c"}
+	for key, owner := range owners {
+		newOwner := ShardOwner(key, remaining)
+		if owner != "kuberhealthy-b" && newOwner != owner {
+			t.Fatal("expected", key, "to stay on", owner, "but it moved to", newOwner)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+)
+
+// DefaultCloudWatchNamespace is the CloudWatch namespace custom metrics are published to
+const DefaultCloudWatchNamespace = "Kuberhealthy"
+
+// cloudWatchBatchSize is the most metrics PutMetricData accepts in a single request
+const cloudWatchBatchSize = 1000
+
+// CloudWatchConfig configures the CloudWatch client
+type CloudWatchConfig struct {
+	Namespace  string                                // the CloudWatch namespace of the metrics
+	Region     string                                // the AWS region.  the region of the environment, such as AWS_REGION, is used when blank
+	Dimensions map[string]string                     // dimensions published with every metric, such as the cluster name
+	Proxy      func(*http.Request) (*url.URL, error) // the proxy requests are sent through. nil uses the proxy environment variables
+}
+
+// CloudWatchClient publishes metrics to CloudWatch as custom metrics.  Credentials come from the default AWS
+// credential chain, which includes the web identity tokens of IAM roles for service accounts.
+type CloudWatchClient struct {
+	config CloudWatchConfig
+	api    cloudwatchiface.CloudWatchAPI
+}
+
+// NewCloudWatchClient creates a CloudWatchClient that can be used to push metrics
+func NewCloudWatchClient(config CloudWatchConfig) (*CloudWatchClient, error) {
+	if len(config.Namespace) == 0 {
+		return nil, errors.New("a CloudWatch namespace is required")
+	}
+	awsConfig := aws.NewConfig().WithCredentialsChainVerboseErrors(true).WithHTTPClient(&http.Client{Transport: proxyTransport(config.Proxy)})
+	if len(config.Region) > 0 {
+		awsConfig = awsConfig.WithRegion(config.Region)
+	}
+	s, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session for CloudWatch: %w", err)
+	}
+	return &CloudWatchClient{
+		config: config,
+		api:    cloudwatch.New(s),
+	}, nil
+}
+
+// Push accepts a list of metrics, with a metric being defined as a map of string (name) to interface (value).  The
+// status and run duration of a check or job are published as the CheckStatus and RunDuration metrics, with the check
+// and its namespace as dimensions.  Other metrics keep their names.
+func (c *CloudWatchClient) Push(points Metric, tags map[string]string) error {
+	now := time.Now()
+	dimensions := c.dimensions(tags)
+	var data []*cloudwatch.MetricDatum
+	for _, p := range points {
+		for key, val := range p {
+			value, err := cloudWatchValue(val)
+			if err != nil {
+				return fmt.Errorf("unable to publish metric %s to CloudWatch: %w", key, err)
+			}
+			name, unit := cloudWatchMetricName(key, tags)
+			data = append(data, &cloudwatch.MetricDatum{
+				MetricName: aws.String(name),
+				Dimensions: dimensions,
+				Timestamp:  aws.Time(now),
+				Unit:       aws.String(unit),
+				Value:      aws.Float64(value),
+			})
+		}
+	}
+
+	for len(data) > 0 {
+		n := cloudWatchBatchSize
+		if n > len(data) {
+			n = len(data)
+		}
+		_, err := c.api.PutMetricData(&cloudwatch.PutMetricDataInput{
+			Namespace:  aws.String(c.config.Namespace),
+			MetricData: data[:n],
+		})
+		if err != nil {
+			return fmt.Errorf("failed to publish metrics to CloudWatch: %w", err)
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// dimensions returns the configured dimensions along with the check and namespace from the tags.  Other tags, such as
+// errors, are left out so that they do not create a new metric for every value.
+func (c *CloudWatchClient) dimensions(tags map[string]string) []*cloudwatch.Dimension {
+	all := make(map[string]string, len(c.config.Dimensions)+2)
+	for k, v := range c.config.Dimensions {
+		all[k] = v
+	}
+	if len(tags["Name"]) > 0 {
+		all["Check"] = tags["Name"]
+	}
+	if len(tags["Namespace"]) > 0 {
+		all["Namespace"] = tags["Namespace"]
+	}
+
+	keys := make([]string, 0, len(all))
+	for k, v := range all {
+		if len(v) > 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	dimensions := make([]*cloudwatch.Dimension, 0, len(keys))
+	for _, k := range keys {
+		dimensions = append(dimensions, &cloudwatch.Dimension{Name: aws.String(k), Value: aws.String(all[k])})
+	}
+	return dimensions
+}
+
+// cloudWatchMetricName returns the CloudWatch name and unit of a metric.  The check name and namespace are part of the
+// metric keys of checks and jobs, so they are dropped from the name in favor of dimensions.
+func cloudWatchMetricName(key string, tags map[string]string) (string, string) {
+	suffix := tags["Name"] + "." + tags["Namespace"]
+	switch {
+	case len(tags["Name"]) > 0 && key == suffix:
+		return "CheckStatus", cloudwatch.StandardUnitNone
+	case len(tags["Name"]) > 0 && key == "RunDuration."+suffix:
+		return "RunDuration", cloudwatch.StandardUnitSeconds
+	}
+	return strings.Replace(key, " ", "_", -1), cloudwatch.StandardUnitNone
+}
+
+// cloudWatchValue converts the value of a metric to the float CloudWatch stores
+func cloudWatchValue(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unsupported value of type %T", value)
+}
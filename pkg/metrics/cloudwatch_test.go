@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+)
+
+// fakeCloudWatch records the metric data it is sent
+type fakeCloudWatch struct {
+	cloudwatchiface.CloudWatchAPI
+	inputs []*cloudwatch.PutMetricDataInput
+	err    error
+}
+
+// PutMetricData records the input
+func (f *fakeCloudWatch) PutMetricData(input *cloudwatch.PutMetricDataInput) (*cloudwatch.PutMetricDataOutput, error) {
+	f.inputs = append(f.inputs, input)
+	return &cloudwatch.PutMetricDataOutput{}, f.err
+}
+
+// TestCloudWatchPush ensures check results are published with the check and namespace as dimensions
+func TestCloudWatchPush(t *testing.T) {
+	api := &fakeCloudWatch{}
+	client := &CloudWatchClient{
+		config: CloudWatchConfig{Namespace: "Kuberhealthy", Dimensions: map[string]string{"Cluster": "prod-east"}},
+		api:    api,
+	}
+	metric := Metric{
+		{"dns-status.kuberhealthy": 1},
+		{"RunDuration.dns-status.kuberhealthy": 1.5},
+	}
+	err := client.Push(metric, map[string]string{"Name": "dns-status", "Namespace": "kuberhealthy", "Errors": "timeout", "KuberhealthyPod": "kuberhealthy-0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(api.inputs) != 1 || aws.StringValue(api.inputs[0].Namespace) != "Kuberhealthy" {
+		t.Fatal("expected one request to the Kuberhealthy namespace but got", api.inputs)
+	}
+
+	values := map[string]float64{}
+	for _, datum := range api.inputs[0].MetricData {
+		values[aws.StringValue(datum.MetricName)+"/"+aws.StringValue(datum.Unit)] = aws.Float64Value(datum.Value)
+		var dimensions []string
+		for _, d := range datum.Dimensions {
+			dimensions = append(dimensions, aws.StringValue(d.Name)+"="+aws.StringValue(d.Value))
+		}
+		if strings.Join(dimensions, ",") != "Check=dns-status,Cluster=prod-east,Namespace=kuberhealthy" {
+			t.Fatal("expected the configured dimensions with the check and namespace but got", dimensions)
+		}
+	}
+	if values["CheckStatus/None"] != 1 || values["RunDuration/Seconds"] != 1.5 {
+		t.Fatal("expected the check status and run duration but got", values)
+	}
+}
+
+func TestCloudWatchPushFailure(t *testing.T) {
+	client := &CloudWatchClient{config: CloudWatchConfig{Namespace: "Kuberhealthy"}, api: &fakeCloudWatch{err: errors.New("AccessDenied")}}
+	err := client.Push(Metric{{"dns-status.kuberhealthy": 0}}, map[string]string{"Name": "dns-status", "Namespace": "kuberhealthy"})
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Fatal("expected the failed request to return an error but got", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
 )
 
@@ -38,7 +39,7 @@ func promMetricName(config PromMetricsConfig, checkOrJob string, checkName strin
 	return metricName
 }
 
-//GenerateMetrics takes the state and returns it in the Prometheus format
+// GenerateMetrics takes the state and returns it in the Prometheus format
 func GenerateMetrics(state health.State, config PromMetricsConfig) string {
 	metricsOutput := ""
 	healthStatus := "0"
@@ -57,6 +58,9 @@ func GenerateMetrics(state health.State, config PromMetricsConfig) string {
 	metricCheckDuration := make(map[string]string)
 	metricJobState := make(map[string]string)
 	metricJobDuration := make(map[string]string)
+	metricCheckLastRun := make(map[string]string)
+	metricCheckWarning := make(map[string]string)
+	metricJobLastRun := make(map[string]string)
 
 	// Parse through all check details and append to metricState
 	for c, d := range state.CheckDetails {
@@ -68,6 +72,13 @@ func GenerateMetrics(state health.State, config PromMetricsConfig) string {
 		metricDurationName := fmt.Sprintf("kuberhealthy_check_duration_seconds{check=\"%s\",namespace=\"%s\"}", c, d.Namespace)
 		metricCheckState[metricName] = checkStatus
 
+		// checks that failed with the Warning severity do not affect the cluster state
+		checkWarning := "0"
+		if !d.OK && d.Severity == status.SeverityWarning {
+			checkWarning = "1"
+		}
+		metricCheckWarning[fmt.Sprintf("kuberhealthy_check_warning{check=\"%s\",namespace=\"%s\"}", c, d.Namespace)] = checkWarning
+
 		// if runDuration hasn't been set yet, ie. pod never ran or failed to provision, set runDuration to 0
 		if d.RunDuration == "" {
 			d.RunDuration = time.Duration(0).String()
@@ -77,6 +88,12 @@ func GenerateMetrics(state health.State, config PromMetricsConfig) string {
 			log.Errorln("Error parsing run duration:", d.RunDuration, "for metric:", metricName, "error:", err)
 		}
 		metricCheckDuration[metricDurationName] = fmt.Sprintf("%f", runDuration.Seconds())
+
+		// checks that have not run yet have no last run time
+		if d.LastRun != nil {
+			metricLastRunName := fmt.Sprintf("kuberhealthy_check_last_run_timestamp_seconds{check=\"%s\",namespace=\"%s\"}", c, d.Namespace)
+			metricCheckLastRun[metricLastRunName] = fmt.Sprintf("%d", d.LastRun.Unix())
+		}
 	}
 
 	// Parse through all job details and append to metricState
@@ -98,6 +115,12 @@ func GenerateMetrics(state health.State, config PromMetricsConfig) string {
 			log.Errorln("Error parsing run duration:", d.RunDuration, "for metric:", metricName, "error:", err)
 		}
 		metricJobDuration[metricDurationName] = fmt.Sprintf("%f", runDuration.Seconds())
+
+		// jobs that have not run yet have no last run time
+		if d.LastRun != nil {
+			metricLastRunName := fmt.Sprintf("kuberhealthy_job_last_run_timestamp_seconds{check=\"%s\",namespace=\"%s\"}", c, d.Namespace)
+			metricJobLastRun[metricLastRunName] = fmt.Sprintf("%d", d.LastRun.Unix())
+		}
 	}
 
 	// Add each metric format individually. This addresses issue https://github.com/kuberhealthy/kuberhealthy/issues/813.
@@ -113,6 +136,16 @@ func GenerateMetrics(state health.State, config PromMetricsConfig) string {
 	for m, v := range metricCheckDuration {
 		metricsOutput += fmt.Sprintf("%s %s\n", m, v)
 	}
+	metricsOutput += "# HELP kuberhealthy_check_last_run_timestamp_seconds Shows the unix time a Kuberhealthy check last ran\n"
+	metricsOutput += "# TYPE kuberhealthy_check_last_run_timestamp_seconds gauge\n"
+	for m, v := range metricCheckLastRun {
+		metricsOutput += fmt.Sprintf("%s %s\n", m, v)
+	}
+	metricsOutput += "# HELP kuberhealthy_check_warning Shows if a Kuberhealthy check failed with the Warning severity, which does not affect the cluster state\n"
+	metricsOutput += "# TYPE kuberhealthy_check_warning gauge\n"
+	for m, v := range metricCheckWarning {
+		metricsOutput += fmt.Sprintf("%s %s\n", m, v)
+	}
 	// Kuberhealthy job metrics
 	metricsOutput += "# HELP kuberhealthy_job Shows the status of a Kuberhealthy job\n"
 	metricsOutput += "# TYPE kuberhealthy_job gauge\n"
@@ -124,11 +157,16 @@ func GenerateMetrics(state health.State, config PromMetricsConfig) string {
 	for m, v := range metricJobDuration {
 		metricsOutput += fmt.Sprintf("%s %s\n", m, v)
 	}
+	metricsOutput += "# HELP kuberhealthy_job_last_run_timestamp_seconds Shows the unix time a Kuberhealthy job last ran\n"
+	metricsOutput += "# TYPE kuberhealthy_job_last_run_timestamp_seconds gauge\n"
+	for m, v := range metricJobLastRun {
+		metricsOutput += fmt.Sprintf("%s %s\n", m, v)
+	}
 
 	return metricsOutput
 }
 
-//ErrorStateMetrics is a Prometheus metric meant to show Kuberhealthy has error
+// ErrorStateMetrics is a Prometheus metric meant to show Kuberhealthy has error
 func ErrorStateMetrics(state health.State) string {
 	errorOutput := ""
 	errorOutput += "# HELP kuberhealthy_running Shows if kuberhealthy is running error free\n"
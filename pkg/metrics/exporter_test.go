@@ -4,8 +4,12 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
 	"github.com/kuberhealthy/kuberhealthy/v2/pkg/health"
 )
 
@@ -135,6 +139,55 @@ func TestGenerateMetrics(t *testing.T) {
 	}
 }
 
+// TestGenerateMetricsLastRun ensures the last run time of checks and jobs that ran is exported as a unix timestamp
+func TestGenerateMetricsLastRun(t *testing.T) {
+	lastRun := metav1.NewTime(time.Unix(1714528800, 0))
+	state := health.State{
+		CheckDetails: map[string]khstatev1.WorkloadDetails{
+			"kuberhealthy/ran":   {OK: true, Namespace: "kuberhealthy", LastRun: &lastRun},
+			"kuberhealthy/never": {OK: true, Namespace: "kuberhealthy"},
+		},
+		JobDetails: map[string]khstatev1.WorkloadDetails{
+			"kuberhealthy/job": {OK: true, Namespace: "kuberhealthy", LastRun: &lastRun},
+		},
+	}
+	metrics := parseMetrics(GenerateMetrics(state, PromMetricsConfig{}))
+	if metrics[`kuberhealthy_check_last_run_timestamp_seconds{check="kuberhealthy/ran",namespace="kuberhealthy"}`] != "1714528800" {
+		t.Fatal("expected the last run timestamp of the check but got", metrics)
+	}
+	if _, ok := metrics[`kuberhealthy_check_last_run_timestamp_seconds{check="kuberhealthy/never",namespace="kuberhealthy"}`]; ok {
+		t.Fatal("expected no last run timestamp for a check that never ran")
+	}
+	if metrics[`kuberhealthy_job_last_run_timestamp_seconds{check="kuberhealthy/job",namespace="kuberhealthy"}`] != "1714528800" {
+		t.Fatal("expected the last run timestamp of the job but got", metrics)
+	}
+}
+
+// TestGenerateMetricsWarning ensures checks that failed with the Warning severity are exported as warnings
+func TestGenerateMetricsWarning(t *testing.T) {
+	state := health.State{
+		OK: true,
+		CheckDetails: map[string]khstatev1.WorkloadDetails{
+			"kuberhealthy/cert":  {OK: false, Namespace: "kuberhealthy", Errors: []string{"expires soon"}, Severity: status.SeverityWarning},
+			"kuberhealthy/dns":   {OK: false, Namespace: "kuberhealthy", Errors: []string{"timeout"}},
+			"kuberhealthy/nodes": {OK: true, Namespace: "kuberhealthy"},
+		},
+	}
+	metrics := parseMetrics(GenerateMetrics(state, PromMetricsConfig{SuppressErrorLabel: true}))
+	if metrics["kuberhealthy_cluster_state"] != "1" {
+		t.Fatal("expected the cluster to be healthy with a warning")
+	}
+	if metrics[`kuberhealthy_check{check="kuberhealthy/cert",namespace="kuberhealthy",status="0"}`] != "0" {
+		t.Fatal("expected the warning check to be failing but got", metrics)
+	}
+	expected := map[string]string{"kuberhealthy/cert": "1", "kuberhealthy/dns": "0", "kuberhealthy/nodes": "0"}
+	for check, value := range expected {
+		if metrics[`kuberhealthy_check_warning{check="`+check+`",namespace="kuberhealthy"}`] != value {
+			t.Fatal("expected the warning metric of", check, "to be", value, "but got", metrics)
+		}
+	}
+}
+
 func TestErrorStateMetrics(t *testing.T) {
 	state := health.State{
 		CurrentMaster: "testMaster",
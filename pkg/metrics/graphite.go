@@ -0,0 +1,214 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// GraphiteProtocolPlaintext sends metrics to carbon as plaintext lines, usually on port 2003
+const GraphiteProtocolPlaintext = "plaintext"
+
+// GraphiteProtocolPickle sends metrics to carbon in batches of pickled tuples, usually on port 2004
+const GraphiteProtocolPickle = "pickle"
+
+// DefaultGraphitePathTemplate is the default template of metric paths, which matches the measurement names written
+// to InfluxDB
+const DefaultGraphitePathTemplate = "kuberhealthy.{{.Key}}"
+
+// graphiteTimeout is the timeout of connecting to carbon and sending a batch of metrics
+const graphiteTimeout = time.Second * 10
+
+// GraphiteConfig configures the Graphite client
+type GraphiteConfig struct {
+	Address      string            // the host:port of the carbon receiver
+	Protocol     string            // GraphiteProtocolPlaintext or GraphiteProtocolPickle
+	PathTemplate string            // a text/template of metric paths, executed with a GraphitePath
+	Tags         map[string]string // tags every metric path can use, such as the cluster name
+}
+
+// GraphitePath is what the path template of a metric is executed with
+type GraphitePath struct {
+	Key       string            // the key of the metric, such as dns-status.kuberhealthy or RunDuration.dns-status.kuberhealthy
+	Type      string            // status or duration for the results of checks and jobs.  the key for other metrics
+	Name      string            // the name of the check or job
+	Namespace string            // the namespace of the check or job
+	Tags      map[string]string // the configured tags along with the tags of the metric
+}
+
+// GraphiteClient sends metrics to the carbon receiver of Graphite over TCP
+type GraphiteClient struct {
+	config   GraphiteConfig
+	template *template.Template
+}
+
+// NewGraphiteClient creates a GraphiteClient that can be used to push metrics
+func NewGraphiteClient(config GraphiteConfig) (*GraphiteClient, error) {
+	if len(config.Address) == 0 {
+		return nil, errors.New("a Graphite address is required")
+	}
+	if config.Protocol != GraphiteProtocolPlaintext && config.Protocol != GraphiteProtocolPickle {
+		return nil, fmt.Errorf("unsupported Graphite protocol %q. Use %s or %s", config.Protocol, GraphiteProtocolPlaintext, GraphiteProtocolPickle)
+	}
+	if len(config.PathTemplate) == 0 {
+		config.PathTemplate = DefaultGraphitePathTemplate
+	}
+	t, err := template.New("graphitePath").Option("missingkey=zero").Parse(config.PathTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Graphite path template: %w", err)
+	}
+	return &GraphiteClient{
+		config:   config,
+		template: t,
+	}, nil
+}
+
+// graphiteMetric is a single metric sent to carbon
+type graphiteMetric struct {
+	path  string
+	value float64
+}
+
+// Push accepts a list of metrics, with a metric being defined as a map of string (name) to interface (value).  Each
+// metric is sent to the path its template renders.  A connection is made for every push, so that a restarted carbon
+// receiver does not break forwarding.
+func (g *GraphiteClient) Push(points Metric, tags map[string]string) error {
+	var batch []graphiteMetric
+	for _, p := range points {
+		for key, val := range p {
+			value, err := graphiteValue(val)
+			if err != nil {
+				return fmt.Errorf("unable to send metric %s to Graphite: %w", key, err)
+			}
+			path, err := g.path(key, tags)
+			if err != nil {
+				return err
+			}
+			batch = append(batch, graphiteMetric{path: path, value: value})
+		}
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var payload []byte
+	if g.config.Protocol == GraphiteProtocolPickle {
+		payload = graphitePickle(batch, now)
+	} else {
+		payload = graphitePlaintext(batch, now)
+	}
+
+	conn, err := net.DialTimeout("tcp", g.config.Address, graphiteTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Graphite at %s: %w", g.config.Address, err)
+	}
+	defer conn.Close()
+	err = conn.SetWriteDeadline(now.Add(graphiteTimeout))
+	if err != nil {
+		return err
+	}
+	_, err = conn.Write(payload)
+	if err != nil {
+		return fmt.Errorf("failed to send metrics to Graphite at %s: %w", g.config.Address, err)
+	}
+	return nil
+}
+
+// path renders the path template of a metric.  The name, namespace and tags are sanitized, so that they are a single
+// node of the path.
+func (g *GraphiteClient) path(key string, tags map[string]string) (string, error) {
+	all := make(map[string]string, len(g.config.Tags)+len(tags))
+	for k, v := range g.config.Tags {
+		all[k] = graphiteNodeReplacer.Replace(v)
+	}
+	for k, v := range tags {
+		all[k] = graphiteNodeReplacer.Replace(v)
+	}
+
+	p := GraphitePath{
+		Key:       graphitePathReplacer.Replace(key),
+		Name:      all["Name"],
+		Namespace: all["Namespace"],
+		Tags:      all,
+	}
+	suffix := tags["Name"] + "." + tags["Namespace"]
+	switch {
+	case len(tags["Name"]) > 0 && key == suffix:
+		p.Type = "status"
+	case len(tags["Name"]) > 0 && key == "RunDuration."+suffix:
+		p.Type = "duration"
+	default:
+		p.Type = p.Key
+	}
+
+	var b strings.Builder
+	err := g.template.Execute(&b, p)
+	if err != nil {
+		return "", fmt.Errorf("failed to render Graphite path of metric %s: %w", key, err)
+	}
+	return b.String(), nil
+}
+
+// graphitePlaintext formats metrics as plaintext lines of path, value and timestamp
+func graphitePlaintext(batch []graphiteMetric, t time.Time) []byte {
+	var b bytes.Buffer
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+	for _, m := range batch {
+		b.WriteString(m.path + " " + strconv.FormatFloat(m.value, 'f', -1, 64) + " " + timestamp + "\n")
+	}
+	return b.Bytes()
+}
+
+// graphitePickle formats metrics as a length prefixed pickle (protocol 2) of a list of (path, (timestamp, value))
+// tuples, which is what the carbon pickle receiver expects
+func graphitePickle(batch []graphiteMetric, t time.Time) []byte {
+	float := func(b []byte, f float64) []byte {
+		b = append(b, 'G') // BINFLOAT
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(f))
+	}
+
+	p := []byte{0x80, 2, ']', '('} // PROTO 2, EMPTY_LIST, MARK
+	for _, m := range batch {
+		p = append(p, 'X') // BINUNICODE
+		p = binary.LittleEndian.AppendUint32(p, uint32(len(m.path)))
+		p = append(p, m.path...)
+		p = float(p, float64(t.Unix()))
+		p = float(p, m.value)
+		p = append(p, 0x86, 0x86) // TUPLE2 of the timestamp and value, then TUPLE2 of the path and that tuple
+	}
+	p = append(p, 'e', '.') // APPENDS, STOP
+
+	return append(binary.BigEndian.AppendUint32(nil, uint32(len(p))), p...)
+}
+
+// graphiteValue converts the value of a metric to the float carbon stores
+func graphiteValue(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, fmt.Errorf("unsupported value of type %T", value)
+}
+
+// graphitePathReplacer replaces the characters that separate the parts of a plaintext line in metric keys
+var graphitePathReplacer = strings.NewReplacer(" ", "_", "\n", "_", "\t", "_")
+
+// graphiteNodeReplacer also replaces dots, which separate the nodes of a path, in values that are a single node
+var graphiteNodeReplacer = strings.NewReplacer(".", "_", " ", "_", "\n", "_", "\t", "_")
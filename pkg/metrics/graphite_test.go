@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// testGraphiteServer starts a TCP server and returns its address and a channel of everything each connection sent
+func testGraphiteServer(t *testing.T) (string, chan []byte) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	received := make(chan []byte, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		b, _ := io.ReadAll(conn)
+		received <- b
+	}()
+	return listener.Addr().String(), received
+}
+
+// TestGraphitePushPlaintext ensures metrics are sent as plaintext lines to the paths their template renders
+func TestGraphitePushPlaintext(t *testing.T) {
+	address, received := testGraphiteServer(t)
+	client, err := NewGraphiteClient(GraphiteConfig{
+		Address:      address,
+		Protocol:     GraphiteProtocolPlaintext,
+		PathTemplate: "kuberhealthy.{{.Tags.cluster}}.{{.Namespace}}.{{.Name}}.{{.Type}}",
+		Tags:         map[string]string{"cluster": "prod.east"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Push(Metric{{"dns-status.kuberhealthy": 1}, {"RunDuration.dns-status.kuberhealthy": 1.5}}, map[string]string{"Name": "dns-status", "Namespace": "kuberhealthy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(<-received)), "\n")
+	if len(lines) != 2 {
+		t.Fatal("expected a line for each metric but got", lines)
+	}
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) != 3:
+			t.Fatal("expected a path, value and timestamp but got", line)
+		case fields[0] == "kuberhealthy.prod_east.kuberhealthy.dns-status.status" && fields[1] == "1":
+		case fields[0] == "kuberhealthy.prod_east.kuberhealthy.dns-status.duration" && fields[1] == "1.5":
+		default:
+			t.Fatal("expected the rendered path and value but got", line)
+		}
+	}
+}
+
+// TestGraphitePushPickle ensures metrics are sent as a length prefixed pickle
+func TestGraphitePushPickle(t *testing.T) {
+	address, received := testGraphiteServer(t)
+	client, err := NewGraphiteClient(GraphiteConfig{Address: address, Protocol: GraphiteProtocolPickle})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Push(Metric{{"dns-status.kuberhealthy": 1}}, map[string]string{"Name": "dns-status", "Namespace": "kuberhealthy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := <-received
+	if len(b) < 4 || int(binary.BigEndian.Uint32(b)) != len(b)-4 {
+		t.Fatal("expected the pickle to be prefixed with its length but got", b)
+	}
+	if !bytes.HasPrefix(b[4:], []byte{0x80, 2, ']', '('}) || !bytes.HasSuffix(b, []byte{0x86, 0x86, 'e', '.'}) {
+		t.Fatal("expected a pickled list of tuples but got", b)
+	}
+	if !bytes.Contains(b, []byte("kuberhealthy.dns-status.kuberhealthy")) {
+		t.Fatal("expected the default path of the metric but got", string(b))
+	}
+}
+
+func TestNewGraphiteClientInvalidTemplate(t *testing.T) {
+	_, err := NewGraphiteClient(GraphiteConfig{Address: "carbon:2003", Protocol: GraphiteProtocolPlaintext, PathTemplate: "kuberhealthy.{{.Name"})
+	if err == nil {
+		t.Fatal("expected an error for an invalid path template")
+	}
+}
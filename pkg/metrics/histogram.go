@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultRunDurationBuckets are the upper bounds in seconds of the run duration histogram buckets
+var DefaultRunDurationBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600}
+
+// RunDurationHistogram accumulates the run durations of checks and jobs since Kuberhealthy started, so that
+// Prometheus can calculate duration quantiles over any range instead of only seeing the duration of the last run
+type RunDurationHistogram struct {
+	mu      sync.Mutex
+	buckets []float64
+	series  map[runDurationSeriesKey]*runDurationSeries
+}
+
+// runDurationSeriesKey identifies the histogram of a single check or job.  checkOrJob is literally "check" or "job".
+type runDurationSeriesKey struct {
+	checkOrJob string
+	name       string
+	namespace  string
+}
+
+// runDurationSeries is the histogram of a single check or job.  counts holds the number of runs in each bucket,
+// not cumulated.
+type runDurationSeries struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewRunDurationHistogram creates a run duration histogram with the supplied bucket upper bounds in seconds
+func NewRunDurationHistogram(buckets []float64) *RunDurationHistogram {
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+	return &RunDurationHistogram{buckets: sorted, series: map[runDurationSeriesKey]*runDurationSeries{}}
+}
+
+// Observe records the duration of a run.  checkOrJob is literally the string "check" or "job", and name is the
+// namespace/name key used on the status page.  Observations on a nil histogram are ignored.
+func (h *RunDurationHistogram) Observe(checkOrJob string, name string, namespace string, duration time.Duration) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := runDurationSeriesKey{checkOrJob: checkOrJob, name: name, namespace: namespace}
+	s, ok := h.series[key]
+	if !ok {
+		s = &runDurationSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	seconds := duration.Seconds()
+	for i, upperBound := range h.buckets {
+		if seconds <= upperBound {
+			s.counts[i]++
+			break
+		}
+	}
+	s.count++
+	s.sum += seconds
+}
+
+// Metrics returns the histograms in the Prometheus format as kuberhealthy_check_run_duration_seconds and
+// kuberhealthy_job_run_duration_seconds
+func (h *RunDurationHistogram) Metrics() string {
+	if h == nil {
+		return ""
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]runDurationSeriesKey, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].checkOrJob != keys[j].checkOrJob {
+			return keys[i].checkOrJob < keys[j].checkOrJob
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	var b strings.Builder
+	var lastCheckOrJob string
+	for _, key := range keys {
+		metricName := fmt.Sprintf("kuberhealthy_%s_run_duration_seconds", key.checkOrJob)
+		if key.checkOrJob != lastCheckOrJob {
+			b.WriteString(fmt.Sprintf("# HELP %s Shows the distribution of the run durations of a Kuberhealthy %s\n", metricName, key.checkOrJob))
+			b.WriteString(fmt.Sprintf("# TYPE %s histogram\n", metricName))
+			lastCheckOrJob = key.checkOrJob
+		}
+		labels := fmt.Sprintf("check=\"%s\",namespace=\"%s\"", key.name, key.namespace)
+		s := h.series[key]
+		var cumulative uint64
+		for i, upperBound := range h.buckets {
+			cumulative += s.counts[i]
+			b.WriteString(fmt.Sprintf("%s_bucket{%s,le=\"%s\"} %d\n", metricName, labels, strconv.FormatFloat(upperBound, 'g', -1, 64), cumulative))
+		}
+		b.WriteString(fmt.Sprintf("%s_bucket{%s,le=\"+Inf\"} %d\n", metricName, labels, s.count))
+		b.WriteString(fmt.Sprintf("%s_sum{%s} %f\n", metricName, labels, s.sum))
+		b.WriteString(fmt.Sprintf("%s_count{%s} %d\n", metricName, labels, s.count))
+	}
+	return b.String()
+}
@@ -0,0 +1,37 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRunDurationHistogram ensures run durations are counted in cumulative buckets for each check and job
+func TestRunDurationHistogram(t *testing.T) {
+	h := NewRunDurationHistogram([]float64{10, 1})
+	h.Observe("check", "kuberhealthy/dns", "kuberhealthy", time.Millisecond*500)
+	h.Observe("check", "kuberhealthy/dns", "kuberhealthy", time.Second*5)
+	h.Observe("check", "kuberhealthy/dns", "kuberhealthy", time.Minute)
+	h.Observe("job", "kuberhealthy/job", "kuberhealthy", time.Second)
+
+	metrics := parseMetrics(h.Metrics())
+	expected := map[string]string{
+		`kuberhealthy_check_run_duration_seconds_bucket{check="kuberhealthy/dns",namespace="kuberhealthy",le="1"}`:    "1",
+		`kuberhealthy_check_run_duration_seconds_bucket{check="kuberhealthy/dns",namespace="kuberhealthy",le="10"}`:   "2",
+		`kuberhealthy_check_run_duration_seconds_bucket{check="kuberhealthy/dns",namespace="kuberhealthy",le="+Inf"}`: "3",
+		`kuberhealthy_check_run_duration_seconds_sum{check="kuberhealthy/dns",namespace="kuberhealthy"}`:              "65.500000",
+		`kuberhealthy_check_run_duration_seconds_count{check="kuberhealthy/dns",namespace="kuberhealthy"}`:            "3",
+		`kuberhealthy_job_run_duration_seconds_bucket{check="kuberhealthy/job",namespace="kuberhealthy",le="1"}`:      "1",
+		`kuberhealthy_job_run_duration_seconds_count{check="kuberhealthy/job",namespace="kuberhealthy"}`:              "1",
+	}
+	for metric, value := range expected {
+		if metrics[metric] != value {
+			t.Error("expected", metric, "to be", value, "but got", metrics[metric])
+		}
+	}
+
+	var nilHistogram *RunDurationHistogram
+	nilHistogram.Observe("check", "kuberhealthy/dns", "kuberhealthy", time.Second)
+	if nilHistogram.Metrics() != "" {
+		t.Fatal("expected no metrics from a nil histogram")
+	}
+}
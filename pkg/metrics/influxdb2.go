@@ -0,0 +1,203 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultInfluxBatchSize is the most points written to InfluxDB 2.x in a single request
+const DefaultInfluxBatchSize = 5000
+
+// DefaultInfluxMaxRetries is the number of times a failed write to InfluxDB 2.x is retried
+const DefaultInfluxMaxRetries = 3
+
+// DefaultInfluxRetryDelay is the delay before the first retry of a failed write.  The delay doubles with each retry.
+const DefaultInfluxRetryDelay = time.Second
+
+// DefaultInfluxTimeout is the timeout of each write to InfluxDB 2.x
+const DefaultInfluxTimeout = time.Second * 10
+
+// InfluxV2Config configures the InfluxDB 2.x client
+type InfluxV2Config struct {
+	URL        url.URL                               // the address of the InfluxDB instance, without the /api/v2/write path
+	Token      string                                // the API token, which needs write access to the bucket
+	Org        string                                // the organization name or ID
+	Bucket     string                                // the bucket name or ID
+	BatchSize  int                                   // the most points written in a single request
+	MaxRetries int                                   // the number of times a failed write is retried
+	RetryDelay time.Duration                         // the delay before the first retry, doubled with each retry
+	Timeout    time.Duration                         // the timeout of each write
+	Proxy      func(*http.Request) (*url.URL, error) // the proxy writes are sent through. nil uses the proxy environment variables
+}
+
+// InfluxV2Client pushes metrics to the /api/v2/write endpoint of InfluxDB 2.x in line protocol, authenticating with
+// an API token
+type InfluxV2Client struct {
+	config InfluxV2Config
+	client *http.Client
+}
+
+// NewInfluxV2Client creates an InfluxV2Client that can be used to push metrics.  Unset batch, retry and timeout
+// settings use their defaults.
+func NewInfluxV2Client(config InfluxV2Config) (*InfluxV2Client, error) {
+	if len(config.URL.Host) == 0 {
+		return nil, errors.New("an InfluxDB 2.x URL is required")
+	}
+	if len(config.Token) == 0 {
+		return nil, errors.New("an InfluxDB 2.x API token is required")
+	}
+	if len(config.Org) == 0 {
+		return nil, errors.New("an InfluxDB 2.x organization is required")
+	}
+	if len(config.Bucket) == 0 {
+		return nil, errors.New("an InfluxDB 2.x bucket is required")
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultInfluxBatchSize
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = DefaultInfluxMaxRetries
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = DefaultInfluxRetryDelay
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultInfluxTimeout
+	}
+	return &InfluxV2Client{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout, Transport: proxyTransport(config.Proxy)},
+	}, nil
+}
+
+// Push accepts a list of metrics, with a metric being defined as a map of string (name) to interface (value).  The
+// points are written in batches of up to BatchSize points.
+func (i *InfluxV2Client) Push(points Metric, tags map[string]string) error {
+	now := time.Now()
+	var lines []string
+	for _, p := range points {
+		for key, val := range p {
+			line, err := influxLine(strings.Replace(key, " ", "_", -1), tags, val, now)
+			if err != nil {
+				return err
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	for len(lines) > 0 {
+		n := i.config.BatchSize
+		if n > len(lines) {
+			n = len(lines)
+		}
+		err := i.writeWithRetry(context.Background(), []byte(strings.Join(lines[:n], "\n")))
+		if err != nil {
+			return err
+		}
+		lines = lines[n:]
+	}
+	return nil
+}
+
+// writeWithRetry writes a batch of lines and retries transient failures with an exponential backoff
+func (i *InfluxV2Client) writeWithRetry(ctx context.Context, body []byte) error {
+	delay := i.config.RetryDelay
+	var err error
+	for try := 0; try <= i.config.MaxRetries; try++ {
+		if try > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay = delay + delay
+		}
+		var retry bool
+		retry, err = i.write(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry {
+			return err
+		}
+	}
+	return fmt.Errorf("failed to write to InfluxDB after %d retries: %w", i.config.MaxRetries, err)
+}
+
+// write posts a batch of lines to the write endpoint.  true is returned with the error if the write failed in a way
+// that is worth retrying, such as a network error, rate limiting or a server error.
+func (i *InfluxV2Client) write(ctx context.Context, body []byte) (bool, error) {
+	u := i.config.URL
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v2/write"
+	u.RawQuery = url.Values{"org": {i.config.Org}, "bucket": {i.config.Bucket}, "precision": {"ns"}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create InfluxDB write request: %w", err)
+	}
+	req.Header.Set("Authorization", "Token "+i.config.Token)
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return false, nil
+	}
+	err = fmt.Errorf("InfluxDB responded to write with status %s: %s", resp.Status, strings.TrimSpace(string(b)))
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// influxLine formats a point in line protocol with the value as its value field.  Tags with blank values are left
+// out, since line protocol does not allow them.
+func influxLine(measurement string, tags map[string]string, value interface{}, t time.Time) (string, error) {
+	var field string
+	switch v := value.(type) {
+	case int:
+		field = strconv.Itoa(v) + "i"
+	case int64:
+		field = strconv.FormatInt(v, 10) + "i"
+	case float64:
+		field = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		field = strconv.FormatBool(v)
+	case string:
+		field = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+	default:
+		return "", fmt.Errorf("unable to write metric %s with a value of type %T to InfluxDB", measurement, value)
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	line := influxMeasurementEscaper.Replace(measurement)
+	for _, k := range keys {
+		if len(tags[k]) == 0 {
+			continue
+		}
+		line += "," + influxTagEscaper.Replace(k) + "=" + influxTagEscaper.Replace(tags[k])
+	}
+	return line + " value=" + field + " " + strconv.FormatInt(t.UnixNano(), 10), nil
+}
+
+// influxMeasurementEscaper escapes the characters of measurement names that have a meaning in line protocol
+var influxMeasurementEscaper = strings.NewReplacer(",", `\,`, " ", `\ `, "\n", `\ `)
+
+// influxTagEscaper escapes the characters of tag keys and values that have a meaning in line protocol
+var influxTagEscaper = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `, "\n", `\ `)
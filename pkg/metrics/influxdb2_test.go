@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testInfluxV2Server starts a server that responds to writes with the supplied statuses in turn and records the
+// bodies it received
+func testInfluxV2Server(t *testing.T, statuses ...int) (*InfluxV2Client, *[]string) {
+	var mu sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/write" || r.URL.Query().Get("org") != "sre" || r.URL.Query().Get("bucket") != "kuberhealthy" {
+			t.Error("unexpected write request:", r.URL.String())
+		}
+		if r.Header.Get("Authorization") != "Token secret-token" {
+			t.Error("expected the API token but got", r.Header.Get("Authorization"))
+		}
+		b, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, string(b))
+		status := http.StatusNoContent
+		if len(statuses) > 0 {
+			status, statuses = statuses[0], statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	u, _ := url.Parse(server.URL)
+	client, err := NewInfluxV2Client(InfluxV2Config{URL: *u, Token: "secret-token", Org: "sre", Bucket: "kuberhealthy", BatchSize: 2, RetryDelay: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client, &bodies
+}
+
+// TestInfluxV2Push ensures points are written in line protocol in batches, and that transient failures are retried
+func TestInfluxV2Push(t *testing.T) {
+	client, bodies := testInfluxV2Server(t, http.StatusServiceUnavailable)
+	metric := Metric{
+		{"dns-status.kuberhealthy": 1},
+		{"RunDuration.dns-status.kuberhealthy": 1.5},
+		{"deployment.kuberhealthy": 0},
+	}
+	err := client.Push(metric, map[string]string{"Name": "dns status", "Namespace": "kuberhealthy", "Errors": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*bodies) != 3 {
+		t.Fatal("expected a retried batch of two points and a batch of one point but got", *bodies)
+	}
+	if (*bodies)[0] != (*bodies)[1] || len(strings.Split((*bodies)[1], "\n")) != 2 || len(strings.Split((*bodies)[2], "\n")) != 1 {
+		t.Fatal("expected the first batch to be retried but got", *bodies)
+	}
+	if !strings.HasPrefix((*bodies)[0], `dns-status.kuberhealthy,Name=dns\ status,Namespace=kuberhealthy value=1i `) {
+		t.Fatal("expected the point in line protocol without blank tags but got", (*bodies)[0])
+	}
+	if !strings.Contains((*bodies)[0], " value=1.5 ") {
+		t.Fatal("expected the run duration as a float but got", (*bodies)[0])
+	}
+}
+
+// TestInfluxV2PushPermanentFailure ensures writes that are refused are not retried
+func TestInfluxV2PushPermanentFailure(t *testing.T) {
+	client, bodies := testInfluxV2Server(t, http.StatusUnauthorized)
+	err := client.Push(Metric{{"dns-status.kuberhealthy": 1}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatal("expected the unauthorized write to fail but got", err)
+	}
+	if len(*bodies) != 1 {
+		t.Fatal("expected the refused write to not be retried but got", *bodies)
+	}
+}
+
+func TestNewInfluxV2ClientRequiresSettings(t *testing.T) {
+	u, _ := url.Parse("http://influxdb:8086")
+	_, err := NewInfluxV2Client(InfluxV2Config{URL: *u, Token: "secret-token", Org: "sre"})
+	if err == nil {
+		t.Fatal("expected an error without a bucket")
+	}
+}
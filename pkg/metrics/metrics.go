@@ -1,5 +1,10 @@
 package metrics
 
+import (
+	"net/http"
+	"net/url"
+)
+
 // Metric is a key value struct
 type Metric []map[string]interface{}
 
@@ -7,3 +12,14 @@ type Metric []map[string]interface{}
 type Client interface {
 	Push(points Metric, tags map[string]string) error
 }
+
+// proxyTransport returns the transport of the HTTP clients of providers, which sends requests through the supplied
+// proxy.  The default transport, which uses the proxy environment variables, is returned when the proxy is nil.
+func proxyTransport(proxy func(*http.Request) (*url.URL, error)) http.RoundTripper {
+	if proxy == nil {
+		return http.DefaultTransport
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	return transport
+}
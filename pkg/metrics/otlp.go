@@ -0,0 +1,276 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// OTLPProtocolGRPC sends metrics to the OTLP/gRPC MetricsService of a collector
+const OTLPProtocolGRPC = "grpc"
+
+// OTLPProtocolHTTP posts metrics in protobuf to the OTLP/HTTP /v1/metrics path of a collector
+const OTLPProtocolHTTP = "http"
+
+// otlpExportMethod is the full gRPC method name of the OTLP metrics export RPC
+const otlpExportMethod = "/opentelemetry.proto.collector.metrics.v1.MetricsService/Export"
+
+// otlpHTTPPath is the path metrics are posted to when the OTLP/HTTP endpoint has no path of its own
+const otlpHTTPPath = "/v1/metrics"
+
+// otlpScopeName is the instrumentation scope of the exported metrics
+const otlpScopeName = "github.com/kuberhealthy/kuberhealthy"
+
+// DefaultOTLPTimeout is the timeout of each export
+const DefaultOTLPTimeout = time.Second * 10
+
+// OTLPConfig configures the OTLP client
+type OTLPConfig struct {
+	Endpoint           string                                // the host:port of the collector for gRPC, or its URL for HTTP
+	Protocol           string                                // OTLPProtocolGRPC or OTLPProtocolHTTP
+	Headers            map[string]string                     // headers sent with every export, such as authorization
+	Insecure           bool                                  // connect to a gRPC endpoint without TLS
+	ResourceAttributes map[string]string                     // attributes of the resource the metrics belong to, such as service.name
+	Timeout            time.Duration                         // the timeout of each export
+	Proxy              func(*http.Request) (*url.URL, error) // the proxy exports over http are sent through. nil uses the proxy environment variables
+}
+
+// OTLPClient exports metrics as OTLP gauges to an OpenTelemetry collector.  Requests are encoded in the protobuf wire
+// format of the OTLP metrics protos, so that Kuberhealthy does not depend on generated code.
+type OTLPClient struct {
+	config OTLPConfig
+	conn   *grpc.ClientConn
+	client *http.Client
+	url    string
+}
+
+// NewOTLPClient creates an OTLPClient that can be used to push metrics.  gRPC connections are established in the
+// background.
+func NewOTLPClient(config OTLPConfig) (*OTLPClient, error) {
+	if len(config.Endpoint) == 0 {
+		return nil, errors.New("an OTLP endpoint is required")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultOTLPTimeout
+	}
+	c := &OTLPClient{config: config}
+	switch config.Protocol {
+	case OTLPProtocolGRPC:
+		creds := credentials.NewTLS(&tls.Config{})
+		if config.Insecure {
+			creds = insecure.NewCredentials()
+		}
+		conn, err := grpc.Dial(config.Endpoint, grpc.WithTransportCredentials(creds), grpc.WithDefaultCallOptions(grpc.ForceCodec(otlpCodec{})))
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to the OTLP endpoint %s: %w", config.Endpoint, err)
+		}
+		c.conn = conn
+	case OTLPProtocolHTTP:
+		c.url = config.Endpoint
+		if !strings.Contains(strings.TrimPrefix(strings.TrimPrefix(c.url, "http://"), "https://"), "/") {
+			c.url = strings.TrimSuffix(c.url, "/") + otlpHTTPPath
+		}
+		c.client = &http.Client{Timeout: config.Timeout, Transport: proxyTransport(config.Proxy)}
+	default:
+		return nil, fmt.Errorf("unsupported OTLP protocol %q. Use %s or %s", config.Protocol, OTLPProtocolGRPC, OTLPProtocolHTTP)
+	}
+	return c, nil
+}
+
+// Push accepts a list of metrics, with a metric being defined as a map of string (name) to interface (value).  Each
+// metric is exported as a gauge with the tags as its attributes.
+func (o *OTLPClient) Push(points Metric, tags map[string]string) error {
+	req, err := encodeOTLPExportRequest(points, tags, o.config.ResourceAttributes, time.Now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.config.Timeout)
+	defer cancel()
+	if o.conn != nil {
+		if len(o.config.Headers) > 0 {
+			ctx = metadata.NewOutgoingContext(ctx, metadata.New(o.config.Headers))
+		}
+		err = o.conn.Invoke(ctx, otlpExportMethod, &otlpMessage{b: req}, &otlpMessage{})
+		if err != nil {
+			return fmt.Errorf("failed to export metrics to %s: %w", o.config.Endpoint, err)
+		}
+		return nil
+	}
+	return o.post(ctx, req)
+}
+
+// Close closes the gRPC connection to the collector
+func (o *OTLPClient) Close() error {
+	if o.conn == nil {
+		return nil
+	}
+	return o.conn.Close()
+}
+
+// post sends an export request to the OTLP/HTTP endpoint
+func (o *OTLPClient) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, o.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP export request: %w", err)
+	}
+	for k, v := range o.config.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export metrics to %s: %w", o.url, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("OTLP endpoint %s responded to export with status %s", o.url, resp.Status)
+	}
+	return nil
+}
+
+// encodeOTLPExportRequest encodes an ExportMetricsServiceRequest with a gauge for each point
+func encodeOTLPExportRequest(points Metric, tags map[string]string, resource map[string]string, now time.Time) ([]byte, error) {
+	var metrics []byte
+	for _, p := range points {
+		for key, val := range p {
+			// NumberDataPoint: attributes = 7, time_unix_nano = 3, as_double = 4, as_int = 6
+			var dataPoint []byte
+			dataPoint = appendOTLPAttributes(dataPoint, 7, tags)
+			dataPoint = protowire.AppendTag(dataPoint, 3, protowire.Fixed64Type)
+			dataPoint = protowire.AppendFixed64(dataPoint, uint64(now.UnixNano()))
+			switch v := val.(type) {
+			case int:
+				dataPoint = protowire.AppendTag(dataPoint, 6, protowire.Fixed64Type)
+				dataPoint = protowire.AppendFixed64(dataPoint, uint64(int64(v)))
+			case int64:
+				dataPoint = protowire.AppendTag(dataPoint, 6, protowire.Fixed64Type)
+				dataPoint = protowire.AppendFixed64(dataPoint, uint64(v))
+			case float64:
+				dataPoint = protowire.AppendTag(dataPoint, 4, protowire.Fixed64Type)
+				dataPoint = protowire.AppendFixed64(dataPoint, math.Float64bits(v))
+			default:
+				return nil, fmt.Errorf("unable to export metric %s with a value of type %T over OTLP", key, val)
+			}
+
+			// Gauge: data_points = 1
+			var gauge []byte
+			gauge = protowire.AppendTag(gauge, 1, protowire.BytesType)
+			gauge = protowire.AppendBytes(gauge, dataPoint)
+
+			// Metric: name = 1, gauge = 5
+			var metric []byte
+			metric = protowire.AppendTag(metric, 1, protowire.BytesType)
+			metric = protowire.AppendString(metric, key)
+			metric = protowire.AppendTag(metric, 5, protowire.BytesType)
+			metric = protowire.AppendBytes(metric, gauge)
+
+			// ScopeMetrics: metrics = 2
+			metrics = protowire.AppendTag(metrics, 2, protowire.BytesType)
+			metrics = protowire.AppendBytes(metrics, metric)
+		}
+	}
+
+	// InstrumentationScope: name = 1
+	var scope []byte
+	scope = protowire.AppendTag(scope, 1, protowire.BytesType)
+	scope = protowire.AppendString(scope, otlpScopeName)
+
+	// ScopeMetrics: scope = 1
+	var scopeMetrics []byte
+	scopeMetrics = protowire.AppendTag(scopeMetrics, 1, protowire.BytesType)
+	scopeMetrics = protowire.AppendBytes(scopeMetrics, scope)
+	scopeMetrics = append(scopeMetrics, metrics...)
+
+	// ResourceMetrics: resource = 1, scope_metrics = 2.  Resource: attributes = 1
+	var resourceMetrics []byte
+	resourceMetrics = protowire.AppendTag(resourceMetrics, 1, protowire.BytesType)
+	resourceMetrics = protowire.AppendBytes(resourceMetrics, appendOTLPAttributes(nil, 1, resource))
+	resourceMetrics = protowire.AppendTag(resourceMetrics, 2, protowire.BytesType)
+	resourceMetrics = protowire.AppendBytes(resourceMetrics, scopeMetrics)
+
+	// ExportMetricsServiceRequest: resource_metrics = 1
+	var req []byte
+	req = protowire.AppendTag(req, 1, protowire.BytesType)
+	req = protowire.AppendBytes(req, resourceMetrics)
+	return req, nil
+}
+
+// appendOTLPAttributes appends a KeyValue field with the supplied number for each attribute with a value.  Attributes
+// are sorted by key.
+func appendOTLPAttributes(b []byte, num protowire.Number, attributes map[string]string) []byte {
+	keys := make([]string, 0, len(attributes))
+	for k, v := range attributes {
+		if len(v) > 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		// AnyValue: string_value = 1
+		var value []byte
+		value = protowire.AppendTag(value, 1, protowire.BytesType)
+		value = protowire.AppendString(value, attributes[k])
+
+		// KeyValue: key = 1, value = 2
+		var kv []byte
+		kv = protowire.AppendTag(kv, 1, protowire.BytesType)
+		kv = protowire.AppendString(kv, k)
+		kv = protowire.AppendTag(kv, 2, protowire.BytesType)
+		kv = protowire.AppendBytes(kv, value)
+
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		b = protowire.AppendBytes(b, kv)
+	}
+	return b
+}
+
+// otlpMessage is an encoded OTLP request or response
+type otlpMessage struct {
+	b []byte
+}
+
+// otlpCodec passes encoded OTLP messages to and from gRPC as they are.  Its name is proto so that the content type
+// matches that of generated clients.
+type otlpCodec struct{}
+
+// Name returns the name of the codec
+func (otlpCodec) Name() string {
+	return "proto"
+}
+
+// Marshal returns the bytes of an encoded message
+func (otlpCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(*otlpMessage)
+	if !ok {
+		return nil, fmt.Errorf("unable to marshal %T as an OTLP message", v)
+	}
+	return m.b, nil
+}
+
+// Unmarshal keeps the bytes of an encoded message
+func (otlpCodec) Unmarshal(data []byte, v interface{}) error {
+	m, ok := v.(*otlpMessage)
+	if !ok {
+		return fmt.Errorf("unable to unmarshal %T as an OTLP message", v)
+	}
+	m.b = append([]byte(nil), data...)
+	return nil
+}
@@ -0,0 +1,180 @@
+package metrics
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// otlpFields returns the values of every length delimited field with the supplied number in an encoded message
+func otlpFields(t *testing.T, b []byte, num protowire.Number) [][]byte {
+	var fields [][]byte
+	for len(b) > 0 {
+		n, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			t.Fatal("invalid OTLP message:", protowire.ParseError(l))
+		}
+		b = b[l:]
+		l = protowire.ConsumeFieldValue(n, typ, b)
+		if l < 0 {
+			t.Fatal("invalid OTLP message:", protowire.ParseError(l))
+		}
+		if n == num && typ == protowire.BytesType {
+			v, _ := protowire.ConsumeBytes(b)
+			fields = append(fields, v)
+		}
+		b = b[l:]
+	}
+	return fields
+}
+
+// otlpMetricNames decodes an ExportMetricsServiceRequest and returns the sorted names of its metrics and the
+// attributes of the resource and of the first data point as key=value pairs
+func otlpMetricNames(t *testing.T, req []byte) ([]string, []string, []string) {
+	resourceMetrics := otlpFields(t, req, 1)
+	if len(resourceMetrics) != 1 {
+		t.Fatal("expected one ResourceMetrics but got", len(resourceMetrics))
+	}
+	attributes := func(kvs [][]byte) []string {
+		var pairs []string
+		for _, kv := range kvs {
+			value := otlpFields(t, kv, 2)[0]
+			pairs = append(pairs, string(otlpFields(t, kv, 1)[0])+"="+string(otlpFields(t, value, 1)[0]))
+		}
+		return pairs
+	}
+	resource := attributes(otlpFields(t, otlpFields(t, resourceMetrics[0], 1)[0], 1))
+
+	var names, pointAttributes []string
+	for _, scopeMetrics := range otlpFields(t, resourceMetrics[0], 2) {
+		for _, metric := range otlpFields(t, scopeMetrics, 2) {
+			names = append(names, string(otlpFields(t, metric, 1)[0]))
+			if pointAttributes == nil {
+				dataPoint := otlpFields(t, otlpFields(t, metric, 5)[0], 1)[0]
+				pointAttributes = attributes(otlpFields(t, dataPoint, 7))
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, resource, pointAttributes
+}
+
+// TestOTLPPushHTTP ensures metrics are posted to the /v1/metrics path as protobuf gauges
+func TestOTLPPushHTTP(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/metrics" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			t.Error("unexpected export request:", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Error("expected the configured header but got", r.Header.Get("Authorization"))
+		}
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	client, err := NewOTLPClient(OTLPConfig{
+		Endpoint:           server.URL,
+		Protocol:           OTLPProtocolHTTP,
+		Headers:            map[string]string{"Authorization": "Bearer secret"},
+		ResourceAttributes: map[string]string{"service.name": "kuberhealthy", "k8s.cluster.name": ""},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Push(Metric{{"dns-status.kuberhealthy": 1}, {"RunDuration.dns-status.kuberhealthy": 1.5}}, map[string]string{"Name": "dns-status", "Errors": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, resource, attributes := otlpMetricNames(t, body)
+	if strings.Join(names, " ") != "RunDuration.dns-status.kuberhealthy dns-status.kuberhealthy" {
+		t.Fatal("expected a gauge for each point but got", names)
+	}
+	if strings.Join(resource, " ") != "service.name=kuberhealthy" {
+		t.Fatal("expected the resource attributes without blank values but got", resource)
+	}
+	if strings.Join(attributes, " ") != "Name=dns-status" {
+		t.Fatal("expected the tags as data point attributes but got", attributes)
+	}
+}
+
+// TestOTLPPushHTTPFailure ensures exports the collector refuses return an error
+func TestOTLPPushHTTPFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	client, err := NewOTLPClient(OTLPConfig{Endpoint: server.URL, Protocol: OTLPProtocolHTTP})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Push(Metric{{"dns-status.kuberhealthy": 0}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Fatal("expected the refused export to fail but got", err)
+	}
+}
+
+// TestOTLPPushGRPC ensures metrics are exported to the MetricsService Export method with the headers as metadata
+func TestOTLPPushGRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan []byte, 1)
+	server := grpc.NewServer(grpc.ForceServerCodec(otlpCodec{}), grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error {
+		method, _ := grpc.MethodFromServerStream(stream)
+		if method != otlpExportMethod {
+			t.Error("unexpected method:", method)
+		}
+		md, _ := metadata.FromIncomingContext(stream.Context())
+		if len(md.Get("authorization")) != 1 || md.Get("authorization")[0] != "Bearer secret" {
+			t.Error("expected the configured header as metadata but got", md)
+		}
+		var req otlpMessage
+		err := stream.RecvMsg(&req)
+		if err != nil {
+			return err
+		}
+		received <- req.b
+		return stream.SendMsg(&otlpMessage{})
+	}))
+	go server.Serve(listener)
+	defer server.Stop()
+
+	client, err := NewOTLPClient(OTLPConfig{
+		Endpoint: listener.Addr().String(),
+		Protocol: OTLPProtocolGRPC,
+		Headers:  map[string]string{"Authorization": "Bearer secret"},
+		Insecure: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	err = client.Push(Metric{{"dns-status.kuberhealthy": 1}}, map[string]string{"Name": "dns-status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names, _, _ := otlpMetricNames(t, <-received)
+	if strings.Join(names, " ") != "dns-status.kuberhealthy" {
+		t.Fatal("expected the gauge to be exported but got", names)
+	}
+}
+
+func TestNewOTLPClientRequiresProtocol(t *testing.T) {
+	_, err := NewOTLPClient(OTLPConfig{Endpoint: "collector:4317", Protocol: "udp"})
+	if err == nil {
+		t.Fatal("expected an error for an unsupported protocol")
+	}
+}
@@ -0,0 +1,154 @@
+package metrics
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+	"github.com/kuberhealthy/kuberhealthy/v2/pkg/checks/external/status"
+)
+
+// DefaultPushgatewayJob is the job label of the groups Kuberhealthy pushes to the Pushgateway
+const DefaultPushgatewayJob = "kuberhealthy"
+
+// DefaultPushgatewayTimeout is the timeout of each request to the Pushgateway
+const DefaultPushgatewayTimeout = time.Second * 10
+
+// PushgatewayConfig configures the Pushgateway client
+type PushgatewayConfig struct {
+	URL     url.URL                               // the address of the Pushgateway, without the /metrics path
+	Job     string                                // the job grouping label
+	Headers map[string]string                     // headers sent with every request, such as authorization
+	Timeout time.Duration                         // the timeout of each request
+	Proxy   func(*http.Request) (*url.URL, error) // the proxy requests are sent through. nil uses the proxy environment variables
+}
+
+// PushgatewayClient replaces and deletes groups of metrics on a Prometheus Pushgateway
+type PushgatewayClient struct {
+	config PushgatewayConfig
+	client *http.Client
+}
+
+// NewPushgatewayClient creates a PushgatewayClient.  An unset job and timeout use their defaults.
+func NewPushgatewayClient(config PushgatewayConfig) (*PushgatewayClient, error) {
+	if len(config.URL.Host) == 0 {
+		return nil, errors.New("a Pushgateway URL is required")
+	}
+	if len(config.Job) == 0 {
+		config.Job = DefaultPushgatewayJob
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultPushgatewayTimeout
+	}
+	return &PushgatewayClient{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout, Transport: proxyTransport(config.Proxy)},
+	}, nil
+}
+
+// Push replaces the metrics of a group with the supplied metrics in the Prometheus text format
+func (p *PushgatewayClient) Push(grouping map[string]string, metrics string) error {
+	return p.do(http.MethodPut, grouping, metrics)
+}
+
+// Delete removes the metrics of a group
+func (p *PushgatewayClient) Delete(grouping map[string]string) error {
+	return p.do(http.MethodDelete, grouping, "")
+}
+
+// do sends a request to the URL of a group
+func (p *PushgatewayClient) do(method string, grouping map[string]string, body string) error {
+	u := p.config.URL
+	u.Path = strings.TrimSuffix(u.Path, "/") + pushgatewayGroupPath(p.config.Job, grouping)
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create Pushgateway request: %w", err)
+	}
+	for k, v := range p.config.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s request to the Pushgateway: %w", method, err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("Pushgateway responded to %s with status %s: %s", method, resp.Status, strings.TrimSpace(string(b)))
+	}
+	return nil
+}
+
+// pushgatewayGroupPath returns the path of a group.  Label values are base64 encoded, so that values with slashes,
+// such as the namespace/name of a check, can be used.
+func pushgatewayGroupPath(job string, grouping map[string]string) string {
+	path := "/metrics/job@base64/" + base64.RawURLEncoding.EncodeToString([]byte(job))
+	keys := make([]string, 0, len(grouping))
+	for k := range grouping {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		// a single = is how the Pushgateway reads an empty base64 value
+		value := base64.RawURLEncoding.EncodeToString([]byte(grouping[k]))
+		if len(value) == 0 {
+			value = "="
+		}
+		path += "/" + k + "@base64/" + value
+	}
+	return path
+}
+
+// GenerateWorkloadMetrics returns the metrics of a single check or job in the Prometheus text format, named and
+// labeled the same way GenerateMetrics does.  The key is the namespace/name of the workload.
+func GenerateWorkloadMetrics(workload khstatev1.KHWorkload, key string, details khstatev1.WorkloadDetails, config PromMetricsConfig) string {
+	kind := "check"
+	if workload == khstatev1.KHJob {
+		kind = "job"
+	}
+	workloadStatus := "0"
+	if details.OK {
+		workloadStatus = "1"
+	}
+	var runDuration time.Duration
+	if len(details.RunDuration) > 0 {
+		runDuration, _ = time.ParseDuration(details.RunDuration)
+	}
+	labels := fmt.Sprintf("{check=\"%s\",namespace=\"%s\"}", key, details.Namespace)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("# HELP kuberhealthy_%s Shows the status of a Kuberhealthy %s\n", kind, kind))
+	b.WriteString(fmt.Sprintf("# TYPE kuberhealthy_%s gauge\n", kind))
+	b.WriteString(fmt.Sprintf("%s %s\n", promMetricName(config, kind, key, details.Namespace, workloadStatus, details.Errors), workloadStatus))
+	b.WriteString(fmt.Sprintf("# HELP kuberhealthy_%s_duration_seconds Shows the %s run duration of a Kuberhealthy %s\n", kind, kind, kind))
+	b.WriteString(fmt.Sprintf("# TYPE kuberhealthy_%s_duration_seconds gauge\n", kind))
+	b.WriteString(fmt.Sprintf("kuberhealthy_%s_duration_seconds%s %f\n", kind, labels, runDuration.Seconds()))
+	if details.LastRun != nil {
+		b.WriteString(fmt.Sprintf("# HELP kuberhealthy_%s_last_run_timestamp_seconds Shows the unix time a Kuberhealthy %s last ran\n", kind, kind))
+		b.WriteString(fmt.Sprintf("# TYPE kuberhealthy_%s_last_run_timestamp_seconds gauge\n", kind))
+		b.WriteString(fmt.Sprintf("kuberhealthy_%s_last_run_timestamp_seconds%s %d\n", kind, labels, details.LastRun.Unix()))
+	}
+	if kind == "check" {
+		checkWarning := "0"
+		if !details.OK && details.Severity == status.SeverityWarning {
+			checkWarning = "1"
+		}
+		b.WriteString("# HELP kuberhealthy_check_warning Shows if a Kuberhealthy check failed with the Warning severity, which does not affect the cluster state\n")
+		b.WriteString("# TYPE kuberhealthy_check_warning gauge\n")
+		b.WriteString(fmt.Sprintf("kuberhealthy_check_warning%s %s\n", labels, checkWarning))
+	}
+	return b.String()
+}
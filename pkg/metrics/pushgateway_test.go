@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// TestPushgatewayPushAndDelete ensures groups are replaced and deleted at their base64 encoded grouping path
+func TestPushgatewayPushAndDelete(t *testing.T) {
+	var requests []string
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			t.Error("expected the configured header but got", r.Header.Get("Authorization"))
+		}
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	client, err := NewPushgatewayClient(PushgatewayConfig{URL: *u, Headers: map[string]string{"Authorization": "Bearer secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	grouping := map[string]string{"check": "kuberhealthy/dns-status", "namespace": "kuberhealthy", "env": ""}
+	err = client.Push(grouping, "kuberhealthy_check 1\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Delete(grouping)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := "/metrics/job@base64/a3ViZXJoZWFsdGh5/check@base64/a3ViZXJoZWFsdGh5L2Rucy1zdGF0dXM/env@base64/=/namespace@base64/a3ViZXJoZWFsdGh5"
+	if len(requests) != 2 || requests[0] != "PUT "+path || requests[1] != "DELETE "+path {
+		t.Fatal("expected a PUT and a DELETE of the group but got", requests)
+	}
+	if body != "" {
+		t.Fatal("expected the delete to have no body but got", body)
+	}
+}
+
+func TestPushgatewayPushFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "pushed metrics are invalid", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+	client, err := NewPushgatewayClient(PushgatewayConfig{URL: *u})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Push(map[string]string{"check": "kuberhealthy/dns-status"}, "kuberhealthy_check 1\n")
+	if err == nil || !strings.Contains(err.Error(), "pushed metrics are invalid") {
+		t.Fatal("expected the refused push to fail but got", err)
+	}
+}
+
+func TestGenerateWorkloadMetrics(t *testing.T) {
+	lastRun := metav1.NewTime(time.Unix(1700000000, 0))
+	details := khstatev1.WorkloadDetails{OK: false, Errors: []string{"timeout"}, Namespace: "kuberhealthy", RunDuration: "1.5s", LastRun: &lastRun, Severity: "Warning"}
+	m := GenerateWorkloadMetrics(khstatev1.KHCheck, "kuberhealthy/dns-status", details, PromMetricsConfig{})
+	for _, expected := range []string{
+		`kuberhealthy_check{check="kuberhealthy/dns-status",namespace="kuberhealthy",status="0",error="timeout"} 0`,
+		`kuberhealthy_check_duration_seconds{check="kuberhealthy/dns-status",namespace="kuberhealthy"} 1.500000`,
+		`kuberhealthy_check_last_run_timestamp_seconds{check="kuberhealthy/dns-status",namespace="kuberhealthy"} 1700000000`,
+		`kuberhealthy_check_warning{check="kuberhealthy/dns-status",namespace="kuberhealthy"} 1`,
+	} {
+		if !strings.Contains(m, expected+"\n") {
+			t.Fatal("expected", expected, "in", m)
+		}
+	}
+
+	m = GenerateWorkloadMetrics(khstatev1.KHJob, "kuberhealthy/dns-job", khstatev1.WorkloadDetails{OK: true, Namespace: "kuberhealthy"}, PromMetricsConfig{SuppressErrorLabel: true})
+	if !strings.Contains(m, `kuberhealthy_job{check="kuberhealthy/dns-job",namespace="kuberhealthy",status="1"} 1`) || strings.Contains(m, "warning") {
+		t.Fatal("expected the job metrics without a warning gauge but got", m)
+	}
+}
@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultStatsDPrefix is the prefix of the names of metrics sent over StatsD
+const DefaultStatsDPrefix = "kuberhealthy"
+
+// maxStatsDPacketSize keeps StatsD packets within the MTU of most networks, so that they are not fragmented
+const maxStatsDPacketSize = 1432
+
+// StatsDConfig configures the StatsD client
+type StatsDConfig struct {
+	Address   string            // the host:port of the StatsD server or agent
+	Prefix    string            // the prefix of metric names, joined to them with a dot
+	Tags      map[string]string // tags sent with every metric when DogStatsD is set
+	DogStatsD bool              // send tags in the DogStatsD format.  plain StatsD has no tags, so they are left out otherwise
+}
+
+// StatsDClient sends metrics as StatsD gauges over UDP
+type StatsDClient struct {
+	config StatsDConfig
+	conn   net.Conn
+}
+
+// NewStatsDClient creates a StatsDClient that can be used to push metrics
+func NewStatsDClient(config StatsDConfig) (*StatsDClient, error) {
+	if len(config.Address) == 0 {
+		return nil, errors.New("a StatsD address is required")
+	}
+	conn, err := net.DialTimeout("udp", config.Address, time.Second*5)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to StatsD at %s: %w", config.Address, err)
+	}
+	return &StatsDClient{
+		config: config,
+		conn:   conn,
+	}, nil
+}
+
+// Push accepts a list of metrics, with a metric being defined as a map of string (name) to interface (value).  Each
+// metric is sent as a gauge.  Metrics are packed into as few packets as fit.
+func (s *StatsDClient) Push(points Metric, tags map[string]string) error {
+	suffix := s.tagSuffix(tags)
+	var lines []string
+	for _, p := range points {
+		for key, val := range p {
+			value, err := statsDValue(val)
+			if err != nil {
+				return fmt.Errorf("unable to send metric %s to StatsD: %w", key, err)
+			}
+			lines = append(lines, s.metricName(key)+":"+value+"|g"+suffix)
+		}
+	}
+
+	var packet string
+	for _, line := range lines {
+		if len(packet) > 0 && len(packet)+1+len(line) > maxStatsDPacketSize {
+			err := s.send(packet)
+			if err != nil {
+				return err
+			}
+			packet = ""
+		}
+		if len(packet) > 0 {
+			packet += "\n"
+		}
+		packet += line
+	}
+	if len(packet) == 0 {
+		return nil
+	}
+	return s.send(packet)
+}
+
+// Close closes the connection to the StatsD server
+func (s *StatsDClient) Close() error {
+	return s.conn.Close()
+}
+
+// send writes a packet to the StatsD server
+func (s *StatsDClient) send(packet string) error {
+	_, err := s.conn.Write([]byte(packet))
+	if err != nil {
+		return fmt.Errorf("failed to send metrics to StatsD at %s: %w", s.config.Address, err)
+	}
+	return nil
+}
+
+// metricName returns the prefixed name of a metric with the characters StatsD reserves replaced
+func (s *StatsDClient) metricName(key string) string {
+	name := statsDNameReplacer.Replace(key)
+	if len(s.config.Prefix) == 0 {
+		return name
+	}
+	return s.config.Prefix + "." + name
+}
+
+// tagSuffix returns the DogStatsD tags of a metric, made of the configured tags and the supplied ones.  The supplied
+// tags take precedence.  Tags with blank values are left out.
+func (s *StatsDClient) tagSuffix(tags map[string]string) string {
+	if !s.config.DogStatsD {
+		return ""
+	}
+	all := make(map[string]string, len(s.config.Tags)+len(tags))
+	for k, v := range s.config.Tags {
+		all[k] = v
+	}
+	for k, v := range tags {
+		all[k] = v
+	}
+	keys := make([]string, 0, len(all))
+	for k, v := range all {
+		if len(v) > 0 {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, statsDTagReplacer.Replace(k)+":"+statsDTagReplacer.Replace(all[k]))
+	}
+	return "|#" + strings.Join(pairs, ",")
+}
+
+// statsDValue formats the value of a gauge
+func statsDValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	}
+	return "", fmt.Errorf("unsupported value of type %T", value)
+}
+
+// statsDNameReplacer replaces the characters that separate the parts of a StatsD line in metric names
+var statsDNameReplacer = strings.NewReplacer(":", "_", "|", "_", "@", "_", " ", "_", "\n", "_")
+
+// statsDTagReplacer replaces the characters that separate DogStatsD tags and lines in tag keys and values
+var statsDTagReplacer = strings.NewReplacer(",", "_", "|", "_", ":", "_", "\n", "_")
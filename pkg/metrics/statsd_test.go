@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testStatsDServer listens for StatsD packets and returns the client of the supplied config pointed at it
+func testStatsDServer(t *testing.T, config StatsDConfig) (*StatsDClient, net.PacketConn) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	config.Address = server.LocalAddr().String()
+	client, err := NewStatsDClient(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client, server
+}
+
+// readStatsDPacket reads a packet from the test server
+func readStatsDPacket(t *testing.T, server net.PacketConn) string {
+	b := make([]byte, maxStatsDPacketSize*2)
+	err := server.SetReadDeadline(time.Now().Add(time.Second * 5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _, err := server.ReadFrom(b)
+	if err != nil {
+		t.Fatal("failed to read a StatsD packet:", err)
+	}
+	return string(b[:n])
+}
+
+// TestStatsDPush ensures metrics are sent as prefixed gauges with DogStatsD tags
+func TestStatsDPush(t *testing.T) {
+	client, server := testStatsDServer(t, StatsDConfig{Prefix: "kuberhealthy", Tags: map[string]string{"cluster": "prod", "env": "prod"}, DogStatsD: true})
+	err := client.Push(Metric{
+		{"dns-status.kuberhealthy": 1},
+		{"RunDuration.dns-status.kuberhealthy": 1.5},
+	}, map[string]string{"Name": "dns-status", "env": "staging", "Errors": "", "KuberhealthyPod": "kh:1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(readStatsDPacket(t, server), "\n")
+	if len(lines) != 2 {
+		t.Fatal("expected both metrics in one packet but got", lines)
+	}
+	tags := "|#KuberhealthyPod:kh_1,Name:dns-status,cluster:prod,env:staging"
+	if lines[0] != "kuberhealthy.dns-status.kuberhealthy:1|g"+tags {
+		t.Fatal("unexpected check status line:", lines[0])
+	}
+	if lines[1] != "kuberhealthy.RunDuration.dns-status.kuberhealthy:1.5|g"+tags {
+		t.Fatal("unexpected run duration line:", lines[1])
+	}
+}
+
+// TestStatsDPushPlain ensures tags are left out of plain StatsD and that large pushes are split into packets
+func TestStatsDPushPlain(t *testing.T) {
+	client, server := testStatsDServer(t, StatsDConfig{Tags: map[string]string{"cluster": "prod"}})
+	var metric Metric
+	for i := 0; i < 100; i++ {
+		metric = append(metric, map[string]interface{}{"check-" + strings.Repeat("x", 20): i})
+	}
+	err := client.Push(metric, map[string]string{"Name": "dns-status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines int
+	for lines < 100 {
+		packet := readStatsDPacket(t, server)
+		if len(packet) > maxStatsDPacketSize || strings.Contains(packet, "|#") {
+			t.Fatal("expected packets within the size limit without tags but got", packet)
+		}
+		lines += len(strings.Split(packet, "\n"))
+	}
+}
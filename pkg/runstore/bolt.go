@@ -0,0 +1,151 @@
+package runstore
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// boltOpenTimeout is how long opening a BoltDB file waits for another process to release its lock
+const boltOpenTimeout = time.Second * 10
+
+// Bolt is a store that keeps results in a BoltDB file, so that they survive restarts when the file is kept on a
+// persistent volume.  Each check has a bucket of its own with its results as JSON by run UUID.
+type Bolt struct {
+	db *bolt.DB
+}
+
+// OpenBolt opens the BoltDB file at the supplied path, creating it if it does not exist.  A file can only be opened
+// by one process at a time.
+func OpenBolt(path string) (*Bolt, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("a file is required to store run results with %s", BackendBolt)
+	}
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open run store %s: %w", path, err)
+	}
+	return &Bolt{db: db}, nil
+}
+
+// Update updates the result of the run with the supplied UUID, adding a result for the run if it has none
+func (b *Bolt) Update(check string, uuid string, now time.Time, update func(r *khstatev1.RunResult, found bool)) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(check))
+		if err != nil {
+			return err
+		}
+
+		// runs without a UUID are kept under a key of their own so that they never update another result
+		key := []byte(uuid)
+		if len(uuid) == 0 {
+			seq, err := bucket.NextSequence()
+			if err != nil {
+				return err
+			}
+			key = []byte(fmt.Sprintf("%d-%d", now.UnixNano(), seq))
+		}
+
+		var r khstatev1.RunResult
+		v := bucket.Get(key)
+		found := v != nil
+		if found {
+			err = json.Unmarshal(v, &r)
+			if err != nil {
+				return fmt.Errorf("failed to read the result of run %s of check %s: %w", key, check, err)
+			}
+		}
+		r.UUID = uuid
+		update(&r, found)
+		r.Time = metav1.NewTime(now)
+		v, err = json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(key, v)
+	})
+}
+
+// Results returns the results of a check recorded at or after since, oldest first
+func (b *Bolt) Results(check string, since time.Time) ([]khstatev1.RunResult, error) {
+	var results []khstatev1.RunResult
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(check))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var r khstatev1.RunResult
+			err := json.Unmarshal(v, &r)
+			if err != nil {
+				return fmt.Errorf("failed to read the result of run %s of check %s: %w", k, check, err)
+			}
+			if !r.Time.Time.Before(since) {
+				results = append(results, r)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	sortResults(results)
+	return results, nil
+}
+
+// Prune removes the results of all checks recorded before the supplied time.  Results that can not be read are
+// removed as well.
+func (b *Bolt) Prune(before time.Time) (int, error) {
+	var pruned int
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		var emptied [][]byte
+		err := tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			var expired [][]byte
+			var kept int
+			err := bucket.ForEach(func(k, v []byte) error {
+				var r khstatev1.RunResult
+				if json.Unmarshal(v, &r) != nil || r.Time.Time.Before(before) {
+					expired = append(expired, append([]byte(nil), k...))
+					return nil
+				}
+				kept++
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			for _, k := range expired {
+				err = bucket.Delete(k)
+				if err != nil {
+					return err
+				}
+			}
+			pruned += len(expired)
+			if kept == 0 {
+				emptied = append(emptied, append([]byte(nil), name...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, name := range emptied {
+			err = tx.DeleteBucket(name)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return pruned, err
+}
+
+// Close closes the BoltDB file
+func (b *Bolt) Close() error {
+	return b.db.Close()
+}
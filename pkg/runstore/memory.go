@@ -0,0 +1,87 @@
+package runstore
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// Memory is a store that keeps results in memory.  Its results are lost when Kuberhealthy restarts.
+type Memory struct {
+	mu      sync.Mutex
+	results map[string][]khstatev1.RunResult // the results of each check, oldest first
+}
+
+// NewMemory creates an empty memory store
+func NewMemory() *Memory {
+	return &Memory{results: make(map[string][]khstatev1.RunResult)}
+}
+
+// Update updates the result of the run with the supplied UUID, adding a result for the run if it has none
+func (m *Memory) Update(check string, uuid string, now time.Time, update func(r *khstatev1.RunResult, found bool)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	results := m.results[check]
+	i := -1
+	if len(uuid) > 0 {
+		for j := range results {
+			if results[j].UUID == uuid {
+				i = j
+			}
+		}
+	}
+	found := i >= 0
+	if !found {
+		results = append(results, khstatev1.RunResult{UUID: uuid})
+		i = len(results) - 1
+	}
+	update(&results[i], found)
+	results[i].Time = metav1.NewTime(now)
+	m.results[check] = results
+	return nil
+}
+
+// Results returns the results of a check recorded at or after since, oldest first
+func (m *Memory) Results(check string, since time.Time) ([]khstatev1.RunResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var results []khstatev1.RunResult
+	for _, r := range m.results[check] {
+		if !r.Time.Time.Before(since) {
+			results = append(results, r)
+		}
+	}
+	sortResults(results)
+	return results, nil
+}
+
+// Prune removes the results of all checks recorded before the supplied time
+func (m *Memory) Prune(before time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var pruned int
+	for check, results := range m.results {
+		kept := results[:0]
+		for _, r := range results {
+			if r.Time.Time.Before(before) {
+				pruned++
+				continue
+			}
+			kept = append(kept, r)
+		}
+		if len(kept) == 0 {
+			delete(m.results, check)
+			continue
+		}
+		m.results[check] = kept
+	}
+	return pruned, nil
+}
+
+// Close releases the store
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,117 @@
+// Package runstore keeps the results of check runs in a store of their own, so that the run history of checks
+// survives restarts of Kuberhealthy and can be summarized into uptime and trends over longer periods than the
+// khstate of a check keeps
+package runstore // import "github.com/kuberhealthy/kuberhealthy/v2/pkg/runstore"
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// the backends a store can be opened with
+const (
+	BackendMemory = "memory" // keeps results in memory until Kuberhealthy restarts
+	BackendBolt   = "bolt"   // keeps results in a BoltDB file
+)
+
+// Store keeps the results of check runs by check.  Checks are identified by their namespace/name.
+type Store interface {
+	// Update updates the result of the run with the supplied UUID, adding a result for the run if it has none.  Runs
+	// without a UUID always add a result.  update is passed the result of the run and whether the run already had
+	// that result.  The time of the result is set to now.
+	Update(check string, uuid string, now time.Time, update func(r *khstatev1.RunResult, found bool)) error
+
+	// Results returns the results of a check recorded at or after since, oldest first
+	Results(check string, since time.Time) ([]khstatev1.RunResult, error)
+
+	// Prune removes the results of all checks recorded before the supplied time and returns how many were removed
+	Prune(before time.Time) (int, error)
+
+	// Close releases the store
+	Close() error
+}
+
+// Open opens a store with the supplied backend.  The path is the file of backends that keep their results in a file.
+func Open(backend string, path string) (Store, error) {
+	switch backend {
+	case BackendMemory:
+		return NewMemory(), nil
+	case BackendBolt:
+		return OpenBolt(path)
+	}
+	return nil, fmt.Errorf("unknown run store backend %q. must be %s or %s", backend, BackendMemory, BackendBolt)
+}
+
+// sortResults sorts results oldest first
+func sortResults(results []khstatev1.RunResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Time.Before(&results[j].Time)
+	})
+}
+
+// Summary summarizes the results of a check over a period of time
+type Summary struct {
+	Start              time.Time // the start of the period
+	Runs               int       // the number of results in the period
+	Passed             int       // the number of results that were OK
+	Failed             int       // the number of results that were not OK
+	Uptime             float64   // the fraction of results that were OK. 1 when there are no results
+	AverageRunDuration string    `json:",omitempty"` // the average run duration of the results that have one
+}
+
+// Summarize summarizes results into a summary of the period that starts at the supplied time
+func Summarize(start time.Time, results []khstatev1.RunResult) Summary {
+	s := Summary{Start: start, Uptime: 1}
+	var total time.Duration
+	var timed int
+	for _, r := range results {
+		s.Runs++
+		if r.OK {
+			s.Passed++
+		} else {
+			s.Failed++
+		}
+		d, err := time.ParseDuration(r.RunDuration)
+		if err == nil {
+			total += d
+			timed++
+		}
+	}
+	if s.Runs > 0 {
+		s.Uptime = float64(s.Passed) / float64(s.Runs)
+	}
+	if timed > 0 {
+		s.AverageRunDuration = (total / time.Duration(timed)).String()
+	}
+	return s
+}
+
+// Trend summarizes results into consecutive periods of the supplied width, starting at the supplied time and ending
+// with the period that holds the end time, so that changes in uptime over time can be seen.  Periods without results
+// are included with an uptime of 1.
+func Trend(results []khstatev1.RunResult, start time.Time, end time.Time, width time.Duration) []Summary {
+	if width <= 0 || end.Before(start) {
+		return nil
+	}
+	results = append([]khstatev1.RunResult(nil), results...)
+	sortResults(results)
+
+	var trend []Summary
+	i := 0
+	for periodStart := start; periodStart.Before(end) || len(trend) == 0; periodStart = periodStart.Add(width) {
+		periodEnd := periodStart.Add(width)
+		for i < len(results) && results[i].Time.Time.Before(periodStart) {
+			i++
+		}
+		j := i
+		for j < len(results) && results[j].Time.Time.Before(periodEnd) {
+			j++
+		}
+		trend = append(trend, Summarize(periodStart, results[i:j]))
+		i = j
+	}
+	return trend
+}
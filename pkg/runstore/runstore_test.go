@@ -0,0 +1,133 @@
+package runstore
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	khstatev1 "github.com/kuberhealthy/kuberhealthy/v2/pkg/apis/khstate/v1"
+)
+
+// TestStores ensures the results of runs are updated in place, listed oldest first and pruned by every backend
+func TestStores(t *testing.T) {
+	for _, backend := range []string{BackendMemory, BackendBolt} {
+		t.Run(backend, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "runs.db")
+			s, err := Open(backend, path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer s.Close()
+
+			now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			for i, uuid := range []string{"a", "b", "c"} {
+				err = s.Update("kuberhealthy/dns", uuid, now.Add(time.Duration(i)*time.Minute), func(r *khstatev1.RunResult, found bool) {
+					if found {
+						t.Fatal("expected no result for run", uuid)
+					}
+					r.OK = uuid != "b"
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			err = s.Update("kuberhealthy/dns", "b", now.Add(time.Minute*3), func(r *khstatev1.RunResult, found bool) {
+				if !found || r.OK {
+					t.Fatal("expected the failed result of run b but got", *r, found)
+				}
+				r.RunDuration = "41s"
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.Update("kuberhealthy/daemonset", "", now, func(r *khstatev1.RunResult, _ bool) { r.OK = true })
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			results, err := s.Results("kuberhealthy/dns", now.Add(time.Minute))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 2 || results[0].UUID != "c" || results[1].UUID != "b" || results[1].RunDuration != "41s" {
+				t.Fatalf("expected the results since the first run oldest first but got %+v", results)
+			}
+
+			pruned, err := s.Prune(now.Add(time.Minute * 2))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if pruned != 2 {
+				t.Fatal("expected run a and the daemonset run to be pruned but", pruned, "were pruned")
+			}
+			results, err = s.Results("kuberhealthy/daemonset", time.Time{})
+			if err != nil || len(results) != 0 {
+				t.Fatal("expected no daemonset results after pruning but got", results, err)
+			}
+		})
+	}
+
+	_, err := Open("sqlite", "")
+	if err == nil {
+		t.Fatal("expected an unknown backend to be rejected")
+	}
+}
+
+// TestBoltSurvivesReopen ensures results kept in a BoltDB file are there when the file is opened again
+func TestBoltSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "runs.db")
+	s, err := OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	err = s.Update("kuberhealthy/dns", "a", now, func(r *khstatev1.RunResult, _ bool) {
+		r.OK = false
+		r.Errors = []string{"lookup failed"}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = s.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = OpenBolt(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	results, err := s.Results("kuberhealthy/dns", now.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].UUID != "a" || len(results[0].Errors) != 1 {
+		t.Fatalf("expected the result of run a after reopening the file but got %+v", results)
+	}
+}
+
+// TestTrend ensures results are summarized into periods, including periods without results
+func TestTrend(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	var results []khstatev1.RunResult
+	for i, ok := range []bool{true, false, true, true} {
+		r := khstatev1.RunResult{OK: ok, RunDuration: "10s"}
+		r.Time.Time = start.Add(time.Duration(i) * time.Minute * 20)
+		results = append(results, r)
+	}
+
+	trend := Trend(results, start, start.Add(time.Hour*3), time.Hour)
+	if len(trend) != 3 {
+		t.Fatal("expected 3 hourly periods but got", len(trend))
+	}
+	if trend[0].Runs != 3 || trend[0].Failed != 1 || trend[0].Uptime != 2.0/3 || trend[0].AverageRunDuration != "10s" {
+		t.Fatalf("expected the first hour to have 3 runs with 1 failure but got %+v", trend[0])
+	}
+	if trend[1].Runs != 1 || trend[1].Uptime != 1 {
+		t.Fatalf("expected the second hour to have 1 passed run but got %+v", trend[1])
+	}
+	if trend[2].Runs != 0 || trend[2].Uptime != 1 {
+		t.Fatalf("expected an empty third hour but got %+v", trend[2])
+	}
+}
@@ -0,0 +1,391 @@
+package webauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultOIDCUsernameClaim is the claim of an ID token used as the user name
+const DefaultOIDCUsernameClaim = "sub"
+
+// DefaultOIDCGroupsClaim is the claim of an ID token used as the groups of the user
+const DefaultOIDCGroupsClaim = "groups"
+
+// oidcKeyRefreshInterval limits how often the signing keys of the issuer are fetched for tokens signed with an
+// unknown key
+const oidcKeyRefreshInterval = time.Minute
+
+// OIDCConfig configures the validation of OIDC ID tokens.  Tokens must be issued by the issuer for the client ID
+// and signed with one of the keys the issuer publishes.
+type OIDCConfig struct {
+	IssuerURL     string // the URL of the issuer, such as https://accounts.google.com
+	ClientID      string // the audience ID tokens must be issued for
+	UsernameClaim string // the claim used as the user name. defaults to sub
+	GroupsClaim   string // the claim used as the groups of the user. defaults to groups
+}
+
+// oidcAuthenticator validates bearer tokens as OIDC ID tokens
+type oidcAuthenticator struct {
+	config OIDCConfig
+	client *http.Client
+	now    func() time.Time
+
+	mu      sync.Mutex
+	jwksURL string                      // the URL the issuer publishes its keys at, from its discovery document
+	keys    map[string]crypto.PublicKey // the signing keys of the issuer by key ID
+	fetched time.Time                   // when the keys were last fetched
+}
+
+// oidcSignatureAlgorithms are the supported signature algorithms of ID tokens and the hashes they sign
+var oidcSignatureAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// newOIDCAuthenticator creates an OIDC authenticator.  The keys of the issuer are fetched when the first token is
+// validated, so that an unreachable issuer does not prevent the web server from starting.
+func newOIDCAuthenticator(config OIDCConfig, client *http.Client, now func() time.Time) (*oidcAuthenticator, error) {
+	if len(config.IssuerURL) == 0 || len(config.ClientID) == 0 {
+		return nil, errors.New("the OIDC issuer URL and client ID must be set together")
+	}
+	u, err := url.Parse(config.IssuerURL)
+	if err != nil || len(u.Host) == 0 {
+		return nil, fmt.Errorf("invalid OIDC issuer URL %q", config.IssuerURL)
+	}
+	if len(config.UsernameClaim) == 0 {
+		config.UsernameClaim = DefaultOIDCUsernameClaim
+	}
+	if len(config.GroupsClaim) == 0 {
+		config.GroupsClaim = DefaultOIDCGroupsClaim
+	}
+	return &oidcAuthenticator{config: config, client: client, now: now}, nil
+}
+
+// authenticateToken validates an ID token and returns the user of its claims
+func (o *oidcAuthenticator) authenticateToken(ctx context.Context, token string) (User, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return User{}, errors.New("token is not a JWT")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	err := decodeJWTPart(parts[0], &header)
+	if err != nil {
+		return User{}, fmt.Errorf("invalid token header: %w", err)
+	}
+	var claims map[string]interface{}
+	err = decodeJWTPart(parts[1], &claims)
+	if err != nil {
+		return User{}, fmt.Errorf("invalid token claims: %w", err)
+	}
+
+	// tokens of other issuers, such as service account tokens, are rejected before the keys are fetched
+	if iss, _ := claims["iss"].(string); iss != o.config.IssuerURL {
+		return User{}, fmt.Errorf("token was issued by %q instead of %q", iss, o.config.IssuerURL)
+	}
+	hash, ok := oidcSignatureAlgorithms[header.Alg]
+	if !ok {
+		return User{}, fmt.Errorf("unsupported token signature algorithm %q", header.Alg)
+	}
+	key, err := o.key(ctx, header.Kid)
+	if err != nil {
+		return User{}, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return User{}, fmt.Errorf("invalid token signature: %w", err)
+	}
+	err = verifySignature(header.Alg, hash, key, []byte(parts[0]+"."+parts[1]), signature)
+	if err != nil {
+		return User{}, err
+	}
+
+	err = o.validateClaims(claims)
+	if err != nil {
+		return User{}, err
+	}
+	name, _ := claims[o.config.UsernameClaim].(string)
+	if len(name) == 0 {
+		return User{}, fmt.Errorf("token has no %s claim", o.config.UsernameClaim)
+	}
+	return User{Name: name, Groups: stringsClaim(claims[o.config.GroupsClaim])}, nil
+}
+
+// validateClaims ensures the token was issued for the client ID and has not expired
+func (o *oidcAuthenticator) validateClaims(claims map[string]interface{}) error {
+	if !containsString(stringsClaim(claims["aud"]), o.config.ClientID) {
+		return fmt.Errorf("token was not issued for client %s", o.config.ClientID)
+	}
+	now := o.now()
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return errors.New("token has no expiry")
+	}
+	if !now.Before(time.Unix(int64(exp), 0)) {
+		return errors.New("token has expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Before(time.Unix(int64(nbf), 0)) {
+		return errors.New("token is not valid yet")
+	}
+	return nil
+}
+
+// key returns the signing key with the supplied ID.  The keys are fetched again when the key is unknown, at most
+// once per oidcKeyRefreshInterval.
+func (o *oidcAuthenticator) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	key, ok := o.findKey(kid)
+	if ok {
+		return key, nil
+	}
+	if !o.fetched.IsZero() && o.now().Sub(o.fetched) < oidcKeyRefreshInterval {
+		return nil, fmt.Errorf("token is signed with unknown key %q", kid)
+	}
+	err := o.fetchKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	key, ok = o.findKey(kid)
+	if !ok {
+		return nil, fmt.Errorf("token is signed with unknown key %q", kid)
+	}
+	return key, nil
+}
+
+// findKey returns the signing key with the supplied ID.  Tokens without a key ID are accepted when the issuer
+// publishes a single key.
+func (o *oidcAuthenticator) findKey(kid string) (crypto.PublicKey, bool) {
+	if len(kid) == 0 && len(o.keys) == 1 {
+		for _, key := range o.keys {
+			return key, true
+		}
+	}
+	key, ok := o.keys[kid]
+	return key, ok
+}
+
+// fetchKeys fetches the signing keys of the issuer, discovering where they are published first
+func (o *oidcAuthenticator) fetchKeys(ctx context.Context) error {
+	o.fetched = o.now()
+	if len(o.jwksURL) == 0 {
+		var discovery struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		err := o.getJSON(ctx, strings.TrimSuffix(o.config.IssuerURL, "/")+"/.well-known/openid-configuration", &discovery)
+		if err != nil {
+			return fmt.Errorf("failed to discover the OIDC issuer: %w", err)
+		}
+		if discovery.Issuer != o.config.IssuerURL {
+			return fmt.Errorf("OIDC discovery returned issuer %q instead of %q", discovery.Issuer, o.config.IssuerURL)
+		}
+		if len(discovery.JWKSURI) == 0 {
+			return errors.New("OIDC discovery did not return a jwks_uri")
+		}
+		o.jwksURL = discovery.JWKSURI
+	}
+
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	err := o.getJSON(ctx, o.jwksURL, &jwks)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the OIDC signing keys: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, k := range jwks.Keys {
+		if len(k.Use) > 0 && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	o.keys = keys
+	return nil
+}
+
+// getJSON decodes the JSON document at the supplied URL
+func (o *oidcAuthenticator) getJSON(ctx context.Context, u string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %s", u, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// jsonWebKey is an RSA or elliptic curve public key of a JSON web key set
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey returns the public key of the JSON web key
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("RSA exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("EC key is not on its curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+// verifySignature verifies the signature of a token with a key of the type alg calls for
+func verifySignature(alg string, hash crypto.Hash, key crypto.PublicKey, signed []byte, signature []byte) error {
+	var digest []byte
+	switch hash {
+	case crypto.SHA256:
+		sum := sha256.Sum256(signed)
+		digest = sum[:]
+	case crypto.SHA384:
+		sum := sha512.Sum384(signed)
+		digest = sum[:]
+	default:
+		sum := sha512.Sum512(signed)
+		digest = sum[:]
+	}
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(alg, "RS") {
+			break
+		}
+		err := rsa.VerifyPKCS1v15(k, hash, digest, signature)
+		if err != nil {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(alg, "ES") {
+			break
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(signature) != size*2 {
+			return errors.New("invalid token signature")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("invalid token signature")
+		}
+		return nil
+	}
+	return fmt.Errorf("token signature algorithm %s does not match its key", alg)
+}
+
+// decodeJWTPart decodes a base64url encoded JSON part of a JWT
+func decodeJWTPart(part string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// decodeBigInt decodes a base64url encoded big-endian integer of a JSON web key
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// stringsClaim returns a claim that is a string or a list of strings as a list
+func stringsClaim(v interface{}) []string {
+	switch c := v.(type) {
+	case string:
+		return []string{c}
+	case []interface{}:
+		var s []string
+		for _, item := range c {
+			if str, ok := item.(string); ok {
+				s = append(s, str)
+			}
+		}
+		return s
+	}
+	return nil
+}
+
+// containsString determines if s is in list
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
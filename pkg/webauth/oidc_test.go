@@ -0,0 +1,153 @@
+package webauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testIssuer is an OIDC issuer that publishes an RSA and an EC signing key
+type testIssuer struct {
+	server     *httptest.Server
+	rsaKey     *rsa.PrivateKey
+	ecKey      *ecdsa.PrivateKey
+	keyFetches int
+}
+
+// newTestIssuer starts an OIDC issuer
+func newTestIssuer(t *testing.T) *testIssuer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{"issuer": issuer.server.URL, "jwks_uri": issuer.server.URL + "/keys"})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		issuer.keyFetches++
+		json.NewEncoder(w).Encode(map[string][]jsonWebKey{"keys": {
+			{Kty: "RSA", Kid: "rsa", Use: "sig", N: encode(rsaKey.N.Bytes()), E: encode(big.NewInt(int64(rsaKey.E)).Bytes())},
+			{Kty: "EC", Kid: "ec", Crv: "P-256", X: encode(ecKey.X.FillBytes(make([]byte, 32))), Y: encode(ecKey.Y.FillBytes(make([]byte, 32)))},
+		}})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+// token returns an ID token with the claims signed with the key of the supplied ID
+func (i *testIssuer) token(t *testing.T, kid string, claims map[string]interface{}) string {
+	alg := "RS256"
+	if kid == "ec" {
+		alg = "ES256"
+	}
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	if kid == "ec" {
+		r, s, err := ecdsa.Sign(rand.Reader, i.ecKey, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	} else {
+		signature, err = rsa.SignPKCS1v15(rand.Reader, i.rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestOIDCAuthenticateToken(t *testing.T) {
+	issuer := newTestIssuer(t)
+	now := time.Now()
+	o, err := newOIDCAuthenticator(OIDCConfig{IssuerURL: issuer.server.URL, ClientID: "kuberhealthy", UsernameClaim: "email"}, issuer.server.Client(), func() time.Time { return now })
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := func(changes map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss":    issuer.server.URL,
+			"aud":    []string{"kuberhealthy", "other"},
+			"exp":    now.Add(time.Hour).Unix(),
+			"sub":    "1234",
+			"email":  "reader@example.com",
+			"groups": []string{"sre"},
+		}
+		for k, v := range changes {
+			c[k] = v
+		}
+		return c
+	}
+
+	for _, kid := range []string{"rsa", "ec"} {
+		user, err := o.authenticateToken(context.Background(), issuer.token(t, kid, claims(nil)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Name != "reader@example.com" || len(user.Groups) != 1 || user.Groups[0] != "sre" {
+			t.Fatalf("unexpected user from a token signed with the %s key: %+v", kid, user)
+		}
+	}
+	if issuer.keyFetches != 1 {
+		t.Fatal("expected the keys to be fetched once but they were fetched", issuer.keyFetches, "times")
+	}
+
+	for name, token := range map[string]string{
+		"another issuer":    issuer.token(t, "rsa", claims(map[string]interface{}{"iss": "https://other.example.com"})),
+		"another audience":  issuer.token(t, "rsa", claims(map[string]interface{}{"aud": "other"})),
+		"an expired token":  issuer.token(t, "rsa", claims(map[string]interface{}{"exp": now.Add(-time.Minute).Unix()})),
+		"a future token":    issuer.token(t, "rsa", claims(map[string]interface{}{"nbf": now.Add(time.Hour).Unix()})),
+		"no user name":      issuer.token(t, "rsa", claims(map[string]interface{}{"email": ""})),
+		"an unknown key":    issuer.token(t, "unknown", claims(nil)),
+		"a bad signature":   issuer.token(t, "rsa", claims(nil))[:20] + issuer.token(t, "ec", claims(nil))[20:],
+		"a malformed token": "not-a-jwt",
+	} {
+		_, err := o.authenticateToken(context.Background(), token)
+		if err == nil {
+			t.Fatalf("expected an error for %s", name)
+		}
+		t.Log(name+":", err)
+	}
+
+	// unknown keys are fetched again once the refresh interval passed
+	now = now.Add(oidcKeyRefreshInterval)
+	_, err = o.authenticateToken(context.Background(), issuer.token(t, "unknown", claims(nil)))
+	if err == nil || issuer.keyFetches != 2 {
+		t.Fatal("expected the keys to be fetched again for an unknown key:", issuer.keyFetches, err)
+	}
+
+	_, err = newOIDCAuthenticator(OIDCConfig{IssuerURL: issuer.server.URL}, issuer.server.Client(), time.Now)
+	if err == nil {
+		t.Fatal("expected an error for an OIDC issuer without a client ID")
+	}
+}
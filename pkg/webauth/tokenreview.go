@@ -0,0 +1,35 @@
+package webauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// tokenReviewAuthenticator validates bearer tokens by creating a TokenReview with the Kubernetes API
+type tokenReviewAuthenticator struct {
+	client    kubernetes.Interface
+	audiences []string
+}
+
+// authenticateToken returns the user the Kubernetes API authenticates the token as
+func (t *tokenReviewAuthenticator) authenticateToken(ctx context.Context, token string) (User, error) {
+	review := &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token, Audiences: t.audiences},
+	}
+	result, err := t.client.AuthenticationV1().TokenReviews().Create(ctx, review, metav1.CreateOptions{})
+	if err != nil {
+		return User{}, fmt.Errorf("failed to create TokenReview: %w", err)
+	}
+	if !result.Status.Authenticated {
+		if len(result.Status.Error) > 0 {
+			return User{}, errors.New("TokenReview did not authenticate the token: " + result.Status.Error)
+		}
+		return User{}, errors.New("TokenReview did not authenticate the token")
+	}
+	return User{Name: result.Status.User.Username, Groups: result.Status.User.Groups}, nil
+}
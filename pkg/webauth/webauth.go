@@ -0,0 +1,268 @@
+// Package webauth authenticates requests to the Kuberhealthy web server with basic auth, with bearer tokens
+// validated by a Kubernetes TokenReview, or with OIDC ID tokens
+package webauth // import "github.com/kuberhealthy/kuberhealthy/v2/pkg/webauth"
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultCacheTTL is how long a successful authentication is remembered before the credentials are checked again
+const DefaultCacheTTL = time.Minute
+
+// ErrUnauthenticated is returned when a request has no valid credentials
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// ErrForbidden is returned when the user of a request is not allowed to read Kuberhealthy
+var ErrForbidden = errors.New("forbidden")
+
+// User is an authenticated user
+type User struct {
+	Name   string
+	Groups []string
+}
+
+// Config configures how requests are authenticated.  Each configured method is tried in turn and the first that
+// accepts the credentials of a request authenticates it.
+type Config struct {
+	BasicUsers    map[string]string    // bcrypt password hashes of basic auth users by user name. empty disables basic auth
+	TokenReviews  kubernetes.Interface // validates bearer tokens with TokenReviews. nil disables TokenReviews
+	Audiences     []string             // the audiences bearer tokens must be issued for. empty uses the audience of the API server
+	OIDC          *OIDCConfig          // validates bearer tokens as OIDC ID tokens. nil disables OIDC
+	AllowedUsers  []string             // the bearer token users allowed to read Kuberhealthy. all authenticated users are allowed when both allow lists are empty
+	AllowedGroups []string             // the bearer token groups allowed to read Kuberhealthy
+	CacheTTL      time.Duration        // how long successful authentications are remembered
+	HTTPClient    *http.Client         // the client OIDC discovery and keys are fetched with
+}
+
+// userContextKey is the key the authenticated user of a request is stored under in its context
+type userContextKey struct{}
+
+// UserFromContext returns the authenticated user of a request served by Handler.  false is returned when the
+// request was not authenticated.
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userContextKey{}).(User)
+	return user, ok
+}
+
+// ContextWithUser returns a copy of ctx with the authenticated user of a request, for requests that were
+// authenticated without Handler
+func ContextWithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, userContextKey{}, user)
+}
+
+// bearerAuthenticator validates a bearer token and returns its user
+type bearerAuthenticator interface {
+	authenticateToken(ctx context.Context, token string) (User, error)
+}
+
+// Authenticator authenticates requests to the web server
+type Authenticator struct {
+	config        Config
+	bearerMethods []bearerAuthenticator // the methods bearer tokens are validated with, in order
+	now           func() time.Time
+	mu            sync.Mutex
+	cache         map[string]cachedUser // successful authentications by the hash of their credentials
+}
+
+// cachedUser is a successful authentication that is remembered until it expires
+type cachedUser struct {
+	user    User
+	expires time.Time
+}
+
+// New creates an authenticator with the supplied configuration.  An error is returned when no authentication
+// method is configured.
+func New(config Config) (*Authenticator, error) {
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = DefaultCacheTTL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: time.Second * 10}
+	}
+	a := &Authenticator{config: config, now: time.Now, cache: make(map[string]cachedUser)}
+	if config.OIDC != nil {
+		o, err := newOIDCAuthenticator(*config.OIDC, config.HTTPClient, func() time.Time { return a.now() })
+		if err != nil {
+			return nil, err
+		}
+		a.bearerMethods = append(a.bearerMethods, o)
+	}
+	if config.TokenReviews != nil {
+		a.bearerMethods = append(a.bearerMethods, &tokenReviewAuthenticator{client: config.TokenReviews, audiences: config.Audiences})
+	}
+	if len(config.BasicUsers) == 0 && len(a.bearerMethods) == 0 {
+		return nil, errors.New("no authentication method is configured")
+	}
+	return a, nil
+}
+
+// ParseHtpasswd parses an htpasswd file of user:hash lines with bcrypt password hashes, such as those created by
+// htpasswd -B.  Blank lines and lines starting with # are ignored.
+func ParseHtpasswd(b []byte) (map[string]string, error) {
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) == 0 || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, hash, found := strings.Cut(text, ":")
+		if !found || len(name) == 0 {
+			return nil, fmt.Errorf("line %d is not a user:hash pair", line)
+		}
+		_, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return nil, fmt.Errorf("the password of user %s on line %d is not a bcrypt hash: %w", name, line, err)
+		}
+		users[name] = hash
+	}
+	return users, scanner.Err()
+}
+
+// Authenticate returns the user of a request.  ErrUnauthenticated is returned when the request has no valid
+// credentials and ErrForbidden is returned when its user is not allowed to read Kuberhealthy.
+func (a *Authenticator) Authenticate(r *http.Request) (User, error) {
+	name, password, basic := r.BasicAuth()
+	token, bearer := BearerToken(r)
+	switch {
+	case basic && len(a.config.BasicUsers) > 0:
+		return a.cached("basic:"+name+":"+password, func() (User, error) {
+			return a.authenticateBasic(name, password)
+		})
+	case bearer && len(a.bearerMethods) > 0:
+		return a.cached("bearer:"+token, func() (User, error) {
+			return a.authenticateBearer(r.Context(), token)
+		})
+	}
+	return User{}, ErrUnauthenticated
+}
+
+// authenticateBasic validates the password of a basic auth user
+func (a *Authenticator) authenticateBasic(name string, password string) (User, error) {
+	hash, ok := a.config.BasicUsers[name]
+	if !ok {
+		return User{}, ErrUnauthenticated
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err != nil {
+		return User{}, ErrUnauthenticated
+	}
+	return User{Name: name}, nil
+}
+
+// authenticateBearer validates a bearer token with each configured method and ensures its user is allowed
+func (a *Authenticator) authenticateBearer(ctx context.Context, token string) (User, error) {
+	for _, m := range a.bearerMethods {
+		user, err := m.authenticateToken(ctx, token)
+		if err != nil {
+			log.Debugln("webauth: bearer token was not accepted:", err)
+			continue
+		}
+		if !a.allowed(user) {
+			return User{}, fmt.Errorf("%w: user %s is not allowed", ErrForbidden, user.Name)
+		}
+		return user, nil
+	}
+	return User{}, ErrUnauthenticated
+}
+
+// allowed determines if a bearer token user is allowed by the allow lists
+func (a *Authenticator) allowed(user User) bool {
+	if len(a.config.AllowedUsers) == 0 && len(a.config.AllowedGroups) == 0 {
+		return true
+	}
+	for _, u := range a.config.AllowedUsers {
+		if u == user.Name {
+			return true
+		}
+	}
+	for _, g := range a.config.AllowedGroups {
+		for _, userGroup := range user.Groups {
+			if g == userGroup {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cached returns the remembered user of the credentials, or authenticates them and remembers the user when they
+// are valid.  Only the hash of the credentials is kept.
+func (a *Authenticator) cached(credentials string, authenticate func() (User, error)) (User, error) {
+	sum := sha256.Sum256([]byte(credentials))
+	key := hex.EncodeToString(sum[:])
+	now := a.now()
+
+	a.mu.Lock()
+	c, ok := a.cache[key]
+	a.mu.Unlock()
+	if ok && now.Before(c.expires) {
+		return c.user, nil
+	}
+
+	user, err := authenticate()
+	if err != nil {
+		return User{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for k, c := range a.cache {
+		if !now.Before(c.expires) {
+			delete(a.cache, k)
+		}
+	}
+	a.cache[key] = cachedUser{user: user, expires: now.Add(a.config.CacheTTL)}
+	return user, nil
+}
+
+// Handler wraps a handler so that it only serves authenticated requests.  Other requests are answered with a 401,
+// or a 403 when their user is not allowed.  The user of a request is available to the wrapped handler with
+// UserFromContext.
+func (a *Authenticator) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, err := a.Authenticate(r)
+		switch {
+		case errors.Is(err, ErrForbidden):
+			log.Infoln("webauth: forbidden request to", r.URL.Path, "from", r.RemoteAddr+":", err)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		case err != nil:
+			log.Debugln("webauth: unauthenticated request to", r.URL.Path, "from", r.RemoteAddr)
+			if len(a.config.BasicUsers) > 0 {
+				w.Header().Add("WWW-Authenticate", `Basic realm="kuberhealthy", charset="UTF-8"`)
+			}
+			if len(a.bearerMethods) > 0 {
+				w.Header().Add("WWW-Authenticate", `Bearer realm="kuberhealthy"`)
+			}
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		log.Debugln("webauth: request to", r.URL.Path, "authenticated as", user.Name)
+		next.ServeHTTP(w, r.WithContext(ContextWithUser(r.Context(), user)))
+	})
+}
+
+// BearerToken returns the bearer token of a request
+func BearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, len(token) > 0
+}
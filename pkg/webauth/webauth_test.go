@@ -0,0 +1,181 @@
+package webauth
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// testHtpasswd returns an htpasswd file with the user and password
+func testHtpasswd(t *testing.T, name string, password string) []byte {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []byte("# kuberhealthy readers\n\n" + name + ":" + string(hash) + "\n")
+}
+
+// newTestTokenReviews returns a client that authenticates the token "valid" as the user "reader" in the group
+// "sre", and counts the TokenReviews created
+func newTestTokenReviews(reviews *int) *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		*reviews++
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token == "valid" {
+			review.Status = authenticationv1.TokenReviewStatus{
+				Authenticated: true,
+				User:          authenticationv1.UserInfo{Username: "reader", Groups: []string{"sre"}},
+			}
+		} else {
+			review.Status = authenticationv1.TokenReviewStatus{Error: "invalid bearer token"}
+		}
+		return true, review, nil
+	})
+	return client
+}
+
+func TestParseHtpasswd(t *testing.T) {
+	users, err := ParseHtpasswd(testHtpasswd(t, "reader", "secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || len(users["reader"]) == 0 {
+		t.Fatalf("unexpected users: %v", users)
+	}
+
+	for _, file := range []string{"reader\n", ":hash\n", "reader:plaintext\n"} {
+		_, err := ParseHtpasswd([]byte(file))
+		if err == nil {
+			t.Fatalf("expected an error for the htpasswd file %q", file)
+		}
+		t.Log(err)
+	}
+}
+
+func TestAuthenticateBasic(t *testing.T) {
+	users, err := ParseHtpasswd(testHtpasswd(t, "reader", "secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := New(Config{BasicUsers: users})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetBasicAuth("reader", "secret")
+	user, err := a.Authenticate(r)
+	if err != nil || user.Name != "reader" {
+		t.Fatal("expected the basic auth user to be authenticated:", user, err)
+	}
+
+	for _, credentials := range [][2]string{{"reader", "wrong"}, {"other", "secret"}} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.SetBasicAuth(credentials[0], credentials[1])
+		_, err := a.Authenticate(r)
+		if !errors.Is(err, ErrUnauthenticated) {
+			t.Fatalf("expected the credentials %v to be rejected but got %v", credentials, err)
+		}
+	}
+}
+
+// TestAuthenticateTokenReview ensures bearer tokens are validated with TokenReviews, that successful reviews are
+// remembered until the cache TTL passes, and that the allow lists are applied
+func TestAuthenticateTokenReview(t *testing.T) {
+	var reviews int
+	a, err := New(Config{TokenReviews: newTestTokenReviews(&reviews), AllowedGroups: []string{"sre"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	a.now = func() time.Time { return now }
+
+	request := func(token string) *http.Request {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		return r
+	}
+	for i := 0; i < 2; i++ {
+		user, err := a.Authenticate(request("valid"))
+		if err != nil || user.Name != "reader" {
+			t.Fatal("expected the token to be authenticated:", user, err)
+		}
+	}
+	if reviews != 1 {
+		t.Fatal("expected the TokenReview to be remembered but", reviews, "were created")
+	}
+	now = now.Add(DefaultCacheTTL)
+	_, err = a.Authenticate(request("valid"))
+	if err != nil || reviews != 2 {
+		t.Fatal("expected the token to be reviewed again once the cache TTL passed:", reviews, err)
+	}
+
+	_, err = a.Authenticate(request("invalid"))
+	if !errors.Is(err, ErrUnauthenticated) {
+		t.Fatal("expected an invalid token to be rejected but got", err)
+	}
+
+	a, err = New(Config{TokenReviews: newTestTokenReviews(&reviews), AllowedUsers: []string{"admin"}, AllowedGroups: []string{"admins"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = a.Authenticate(request("valid"))
+	if !errors.Is(err, ErrForbidden) {
+		t.Fatal("expected a user that is not allowed to be forbidden but got", err)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	var reviews int
+	users, err := ParseHtpasswd(testHtpasswd(t, "reader", "secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := New(Config{BasicUsers: users, TokenReviews: newTestTokenReviews(&reviews), AllowedUsers: []string{"admin"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := a.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := UserFromContext(r.Context())
+		if !ok || user.Name != "reader" {
+			t.Fatal("expected the authenticated user to be in the request context but got", user, ok)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for _, c := range []struct {
+		name     string
+		setAuth  func(r *http.Request)
+		expected int
+	}{
+		{"no credentials", func(r *http.Request) {}, http.StatusUnauthorized},
+		{"basic auth", func(r *http.Request) { r.SetBasicAuth("reader", "secret") }, http.StatusOK},
+		{"invalid token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer invalid") }, http.StatusUnauthorized},
+		{"user that is not allowed", func(r *http.Request) { r.Header.Set("Authorization", "Bearer valid") }, http.StatusForbidden},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		c.setAuth(r)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != c.expected {
+			t.Fatalf("expected a %d for a request with %s but got %d", c.expected, c.name, w.Code)
+		}
+		if w.Code == http.StatusUnauthorized && len(w.Header().Values("WWW-Authenticate")) != 2 {
+			t.Fatalf("expected a basic and a bearer challenge but got %v", w.Header().Values("WWW-Authenticate"))
+		}
+	}
+
+	_, err = New(Config{})
+	if err == nil {
+		t.Fatal("expected an error when no authentication method is configured")
+	}
+}
@@ -0,0 +1,204 @@
+// Package webhook posts JSON payloads to HTTP endpoints in the background
+package webhook // import "github.com/kuberhealthy/kuberhealthy/v2/pkg/webhook"
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SignatureHeader is the header that holds the HMAC-SHA256 signature of the payload when a secret is configured, in
+// the form sha256=<hex>
+const SignatureHeader = "X-Kuberhealthy-Signature-256"
+
+// DefaultQueueSize is the number of payloads that can wait for delivery before new payloads are dropped
+const DefaultQueueSize = 100
+
+// DefaultMaxRetries is the number of times delivery of a payload is retried before it is dropped
+const DefaultMaxRetries = 3
+
+// DefaultRetryDelay is the delay before the first retry of a payload.  The delay doubles with each retry.
+const DefaultRetryDelay = time.Second
+
+// DefaultTimeout is the timeout of each delivery attempt
+const DefaultTimeout = time.Second * 10
+
+// SinkConfig configures the delivery of payloads to a webhook
+type SinkConfig struct {
+	URL        string                                // the URL payloads are posted to
+	Headers    map[string]string                     // extra headers sent with every payload, such as authorization
+	Secret     string                                // signs payloads in the SignatureHeader when set
+	QueueSize  int                                   // the number of payloads that can wait for delivery
+	MaxRetries int                                   // the number of times a failed delivery is retried
+	RetryDelay time.Duration                         // the delay before the first retry, doubled with each retry
+	Timeout    time.Duration                         // the timeout of each delivery attempt
+	Proxy      func(*http.Request) (*url.URL, error) // the proxy deliveries are sent through. nil uses the proxy environment variables
+}
+
+// Sink delivers JSON payloads to a webhook in the background.  Payloads that can not be queued or delivered are
+// dropped and counted.
+type Sink struct {
+	config  SinkConfig
+	name    string
+	client  *http.Client
+	queue   chan []byte
+	dropped uint64
+}
+
+// NewSink creates a sink that delivers payloads to the configured URL.  Unset settings use their defaults.  Payloads
+// are queued until the sink is started.
+func NewSink(config SinkConfig) *Sink {
+	if config.QueueSize <= 0 {
+		config.QueueSize = DefaultQueueSize
+	}
+	if config.MaxRetries <= 0 {
+		config.MaxRetries = DefaultMaxRetries
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = DefaultRetryDelay
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultTimeout
+	}
+	return &Sink{
+		config: config,
+		name:   redactURL(config.URL),
+		client: &http.Client{Timeout: config.Timeout, Transport: proxyTransport(config.Proxy)},
+		queue:  make(chan []byte, config.QueueSize),
+	}
+}
+
+// Send marshals a payload to JSON and queues it for delivery without blocking.  The payload is dropped if it can not
+// be marshaled or the queue is full.
+func (s *Sink) Send(payload interface{}) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		atomic.AddUint64(&s.dropped, 1)
+		log.Errorln("webhook: dropping payload for", s.name, "because it can not be marshaled:", err)
+		return
+	}
+	select {
+	case s.queue <- b:
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+		log.Warningln("webhook: dropping payload for", s.name, "because the delivery queue is full")
+	}
+}
+
+// Name returns the URL of the webhook without credentials or a query, which often carry tokens, so that it can be
+// logged
+func (s *Sink) Name() string {
+	return s.name
+}
+
+// Dropped returns the number of payloads that were dropped since the sink was created
+func (s *Sink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Start delivers queued payloads until the context is canceled
+func (s *Sink) Start(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case b := <-s.queue:
+			err := s.deliverWithRetry(ctx, b)
+			if err != nil {
+				atomic.AddUint64(&s.dropped, 1)
+				log.Errorln("webhook: dropping payload for", s.name+":", err)
+			}
+		}
+	}
+}
+
+// deliverWithRetry delivers a payload and retries failed deliveries with an exponential backoff
+func (s *Sink) deliverWithRetry(ctx context.Context, b []byte) error {
+	delay := s.config.RetryDelay
+	var err error
+	for try := 0; try <= s.config.MaxRetries; try++ {
+		if try > 0 {
+			log.Debugln("webhook: retrying delivery to", s.name, "in", delay.String()+":", err)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+			delay = delay + delay
+		}
+		err = s.deliver(ctx, b)
+		if err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("failed to deliver payload after %d retries: %w", s.config.MaxRetries, err)
+}
+
+// deliver posts a payload to the webhook
+func (s *Sink) deliver(ctx context.Context, b []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(s.config.Secret) > 0 {
+		req.Header.Set(SignatureHeader, Sign(s.config.Secret, b))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature of a payload sent in the SignatureHeader.  Receivers verify payloads by comparing the
+// header to the signature of the request body with the shared secret.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// redactURL removes the credentials, query and fragment from a URL
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "invalid-url"
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
+
+// proxyTransport returns the transport of the HTTP client, which sends requests through the supplied proxy.  The
+// default transport, which uses the proxy environment variables, is returned when the proxy is nil.
+func proxyTransport(proxy func(*http.Request) (*url.URL, error)) http.RoundTripper {
+	if proxy == nil {
+		return http.DefaultTransport
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxy
+	return transport
+}
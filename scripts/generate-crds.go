@@ -46,9 +46,9 @@ var (
 	crdGenerators = []crdGenerator{
 		{
 			ControllerGenOpts: "crd:crdVersions=v1,preserveUnknownFields=false",
-			YAMLDir:           "./generated",
+			YAMLDir:           "../deploy/helm/kuberhealthy/crds",
 			CRDAPIGroup:       "comcast.github.io",
-			ControllerPath:    "../pkg/apis/khcheck/v1",
+			ControllerPath:    "../pkg/apis/khcheck",
 			CRDNames: []crdName{
 				{"khcheck", "khchecks"},
 			},
@@ -58,7 +58,7 @@ var (
 		},
 		{
 			ControllerGenOpts: "crd:crdVersions=v1,preserveUnknownFields=false",
-			YAMLDir:           "./generated",
+			YAMLDir:           "../deploy/helm/kuberhealthy/crds",
 			CRDAPIGroup:       "comcast.github.io",
 			ControllerPath:    "../pkg/apis/khjob/v1",
 			CRDNames: []crdName{
@@ -70,9 +70,9 @@ var (
 		},
 		{
 			ControllerGenOpts: "crd:crdVersions=v1",
-			YAMLDir:           "./generated",
+			YAMLDir:           "../deploy/helm/kuberhealthy/crds",
 			CRDAPIGroup:       "comcast.github.io",
-			ControllerPath:    "../pkg/apis/khstate/v1",
+			ControllerPath:    "../pkg/apis/khstate",
 			CRDNames: []crdName{
 				{"khstate", "khstates"},
 			},
@@ -91,9 +91,10 @@ func (generator crdGenerator) generateYAMLManifests() error {
 
 	// #nosec
 	log.Println("running binary:", controllergen)
+	// the packages of every API version below ControllerPath are generated into one CRD that serves each of them
 	cmd := exec.Command(controllergen,
 		generator.ControllerGenOpts,
-		"paths=.",
+		"paths=./...",
 		"output:crd:dir="+outputDir,
 	)
 
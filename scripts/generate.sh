@@ -4,9 +4,13 @@ go get sigs.k8s.io/controller-tools/cmd/controller-gen@v0.6.2
 go get github.com/brancz/gojsontoyaml
 
 GOPATH=$(go env GOPATH)
+CRDS=../deploy/helm/kuberhealthy/crds
 
 go run -v ./generate-crds.go --controller-gen=${GOPATH}/bin/controller-gen --gojsontoyaml=${GOPATH}/bin/gojsontoyaml
 
 ## Edit the khcheck and khjob crds files to have `preserveUnknownFields: false`
-awk '{print} sub(/scope: Namespaced/,"preserveUnknownFields: false")' ./generated/comcast.github.io_khchecks.yaml > tmpfile.yaml  && mv tmpfile.yaml ./generated/comcast.github.io_khchecks.yaml
-awk '{print} sub(/scope: Namespaced/,"preserveUnknownFields: false")' ./generated/comcast.github.io_khjobs.yaml > tmpfile.yaml  && mv tmpfile.yaml ./generated/comcast.github.io_khjobs.yaml
+awk '{print} sub(/scope: Namespaced/,"preserveUnknownFields: false")' ${CRDS}/comcast.github.io_khchecks.yaml > tmpfile.yaml  && mv tmpfile.yaml ${CRDS}/comcast.github.io_khchecks.yaml
+awk '{print} sub(/scope: Namespaced/,"preserveUnknownFields: false")' ${CRDS}/comcast.github.io_khjobs.yaml > tmpfile.yaml  && mv tmpfile.yaml ${CRDS}/comcast.github.io_khjobs.yaml
+
+## Render the flat deploy files from the Helm chart, which includes the CRDs
+cd ../deploy/helm && ./create-flat-files.sh && rm -f *.old